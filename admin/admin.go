@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+)
+
+// Option sets an optional parameter for the admin handler.
+type Option func(*options)
+
+type check struct {
+	name string
+	f    func(ctx context.Context) error
+}
+
+type options struct {
+	metrics  http.Handler
+	checks   []check
+	logLevel http.Handler
+	version  string
+	user     []byte
+	password []byte
+	handlers map[string]http.Handler
+	noPprof  bool
+	noExpvar bool
+}
+
+// Metrics mounts the metrics handler, e.g. promhttp.Handler(), at /metrics.
+func Metrics(h http.Handler) Option {
+	return func(o *options) { o.metrics = h }
+}
+
+// Check adds a health check, run on every request to /health with the
+// request's context. The instance is healthy if all checks return nil.
+func Check(name string, f func(ctx context.Context) error) Option {
+	return func(o *options) { o.checks = append(o.checks, check{name: name, f: f}) }
+}
+
+// LogLevel mounts the log level control, typically a *level.Dynamic, at
+// /loglevel.
+func LogLevel(h http.Handler) Option {
+	return func(o *options) { o.logLevel = h }
+}
+
+// Version sets the version reported by /buildinfo, e.g. one set at link
+// time. By default, the version of the main module is reported.
+func Version(v string) Option {
+	return func(o *options) { o.version = v }
+}
+
+// BasicAuth protects all endpoints with HTTP basic authentication. By
+// default, the endpoints aren't protected, and the admin listener shouldn't
+// be reachable from outside.
+func BasicAuth(user, password string) Option {
+	return func(o *options) {
+		o.user, o.password = hash(user), hash(password)
+	}
+}
+
+// Handle mounts an additional handler, e.g. a load balancer's Drainer, at
+// pattern.
+func Handle(pattern string, h http.Handler) Option {
+	return func(o *options) { o.handlers[pattern] = h }
+}
+
+// WithoutPprof disables the /debug/pprof/ endpoints.
+func WithoutPprof() Option {
+	return func(o *options) { o.noPprof = true }
+}
+
+// WithoutExpvar disables the /debug/vars endpoint.
+func WithoutExpvar() Option {
+	return func(o *options) { o.noExpvar = true }
+}
+
+// NewHandler returns a handler serving the admin endpoints.
+func NewHandler(opts ...Option) http.Handler {
+	o := options{handlers: map[string]http.Handler{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mux := http.NewServeMux()
+	if !o.noPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	if !o.noExpvar {
+		mux.Handle("/debug/vars", expvar.Handler())
+	}
+	if o.metrics != nil {
+		mux.Handle("/metrics", o.metrics)
+	}
+	if len(o.checks) > 0 {
+		mux.Handle("/health", healthHandler(o.checks))
+	}
+	mux.Handle("/buildinfo", buildInfoHandler(o.version))
+	if o.logLevel != nil {
+		mux.Handle("/loglevel", o.logLevel)
+	}
+	for pattern, h := range o.handlers {
+		mux.Handle(pattern, h)
+	}
+
+	if o.user == nil {
+		return mux
+	}
+	return basicAuth(o.user, o.password, mux)
+}
+
+// HealthResponse is the body of the /health endpoint. Checks maps the name
+// of each check to "ok", or its error.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func healthHandler(checks []check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			resp = HealthResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+			code = http.StatusOK
+		)
+		for _, c := range checks {
+			if err := c.f(r.Context()); err != nil {
+				resp.Status, code = "fail", http.StatusServiceUnavailable
+				resp.Checks[c.name] = err.Error()
+				continue
+			}
+			resp.Checks[c.name] = "ok"
+		}
+		writeJSON(w, code, resp)
+	})
+}
+
+// BuildInfo is the body of the /buildinfo endpoint.
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"`
+}
+
+func buildInfoHandler(version string) http.Handler {
+	info := BuildInfo{GoVersion: runtime.Version(), Version: version}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Path = bi.Main.Path
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+		info.Settings = make(map[string]string, len(bi.Settings))
+		for _, s := range bi.Settings {
+			info.Settings[s.Key] = s.Value
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, info)
+	})
+}
+
+func basicAuth(user, password []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare(hash(u), user) != 1 ||
+			subtle.ConstantTimeCompare(hash(p), password) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hash(s string) []byte {
+	h := sha256.Sum256([]byte(s))
+	return h[:]
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,117 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/admin"
+)
+
+func TestEndpoints(t *testing.T) {
+	h := admin.NewHandler(
+		admin.Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("metrics")) })),
+		admin.LogLevel(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("level")) })),
+		admin.Handle("/drain", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("drain")) })),
+		admin.Version("v1.2.3"),
+	)
+	for path, want := range map[string]string{
+		"/metrics":      "metrics",
+		"/loglevel":     "level",
+		"/drain":        "drain",
+		"/debug/vars":   `"memstats"`,
+		"/debug/pprof/": "goroutine",
+		"/buildinfo":    `"version":"v1.2.3"`,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if want, have := http.StatusOK, rec.Code; want != have {
+			t.Errorf("%s: want %d, have %d", path, want, have)
+		}
+		if have := rec.Body.String(); !strings.Contains(have, want) {
+			t.Errorf("%s: want %q in %q", path, want, have)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if want, have := http.StatusNotFound, rec.Code; want != have {
+		t.Errorf("/health without checks: want %d, have %d", want, have)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+	h := admin.NewHandler(
+		admin.Check("cache", func(context.Context) error { return nil }),
+		admin.Check("db", func(context.Context) error { return dbErr }),
+	)
+
+	for _, tc := range []struct {
+		err    error
+		code   int
+		status string
+		db     string
+	}{
+		{nil, http.StatusOK, "ok", "ok"},
+		{errors.New("unreachable"), http.StatusServiceUnavailable, "fail", "unreachable"},
+	} {
+		dbErr = tc.err
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+		if want, have := tc.code, rec.Code; want != have {
+			t.Errorf("want %d, have %d", want, have)
+		}
+		var resp admin.HealthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := tc.status, resp.Status; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+		if want, have := tc.db, resp.Checks["db"]; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+		if want, have := "ok", resp.Checks["cache"]; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	h := admin.NewHandler(admin.BasicAuth("admin", "secret"), admin.WithoutPprof())
+
+	for _, tc := range []struct {
+		user, password string
+		code           int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"admin", "wrong", http.StatusUnauthorized},
+		{"admin", "secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/buildinfo", nil)
+		if tc.user != "" {
+			req.SetBasicAuth(tc.user, tc.password)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if want, have := tc.code, rec.Code; want != have {
+			t.Errorf("%s:%s: want %d, have %d", tc.user, tc.password, want, have)
+		}
+		if tc.code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s:%s: missing WWW-Authenticate header", tc.user, tc.password)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if want, have := http.StatusNotFound, rec.Code; want != have {
+		t.Errorf("pprof disabled: want %d, have %d", want, have)
+	}
+}
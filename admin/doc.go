@@ -0,0 +1,21 @@
+// Package admin assembles the operational endpoints of a service onto a
+// single handler, meant to be served on a separate admin listener, so that
+// all services expose the same ops surface:
+//
+//	/debug/pprof/  runtime profiles, see net/http/pprof
+//	/debug/vars    exported variables, see expvar
+//	/metrics       the metrics handler, if any
+//	/health        the result of the health checks, if any
+//	/buildinfo     the build information of the binary
+//	/loglevel      the log level control, if any
+//
+// For example, with the run package:
+//
+//	handler := admin.NewHandler(
+//		admin.Metrics(promhttp.Handler()),
+//		admin.Check("db", db.PingContext),
+//		admin.LogLevel(dynamic),
+//		admin.BasicAuth(user, password),
+//	)
+//	r.Add(run.HTTPServer("admin", &http.Server{Handler: handler}, adminListener))
+package admin
@@ -0,0 +1,51 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// ErrUnauthorized is returned by the middleware when the subject isn't allowed
+// to perform the action on the resource.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authorizer decides whether a subject may perform an action on a resource.
+// A non-nil error means no decision could be made.
+type Authorizer interface {
+	Authorize(ctx context.Context, subject, action, resource string) (bool, error)
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions as
+// Authorizers.
+type AuthorizerFunc func(ctx context.Context, subject, action, resource string) (bool, error)
+
+// Authorize implements Authorizer.
+func (f AuthorizerFunc) Authorize(ctx context.Context, subject, action, resource string) (bool, error) {
+	return f(ctx, subject, action, resource)
+}
+
+// RequestFunc extracts the subject, action and resource of a request, e.g.
+// the subject from a JWT claim in the context and the resource from a field
+// of the request.
+type RequestFunc[REQ any] func(ctx context.Context, request REQ) (subject, action, resource string)
+
+// NewMiddleware returns an endpoint.Middleware that asks the Authorizer about
+// every request before invoking the next endpoint. Denied requests fail with
+// ErrUnauthorized; errors from the Authorizer are returned as-is.
+func NewMiddleware[REQ any, RES any](a Authorizer, extract RequestFunc[REQ]) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			subject, action, resource := extract(ctx, request)
+			allowed, err := a.Authorize(ctx, subject, action, resource)
+			if err != nil {
+				return response, err
+			}
+			if !allowed {
+				return response, ErrUnauthorized
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package authz
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Key identifies an authorization decision. When used to invalidate
+// decisions, empty fields act as wildcards: Key{Subject: "alice"} drops every
+// decision about alice, and the zero Key drops everything.
+type Key struct {
+	Subject  string
+	Action   string
+	Resource string
+}
+
+func (k Key) matches(other Key) bool {
+	return (k.Subject == "" || k.Subject == other.Subject) &&
+		(k.Action == "" || k.Action == other.Action) &&
+		(k.Resource == "" || k.Resource == other.Resource)
+}
+
+// Cache is an Authorizer that remembers the decisions of another Authorizer.
+// Allow and deny decisions are cached for separate durations, so that denials
+// (negative caching) can be re-checked sooner than grants, or not cached at
+// all. Errors are never cached, and neither are decisions made while an
+// invalidation happened, as they may predate it.
+type Cache struct {
+	next       Authorizer
+	allowTTL   time.Duration
+	denyTTL    time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mtx        sync.Mutex
+	entries    map[Key]*list.Element
+	lru        *list.List
+	generation uint64 // incremented by every invalidation
+}
+
+type entry struct {
+	key     Key
+	allowed bool
+	expires time.Time
+}
+
+// CacheOption sets an optional parameter for Caches.
+type CacheOption func(*Cache)
+
+// AllowTTL sets how long decisions that allow access are cached. The default
+// is one minute.
+func AllowTTL(d time.Duration) CacheOption {
+	return func(c *Cache) { c.allowTTL = d }
+}
+
+// DenyTTL sets how long decisions that deny access are cached. The default is
+// ten seconds. A zero duration disables negative caching.
+func DenyTTL(d time.Duration) CacheOption {
+	return func(c *Cache) { c.denyTTL = d }
+}
+
+// MaxEntries sets the number of decisions kept by the cache, the least
+// recently used being evicted first. The default is 10000.
+func MaxEntries(n int) CacheOption {
+	return func(c *Cache) { c.maxEntries = n }
+}
+
+// NewCache returns a Cache in front of the given Authorizer.
+func NewCache(next Authorizer, options ...CacheOption) *Cache {
+	c := &Cache{
+		next:       next,
+		allowTTL:   time.Minute,
+		denyTTL:    10 * time.Second,
+		maxEntries: 10000,
+		now:        time.Now,
+		entries:    map[Key]*list.Element{},
+		lru:        list.New(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Authorize implements Authorizer.
+func (c *Cache) Authorize(ctx context.Context, subject, action, resource string) (bool, error) {
+	key := Key{Subject: subject, Action: action, Resource: resource}
+	now := c.now()
+
+	c.mtx.Lock()
+	if el, ok := c.entries[key]; ok {
+		if e := el.Value.(*entry); now.Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.mtx.Unlock()
+			return e.allowed, nil
+		}
+		c.remove(el)
+	}
+	generation := c.generation
+	c.mtx.Unlock()
+
+	allowed, err := c.next.Authorize(ctx, subject, action, resource)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := c.denyTTL
+	if allowed {
+		ttl = c.allowTTL
+	}
+	if ttl > 0 {
+		c.store(generation, &entry{key: key, allowed: allowed, expires: now.Add(ttl)})
+	}
+	return allowed, nil
+}
+
+// store caches e, unless the cache was invalidated since generation, and
+// evicts the least recently used entries beyond the limit.
+func (c *Cache) store(generation uint64, e *entry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
+
+// Invalidate drops all cached decisions matching the key. Empty fields of the
+// key act as wildcards.
+func (c *Cache) Invalidate(key Key) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.generation++
+	if key == (Key{}) {
+		c.entries = map[Key]*list.Element{}
+		c.lru.Init()
+		return
+	}
+	for k, el := range c.entries {
+		if key.matches(k) {
+			c.remove(el)
+		}
+	}
+}
+
+// Listen invalidates decisions for every key received on the channel, until
+// the channel is closed or ctx is done. It's meant to be run in its own
+// goroutine, fed by e.g. a policy store's change notifications.
+func (c *Cache) Listen(ctx context.Context, invalidations <-chan Key) {
+	for {
+		select {
+		case key, ok := <-invalidations:
+			if !ok {
+				return
+			}
+			c.Invalidate(key)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
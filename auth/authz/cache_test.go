@@ -0,0 +1,141 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var (
+		calls  int
+		policy = map[Key]bool{{"alice", "read", "doc"}: true}
+		next   = AuthorizerFunc(func(_ context.Context, subject, action, resource string) (bool, error) {
+			calls++
+			return policy[Key{subject, action, resource}], nil
+		})
+		now   = time.Now()
+		cache = NewCache(next, AllowTTL(time.Minute), DenyTTL(time.Second))
+		ctx   = context.Background()
+	)
+	cache.now = func() time.Time { return now }
+
+	assert := func(subject string, wantAllowed bool, wantCalls int) {
+		t.Helper()
+		allowed, err := cache.Authorize(ctx, subject, "read", "doc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != wantAllowed {
+			t.Errorf("%s: want allowed=%v, have %v", subject, wantAllowed, allowed)
+		}
+		if calls != wantCalls {
+			t.Errorf("%s: want %d calls, have %d", subject, wantCalls, calls)
+		}
+	}
+
+	assert("alice", true, 1)
+	assert("alice", true, 1) // cached
+	assert("bob", false, 2)
+	assert("bob", false, 2) // negatively cached
+
+	now = now.Add(2 * time.Second)
+	assert("alice", true, 2) // still cached
+	assert("bob", false, 3)  // denial expired
+
+	cache.Invalidate(Key{Subject: "alice"})
+	assert("alice", true, 4)
+
+	invalidations := make(chan Key)
+	go cache.Listen(ctx, invalidations)
+	invalidations <- Key{}
+	close(invalidations)
+	assert("alice", true, 5)
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	var (
+		calls   int
+		failure = errors.New("policy store unavailable")
+		cache   = NewCache(AuthorizerFunc(func(context.Context, string, string, string) (bool, error) {
+			calls++
+			return false, failure
+		}))
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Authorize(context.Background(), "alice", "read", "doc"); err != failure {
+			t.Errorf("want %v, have %v", failure, err)
+		}
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
+
+func TestCacheInvalidationDuringDecision(t *testing.T) {
+	var (
+		allowed = true
+		cache   *Cache
+	)
+	cache = NewCache(AuthorizerFunc(func(context.Context, string, string, string) (bool, error) {
+		decision := allowed
+		// The permission is revoked while the decision is on its way back.
+		allowed = false
+		cache.Invalidate(Key{Subject: "alice"})
+		return decision, nil
+	}))
+
+	for _, want := range []bool{true, false} {
+		have, err := cache.Authorize(context.Background(), "alice", "read", "doc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != have {
+			t.Errorf("want allowed=%v, have %v", want, have)
+		}
+	}
+}
+
+func TestCacheMaxEntries(t *testing.T) {
+	var (
+		calls int
+		cache = NewCache(AuthorizerFunc(func(context.Context, string, string, string) (bool, error) {
+			calls++
+			return true, nil
+		}), MaxEntries(2))
+		ctx = context.Background()
+	)
+	for _, subject := range []string{"alice", "bob", "alice", "carol", "alice", "bob"} {
+		if _, err := cache.Authorize(ctx, subject, "read", "doc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// bob was evicted by carol, alice being more recently used.
+	if want, have := 4, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if want, have := 2, len(cache.entries); want != have {
+		t.Errorf("want %d entries, have %d", want, have)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var (
+		a = AuthorizerFunc(func(_ context.Context, subject, _, _ string) (bool, error) {
+			return subject == "alice", nil
+		})
+		extract = func(_ context.Context, subject string) (string, string, string) {
+			return subject, "read", "doc"
+		}
+		e = NewMiddleware[string, bool](a, extract)(func(context.Context, string) (bool, error) {
+			return true, nil
+		})
+	)
+	if _, err := e(context.Background(), "alice"); err != nil {
+		t.Errorf("unexpected: %v", err)
+	}
+	if _, err := e(context.Background(), "bob"); err != ErrUnauthorized {
+		t.Errorf("want %v, have %v", ErrUnauthorized, err)
+	}
+}
@@ -0,0 +1,7 @@
+// Package authz provides a transport-agnostic authorization abstraction, an
+// endpoint middleware enforcing it, and a cache for authorization decisions.
+//
+// Decisions are keyed by subject, action and resource. Policy engines like
+// casbin, or remote policy services, implement Authorizer; wrapping them in a
+// Cache avoids a round-trip to the policy store on every request.
+package authz
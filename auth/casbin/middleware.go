@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/a69/kit.go/auth/authz"
 	"github.com/a69/kit.go/endpoint"
 	stdcasbin "github.com/casbin/casbin/v2"
 )
@@ -70,3 +71,12 @@ func NewEnforcer[REQ any, RES any](
 		}
 	}
 }
+
+// NewAuthorizer returns an authz.Authorizer backed by a casbin Enforcer, with
+// the resource passed as the casbin object. Wrap it in an authz.Cache to avoid
+// evaluating the policy on every request.
+func NewAuthorizer(enforcer stdcasbin.IEnforcer) authz.Authorizer {
+	return authz.AuthorizerFunc(func(_ context.Context, subject, action, resource string) (bool, error) {
+		return enforcer.Enforce(subject, resource, action)
+	})
+}
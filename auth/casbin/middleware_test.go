@@ -54,3 +54,27 @@ func TestFileBaseContext(t *testing.T) {
 		t.Fatalf("Enforcer returned error: %s", err)
 	}
 }
+
+func TestAuthorizer(t *testing.T) {
+	enforcer, err := stdcasbin.NewEnforcer("testdata/basic_model.conf", "testdata/basic_policy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthorizer(enforcer)
+
+	allowed, err := a.Authorize(context.Background(), "alice", "read", "data1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !allowed {
+		t.Errorf("alice should be allowed to read data1")
+	}
+
+	allowed, err = a.Authorize(context.Background(), "alice", "write", "data2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed {
+		t.Errorf("alice shouldn't be allowed to write data2")
+	}
+}
@@ -0,0 +1,304 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	stdhttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrKIDMissing denotes a token has no key ID header (kid), so its key
+	// can't be looked up in a JWKS.
+	ErrKIDMissing = errors.New("token has no key ID")
+
+	// ErrKeyNotFound denotes no key in the JWKS matches a token's key ID, even
+	// after refreshing it.
+	ErrKeyNotFound = errors.New("no key found for key ID")
+)
+
+// JWKSOption sets an optional parameter for a JWKS.
+type JWKSOption func(*JWKS)
+
+// JWKSClient sets the HTTP client used to fetch the key set. By default,
+// http.DefaultClient is used.
+func JWKSClient(client *stdhttp.Client) JWKSOption {
+	return func(j *JWKS) { j.client = client }
+}
+
+// JWKSRefreshInterval sets how often the key set is refreshed in the
+// background. The default is one hour.
+func JWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.refreshInterval = d }
+}
+
+// JWKSRefreshUnknown sets the minimum time between two refreshes triggered by
+// tokens signed with an unknown key ID, which is how rotated keys are picked
+// up before the next background refresh. It bounds the load a client sending
+// bogus key IDs can put on the provider. The default is one minute.
+func JWKSRefreshUnknown(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.refreshUnknown = d }
+}
+
+// JWKSTimeout sets the timeout of every fetch of the key set. The default is
+// 10 seconds.
+func JWKSTimeout(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.timeout = d }
+}
+
+// JWKS fetches and caches a JSON Web Key Set, as published by OpenID Connect
+// providers like Auth0 or Keycloak at their jwks_uri, and looks keys up by
+// the key ID header (kid) of tokens. Its Keyfunc method can be passed to
+// NewParser:
+//
+//	jwks := jwt.NewJWKS("https://example.auth0.com/.well-known/jwks.json")
+//	defer jwks.Stop()
+//	ep = jwt.NewParser[Req, Res](jwks.Keyfunc, stdjwt.SigningMethodRS256, jwt.StandardClaimsFactory)(ep)
+//
+// RSA, EC and Ed25519 signing keys are supported; other keys are ignored.
+type JWKS struct {
+	url             string
+	client          *stdhttp.Client
+	refreshInterval time.Duration
+	refreshUnknown  time.Duration
+	timeout         time.Duration
+
+	mtx         sync.RWMutex
+	keys        map[string]interface{}
+	refreshedAt time.Time
+
+	refreshMtx sync.Mutex // serializes fetches
+
+	once  sync.Once
+	quitc chan struct{}
+}
+
+// NewJWKS returns a JWKS fetching the key set at url, once right away and
+// then periodically in the background, until stopped. Errors of background
+// fetches are ignored, and the last key set fetched is kept.
+func NewJWKS(url string, options ...JWKSOption) *JWKS {
+	j := &JWKS{
+		url:             url,
+		client:          stdhttp.DefaultClient,
+		refreshInterval: time.Hour,
+		refreshUnknown:  time.Minute,
+		timeout:         10 * time.Second,
+		keys:            map[string]interface{}{},
+		quitc:           make(chan struct{}),
+	}
+	for _, option := range options {
+		option(j)
+	}
+	go j.loop()
+	return j
+}
+
+// Keyfunc implements jwt.Keyfunc. It returns the key matching the token's key
+// ID, refreshing the key set first if the key ID is unknown, which happens
+// after the provider rotated its keys.
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrKIDMissing
+	}
+	if key, ok := j.key(kid); ok {
+		return key, nil
+	}
+
+	j.refreshMtx.Lock()
+	// Another request may have refreshed the key set while we were waiting.
+	key, ok := j.key(kid)
+	if !ok && time.Since(j.lastRefresh()) >= j.refreshUnknown {
+		ctx, cancel := context.WithTimeout(context.Background(), j.timeout)
+		err := j.refresh(ctx)
+		cancel()
+		if err != nil {
+			j.refreshMtx.Unlock()
+			return nil, err
+		}
+		key, ok = j.key(kid)
+	}
+	j.refreshMtx.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+// Refresh fetches the key set now, replacing the cached one if successful.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.refreshMtx.Lock()
+	defer j.refreshMtx.Unlock()
+	return j.refresh(ctx)
+}
+
+// Stop stops refreshing the key set in the background.
+func (j *JWKS) Stop() {
+	j.once.Do(func() { close(j.quitc) })
+}
+
+func (j *JWKS) loop() {
+	ticker := time.NewTicker(j.refreshInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), j.timeout)
+		j.Refresh(ctx)
+		cancel()
+
+		select {
+		case <-ticker.C:
+		case <-j.quitc:
+			return
+		}
+	}
+}
+
+func (j *JWKS) key(kid string) (interface{}, bool) {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) lastRefresh() time.Time {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+	return j.refreshedAt
+}
+
+// refresh must be called with refreshMtx held.
+func (j *JWKS) refresh(ctx context.Context) error {
+	// Failed fetches count as refreshes too, so that an unavailable provider
+	// isn't hammered by requests with unknown key IDs.
+	defer func(now time.Time) {
+		j.mtx.Lock()
+		j.refreshedAt = now
+		j.mtx.Unlock()
+	}(time.Now())
+
+	req, err := stdhttp.NewRequestWithContext(ctx, stdhttp.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != stdhttp.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	j.mtx.Lock()
+	j.keys = keys
+	j.mtx.Unlock()
+	return nil
+}
+
+// jsonWebKey is a public key, as defined by RFC 7517 and RFC 8037.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type jwksServer struct {
+	mtx     sync.Mutex
+	keys    []map[string]string
+	fetches atomic.Int64
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.fetches.Add(1)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+}
+
+func (s *jwksServer) set(keys ...map[string]string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.keys = keys
+}
+
+// settle waits for the initial background fetch and an explicit one, so that
+// fetches counted afterwards are only those triggered by the test.
+func (s *jwksServer) settle(t *testing.T, jwks *JWKS) {
+	t.Helper()
+	if err := jwks.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &jwksServer{}
+	s.set(rsaJWK("rsa-1", &rsaKey.PublicKey))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, JWKSRefreshUnknown(0))
+	defer jwks.Stop()
+	s.settle(t, jwks)
+
+	sign := func(kid string, method jwt.SigningMethod, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"user": "go-kit"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	parse := func(token string, method jwt.SigningMethod) error {
+		e := func(context.Context, struct{}) (struct{}, error) { return struct{}{}, nil }
+		parser := NewParser[struct{}, struct{}](jwks.Keyfunc, method, MapClaimsFactory)(e)
+		_, err := parser(context.WithValue(context.Background(), JWTContextKey, token), struct{}{})
+		return err
+	}
+
+	if err := parse(sign("rsa-1", jwt.SigningMethodRS256, rsaKey), jwt.SigningMethodRS256); err != nil {
+		t.Errorf("rsa-1: %v", err)
+	}
+	if want, have := ErrKIDMissing, parse(sign("", jwt.SigningMethodRS256, rsaKey), jwt.SigningMethodRS256); !errors.Is(have, want) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	// Unknown key IDs trigger a refresh, which picks up rotated keys.
+	fetches := s.fetches.Load()
+	if want, have := ErrKeyNotFound, parse(sign("ec-1", jwt.SigningMethodES256, ecKey), jwt.SigningMethodES256); !errors.Is(have, want) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := fetches+1, s.fetches.Load(); want != have {
+		t.Errorf("want %d fetches, have %d", want, have)
+	}
+	s.set(ecJWK("ec-1", &ecKey.PublicKey))
+	if err := parse(sign("ec-1", jwt.SigningMethodES256, ecKey), jwt.SigningMethodES256); err != nil {
+		t.Errorf("ec-1: %v", err)
+	}
+	if err := parse(sign("rsa-1", jwt.SigningMethodRS256, rsaKey), jwt.SigningMethodRS256); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("rsa-1 after rotation: want %v, have %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestJWKSRefreshUnknownRateLimited(t *testing.T) {
+	s := &jwksServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, JWKSRefreshUnknown(time.Hour))
+	defer jwks.Stop()
+	s.settle(t, jwks)
+
+	fetches := s.fetches.Load()
+	for i := 0; i < 10; i++ {
+		token := &jwt.Token{Header: map[string]interface{}{"kid": "unknown"}}
+		if _, err := jwks.Keyfunc(token); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("want %v, have %v", ErrKeyNotFound, err)
+		}
+	}
+	if want, have := fetches, s.fetches.Load(); want != have {
+		t.Errorf("want %d fetches, have %d", want, have)
+	}
+}
+
+func TestJWKSTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(srv.URL, JWKSTimeout(10*time.Millisecond), JWKSRefreshUnknown(0))
+	defer jwks.Stop()
+
+	token := &jwt.Token{Header: map[string]interface{}{"kid": "kid"}}
+	if _, err := jwks.Keyfunc(token); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
@@ -3,6 +3,7 @@ package jwt
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/a69/kit.go/endpoint"
 	"github.com/golang-jwt/jwt/v5"
@@ -45,6 +46,18 @@ var (
 	// ErrUnexpectedSigningMethod denotes a token was signed with an unexpected
 	// signing method.
 	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
+
+	// ErrTokenInvalidAudience denotes a token's audience claim (aud) doesn't
+	// contain the audience required by WithAudience.
+	ErrTokenInvalidAudience = errors.New("JWT has an invalid audience")
+
+	// ErrTokenInvalidIssuer denotes a token's issuer claim (iss) isn't the
+	// one required by WithIssuer.
+	ErrTokenInvalidIssuer = errors.New("JWT has an invalid issuer")
+
+	// ErrTokenInvalidSubject denotes a token's subject claim (sub) isn't the
+	// one required by WithSubject.
+	ErrTokenInvalidSubject = errors.New("JWT has an invalid subject")
 )
 
 // NewSigner creates a new JWT generating middleware, specifying key ID,
@@ -85,11 +98,43 @@ func StandardClaimsFactory() jwt.Claims {
 	return &jwt.RegisteredClaims{}
 }
 
+// ParserOption sets an optional claim validation for NewParser.
+type ParserOption func(*[]jwt.ParserOption)
+
+// WithAudience requires tokens to have the given audience in their audience
+// claim (aud). Otherwise, parsing fails with ErrTokenInvalidAudience.
+func WithAudience(aud string) ParserOption {
+	return func(opts *[]jwt.ParserOption) { *opts = append(*opts, jwt.WithAudience(aud)) }
+}
+
+// WithIssuer requires tokens to have the given issuer claim (iss). Otherwise,
+// parsing fails with ErrTokenInvalidIssuer.
+func WithIssuer(iss string) ParserOption {
+	return func(opts *[]jwt.ParserOption) { *opts = append(*opts, jwt.WithIssuer(iss)) }
+}
+
+// WithSubject requires tokens to have the given subject claim (sub).
+// Otherwise, parsing fails with ErrTokenInvalidSubject.
+func WithSubject(sub string) ParserOption {
+	return func(opts *[]jwt.ParserOption) { *opts = append(*opts, jwt.WithSubject(sub)) }
+}
+
+// WithLeeway allows for the given clock skew between the issuer and this
+// service when validating the time claims (exp, nbf and iat).
+func WithLeeway(leeway time.Duration) ParserOption {
+	return func(opts *[]jwt.ParserOption) { *opts = append(*opts, jwt.WithLeeway(leeway)) }
+}
+
 // NewParser creates a new JWT parsing middleware, specifying a
 // jwt.Keyfunc interface, the signing method and the claims type to be used. NewParser
 // adds the resulting claims to endpoint context or returns error on invalid token.
-// Particularly useful for servers.
-func NewParser[REQ any, RES any](keyFunc jwt.Keyfunc, method jwt.SigningMethod, newClaims ClaimsFactory) endpoint.Middleware[REQ, RES] {
+// Claims beyond the signature and time claims are only validated as required
+// by the options. Particularly useful for servers.
+func NewParser[REQ any, RES any](keyFunc jwt.Keyfunc, method jwt.SigningMethod, newClaims ClaimsFactory, options ...ParserOption) endpoint.Middleware[REQ, RES] {
+	var parserOptions []jwt.ParserOption
+	for _, option := range options {
+		option(&parserOptions)
+	}
 	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
 		return func(ctx context.Context, request REQ) (response RES, err error) {
 			// tokenString is stored in the context from the transport handlers.
@@ -112,7 +157,7 @@ func NewParser[REQ any, RES any](keyFunc jwt.Keyfunc, method jwt.SigningMethod,
 				}
 
 				return keyFunc(token)
-			})
+			}, parserOptions...)
 			if err != nil {
 				switch {
 				case errors.Is(err, jwt.ErrTokenMalformed):
@@ -127,6 +172,15 @@ func NewParser[REQ any, RES any](keyFunc jwt.Keyfunc, method jwt.SigningMethod,
 					// Token is not active yet
 					err = ErrTokenNotActive
 					return
+				case errors.Is(err, jwt.ErrTokenInvalidAudience):
+					err = ErrTokenInvalidAudience
+					return
+				case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+					err = ErrTokenInvalidIssuer
+					return
+				case errors.Is(err, jwt.ErrTokenInvalidSubject):
+					err = ErrTokenInvalidSubject
+					return
 				default:
 					return
 				}
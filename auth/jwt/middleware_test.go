@@ -209,3 +209,40 @@ func TestIssue562(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestParserOptions(t *testing.T) {
+	e := func(ctx context.Context, i struct{}) (context.Context, error) { return ctx, nil }
+	keys := func(token *jwt.Token) (interface{}, error) { return key, nil }
+
+	sign := func(claims jwt.RegisteredClaims) context.Context {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return context.WithValue(context.Background(), JWTContextKey, token)
+	}
+	claims := jwt.RegisteredClaims{
+		Issuer:    "https://issuer.example",
+		Subject:   "alice",
+		Audience:  jwt.ClaimStrings{"go-kit"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-30 * time.Second)),
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options []ParserOption
+		want    error
+	}{
+		{"expired", nil, ErrTokenExpired},
+		{"leeway", []ParserOption{WithLeeway(time.Minute)}, nil},
+		{"all valid", []ParserOption{WithLeeway(time.Minute), WithAudience("go-kit"), WithIssuer("https://issuer.example"), WithSubject("alice")}, nil},
+		{"audience", []ParserOption{WithLeeway(time.Minute), WithAudience("other")}, ErrTokenInvalidAudience},
+		{"issuer", []ParserOption{WithLeeway(time.Minute), WithIssuer("https://other.example")}, ErrTokenInvalidIssuer},
+		{"subject", []ParserOption{WithLeeway(time.Minute), WithSubject("bob")}, ErrTokenInvalidSubject},
+	} {
+		parser := NewParser[struct{}, context.Context](keys, method, StandardClaimsFactory, tc.options...)(e)
+		if _, err := parser(sign(claims), struct{}{}); err != tc.want {
+			t.Errorf("%s: want %v, have %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -2,14 +2,20 @@ package jwt
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	stdhttp "net/http"
 	"strings"
 
+	stdnats "github.com/nats-io/nats.go"
+	stdamqp "github.com/rabbitmq/amqp091-go"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/a69/kit.go/transport/amqp"
+	"github.com/a69/kit.go/transport/awslambda"
 	"github.com/a69/kit.go/transport/grpc"
 	"github.com/a69/kit.go/transport/http"
+	"github.com/a69/kit.go/transport/nats"
 )
 
 const (
@@ -75,6 +81,110 @@ func ContextToGRPC() grpc.ClientRequestFunc {
 	}
 }
 
+// NATSToContext moves a JWT from the Authorization header of a NATS message to
+// context. Particularly useful for subscribers.
+func NATSToContext() nats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		token, ok := extractTokenFromAuthHeader(msg.Header.Get("Authorization"))
+		if !ok {
+			return ctx
+		}
+
+		return context.WithValue(ctx, JWTContextKey, token)
+	}
+}
+
+// ContextToNATS moves a JWT from context to the Authorization header of a NATS
+// message. Particularly useful for publishers.
+func ContextToNATS() nats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		token, ok := ctx.Value(JWTContextKey).(string)
+		if ok {
+			if msg.Header == nil {
+				msg.Header = stdnats.Header{}
+			}
+			msg.Header.Set("Authorization", generateAuthHeaderFromToken(token))
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext moves a JWT from the Authorization header of an AMQP delivery
+// to context. Particularly useful for subscribers.
+func AMQPToContext() amqp.RequestFunc {
+	return func(ctx context.Context, _ *stdamqp.Publishing, d *stdamqp.Delivery) context.Context {
+		if d == nil {
+			return ctx
+		}
+		authHeader, ok := d.Headers["Authorization"].(string)
+		if !ok {
+			return ctx
+		}
+
+		token, ok := extractTokenFromAuthHeader(authHeader)
+		if ok {
+			ctx = context.WithValue(ctx, JWTContextKey, token)
+		}
+
+		return ctx
+	}
+}
+
+// ContextToAMQP moves a JWT from context to the Authorization header of an
+// AMQP publishing. Particularly useful for publishers.
+func ContextToAMQP() amqp.RequestFunc {
+	return func(ctx context.Context, pub *stdamqp.Publishing, _ *stdamqp.Delivery) context.Context {
+		token, ok := ctx.Value(JWTContextKey).(string)
+		if ok {
+			if pub.Headers == nil {
+				pub.Headers = stdamqp.Table{}
+			}
+			pub.Headers["Authorization"] = generateAuthHeaderFromToken(token)
+		}
+		return ctx
+	}
+}
+
+// LambdaToContext moves a JWT from an API Gateway payload to context. It
+// understands proxy integration events of REST and HTTP APIs, which carry the
+// token in their Authorization header, as well as the events received by
+// TOKEN and REQUEST Lambda authorizers. Particularly useful for handlers.
+func LambdaToContext() awslambda.HandlerRequestFunc {
+	return func(ctx context.Context, payload []byte) context.Context {
+		var event struct {
+			AuthorizationToken string            `json:"authorizationToken"`
+			Headers            map[string]string `json:"headers"`
+			IdentitySource     json.RawMessage   `json:"identitySource"`
+		}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return ctx
+		}
+
+		candidates := []string{event.AuthorizationToken}
+		for k, v := range event.Headers {
+			if strings.EqualFold(k, "Authorization") {
+				candidates = append(candidates, v)
+			}
+		}
+		// HTTP API authorizers get their identity sources as a list, and REST
+		// API ones as a single string.
+		var sources []string
+		if json.Unmarshal(event.IdentitySource, &sources) != nil {
+			var source string
+			json.Unmarshal(event.IdentitySource, &source)
+			sources = []string{source}
+		}
+		candidates = append(candidates, sources...)
+
+		for _, candidate := range candidates {
+			if token, ok := extractTokenFromAuthHeader(candidate); ok {
+				return context.WithValue(ctx, JWTContextKey, token)
+			}
+		}
+		return ctx
+	}
+}
+
 func extractTokenFromAuthHeader(val string) (token string, ok bool) {
 	authHeaderParts := strings.Split(val, " ")
 	if len(authHeaderParts) != 2 || !strings.EqualFold(authHeaderParts[0], bearer) {
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -123,3 +125,71 @@ func TestContextToGRPC(t *testing.T) {
 		t.Errorf("JWTs did not match: expecting %s got %s", signedKey, token[0])
 	}
 }
+
+func TestNATS(t *testing.T) {
+	ctx := context.WithValue(context.Background(), JWTContextKey, signedKey)
+	msg := &nats.Msg{}
+	ContextToNATS()(ctx, msg)
+
+	if want, have := generateAuthHeaderFromToken(signedKey), msg.Header.Get("Authorization"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	ctx = NATSToContext()(context.Background(), msg)
+	if want, have := signedKey, ctx.Value(JWTContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx = NATSToContext()(context.Background(), &nats.Msg{})
+	if ctx.Value(JWTContextKey) != nil {
+		t.Error("Context should not contain a JWT")
+	}
+}
+
+func TestAMQP(t *testing.T) {
+	ctx := context.WithValue(context.Background(), JWTContextKey, signedKey)
+	pub := &amqp.Publishing{}
+	ContextToAMQP()(ctx, pub, nil)
+
+	if want, have := generateAuthHeaderFromToken(signedKey), pub.Headers["Authorization"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	ctx = AMQPToContext()(context.Background(), &amqp.Publishing{}, &amqp.Delivery{Headers: pub.Headers})
+	if want, have := signedKey, ctx.Value(JWTContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx = AMQPToContext()(context.Background(), &amqp.Publishing{}, &amqp.Delivery{})
+	if ctx.Value(JWTContextKey) != nil {
+		t.Error("Context should not contain a JWT")
+	}
+}
+
+func TestLambdaToContext(t *testing.T) {
+	reqFunc := LambdaToContext()
+	auth := generateAuthHeaderFromToken(signedKey)
+
+	for _, tc := range []struct {
+		name    string
+		payload string
+	}{
+		{"REST API proxy", fmt.Sprintf(`{"httpMethod":"GET","headers":{"Authorization":%q}}`, auth)},
+		{"HTTP API proxy", fmt.Sprintf(`{"version":"2.0","headers":{"authorization":%q}}`, auth)},
+		{"TOKEN authorizer", fmt.Sprintf(`{"type":"TOKEN","authorizationToken":%q}`, auth)},
+		{"HTTP API authorizer", fmt.Sprintf(`{"type":"REQUEST","identitySource":[%q]}`, auth)},
+		{"REST API authorizer", fmt.Sprintf(`{"type":"REQUEST","identitySource":%q}`, auth)},
+	} {
+		ctx := reqFunc(context.Background(), []byte(tc.payload))
+		if want, have := signedKey, ctx.Value(JWTContextKey); want != have {
+			t.Errorf("%s: want %v, have %v", tc.name, want, have)
+		}
+	}
+
+	for _, payload := range []string{`{"headers":{}}`, `{"authorizationToken":"nope"}`, `not json`} {
+		ctx := reqFunc(context.Background(), []byte(payload))
+		if ctx.Value(JWTContextKey) != nil {
+			t.Errorf("%s: Context should not contain a JWT", payload)
+		}
+	}
+}
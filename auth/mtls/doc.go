@@ -0,0 +1,8 @@
+// Package mtls provides authentication and authorization of clients by the
+// certificates they present over mutual TLS.
+//
+// HTTPToContext and GRPCToContext put the verified client certificate in the
+// context, and the middleware returned by NewMiddleware only lets requests
+// through if one of the certificate's subject alternative names, such as its
+// SPIFFE ID, matches one of the allowed patterns.
+package mtls
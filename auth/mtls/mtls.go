@@ -0,0 +1,198 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	stdhttp "net/http"
+	"path"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport/grpc"
+	"github.com/a69/kit.go/transport/http"
+)
+
+type contextKey string
+
+// CertificateContextKey holds the key used to store the verified client
+// certificate in the context.
+const CertificateContextKey contextKey = "ClientCertificate"
+
+var (
+	// ErrCertificateMissing denotes no verified client certificate was
+	// passed into the authorizing middleware's context.
+	ErrCertificateMissing = errors.New("no verified client certificate")
+
+	// ErrUnauthorized denotes none of the client certificate's names matches
+	// an allowed pattern.
+	ErrUnauthorized = errors.New("client certificate not authorized")
+)
+
+// HTTPToContext moves the verified client certificate of the request's TLS
+// connection to context. Certificates the server didn't verify, because its
+// tls.Config doesn't require it, are ignored. Particularly useful for
+// servers.
+func HTTPToContext() http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if cert, ok := verified(r.TLS); ok {
+			ctx = context.WithValue(ctx, CertificateContextKey, cert)
+		}
+		return ctx
+	}
+}
+
+// GRPCToContext moves the verified client certificate of the call's peer to
+// context. It requires the server to use TLS transport credentials.
+// Particularly useful for servers.
+func GRPCToContext() grpc.ServerRequestFunc {
+	return func(ctx context.Context, _ metadata.MD) context.Context {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return ctx
+		}
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok {
+			return ctx
+		}
+		if cert, ok := verified(&info.State); ok {
+			ctx = context.WithValue(ctx, CertificateContextKey, cert)
+		}
+		return ctx
+	}
+}
+
+func verified(state *tls.ConnectionState) (*x509.Certificate, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return state.VerifiedChains[0][0], true
+}
+
+// CertificateFromContext returns the verified client certificate put in the
+// context by HTTPToContext or GRPCToContext.
+func CertificateFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	cert, ok := ctx.Value(CertificateContextKey).(*x509.Certificate)
+	return cert, ok
+}
+
+// SPIFFEID returns the SPIFFE ID of the certificate, i.e. its spiffe:// URI
+// subject alternative name, or "" if it has none.
+func SPIFFEID(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
+// Names returns the subject alternative names of the certificate: its URIs,
+// including any SPIFFE ID, DNS names and email addresses. It's meant for
+// logging; the types of the names are lost, so authorize with Match instead.
+func Names(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.URIs)+len(cert.DNSNames)+len(cert.EmailAddresses))
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	return names
+}
+
+// NewMiddleware returns an endpoint.Middleware that lets a request through if
+// one of the subject alternative names of its client certificate matches one
+// of the patterns. Patterns of URIs and email addresses use the syntax of
+// path.Match, so that a "*" matches a single segment of a SPIFFE ID, e.g.
+//
+//	mtls.NewMiddleware[Req, Res]("spiffe://example.org/ns/prod/sa/*")
+//
+// Patterns of DNS names follow RFC 6125 instead: they're compared label by
+// label, ignoring case, and may only have a "*" as their entire left-most
+// label, matching exactly one label. So "*.example.org" matches
+// "a.example.org", but neither "a.b.example.org" nor "example.org".
+//
+// Each pattern only matches names of its own type: patterns with a scheme,
+// like SPIFFE IDs, match URIs, patterns with an "@" match email addresses,
+// and others match DNS names.
+//
+// Malformed patterns match nothing. Requests without a verified client
+// certificate fail with ErrCertificateMissing, and others with
+// ErrUnauthorized.
+func NewMiddleware[REQ any, RES any](patterns ...string) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			cert, ok := CertificateFromContext(ctx)
+			if !ok {
+				return response, ErrCertificateMissing
+			}
+			if !Match(cert, patterns...) {
+				return response, ErrUnauthorized
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// Match reports whether one of the names of the certificate matches one of
+// the patterns, as NewMiddleware does.
+func Match(cert *x509.Certificate, patterns ...string) bool {
+	for _, pattern := range patterns {
+		names, match := namesFor(cert, pattern)
+		for _, name := range names {
+			if match(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// namesFor returns the names of the certificate of the type the pattern
+// matches, and how to match them.
+func namesFor(cert *x509.Certificate, pattern string) ([]string, func(pattern, name string) bool) {
+	switch {
+	case strings.Contains(pattern, "://"):
+		names := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			names = append(names, uri.String())
+		}
+		return names, matchPath
+	case strings.Contains(pattern, "@"):
+		return cert.EmailAddresses, matchPath
+	default:
+		return cert.DNSNames, matchDNSName
+	}
+}
+
+func matchPath(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// matchDNSName matches a DNS name against a pattern whose left-most label
+// may be a wildcard. Names with wildcards or empty labels match nothing.
+func matchDNSName(pattern, name string) bool {
+	patternLabels := strings.Split(strings.ToLower(strings.TrimSuffix(pattern, ".")), ".")
+	nameLabels := strings.Split(strings.ToLower(strings.TrimSuffix(name, ".")), ".")
+	if len(patternLabels) != len(nameLabels) {
+		return false
+	}
+	for i, label := range nameLabels {
+		if label == "" || strings.Contains(label, "*") {
+			return false
+		}
+		if i == 0 && patternLabels[0] == "*" && len(patternLabels) > 1 {
+			continue
+		}
+		if patternLabels[i] != label {
+			return false
+		}
+	}
+	return true
+}
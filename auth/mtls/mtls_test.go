@@ -0,0 +1,180 @@
+package mtls_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/a69/kit.go/auth/mtls"
+	"github.com/a69/kit.go/endpoint"
+)
+
+func newCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestHTTPToContext(t *testing.T) {
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	spiffeID, _ := url.Parse("spiffe://example.org/ns/prod/sa/orders")
+	client, clientKey := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "orders"},
+		URIs:        []*url.URL{spiffeID},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	have := make(chan string, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := mtls.HTTPToContext()(r.Context(), r)
+		cert, ok := mtls.CertificateFromContext(ctx)
+		if !ok {
+			have <- ""
+			return
+		}
+		have <- mtls.SPIFFEID(cert)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	c := srv.Client()
+	c.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{{
+		Certificate: [][]byte{client.Raw},
+		PrivateKey:  clientKey,
+	}}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want, have := spiffeID.String(), <-have; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Plain requests carry no certificate.
+	ctx := mtls.HTTPToContext()(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, ok := mtls.CertificateFromContext(ctx); ok {
+		t.Error("Context should not contain a certificate")
+	}
+}
+
+func TestGRPCToContext(t *testing.T) {
+	cert := &x509.Certificate{DNSNames: []string{"orders.prod.svc"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+	ctx = mtls.GRPCToContext()(ctx, metadata.MD{})
+	if have, ok := mtls.CertificateFromContext(ctx); !ok || have != cert {
+		t.Errorf("want %v, have %v", cert, have)
+	}
+
+	// Unverified certificates are ignored.
+	ctx = peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+		}},
+	})
+	ctx = mtls.GRPCToContext()(ctx, metadata.MD{})
+	if _, ok := mtls.CertificateFromContext(ctx); ok {
+		t.Error("Context should not contain a certificate")
+	}
+}
+
+func TestNewMiddleware(t *testing.T) {
+	var (
+		orders, _  = url.Parse("spiffe://example.org/ns/prod/sa/orders")
+		billing, _ = url.Parse("spiffe://example.org/ns/staging/sa/billing")
+		e          = mtls.NewMiddleware[struct{}, struct{}]("spiffe://example.org/ns/prod/sa/*", "*.internal.example.org", "*@example.org")(endpoint.Nop)
+	)
+	for _, tc := range []struct {
+		name string
+		cert *x509.Certificate
+		want error
+	}{
+		{"no certificate", nil, mtls.ErrCertificateMissing},
+		{"SPIFFE ID", &x509.Certificate{URIs: []*url.URL{orders}}, nil},
+		{"other namespace", &x509.Certificate{URIs: []*url.URL{billing}}, mtls.ErrUnauthorized},
+		{"DNS name", &x509.Certificate{DNSNames: []string{"billing.internal.example.org"}}, nil},
+		{"other DNS name", &x509.Certificate{DNSNames: []string{"billing.example.org"}}, mtls.ErrUnauthorized},
+		{"email address", &x509.Certificate{EmailAddresses: []string{"ops@example.org"}}, nil},
+		{"SPIFFE ID as DNS name", &x509.Certificate{DNSNames: []string{"spiffe://example.org/ns/prod/sa/orders"}}, mtls.ErrUnauthorized},
+		{"SPIFFE ID as email address", &x509.Certificate{EmailAddresses: []string{"spiffe://example.org/ns/prod/sa/orders"}}, mtls.ErrUnauthorized},
+		{"email address as DNS name", &x509.Certificate{DNSNames: []string{"ops@example.org"}}, mtls.ErrUnauthorized},
+		{"DNS name in other case", &x509.Certificate{DNSNames: []string{"Billing.Internal.Example.org."}}, nil},
+		{"DNS name with more labels", &x509.Certificate{DNSNames: []string{"a.billing.internal.example.org"}}, mtls.ErrUnauthorized},
+		{"DNS name of the wildcard's parent", &x509.Certificate{DNSNames: []string{"internal.example.org"}}, mtls.ErrUnauthorized},
+		{"wildcard DNS name", &x509.Certificate{DNSNames: []string{"*.internal.example.org"}}, mtls.ErrUnauthorized},
+	} {
+		ctx := context.Background()
+		if tc.cert != nil {
+			ctx = context.WithValue(ctx, mtls.CertificateContextKey, tc.cert)
+		}
+		if _, have := e(ctx, struct{}{}); tc.want != have {
+			t.Errorf("%s: want %v, have %v", tc.name, tc.want, have)
+		}
+	}
+}
+
+func TestMatchDNSWildcards(t *testing.T) {
+	cert := &x509.Certificate{DNSNames: []string{"a.b.example.org"}}
+	for _, tc := range []struct {
+		pattern string
+		want    bool
+	}{
+		{"a.b.example.org", true},
+		{"*.b.example.org", true},
+		{"*.example.org", false},
+		{"*", false},
+		{"*.*.example.org", false},
+		{"a.*.example.org", false},
+		{"a*.b.example.org", false},
+		{"*.org", false},
+	} {
+		if have := mtls.Match(cert, tc.pattern); tc.want != have {
+			t.Errorf("%s: want %v, have %v", tc.pattern, tc.want, have)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Package oauth2 provides a client middleware authenticating outgoing
+// requests with access tokens obtained through the OAuth 2.0 client
+// credentials flow (RFC 6749, section 4.4), as used for service-to-service
+// calls.
+//
+// A TokenSource fetches tokens from the authorization server and caches them
+// until shortly before they expire. The Middleware puts the current token in
+// the context, and ContextToHTTP and ContextToGRPC move it into the
+// Authorization header of outgoing HTTP requests and gRPC calls.
+package oauth2
@@ -0,0 +1,68 @@
+package oauth2
+
+import (
+	"context"
+	stdhttp "net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport/grpc"
+	"github.com/a69/kit.go/transport/http"
+)
+
+type contextKey string
+
+// TokenContextKey holds the key used to store an access token in the context.
+const TokenContextKey contextKey = "OAuth2Token"
+
+// Middleware returns an endpoint.Middleware that obtains an access token from
+// the TokenSource and puts it in the context, for ContextToHTTP or
+// ContextToGRPC to send along with the request. If no token can be obtained,
+// the request fails with the TokenSource's error.
+func Middleware[REQ any, RES any](ts *TokenSource) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			token, err := ts.Token(ctx)
+			if err != nil {
+				return response, err
+			}
+			return next(context.WithValue(ctx, TokenContextKey, token), request)
+		}
+	}
+}
+
+// ContextToHTTP moves an access token from context to the Authorization
+// header of an outgoing HTTP request.
+func ContextToHTTP() http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if token, ok := ctx.Value(TokenContextKey).(Token); ok {
+			r.Header.Set("Authorization", authorization(token))
+		}
+		return ctx
+	}
+}
+
+// ContextToGRPC moves an access token from context to the authorization
+// metadata of an outgoing gRPC call.
+func ContextToGRPC() grpc.ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if token, ok := ctx.Value(TokenContextKey).(Token); ok {
+			// capital "Key" is illegal in HTTP/2.
+			(*md)["authorization"] = []string{authorization(token)}
+		}
+		return ctx
+	}
+}
+
+// authorization returns the Authorization header value for the token. Token
+// types are case-insensitive, and some servers return "bearer", which some
+// resource servers reject, so Bearer tokens are always sent as "Bearer".
+func authorization(token Token) string {
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + token.AccessToken
+}
@@ -0,0 +1,260 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes a client of an authorization server.
+type Config struct {
+	// TokenURL is the token endpoint of the authorization server.
+	TokenURL string
+
+	// ClientID and ClientSecret are the client's credentials. They're sent
+	// with HTTP Basic authentication, as RFC 6749 recommends, unless the
+	// CredentialsInBody option is set.
+	ClientID     string
+	ClientSecret string
+
+	// Scopes are the optional scopes requested.
+	Scopes []string
+
+	// EndpointParams are additional parameters of token requests, e.g. the
+	// "audience" required by some providers.
+	EndpointParams url.Values
+}
+
+// Token is an access token.
+type Token struct {
+	AccessToken string
+	TokenType   string
+
+	// Expiry is when the token expires. The zero value means it never does.
+	Expiry time.Time
+}
+
+// Error is an error response of the authorization server (RFC 6749, section
+// 5.2).
+type Error struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: token request failed with status %d", e.StatusCode)
+	}
+	if e.Description == "" {
+		return fmt.Sprintf("oauth2: %s", e.Code)
+	}
+	return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+}
+
+// Option sets an optional parameter for a TokenSource.
+type Option func(*TokenSource)
+
+// HTTPClient sets the HTTP client used to request tokens. By default,
+// http.DefaultClient is used.
+func HTTPClient(client *http.Client) Option {
+	return func(ts *TokenSource) { ts.client = client }
+}
+
+// RefreshBefore sets how long before its expiry a token is refreshed. While
+// the refresh is in flight, requests keep using the cached token, so they
+// never wait for one. Tokens living less than twice as long are refreshed
+// halfway through their lifetime instead, so that short-lived tokens aren't
+// refreshed on every request. The default is one minute.
+func RefreshBefore(d time.Duration) Option {
+	return func(ts *TokenSource) { ts.refreshBefore = d }
+}
+
+// Timeout sets the timeout of token requests. The default is 10 seconds.
+func Timeout(d time.Duration) Option {
+	return func(ts *TokenSource) { ts.timeout = d }
+}
+
+// CredentialsInBody sends the client credentials as form parameters rather
+// than with HTTP Basic authentication, for authorization servers that
+// require it.
+func CredentialsInBody() Option {
+	return func(ts *TokenSource) { ts.inBody = true }
+}
+
+// TokenSource obtains access tokens with the client credentials flow, and
+// caches them until they're about to expire. It's safe for concurrent use;
+// concurrent requests for a token share a single token request.
+type TokenSource struct {
+	cfg           Config
+	client        *http.Client
+	refreshBefore time.Duration
+	timeout       time.Duration
+	inBody        bool
+	now           func() time.Time
+
+	mtx       sync.Mutex
+	token     Token
+	refreshAt time.Time // when the cached token is refreshed, if it expires
+	inflight  *call
+}
+
+type call struct {
+	done  chan struct{}
+	token Token
+	err   error
+}
+
+// NewTokenSource returns a TokenSource for the given client. No token is
+// requested until the first call to Token.
+func NewTokenSource(cfg Config, options ...Option) *TokenSource {
+	ts := &TokenSource{
+		cfg:           cfg,
+		client:        http.DefaultClient,
+		refreshBefore: time.Minute,
+		timeout:       10 * time.Second,
+		now:           time.Now,
+	}
+	for _, option := range options {
+		option(ts)
+	}
+	return ts
+}
+
+// Token returns a valid access token. It returns the cached token if it
+// doesn't expire within the refresh window, and also if it does but hasn't
+// expired yet, after starting a refresh in the background. Otherwise, it
+// requests a new token and waits for it, or for ctx to be done.
+func (ts *TokenSource) Token(ctx context.Context) (Token, error) {
+	ts.mtx.Lock()
+	now := ts.now()
+	token := ts.token
+	switch {
+	case token.AccessToken != "" && (token.Expiry.IsZero() || now.Before(ts.refreshAt)):
+		ts.mtx.Unlock()
+		return token, nil
+	case token.AccessToken != "" && now.Before(token.Expiry):
+		ts.fetch()
+		ts.mtx.Unlock()
+		return token, nil
+	}
+	c := ts.fetch()
+	ts.mtx.Unlock()
+
+	select {
+	case <-c.done:
+		return c.token, c.err
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	}
+}
+
+// Invalidate drops the cached token, e.g. after it was rejected, so that the
+// next call to Token requests a new one.
+func (ts *TokenSource) Invalidate() {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	ts.token = Token{}
+}
+
+// fetch starts a token request, unless one is already in flight, and returns
+// it. It must be called with the mutex held.
+func (ts *TokenSource) fetch() *call {
+	if ts.inflight != nil {
+		return ts.inflight
+	}
+	c := &call{done: make(chan struct{})}
+	ts.inflight = c
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ts.timeout)
+		defer cancel()
+		issued := ts.now()
+		c.token, c.err = ts.request(ctx)
+
+		ts.mtx.Lock()
+		if c.err == nil {
+			ts.token = c.token
+			ts.refreshAt = ts.refreshPoint(c.token, issued)
+		}
+		ts.inflight = nil
+		ts.mtx.Unlock()
+		close(c.done)
+	}()
+	return c
+}
+
+// refreshPoint returns when a token issued at the given time is refreshed:
+// RefreshBefore its expiry, but not before half of its lifetime.
+func (ts *TokenSource) refreshPoint(token Token, issued time.Time) time.Time {
+	before := ts.refreshBefore
+	if half := token.Expiry.Sub(issued) / 2; before > half {
+		before = half
+	}
+	return token.Expiry.Add(-before)
+}
+
+func (ts *TokenSource) request(ctx context.Context) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	for k, v := range ts.cfg.EndpointParams {
+		form[k] = v
+	}
+	if len(ts.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.cfg.Scopes, " "))
+	}
+	if ts.inBody {
+		form.Set("client_id", ts.cfg.ClientID)
+		form.Set("client_secret", ts.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !ts.inBody {
+		req.SetBasicAuth(url.QueryEscape(ts.cfg.ClientID), url.QueryEscape(ts.cfg.ClientSecret))
+	}
+
+	begin := ts.now()
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Token{}, err
+	}
+
+	var payload struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	jsonErr := json.Unmarshal(body, &payload)
+	if resp.StatusCode != http.StatusOK || payload.Error != "" {
+		return Token{}, &Error{StatusCode: resp.StatusCode, Code: payload.Error, Description: payload.ErrorDescription}
+	}
+	if jsonErr != nil {
+		return Token{}, fmt.Errorf("oauth2: decoding token response: %w", jsonErr)
+	}
+	if payload.AccessToken == "" {
+		return Token{}, fmt.Errorf("oauth2: token response has no access_token")
+	}
+
+	token := Token{AccessToken: payload.AccessToken, TokenType: payload.TokenType}
+	if payload.ExpiresIn > 0 {
+		token.Expiry = begin.Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,209 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+type tokenServer struct {
+	requests  atomic.Int64
+	release   chan struct{} // if not nil, requests block until it's closed
+	expiresIn int           // 3600 if zero
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := s.requests.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.PostFormValue("grant_type") != "client_credentials" || id != "client" || secret != "s3cr3t" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad credentials"})
+		return
+	}
+	expiresIn := s.expiresIn
+	if expiresIn == 0 {
+		expiresIn = 3600
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "token-" + string(rune('0'+n)),
+		"token_type":   "bearer",
+		"expires_in":   expiresIn,
+		"scope":        r.PostFormValue("scope"),
+	})
+}
+
+func TestTokenSource(t *testing.T) {
+	s := &tokenServer{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var (
+		mtx sync.Mutex
+		now = time.Now()
+	)
+	ts := NewTokenSource(Config{TokenURL: srv.URL, ClientID: "client", ClientSecret: "s3cr3t", Scopes: []string{"a", "b"}})
+	ts.now = func() time.Time { mtx.Lock(); defer mtx.Unlock(); return now }
+	advance := func(d time.Duration) { mtx.Lock(); defer mtx.Unlock(); now = now.Add(d) }
+
+	token, err := ts.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "token-1", token.AccessToken; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Cached until the refresh window.
+	advance(58 * time.Minute)
+	if token, _ = ts.Token(context.Background()); token.AccessToken != "token-1" || s.requests.Load() != 1 {
+		t.Errorf("want cached token-1 and 1 request, have %q and %d", token.AccessToken, s.requests.Load())
+	}
+
+	// Within the refresh window, the cached token is returned while a new one
+	// is requested in the background.
+	advance(90 * time.Second)
+	if token, _ = ts.Token(context.Background()); token.AccessToken != "token-1" {
+		t.Errorf("want token-1, have %q", token.AccessToken)
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if token, _ = ts.Token(context.Background()); token.AccessToken == "token-2" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if want, have := "token-2", token.AccessToken; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Expired tokens are never returned.
+	ts.Invalidate()
+	if token, _ = ts.Token(context.Background()); token.AccessToken != "token-3" {
+		t.Errorf("want token-3, have %q", token.AccessToken)
+	}
+}
+
+func TestTokenSourceShortLivedTokens(t *testing.T) {
+	s := &tokenServer{expiresIn: 60}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var (
+		mtx sync.Mutex
+		now = time.Now()
+	)
+	ts := NewTokenSource(Config{TokenURL: srv.URL, ClientID: "client", ClientSecret: "s3cr3t"}, RefreshBefore(5*time.Minute))
+	ts.now = func() time.Time { mtx.Lock(); defer mtx.Unlock(); return now }
+	advance := func(d time.Duration) { mtx.Lock(); defer mtx.Unlock(); now = now.Add(d) }
+
+	if _, err := ts.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens living less than RefreshBefore are cached for half their life.
+	advance(29 * time.Second)
+	for i := 0; i < 3; i++ {
+		if token, _ := ts.Token(context.Background()); token.AccessToken != "token-1" {
+			t.Errorf("want token-1, have %q", token.AccessToken)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if want, have := int64(1), s.requests.Load(); want != have {
+		t.Errorf("want %d requests, have %d", want, have)
+	}
+
+	advance(2 * time.Second)
+	ts.Token(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for s.requests.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if want, have := int64(2), s.requests.Load(); want != have {
+		t.Errorf("want %d requests, have %d", want, have)
+	}
+}
+
+func TestTokenSourceSharesRequests(t *testing.T) {
+	s := &tokenServer{release: make(chan struct{})}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ts := NewTokenSource(Config{TokenURL: srv.URL, ClientID: "client", ClientSecret: "s3cr3t"}, CredentialsInBody())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ts.Token(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(s.release)
+	wg.Wait()
+
+	if want, have := int64(1), s.requests.Load(); want != have {
+		t.Errorf("want %d requests, have %d", want, have)
+	}
+}
+
+func TestTokenSourceError(t *testing.T) {
+	srv := httptest.NewServer(&tokenServer{})
+	defer srv.Close()
+
+	ts := NewTokenSource(Config{TokenURL: srv.URL, ClientID: "client", ClientSecret: "wrong"})
+	_, err := ts.Token(context.Background())
+	var oerr *Error
+	if !errors.As(err, &oerr) {
+		t.Fatalf("want *Error, have %v", err)
+	}
+	if want, have := "invalid_client", oerr.Code; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := http.StatusUnauthorized, oerr.StatusCode; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	srv := httptest.NewServer(&tokenServer{})
+	defer srv.Close()
+
+	ts := NewTokenSource(Config{TokenURL: srv.URL, ClientID: "client", ClientSecret: "s3cr3t"})
+	e := Middleware[struct{}, context.Context](ts)(func(ctx context.Context, _ struct{}) (context.Context, error) {
+		return ctx, nil
+	})
+	ctx, err := e(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ContextToHTTP()(ctx, r)
+	if want, have := "Bearer token-1", r.Header.Get("Authorization"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	md := metadata.MD{}
+	ContextToGRPC()(ctx, &md)
+	if want, have := []string{"Bearer token-1"}, md["authorization"]; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,282 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/internal/window"
+)
+
+// ErrOpen is returned by the Middleware while the Breaker is open, or while it
+// is half-open and all of its probes are taken.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	// StateClosed lets all requests through, while keeping track of their
+	// outcomes.
+	StateClosed State = iota
+
+	// StateOpen rejects all requests with ErrOpen.
+	StateOpen
+
+	// StateHalfOpen lets a limited number of probe requests through, to find
+	// out whether the endpoint has recovered.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOption sets an optional parameter for a Breaker.
+type BreakerOption func(*breakerOptions)
+
+type breakerOptions struct {
+	failureRate    float64
+	slowCall       time.Duration
+	slowCallRate   float64
+	minRequests    int
+	window         time.Duration
+	openDuration   time.Duration
+	halfOpenProbes int
+	onStateChange  func(from, to State)
+}
+
+// FailureRate sets the fraction of failed requests within the window at which
+// the breaker opens. The default is 0.5.
+func FailureRate(rate float64) BreakerOption {
+	return func(o *breakerOptions) { o.failureRate = rate }
+}
+
+// SlowCalls makes the breaker open when the fraction of requests within the
+// window that took longer than threshold reaches rate, even if they succeeded.
+// Slow calls are a leading sign of an overloaded dependency. By default, the
+// duration of requests isn't taken into account.
+func SlowCalls(threshold time.Duration, rate float64) BreakerOption {
+	return func(o *breakerOptions) { o.slowCall, o.slowCallRate = threshold, rate }
+}
+
+// MinRequests sets the number of requests within the window below which the
+// breaker doesn't open, however many of them failed. The default is 10.
+func MinRequests(n int) BreakerOption {
+	return func(o *breakerOptions) { o.minRequests = n }
+}
+
+// Window sets the duration of the sliding window over which the failure and
+// slow call rates are computed. The default is one minute.
+func Window(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) { o.window = d }
+}
+
+// OpenDuration sets how long the breaker stays open before it lets probes
+// through. The default is 30 seconds.
+func OpenDuration(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) { o.openDuration = d }
+}
+
+// HalfOpenProbes sets the number of probe requests let through while the
+// breaker is half-open. The breaker closes once all of them succeeded, and
+// opens again as soon as one of them fails or is slow. The default is 1.
+func HalfOpenProbes(n int) BreakerOption {
+	return func(o *breakerOptions) { o.halfOpenProbes = n }
+}
+
+// OnStateChange sets a function called whenever the breaker changes state,
+// e.g. to log the change or update a metric. It's called synchronously, from
+// the request that caused the change, so it should return quickly.
+func OnStateChange(f func(from, to State)) BreakerOption {
+	return func(o *breakerOptions) { o.onStateChange = f }
+}
+
+// Breaker is a dependency-free circuit breaker, with failure rate and slow
+// call thresholds computed over a sliding window. It's safe for concurrent
+// use, and may be shared by several endpoints calling the same dependency.
+type Breaker struct {
+	opts breakerOptions
+
+	mtx        sync.Mutex
+	state      State
+	generation uint64
+	openedAt   time.Time
+	outcomes   *window.Window // succeeded and failed requests, while closed
+	latencies  *window.Window // fast and slow requests, while closed
+	probes     int // in flight or succeeded, while half-open
+	succeeded  int // probes, while half-open
+}
+
+// NewBreaker returns a closed Breaker.
+func NewBreaker(options ...BreakerOption) *Breaker {
+	opts := breakerOptions{
+		failureRate:    0.5,
+		minRequests:    10,
+		window:         time.Minute,
+		openDuration:   30 * time.Second,
+		halfOpenProbes: 1,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.halfOpenProbes < 1 {
+		opts.halfOpenProbes = 1
+	}
+	return &Breaker{
+		opts:      opts,
+		outcomes:  window.New(opts.window),
+		latencies: window.New(opts.window),
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mtx.Lock()
+	from, to := b.tick(time.Now())
+	b.mtx.Unlock()
+	b.notify(from, to)
+	return to
+}
+
+// allow reports whether a request may go through, and returns the generation
+// its outcome must be reported with.
+func (b *Breaker) allow() (generation uint64, err error) {
+	b.mtx.Lock()
+	from, to := b.tick(time.Now())
+	switch b.state {
+	case StateOpen:
+		err = ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.halfOpenProbes {
+			err = ErrOpen
+			break
+		}
+		b.probes++
+	}
+	generation = b.generation
+	b.mtx.Unlock()
+
+	b.notify(from, to)
+	return generation, err
+}
+
+// done records the outcome of a request let through by allow. Outcomes of
+// requests admitted before the last state change are ignored.
+func (b *Breaker) done(generation uint64, failed bool, took time.Duration) {
+	now := time.Now()
+	slow := b.opts.slowCall > 0 && took > b.opts.slowCall
+
+	b.mtx.Lock()
+	if generation != b.generation {
+		b.mtx.Unlock()
+		return
+	}
+	var from, to State
+	switch b.state {
+	case StateClosed:
+		b.outcomes.Add(now, count(!failed), count(failed))
+		b.latencies.Add(now, count(!slow), count(slow))
+		if b.tripped(now) {
+			from, to = b.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failed || slow {
+			from, to = b.transition(StateOpen, now)
+			break
+		}
+		b.succeeded++
+		if b.succeeded >= b.opts.halfOpenProbes {
+			from, to = b.transition(StateClosed, now)
+		}
+	}
+	b.mtx.Unlock()
+
+	b.notify(from, to)
+}
+
+// tick moves an open breaker to half-open once its open duration is over. Like
+// transition, it must be called with the mutex held, and the change it
+// returns passed to notify once the mutex is released.
+func (b *Breaker) tick(now time.Time) (from, to State) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.openDuration {
+		return b.transition(StateHalfOpen, now)
+	}
+	return b.state, b.state
+}
+
+func (b *Breaker) transition(state State, now time.Time) (from, to State) {
+	from, to = b.state, state
+	b.state = state
+	b.generation++
+	b.probes, b.succeeded = 0, 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.outcomes, b.latencies = window.New(b.opts.window), window.New(b.opts.window)
+	}
+	return from, to
+}
+
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.opts.onStateChange != nil {
+		b.opts.onStateChange(from, to)
+	}
+}
+
+func (b *Breaker) tripped(now time.Time) bool {
+	succeeded, failed := b.outcomes.Counts(now)
+	_, slowed := b.latencies.Counts(now)
+	total := succeeded + failed
+	if total == 0 || total < uint64(max(b.opts.minRequests, 0)) {
+		return false
+	}
+	if float64(failed)/float64(total) >= b.opts.failureRate {
+		return true
+	}
+	return b.opts.slowCall > 0 && float64(slowed)/float64(total) >= b.opts.slowCallRate
+}
+
+func count(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Middleware returns an endpoint.Middleware that guards the endpoint with the
+// Breaker. Only errors returned by the wrapped endpoint count as failures,
+// and only those the IsFailure option, if any, accepts, as well as panics,
+// which are raised again. While the breaker is open, the endpoint isn't
+// called, and ErrOpen is returned instead.
+func Middleware[REQ any, RES any](b *Breaker, opts ...Option) endpoint.Middleware[REQ, RES] {
+	o := makeOptions(opts)
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			generation, err := b.allow()
+			if err != nil {
+				return response, err
+			}
+			defer func(begin time.Time) {
+				if v := recover(); v != nil {
+					b.done(generation, true, time.Since(begin))
+					panic(v)
+				}
+				b.done(generation, o.failed(err), time.Since(begin))
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
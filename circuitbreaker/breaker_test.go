@@ -0,0 +1,228 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/circuitbreaker"
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestBreaker(t *testing.T) {
+	var (
+		breaker          = circuitbreaker.Middleware[int, bool](circuitbreaker.NewBreaker())
+		primeWith        = 100
+		shouldPass       = func(n int) bool { return n < 100 } // failure rate reaches 50%
+		circuitOpenError = "circuit breaker is open"
+	)
+	testFailingEndpoint(t, breaker, primeWith, shouldPass, 0, circuitOpenError)
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	var (
+		mtx         sync.Mutex
+		transitions []string
+		b           = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(2),
+			circuitbreaker.OpenDuration(20*time.Millisecond),
+			circuitbreaker.HalfOpenProbes(2),
+			circuitbreaker.OnStateChange(func(from, to circuitbreaker.State) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				transitions = append(transitions, from.String()+">"+to.String())
+			}),
+		)
+		failure = errors.New("failure")
+		err     error
+		e       = circuitbreaker.Middleware[int, bool](b)(func(context.Context, int) (bool, error) { return true, err })
+	)
+
+	err = failure
+	e(context.Background(), 0)
+	e(context.Background(), 0)
+	if want, have := circuitbreaker.StateOpen, b.State(); want != have {
+		t.Fatalf("want %s, have %s", want, have)
+	}
+	if _, have := e(context.Background(), 0); have != circuitbreaker.ErrOpen {
+		t.Errorf("want %v, have %v", circuitbreaker.ErrOpen, have)
+	}
+
+	// A failed probe opens the breaker again.
+	time.Sleep(25 * time.Millisecond)
+	if _, have := e(context.Background(), 0); have != failure {
+		t.Errorf("want %v, have %v", failure, have)
+	}
+	if want, have := circuitbreaker.StateOpen, b.State(); want != have {
+		t.Fatalf("want %s, have %s", want, have)
+	}
+
+	// Once all probes succeed, the breaker closes.
+	time.Sleep(25 * time.Millisecond)
+	err = nil
+	for i := 0; i < 2; i++ {
+		if _, have := e(context.Background(), 0); have != nil {
+			t.Fatalf("probe %d: %v", i, have)
+		}
+	}
+	if want, have := circuitbreaker.StateClosed, b.State(); want != have {
+		t.Fatalf("want %s, have %s", want, have)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(want) != len(transitions) {
+		t.Fatalf("want %v, have %v", want, transitions)
+	}
+	for i := range want {
+		if want[i] != transitions[i] {
+			t.Errorf("want %v, have %v", want, transitions)
+			break
+		}
+	}
+}
+
+func TestBreakerProbeLimit(t *testing.T) {
+	var (
+		b = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(1),
+			circuitbreaker.OpenDuration(time.Millisecond),
+		)
+		release = make(chan struct{})
+		started = make(chan struct{})
+		failing = true
+		e       = circuitbreaker.Middleware[int, bool](b)(func(context.Context, int) (bool, error) {
+			if failing {
+				return false, errors.New("failure")
+			}
+			close(started)
+			<-release
+			return true, nil
+		})
+	)
+	e(context.Background(), 0)
+	time.Sleep(5 * time.Millisecond)
+	failing = false
+
+	done := make(chan error)
+	go func() { _, err := e(context.Background(), 0); done <- err }()
+	<-started
+	if _, have := e(context.Background(), 0); have != circuitbreaker.ErrOpen {
+		t.Errorf("want %v, have %v", circuitbreaker.ErrOpen, have)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want, have := circuitbreaker.StateClosed, b.State(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestBreakerPanicFailsProbe(t *testing.T) {
+	var (
+		b = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(1),
+			circuitbreaker.OpenDuration(20*time.Millisecond),
+		)
+		e = circuitbreaker.Middleware[int, bool](b)(func(context.Context, int) (bool, error) {
+			panic("boom")
+		})
+	)
+	call := func() (v interface{}) {
+		defer func() { v = recover() }()
+		e(context.Background(), 0)
+		return nil
+	}
+
+	if want, have := "boom", call(); want != have {
+		t.Fatalf("want panic %v, have %v", want, have)
+	}
+	if want, have := circuitbreaker.StateOpen, b.State(); want != have {
+		t.Fatalf("want %s, have %s", want, have)
+	}
+
+	// A panicking probe opens the breaker again, rather than closing it.
+	time.Sleep(25 * time.Millisecond)
+	if want, have := "boom", call(); want != have {
+		t.Fatalf("want panic %v, have %v", want, have)
+	}
+	if want, have := circuitbreaker.StateOpen, b.State(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestBreakerSlowCalls(t *testing.T) {
+	var (
+		b = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(3),
+			circuitbreaker.SlowCalls(5*time.Millisecond, 0.6),
+		)
+		e = circuitbreaker.Middleware[int, bool](b)(func(_ context.Context, d int) (bool, error) {
+			time.Sleep(time.Duration(d) * time.Millisecond)
+			return true, nil
+		})
+	)
+	for _, d := range []int{0, 10, 10} {
+		if _, err := e(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := circuitbreaker.StateOpen, b.State(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestBreakerIgnoredErrors(t *testing.T) {
+	ignored := errors.New("validation failed")
+	breaker := circuitbreaker.Middleware[int, bool](
+		circuitbreaker.NewBreaker(),
+		circuitbreaker.IsFailure(circuitbreaker.Ignore(ignored, context.Canceled)),
+	)
+	testIgnoredErrors(t, breaker, ignored)
+}
+
+func TestBreakerWindowExpiry(t *testing.T) {
+	var (
+		b = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(2),
+			circuitbreaker.Window(60*time.Millisecond),
+		)
+		e = circuitbreaker.Middleware[bool, bool](b)(func(_ context.Context, fail bool) (bool, error) {
+			if fail {
+				return false, errors.New("dang")
+			}
+			return true, nil
+		})
+	)
+	e(context.Background(), true)
+	time.Sleep(100 * time.Millisecond) // the failure leaves the window
+
+	for _, fail := range []bool{false, false, true} {
+		e(context.Background(), fail)
+	}
+	if want, have := circuitbreaker.StateClosed, b.State(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestBreakerSharesRetryClassifier(t *testing.T) {
+	var (
+		errUnavailable = errors.New("unavailable")
+		retryable      = endpoint.Classifier(func(err error) bool { return errors.Is(err, errUnavailable) })
+		b              = circuitbreaker.NewBreaker(circuitbreaker.MinRequests(1))
+		e              = endpoint.Chain(
+			endpoint.Retry[int, int](2, nil, retryable),
+			circuitbreaker.Middleware[int, int](b, circuitbreaker.IsFailure(retryable)),
+		)(func(context.Context, int) (int, error) { return 0, errors.New("invalid") })
+	)
+	if _, err := e(context.Background(), 0); err == nil {
+		t.Fatal("want error")
+	}
+	if want, have := circuitbreaker.StateClosed, b.State(); want != have {
+		t.Errorf("an error the classifier rejects: want %s, have %s", want, have)
+	}
+}
@@ -6,5 +6,6 @@
 //
 // We provide several implementations in this package, but if you're looking
 // for guidance, Gobreaker is probably the best place to start.  It has a
-// simple and intuitive API, and is well-tested.
+// simple and intuitive API, and is well-tested. If you'd rather avoid the
+// dependency, or need slow call detection, use NewBreaker with Middleware.
 package circuitbreaker
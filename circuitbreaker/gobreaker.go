@@ -10,16 +10,27 @@ import (
 
 // Gobreaker returns an endpoint.Middleware that implements the circuit
 // breaker pattern using the sony/gobreaker package. Only errors returned by
-// the wrapped endpoint count against the circuit breaker's error count.
+// the wrapped endpoint count against the circuit breaker's error count, and
+// only those the IsFailure option, if any, accepts.
 //
 // See http://godoc.org/github.com/sony/gobreaker for more information.
-func Gobreaker[REQ any, RES any](cb *gobreaker.CircuitBreaker) endpoint.Middleware[REQ, RES] {
+func Gobreaker[REQ any, RES any](cb *gobreaker.CircuitBreaker, opts ...Option) endpoint.Middleware[REQ, RES] {
+	o := makeOptions(opts)
 	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
 		return func(ctx context.Context, request REQ) (res RES, err error) {
-			resp, err := cb.Execute(func() (interface{}, error) { return next(ctx, request) })
+			resp, err := cb.Execute(func() (interface{}, error) {
+				resp, err := next(ctx, request)
+				if err != nil && !o.failed(err) {
+					return ignoredError{err}, nil
+				}
+				return resp, err
+			})
 			if err != nil {
 				return
 			}
+			if ignored, ok := resp.(ignoredError); ok {
+				return res, ignored.err
+			}
 			return resp.(RES), err
 		}
 	}
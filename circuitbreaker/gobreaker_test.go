@@ -1,6 +1,7 @@
 package circuitbreaker_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/sony/gobreaker"
@@ -17,3 +18,12 @@ func TestGobreaker(t *testing.T) {
 	)
 	testFailingEndpoint(t, breaker, primeWith, shouldPass, 0, circuitOpenError)
 }
+
+func TestGobreakerIgnoredErrors(t *testing.T) {
+	ignored := errors.New("invalid argument")
+	breaker := circuitbreaker.Gobreaker[int, bool](
+		gobreaker.NewCircuitBreaker(gobreaker.Settings{}),
+		circuitbreaker.IsFailure(circuitbreaker.Ignore(ignored)),
+	)
+	testIgnoredErrors(t, breaker, ignored)
+}
@@ -12,11 +12,12 @@ import (
 // HandyBreaker returns an endpoint.Middleware that implements the circuit
 // breaker pattern using the streadway/handy/breaker package. Only errors
 // returned by the wrapped endpoint count against the circuit breaker's error
-// count.
+// count, and only those the IsFailure option, if any, accepts.
 //
 // See http://godoc.org/github.com/streadway/handy/breaker for more
 // information.
-func HandyBreaker[REQ any, RES any](cb breaker.Breaker) endpoint.Middleware[REQ, RES] {
+func HandyBreaker[REQ any, RES any](cb breaker.Breaker, opts ...Option) endpoint.Middleware[REQ, RES] {
+	o := makeOptions(opts)
 	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
 		return func(ctx context.Context, request REQ) (response RES, err error) {
 			if !cb.Allow() {
@@ -25,7 +26,7 @@ func HandyBreaker[REQ any, RES any](cb breaker.Breaker) endpoint.Middleware[REQ,
 			}
 
 			defer func(begin time.Time) {
-				if err == nil {
+				if !o.failed(err) {
 					cb.Success(time.Since(begin))
 				} else {
 					cb.Failure(time.Since(begin))
@@ -1,6 +1,7 @@
 package circuitbreaker_test
 
 import (
+	"context"
 	"testing"
 
 	handybreaker "github.com/streadway/handy/breaker"
@@ -18,3 +19,11 @@ func TestHandyBreaker(t *testing.T) {
 	)
 	testFailingEndpoint(t, breaker, primeWith, shouldPass, 0, openCircuitError)
 }
+
+func TestHandyBreakerIgnoredErrors(t *testing.T) {
+	breaker := circuitbreaker.HandyBreaker[int, bool](
+		handybreaker.NewBreaker(0.05),
+		circuitbreaker.IsFailure(circuitbreaker.IgnoreCanceled),
+	)
+	testIgnoredErrors(t, breaker, context.Canceled)
+}
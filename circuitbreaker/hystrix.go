@@ -12,16 +12,27 @@ import (
 // breaker pattern using the afex/hystrix-go package.
 //
 // When using this circuit breaker, please configure your commands separately.
+// Errors the IsFailure option, if any, rejects are returned to the caller
+// without counting against the command.
 //
 // See https://godoc.org/github.com/afex/hystrix-go/hystrix for more
 // information.
-func Hystrix[REQ any, RES any](commandName string) endpoint.Middleware[REQ, RES] {
+func Hystrix[REQ any, RES any](commandName string, opts ...Option) endpoint.Middleware[REQ, RES] {
+	o := makeOptions(opts)
 	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
 		return func(ctx context.Context, request REQ) (response RES, err error) {
+			var ignored error
 			err = hystrix.Do(commandName, func() (err error) {
 				response, err = next(ctx, request)
+				if err != nil && !o.failed(err) {
+					ignored = err
+					return nil
+				}
 				return err
 			}, nil)
+			if err == nil && ignored != nil {
+				err = ignored
+			}
 			return
 		}
 	}
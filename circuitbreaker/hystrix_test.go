@@ -1,6 +1,7 @@
 package circuitbreaker_test
 
 import (
+	"errors"
 	"io/ioutil"
 	stdlog "log"
 	"testing"
@@ -38,3 +39,14 @@ func TestHystrix(t *testing.T) {
 
 	testFailingEndpoint(t, breaker, primeWith, shouldPass, requestDelay, openCircuitError)
 }
+
+func TestHystrixIgnoredErrors(t *testing.T) {
+	const commandName = "my-ignoring-endpoint"
+	hystrix.ConfigureCommand(commandName, hystrix.CommandConfig{
+		ErrorPercentThreshold: 5,
+		MaxConcurrentRequests: 1000,
+	})
+	ignored := errors.New("not found")
+	breaker := circuitbreaker.Hystrix[int, bool](commandName, circuitbreaker.IsFailure(circuitbreaker.Ignore(ignored)))
+	testIgnoredErrors(t, breaker, ignored)
+}
@@ -0,0 +1,62 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// Option sets an optional parameter for the circuit breaker middlewares.
+type Option func(*options)
+
+type options struct {
+	isFailure endpoint.Classifier
+}
+
+// IsFailure sets the classifier deciding which errors returned by the
+// endpoint count as failures. Other errors are still returned to the caller,
+// but count as successes, so that e.g. business errors, validation errors or
+// requests canceled by the caller don't trip the breaker. By default, every
+// error is a failure. The classifier given to endpoint.Retry usually fits: the
+// errors worth retrying are those that say the endpoint is unhealthy.
+func IsFailure(c endpoint.Classifier) Option {
+	return func(o *options) { o.isFailure = c }
+}
+
+// Ignore returns a Classifier counting all errors as failures, except those
+// matching one of the given errors, as reported by errors.Is.
+func Ignore(errs ...error) endpoint.Classifier {
+	return func(err error) bool {
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// IgnoreCanceled is a Classifier that doesn't count requests canceled
+// by the caller as failures. They say nothing about the health of the
+// endpoint.
+var IgnoreCanceled = Ignore(context.Canceled)
+
+func makeOptions(opts []Option) options {
+	o := options{isFailure: endpoint.RetryAll}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// failed reports whether err counts against the breaker.
+func (o options) failed(err error) bool {
+	return err != nil && o.isFailure(err)
+}
+
+// ignoredError carries an error that doesn't count as a failure through the
+// breakers that count every error they see.
+type ignoredError struct {
+	err error
+}
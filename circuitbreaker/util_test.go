@@ -73,3 +73,21 @@ func (m *mock) endpoint(context.Context, int) (bool, error) {
 	m.through++
 	return false, m.err
 }
+
+// testIgnoredErrors checks that errors the breaker was told to ignore are
+// returned unchanged, and never open the circuit.
+func testIgnoredErrors(t *testing.T, breaker endpoint.Middleware[int, bool], ignored error) {
+	_, file, line, _ := runtime.Caller(1)
+	caller := fmt.Sprintf("%s:%d", filepath.Base(file), line)
+
+	m := mock{err: ignored}
+	e := breaker(m.endpoint)
+	for i := 0; i < 1000; i++ {
+		if _, err := e(context.Background(), 0); err != ignored {
+			t.Fatalf("%s: request %d: want %v, have %v", caller, i, ignored, err)
+		}
+	}
+	if want, have := 1000, m.through; want != have {
+		t.Errorf("%s: want %d, have %d", caller, want, have)
+	}
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// file is a generated file.
+type file struct {
+	Name    string
+	Content []byte
+}
+
+// data is passed to the templates.
+type data struct {
+	*service
+	Out string // name of the generated package
+}
+
+// transports maps the transports that can be generated to their templates.
+var transports = map[string]*template.Template{
+	"http":    parse("http", httpTemplate),
+	"jsonrpc": parse("jsonrpc", jsonrpcTemplate),
+	"grpc":    parse("grpc", grpcTemplate),
+}
+
+var endpointsTmpl = parse("endpoints", endpointsTemplate)
+
+// generate returns the files of package out, generated from svc for the
+// given transports.
+func generate(svc *service, out string, names []string) ([]file, error) {
+	d := data{service: svc, Out: out}
+	tmpls := []*template.Template{endpointsTmpl}
+	for _, name := range names {
+		tmpl, ok := transports[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transport %q", name)
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+
+	var files []file
+	for _, tmpl := range tmpls {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, d); err != nil {
+			return nil, err
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s.go: %w\n%s", tmpl.Name(), err, buf.Bytes())
+		}
+		files = append(files, file{Name: tmpl.Name() + ".go", Content: src})
+	}
+	return files, nil
+}
+
+func parse(name, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(template.FuncMap{
+		"kebab":      kebab,
+		"lowerCamel": lowerCamel,
+		"args":       args,
+		"params":     params,
+		"results":    results,
+	}).Parse(text))
+}
+
+// kebab returns the path segment of a method, e.g. get-user for GetUser.
+func kebab(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	for i, c := range r {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+				b.WriteByte('-')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// lowerCamel returns the JSON-RPC method name of a method, e.g. getUser for
+// GetUser.
+func lowerCamel(s string) string {
+	r := []rune(s)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// params returns the parameter list of the generated method.
+func params(m method) string {
+	ps := []string{"ctx context.Context"}
+	for _, p := range m.Params {
+		if p.Variadic {
+			ps = append(ps, p.Name+" ..."+strings.TrimPrefix(p.Type, "[]"))
+			continue
+		}
+		ps = append(ps, p.Name+" "+p.Type)
+	}
+	return strings.Join(ps, ", ")
+}
+
+// results returns the named result list of the generated method.
+func results(m method) string {
+	var rs []string
+	for _, r := range m.Results {
+		rs = append(rs, r.Name+" "+r.Type)
+	}
+	return "(" + strings.Join(append(rs, "err error"), ", ") + ")"
+}
+
+// args returns the arguments the endpoint passes to the service method.
+func args(m method) string {
+	as := []string{"ctx"}
+	for _, p := range m.Params {
+		a := "request." + p.Field
+		if p.Variadic {
+			a += "..."
+		}
+		as = append(as, a)
+	}
+	return strings.Join(as, ", ")
+}
+
+const header = `// Code generated by kitgen. DO NOT EDIT.
+
+package {{.Out}}
+`
+
+const endpointsTemplate = header + `
+import (
+	"context"
+
+	"github.com/a69/kit.go/endpoint"
+
+	"{{.ImportPath}}"
+{{- range .Imports}}{{if ne . "\"context\""}}
+	{{.}}{{end}}{{end}}
+)
+
+// Set collects an endpoint per method of {{.Package}}.{{.Name}}. It implements
+// {{.Package}}.{{.Name}} too, by calling them, so that a Set of client
+// endpoints may be used as a client library.
+type Set struct {
+{{- range .Methods}}
+	{{.Endpoint}} endpoint.Endpoint[{{.Request}}, {{.Response}}]
+{{- end}}
+}
+
+var _ {{.Package}}.{{.Name}} = Set{}
+
+// NewSet returns a Set of endpoints calling svc. Endpoint middlewares may be
+// applied to its fields.
+func NewSet(svc {{.Package}}.{{.Name}}) Set {
+	return Set{
+	{{- range .Methods}}
+		{{.Endpoint}}: Make{{.Endpoint}}(svc),
+	{{- end}}
+	}
+}
+{{range $m := .Methods}}
+// {{.Name}} implements {{$.Package}}.{{$.Name}}.
+func (s Set) {{.Name}}({{params .}}) {{results .}} {
+	response, err := s.{{.Endpoint}}(ctx, {{.Request}}{
+	{{- range .Params}}
+		{{.Field}}: {{.Name}},
+	{{- end}}
+	})
+	if err != nil {
+		return {{range .Results}}{{.Name}}, {{end}}err
+	}
+	return {{range .Results}}response.{{.Field}}, {{end}}response.Err
+}
+
+// Make{{.Endpoint}} returns an endpoint calling the {{.Name}} method of svc.
+// Errors returned by the method are returned in the response.
+func Make{{.Endpoint}}(svc {{$.Package}}.{{$.Name}}) endpoint.Endpoint[{{.Request}}, {{.Response}}] {
+	return func(ctx context.Context, request {{.Request}}) (response {{.Response}}, err error) {
+		{{range .Results}}response.{{.Field}}, {{end}}response.Err = svc.{{.Name}}({{args .}})
+		return response, nil
+	}
+}
+
+// {{.Request}} collects the parameters of the {{.Name}} method.
+type {{.Request}} struct {
+{{- range .Params}}
+	{{.Field}} {{.Type}} ` + "`" + `json:"{{.JSON}}"` + "`" + `
+{{- end}}
+}
+
+// {{.Response}} collects the results of the {{.Name}} method.
+type {{.Response}} struct {
+{{- range .Results}}
+	{{.Field}} {{.Type}} ` + "`" + `json:"{{.JSON}}"` + "`" + `
+{{- end}}
+	Err error ` + "`" + `json:"-"` + "`" + `
+}
+
+// Failed implements endpoint.Failer.
+func (r {{.Response}}) Failed() error { return r.Err }
+{{end}}`
+
+const httpTemplate = header + `
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/a69/kit.go/endpoint"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+// NewHTTPHandler returns a handler serving the endpoints of set, each on its
+// own path, with JSON requests and responses. Errors are returned with a
+// 500 status code, unless they implement httptransport.StatusCoder, and a
+// JSON body.
+func NewHTTPHandler(set Set) http.Handler {
+	m := http.NewServeMux()
+{{- range .Methods}}
+	m.Handle("/{{kebab .Name}}", httptransport.NewServer(
+		set.{{.Endpoint}},
+		decodeHTTPRequest[{{.Request}}],
+		encodeHTTPResponse[{{.Response}}],
+		httptransport.ServerErrorEncoder[{{.Request}}, {{.Response}}](encodeHTTPError),
+	))
+{{- end}}
+	return m
+}
+
+// NewHTTPClient returns a Set of endpoints calling the handler returned by
+// NewHTTPHandler on the remote instance, e.g. "host:port".
+func NewHTTPClient(instance string) (Set, error) {
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	u, err := url.Parse(instance)
+	if err != nil {
+		return Set{}, err
+	}
+	return Set{
+	{{- range .Methods}}
+		{{.Endpoint}}: httptransport.NewClient(
+			http.MethodPost,
+			httpURL(u, "/{{kebab .Name}}"),
+			encodeHTTPRequest[{{.Request}}],
+			decodeHTTPResponse[{{.Response}}],
+		).Endpoint(),
+	{{- end}}
+	}, nil
+}
+
+type httpError struct {
+	Error string ` + "`" + `json:"error"` + "`" + `
+}
+
+func decodeHTTPRequest[REQ any](_ context.Context, r *http.Request) (REQ, error) {
+	var request REQ
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		return request, err
+	}
+	return request, nil
+}
+
+func encodeHTTPResponse[RES endpoint.Failer](ctx context.Context, w http.ResponseWriter, response RES) error {
+	if err := response.Failed(); err != nil {
+		encodeHTTPError(ctx, err, w)
+		return nil
+	}
+	return httptransport.EncodeJSONResponse(ctx, w, response)
+}
+
+func encodeHTTPError(_ context.Context, err error, w http.ResponseWriter) {
+	code := http.StatusInternalServerError
+	if sc, ok := err.(httptransport.StatusCoder); ok {
+		code = sc.StatusCode()
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(httpError{Error: err.Error()})
+}
+
+func encodeHTTPRequest[REQ any](_ context.Context, r *http.Request, request *REQ) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(request); err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Body = io.NopCloser(&buf)
+	return nil
+}
+
+func decodeHTTPResponse[RES any](_ context.Context, r *http.Response) (RES, error) {
+	var response RES
+	if r.StatusCode != http.StatusOK {
+		var e httpError
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil || e.Error == "" {
+			return response, errors.New(r.Status)
+		}
+		return response, errors.New(e.Error)
+	}
+	err := json.NewDecoder(r.Body).Decode(&response)
+	return response, err
+}
+
+func httpURL(base *url.URL, path string) *url.URL {
+	next := *base
+	next.Path = strings.TrimSuffix(next.Path, "/") + path
+	return &next
+}
+`
+
+const jsonrpcTemplate = header + `
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+// NewJSONRPCHandler returns a JSON-RPC server serving the endpoints of set,
+// each as a method named after the one of the service, e.g. "{{lowerCamel (index .Methods 0).Name}}".
+func NewJSONRPCHandler(set Set, options ...jsonrpc.ServerOption) *jsonrpc.Server {
+	return jsonrpc.NewServer(jsonrpc.EndpointCodecMap{
+	{{- range .Methods}}
+		"{{lowerCamel .Name}}": &jsonrpc.EndpointCodec[{{.Request}}, {{.Response}}]{
+			Endpoint: set.{{.Endpoint}},
+			Decode:   decodeJSONRPCRequest[{{.Request}}],
+			Encode:   encodeJSONRPCResponse[{{.Response}}],
+		},
+	{{- end}}
+	}, options...)
+}
+
+// NewJSONRPCClient returns a Set of endpoints calling the server returned by
+// NewJSONRPCHandler on the remote instance, e.g. "host:port".
+func NewJSONRPCClient(instance string) (Set, error) {
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	u, err := url.Parse(instance)
+	if err != nil {
+		return Set{}, err
+	}
+	return Set{
+	{{- range .Methods}}
+		{{.Endpoint}}: jsonrpc.NewClient[{{.Request}}, {{.Response}}](u, "{{lowerCamel .Name}}").Endpoint(),
+	{{- end}}
+	}, nil
+}
+
+func decodeJSONRPCRequest[REQ any](_ context.Context, params json.RawMessage) (REQ, error) {
+	var request REQ
+	if len(params) == 0 {
+		return request, nil
+	}
+	if err := json.Unmarshal(params, &request); err != nil {
+		return request, &jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: err.Error()}
+	}
+	return request, nil
+}
+
+func encodeJSONRPCResponse[RES endpoint.Failer](_ context.Context, response RES) (json.RawMessage, error) {
+	if err := response.Failed(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
+`
+
+const grpcTemplate = header + `
+import (
+	"google.golang.org/grpc"
+
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+)
+
+// GRPCCodecs converts the requests and responses of the endpoints from and to
+// the messages of the gRPC service, as defined in its .proto file.
+type GRPCCodecs struct {
+{{- range .Methods}}
+	Decode{{.Request}}  grpctransport.DecodeRequestFunc[{{.Request}}]
+	Encode{{.Response}} grpctransport.EncodeResponseFunc[{{.Response}}]
+	Encode{{.Request}}  grpctransport.EncodeRequestFunc[{{.Request}}]
+	Decode{{.Response}} grpctransport.DecodeResponseFunc[{{.Response}}]
+	{{.Name}}Reply      interface{} // zero value of the reply message
+{{- end}}
+}
+
+// GRPCServer holds a gRPC transport handler per endpoint. The implementation
+// of the server interface generated by protoc calls them, e.g. with a
+// GRPCServer in its handlers field:
+//
+//	func (s *server) {{(index .Methods 0).Name}}(ctx context.Context, req *pb.{{(index .Methods 0).Name}}Request) (*pb.{{(index .Methods 0).Name}}Reply, error) {
+//		_, rep, err := s.handlers.{{(index .Methods 0).Name}}.ServeGRPC(ctx, req)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return rep.(*pb.{{(index .Methods 0).Name}}Reply), nil
+//	}
+type GRPCServer struct {
+{{- range .Methods}}
+	{{.Name}} grpctransport.Handler
+{{- end}}
+}
+
+// NewGRPCServer returns the gRPC transport handlers of the endpoints of set.
+func NewGRPCServer(set Set, codecs GRPCCodecs) GRPCServer {
+	return GRPCServer{
+	{{- range .Methods}}
+		{{.Name}}: grpctransport.NewServer(
+			set.{{.Endpoint}},
+			codecs.Decode{{.Request}},
+			codecs.Encode{{.Response}},
+		),
+	{{- end}}
+	}
+}
+
+// NewGRPCClient returns a Set of endpoints calling the methods of the gRPC
+// service, e.g. "pb.{{.Name}}", on conn.
+func NewGRPCClient(conn *grpc.ClientConn, serviceName string, codecs GRPCCodecs) Set {
+	return Set{
+	{{- range .Methods}}
+		{{.Endpoint}}: grpctransport.NewClient(
+			conn,
+			serviceName,
+			"{{.Name}}",
+			codecs.Encode{{.Request}},
+			codecs.Decode{{.Response}},
+			codecs.{{.Name}}Reply,
+		).Endpoint(),
+	{{- end}}
+	}
+}
+`
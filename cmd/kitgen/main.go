@@ -0,0 +1,106 @@
+// Command kitgen generates the endpoints and transports of a service from its
+// interface.
+//
+// Given the package of a service interface, whose methods take a
+// context.Context first and return an error last, like
+//
+//	type Service interface {
+//		Sum(ctx context.Context, a, b int) (int, error)
+//		Concat(ctx context.Context, a, b string) (string, error)
+//	}
+//
+// kitgen writes a package with the endpoint Set, the request and response
+// types of each method, and the server and client bindings of the HTTP,
+// JSON-RPC and gRPC transports. The Set implements the service interface, so
+// that the clients may be used as client libraries.
+//
+// Usage:
+//
+//	kitgen [flags] [dir]
+//
+// The flags are:
+//
+//	-type name
+//		name of the service interface (default "Service")
+//	-pkg name
+//		name of the generated package (default the service package's,
+//		suffixed with "kit")
+//	-out dir
+//		directory of the generated package (default a sibling of the
+//		service package, named after the generated package)
+//	-transports list
+//		comma-separated transports to generate (default "http,jsonrpc,grpc")
+//
+// It's meant to be run by go generate, from the package of the service:
+//
+//	//go:generate kitgen -type Service
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	fs := flag.NewFlagSet("kitgen", flag.ExitOnError)
+	var (
+		typeName   = fs.String("type", "Service", "name of the service interface")
+		pkg        = fs.String("pkg", "", "name of the generated package")
+		out        = fs.String("out", "", "directory of the generated package")
+		transports = fs.String("transports", "http,jsonrpc,grpc", "comma-separated transports to generate")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: kitgen [flags] [dir]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if err := run(dir, *typeName, *pkg, *out, strings.Split(*transports, ",")); err != nil {
+		fmt.Fprintln(os.Stderr, "kitgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, typeName, pkg, out string, transports []string) error {
+	svc, err := parseService(dir, typeName)
+	if err != nil {
+		return err
+	}
+	if pkg == "" {
+		pkg = svc.Package + "kit"
+	}
+	if out == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		out = filepath.Join(filepath.Dir(abs), pkg)
+	}
+
+	var names []string
+	for _, t := range transports {
+		if t = strings.TrimSpace(t); t != "" {
+			names = append(names, t)
+		}
+	}
+	files, err := generate(svc, pkg, names)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(out, f.Name), f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
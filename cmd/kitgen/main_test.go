@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/cmd/kitgen/testdata/profile"
+	"github.com/a69/kit.go/cmd/kitgen/testdata/profilekit"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGolden(t *testing.T) {
+	svc, err := parseService("testdata/profile", "Service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := generate(svc, "profilekit", []string{"http", "jsonrpc", "grpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		golden := filepath.Join("testdata", "profilekit", f.Name)
+		if *update {
+			if err := os.WriteFile(golden, f.Content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want, f.Content) {
+			t.Errorf("%s differs from the golden file, run go test -update to update it", f.Name)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, typeName := range []string{"Profile", "Missing"} {
+		if _, err := parseService("testdata/profile", typeName); err == nil {
+			t.Errorf("%s: want error, have none", typeName)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for _, tc := range []struct{ in, kebab, lowerCamel string }{
+		{"Sum", "sum", "sum"},
+		{"GetProfile", "get-profile", "getProfile"},
+		{"HTTPGet", "http-get", "httpGet"},
+		{"ID", "id", "id"},
+	} {
+		if want, have := tc.kebab, kebab(tc.in); want != have {
+			t.Errorf("kebab(%q): want %q, have %q", tc.in, want, have)
+		}
+		if want, have := tc.lowerCamel, lowerCamel(tc.in); want != have {
+			t.Errorf("lowerCamel(%q): want %q, have %q", tc.in, want, have)
+		}
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(profilekit.NewHTTPHandler(profilekit.NewSet(newProfileService())))
+	defer srv.Close()
+	client, err := profilekit.NewHTTPClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testClient(t, client)
+}
+
+func TestJSONRPC(t *testing.T) {
+	srv := httptest.NewServer(profilekit.NewJSONRPCHandler(profilekit.NewSet(newProfileService())))
+	defer srv.Close()
+	client, err := profilekit.NewJSONRPCClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testClient(t, client)
+}
+
+func testClient(t *testing.T, client profile.Service) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := client.GetProfile(ctx, "a"); err == nil || err.Error() != profile.ErrNotFound.Error() {
+		t.Errorf("want %v, have %v", profile.ErrNotFound, err)
+	}
+	if err := client.PutProfile(ctx, profile.Profile{ID: "a", Name: "Ann"}); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := client.Tag(ctx, "a", "x", "y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, n; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	p, err := client.GetProfile(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []string{"x", "y"}, p.Tags; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	count, err := client.Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, count; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+type profileService map[string]profile.Profile
+
+func newProfileService() profileService { return profileService{} }
+
+func (s profileService) GetProfile(_ context.Context, id string) (*profile.Profile, error) {
+	p, ok := s[id]
+	if !ok {
+		return nil, profile.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (s profileService) PutProfile(_ context.Context, p profile.Profile) error {
+	s[p.ID] = p
+	return nil
+}
+
+func (s profileService) Tag(_ context.Context, id string, tags ...string) (int, time.Time, error) {
+	p, ok := s[id]
+	if !ok {
+		return 0, time.Time{}, profile.ErrNotFound
+	}
+	p.Tags, p.Modified = append(p.Tags, tags...), time.Now()
+	s[id] = p
+	return len(p.Tags), p.Modified, nil
+}
+
+func (s profileService) Count(context.Context) (int, error) { return len(s), nil }
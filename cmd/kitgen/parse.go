@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// service is the model of a service interface the code is generated from.
+type service struct {
+	Name       string   // name of the interface, e.g. Service
+	Package    string   // name of its package, e.g. addservice
+	ImportPath string   // import path of its package
+	Imports    []string // import specs used by the method signatures
+	Methods    []method
+}
+
+type method struct {
+	Name    string
+	Params  []field // excluding the leading context.Context
+	Results []field // excluding the trailing error
+}
+
+type field struct {
+	Name     string // of the parameter or result in the generated method
+	Field    string // of the request or response struct
+	JSON     string // name of the struct field in JSON
+	Type     string // qualified type, e.g. addservice.Item
+	Variadic bool
+}
+
+// Request returns the name of the request type of the method.
+func (m method) Request() string { return m.Name + "Request" }
+
+// Response returns the name of the response type of the method.
+func (m method) Response() string { return m.Name + "Response" }
+
+// Endpoint returns the name of the field holding the endpoint of the method.
+func (m method) Endpoint() string { return m.Name + "Endpoint" }
+
+var predeclared = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true,
+	"float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"rune": true, "string": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// parseService parses the package in dir, and returns the model of the
+// interface called name.
+func parseService(dir, name string) (*service, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if ts.Name.Name != name {
+						continue
+					}
+					it, ok := ts.Type.(*ast.InterfaceType)
+					if !ok {
+						return nil, fmt.Errorf("%s is not an interface", name)
+					}
+					importPath, err := importPath(dir)
+					if err != nil {
+						return nil, err
+					}
+					return newService(name, pkg.Name, importPath, file, it)
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("interface %s not found in %s", name, dir)
+}
+
+func newService(name, pkg, importPath string, file *ast.File, it *ast.InterfaceType) (*service, error) {
+	q := &qualifier{
+		pkg:     pkg,
+		imports: map[string]string{},
+		used:    map[string]bool{},
+	}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		q.imports[name] = spec.Path.Value
+		if spec.Name != nil {
+			q.imports[name] = spec.Name.Name + " " + spec.Path.Value
+		}
+	}
+
+	svc := &service{Name: name, Package: pkg, ImportPath: importPath}
+	for _, m := range it.Methods.List {
+		ft, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded interfaces aren't supported", name)
+		}
+		meth, err := newMethod(m.Names[0].Name, ft, q)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, m.Names[0].Name, err)
+		}
+		svc.Methods = append(svc.Methods, meth)
+	}
+	if len(svc.Methods) == 0 {
+		return nil, fmt.Errorf("%s has no methods", name)
+	}
+	for name := range q.used {
+		svc.Imports = append(svc.Imports, q.imports[name])
+	}
+	sort.Strings(svc.Imports)
+	return svc, nil
+}
+
+func newMethod(name string, ft *ast.FuncType, q *qualifier) (method, error) {
+	m := method{Name: name}
+	params := expand(ft.Params)
+	if len(params) == 0 || q.typeString(params[0].Type) != "context.Context" {
+		return m, errors.New("the first parameter must be a context.Context")
+	}
+	var results []*ast.Field
+	if ft.Results != nil {
+		results = expand(ft.Results)
+	}
+	if len(results) == 0 || q.typeString(results[len(results)-1].Type) != "error" {
+		return m, errors.New("the last result must be an error")
+	}
+
+	// The generated methods use these names, besides the parameters and
+	// results.
+	names := map[string]bool{"s": true, "ctx": true, "response": true, "err": true}
+	for i, p := range params[1:] {
+		f, err := newField(p, i, len(params)-1, "p", q, names)
+		if err != nil {
+			return m, err
+		}
+		m.Params = append(m.Params, f)
+	}
+	for i, r := range results[:len(results)-1] {
+		f, err := newField(r, i, len(results)-1, "v", q, names)
+		if err != nil {
+			return m, err
+		}
+		m.Results = append(m.Results, f)
+	}
+	return m, nil
+}
+
+// expand returns a field per name, so that "a, b int" yields two fields.
+func expand(fl *ast.FieldList) []*ast.Field {
+	var fields []*ast.Field
+	for _, f := range fl.List {
+		if len(f.Names) == 0 {
+			fields = append(fields, f)
+			continue
+		}
+		for _, n := range f.Names {
+			fields = append(fields, &ast.Field{Names: []*ast.Ident{n}, Type: f.Type})
+		}
+	}
+	return fields
+}
+
+func newField(f *ast.Field, i, n int, prefix string, q *qualifier, names map[string]bool) (field, error) {
+	var fd field
+	if ell, ok := f.Type.(*ast.Ellipsis); ok {
+		fd.Variadic = true
+		fd.Type = "[]" + q.typeString(ell.Elt)
+	} else {
+		fd.Type = q.typeString(f.Type)
+	}
+	if q.err != nil {
+		return fd, q.err
+	}
+
+	name := ""
+	if len(f.Names) > 0 && f.Names[0].Name != "_" {
+		name = f.Names[0].Name
+	}
+	if name == "" {
+		name = prefix
+		if n > 1 {
+			name = prefix + strconv.Itoa(i)
+		}
+	}
+	fd.JSON = name
+	fd.Field = exported(name)
+	for names[name] {
+		name += "_"
+	}
+	names[name] = true
+	fd.Name = name
+	return fd, nil
+}
+
+// initialisms are capitalized as a whole in exported names, as in Go.
+var initialisms = map[string]bool{
+	"api": true, "id": true, "ip": true, "json": true, "sql": true,
+	"uri": true, "url": true, "uuid": true,
+}
+
+func exported(name string) string {
+	if initialisms[name] {
+		return strings.ToUpper(name)
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// qualifier renders the type expressions of the service's package from the
+// point of view of the generated package.
+type qualifier struct {
+	pkg     string
+	imports map[string]string // package name to import spec
+	used    map[string]bool
+	err     error
+}
+
+func (q *qualifier) typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if predeclared[t.Name] {
+			return t.Name
+		}
+		if !ast.IsExported(t.Name) {
+			q.fail(fmt.Errorf("unexported type %s", t.Name))
+		}
+		return q.pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			q.fail(fmt.Errorf("unsupported type %T", t.X))
+			return ""
+		}
+		if _, ok := q.imports[x.Name]; !ok {
+			q.fail(fmt.Errorf("unknown package %s", x.Name))
+		}
+		q.used[x.Name] = true
+		return x.Name + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + q.typeString(t.X)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + q.typeString(t.Elt)
+		}
+		lit, ok := t.Len.(*ast.BasicLit)
+		if !ok {
+			q.fail(errors.New("array lengths must be literals"))
+			return ""
+		}
+		return "[" + lit.Value + "]" + q.typeString(t.Elt)
+	case *ast.MapType:
+		return "map[" + q.typeString(t.Key) + "]" + q.typeString(t.Value)
+	case *ast.InterfaceType:
+		if len(t.Methods.List) > 0 {
+			q.fail(errors.New("interface literals aren't supported"))
+		}
+		return "interface{}"
+	case *ast.IndexExpr:
+		return q.typeString(t.X) + "[" + q.typeString(t.Index) + "]"
+	case *ast.IndexListExpr:
+		args := make([]string, len(t.Indices))
+		for i, index := range t.Indices {
+			args[i] = q.typeString(index)
+		}
+		return q.typeString(t.X) + "[" + strings.Join(args, ", ") + "]"
+	default:
+		q.fail(fmt.Errorf("unsupported type %T", expr))
+		return ""
+	}
+}
+
+func (q *qualifier) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// importPath returns the import path of the package in dir, from the module
+// path declared in the nearest go.mod.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; {
+		if module, err := modulePath(filepath.Join(root, "go.mod")); err == nil {
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return module, nil
+			}
+			return module + "/" + filepath.ToSlash(rel), nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+		root = parent
+	}
+}
+
+func modulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "module") {
+			module := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+			if unquoted, err := strconv.Unquote(module); err == nil {
+				module = unquoted
+			}
+			return module, nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s: no module directive", gomod)
+}
@@ -0,0 +1,27 @@
+// Package profile is the service kitgen generates the golden files from.
+package profile
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Profile is a user profile.
+type Profile struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Tags     []string  `json:"tags,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+// Service manages profiles.
+type Service interface {
+	GetProfile(ctx context.Context, id string) (*Profile, error)
+	PutProfile(ctx context.Context, p Profile) error
+	Tag(ctx context.Context, id string, tags ...string) (n int, modified time.Time, err error)
+	Count(context.Context) (int, error)
+}
+
+// ErrNotFound is returned for unknown profiles.
+var ErrNotFound = errors.New("profile not found")
@@ -0,0 +1,170 @@
+// Code generated by kitgen. DO NOT EDIT.
+
+package profilekit
+
+import (
+	"context"
+
+	"github.com/a69/kit.go/endpoint"
+
+	"github.com/a69/kit.go/cmd/kitgen/testdata/profile"
+	"time"
+)
+
+// Set collects an endpoint per method of profile.Service. It implements
+// profile.Service too, by calling them, so that a Set of client
+// endpoints may be used as a client library.
+type Set struct {
+	GetProfileEndpoint endpoint.Endpoint[GetProfileRequest, GetProfileResponse]
+	PutProfileEndpoint endpoint.Endpoint[PutProfileRequest, PutProfileResponse]
+	TagEndpoint        endpoint.Endpoint[TagRequest, TagResponse]
+	CountEndpoint      endpoint.Endpoint[CountRequest, CountResponse]
+}
+
+var _ profile.Service = Set{}
+
+// NewSet returns a Set of endpoints calling svc. Endpoint middlewares may be
+// applied to its fields.
+func NewSet(svc profile.Service) Set {
+	return Set{
+		GetProfileEndpoint: MakeGetProfileEndpoint(svc),
+		PutProfileEndpoint: MakePutProfileEndpoint(svc),
+		TagEndpoint:        MakeTagEndpoint(svc),
+		CountEndpoint:      MakeCountEndpoint(svc),
+	}
+}
+
+// GetProfile implements profile.Service.
+func (s Set) GetProfile(ctx context.Context, id string) (v *profile.Profile, err error) {
+	response, err := s.GetProfileEndpoint(ctx, GetProfileRequest{
+		ID: id,
+	})
+	if err != nil {
+		return v, err
+	}
+	return response.V, response.Err
+}
+
+// MakeGetProfileEndpoint returns an endpoint calling the GetProfile method of svc.
+// Errors returned by the method are returned in the response.
+func MakeGetProfileEndpoint(svc profile.Service) endpoint.Endpoint[GetProfileRequest, GetProfileResponse] {
+	return func(ctx context.Context, request GetProfileRequest) (response GetProfileResponse, err error) {
+		response.V, response.Err = svc.GetProfile(ctx, request.ID)
+		return response, nil
+	}
+}
+
+// GetProfileRequest collects the parameters of the GetProfile method.
+type GetProfileRequest struct {
+	ID string `json:"id"`
+}
+
+// GetProfileResponse collects the results of the GetProfile method.
+type GetProfileResponse struct {
+	V   *profile.Profile `json:"v"`
+	Err error            `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r GetProfileResponse) Failed() error { return r.Err }
+
+// PutProfile implements profile.Service.
+func (s Set) PutProfile(ctx context.Context, p profile.Profile) (err error) {
+	response, err := s.PutProfileEndpoint(ctx, PutProfileRequest{
+		P: p,
+	})
+	if err != nil {
+		return err
+	}
+	return response.Err
+}
+
+// MakePutProfileEndpoint returns an endpoint calling the PutProfile method of svc.
+// Errors returned by the method are returned in the response.
+func MakePutProfileEndpoint(svc profile.Service) endpoint.Endpoint[PutProfileRequest, PutProfileResponse] {
+	return func(ctx context.Context, request PutProfileRequest) (response PutProfileResponse, err error) {
+		response.Err = svc.PutProfile(ctx, request.P)
+		return response, nil
+	}
+}
+
+// PutProfileRequest collects the parameters of the PutProfile method.
+type PutProfileRequest struct {
+	P profile.Profile `json:"p"`
+}
+
+// PutProfileResponse collects the results of the PutProfile method.
+type PutProfileResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r PutProfileResponse) Failed() error { return r.Err }
+
+// Tag implements profile.Service.
+func (s Set) Tag(ctx context.Context, id string, tags ...string) (n int, modified time.Time, err error) {
+	response, err := s.TagEndpoint(ctx, TagRequest{
+		ID:   id,
+		Tags: tags,
+	})
+	if err != nil {
+		return n, modified, err
+	}
+	return response.N, response.Modified, response.Err
+}
+
+// MakeTagEndpoint returns an endpoint calling the Tag method of svc.
+// Errors returned by the method are returned in the response.
+func MakeTagEndpoint(svc profile.Service) endpoint.Endpoint[TagRequest, TagResponse] {
+	return func(ctx context.Context, request TagRequest) (response TagResponse, err error) {
+		response.N, response.Modified, response.Err = svc.Tag(ctx, request.ID, request.Tags...)
+		return response, nil
+	}
+}
+
+// TagRequest collects the parameters of the Tag method.
+type TagRequest struct {
+	ID   string   `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// TagResponse collects the results of the Tag method.
+type TagResponse struct {
+	N        int       `json:"n"`
+	Modified time.Time `json:"modified"`
+	Err      error     `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r TagResponse) Failed() error { return r.Err }
+
+// Count implements profile.Service.
+func (s Set) Count(ctx context.Context) (v int, err error) {
+	response, err := s.CountEndpoint(ctx, CountRequest{})
+	if err != nil {
+		return v, err
+	}
+	return response.V, response.Err
+}
+
+// MakeCountEndpoint returns an endpoint calling the Count method of svc.
+// Errors returned by the method are returned in the response.
+func MakeCountEndpoint(svc profile.Service) endpoint.Endpoint[CountRequest, CountResponse] {
+	return func(ctx context.Context, request CountRequest) (response CountResponse, err error) {
+		response.V, response.Err = svc.Count(ctx)
+		return response, nil
+	}
+}
+
+// CountRequest collects the parameters of the Count method.
+type CountRequest struct {
+}
+
+// CountResponse collects the results of the Count method.
+type CountResponse struct {
+	V   int   `json:"v"`
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r CountResponse) Failed() error { return r.Err }
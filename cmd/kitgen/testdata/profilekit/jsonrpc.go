@@ -0,0 +1,76 @@
+// Code generated by kitgen. DO NOT EDIT.
+
+package profilekit
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+// NewJSONRPCHandler returns a JSON-RPC server serving the endpoints of set,
+// each as a method named after the one of the service, e.g. "getProfile".
+func NewJSONRPCHandler(set Set, options ...jsonrpc.ServerOption) *jsonrpc.Server {
+	return jsonrpc.NewServer(jsonrpc.EndpointCodecMap{
+		"getProfile": &jsonrpc.EndpointCodec[GetProfileRequest, GetProfileResponse]{
+			Endpoint: set.GetProfileEndpoint,
+			Decode:   decodeJSONRPCRequest[GetProfileRequest],
+			Encode:   encodeJSONRPCResponse[GetProfileResponse],
+		},
+		"putProfile": &jsonrpc.EndpointCodec[PutProfileRequest, PutProfileResponse]{
+			Endpoint: set.PutProfileEndpoint,
+			Decode:   decodeJSONRPCRequest[PutProfileRequest],
+			Encode:   encodeJSONRPCResponse[PutProfileResponse],
+		},
+		"tag": &jsonrpc.EndpointCodec[TagRequest, TagResponse]{
+			Endpoint: set.TagEndpoint,
+			Decode:   decodeJSONRPCRequest[TagRequest],
+			Encode:   encodeJSONRPCResponse[TagResponse],
+		},
+		"count": &jsonrpc.EndpointCodec[CountRequest, CountResponse]{
+			Endpoint: set.CountEndpoint,
+			Decode:   decodeJSONRPCRequest[CountRequest],
+			Encode:   encodeJSONRPCResponse[CountResponse],
+		},
+	}, options...)
+}
+
+// NewJSONRPCClient returns a Set of endpoints calling the server returned by
+// NewJSONRPCHandler on the remote instance, e.g. "host:port".
+func NewJSONRPCClient(instance string) (Set, error) {
+	if !strings.HasPrefix(instance, "http") {
+		instance = "http://" + instance
+	}
+	u, err := url.Parse(instance)
+	if err != nil {
+		return Set{}, err
+	}
+	return Set{
+		GetProfileEndpoint: jsonrpc.NewClient[GetProfileRequest, GetProfileResponse](u, "getProfile").Endpoint(),
+		PutProfileEndpoint: jsonrpc.NewClient[PutProfileRequest, PutProfileResponse](u, "putProfile").Endpoint(),
+		TagEndpoint:        jsonrpc.NewClient[TagRequest, TagResponse](u, "tag").Endpoint(),
+		CountEndpoint:      jsonrpc.NewClient[CountRequest, CountResponse](u, "count").Endpoint(),
+	}, nil
+}
+
+func decodeJSONRPCRequest[REQ any](_ context.Context, params json.RawMessage) (REQ, error) {
+	var request REQ
+	if len(params) == 0 {
+		return request, nil
+	}
+	if err := json.Unmarshal(params, &request); err != nil {
+		return request, &jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: err.Error()}
+	}
+	return request, nil
+}
+
+func encodeJSONRPCResponse[RES endpoint.Failer](_ context.Context, response RES) (json.RawMessage, error) {
+	if err := response.Failed(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
@@ -0,0 +1,700 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generator writes the client package of an OpenAPI document.
+type generator struct {
+	doc     *document
+	pkg     string
+	source  string
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// op is an operation of the document, ready to be generated.
+type op struct {
+	name     string // Go name, e.g. GetPet
+	id       string // operationId, or method and path
+	method   string
+	path     string
+	summary  string
+	params   []param
+	body     *schema
+	bodyType string
+	result   string // Go type of the response
+	empty    bool   // whether the response has no JSON content
+}
+
+type param struct {
+	name     string // as sent
+	in       string // path, query or header
+	field    string // of the request struct
+	typ      string // of the field
+	elem     string // type of the elements, for arrays
+	required bool
+	doc      string
+}
+
+// reserved are the top-level names of the generated package, which schemas
+// can't use.
+var reserved = map[string]bool{
+	"Endpoints": true, "NewEndpoints": true, "APIError": true,
+}
+
+func generate(doc *document, pkg, source string) ([]byte, error) {
+	g := &generator{doc: doc, pkg: pkg, source: source, imports: map[string]bool{}}
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operations found")
+	}
+
+	g.endpoints(ops)
+	for _, o := range ops {
+		g.operation(o)
+	}
+	if err := g.schemas(); err != nil {
+		return nil, err
+	}
+	g.helpers(ops)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by openapigen. DO NOT EDIT.\n// source: %s\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkg)
+	var std, other []string
+	for imp := range g.imports {
+		if strings.Contains(imp, ".") {
+			other = append(other, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	for _, imp := range std {
+		fmt.Fprintf(&out, "%q\n", imp)
+	}
+	out.WriteString("\n")
+	for _, imp := range other {
+		if strings.HasSuffix(imp, "/transport/http") {
+			fmt.Fprintf(&out, "httptransport %q\n", imp)
+			continue
+		}
+		fmt.Fprintf(&out, "%q\n", imp)
+	}
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) use(imports ...string) {
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+// comment writes text as a doc comment, if it isn't empty.
+func (g *generator) comment(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			g.p("// %s", line)
+		}
+	}
+}
+
+func (g *generator) operations() ([]op, error) {
+	paths := make([]string, 0, len(g.doc.Paths))
+	for path := range g.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var (
+		ops   []op
+		names = map[string]string{}
+	)
+	for _, path := range paths {
+		item := g.doc.Paths[path]
+		for _, mo := range item.operations() {
+			o, err := g.newOp(path, mo.method, item.Parameters, mo.op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", mo.method, path, err)
+			}
+			if other, ok := names[o.name]; ok {
+				return nil, fmt.Errorf("operations %s and %s have the same Go name %s", other, o.id, o.name)
+			}
+			names[o.name] = o.id
+			ops = append(ops, o)
+		}
+	}
+	return ops, nil
+}
+
+func (g *generator) newOp(path, method string, common []*parameter, operation *operation) (op, error) {
+	o := op{method: method, path: path, id: operation.OperationID, summary: operation.Summary}
+	if o.id == "" {
+		o.id = method + " " + path
+	}
+	o.name = goName(o.id)
+	if operation.OperationID == "" {
+		o.name = goName(strings.ToLower(method) + " " + path)
+	}
+	if o.summary == "" {
+		o.summary = operation.Description
+	}
+
+	// Parameters of the operation override those of the path with the same
+	// name and location.
+	var (
+		params []*parameter
+		index  = map[string]int{}
+	)
+	for _, p := range append(append([]*parameter{}, common...), operation.Parameters...) {
+		p, err := g.doc.parameter(p)
+		if err != nil {
+			return o, err
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			params[i] = p
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, p)
+	}
+	fields := map[string]bool{"Body": operation.RequestBody != nil}
+	for _, p := range params {
+		if p.In == "cookie" {
+			return o, fmt.Errorf("cookie parameter %s isn't supported", p.Name)
+		}
+		s := p.Schema
+		if s == nil {
+			s = &schema{Type: "string"}
+		}
+		required := p.Required || p.In == "path"
+		pr := param{name: p.Name, in: p.In, required: required, doc: p.Description}
+		pr.field = goName(p.Name)
+		for fields[pr.field] {
+			pr.field += "Param"
+		}
+		fields[pr.field] = true
+		resolved := g.resolve(s)
+		if resolved.Type == "array" {
+			if p.In == "path" {
+				return o, fmt.Errorf("array path parameter %s isn't supported", p.Name)
+			}
+			pr.elem = g.typeOf(resolved.Items)
+			pr.typ = "[]" + pr.elem
+		} else if resolved.Type == "object" {
+			return o, fmt.Errorf("object parameter %s isn't supported", p.Name)
+		} else {
+			pr.typ = g.typeOf(s)
+			if !required {
+				pr.typ = "*" + pr.typ
+			}
+		}
+		o.params = append(o.params, pr)
+	}
+
+	body, err := g.doc.requestBody(operation.RequestBody)
+	if err != nil {
+		return o, err
+	}
+	if body != nil {
+		o.body = jsonSchema(body.Content)
+		if o.body == nil {
+			return o, fmt.Errorf("request body without JSON content isn't supported")
+		}
+		o.bodyType = g.typeOf(o.body)
+		if !body.Required && !nilable(o.bodyType) {
+			o.bodyType = "*" + o.bodyType
+		}
+	}
+
+	o.result, o.empty = "struct{}", true
+	codes := make([]string, 0, len(operation.Responses))
+	for code := range operation.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) > 0 {
+		resp, err := g.doc.response(operation.Responses[codes[0]])
+		if err != nil {
+			return o, err
+		}
+		if s := jsonSchema(resp.Content); s != nil {
+			o.result, o.empty = g.typeOf(s), false
+		}
+	}
+	return o, nil
+}
+
+// resolve follows the reference of s to a component schema, if any.
+func (g *generator) resolve(s *schema) *schema {
+	for s != nil && s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return &schema{}
+		}
+		s = g.doc.Components.Schemas[name]
+	}
+	if s == nil {
+		return &schema{}
+	}
+	return s
+}
+
+// typeOf returns the Go type of the values of a schema.
+func (g *generator) typeOf(s *schema) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return "interface{}"
+		}
+		return schemaName(name)
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.use("time")
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch s.Format {
+		case "int32":
+			return "int32"
+		case "int64":
+			return "int64"
+		}
+		return "int"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.typeOf(s.Items)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		g.use("encoding/json")
+		return "json.RawMessage"
+	}
+	if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+		var b strings.Builder
+		b.WriteString("struct {\n")
+		g.fields(&b, s)
+		b.WriteString("}")
+		return b.String()
+	}
+	if a, ok := s.additional(); ok {
+		return "map[string]" + g.typeOf(a)
+	}
+	if s.Type == "object" {
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// fields writes the fields of an object schema, including those of the
+// schemas it's composed of with allOf.
+func (g *generator) fields(b *strings.Builder, s *schema) {
+	for _, sub := range s.AllOf {
+		g.fields(b, g.resolve(sub))
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop.Description != "" {
+			for _, line := range strings.Split(strings.TrimSpace(prop.Description), "\n") {
+				fmt.Fprintf(b, "// %s\n", strings.TrimSpace(line))
+			}
+		}
+		typ, tag := g.typeOf(prop), name
+		if !required[name] {
+			tag += ",omitempty"
+			if !nilable(typ) {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(b, "%s %s `json:%q`\n", goName(name), typ, tag)
+	}
+}
+
+// nilable reports whether the zero value of typ is nil, so that it needs no
+// pointer to be optional.
+func nilable(typ string) bool {
+	return strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") ||
+		strings.HasPrefix(typ, "*") || typ == "interface{}" || typ == "json.RawMessage"
+}
+
+func (g *generator) schemas() error {
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s, typeName := g.doc.Components.Schemas[name], schemaName(name)
+		if reserved[typeName] {
+			return fmt.Errorf("schema %s conflicts with the generated %s", name, typeName)
+		}
+		g.p("")
+		if s.Description != "" {
+			g.comment(typeName + " is " + lowerFirst(s.Description))
+		} else {
+			g.p("// %s is the %s schema.", typeName, name)
+		}
+		if s.Type == "string" && len(s.Enum) > 0 && s.Format == "" {
+			g.p("type %s string", typeName)
+			g.p("")
+			g.p("// Values of %s.", typeName)
+			g.p("const (")
+			for _, v := range s.Enum {
+				str, ok := v.(string)
+				if !ok {
+					continue
+				}
+				g.p("%s%s %s = %q", typeName, goName(str), typeName, str)
+			}
+			g.p(")")
+			continue
+		}
+		if s.Ref != "" {
+			g.p("type %s = %s", typeName, g.typeOf(s))
+			continue
+		}
+		g.p("type %s %s", typeName, g.typeOf(s))
+	}
+	return nil
+}
+
+func (g *generator) endpoints(ops []op) {
+	g.use("github.com/a69/kit.go/endpoint", "github.com/a69/kit.go/transport/http", "net/http", "net/url", "context")
+	g.p("")
+	g.p("// Endpoints collects an endpoint per operation of the API. Endpoint")
+	g.p("// middlewares may be applied to its fields. Endpoints return an *APIError")
+	g.p("// when the API responds with a status code other than 2xx.")
+	g.p("type Endpoints struct {")
+	for _, o := range ops {
+		g.p("%sEndpoint endpoint.Endpoint[%sRequest, %s]", o.name, o.name, o.result)
+	}
+	g.p("}")
+	g.p("")
+	g.p("// NewEndpoints returns the endpoints calling the API at base, e.g.")
+	g.p("// \"https://api.example.com/v1\". Requests are made with client, or")
+	g.p("// http.DefaultClient if it's nil, after applying the before functions, e.g.")
+	g.p("// to set an authorization header.")
+	g.p("func NewEndpoints(base string, client httptransport.HTTPClient, before ...httptransport.RequestFunc) (Endpoints, error) {")
+	g.p("u, err := url.Parse(base)")
+	g.p("if err != nil {")
+	g.p("return Endpoints{}, err")
+	g.p("}")
+	g.p("if client == nil {")
+	g.p("client = http.DefaultClient")
+	g.p("}")
+	g.p("return Endpoints{")
+	for _, o := range ops {
+		dec := "decodeJSONResponse[" + o.result + "]"
+		if o.empty {
+			dec = "decodeEmptyResponse"
+		}
+		g.p("%sEndpoint: httptransport.NewExplicitClient(", o.name)
+		g.p("make%sRequest(u),", o.name)
+		g.p("%s,", dec)
+		g.p("httptransport.SetClient[%sRequest, %s](client),", o.name, o.result)
+		g.p("httptransport.ClientBefore[%sRequest, %s](before...),", o.name, o.result)
+		g.p(").Endpoint(),")
+	}
+	g.p("}, nil")
+	g.p("}")
+}
+
+func (g *generator) operation(o op) {
+	g.p("")
+	if o.summary != "" {
+		g.comment(o.name + " calls the " + o.id + " operation: " + lowerFirst(o.summary))
+	} else {
+		g.p("// %s calls the %s operation.", o.name, o.id)
+	}
+	g.p("func (e Endpoints) %s(ctx context.Context, request %sRequest) (%s, error) {", o.name, o.name, o.result)
+	g.p("return e.%sEndpoint(ctx, request)", o.name)
+	g.p("}")
+
+	g.p("")
+	g.p("// %sRequest collects the parameters of the %s operation.", o.name, o.id)
+	g.p("type %sRequest struct {", o.name)
+	for _, p := range o.params {
+		g.comment(p.doc)
+		g.p("%s %s // %s parameter %s", p.field, p.typ, p.in, p.name)
+	}
+	if o.body != nil {
+		g.p("Body %s", o.bodyType)
+	}
+	g.p("}")
+
+	g.use("strings")
+	g.p("")
+	g.p("func make%sRequest(base *url.URL) httptransport.CreateRequestFunc[%sRequest] {", o.name, o.name)
+	g.p("return func(ctx context.Context, request %sRequest) (*http.Request, error) {", o.name)
+	g.p("path := %s", g.pathExpr(o))
+	g.p("query := url.Values{}")
+	for _, p := range o.params {
+		if p.in != "query" {
+			continue
+		}
+		g.setValue(p, "query.Add(%q, %s)")
+	}
+	if o.body != nil {
+		g.use("bytes", "encoding/json", "io")
+		g.p("var body io.Reader")
+		if nilable(o.bodyType) {
+			g.p("if request.Body != nil {")
+		} else {
+			g.p("{")
+		}
+		g.p("b, err := json.Marshal(request.Body)")
+		g.p("if err != nil {")
+		g.p("return nil, err")
+		g.p("}")
+		g.p("body = bytes.NewReader(b)")
+		g.p("}")
+		g.p("req, err := http.NewRequestWithContext(ctx, %q, resolve(base, path, query).String(), body)", o.method)
+	} else {
+		g.p("req, err := http.NewRequestWithContext(ctx, %q, resolve(base, path, query).String(), nil)", o.method)
+	}
+	g.p("if err != nil {")
+	g.p("return nil, err")
+	g.p("}")
+	if o.body != nil {
+		g.p("if body != nil {")
+		g.p("req.Header.Set(\"Content-Type\", \"application/json\")")
+		g.p("}")
+	}
+	g.p("req.Header.Set(\"Accept\", \"application/json\")")
+	for _, p := range o.params {
+		if p.in != "header" {
+			continue
+		}
+		g.setValue(p, "req.Header.Add(%q, %s)")
+	}
+	g.p("return req, nil")
+	g.p("}")
+	g.p("}")
+}
+
+// pathExpr returns the expression of the escaped path of the operation.
+func (g *generator) pathExpr(o op) string {
+	var (
+		parts []string
+		path  = o.path
+	)
+	for {
+		i := strings.Index(path, "{")
+		j := strings.Index(path, "}")
+		if i < 0 || j < i {
+			break
+		}
+		if i > 0 {
+			parts = append(parts, strconv.Quote(path[:i]))
+		}
+		name := path[i+1 : j]
+		for _, p := range o.params {
+			if p.in == "path" && p.name == name {
+				parts = append(parts, "url.PathEscape("+g.format(p.typ, "request."+p.field)+")")
+			}
+		}
+		path = path[j+1:]
+	}
+	if path != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(path))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// setValue writes the statements adding the value of a query or header
+// parameter with call, a format taking its name and string value.
+func (g *generator) setValue(p param, call string) {
+	v := "request." + p.field
+	switch {
+	case p.elem != "":
+		g.p("for _, v := range %s {", v)
+		g.p(call, p.name, g.format(p.elem, "v"))
+		g.p("}")
+	case strings.HasPrefix(p.typ, "*"):
+		g.p("if %s != nil {", v)
+		g.p(call, p.name, g.format(strings.TrimPrefix(p.typ, "*"), "*"+v))
+		g.p("}")
+	default:
+		g.p(call, p.name, g.format(p.typ, v))
+	}
+}
+
+// format returns the expression formatting v, of type typ, as a string.
+func (g *generator) format(typ, v string) string {
+	switch typ {
+	case "string":
+		return v
+	case "time.Time":
+		return v + ".Format(time.RFC3339)"
+	}
+	g.use("fmt")
+	return "fmt.Sprint(" + v + ")"
+}
+
+func (g *generator) helpers(ops []op) {
+	g.use("fmt", "io")
+	g.p("")
+	g.p("// APIError is returned by the endpoints when the API responds with a status")
+	g.p("// code other than 2xx.")
+	g.p("type APIError struct {")
+	g.p("Code int")
+	g.p("Body []byte")
+	g.p("}")
+	g.p("")
+	g.p("func (e *APIError) Error() string {")
+	g.p("return fmt.Sprintf(\"%%d %%s: %%s\", e.Code, http.StatusText(e.Code), e.Body)")
+	g.p("}")
+	g.p("")
+	g.p("// StatusCode implements httptransport.StatusCoder.")
+	g.p("func (e *APIError) StatusCode() int { return e.Code }")
+	g.p("")
+	g.p("func decodeError(r *http.Response) error {")
+	g.p("body, _ := io.ReadAll(io.LimitReader(r.Body, 64<<10))")
+	g.p("return &APIError{Code: r.StatusCode, Body: body}")
+	g.p("}")
+
+	var jsonResponses bool
+	for _, o := range ops {
+		jsonResponses = jsonResponses || !o.empty
+	}
+	if jsonResponses {
+		g.use("encoding/json")
+		g.p("")
+		g.p("func decodeJSONResponse[RES any](_ context.Context, r *http.Response) (RES, error) {")
+		g.p("var response RES")
+		g.p("if r.StatusCode < 200 || r.StatusCode > 299 {")
+		g.p("return response, decodeError(r)")
+		g.p("}")
+		g.p("if r.StatusCode == http.StatusNoContent {")
+		g.p("return response, nil")
+		g.p("}")
+		g.p("err := json.NewDecoder(r.Body).Decode(&response)")
+		g.p("return response, err")
+		g.p("}")
+	}
+	g.p("")
+	g.p("func decodeEmptyResponse(_ context.Context, r *http.Response) (struct{}, error) {")
+	g.p("if r.StatusCode < 200 || r.StatusCode > 299 {")
+	g.p("return struct{}{}, decodeError(r)")
+	g.p("}")
+	g.p("return struct{}{}, nil")
+	g.p("}")
+	g.p("")
+	g.p("func resolve(base *url.URL, path string, query url.Values) *url.URL {")
+	g.p("u := *base")
+	g.p("u.RawPath = strings.TrimSuffix(base.EscapedPath(), \"/\") + path")
+	g.p("u.Path, _ = url.PathUnescape(u.RawPath)")
+	g.p("u.RawQuery = query.Encode()")
+	g.p("return &u")
+	g.p("}")
+}
+
+// initialisms are capitalized as a whole in Go names.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true,
+	"ip": true, "json": true, "sql": true, "uri": true, "url": true,
+	"uuid": true, "xml": true,
+}
+
+// goName returns the exported Go name of an identifier of the document, e.g.
+// PetID for pet_id or petId.
+func goName(s string) string {
+	var (
+		b     strings.Builder
+		words []string
+		word  []rune
+		runes = []rune(s)
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// schemaName returns the Go name of the type of a component schema.
+func schemaName(name string) string { return goName(name) }
+
+func lowerFirst(s string) string {
+	s = strings.TrimSpace(s)
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[0]) && !unicode.IsUpper(r[1]) {
+		r[0] = unicode.ToLower(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,73 @@
+// Command openapigen generates a Go kit client package from an OpenAPI 3
+// document, so that third-party REST APIs may be called through endpoints,
+// and get the same middlewares as any other.
+//
+// For each operation, e.g. getPet, it generates a GetPetRequest type, with a
+// field per path, query and header parameter, and a Body field for the JSON
+// request body, if any; a GetPetEndpoint field of the Endpoints struct, with
+// the Go type of the JSON response of its first 2xx status code as response
+// type; and a GetPet method on Endpoints, calling it. Component schemas become
+// named types. Responses with other status codes are returned as *APIError,
+// which implements httptransport.StatusCoder.
+//
+// Usage:
+//
+//	openapigen [flags] document.json
+//
+// The flags are:
+//
+//	-pkg name
+//		name of the generated package (default "client")
+//	-o file
+//		file to write the generated code to (default standard output)
+//
+// Only JSON documents are supported; YAML ones have to be converted first.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	fs := flag.NewFlagSet("openapigen", flag.ExitOnError)
+	var (
+		pkg = fs.String("pkg", "client", "name of the generated package")
+		out = fs.String("o", "", "file to write the generated code to")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: openapigen [flags] document.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := run(fs.Arg(0), *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "openapigen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, pkg, out string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err := parseDocument(b)
+	if err != nil {
+		return err
+	}
+	src, err := generate(doc, pkg, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/a69/kit.go/cmd/openapigen/testdata/petstore"
+)
+
+var update = flag.Bool("update", false, "update the golden file")
+
+func TestGolden(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parseDocument(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err := generate(doc, "petstore", "petstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "petstore", "client.go")
+	if *update {
+		if err := os.WriteFile(golden, have, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, have) {
+		t.Errorf("%s differs from the generated code, run go test -update to update it", golden)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"pet_id":                "PetID",
+		"petId":                 "PetID",
+		"X-Request-ID":          "XRequestID",
+		"listPets":              "ListPets",
+		"get /owners/{name}":    "GetOwnersName",
+		"2fa":                   "X2fa",
+		"html_url":              "HTMLURL",
+		"already_Exported_Name": "AlreadyExportedName",
+	} {
+		if have := goName(in); want != have {
+			t.Errorf("goName(%q): want %q, have %q", in, want, have)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	var (
+		mux  = http.NewServeMux()
+		last *http.Request
+		body []byte
+	)
+	record := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			last = r
+			body, _ = io.ReadAll(r.Body)
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("GET /v1/pets", record(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]petstore.Pet{{ID: 1, Name: "Rex"}})
+	}))
+	mux.HandleFunc("POST /v1/pets", record(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(petstore.Pet{ID: 2, Name: "Tom"})
+	}))
+	mux.HandleFunc("GET /v1/pets/{id}", record(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+	}))
+	mux.HandleFunc("DELETE /v1/pets/{id}", record(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("GET /v1/owners/{name}/pets", record(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]petstore.Pet{r.PathValue("name"): {ID: 3}})
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	e, err := petstore.NewEndpoints(srv.URL+"/v1/", nil, func(ctx context.Context, r *http.Request) context.Context {
+		r.Header.Set("Authorization", "Bearer token")
+		return ctx
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var (
+		limit  = int32(10)
+		status = petstore.PetStatusSold
+	)
+	pets, err := e.ListPets(ctx, petstore.ListPetsRequest{Limit: &limit, Status: &status, Tags: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []petstore.Pet{{ID: 1, Name: "Rex"}}, pets; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := "limit=10&status=sold&tags=a&tags=b", last.URL.RawQuery; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "Bearer token", last.Header.Get("Authorization"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	requestID := "abc"
+	pet, err := e.CreatePet(ctx, petstore.CreatePetRequest{XRequestID: &requestID, Body: petstore.NewPet{Name: "Tom"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int64(2), pet.ID; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := `{"name":"Tom"}`, string(bytes.TrimSpace(body)); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := requestID, last.Header.Get("X-Request-ID"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	_, err = e.GetPet(ctx, petstore.GetPetRequest{PetID: 42})
+	var apiErr *petstore.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("want *APIError, have %v", err)
+	}
+	if want, have := http.StatusNotFound, apiErr.StatusCode(); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	if _, err := e.DeletePet(ctx, petstore.DeletePetRequest{PetID: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	owned, err := e.GetOwnersNamePets(ctx, petstore.GetOwnersNamePetsRequest{Name: "a/b c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := owned["a/b c"]; !ok {
+		t.Errorf("want pets of %q, have %v", "a/b c", owned)
+	}
+	if want, have := "/v1/owners/a%2Fb%20c/pets", last.URL.EscapedPath(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage       = protogen.GoImportPath("context")
+	fmtPackage           = protogen.GoImportPath("fmt")
+	grpcPackage          = protogen.GoImportPath("google.golang.org/grpc")
+	endpointPackage      = protogen.GoImportPath("github.com/a69/kit.go/endpoint")
+	grpctransportPackage = protogen.GoImportPath("github.com/a69/kit.go/transport/grpc")
+)
+
+// generateFile generates the _gokit.pb.go file of f, if it has services.
+func generateFile(gen *protogen.Plugin, f *protogen.File, requireUnimplemented bool) error {
+	if len(f.Services) == 0 {
+		return nil
+	}
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_gokit.pb.go", f.GoImportPath)
+	g.P("// Code generated by protoc-gen-gokit. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	for _, s := range f.Services {
+		if err := generateService(g, s, requireUnimplemented); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unary(m *protogen.Method) bool {
+	return !m.Desc.IsStreamingClient() && !m.Desc.IsStreamingServer()
+}
+
+func generateService(g *protogen.GeneratedFile, s *protogen.Service, requireUnimplemented bool) error {
+	var methods []*protogen.Method
+	for _, m := range s.Methods {
+		if unary(m) {
+			methods = append(methods, m)
+		} else if !requireUnimplemented {
+			return fmt.Errorf("%s: streaming method %s requires require_unimplemented_servers", s.Desc.FullName(), m.GoName)
+		}
+	}
+
+	var (
+		name      = s.GoName
+		svc       = name + "Service"
+		endpoints = name + "Endpoints"
+		server    = name + "KitServer"
+		ctx       = g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	)
+
+	g.P()
+	g.P("// ", svc, " is the interface of the unary methods of the ", name, " service.")
+	g.P("type ", svc, " interface {")
+	for _, m := range methods {
+		g.P(m.Comments.Leading, m.GoName, "(ctx ", ctx, ", req *", m.Input.GoIdent, ") (*", m.Output.GoIdent, ", error)")
+	}
+	g.P("}")
+
+	g.P()
+	g.P("// ", endpoints, " collects an endpoint per unary method of the ", name, " service. It")
+	g.P("// implements ", svc, " too, by calling them.")
+	g.P("type ", endpoints, " struct {")
+	for _, m := range methods {
+		g.P(m.GoName, "Endpoint ", endpointPackage.Ident("Endpoint"), "[*", m.Input.GoIdent, ", *", m.Output.GoIdent, "]")
+	}
+	g.P("}")
+	g.P()
+	g.P("// Make", endpoints, " returns the endpoints calling the methods of svc.")
+	g.P("func Make", endpoints, "(svc ", svc, ") ", endpoints, " {")
+	g.P("return ", endpoints, "{")
+	for _, m := range methods {
+		g.P(m.GoName, "Endpoint: svc.", m.GoName, ",")
+	}
+	g.P("}")
+	g.P("}")
+	for _, m := range methods {
+		g.P()
+		g.P("// ", m.GoName, " implements ", svc, ".")
+		g.P("func (e ", endpoints, ") ", m.GoName, "(ctx ", ctx, ", req *", m.Input.GoIdent, ") (*", m.Output.GoIdent, ", error) {")
+		g.P("return e.", m.GoName, "Endpoint(ctx, req)")
+		g.P("}")
+	}
+
+	g.P()
+	g.P("// ", server, " implements ", name, "Server with a gRPC transport handler per")
+	g.P("// unary method. The handlers may be replaced, e.g. to set server options.")
+	g.P("type ", server, " struct {")
+	if requireUnimplemented {
+		g.P("Unimplemented", name, "Server")
+		g.P()
+	}
+	for _, m := range methods {
+		g.P(m.GoName, "Handler ", grpctransportPackage.Ident("Handler"))
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", name, "Server = (*", server, ")(nil)")
+	g.P()
+	g.P("// New", server, " returns a server calling endpoints.")
+	g.P("func New", server, "(endpoints ", endpoints, ") *", server, " {")
+	g.P("return &", server, "{")
+	for _, m := range methods {
+		g.P(m.GoName, "Handler: ", grpctransportPackage.Ident("NewServer"), "(")
+		g.P("endpoints.", m.GoName, "Endpoint,")
+		g.P("Decode", name, m.GoName, "Request,")
+		g.P("Encode", name, m.GoName, "Response,")
+		g.P("),")
+	}
+	g.P("}")
+	g.P("}")
+	for _, m := range methods {
+		g.P()
+		g.P("// ", m.GoName, " implements ", name, "Server.")
+		g.P("func (s *", server, ") ", m.GoName, "(ctx ", ctx, ", req *", m.Input.GoIdent, ") (*", m.Output.GoIdent, ", error) {")
+		g.P("_, rep, err := s.", m.GoName, "Handler.ServeGRPC(ctx, req)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return rep.(*", m.Output.GoIdent, "), nil")
+		g.P("}")
+	}
+
+	g.P()
+	g.P("// New", name, "KitClient returns endpoints calling the ", name, " service on conn.")
+	g.P("func New", name, "KitClient(conn *", grpcPackage.Ident("ClientConn"), ") ", endpoints, " {")
+	g.P("return ", endpoints, "{")
+	for _, m := range methods {
+		g.P(m.GoName, "Endpoint: ", grpctransportPackage.Ident("NewClient"), "(")
+		g.P("conn,")
+		g.P(fmt.Sprintf("%q,", s.Desc.FullName()))
+		g.P(fmt.Sprintf("%q,", m.Desc.Name()))
+		g.P("Encode", name, m.GoName, "Request,")
+		g.P("Decode", name, m.GoName, "Response,")
+		g.P("&", m.Output.GoIdent, "{},")
+		g.P(").Endpoint(),")
+	}
+	g.P("}")
+	g.P("}")
+
+	for _, m := range methods {
+		var (
+			prefix = name + m.GoName
+			errorf = g.QualifiedGoIdent(fmtPackage.Ident("Errorf"))
+		)
+		g.P()
+		g.P("// Decode", prefix, "Request is the grpctransport.DecodeRequestFunc of the")
+		g.P("// ", m.GoName, " method of the ", name, " service.")
+		g.P("func Decode", prefix, "Request(_ ", ctx, ", req interface{}) (*", m.Input.GoIdent, ", error) {")
+		g.P("r, ok := req.(*", m.Input.GoIdent, ")")
+		g.P("if !ok {")
+		g.P("return nil, ", errorf, "(\"unexpected request type %T\", req)")
+		g.P("}")
+		g.P("return r, nil")
+		g.P("}")
+		g.P()
+		g.P("// Encode", prefix, "Response is the grpctransport.EncodeResponseFunc of the")
+		g.P("// ", m.GoName, " method of the ", name, " service.")
+		g.P("func Encode", prefix, "Response(_ ", ctx, ", rep *", m.Output.GoIdent, ") (interface{}, error) {")
+		g.P("return rep, nil")
+		g.P("}")
+		g.P()
+		g.P("// Encode", prefix, "Request is the grpctransport.EncodeRequestFunc of the")
+		g.P("// ", m.GoName, " method of the ", name, " service.")
+		g.P("func Encode", prefix, "Request(_ ", ctx, ", req *", m.Input.GoIdent, ") (interface{}, error) {")
+		g.P("return req, nil")
+		g.P("}")
+		g.P()
+		g.P("// Decode", prefix, "Response is the grpctransport.DecodeResponseFunc of the")
+		g.P("// ", m.GoName, " method of the ", name, " service.")
+		g.P("func Decode", prefix, "Response(_ ", ctx, ", rep interface{}) (*", m.Output.GoIdent, ", error) {")
+		g.P("r, ok := rep.(*", m.Output.GoIdent, ")")
+		g.P("if !ok {")
+		g.P("return nil, ", errorf, "(\"unexpected reply type %T\", rep)")
+		g.P("}")
+		g.P("return r, nil")
+		g.P("}")
+	}
+	return nil
+}
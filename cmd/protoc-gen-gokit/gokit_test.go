@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/a69/kit.go/examples/addsvc/pb"
+	_ "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var update = flag.Bool("update", false, "update the generated example")
+
+// generated is the file generated from examples/addsvc/pb/addsvc.proto, which
+// is checked in so that it's compiled with the rest of the example.
+const generated = "../../examples/addsvc/pb/addsvc_gokit.pb.go"
+
+func TestAddsvc(t *testing.T) {
+	content, err := run(t, "addsvc.proto", "Maddsvc.proto=github.com/a69/kit.go/examples/addsvc/pb", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile(generated, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, content) {
+		t.Errorf("%s differs from the generated file, run go test -update to update it", generated)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterAddServer(srv, pb.NewAddKitServer(pb.MakeAddEndpoints(addService{})))
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := pb.NewAddKitClient(conn)
+	sum, err := client.Sum(context.Background(), &pb.SumRequest{A: 1, B: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int64(3), sum.V; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	concat, err := client.Concat(context.Background(), &pb.ConcatRequest{A: "a", B: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "ab", concat.V; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+type addService struct{}
+
+func (addService) Sum(_ context.Context, req *pb.SumRequest) (*pb.SumReply, error) {
+	return &pb.SumReply{V: req.A + req.B}, nil
+}
+
+func (addService) Concat(_ context.Context, req *pb.ConcatRequest) (*pb.ConcatReply, error) {
+	return &pb.ConcatReply{V: req.A + req.B}, nil
+}
+
+func TestStreaming(t *testing.T) {
+	// The Health service has a unary Check method and a streaming Watch one.
+	const path = "grpc/health/v1/health.proto"
+	content, err := run(t, path, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"UnimplementedHealthServer",
+		"func (s *HealthKitServer) Check(",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("want %q in generated file", want)
+		}
+	}
+	if strings.Contains(string(content), "Watch") {
+		t.Errorf("want no Watch method in generated file")
+	}
+
+	if _, err := run(t, path, "", false); err == nil {
+		t.Errorf("want error for streaming method without require_unimplemented_servers")
+	}
+}
+
+func run(t *testing.T, path, parameter string, requireUnimplemented bool) ([]byte, error) {
+	t.Helper()
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{path},
+		Parameter:      proto.String(parameter),
+		ProtoFile:      fileAndDeps(fd, map[string]bool{}),
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			if err := generateFile(gen, f, requireUnimplemented); err != nil {
+				return nil, err
+			}
+		}
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	if want, have := 1, len(resp.File); want != have {
+		t.Fatalf("want %d files, have %d", want, have)
+	}
+	return []byte(resp.File[0].GetContent()), nil
+}
+
+// fileAndDeps returns the descriptors of fd and its dependencies, in
+// topological order, as protoc passes them to plugins.
+func fileAndDeps(fd protoreflect.FileDescriptor, seen map[string]bool) []*descriptorpb.FileDescriptorProto {
+	if seen[fd.Path()] {
+		return nil
+	}
+	seen[fd.Path()] = true
+	var fds []*descriptorpb.FileDescriptorProto
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		fds = append(fds, fileAndDeps(imports.Get(i).FileDescriptor, seen)...)
+	}
+	return append(fds, protodesc.ToFileDescriptorProto(fd))
+}
@@ -0,0 +1,50 @@
+// Command protoc-gen-gokit is a protoc plugin generating the Go kit endpoints,
+// gRPC transport server bindings and client of the services of a .proto file.
+//
+// For each service, e.g. Add, it generates, next to the code generated by
+// protoc-gen-go and protoc-gen-go-grpc, in the same package:
+//
+//   - AddService, the interface of the unary methods of the service, with the
+//     request and reply messages as request and response types;
+//   - AddEndpoints, an endpoint per method, and MakeAddEndpoints, returning
+//     the endpoints of an AddService;
+//   - AddKitServer, implementing AddServer with a gRPC transport handler per
+//     method, and NewAddKitServer, returning the one of AddEndpoints;
+//   - NewAddKitClient, returning AddEndpoints calling the service over a
+//     *grpc.ClientConn, which implements AddService;
+//   - the encode and decode functions of each method, which pass the messages
+//     through, so that none have to be written by hand.
+//
+// Streaming methods are left to the embedded UnimplementedAddServer.
+//
+// Usage:
+//
+//	protoc --go_out=. --go-grpc_out=. --gokit_out=. add.proto
+//
+// The require_unimplemented_servers parameter has the same meaning as the
+// one of protoc-gen-go-grpc, and must have the same value:
+//
+//	protoc --go_out=plugins=grpc:. --gokit_out=require_unimplemented_servers=false:. add.proto
+package main
+
+import (
+	"flag"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	var flags flag.FlagSet
+	requireUnimplemented := flags.Bool("require_unimplemented_servers", true, "embed the Unimplemented<Service>Server in servers")
+	protogen.Options{ParamFunc: flags.Set}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f, *requireUnimplemented); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
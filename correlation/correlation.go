@@ -0,0 +1,132 @@
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/log"
+)
+
+// Key identifies a correlation value.
+type Key struct {
+	// Name is used for span tags and log keys, e.g. "request_id".
+	Name string
+
+	// Header is the name of the header, or metadata key, the value is
+	// propagated in, e.g. "X-Request-Id".
+	Header string
+}
+
+// Well-known correlation keys.
+//
+// TenantID and UserID identify who a request is made on behalf of, but
+// they're only as trustworthy as the hop that set them: anyone can send the
+// headers. They're thus not among the DefaultKeys, and should only be
+// extracted, by naming them, from hops known to authenticate the caller,
+// e.g. by services behind an authenticating gateway:
+//
+//	correlation.HTTPToContext(correlation.RequestID, correlation.TenantID, correlation.UserID)
+var (
+	RequestID = Key{Name: "request_id", Header: "X-Request-Id"}
+	TenantID  = Key{Name: "tenant_id", Header: "X-Tenant-Id"}
+	UserID    = Key{Name: "user_id", Header: "X-User-Id"}
+)
+
+// DefaultKeys are the keys propagated by the transport functions when called
+// without keys. They only hold keys that are safe to accept from anyone.
+var DefaultKeys = []Key{RequestID}
+
+type contextKey struct{}
+
+// values is never modified once stored in a context.
+type values map[Key]string
+
+// With returns a context carrying value for key, in addition to the
+// correlation values already in ctx. An empty value removes key.
+func With(ctx context.Context, key Key, value string) context.Context {
+	prev, _ := ctx.Value(contextKey{}).(values)
+	next := make(values, len(prev)+1)
+	for k, v := range prev {
+		next[k] = v
+	}
+	if value == "" {
+		delete(next, key)
+	} else {
+		next[key] = value
+	}
+	return context.WithValue(ctx, contextKey{}, next)
+}
+
+// Value returns the value for key in ctx, or "" if there's none.
+func Value(ctx context.Context, key Key) string {
+	vs, _ := ctx.Value(contextKey{}).(values)
+	return vs[key]
+}
+
+// Range calls f for every correlation value in ctx, in order of key name.
+func Range(ctx context.Context, f func(key Key, value string)) {
+	vs, _ := ctx.Value(contextKey{}).(values)
+	keys := make([]Key, 0, len(vs))
+	for k := range vs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	for _, k := range keys {
+		f(k, vs[k])
+	}
+}
+
+// LogKeyvals returns the correlation values in ctx as keyvals, keyed by name.
+// It's a log.ContextFunc.
+func LogKeyvals(ctx context.Context) []interface{} {
+	var keyvals []interface{}
+	Range(ctx, func(key Key, value string) { keyvals = append(keyvals, key.Name, value) })
+	return keyvals
+}
+
+// Logger returns a logger adding the correlation values in ctx to every log
+// record, keyed by name.
+func Logger(ctx context.Context, logger log.Logger) log.Logger {
+	return log.WithContext(ctx, logger, LogKeyvals)
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// TagFunc sets a correlation value as a tag on the span in ctx, if any, e.g.
+//
+//	func(ctx context.Context, key, value string) {
+//		if span := opentracing.SpanFromContext(ctx); span != nil {
+//			span.SetTag(key, value)
+//		}
+//	}
+type TagFunc func(ctx context.Context, key, value string)
+
+// Middleware returns an endpoint.Middleware that makes sure every request has
+// a request ID, generating one if none was propagated, and passes every
+// correlation value to the TagFuncs, keyed by name. Place it inside the
+// tracing middleware, so that the span is in the context.
+func Middleware[REQ any, RES any](tag ...TagFunc) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			if Value(ctx, RequestID) == "" {
+				ctx = With(ctx, RequestID, NewRequestID())
+			}
+			if len(tag) > 0 {
+				Range(ctx, func(key Key, value string) {
+					for _, f := range tag {
+						f(ctx, key.Name, value)
+					}
+				})
+			}
+			return next(ctx, request)
+		}
+	}
+}
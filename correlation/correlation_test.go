@@ -0,0 +1,152 @@
+package correlation_test
+
+import (
+	"bytes"
+	"context"
+	stdhttp "net/http"
+	"testing"
+
+	stdnats "github.com/nats-io/nats.go"
+	stdamqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/correlation"
+	"github.com/a69/kit.go/log"
+)
+
+func TestWith(t *testing.T) {
+	ctx := correlation.With(context.Background(), correlation.TenantID, "acme")
+	child := correlation.With(ctx, correlation.UserID, "alice")
+
+	if want, have := "", correlation.Value(ctx, correlation.UserID); want != have {
+		t.Errorf("parent modified: want %q, have %q", want, have)
+	}
+	if want, have := "acme", correlation.Value(child, correlation.TenantID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	child = correlation.With(child, correlation.TenantID, "")
+	var names []string
+	correlation.Range(child, func(key correlation.Key, _ string) { names = append(names, key.Name) })
+	if want, have := []string{"user_id"}, names; len(want) != len(have) || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	ctx := correlation.With(context.Background(), correlation.RequestID, "r1")
+	ctx = correlation.With(ctx, correlation.TenantID, "acme")
+
+	r, _ := stdhttp.NewRequest("GET", "http://example.com", nil)
+	correlation.ContextToHTTP(correlation.RequestID, correlation.TenantID, correlation.UserID)(ctx, r)
+	if want, have := "acme", r.Header.Get("X-Tenant-Id"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "", r.Header.Get("X-User-Id"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Only the given keys are propagated.
+	ctx = correlation.HTTPToContext(correlation.RequestID)(context.Background(), r)
+	if want, have := "r1", correlation.Value(ctx, correlation.RequestID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "", correlation.Value(ctx, correlation.TenantID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Identities aren't accepted unless asked for.
+	r.Header.Set("X-User-Id", "mallory")
+	ctx = correlation.HTTPToContext()(context.Background(), r)
+	if want, have := "r1", correlation.Value(ctx, correlation.RequestID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	for _, key := range []correlation.Key{correlation.TenantID, correlation.UserID} {
+		if want, have := "", correlation.Value(ctx, key); want != have {
+			t.Errorf("%s: want %q, have %q", key.Name, want, have)
+		}
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	ctx := correlation.With(context.Background(), correlation.UserID, "alice")
+
+	md := metadata.MD{}
+	correlation.ContextToGRPC(correlation.UserID)(ctx, &md)
+	if want, have := []string{"alice"}, md["x-user-id"]; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx = correlation.GRPCToContext(correlation.UserID)(context.Background(), md)
+	if want, have := "alice", correlation.Value(ctx, correlation.UserID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestNATS(t *testing.T) {
+	ctx := correlation.With(context.Background(), correlation.UserID, "alice")
+
+	msg := &stdnats.Msg{}
+	correlation.ContextToNATS(correlation.UserID)(ctx, msg)
+	ctx = correlation.NATSToContext(correlation.UserID)(context.Background(), msg)
+	if want, have := "alice", correlation.Value(ctx, correlation.UserID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestAMQP(t *testing.T) {
+	ctx := correlation.With(context.Background(), correlation.UserID, "alice")
+
+	pub := &stdamqp.Publishing{}
+	correlation.ContextToAMQP(correlation.UserID)(ctx, pub, nil)
+	d := &stdamqp.Delivery{Headers: pub.Headers}
+	ctx = correlation.AMQPToContext(correlation.UserID)(context.Background(), nil, d)
+	if want, have := "alice", correlation.Value(ctx, correlation.UserID); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tags := map[string]string{}
+	tag := func(_ context.Context, key, value string) { tags[key] = value }
+
+	var requestID string
+	e := correlation.Middleware[any, any](tag)(func(ctx context.Context, _ any) (any, error) {
+		requestID = correlation.Value(ctx, correlation.RequestID)
+		return nil, nil
+	})
+
+	ctx := correlation.With(context.Background(), correlation.TenantID, "acme")
+	if _, err := e(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if requestID == "" {
+		t.Fatal("no request ID generated")
+	}
+	if want, have := requestID, tags["request_id"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "acme", tags["tenant_id"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// A propagated request ID is kept.
+	ctx = correlation.With(ctx, correlation.RequestID, "r1")
+	if _, err := e(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "r1", requestID; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := correlation.With(context.Background(), correlation.UserID, "alice")
+	ctx = correlation.With(ctx, correlation.TenantID, "acme")
+
+	correlation.Logger(ctx, log.NewLogfmtLogger(&buf)).Log("msg", "hello")
+	if want, have := "tenant_id=acme user_id=alice msg=hello\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,12 @@
+// Package correlation propagates correlation values, like the request ID or
+// the tenant and user a request is made on behalf of, across service
+// boundaries, independently of any tracing system.
+//
+// Values are stored in the context with With, and read with Value. Transport
+// functions move them between the context and HTTP headers, gRPC metadata,
+// or NATS and AMQP message headers: XToContext on the receiving side, and
+// ContextToX on the sending side. By default, they only propagate the request
+// ID; identities, like the tenant and the user, must be asked for, and only
+// from trusted hops. The Middleware copies them onto spans, and
+// Logger onto log records, so that they can be searched for.
+package correlation
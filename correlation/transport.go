@@ -0,0 +1,129 @@
+package correlation
+
+import (
+	"context"
+	stdhttp "net/http"
+	"strings"
+
+	stdnats "github.com/nats-io/nats.go"
+	stdamqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/transport/amqp"
+	"github.com/a69/kit.go/transport/grpc"
+	"github.com/a69/kit.go/transport/http"
+	"github.com/a69/kit.go/transport/nats"
+)
+
+// HTTPToContext moves correlation values from request headers to context.
+// Particularly useful for servers.
+func HTTPToContext(keys ...Key) http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		return extract(ctx, keys, r.Header.Get)
+	}
+}
+
+// ContextToHTTP moves correlation values from context to request headers.
+// Particularly useful for clients.
+func ContextToHTTP(keys ...Key) http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		inject(ctx, keys, r.Header.Set)
+		return ctx
+	}
+}
+
+// GRPCToContext moves correlation values from gRPC metadata to context.
+// Particularly useful for servers.
+func GRPCToContext(keys ...Key) grpc.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		return extract(ctx, keys, func(header string) string {
+			if vs := md.Get(header); len(vs) > 0 {
+				return vs[0]
+			}
+			return ""
+		})
+	}
+}
+
+// ContextToGRPC moves correlation values from context to gRPC metadata.
+// Particularly useful for clients.
+func ContextToGRPC(keys ...Key) grpc.ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		// capital "Key" is illegal in HTTP/2.
+		inject(ctx, keys, func(header, value string) { (*md)[strings.ToLower(header)] = []string{value} })
+		return ctx
+	}
+}
+
+// NATSToContext moves correlation values from NATS message headers to
+// context. Particularly useful for subscribers.
+func NATSToContext(keys ...Key) nats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		return extract(ctx, keys, msg.Header.Get)
+	}
+}
+
+// ContextToNATS moves correlation values from context to NATS message
+// headers. Particularly useful for publishers.
+func ContextToNATS(keys ...Key) nats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		inject(ctx, keys, func(header, value string) {
+			if msg.Header == nil {
+				msg.Header = stdnats.Header{}
+			}
+			msg.Header.Set(header, value)
+		})
+		return ctx
+	}
+}
+
+// AMQPToContext moves correlation values from AMQP delivery headers to
+// context. Particularly useful for subscribers.
+func AMQPToContext(keys ...Key) amqp.RequestFunc {
+	return func(ctx context.Context, _ *stdamqp.Publishing, d *stdamqp.Delivery) context.Context {
+		if d == nil {
+			return ctx
+		}
+		return extract(ctx, keys, func(header string) string {
+			v, _ := d.Headers[header].(string)
+			return v
+		})
+	}
+}
+
+// ContextToAMQP moves correlation values from context to AMQP publishing
+// headers. Particularly useful for publishers.
+func ContextToAMQP(keys ...Key) amqp.RequestFunc {
+	return func(ctx context.Context, pub *stdamqp.Publishing, _ *stdamqp.Delivery) context.Context {
+		inject(ctx, keys, func(header, value string) {
+			if pub.Headers == nil {
+				pub.Headers = stdamqp.Table{}
+			}
+			pub.Headers[header] = value
+		})
+		return ctx
+	}
+}
+
+func extract(ctx context.Context, keys []Key, get func(header string) string) context.Context {
+	if len(keys) == 0 {
+		keys = DefaultKeys
+	}
+	for _, key := range keys {
+		if value := get(key.Header); value != "" {
+			ctx = With(ctx, key, value)
+		}
+	}
+	return ctx
+}
+
+func inject(ctx context.Context, keys []Key, set func(header, value string)) {
+	if len(keys) == 0 {
+		keys = DefaultKeys
+	}
+	for _, key := range keys {
+		if value := Value(ctx, key); value != "" {
+			set(key.Header, value)
+		}
+	}
+}
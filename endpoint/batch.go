@@ -0,0 +1,152 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBatchSizeMismatch is returned to every caller in a batch when the batch
+// endpoint returns a different number of responses than it was given
+// requests.
+var ErrBatchSizeMismatch = errors.New("batch endpoint returned wrong number of responses")
+
+// ErrBatchPanic is wrapped by the error returned to every caller in a batch
+// when the batch endpoint panics.
+var ErrBatchPanic = errors.New("batch endpoint panicked")
+
+// Batch returns an endpoint that buffers individual requests and forwards them
+// to the batch endpoint together, fanning the responses back out to each
+// caller. A batch is sent as soon as it holds maxItems requests, or when
+// maxWait has elapsed since its first request, whichever comes first.
+//
+// The batch endpoint must return exactly one response per request, in the same
+// order. If it returns an error, every caller in the batch receives that error.
+//
+// The batch endpoint is invoked in its own goroutine, with the context of the
+// first request in the batch detached from its cancelation, so that one caller
+// giving up doesn't fail the others. Its deadline is dropped too, and replaced
+// by the batch timeout, counted from the time the batch is sent at the latest.
+// If the batch endpoint panics, every caller in the batch receives an error
+// wrapping ErrBatchPanic. Callers honour their own context only while they
+// wait: a caller whose context is done, including the one that filled the
+// batch, stops waiting and receives the context's error, but its request stays
+// in the batch.
+func Batch[REQ any, RES any](maxItems int, maxWait time.Duration, batch Endpoint[[]REQ, []RES], options ...BatchOption) Endpoint[REQ, RES] {
+	if maxItems < 1 {
+		maxItems = 1
+	}
+	b := &batcher[REQ, RES]{
+		batch:    batch,
+		maxItems: maxItems,
+		maxWait:  maxWait,
+		options:  batchOptions{timeout: 30 * time.Second},
+	}
+	for _, option := range options {
+		option(&b.options)
+	}
+	return b.call
+}
+
+// BatchOption sets an optional parameter for Batch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	timeout time.Duration
+}
+
+// BatchTimeout bounds how long the batch endpoint may run, after maxWait.
+// By default, it's 30 seconds. A timeout of zero or less leaves the batch
+// endpoint unbounded.
+func BatchTimeout(d time.Duration) BatchOption {
+	return func(o *batchOptions) { o.timeout = d }
+}
+
+type batcher[REQ any, RES any] struct {
+	batch    Endpoint[[]REQ, []RES]
+	maxItems int
+	maxWait  time.Duration
+	options  batchOptions
+
+	mtx     sync.Mutex
+	pending *pendingBatch[REQ, RES]
+}
+
+type pendingBatch[REQ any, RES any] struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	requests  []REQ
+	timer     *time.Timer
+	done      chan struct{}
+	responses []RES
+	err       error
+}
+
+func (b *batcher[REQ, RES]) call(ctx context.Context, request REQ) (response RES, err error) {
+	b.mtx.Lock()
+	p := b.pending
+	if p == nil {
+		p = &pendingBatch[REQ, RES]{
+			ctx:    context.WithoutCancel(ctx),
+			cancel: func() {},
+			done:   make(chan struct{}),
+		}
+		if b.options.timeout > 0 {
+			p.ctx, p.cancel = context.WithTimeout(p.ctx, b.maxWait+b.options.timeout)
+		}
+		p.timer = time.AfterFunc(b.maxWait, func() { b.flush(p) })
+		b.pending = p
+	}
+	index := len(p.requests)
+	p.requests = append(p.requests, request)
+	if len(p.requests) >= b.maxItems {
+		// Detach the full batch before unlocking, so no later caller can
+		// append to it.
+		b.pending = nil
+		p.timer.Stop()
+		go b.send(p)
+	}
+	b.mtx.Unlock()
+
+	select {
+	case <-p.done:
+		if p.err != nil {
+			return response, p.err
+		}
+		return p.responses[index], nil
+	case <-ctx.Done():
+		return response, ctx.Err()
+	}
+}
+
+// flush detaches p from the batcher, if it's still pending, and sends it. It's
+// called by the timer, which loses to a request that already filled p.
+func (b *batcher[REQ, RES]) flush(p *pendingBatch[REQ, RES]) {
+	b.mtx.Lock()
+	if b.pending != p {
+		b.mtx.Unlock()
+		return
+	}
+	b.pending = nil
+	b.mtx.Unlock()
+
+	b.send(p)
+}
+
+// send invokes the batch endpoint with a detached batch and releases its
+// callers.
+func (b *batcher[REQ, RES]) send(p *pendingBatch[REQ, RES]) {
+	defer close(p.done)
+	defer p.cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			p.responses, p.err = nil, fmt.Errorf("%w: %v", ErrBatchPanic, v)
+		}
+	}()
+	p.responses, p.err = b.batch(p.ctx, p.requests)
+	if p.err == nil && len(p.responses) != len(p.requests) {
+		p.err = ErrBatchSizeMismatch
+	}
+}
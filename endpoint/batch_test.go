@@ -0,0 +1,185 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestBatchMaxItems(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		batches [][]int
+		double  = func(_ context.Context, requests []int) ([]int, error) {
+			mtx.Lock()
+			batches = append(batches, requests)
+			mtx.Unlock()
+			responses := make([]int, len(requests))
+			for i, n := range requests {
+				responses[i] = n * 2
+			}
+			return responses, nil
+		}
+		e  = endpoint.Batch[int, int](3, time.Hour, double)
+		wg sync.WaitGroup
+	)
+
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			res, err := e(context.Background(), n)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want, have := n*2, res; want != have {
+				t.Errorf("want %d, have %d", want, have)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if want, have := 1, len(batches); want != have {
+		t.Errorf("batches: want %d, have %d", want, have)
+	}
+}
+
+func TestBatchMaxWait(t *testing.T) {
+	var (
+		calls int
+		e     = endpoint.Batch[int, int](100, 10*time.Millisecond, func(_ context.Context, requests []int) ([]int, error) {
+			calls++
+			return requests, nil
+		})
+	)
+	res, err := e(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 7, res; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	failure := errors.New("batch failed")
+	e := endpoint.Batch[int, int](1, time.Hour, func(context.Context, []int) ([]int, error) {
+		return nil, failure
+	})
+	if _, err := e(context.Background(), 1); err != failure {
+		t.Errorf("want %v, have %v", failure, err)
+	}
+
+	e = endpoint.Batch[int, int](1, time.Hour, func(context.Context, []int) ([]int, error) {
+		return []int{}, nil
+	})
+	if _, err := e(context.Background(), 1); err != endpoint.ErrBatchSizeMismatch {
+		t.Errorf("want %v, have %v", endpoint.ErrBatchSizeMismatch, err)
+	}
+}
+
+func TestBatchPanic(t *testing.T) {
+	e := endpoint.Batch[int, int](2, time.Hour, func(context.Context, []int) ([]int, error) {
+		panic("boom")
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e(context.Background(), i); !errors.Is(err, endpoint.ErrBatchPanic) {
+				t.Errorf("want %v, have %v", endpoint.ErrBatchPanic, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBatchTimeout(t *testing.T) {
+	e := endpoint.Batch[int, int](1, 10*time.Millisecond, func(ctx context.Context, requests []int) ([]int, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, endpoint.BatchTimeout(10*time.Millisecond))
+
+	// The batch endpoint is bounded even though the caller isn't.
+	if _, err := e(context.Background(), 1); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBatchFillingCallerCanceled(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		e       = endpoint.Batch[int, int](2, time.Hour, func(ctx context.Context, requests []int) ([]int, error) {
+			<-release
+			return requests, ctx.Err()
+		})
+		results = make(chan error, 1)
+	)
+	go func() {
+		_, err := e(context.Background(), 1)
+		results <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // let the first request in
+
+	// The second request fills the batch, then gives up while it runs.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := e(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the filling caller waited for the batch: %v", elapsed)
+	}
+
+	close(release)
+	if err := <-results; err != nil {
+		t.Errorf("other caller: want no error, have %v", err)
+	}
+}
+
+func TestBatchNeverExceedsMaxItems(t *testing.T) {
+	const maxItems = 3
+	var (
+		mtx     sync.Mutex
+		largest int
+		e       = endpoint.Batch[int, int](maxItems, time.Millisecond, func(_ context.Context, requests []int) ([]int, error) {
+			mtx.Lock()
+			if len(requests) > largest {
+				largest = len(requests)
+			}
+			mtx.Unlock()
+			time.Sleep(time.Millisecond) // give other callers a chance to pile in
+			return requests, nil
+		})
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			res, err := e(context.Background(), n)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want, have := n, res; want != have {
+				t.Errorf("want %d, have %d", want, have)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if largest > maxItems {
+		t.Errorf("largest batch: want at most %d, have %d", maxItems, largest)
+	}
+}
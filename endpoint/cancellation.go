@@ -0,0 +1,65 @@
+package endpoint
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Overrun describes an endpoint call that kept running after its context was
+// done.
+type Overrun struct {
+	// Err is the context's error: context.Canceled when the caller went away,
+	// context.DeadlineExceeded when the deadline passed.
+	Err error
+
+	// Overrun is how long the endpoint kept running after the context was
+	// done.
+	Overrun time.Duration
+
+	// Elapsed is the total duration of the call.
+	Elapsed time.Duration
+}
+
+// CancellationAudit returns a Middleware that detects endpoints ignoring
+// context cancellation. Whenever the next endpoint returns more than grace
+// after its context was done, report is called with the details, typically to
+// increment a counter and log the offending method. Calls that return within
+// grace of the cancellation, or whose context is never done, aren't reported.
+//
+// The middleware doesn't change the request or response, so it can be left
+// in place in production to find handlers that keep wasting resources after
+// their clients disconnected.
+func CancellationAudit[REQ any, RES any](grace time.Duration, report func(ctx context.Context, o Overrun)) Middleware[REQ, RES] {
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			var (
+				begin  = time.Now()
+				doneAt atomic.Int64
+				stop   = func() bool { return false }
+			)
+			// A context done before the call starts would only be seen by
+			// the AfterFunc goroutine, which may run after the call returns.
+			if ctx.Err() != nil {
+				doneAt.Store(begin.UnixNano())
+			} else {
+				stop = context.AfterFunc(ctx, func() { doneAt.Store(time.Now().UnixNano()) })
+			}
+			defer func() {
+				stop()
+				at := doneAt.Load()
+				if at == 0 {
+					return
+				}
+				if overrun := time.Since(time.Unix(0, at)); overrun > grace {
+					report(ctx, Overrun{
+						Err:     ctx.Err(),
+						Overrun: overrun,
+						Elapsed: time.Since(begin),
+					})
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestCancellationAudit(t *testing.T) {
+	var reports []endpoint.Overrun
+	audit := endpoint.CancellationAudit[time.Duration, struct{}](20*time.Millisecond, func(_ context.Context, o endpoint.Overrun) {
+		reports = append(reports, o)
+	})
+
+	// stubborn ignores ctx and sleeps for as long as it's asked to.
+	stubborn := audit(func(_ context.Context, d time.Duration) (struct{}, error) {
+		time.Sleep(d)
+		return struct{}{}, nil
+	})
+	// polite returns as soon as ctx is done.
+	polite := audit(func(ctx context.Context, d time.Duration) (struct{}, error) {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+		return struct{}{}, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := polite(ctx, time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if _, err := stubborn(context.Background(), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 0, len(reports); want != have {
+		t.Fatalf("want %d reports, have %d", want, have)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := stubborn(ctx, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, len(reports); want != have {
+		t.Fatalf("want %d reports, have %d", want, have)
+	}
+	if want, have := context.Canceled, reports[0].Err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if have := reports[0].Overrun; have < 50*time.Millisecond || have > reports[0].Elapsed {
+		t.Errorf("overrun: have %v, elapsed %v", have, reports[0].Elapsed)
+	}
+}
+
+func TestCancellationAuditDoneBeforeCall(t *testing.T) {
+	var reports []endpoint.Overrun
+	e := endpoint.CancellationAudit[struct{}, struct{}](10*time.Millisecond, func(_ context.Context, o endpoint.Overrun) {
+		reports = append(reports, o)
+	})(func(context.Context, struct{}) (struct{}, error) {
+		time.Sleep(30 * time.Millisecond)
+		return struct{}{}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 20; i++ {
+		e(ctx, struct{}{})
+	}
+	if want, have := 20, len(reports); want != have {
+		t.Fatalf("want %d reports, have %d", want, have)
+	}
+	if have := reports[0].Overrun; have < 30*time.Millisecond || have > reports[0].Elapsed {
+		t.Errorf("overrun: have %v, elapsed %v", have, reports[0].Elapsed)
+	}
+}
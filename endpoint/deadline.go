@@ -0,0 +1,59 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by the DeadlineBudget middleware when the
+// remaining deadline is too short to cover the local reserve, so calling the
+// next endpoint would be pointless.
+var ErrBudgetExhausted = errors.New("deadline budget exhausted")
+
+type budgetKey struct{}
+
+// WithBudget returns a context carrying a deadline that was propagated from an
+// upstream caller, typically decoded from a transport header. Unlike
+// context.WithDeadline, it doesn't cancel anything by itself; the
+// DeadlineBudget middleware applies it.
+func WithBudget(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, budgetKey{}, deadline)
+}
+
+// Budget returns the effective deadline for ctx: the earlier of the context's
+// own deadline and any deadline propagated with WithBudget.
+func Budget(ctx context.Context) (deadline time.Time, ok bool) {
+	deadline, ok = ctx.Deadline()
+	if propagated, has := ctx.Value(budgetKey{}).(time.Time); has {
+		if !ok || propagated.Before(deadline) {
+			deadline, ok = propagated, true
+		}
+	}
+	return deadline, ok
+}
+
+// DeadlineBudget returns a Middleware that reserves part of the caller's
+// deadline for local work. The next endpoint is called with a context whose
+// deadline is the effective deadline (see Budget) minus reserve, so that
+// downstream calls, and any retries around them, give up early enough to let
+// this service still respond in time. If the remaining time doesn't exceed
+// reserve, the next endpoint isn't called and ErrBudgetExhausted is returned.
+// Requests without a deadline are passed through unchanged.
+func DeadlineBudget[REQ any, RES any](reserve time.Duration) Middleware[REQ, RES] {
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			deadline, ok := Budget(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+			deadline = deadline.Add(-reserve)
+			if !time.Now().Before(deadline) {
+				return response, ErrBudgetExhausted
+			}
+			ctx, cancel := context.WithDeadline(ctx, deadline)
+			defer cancel()
+			return next(ctx, request)
+		}
+	}
+}
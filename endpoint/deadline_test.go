@@ -0,0 +1,63 @@
+package endpoint_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestDeadlineBudgetReserves(t *testing.T) {
+	var (
+		reserve  = 100 * time.Millisecond
+		deadline = time.Now().Add(time.Second)
+		seen     time.Time
+		e        = endpoint.DeadlineBudget[int, int](reserve)(func(ctx context.Context, _ int) (int, error) {
+			seen, _ = ctx.Deadline()
+			return 0, nil
+		})
+	)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if _, err := e(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := deadline.Add(-reserve), seen; !want.Equal(have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestDeadlineBudgetPropagated(t *testing.T) {
+	var (
+		deadline = time.Now().Add(time.Second)
+		seen     time.Time
+		e        = endpoint.DeadlineBudget[int, int](0)(func(ctx context.Context, _ int) (int, error) {
+			seen, _ = ctx.Deadline()
+			return 0, nil
+		})
+	)
+	if _, err := e(endpoint.WithBudget(context.Background(), deadline), 0); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := deadline, seen; !want.Equal(have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestDeadlineBudgetExhausted(t *testing.T) {
+	var (
+		called bool
+		e      = endpoint.DeadlineBudget[int, int](time.Second)(func(context.Context, int) (int, error) {
+			called = true
+			return 0, nil
+		})
+		ctx = endpoint.WithBudget(context.Background(), time.Now().Add(500*time.Millisecond))
+	)
+	if _, err := e(ctx, 0); err != endpoint.ErrBudgetExhausted {
+		t.Errorf("want %v, have %v", endpoint.ErrBudgetExhausted, err)
+	}
+	if called {
+		t.Error("next endpoint was called")
+	}
+}
@@ -0,0 +1,54 @@
+package endpoint
+
+import "context"
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context marking the request as a dry run, typically set
+// by a transport from a request header or query parameter.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// DryRunner may be implemented by requests that carry their own dry-run flag,
+// e.g. a "dry_run" field of a JSON request.
+type DryRunner interface {
+	DryRun() bool
+}
+
+// IsDryRun reports whether request, in ctx, is a dry run: either ctx was
+// marked with WithDryRun, or request implements DryRunner and asks for one.
+func IsDryRun(ctx context.Context, request interface{}) bool {
+	if dry, _ := ctx.Value(dryRunKey{}).(bool); dry {
+		return true
+	}
+	if d, ok := request.(DryRunner); ok {
+		return d.DryRun()
+	}
+	return false
+}
+
+// DryRun returns a Middleware that short-circuits dry runs of side-effecting
+// endpoints, like deletions. For dry runs, the request is validated, and if
+// it's valid, the response is produced by simulate instead of the next
+// endpoint. Other requests are passed to the next endpoint unchanged.
+//
+// Validation that lives in middlewares placed before DryRun in the chain runs
+// in both modes. Validation that lives in the service, which is skipped for
+// dry runs, should be repeated in validate, so that API consumers still get
+// the errors they would get for real. validate may be nil.
+func DryRun[REQ any, RES any](validate func(context.Context, REQ) error, simulate Endpoint[REQ, RES]) Middleware[REQ, RES] {
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			if !IsDryRun(ctx, request) {
+				return next(ctx, request)
+			}
+			if validate != nil {
+				if err := validate(ctx, request); err != nil {
+					return response, err
+				}
+			}
+			return simulate(ctx, request)
+		}
+	}
+}
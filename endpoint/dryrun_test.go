@@ -0,0 +1,59 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+type deleteProfile struct {
+	ID  string
+	Dry bool
+}
+
+func (r deleteProfile) DryRun() bool { return r.Dry }
+
+func TestDryRun(t *testing.T) {
+	var (
+		deleted    []string
+		errInvalid = errors.New("missing ID")
+		validate   = func(_ context.Context, r deleteProfile) error {
+			if r.ID == "" {
+				return errInvalid
+			}
+			return nil
+		}
+		simulate = func(context.Context, deleteProfile) (string, error) { return "would delete", nil }
+		e        = endpoint.DryRun[deleteProfile, string](validate, simulate)(func(_ context.Context, r deleteProfile) (string, error) {
+			deleted = append(deleted, r.ID)
+			return "deleted", nil
+		})
+		ctx = context.Background()
+	)
+
+	for _, tc := range []struct {
+		ctx     context.Context
+		request deleteProfile
+		want    string
+		err     error
+	}{
+		{endpoint.WithDryRun(ctx), deleteProfile{ID: "a"}, "would delete", nil},
+		{ctx, deleteProfile{ID: "a", Dry: true}, "would delete", nil},
+		{endpoint.WithDryRun(ctx), deleteProfile{}, "", errInvalid},
+		{ctx, deleteProfile{ID: "b"}, "deleted", nil},
+	} {
+		have, err := e(tc.ctx, tc.request)
+		if err != tc.err {
+			t.Errorf("%+v: want error %v, have %v", tc.request, tc.err, err)
+		}
+		if tc.want != have {
+			t.Errorf("%+v: want %q, have %q", tc.request, tc.want, have)
+		}
+	}
+
+	if want, have := 1, len(deleted); want != have {
+		t.Errorf("want %d deletions, have %d", want, have)
+	}
+}
@@ -0,0 +1,171 @@
+package endpointtest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// ErrBurstExceeded is returned by Limiter.Wait when the limiter could never
+// allow a request, i.e. when its burst is zero.
+var ErrBurstExceeded = errors.New("rate limiter burst exceeded")
+
+// Clock is a fake clock. Its time only moves when advanced, or when code
+// sleeps through it: sleeps advance the clock right away instead of
+// blocking, and are recorded, so that tests of retries and rate limiters run
+// instantly and can assert on how long the code would have waited.
+type Clock struct {
+	mtx    sync.Mutex
+	now    time.Time
+	waits  []time.Duration
+	timers []*timer
+	added  chan struct{}
+}
+
+type timer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock returns a Clock set to now. A zero now stands for 2000-01-01 UTC,
+// so that tests don't depend on the real time.
+func NewClock(now time.Time) *Clock {
+	if now.IsZero() {
+		now = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Clock{now: now, added: make(chan struct{})}
+}
+
+// Now returns the time of the clock. It can stand in for time.Now.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time of the clock once it was
+// advanced by d. It can stand in for time.After.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	t := &timer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t.c
+	}
+	c.timers = append(c.timers, t)
+	close(c.added)
+	c.added = make(chan struct{})
+	return t.c
+}
+
+// Advance moves the clock forward by d, firing the channels of After that
+// are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.advance(d)
+}
+
+func (c *Clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+	for len(c.timers) > 0 && !c.timers[0].at.After(c.now) {
+		c.timers[0].c <- c.now
+		c.timers = c.timers[1:]
+	}
+}
+
+// BlockUntil blocks until n channels returned by After are waiting for the
+// clock to advance, so that goroutines can be advanced past their timers
+// without racing them.
+func (c *Clock) BlockUntil(n int) {
+	for {
+		c.mtx.Lock()
+		pending, added := len(c.timers), c.added
+		c.mtx.Unlock()
+		if pending >= n {
+			return
+		}
+		<-added
+	}
+}
+
+// Sleep records a wait of d and advances the clock by d, without blocking.
+// It returns the error of ctx if it's done.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.wait(d)
+	return nil
+}
+
+func (c *Clock) wait(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.waits = append(c.waits, d)
+	if d > 0 {
+		c.advance(d)
+	}
+}
+
+// Waits returns the durations slept through the clock so far, in order.
+func (c *Clock) Waits() []time.Duration {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+// Backoff wraps b so that its waits are slept through the clock, and
+// returns a zero backoff to the caller. Given to endpoint.Retry, it makes
+// retries immediate while Waits reports the backoff they would have used.
+// A nil b never waits.
+func (c *Clock) Backoff(b endpoint.Backoff) endpoint.Backoff {
+	return func(attempt int) time.Duration {
+		var d time.Duration
+		if b != nil {
+			d = b(attempt)
+		}
+		c.wait(d)
+		return 0
+	}
+}
+
+// Limiter is a token bucket rate limiter driven by a Clock. It implements
+// both the Allower and the Waiter interfaces of package ratelimit.
+type Limiter struct {
+	clock   *Clock
+	limiter *rate.Limiter
+}
+
+// Limiter returns a Limiter allowing events up to limit per second, with
+// bursts of up to burst events, measured by the clock.
+func (c *Clock) Limiter(limit rate.Limit, burst int) *Limiter {
+	return &Limiter{clock: c, limiter: rate.NewLimiter(limit, burst)}
+}
+
+// Allow reports whether an event may happen at the time of the clock.
+func (l *Limiter) Allow() bool {
+	return l.limiter.AllowN(l.clock.Now(), 1)
+}
+
+// Wait sleeps through the clock until an event may happen.
+func (l *Limiter) Wait(ctx context.Context) error {
+	now := l.clock.Now()
+	r := l.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return ErrBurstExceeded
+	}
+	if err := l.clock.Sleep(ctx, r.DelayFrom(now)); err != nil {
+		r.CancelAt(now)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package endpointtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/endpoint/endpointtest"
+	"github.com/a69/kit.go/ratelimit"
+)
+
+func TestClockBackoff(t *testing.T) {
+	var (
+		clock = endpointtest.NewClock(time.Time{})
+		start = clock.Now()
+		fail  = errors.New("fail")
+		e     = endpointtest.NewRecorder[int, int](0, fail)
+	)
+	retry := endpoint.Retry[int, int](4, clock.Backoff(endpoint.ExponentialBackoff(time.Second, 0)), nil)
+	if _, err := retry(e.Endpoint())(context.Background(), 1); !errors.Is(err, fail) {
+		t.Errorf("want %v, have %v", fail, err)
+	}
+	if want, have := 4, len(e.Calls()); want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if want, have := "[1s 2s 4s]", fmt.Sprint(clock.Waits()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 7*time.Second, clock.Now().Sub(start); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestClockLimiter(t *testing.T) {
+	var (
+		clock   = endpointtest.NewClock(time.Time{})
+		limiter = clock.Limiter(2, 1)
+		e       = ratelimit.NewDelayingLimiter[int, int](limiter)(endpointtest.NewRecorder[int, int](0, nil).Endpoint())
+	)
+	for i := 0; i < 3; i++ {
+		if _, err := e(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := "[0s 500ms 500ms]", fmt.Sprint(clock.Waits()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if limiter.Allow() {
+		t.Error("want limited, have allowed")
+	}
+	clock.Advance(time.Second)
+	if !limiter.Allow() {
+		t.Error("want allowed, have limited")
+	}
+}
+
+func TestClockAfter(t *testing.T) {
+	clock := endpointtest.NewClock(time.Time{})
+	done := make(chan time.Time)
+	go func() { done <- <-clock.After(time.Minute) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("fired early")
+	default:
+	}
+	clock.Advance(30 * time.Second)
+	if want, have := clock.Now(), <-done; !want.Equal(have) {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
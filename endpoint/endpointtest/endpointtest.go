@@ -0,0 +1,70 @@
+// Package endpointtest provides helpers for testing endpoints, middlewares
+// and transports: fakes recording what they're given, golden files for
+// encoders and decoders, a harness asserting the order in which middlewares
+// run, and a fake clock for the middlewares that wait, such as retries and
+// rate limiters.
+//
+// Everything here is safe for concurrent use, so the fakes can be shared by
+// the goroutines of the code under test.
+package endpointtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// Call is a request an endpoint was called with.
+type Call[REQ any] struct {
+	Context context.Context
+	Request REQ
+}
+
+// Recorder is a fake endpoint recording its calls, and answering them with
+// a canned response or a function of the request.
+type Recorder[REQ any, RES any] struct {
+	mtx   sync.Mutex
+	calls []Call[REQ]
+	fn    endpoint.Endpoint[REQ, RES]
+}
+
+// NewRecorder returns a Recorder answering every call with response and err.
+func NewRecorder[REQ any, RES any](response RES, err error) *Recorder[REQ, RES] {
+	return &Recorder[REQ, RES]{
+		fn: func(context.Context, REQ) (RES, error) { return response, err },
+	}
+}
+
+// NewRecorderFunc returns a Recorder answering calls with fn.
+func NewRecorderFunc[REQ any, RES any](fn endpoint.Endpoint[REQ, RES]) *Recorder[REQ, RES] {
+	return &Recorder[REQ, RES]{fn: fn}
+}
+
+// Endpoint returns the endpoint recording calls.
+func (r *Recorder[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
+	return func(ctx context.Context, request REQ) (RES, error) {
+		r.mtx.Lock()
+		r.calls = append(r.calls, Call[REQ]{Context: ctx, Request: request})
+		r.mtx.Unlock()
+		return r.fn(ctx, request)
+	}
+}
+
+// Calls returns the calls made so far, in order.
+func (r *Recorder[REQ, RES]) Calls() []Call[REQ] {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]Call[REQ](nil), r.calls...)
+}
+
+// Requests returns the requests of the calls made so far, in order.
+func (r *Recorder[REQ, RES]) Requests() []REQ {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	requests := make([]REQ, len(r.calls))
+	for i, call := range r.calls {
+		requests[i] = call.Request
+	}
+	return requests
+}
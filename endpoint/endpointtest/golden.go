@@ -0,0 +1,139 @@
+package endpointtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+var update = flag.Bool("update-golden", false, "update the golden files of endpointtest")
+
+// Golden compares have with the content of the golden file at path, usually
+// under testdata. Run the tests with -update-golden to write have to the
+// file instead, after checking the differences are expected.
+func Golden(t testing.TB, path string, have []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, have, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update-golden to create it)", err)
+	}
+	if !bytes.Equal(want, have) {
+		t.Errorf("%s: want\n%s\nhave\n%s", path, want, have)
+	}
+}
+
+// GoldenRequest encodes request with enc onto a POST to http://localhost/,
+// and compares the result with the golden file at path. Requests are
+// written as on the wire, with headers sorted and LF line endings.
+func GoldenRequest[REQ any](t testing.TB, path string, enc httptransport.EncodeRequestFunc[REQ], request REQ) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc(context.Background(), req, &request); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var body []byte
+	if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s HTTP/1.1\n", req.Method, req.URL.RequestURI())
+	fmt.Fprintf(&buf, "Host: %s\n", req.Host)
+	writeMessage(&buf, req.Header, body)
+	Golden(t, path, buf.Bytes())
+}
+
+// GoldenResponse encodes response with enc, and compares the result with
+// the golden file at path, in the format of GoldenRequest.
+func GoldenResponse[RES any](t testing.TB, path string, enc httptransport.EncodeResponseFunc[RES], response RES) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := enc(context.Background(), rec, response); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "HTTP/1.1 %d %s\n", rec.Code, http.StatusText(rec.Code))
+	writeMessage(&buf, rec.Header(), rec.Body.Bytes())
+	Golden(t, path, buf.Bytes())
+}
+
+// DecodeGoldenRequest reads the HTTP request of the golden file at path, in
+// the format of GoldenRequest, and returns it decoded by dec.
+func DecodeGoldenRequest[REQ any](t testing.TB, path string, dec httptransport.DecodeRequestFunc[REQ]) REQ {
+	t.Helper()
+	head, body := readMessage(t, path)
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(head)))
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	req.Body, req.ContentLength = io.NopCloser(strings.NewReader(body)), int64(len(body))
+	request, err := dec(context.Background(), req)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return request
+}
+
+// DecodeGoldenResponse reads the HTTP response of the golden file at path,
+// in the format of GoldenResponse, and returns it decoded by dec.
+func DecodeGoldenResponse[RES any](t testing.TB, path string, dec httptransport.DecodeResponseFunc[RES]) RES {
+	t.Helper()
+	head, body := readMessage(t, path)
+	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(head)), nil)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	resp.Body, resp.ContentLength = io.NopCloser(strings.NewReader(body)), int64(len(body))
+	response, err := dec(context.Background(), resp)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return response
+}
+
+func writeMessage(buf *bytes.Buffer, header http.Header, body []byte) {
+	var h bytes.Buffer
+	header.Write(&h)
+	buf.WriteString(strings.ReplaceAll(h.String(), "\r\n", "\n"))
+	buf.WriteString("\n")
+	buf.Write(body)
+}
+
+// readMessage splits the golden file at path into the head of the message,
+// ending with a blank line, and its body.
+func readMessage(t testing.TB, path string) (head, body string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, body, ok := strings.Cut(string(b), "\n\n")
+	if !ok {
+		t.Fatalf("%s: no blank line after the headers", path)
+	}
+	return head + "\n\n", body
+}
@@ -0,0 +1,35 @@
+package endpointtest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint/endpointtest"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestGoldenRequest(t *testing.T) {
+	endpointtest.GoldenRequest(t, "testdata/sum_request.golden", encodeSum, sum{A: 1, B: 2})
+
+	have := endpointtest.DecodeGoldenRequest(t, "testdata/sum_request.golden", func(_ context.Context, r *http.Request) (s sum, err error) {
+		err = json.NewDecoder(r.Body).Decode(&s)
+		return s, err
+	})
+	if want := (sum{A: 1, B: 2}); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
+
+func TestGoldenResponse(t *testing.T) {
+	endpointtest.GoldenResponse(t, "testdata/sum_response.golden", httptransport.EncodeJSONResponse[int], 3)
+
+	have := endpointtest.DecodeGoldenResponse(t, "testdata/sum_response.golden", func(_ context.Context, r *http.Response) (n int, err error) {
+		err = json.NewDecoder(r.Body).Decode(&n)
+		return n, err
+	})
+	if want := 3; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
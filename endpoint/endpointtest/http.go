@@ -0,0 +1,80 @@
+package endpointtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// HTTPRequest is a request sent through an HTTPClient. Body holds the bytes
+// read from the request body, so it can be asserted on after the request
+// was sent.
+type HTTPRequest struct {
+	*http.Request
+	Body []byte
+}
+
+// HTTPClient is a fake transport for HTTP clients, recording the requests
+// encoded by them and answering with a handler, without going through the
+// network. It implements the HTTPClient interface of transport/http.
+type HTTPClient struct {
+	handler  http.Handler
+	mtx      sync.Mutex
+	requests []HTTPRequest
+}
+
+// NewHTTPClient returns an HTTPClient answering requests with the handler.
+// A nil handler answers every request with an empty 200.
+func NewHTTPClient(handler http.Handler) *HTTPClient {
+	if handler == nil {
+		handler = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	}
+	return &HTTPClient{handler: handler}
+}
+
+// Do records the request and serves it with the handler.
+func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	c.mtx.Lock()
+	c.requests = append(c.requests, HTTPRequest{Request: req, Body: body})
+	c.mtx.Unlock()
+
+	served := req.Clone(req.Context())
+	served.Body = io.NopCloser(bytes.NewReader(body))
+	if served.RequestURI == "" {
+		served.RequestURI = req.URL.RequestURI()
+	}
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, served)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// Requests returns the requests sent so far, in order.
+func (c *HTTPClient) Requests() []HTTPRequest {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]HTTPRequest(nil), c.requests...)
+}
+
+// Last returns the last request sent, or nil if none was.
+func (c *HTTPClient) Last() *HTTPRequest {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if len(c.requests) == 0 {
+		return nil
+	}
+	req := c.requests[len(c.requests)-1]
+	return &req
+}
@@ -0,0 +1,64 @@
+package endpointtest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint/endpointtest"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+type sum struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func encodeSum(_ context.Context, r *http.Request, s *sum) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(s); err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Body = io.NopCloser(&buf)
+	return nil
+}
+
+func TestHTTPClient(t *testing.T) {
+	fake := endpointtest.NewHTTPClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var s sum
+		json.NewDecoder(r.Body).Decode(&s)
+		json.NewEncoder(w).Encode(s.A + s.B)
+	}))
+	u, _ := url.Parse("http://adder/sum")
+	client := httptransport.NewClient[sum, int](
+		http.MethodPost, u,
+		encodeSum,
+		func(_ context.Context, resp *http.Response) (n int, err error) {
+			err = json.NewDecoder(resp.Body).Decode(&n)
+			return n, err
+		},
+		httptransport.SetClient[sum, int](fake),
+	)
+	n, err := client.Endpoint()(context.Background(), sum{A: 1, B: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 3, n; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	req := fake.Last()
+	if req == nil {
+		t.Fatal("no request recorded")
+	}
+	if want, have := "/sum", req.URL.Path; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := `{"a":1,"b":2}`+"\n", string(req.Body); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
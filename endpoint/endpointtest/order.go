@@ -0,0 +1,89 @@
+package endpointtest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// Order records the order in which requests go through probes, to assert
+// on the order of middlewares in a chain, e.g. that authentication runs
+// before rate limiting. Insert probes between the middlewares of the chain,
+// and compare the names they recorded with Assert.
+type Order struct {
+	mtx   sync.Mutex
+	names []string
+}
+
+// Probe returns a Middleware recording name in o whenever a request goes
+// through it, and "name returned" when the response does.
+func Probe[REQ any, RES any](o *Order, name string) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			o.record(name)
+			defer o.record(name + " returned")
+			return next(ctx, request)
+		}
+	}
+}
+
+// Step returns an endpoint recording name in o, and answering with the zero
+// response. It stands for the innermost endpoint of a chain.
+func Step[REQ any, RES any](o *Order, name string) endpoint.Endpoint[REQ, RES] {
+	return func(context.Context, REQ) (response RES, err error) {
+		o.record(name)
+		return response, nil
+	}
+}
+
+func (o *Order) record(name string) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.names = append(o.names, name)
+}
+
+// Names returns the names recorded so far, in order.
+func (o *Order) Names() []string {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return append([]string(nil), o.names...)
+}
+
+// Reset forgets the names recorded so far.
+func (o *Order) Reset() {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.names = nil
+}
+
+// Assert fails the test unless the names recorded so far are want, in
+// order. Names recorded on the way back, with the " returned" suffix, are
+// ignored unless want contains some.
+func (o *Order) Assert(t testing.TB, want ...string) {
+	t.Helper()
+	have := o.Names()
+	if !returns(want) {
+		filtered := have[:0]
+		for _, name := range have {
+			if !strings.HasSuffix(name, " returned") {
+				filtered = append(filtered, name)
+			}
+		}
+		have = filtered
+	}
+	if strings.Join(want, ", ") != strings.Join(have, ", ") {
+		t.Errorf("want order [%s], have [%s]", strings.Join(want, ", "), strings.Join(have, ", "))
+	}
+}
+
+func returns(names []string) bool {
+	for _, name := range names {
+		if strings.HasSuffix(name, " returned") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package endpointtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/endpoint/endpointtest"
+)
+
+func TestOrder(t *testing.T) {
+	var (
+		o     endpointtest.Order
+		chain = endpoint.Chain(
+			endpointtest.Probe[int, int](&o, "auth"),
+			endpointtest.Probe[int, int](&o, "ratelimit"),
+		)
+		e = chain(endpointtest.Step[int, int](&o, "service"))
+	)
+	if _, err := e(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	o.Assert(t, "auth", "ratelimit", "service")
+	o.Assert(t, "auth", "ratelimit", "service", "ratelimit returned", "auth returned")
+
+	o.Reset()
+	if want, have := 0, len(o.Names()); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
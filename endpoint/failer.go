@@ -0,0 +1,60 @@
+package endpoint
+
+import "context"
+
+// Failed returns the business error carried by response, if response
+// implements Failer. Otherwise it returns nil.
+func Failed(response interface{}) error {
+	if f, ok := response.(Failer); ok {
+		return f.Failed()
+	}
+	return nil
+}
+
+// FailuresAsErrors returns a Middleware that promotes selected business errors
+// to endpoint errors. If the response implements Failer and the classifier
+// returns true for its error, that error is returned alongside the response
+// as the endpoint error. A nil classifier promotes every business error.
+//
+// Promoting is useful for business errors that should count as failures to
+// circuit breakers, retries and tracing, e.g. a dependency reporting that it's
+// unavailable, while leaving errors like "not found" in the response.
+func FailuresAsErrors[REQ any, RES any](classifier Classifier) Middleware[REQ, RES] {
+	if classifier == nil {
+		classifier = func(error) bool { return true }
+	}
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+			if failure := Failed(response); failure != nil && classifier(failure) {
+				return response, failure
+			}
+			return response, nil
+		}
+	}
+}
+
+// ErrorsAsFailures returns a Middleware that demotes selected endpoint errors
+// to business errors. For every endpoint error, demote is asked for a response
+// carrying it; if demote returns true, that response is returned with a nil
+// error. Otherwise the endpoint error is returned unchanged.
+//
+// Demoting is useful for errors that a transport should encode as a regular,
+// if unsuccessful, response, rather than as a transport-level failure.
+func ErrorsAsFailures[REQ any, RES any](demote func(error) (RES, bool)) Middleware[REQ, RES] {
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			response, err := next(ctx, request)
+			if err == nil {
+				return response, nil
+			}
+			if demoted, ok := demote(err); ok {
+				return demoted, nil
+			}
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+type failingResponse struct{ Err error }
+
+func (r failingResponse) Failed() error { return r.Err }
+
+var (
+	errNotFound    = errors.New("not found")
+	errUnavailable = errors.New("unavailable")
+)
+
+func TestFailuresAsErrors(t *testing.T) {
+	var (
+		promote = func(err error) bool { return err == errUnavailable }
+		mw      = endpoint.FailuresAsErrors[error, failingResponse](promote)
+		e       = mw(func(_ context.Context, failure error) (failingResponse, error) {
+			return failingResponse{Err: failure}, nil
+		})
+	)
+	for _, tc := range []struct {
+		failure error
+		want    error
+	}{
+		{nil, nil},
+		{errNotFound, nil},
+		{errUnavailable, errUnavailable},
+	} {
+		if _, have := e(context.Background(), tc.failure); tc.want != have {
+			t.Errorf("%v: want %v, have %v", tc.failure, tc.want, have)
+		}
+	}
+}
+
+func TestErrorsAsFailures(t *testing.T) {
+	var (
+		demote = func(err error) (failingResponse, bool) {
+			return failingResponse{Err: err}, err == errNotFound
+		}
+		mw = endpoint.ErrorsAsFailures[error, failingResponse](demote)
+		e  = mw(func(_ context.Context, err error) (failingResponse, error) {
+			return failingResponse{}, err
+		})
+	)
+
+	response, err := e(context.Background(), errNotFound)
+	if err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	if want, have := errNotFound, endpoint.Failed(response); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	if _, err := e(context.Background(), errUnavailable); err != errUnavailable {
+		t.Errorf("want %v, have %v", errUnavailable, err)
+	}
+}
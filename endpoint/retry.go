@@ -0,0 +1,123 @@
+package endpoint
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns how long to wait before the given retry attempt. The first
+// retry (i.e. the second call to the endpoint) is attempt 1.
+type Backoff func(attempt int) time.Duration
+
+// Classifier reports whether an error returned by an endpoint is worth
+// retrying.
+type Classifier func(err error) bool
+
+// ConstantBackoff returns a Backoff that always waits d between attempts.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// ExponentialBackoff returns a Backoff that starts at base and doubles with
+// every attempt, never exceeding max. A max of zero means no upper bound, in
+// which case durations saturate at the longest time.Duration rather than
+// overflowing.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt; i++ {
+			if d > math.MaxInt64/2 {
+				d = math.MaxInt64
+				break
+			}
+			d *= 2
+			if max > 0 && d >= max {
+				return max
+			}
+		}
+		if max > 0 && d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// Jitter wraps a Backoff and randomizes each duration by up to the given
+// fraction in either direction. A fraction of 0.2 turns a 1s wait into a wait
+// between 800ms and 1.2s. Jitter keeps many clients that failed at the same
+// moment from retrying in lockstep.
+func Jitter(b Backoff, fraction float64) Backoff {
+	return func(attempt int) time.Duration {
+		d := b(attempt)
+		if fraction <= 0 || d <= 0 {
+			return d
+		}
+		delta := time.Duration(math.MaxInt64)
+		if f := fraction * float64(d); f < math.MaxInt64 {
+			delta = time.Duration(f)
+		}
+		// Clamp the range to [0, math.MaxInt64], so a saturated backoff can't
+		// overflow into a negative wait.
+		lo := d - min(delta, d)
+		hi := d + min(delta, math.MaxInt64-d)
+		if hi == lo {
+			return lo
+		}
+		return lo + time.Duration(rand.Int63n(int64(hi-lo)))
+	}
+}
+
+// RetryAll is a Classifier that considers every error retryable.
+func RetryAll(error) bool { return true }
+
+// Retry returns a Middleware that calls the wrapped endpoint up to attempts
+// times, until it succeeds or returns an error the classifier rejects. Between
+// attempts it waits according to backoff; a nil backoff retries immediately,
+// and a nil classifier retries every error.
+//
+// Unlike sd/lb.Retry, Retry needs no Balancer or Endpointer, so it can be used
+// by plain clients that talk to a single endpoint. Retrying stops early if the
+// context is done, in which case the context's error is returned.
+func Retry[REQ any, RES any](attempts int, backoff Backoff, classifier Classifier) Middleware[REQ, RES] {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if backoff == nil {
+		backoff = ConstantBackoff(0)
+	}
+	if classifier == nil {
+		classifier = RetryAll
+	}
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			for i := 0; i < attempts; i++ {
+				if i > 0 {
+					if err := sleep(ctx, backoff(i)); err != nil {
+						return response, err
+					}
+				}
+				response, err = next(ctx, request)
+				if err == nil || !classifier(err) {
+					return response, err
+				}
+			}
+			return response, err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
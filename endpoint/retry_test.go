@@ -0,0 +1,124 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestRetrySucceedsEventually(t *testing.T) {
+	var calls int
+	e := endpoint.Retry[int, int](3, nil, nil)(func(_ context.Context, n int) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("transient")
+		}
+		return n * 2, nil
+	})
+	res, err := e(context.Background(), 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 42, res; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 3, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var (
+		calls int
+		final = errors.New("final")
+	)
+	e := endpoint.Retry[int, int](4, nil, nil)(func(context.Context, int) (int, error) {
+		calls++
+		return 0, final
+	})
+	if _, err := e(context.Background(), 0); err != final {
+		t.Errorf("want %v, have %v", final, err)
+	}
+	if want, have := 4, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestRetryClassifier(t *testing.T) {
+	var (
+		calls     int
+		permanent = errors.New("permanent")
+		retryable = func(err error) bool { return err != permanent }
+	)
+	e := endpoint.Retry[int, int](5, nil, retryable)(func(context.Context, int) (int, error) {
+		calls++
+		return 0, permanent
+	})
+	if _, err := e(context.Background(), 0); err != permanent {
+		t.Errorf("want %v, have %v", permanent, err)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	e := endpoint.Retry[int, int](100, endpoint.ConstantBackoff(time.Hour), nil)(func(context.Context, int) (int, error) {
+		return 0, errors.New("fail")
+	})
+	if _, err := e(ctx, 0); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := endpoint.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for attempt, want := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		4: 50 * time.Millisecond,
+		9: 50 * time.Millisecond,
+	} {
+		if have := b(attempt); want != have {
+			t.Errorf("attempt %d: want %v, have %v", attempt, want, have)
+		}
+	}
+}
+
+func TestExponentialBackoffOverflow(t *testing.T) {
+	b := endpoint.ExponentialBackoff(time.Second, 0)
+	for _, attempt := range []int{40, 64, 65, 1000} {
+		if want, have := time.Duration(math.MaxInt64), b(attempt); want != have {
+			t.Errorf("attempt %d: want %v, have %v", attempt, want, have)
+		}
+	}
+	for attempt := 1; attempt < 100; attempt++ {
+		if b(attempt+1) < b(attempt) {
+			t.Fatalf("attempt %d: backoff decreased from %v to %v", attempt+1, b(attempt), b(attempt+1))
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	b := endpoint.Jitter(endpoint.ConstantBackoff(100*time.Millisecond), 0.5)
+	for i := 0; i < 100; i++ {
+		if d := b(1); d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("jittered duration %v out of range", d)
+		}
+	}
+
+	// A saturated backoff must not overflow into a negative wait.
+	b = endpoint.Jitter(endpoint.ExponentialBackoff(time.Second, 0), 0.5)
+	for i := 0; i < 100; i++ {
+		if d := b(64); d <= 0 {
+			t.Fatalf("jittered saturated duration %v isn't positive", d)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package endpoint
+
+import (
+	"context"
+)
+
+// StreamEndpoint is the server-streaming counterpart of Endpoint. It yields
+// any number of responses to a single request, by calling send with each of
+// them in turn, and returns once it's done. If send fails, e.g. because the
+// client went away, the endpoint should stop and return the error.
+type StreamEndpoint[REQ any, RES any] func(ctx context.Context, request REQ, send func(RES) error) error
+
+// StreamMiddleware is a chainable behavior modifier for stream endpoints.
+type StreamMiddleware[REQ any, RES any] func(StreamEndpoint[REQ, RES]) StreamEndpoint[REQ, RES]
+
+// StreamChain is the StreamMiddleware counterpart of Chain. Requests will
+// traverse the middlewares in the order they're declared.
+func StreamChain[REQ any, RES any](outer StreamMiddleware[REQ, RES], others ...StreamMiddleware[REQ, RES]) StreamMiddleware[REQ, RES] {
+	return func(next StreamEndpoint[REQ, RES]) StreamEndpoint[REQ, RES] {
+		for i := len(others) - 1; i >= 0; i-- { // reverse
+			next = others[i](next)
+		}
+		return outer(next)
+	}
+}
+
+// Stream adapts a Middleware, e.g. for tracing, logging or rate limiting, to
+// stream endpoints. The middleware sees each stream as a single call, lasting
+// until the stream endpoint returns, with the error of the stream and an
+// empty response, so that e.g. a span covers the whole stream, and a limiter
+// admits a number of streams rather than of responses. The middleware must
+// call the next endpoint with its context, or one derived from it.
+func Stream[REQ any, RES any](m Middleware[REQ, struct{}]) StreamMiddleware[REQ, RES] {
+	return func(next StreamEndpoint[REQ, RES]) StreamEndpoint[REQ, RES] {
+		e := m(func(ctx context.Context, request REQ) (struct{}, error) {
+			send := ctx.Value(streamSendKey{}).(func(RES) error)
+			return struct{}{}, next(ctx, request, send)
+		})
+		return func(ctx context.Context, request REQ, send func(RES) error) error {
+			_, err := e(context.WithValue(ctx, streamSendKey{}, send), request)
+			return err
+		}
+	}
+}
+
+type streamSendKey struct{}
+
+// FromChannel returns a StreamEndpoint sending the responses received from
+// the channel returned by f, until it's closed. The producer must close the
+// channel once it's done, and stop sending once ctx is done, which happens
+// when the stream fails to send or is canceled.
+func FromChannel[REQ any, RES any](f func(ctx context.Context, request REQ) (<-chan RES, error)) StreamEndpoint[REQ, RES] {
+	return func(ctx context.Context, request REQ, send func(RES) error) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		responses, err := f(ctx, request)
+		if err != nil {
+			return err
+		}
+		for {
+			select {
+			case response, ok := <-responses:
+				if !ok {
+					return nil
+				}
+				if err := send(response); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Collect returns an Endpoint calling the stream endpoint, and returning all
+// of its responses at once, e.g. for transports without streaming.
+func Collect[REQ any, RES any](e StreamEndpoint[REQ, RES]) Endpoint[REQ, []RES] {
+	return func(ctx context.Context, request REQ) ([]RES, error) {
+		var responses []RES
+		err := e(ctx, request, func(response RES) error {
+			responses = append(responses, response)
+			return nil
+		})
+		return responses, err
+	}
+}
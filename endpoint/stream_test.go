@@ -0,0 +1,102 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func count(_ context.Context, n int, send func(int) error) error {
+	for i := 1; i <= n; i++ {
+		if err := send(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	var (
+		calls  int
+		errs   []error
+		logged = func(next endpoint.Endpoint[int, struct{}]) endpoint.Endpoint[int, struct{}] {
+			return func(ctx context.Context, request int) (struct{}, error) {
+				calls++
+				response, err := next(ctx, request)
+				errs = append(errs, err)
+				return response, err
+			}
+		}
+		double = func(next endpoint.StreamEndpoint[int, int]) endpoint.StreamEndpoint[int, int] {
+			return func(ctx context.Context, request int, send func(int) error) error {
+				return next(ctx, request, func(n int) error { return send(2 * n) })
+			}
+		}
+		e = endpoint.StreamChain(endpoint.Stream[int, int](logged), double)(count)
+	)
+
+	responses, err := endpoint.Collect(e)(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []int{2, 4, 6}, responses; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	errStop := errors.New("stop")
+	if want, have := errStop, e(context.Background(), 3, func(int) error { return errStop }); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if want, have := []error{nil, errStop}, errs; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestFromChannel(t *testing.T) {
+	var producerDone = make(chan struct{})
+	e := endpoint.FromChannel(func(ctx context.Context, n int) (<-chan int, error) {
+		if n < 0 {
+			return nil, errors.New("negative")
+		}
+		c := make(chan int)
+		go func() {
+			defer close(producerDone)
+			defer close(c)
+			for i := 1; i <= n; i++ {
+				select {
+				case c <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return c, nil
+	})
+
+	responses, err := endpoint.Collect(e)(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []int{1, 2, 3}, responses; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	<-producerDone
+
+	// A failing send stops the producer.
+	producerDone = make(chan struct{})
+	errStop := errors.New("stop")
+	if want, have := errStop, e(context.Background(), 100, func(int) error { return errStop }); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	<-producerDone
+
+	if _, err := endpoint.Collect(e)(context.Background(), -1); err == nil {
+		t.Error("want error, have none")
+	}
+}
@@ -0,0 +1,37 @@
+package endpoint
+
+import (
+	"context"
+)
+
+// Validator is implemented by requests that can check their own fields.
+type Validator interface {
+	Validate() error
+}
+
+// Validate returns a middleware rejecting the requests for which validate
+// returns an error, without calling the next endpoint. If validate is nil,
+// requests implementing Validator are checked with their Validate method, and
+// others are passed through.
+//
+// Validation errors are typically one or more transport.DecodeErrors, joined
+// with errors.Join, which transports render as client errors listing the
+// invalid fields.
+func Validate[REQ any, RES any](validate func(context.Context, REQ) error) Middleware[REQ, RES] {
+	if validate == nil {
+		validate = func(_ context.Context, request REQ) error {
+			if v, ok := any(request).(Validator); ok {
+				return v.Validate()
+			}
+			return nil
+		}
+	}
+	return func(next Endpoint[REQ, RES]) Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			if err := validate(ctx, request); err != nil {
+				return response, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+var errNoName = errors.New("missing name")
+
+type createProfile struct{ Name string }
+
+func (r createProfile) Validate() error {
+	if r.Name == "" {
+		return errNoName
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	var calls int
+	next := func(_ context.Context, r createProfile) (string, error) {
+		calls++
+		return r.Name, nil
+	}
+
+	e := endpoint.Validate[createProfile, string](nil)(next)
+	if _, err := e(context.Background(), createProfile{}); !errors.Is(err, errNoName) {
+		t.Errorf("want %v, have %v", errNoName, err)
+	}
+	if want, have := 0, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if response, err := e(context.Background(), createProfile{Name: "alice"}); err != nil || response != "alice" {
+		t.Errorf("want alice, have %q (%v)", response, err)
+	}
+
+	errTooLong := errors.New("name too long")
+	e = endpoint.Validate[createProfile, string](func(_ context.Context, r createProfile) error {
+		if len(r.Name) > 3 {
+			return errTooLong
+		}
+		return nil
+	})(next)
+	if _, err := e(context.Background(), createProfile{Name: "alice"}); !errors.Is(err, errTooLong) {
+		t.Errorf("want %v, have %v", errTooLong, err)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
@@ -0,0 +1,57 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Warmer may be implemented by clients, factories and other components that
+// own endpoints, to prepare them before the first real request, e.g. by
+// establishing connections, filling caches or triggering lazy
+// initialization. Warm should return once the component is ready, or when
+// ctx is done.
+type Warmer interface {
+	Warm(ctx context.Context) error
+}
+
+// WarmerFunc is an adapter to allow the use of ordinary functions as Warmers.
+type WarmerFunc func(ctx context.Context) error
+
+// Warm implements Warmer.
+func (f WarmerFunc) Warm(ctx context.Context) error { return f(ctx) }
+
+// Prime returns a Warmer that calls the endpoint n times, one after the other,
+// with the given request. It's a simple way to warm endpoints whose owners
+// don't implement Warmer themselves. Priming stops at the first error.
+func Prime[REQ any, RES any](e Endpoint[REQ, RES], request REQ, n int) Warmer {
+	return WarmerFunc(func(ctx context.Context) error {
+		for i := 0; i < n; i++ {
+			if _, err := e(ctx, request); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Warm runs all the warmers concurrently, and returns once they have all
+// finished. It's meant to be called at startup, before the service registers
+// itself with service discovery or reports itself as ready, so that the first
+// real requests don't pay for cold connections. The returned error joins the
+// errors of all the warmers that failed.
+func Warm(ctx context.Context, warmers ...Warmer) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(warmers))
+	)
+	for i, w := range warmers {
+		wg.Add(1)
+		go func(i int, w Warmer) {
+			defer wg.Done()
+			errs[i] = w.Warm(ctx)
+		}(i, w)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,34 @@
+package endpoint_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestWarm(t *testing.T) {
+	var (
+		calls int64
+		e     = func(context.Context, int) (int, error) {
+			atomic.AddInt64(&calls, 1)
+			return 0, nil
+		}
+		failure = errors.New("cannot connect")
+		failing = endpoint.WarmerFunc(func(context.Context) error { return failure })
+	)
+
+	if err := endpoint.Warm(context.Background(), endpoint.Prime[int, int](e, 0, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int64(3), atomic.LoadInt64(&calls); want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+
+	err := endpoint.Warm(context.Background(), endpoint.Prime[int, int](e, 0, 1), failing)
+	if !errors.Is(err, failure) {
+		t.Errorf("want %v, have %v", failure, err)
+	}
+}
@@ -0,0 +1,172 @@
+// Code generated by protoc-gen-gokit. DO NOT EDIT.
+// source: addsvc.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	endpoint "github.com/a69/kit.go/endpoint"
+	grpc "github.com/a69/kit.go/transport/grpc"
+	grpc1 "google.golang.org/grpc"
+)
+
+// AddService is the interface of the unary methods of the Add service.
+type AddService interface {
+	Sum(ctx context.Context, req *SumRequest) (*SumReply, error)
+	Concat(ctx context.Context, req *ConcatRequest) (*ConcatReply, error)
+}
+
+// AddEndpoints collects an endpoint per unary method of the Add service. It
+// implements AddService too, by calling them.
+type AddEndpoints struct {
+	SumEndpoint    endpoint.Endpoint[*SumRequest, *SumReply]
+	ConcatEndpoint endpoint.Endpoint[*ConcatRequest, *ConcatReply]
+}
+
+// MakeAddEndpoints returns the endpoints calling the methods of svc.
+func MakeAddEndpoints(svc AddService) AddEndpoints {
+	return AddEndpoints{
+		SumEndpoint:    svc.Sum,
+		ConcatEndpoint: svc.Concat,
+	}
+}
+
+// Sum implements AddService.
+func (e AddEndpoints) Sum(ctx context.Context, req *SumRequest) (*SumReply, error) {
+	return e.SumEndpoint(ctx, req)
+}
+
+// Concat implements AddService.
+func (e AddEndpoints) Concat(ctx context.Context, req *ConcatRequest) (*ConcatReply, error) {
+	return e.ConcatEndpoint(ctx, req)
+}
+
+// AddKitServer implements AddServer with a gRPC transport handler per
+// unary method. The handlers may be replaced, e.g. to set server options.
+type AddKitServer struct {
+	SumHandler    grpc.Handler
+	ConcatHandler grpc.Handler
+}
+
+var _ AddServer = (*AddKitServer)(nil)
+
+// NewAddKitServer returns a server calling endpoints.
+func NewAddKitServer(endpoints AddEndpoints) *AddKitServer {
+	return &AddKitServer{
+		SumHandler: grpc.NewServer(
+			endpoints.SumEndpoint,
+			DecodeAddSumRequest,
+			EncodeAddSumResponse,
+		),
+		ConcatHandler: grpc.NewServer(
+			endpoints.ConcatEndpoint,
+			DecodeAddConcatRequest,
+			EncodeAddConcatResponse,
+		),
+	}
+}
+
+// Sum implements AddServer.
+func (s *AddKitServer) Sum(ctx context.Context, req *SumRequest) (*SumReply, error) {
+	_, rep, err := s.SumHandler.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return rep.(*SumReply), nil
+}
+
+// Concat implements AddServer.
+func (s *AddKitServer) Concat(ctx context.Context, req *ConcatRequest) (*ConcatReply, error) {
+	_, rep, err := s.ConcatHandler.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return rep.(*ConcatReply), nil
+}
+
+// NewAddKitClient returns endpoints calling the Add service on conn.
+func NewAddKitClient(conn *grpc1.ClientConn) AddEndpoints {
+	return AddEndpoints{
+		SumEndpoint: grpc.NewClient(
+			conn,
+			"pb.Add",
+			"Sum",
+			EncodeAddSumRequest,
+			DecodeAddSumResponse,
+			&SumReply{},
+		).Endpoint(),
+		ConcatEndpoint: grpc.NewClient(
+			conn,
+			"pb.Add",
+			"Concat",
+			EncodeAddConcatRequest,
+			DecodeAddConcatResponse,
+			&ConcatReply{},
+		).Endpoint(),
+	}
+}
+
+// DecodeAddSumRequest is the grpctransport.DecodeRequestFunc of the
+// Sum method of the Add service.
+func DecodeAddSumRequest(_ context.Context, req interface{}) (*SumRequest, error) {
+	r, ok := req.(*SumRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected request type %T", req)
+	}
+	return r, nil
+}
+
+// EncodeAddSumResponse is the grpctransport.EncodeResponseFunc of the
+// Sum method of the Add service.
+func EncodeAddSumResponse(_ context.Context, rep *SumReply) (interface{}, error) {
+	return rep, nil
+}
+
+// EncodeAddSumRequest is the grpctransport.EncodeRequestFunc of the
+// Sum method of the Add service.
+func EncodeAddSumRequest(_ context.Context, req *SumRequest) (interface{}, error) {
+	return req, nil
+}
+
+// DecodeAddSumResponse is the grpctransport.DecodeResponseFunc of the
+// Sum method of the Add service.
+func DecodeAddSumResponse(_ context.Context, rep interface{}) (*SumReply, error) {
+	r, ok := rep.(*SumReply)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply type %T", rep)
+	}
+	return r, nil
+}
+
+// DecodeAddConcatRequest is the grpctransport.DecodeRequestFunc of the
+// Concat method of the Add service.
+func DecodeAddConcatRequest(_ context.Context, req interface{}) (*ConcatRequest, error) {
+	r, ok := req.(*ConcatRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected request type %T", req)
+	}
+	return r, nil
+}
+
+// EncodeAddConcatResponse is the grpctransport.EncodeResponseFunc of the
+// Concat method of the Add service.
+func EncodeAddConcatResponse(_ context.Context, rep *ConcatReply) (interface{}, error) {
+	return rep, nil
+}
+
+// EncodeAddConcatRequest is the grpctransport.EncodeRequestFunc of the
+// Concat method of the Add service.
+func EncodeAddConcatRequest(_ context.Context, req *ConcatRequest) (interface{}, error) {
+	return req, nil
+}
+
+// DecodeAddConcatResponse is the grpctransport.DecodeResponseFunc of the
+// Concat method of the Add service.
+func DecodeAddConcatResponse(_ context.Context, rep interface{}) (*ConcatReply, error) {
+	r, ok := rep.(*ConcatReply)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply type %T", rep)
+	}
+	return r, nil
+}
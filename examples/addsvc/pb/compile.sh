@@ -8,7 +8,10 @@
 # Update protoc Go bindings via
 #  go get -u github.com/golang/protobuf/{proto,protoc-gen-go}
 #
+# Install the Go kit bindings generator via
+#  go install github.com/a69/kit.go/cmd/protoc-gen-gokit
+#
 # See also
 #  https://github.com/grpc/grpc-go/tree/master/examples
 
-protoc addsvc.proto --go_out=plugins=grpc:.
+protoc addsvc.proto --go_out=plugins=grpc:. --gokit_out=require_unimplemented_servers=false:.
@@ -0,0 +1,21 @@
+package addtransport
+
+import (
+	"net/http"
+
+	"github.com/a69/kit.go/examples/addsvc/pkg/addservice"
+	kiterrors "github.com/a69/kit.go/transport/errors"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+// errorRegistry maps the business errors of the service to the HTTP status
+// codes, and JSON-RPC codes, the client sees.
+var errorRegistry = kiterrors.NewRegistry()
+
+func init() {
+	for _, err := range []error{addservice.ErrTwoZeroes, addservice.ErrMaxSizeExceeded, addservice.ErrIntOverflow} {
+		errorRegistry.Register(err, kiterrors.Mapping{HTTP: http.StatusBadRequest, JSONRPC: jsonrpc.InvalidParamsError})
+	}
+}
+
+var errorEncoder = errorRegistry.HTTPErrorEncoder()
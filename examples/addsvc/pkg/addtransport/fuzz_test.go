@@ -0,0 +1,32 @@
+//go:build fuzz
+// +build fuzz
+
+package addtransport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/a69/kit.go/examples/addsvc/pkg/addendpoint"
+	"github.com/a69/kit.go/transport/fuzztest"
+)
+
+// Run with go test -tags fuzz -fuzz FuzzDecoders.
+func FuzzDecoders(f *testing.F) {
+	var set fuzztest.Set
+	set.Register("http sum", fuzztest.HTTPBody(http.MethodPost, "/sum", decodeHTTPSumRequest),
+		[]byte(`{"a":1,"b":2}`),
+	)
+	set.Register("http concat", fuzztest.HTTPBody(http.MethodPost, "/concat", decodeHTTPConcatRequest),
+		[]byte(`{"a":"1","b":"2"}`),
+	)
+	set.Register("jsonrpc sum", fuzztest.Bytes(func(ctx context.Context, b []byte) (addendpoint.SumRequest, error) {
+		return decodeSumRequest(ctx, json.RawMessage(b))
+	}), []byte(`{"a":1,"b":2}`))
+	set.Register("jsonrpc concat", fuzztest.Bytes(func(ctx context.Context, b []byte) (addendpoint.ConcatRequest, error) {
+		return decodeConcatRequest(ctx, json.RawMessage(b))
+	}), []byte(`{"a":"1","b":"2"}`))
+	set.Fuzz(f)
+}
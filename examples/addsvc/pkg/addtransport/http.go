@@ -121,19 +121,6 @@ func copyURL(base *url.URL, path string) *url.URL {
 	return &next
 }
 
-func errorEncoder(_ context.Context, err error, w http.ResponseWriter) {
-	w.WriteHeader(err2code(err))
-	json.NewEncoder(w).Encode(errorWrapper{Error: err.Error()})
-}
-
-func err2code(err error) int {
-	switch err {
-	case addservice.ErrTwoZeroes, addservice.ErrMaxSizeExceeded, addservice.ErrIntOverflow:
-		return http.StatusBadRequest
-	}
-	return http.StatusInternalServerError
-}
-
 func errorDecoder(r *http.Response) error {
 	var w errorWrapper
 	if err := json.NewDecoder(r.Body).Decode(&w); err != nil {
@@ -211,7 +198,9 @@ func encodeHTTPGenericResponse[RES any](ctx context.Context, w http.ResponseWrit
 	return json.NewEncoder(w).Encode(response)
 }
 func makeHTTPServerOptions[REQ any, RES any](zipkinTracer *stdzipkin.Tracer) []httptransport.ServerOption[REQ, RES] {
-	var options []httptransport.ServerOption[REQ, RES]
+	options := []httptransport.ServerOption[REQ, RES]{
+		httptransport.ServerErrorEncoder[REQ, RES](errorEncoder),
+	}
 
 	if zipkinTracer != nil {
 		// Zipkin GRPC Client Trace can either be instantiated per gRPC method with a
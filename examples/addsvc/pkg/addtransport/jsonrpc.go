@@ -24,6 +24,7 @@ func NewJSONRPCHandler(endpoints addendpoint.Set, logger log.Logger) *jsonrpc.Se
 	handler := jsonrpc.NewServer(
 		makeEndpointCodecMap(endpoints),
 		jsonrpc.ServerErrorLogger(logger),
+		jsonrpc.ServerErrorEncoder(errorRegistry.JSONRPCErrorEncoder()),
 	)
 	return handler
 }
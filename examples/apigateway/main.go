@@ -184,9 +184,9 @@ func addsvcFactory[REQ any, RES any](makeEndpoint func(addservice.Service) endpo
 		// Notice that the addsvc gRPC client converts the connection to a
 		// complete addsvc, and we just throw away everything except the method
 		// we're interested in. A smarter factory would mux multiple methods
-		// over the same connection. But that would require more work to manage
-		// the returned io.Closer, e.g. reference counting. Since this is for
-		// the purposes of demonstration, we'll just keep it simple.
+		// over the same connection, which sd.SharedConns and sd.SharedFactory
+		// do by reference counting the connection. Since this is for the
+		// purposes of demonstration, we'll just keep it simple.
 
 		return endpoint, conn, nil
 	}
@@ -0,0 +1,27 @@
+package ordersvc
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MakeAdminHandler returns the handler for the admin listener, which is kept
+// off the public one: /healthz always succeeds while the process is up,
+// /readyz succeeds once ready returns nil, and /metrics serves Prometheus
+// metrics. Extra handlers, like an sd/lb.Drainer, can be mounted on it.
+func MakeAdminHandler(ready func() error) *http.ServeMux {
+	m := http.NewServeMux()
+	m.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	m.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	m.Handle("/metrics", promhttp.Handler())
+	return m
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	stdconsul "github.com/hashicorp/consul/api"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.opencensus.io/trace"
+	"golang.org/x/time/rate"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/metrics/prometheus"
+	"github.com/a69/kit.go/ratelimit"
+	"github.com/a69/kit.go/run"
+	consulsd "github.com/a69/kit.go/sd/consul"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+	"github.com/a69/kit.go/examples/ordersvc/ordersvckit"
+)
+
+func main() {
+	fs := flag.NewFlagSet("ordersvc", flag.ExitOnError)
+	var (
+		httpAddr      = fs.String("http-addr", ":8080", "HTTP listen address")
+		adminAddr     = fs.String("admin-addr", ":8081", "Admin (health and metrics) listen address")
+		natsURL       = fs.String("nats-url", nats.DefaultURL, "NATS server URL, with JetStream enabled")
+		consulAddr    = fs.String("consul-addr", "", "Register with the Consul agent at this address, if set")
+		advertiseAddr = fs.String("advertise-addr", "127.0.0.1:8080", "host:port registered with Consul")
+		redisAddr     = fs.String("redis-addr", "", "Share the rate limit of orders placed through the Redis server at this address, if set")
+		placeRate     = fs.Int("place-rate", 100, "Maximum orders placed per second")
+		sampleRate    = fs.Float64("trace-sample-rate", 0.01, "Fraction of requests to trace")
+	)
+	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
+	fs.Parse(os.Args[1:])
+
+	// Logs are JSON, with the field names expected by OpenTelemetry pipelines.
+	var logger log.Logger
+	{
+		logger = log.NewJSONLoggerPreset(os.Stderr, log.PresetOTel)
+		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		logger = log.With(logger, "caller", log.DefaultCaller)
+	}
+
+	// Spans are sampled at the given rate. The service faces clients
+	// directly, so it doesn't let them force sampling with the
+	// tracing.DebugHeader. Register an exporter to ship them somewhere, e.g.
+	// an OpenTelemetry collector, with trace.RegisterExporter.
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(*sampleRate)})
+
+	var (
+		duration = prometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "example",
+			Subsystem: "ordersvc",
+			Name:      "request_duration_seconds",
+			Help:      "Endpoint duration in seconds.",
+		}, []string{"method"})
+		phases = prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: "example",
+			Subsystem: "ordersvc",
+			Name:      "request_phase_seconds",
+			Help:      "Time spent decoding, in the endpoint, and encoding, in seconds.",
+		}, []string{"method", "phase"})
+	)
+
+	// Order events go through a JetStream work queue.
+	nc, err := nats.Connect(*natsURL)
+	if err != nil {
+		logger.Log("during", "Connect", "err", err)
+		os.Exit(1)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		logger.Log("during", "JetStream", "err", err)
+		os.Exit(1)
+	}
+	consumer, err := setupStreams(js)
+	if err != nil {
+		logger.Log("during", "setupStreams", "err", err)
+		os.Exit(1)
+	}
+
+	// Orders placed are rate limited across all instances through Redis, if
+	// configured, or else by each instance on its own.
+	var placeLimit ratelimit.Allower
+	if *redisAddr != "" {
+		client := redis.NewClient(&redis.Options{Addr: *redisAddr})
+		defer client.Close()
+		placeLimit = ordersvc.NewRedisLimiter(client, "ordersvc:place", *placeRate, time.Second, log.With(logger, "component", "Redis"))
+	} else {
+		placeLimit = rate.NewLimiter(rate.Limit(*placeRate), *placeRate)
+	}
+
+	var (
+		service     = ordersvc.NewInmemService(ordersvc.NewJetStreamEvents(js))
+		endpoints   = ordersvckit.MakeServerEndpoints(service, placeLimit, duration)
+		httpHandler = ordersvckit.MakeHTTPHandler(endpoints, phases, log.With(logger, "component", "HTTP"))
+		workQueue   = ordersvckit.MakeConfirmationWorkQueue(endpoints, js, log.With(logger, "component", "JetStream"))
+	)
+
+	// The service is ready once it consumes events; until then, /readyz
+	// fails, and orchestrators keep it out of rotation.
+	var consuming atomic.Bool
+	adminHandler := ordersvc.MakeAdminHandler(func() error {
+		if !consuming.Load() {
+			return errors.New("not consuming events yet")
+		}
+		return nil
+	})
+
+	// Each component is an actor, started in order: the public listener
+	// only once events are consumed, and the Consul registration last. On a
+	// failure or a signal, they're stopped in reverse order, so that clients
+	// stop routing to this instance before its listeners are closed.
+	r := run.New(run.Logger(log.With(logger, "component", "run")))
+	{
+		adminListener, err := net.Listen("tcp", *adminAddr)
+		if err != nil {
+			logger.Log("transport", "admin/HTTP", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		r.Add(run.HTTPServer("admin/HTTP", &http.Server{Handler: adminHandler}, adminListener))
+	}
+	{
+		consumerReady := make(chan struct{})
+		a := run.Worker("JetStream", func(ctx context.Context) error {
+			cc, err := consumer.Consume(workQueue.HandleMsg)
+			if err != nil {
+				return err
+			}
+			defer cc.Drain()
+			consuming.Store(true)
+			defer consuming.Store(false)
+			close(consumerReady)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		a.Ready = consumerReady
+		r.Add(a)
+	}
+	{
+		httpListener, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			logger.Log("transport", "HTTP", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		r.Add(run.HTTPServer("HTTP", &http.Server{Handler: httpHandler}, httpListener))
+	}
+	if *consulAddr != "" {
+		registrar, err := newRegistrar(*consulAddr, *advertiseAddr, logger)
+		if err != nil {
+			logger.Log("during", "newRegistrar", "err", err)
+			os.Exit(1)
+		}
+		r.Add(run.Registrar(registrar, 5*time.Second))
+	}
+	if err := r.Run(context.Background()); err != nil {
+		logger.Log("exit", err)
+		os.Exit(1)
+	}
+}
+
+func setupStreams(js jetstream.JetStream) (jetstream.Consumer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return ordersvc.SetupStreams(ctx, js)
+}
+
+func newRegistrar(consulAddr, advertiseAddr string, logger log.Logger) (*consulsd.TTLRegistrar, error) {
+	host, portStr, err := net.SplitHostPort(advertiseAddr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	consulClient, err := stdconsul.NewClient(&stdconsul.Config{Address: consulAddr})
+	if err != nil {
+		return nil, err
+	}
+	client := consulsd.NewClient(consulClient).(consulsd.TTLClient)
+	return consulsd.NewTTLRegistrar(client, &stdconsul.AgentServiceRegistration{
+		ID:      "ordersvc-" + advertiseAddr,
+		Name:    "ordersvc",
+		Address: host,
+		Port:    port,
+	}, 10*time.Second, logger), nil
+}
+
+func usageFor(fs *flag.FlagSet, short string) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "USAGE\n")
+		fmt.Fprintf(os.Stderr, "  %s\n", short)
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "FLAGS\n")
+		w := tabwriter.NewWriter(os.Stderr, 0, 2, 2, ' ', 0)
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "\t-%s %s\t%s\n", f.Name, f.DefValue, f.Usage)
+		})
+		w.Flush()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+}
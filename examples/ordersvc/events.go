@@ -0,0 +1,78 @@
+package ordersvc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStream names used for order events. The events stream uses the
+// work-queue retention policy, so every event is consumed exactly once, by
+// whichever ordersvc instance gets to it first. Events that keep failing are
+// kept in the failed stream for a week, for operators to inspect or replay.
+const (
+	StreamName         = "ORDERS"
+	FailedStreamName   = "ORDERS_FAILED"
+	ConsumerName       = "ordersvc-confirmations"
+	SubjectOrderPlaced = "orders.placed"
+	SubjectFailed      = "orders.failed"
+)
+
+// StreamConfig returns the configuration of the order events stream.
+func StreamConfig() jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:      StreamName,
+		Subjects:  []string{SubjectOrderPlaced},
+		Retention: jetstream.WorkQueuePolicy,
+	}
+}
+
+// FailedStreamConfig returns the configuration of the stream of failed order
+// events. Without it, routing events to SubjectFailed fails, and they're
+// redelivered forever.
+func FailedStreamConfig() jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:     FailedStreamName,
+		Subjects: []string{SubjectFailed},
+		MaxAge:   7 * 24 * time.Hour,
+	}
+}
+
+// SetupStreams creates or updates the streams of order events, and returns
+// the durable consumer of the confirmation work queue.
+func SetupStreams(ctx context.Context, js jetstream.JetStream) (jetstream.Consumer, error) {
+	for _, cfg := range []jetstream.StreamConfig{StreamConfig(), FailedStreamConfig()} {
+		if _, err := js.CreateOrUpdateStream(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return js.CreateOrUpdateConsumer(ctx, StreamName, jetstream.ConsumerConfig{
+		Durable:    ConsumerName,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    30 * time.Second,
+		// The work queue gives up after its default of 5 attempts; the spare
+		// deliveries retry routing events to the failure subject.
+		MaxDeliver: 8,
+	})
+}
+
+type jetStreamEvents struct {
+	js jetstream.Publisher
+}
+
+// NewJetStreamEvents returns an EventPublisher publishing JSON-encoded orders
+// to JetStream.
+func NewJetStreamEvents(js jetstream.Publisher) EventPublisher {
+	return jetStreamEvents{js: js}
+}
+
+func (e jetStreamEvents) OrderPlaced(ctx context.Context, o Order) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	_, err = e.js.Publish(ctx, SubjectOrderPlaced, data)
+	return err
+}
@@ -0,0 +1,37 @@
+package ordersvc_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+func TestAdminHandler(t *testing.T) {
+	var (
+		ready   error = errors.New("starting")
+		handler       = ordersvc.MakeAdminHandler(func() error { return ready })
+	)
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusServiceUnavailable},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+		if want, have := tc.code, rec.Code; want != have {
+			t.Errorf("%s: want %d, have %d", tc.path, want, have)
+		}
+	}
+
+	ready = nil
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if want, have := http.StatusOK, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
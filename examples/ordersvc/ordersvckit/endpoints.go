@@ -0,0 +1,133 @@
+// Code generated by kitgen. DO NOT EDIT.
+
+package ordersvckit
+
+import (
+	"context"
+
+	"github.com/a69/kit.go/endpoint"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+// Set collects an endpoint per method of ordersvc.Service. It implements
+// ordersvc.Service too, by calling them, so that a Set of client
+// endpoints may be used as a client library.
+type Set struct {
+	PlaceOrderEndpoint   endpoint.Endpoint[PlaceOrderRequest, PlaceOrderResponse]
+	GetOrderEndpoint     endpoint.Endpoint[GetOrderRequest, GetOrderResponse]
+	ConfirmOrderEndpoint endpoint.Endpoint[ConfirmOrderRequest, ConfirmOrderResponse]
+}
+
+var _ ordersvc.Service = Set{}
+
+// NewSet returns a Set of endpoints calling svc. Endpoint middlewares may be
+// applied to its fields.
+func NewSet(svc ordersvc.Service) Set {
+	return Set{
+		PlaceOrderEndpoint:   MakePlaceOrderEndpoint(svc),
+		GetOrderEndpoint:     MakeGetOrderEndpoint(svc),
+		ConfirmOrderEndpoint: MakeConfirmOrderEndpoint(svc),
+	}
+}
+
+// PlaceOrder implements ordersvc.Service.
+func (s Set) PlaceOrder(ctx context.Context, draft ordersvc.Order) (order ordersvc.Order, err error) {
+	response, err := s.PlaceOrderEndpoint(ctx, PlaceOrderRequest{
+		Draft: draft,
+	})
+	if err != nil {
+		return order, err
+	}
+	return response.Order, response.Err
+}
+
+// MakePlaceOrderEndpoint returns an endpoint calling the PlaceOrder method of svc.
+// Errors returned by the method are returned in the response.
+func MakePlaceOrderEndpoint(svc ordersvc.Service) endpoint.Endpoint[PlaceOrderRequest, PlaceOrderResponse] {
+	return func(ctx context.Context, request PlaceOrderRequest) (response PlaceOrderResponse, err error) {
+		response.Order, response.Err = svc.PlaceOrder(ctx, request.Draft)
+		return response, nil
+	}
+}
+
+// PlaceOrderRequest collects the parameters of the PlaceOrder method.
+type PlaceOrderRequest struct {
+	Draft ordersvc.Order `json:"draft"`
+}
+
+// PlaceOrderResponse collects the results of the PlaceOrder method.
+type PlaceOrderResponse struct {
+	Order ordersvc.Order `json:"order"`
+	Err   error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r PlaceOrderResponse) Failed() error { return r.Err }
+
+// GetOrder implements ordersvc.Service.
+func (s Set) GetOrder(ctx context.Context, id string) (order ordersvc.Order, err error) {
+	response, err := s.GetOrderEndpoint(ctx, GetOrderRequest{
+		ID: id,
+	})
+	if err != nil {
+		return order, err
+	}
+	return response.Order, response.Err
+}
+
+// MakeGetOrderEndpoint returns an endpoint calling the GetOrder method of svc.
+// Errors returned by the method are returned in the response.
+func MakeGetOrderEndpoint(svc ordersvc.Service) endpoint.Endpoint[GetOrderRequest, GetOrderResponse] {
+	return func(ctx context.Context, request GetOrderRequest) (response GetOrderResponse, err error) {
+		response.Order, response.Err = svc.GetOrder(ctx, request.ID)
+		return response, nil
+	}
+}
+
+// GetOrderRequest collects the parameters of the GetOrder method.
+type GetOrderRequest struct {
+	ID string `json:"id"`
+}
+
+// GetOrderResponse collects the results of the GetOrder method.
+type GetOrderResponse struct {
+	Order ordersvc.Order `json:"order"`
+	Err   error          `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r GetOrderResponse) Failed() error { return r.Err }
+
+// ConfirmOrder implements ordersvc.Service.
+func (s Set) ConfirmOrder(ctx context.Context, id string) (err error) {
+	response, err := s.ConfirmOrderEndpoint(ctx, ConfirmOrderRequest{
+		ID: id,
+	})
+	if err != nil {
+		return err
+	}
+	return response.Err
+}
+
+// MakeConfirmOrderEndpoint returns an endpoint calling the ConfirmOrder method of svc.
+// Errors returned by the method are returned in the response.
+func MakeConfirmOrderEndpoint(svc ordersvc.Service) endpoint.Endpoint[ConfirmOrderRequest, ConfirmOrderResponse] {
+	return func(ctx context.Context, request ConfirmOrderRequest) (response ConfirmOrderResponse, err error) {
+		response.Err = svc.ConfirmOrder(ctx, request.ID)
+		return response, nil
+	}
+}
+
+// ConfirmOrderRequest collects the parameters of the ConfirmOrder method.
+type ConfirmOrderRequest struct {
+	ID string `json:"id"`
+}
+
+// ConfirmOrderResponse collects the results of the ConfirmOrder method.
+type ConfirmOrderResponse struct {
+	Err error `json:"-"`
+}
+
+// Failed implements endpoint.Failer.
+func (r ConfirmOrderResponse) Failed() error { return r.Err }
@@ -0,0 +1,33 @@
+package ordersvckit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/transport"
+	natstransport "github.com/a69/kit.go/transport/nats"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+// MakeConfirmationWorkQueue returns the consumer of order events, which
+// confirms placed orders. Events that keep failing, or can't be decoded, are
+// routed to ordersvc.SubjectFailed, stored by the stream of
+// ordersvc.FailedStreamConfig.
+func MakeConfirmationWorkQueue(e Set, js jetstream.Publisher, logger log.Logger) *natstransport.WorkQueue[ConfirmOrderRequest, ConfirmOrderResponse] {
+	return natstransport.NewWorkQueue(
+		e.ConfirmOrderEndpoint,
+		decodeOrderPlaced,
+		natstransport.WorkQueueFailureSubject[ConfirmOrderRequest, ConfirmOrderResponse](js, ordersvc.SubjectFailed),
+		natstransport.WorkQueueErrorHandler[ConfirmOrderRequest, ConfirmOrderResponse](transport.NewLogErrorHandler(logger)),
+	)
+}
+
+func decodeOrderPlaced(_ context.Context, msg jetstream.Msg) (ConfirmOrderRequest, error) {
+	var o ordersvc.Order
+	err := json.Unmarshal(msg.Data(), &o)
+	return ConfirmOrderRequest{ID: o.ID}, err
+}
@@ -0,0 +1,104 @@
+package ordersvckit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/metrics/metricstest"
+	"github.com/a69/kit.go/ratelimit"
+	natstransport "github.com/a69/kit.go/transport/nats"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+	"github.com/a69/kit.go/examples/ordersvc/ordersvckit"
+)
+
+func newJetStream(t *testing.T) jetstream.JetStream {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:      "localhost",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(func() { s.Shutdown(); s.WaitForShutdown() })
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return js
+}
+
+func TestConfirmationWorkQueue(t *testing.T) {
+	var (
+		js     = newJetStream(t)
+		events = ordersvc.NewJetStreamEvents(js)
+		svc    = ordersvc.NewInmemService(events)
+		ctx    = context.Background()
+	)
+	consumer, err := ordersvc.SetupStreams(ctx, js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints := ordersvckit.MakeServerEndpoints(svc, ratelimit.AllowerFunc(func() bool { return true }), metricstest.NewHistogram("duration"))
+	cc, err := consumer.Consume(ordersvckit.MakeConfirmationWorkQueue(endpoints, js, log.NewNopLogger()).HandleMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Stop()
+
+	// Placed orders are confirmed by the work queue.
+	o, err := svc.PlaceOrder(ctx, ordersvc.Order{Customer: "alice", Items: []ordersvc.Item{{SKU: "tea", Quantity: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if o, err = svc.GetOrder(ctx, o.ID); err == nil && o.Status == ordersvc.StatusConfirmed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("order wasn't confirmed, status %q, err %v", o.Status, err)
+		}
+	}
+
+	// Events that can't be decoded end up in the failed stream.
+	if _, err := js.Publish(ctx, ordersvc.SubjectOrderPlaced, []byte("not json")); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := js.Stream(ctx, ordersvc.FailedStreamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg *jetstream.RawStreamMsg
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if msg, err = failed.GetLastMsgForSubject(ctx, ordersvc.SubjectFailed); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no failed event: %v", err)
+		}
+	}
+	if want, have := "not json", string(msg.Data); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := ordersvc.SubjectOrderPlaced, msg.Header.Get(natstransport.FailureSubjectHeader); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,45 @@
+package ordersvckit
+
+import (
+	"context"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/ratelimit"
+	"github.com/a69/kit.go/tracing/opencensus"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+// MakeServerEndpoints returns the Set of endpoints generated for s, wrapped in
+// the middlewares every one of them gets: tracing, a deadline budget reserving
+// time to write the response, and duration metrics. Placing orders is also
+// rate limited by placeLimit, so that bursts don't flood the event stream.
+func MakeServerEndpoints(s ordersvc.Service, placeLimit ratelimit.Allower, duration metrics.Histogram) Set {
+	set := NewSet(s)
+	set.PlaceOrderEndpoint = ratelimit.NewErroringLimiter[PlaceOrderRequest, PlaceOrderResponse](placeLimit)(set.PlaceOrderEndpoint)
+	set.PlaceOrderEndpoint = middlewares[PlaceOrderRequest, PlaceOrderResponse]("PlaceOrder", duration)(set.PlaceOrderEndpoint)
+	set.GetOrderEndpoint = middlewares[GetOrderRequest, GetOrderResponse]("GetOrder", duration)(set.GetOrderEndpoint)
+	set.ConfirmOrderEndpoint = middlewares[ConfirmOrderRequest, ConfirmOrderResponse]("ConfirmOrder", duration)(set.ConfirmOrderEndpoint)
+	return set
+}
+
+func middlewares[REQ any, RES any](method string, duration metrics.Histogram) endpoint.Middleware[REQ, RES] {
+	return endpoint.Chain(
+		opencensus.TraceEndpoint[REQ, RES](method),
+		endpoint.DeadlineBudget[REQ, RES](10*time.Millisecond),
+		instrumentingMiddleware[REQ, RES](duration.With("method", method)),
+	)
+}
+
+func instrumentingMiddleware[REQ any, RES any](duration metrics.Histogram) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			defer func(begin time.Time) {
+				duration.Observe(time.Since(begin).Seconds())
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
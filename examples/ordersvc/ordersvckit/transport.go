@@ -0,0 +1,101 @@
+package ordersvckit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/ratelimit"
+	"github.com/a69/kit.go/tracing/opencensus"
+	"github.com/a69/kit.go/transport"
+	kiterrors "github.com/a69/kit.go/transport/errors"
+	httptransport "github.com/a69/kit.go/transport/http"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+// ErrBadRouting is returned when an expected path variable is missing.
+// It always indicates programmer error.
+var ErrBadRouting = errors.New("inconsistent mapping between route and handler (programmer error)")
+
+// MakeHTTPHandler mounts the public service endpoints into an http.Handler,
+// as a REST API rather than the RPC-style bindings kitgen generates. Every
+// request is traced, annotated with its decode, endpoint and encode
+// timings, and answered with the default security headers. The timings are
+// also observed in phases, labeled with "phase".
+func MakeHTTPHandler(e Set, phases metrics.Histogram, logger log.Logger) http.Handler {
+	r := mux.NewRouter()
+
+	// POST    /orders/      places an order
+	// GET     /orders/:id   retrieves the given order by id
+
+	r.Methods("POST").Path("/orders/").Handler(httptransport.NewServer(
+		e.PlaceOrderEndpoint,
+		decodePlaceOrderRequest,
+		encodeResponse[PlaceOrderResponse],
+		serverOptions[PlaceOrderRequest, PlaceOrderResponse]("PlaceOrder", phases, logger)...,
+	))
+	r.Methods("GET").Path("/orders/{id}").Handler(httptransport.NewServer(
+		e.GetOrderEndpoint,
+		decodeGetOrderRequest,
+		encodeResponse[GetOrderResponse],
+		serverOptions[GetOrderRequest, GetOrderResponse]("GetOrder", phases, logger)...,
+	))
+	return r
+}
+
+func serverOptions[REQ any, RES any](method string, phases metrics.Histogram, logger log.Logger) []httptransport.ServerOption[REQ, RES] {
+	return []httptransport.ServerOption[REQ, RES]{
+		opencensus.HTTPServerTrace[REQ, RES](opencensus.WithName(method)),
+		httptransport.ServerPhase[REQ, RES](
+			opencensus.PhaseAnnotations(),
+			transport.PhaseMetrics(phases.With("method", method)),
+		),
+		httptransport.ServerSecurityHeaders[REQ, RES](httptransport.DefaultSecurityHeaders()),
+		httptransport.ServerErrorHandler[REQ, RES](transport.NewLogErrorHandler(logger)),
+		httptransport.ServerErrorEncoder[REQ, RES](encodeError),
+	}
+}
+
+func decodePlaceOrderRequest(_ context.Context, r *http.Request) (PlaceOrderRequest, error) {
+	var req PlaceOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req.Draft)
+	return req, transport.JSONDecodeError(err)
+}
+
+func decodeGetOrderRequest(_ context.Context, r *http.Request) (GetOrderRequest, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return GetOrderRequest{}, ErrBadRouting
+	}
+	return GetOrderRequest{ID: id}, nil
+}
+
+// encodeResponse is the common method to encode all response types to the
+// client. Business errors are encoded like transport errors, so the client
+// sees a meaningful status code.
+func encodeResponse[RES any](ctx context.Context, w http.ResponseWriter, response RES) error {
+	if f, ok := any(response).(endpoint.Failer); ok && f.Failed() != nil {
+		encodeError(ctx, f.Failed(), w)
+		return nil
+	}
+	return httptransport.EncodeJSONResponse(ctx, w, response)
+}
+
+// errorRegistry maps the errors of the service, and of its middlewares, to the
+// HTTP status codes the client sees.
+var errorRegistry = kiterrors.NewRegistry()
+
+func init() {
+	errorRegistry.Register(ordersvc.ErrNotFound, kiterrors.Mapping{HTTP: http.StatusNotFound})
+	errorRegistry.Register(ordersvc.ErrInvalidOrder, kiterrors.Mapping{HTTP: http.StatusBadRequest})
+	errorRegistry.Register(ratelimit.ErrLimited, kiterrors.Mapping{HTTP: http.StatusTooManyRequests})
+}
+
+var encodeError = errorRegistry.HTTPErrorEncoder()
@@ -0,0 +1,98 @@
+package ordersvckit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/metrics/metricstest"
+	"github.com/a69/kit.go/ratelimit"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+	"github.com/a69/kit.go/examples/ordersvc/ordersvckit"
+)
+
+type recordingEvents struct {
+	placed []ordersvc.Order
+}
+
+func (e *recordingEvents) OrderPlaced(_ context.Context, o ordersvc.Order) error {
+	e.placed = append(e.placed, o)
+	return nil
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	var (
+		events    = &recordingEvents{}
+		allowed   = 1
+		limit     = ratelimit.AllowerFunc(func() bool { allowed--; return allowed >= 0 })
+		phases    = metricstest.NewHistogram("phases")
+		endpoints = ordersvckit.MakeServerEndpoints(ordersvc.NewInmemService(events), limit, metricstest.NewHistogram("duration"))
+		server    = httptest.NewServer(ordersvckit.MakeHTTPHandler(endpoints, phases, log.NewNopLogger()))
+	)
+	defer server.Close()
+
+	place := func() *http.Response {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/orders/", "application/json", strings.NewReader(`{"customer":"alice","items":[{"sku":"tea","quantity":2}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	get := func(id string) (ordersvc.Order, int) {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/orders/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body ordersvckit.GetOrderResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body.Order, resp.StatusCode
+	}
+
+	resp := place()
+	defer resp.Body.Close()
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	if want, have := "nosniff", resp.Header.Get("X-Content-Type-Options"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, len(events.placed); want != have {
+		t.Fatalf("events: want %d, have %d", want, have)
+	}
+	id := events.placed[0].ID
+
+	if o, code := get(id); code != http.StatusOK || o.Status != ordersvc.StatusPending {
+		t.Errorf("want %d %s, have %d %s", http.StatusOK, ordersvc.StatusPending, code, o.Status)
+	}
+
+	// The work queue decodes the event and calls the confirmation endpoint.
+	if res, err := endpoints.ConfirmOrderEndpoint(context.Background(), ordersvckit.ConfirmOrderRequest{ID: id}); err != nil || res.Err != nil {
+		t.Fatal(errors.Join(err, res.Err))
+	}
+	if o, _ := get(id); o.Status != ordersvc.StatusConfirmed {
+		t.Errorf("want %s, have %s", ordersvc.StatusConfirmed, o.Status)
+	}
+
+	if _, code := get("nope"); code != http.StatusNotFound {
+		t.Errorf("want %d, have %d", http.StatusNotFound, code)
+	}
+
+	limited := place()
+	defer limited.Body.Close()
+	if want, have := http.StatusTooManyRequests, limited.StatusCode; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	if have := len(phases.ObservationsWith("method", "GetOrder", "phase", "endpoint")); have == 0 {
+		t.Error("want endpoint phase observations, have none")
+	}
+}
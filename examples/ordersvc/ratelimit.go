@@ -0,0 +1,50 @@
+package ordersvc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/ratelimit"
+)
+
+// redisCount increments the counter of the current window, which expires
+// with it, and returns the count.
+var redisCount = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+type redisLimiter struct {
+	client redis.UniversalClient
+	key    string
+	limit  int64
+	window time.Duration
+	logger log.Logger
+}
+
+// NewRedisLimiter returns a ratelimit.Allower allowing limit events per
+// window, counted in Redis under key, so that the limit is shared by every
+// instance of the service. Windows are fixed, so up to twice the limit may be
+// allowed across the boundary of two windows. If Redis can't be reached, the
+// error is logged and events are allowed, so that the limiter doesn't take
+// the service down with it.
+func NewRedisLimiter(client redis.UniversalClient, key string, limit int, window time.Duration, logger log.Logger) ratelimit.Allower {
+	return &redisLimiter{client: client, key: key, limit: int64(limit), window: window, logger: logger}
+}
+
+func (l *redisLimiter) Allow() bool {
+	key := l.key + ":" + strconv.FormatInt(time.Now().UnixNano()/int64(l.window), 10)
+	n, err := redisCount.Run(context.Background(), l.client, []string{key}, l.window.Milliseconds()).Int64()
+	if err != nil {
+		l.logger.Log("limiter", l.key, "err", err)
+		return true
+	}
+	return n <= l.limit
+}
@@ -0,0 +1,42 @@
+package ordersvc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/a69/kit.go/log"
+
+	"github.com/a69/kit.go/examples/ordersvc"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	var (
+		s      = miniredis.RunT(t)
+		client = redis.NewClient(&redis.Options{Addr: s.Addr()})
+		window = time.Hour
+	)
+	defer client.Close()
+
+	// The limit is shared by every limiter using the same key.
+	a := ordersvc.NewRedisLimiter(client, "place", 2, window, log.NewNopLogger())
+	b := ordersvc.NewRedisLimiter(client, "place", 2, window, log.NewNopLogger())
+	if want, have := []bool{true, true, false}, []bool{a.Allow(), b.Allow(), a.Allow()}; want[0] != have[0] || want[1] != have[1] || want[2] != have[2] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	// Counters expire with their window.
+	for _, key := range s.Keys() {
+		if ttl := s.TTL(key); ttl <= 0 || ttl > window {
+			t.Errorf("%s: want a TTL up to %v, have %v", key, window, ttl)
+		}
+	}
+
+	// Events are allowed while Redis can't be reached.
+	s.Close()
+	if !a.Allow() {
+		t.Error("want allowed without Redis")
+	}
+}
@@ -0,0 +1,113 @@
+package ordersvc
+
+//go:generate go run github.com/a69/kit.go/cmd/kitgen -type Service -transports "" -out ordersvckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Service manages customer orders. Placing an order emits an event, which is
+// consumed asynchronously to confirm it, the way a real service would hand off
+// to payment or fulfillment. Its endpoints are generated by kitgen, in package
+// ordersvckit, and named after the parameters and results below.
+type Service interface {
+	PlaceOrder(ctx context.Context, draft Order) (order Order, err error)
+	GetOrder(ctx context.Context, id string) (order Order, err error)
+	ConfirmOrder(ctx context.Context, id string) error
+}
+
+// Order is a customer order. Its ID and Status are set by the service.
+type Order struct {
+	ID       string `json:"id"`
+	Customer string `json:"customer"`
+	Items    []Item `json:"items"`
+	Status   string `json:"status"`
+}
+
+// Item is a line of an order.
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// Order statuses.
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+)
+
+var (
+	ErrInvalidOrder = errors.New("order needs a customer and at least one item")
+	ErrNotFound     = errors.New("not found")
+)
+
+// EventPublisher announces changes to orders to the rest of the system.
+type EventPublisher interface {
+	OrderPlaced(ctx context.Context, o Order) error
+}
+
+type inmemService struct {
+	events EventPublisher
+	seq    atomic.Uint64
+
+	mtx sync.RWMutex
+	m   map[string]Order
+}
+
+// NewInmemService returns a Service keeping orders in memory, and publishing
+// events to the given EventPublisher. Orders aren't shared between processes,
+// so a single instance of the service may run: with several, orders are only
+// known to the instance that placed them, and confirmations fail on the
+// instances consuming their events.
+func NewInmemService(events EventPublisher) Service {
+	return &inmemService{
+		events: events,
+		m:      map[string]Order{},
+	}
+}
+
+func (s *inmemService) PlaceOrder(ctx context.Context, o Order) (Order, error) {
+	if o.Customer == "" || len(o.Items) == 0 {
+		return Order{}, ErrInvalidOrder
+	}
+	o.ID = fmt.Sprintf("ord-%d", s.seq.Add(1))
+	o.Status = StatusPending
+
+	s.mtx.Lock()
+	s.m[o.ID] = o
+	s.mtx.Unlock()
+
+	if err := s.events.OrderPlaced(ctx, o); err != nil {
+		s.mtx.Lock()
+		delete(s.m, o.ID)
+		s.mtx.Unlock()
+		return Order{}, err
+	}
+	return o, nil
+}
+
+func (s *inmemService) GetOrder(_ context.Context, id string) (Order, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	o, ok := s.m[id]
+	if !ok {
+		return Order{}, ErrNotFound
+	}
+	return o, nil
+}
+
+func (s *inmemService) ConfirmOrder(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	o, ok := s.m[id]
+	if !ok {
+		return ErrNotFound
+	}
+	o.Status = StatusConfirmed
+	s.m[id] = o
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build fuzz
+// +build fuzz
+
+package profilesvc
+
+import (
+	"testing"
+
+	"github.com/a69/kit.go/log"
+
+	"github.com/a69/kit.go/transport/fuzztest"
+)
+
+// Run with go test -tags fuzz -fuzz FuzzHTTPHandler.
+func FuzzHTTPHandler(f *testing.F) {
+	fuzztest.Fuzz(f, fuzztest.HTTPHandler(MakeHTTPHandler(NewInmemService(), log.NewNopLogger())),
+		[]byte("POST /profiles/ HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: 24\r\n\r\n{\"id\":\"1\",\"name\":\"Ana\"}\n"),
+		[]byte("GET /profiles/1/addresses/2 HTTP/1.1\r\nHost: localhost\r\n\r\n"),
+		[]byte("PATCH /profiles/1 HTTP/1.1\r\nHost: localhost\r\nContent-Length: 2\r\n\r\n{}"),
+	)
+}
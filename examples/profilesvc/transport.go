@@ -15,7 +15,9 @@ import (
 
 	"github.com/a69/kit.go/log"
 	"github.com/a69/kit.go/transport"
+	kiterrors "github.com/a69/kit.go/transport/errors"
 	httptransport "github.com/a69/kit.go/transport/http"
+	kitmux "github.com/a69/kit.go/transport/http/mux"
 )
 
 var (
@@ -29,6 +31,10 @@ var (
 func MakeHTTPHandler(s Service, logger log.Logger) http.Handler {
 	r := mux.NewRouter()
 	e := MakeServerEndpoints(s)
+	router := kitmux.NewRouter(r,
+		httptransport.ServerErrorHandler[any, any](transport.NewLogErrorHandler(logger)),
+		httptransport.ServerErrorEncoder[any, any](errorRegistry.HTTPErrorEncoder()),
+	)
 
 	// POST    /profiles/                          adds another profile
 	// GET     /profiles/:id                       retrieves the given profile by id
@@ -40,67 +46,22 @@ func MakeHTTPHandler(s Service, logger log.Logger) http.Handler {
 	// POST    /profiles/:id/addresses/            add a new address
 	// DELETE  /profiles/:id/addresses/:addressID  remove an address
 
-	r.Methods("POST").Path("/profiles/").Handler(httptransport.NewServer(
-		e.PostProfileEndpoint,
-		decodePostProfileRequest,
-		encodeResponse[PostProfileResponse],
-		makeServerOption[PostProfileRequest, PostProfileResponse](logger)...,
-	))
-	r.Methods("GET").Path("/profiles/{id}").Handler(httptransport.NewServer(
-		e.GetProfileEndpoint,
-		decodeGetProfileRequest,
-		encodeResponse[GetProfileResponse],
-		makeServerOption[GetProfileRequest, GetProfileResponse](logger)...,
-	))
-	r.Methods("PUT").Path("/profiles/{id}").Handler(httptransport.NewServer(
-		e.PutProfileEndpoint,
-		decodePutProfileRequest,
-		encodeResponse[PutProfileResponse],
-		makeServerOption[PutProfileRequest, PutProfileResponse](logger)...,
-	))
-	r.Methods("PATCH").Path("/profiles/{id}").Handler(httptransport.NewServer(
-		e.PatchProfileEndpoint,
-		decodePatchProfileRequest,
-		encodeResponse[PatchProfileResponse],
-		makeServerOption[PatchProfileRequest, PatchProfileResponse](logger)...,
-	))
-	r.Methods("DELETE").Path("/profiles/{id}").Handler(httptransport.NewServer(
-		e.DeleteProfileEndpoint,
-		decodeDeleteProfileRequest,
-		encodeResponse[DeleteProfileResponse],
-		makeServerOption[DeleteProfileRequest, DeleteProfileResponse](logger)...,
-	))
-	r.Methods("GET").Path("/profiles/{id}/addresses/").Handler(httptransport.NewServer(
-		e.GetAddressesEndpoint,
-		decodeGetAddressesRequest,
-		encodeResponse[GetAddressesResponse],
-		makeServerOption[GetAddressesRequest, GetAddressesResponse](logger)...,
-	))
-	r.Methods("GET").Path("/profiles/{id}/addresses/{addressID}").Handler(httptransport.NewServer(
-		e.GetAddressEndpoint,
-		decodeGetAddressRequest,
-		encodeResponse[GetAddressResponse],
-		makeServerOption[GetAddressRequest, GetAddressResponse](logger)...,
-	))
-	r.Methods("POST").Path("/profiles/{id}/addresses/").Handler(httptransport.NewServer(
-		e.PostAddressEndpoint,
-		decodePostAddressRequest,
-		encodeResponse[PostAddressResponse],
-		makeServerOption[PostAddressRequest, PostAddressResponse](logger)...,
-	))
-	r.Methods("DELETE").Path("/profiles/{id}/addresses/{addressID}").Handler(httptransport.NewServer(
-		e.DeleteAddressEndpoint,
-		decodeDeleteAddressRequest,
-		encodeResponse[DeleteAddressResponse],
-		makeServerOption[DeleteAddressRequest, DeleteAddressResponse](logger)...,
-	))
+	kitmux.Handle(router, "POST", "/profiles/", e.PostProfileEndpoint, decodePostProfileRequest, encodeResponse[PostProfileResponse])
+	kitmux.Handle(router, "GET", "/profiles/{id}", e.GetProfileEndpoint, decodeGetProfileRequest, encodeResponse[GetProfileResponse])
+	kitmux.Handle(router, "PUT", "/profiles/{id}", e.PutProfileEndpoint, decodePutProfileRequest, encodeResponse[PutProfileResponse])
+	kitmux.Handle(router, "PATCH", "/profiles/{id}", e.PatchProfileEndpoint, decodePatchProfileRequest, encodeResponse[PatchProfileResponse])
+	kitmux.Handle(router, "DELETE", "/profiles/{id}", e.DeleteProfileEndpoint, decodeDeleteProfileRequest, encodeResponse[DeleteProfileResponse])
+	kitmux.Handle(router, "GET", "/profiles/{id}/addresses/", e.GetAddressesEndpoint, decodeGetAddressesRequest, encodeResponse[GetAddressesResponse])
+	kitmux.Handle(router, "GET", "/profiles/{id}/addresses/{addressID}", e.GetAddressEndpoint, decodeGetAddressRequest, encodeResponse[GetAddressResponse])
+	kitmux.Handle(router, "POST", "/profiles/{id}/addresses/", e.PostAddressEndpoint, decodePostAddressRequest, encodeResponse[PostAddressResponse])
+	kitmux.Handle(router, "DELETE", "/profiles/{id}/addresses/{addressID}", e.DeleteAddressEndpoint, decodeDeleteAddressRequest, encodeResponse[DeleteAddressResponse])
 	return r
 }
 
 func decodePostProfileRequest(_ context.Context, r *http.Request) (request PostProfileRequest, err error) {
 	var req PostProfileRequest
 	if e := json.NewDecoder(r.Body).Decode(&req.Profile); e != nil {
-		return PostProfileRequest{}, e
+		return PostProfileRequest{}, transport.JSONDecodeError(e)
 	}
 	return req, nil
 }
@@ -122,7 +83,7 @@ func decodePutProfileRequest(_ context.Context, r *http.Request) (request PutPro
 	}
 	var profile Profile
 	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
-		return PutProfileRequest{}, err
+		return PutProfileRequest{}, transport.JSONDecodeError(err)
 	}
 	return PutProfileRequest{
 		ID:      id,
@@ -138,7 +99,7 @@ func decodePatchProfileRequest(_ context.Context, r *http.Request) (request Patc
 	}
 	var profile Profile
 	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
-		return PatchProfileRequest{}, err
+		return PatchProfileRequest{}, transport.JSONDecodeError(err)
 	}
 	return PatchProfileRequest{
 		ID:      id,
@@ -188,7 +149,7 @@ func decodePostAddressRequest(_ context.Context, r *http.Request) (request PostA
 	}
 	var address Address
 	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
-		return PostAddressRequest{}, err
+		return PostAddressRequest{}, transport.JSONDecodeError(err)
 	}
 	return PostAddressRequest{
 		ProfileID: id,
@@ -360,30 +321,12 @@ func encodeRequest[REQ any](_ context.Context, req *http.Request, request REQ) e
 	return nil
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
-	if err == nil {
-		panic("encodeError with nil error")
-	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(codeFrom(err))
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
-}
-
-func codeFrom(err error) int {
-	switch err {
-	case ErrNotFound:
-		return http.StatusNotFound
-	case ErrAlreadyExists, ErrInconsistentIDs:
-		return http.StatusBadRequest
-	default:
-		return http.StatusInternalServerError
-	}
-}
+// errorRegistry maps the errors of the service to the HTTP status codes the
+// client sees.
+var errorRegistry = kiterrors.NewRegistry()
 
-func makeServerOption[REQ any, RES any](logger log.Logger) []httptransport.ServerOption[REQ, RES] {
-	return []httptransport.ServerOption[REQ, RES]{
-		httptransport.ServerErrorHandler[REQ, RES](transport.NewLogErrorHandler(logger)),
-	}
+func init() {
+	errorRegistry.Register(ErrNotFound, kiterrors.Mapping{HTTP: http.StatusNotFound})
+	errorRegistry.Register(ErrAlreadyExists, kiterrors.Mapping{HTTP: http.StatusBadRequest})
+	errorRegistry.Register(ErrInconsistentIDs, kiterrors.Mapping{HTTP: http.StatusBadRequest})
 }
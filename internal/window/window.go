@@ -0,0 +1,67 @@
+// Package window implements counters over a sliding window of time, e.g. of
+// the good and bad requests of the last 5 minutes.
+package window
+
+import "time"
+
+// Slots is the number of slots a Window is divided into. Events leave a
+// window one slot at a time, e.g. 5s at a time for a 5m window.
+const Slots = 60
+
+// Window counts two kinds of events, e.g. good and bad requests, over a
+// sliding window of time. It isn't goroutine-safe.
+type Window struct {
+	size   time.Duration
+	slot   int64 // nanoseconds
+	cur    int64 // number of the current slot since the epoch
+	counts [Slots][2]uint64
+	totals [2]uint64
+}
+
+// New returns an empty Window of the given size.
+func New(size time.Duration) *Window {
+	slot := int64(size) / Slots
+	if slot <= 0 {
+		slot = 1
+	}
+	return &Window{size: size, slot: slot}
+}
+
+// Size returns the size of the window.
+func (w *Window) Size() time.Duration { return w.size }
+
+// Add counts a events of the first kind, and b of the second, at now.
+func (w *Window) Add(now time.Time, a, b uint64) {
+	w.advance(now)
+	j := w.cur % Slots
+	w.counts[j][0] += a
+	w.counts[j][1] += b
+	w.totals[0] += a
+	w.totals[1] += b
+}
+
+// Counts returns the number of events of each kind over the window ending
+// at now.
+func (w *Window) Counts(now time.Time) (a, b uint64) {
+	w.advance(now)
+	return w.totals[0], w.totals[1]
+}
+
+// advance drops the slots that left the window by now.
+func (w *Window) advance(now time.Time) {
+	n := now.UnixNano() / w.slot
+	if n <= w.cur {
+		return
+	}
+	if n-w.cur >= Slots {
+		w.counts, w.totals = [Slots][2]uint64{}, [2]uint64{}
+	} else {
+		for i := w.cur + 1; i <= n; i++ {
+			j := i % Slots
+			w.totals[0] -= w.counts[j][0]
+			w.totals[1] -= w.counts[j][1]
+			w.counts[j] = [2]uint64{}
+		}
+	}
+	w.cur = n
+}
@@ -0,0 +1,37 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	var (
+		w     = New(time.Minute) // 1s slots
+		start = time.Unix(1000, 0)
+	)
+	w.Add(start, 1, 0)
+	w.Add(start.Add(30*time.Second), 2, 1)
+
+	for _, tc := range []struct {
+		at   time.Duration
+		a, b uint64
+	}{
+		{30 * time.Second, 3, 1},
+		{59 * time.Second, 3, 1},
+		{60 * time.Second, 2, 1}, // the first slot left the window
+		{89 * time.Second, 2, 1},
+		{90 * time.Second, 0, 0},
+	} {
+		a, b := w.Counts(start.Add(tc.at))
+		if a != tc.a || b != tc.b {
+			t.Errorf("at %v: want %d, %d, have %d, %d", tc.at, tc.a, tc.b, a, b)
+		}
+	}
+
+	// Jumps longer than the window clear it at once.
+	w.Add(start.Add(100*time.Second), 5, 5)
+	if a, b := w.Counts(start.Add(time.Hour)); a != 0 || b != 0 {
+		t.Errorf("want 0, 0, have %d, %d", a, b)
+	}
+}
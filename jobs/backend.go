@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Backend stores jobs in named queues, and delivers them to workers.
+type Backend interface {
+	// Enqueue adds a job to the queue, to be delivered after the delay.
+	Enqueue(ctx context.Context, queue string, body []byte, delay time.Duration) error
+
+	// Receive waits for a job of the queue, until ctx is done.
+	Receive(ctx context.Context, queue string) (Delivery, error)
+}
+
+// Delivery is a job received from a backend. It must be settled with either
+// Ack or Retry.
+type Delivery interface {
+	// Body returns the encoded job.
+	Body() []byte
+
+	// Attempt returns the number of times the job was delivered, including
+	// this one.
+	Attempt() int
+
+	// Ack removes the job from its queue, once it's done.
+	Ack(ctx context.Context) error
+
+	// Retry delivers the job again after the delay.
+	Retry(ctx context.Context, delay time.Duration) error
+}
+
+// MemoryBackend is a Backend keeping jobs in memory, e.g. for tests, or for
+// work that may be lost when the process exits.
+type MemoryBackend struct {
+	mtx    sync.Mutex
+	queues map[string]*memoryQueue
+}
+
+type memoryQueue struct {
+	jobs   []*memoryDelivery
+	signal chan struct{}
+}
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{queues: map[string]*memoryQueue{}}
+}
+
+// Enqueue implements Backend.
+func (b *MemoryBackend) Enqueue(_ context.Context, queue string, body []byte, delay time.Duration) error {
+	b.push(queue, &memoryDelivery{backend: b, queue: queue, body: append([]byte(nil), body...), attempt: 1}, delay)
+	return nil
+}
+
+// Receive implements Backend.
+func (b *MemoryBackend) Receive(ctx context.Context, queue string) (Delivery, error) {
+	for {
+		b.mtx.Lock()
+		q := b.queue(queue)
+		if len(q.jobs) > 0 {
+			d := q.jobs[0]
+			q.jobs[0] = nil
+			q.jobs = q.jobs[1:]
+			if len(q.jobs) > 0 {
+				q.notify() // wake up another receiver
+			}
+			b.mtx.Unlock()
+			return d, nil
+		}
+		b.mtx.Unlock()
+
+		select {
+		case <-q.signal:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of jobs ready to be delivered from the queue.
+func (b *MemoryBackend) Len(queue string) int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.queue(queue).jobs)
+}
+
+func (b *MemoryBackend) push(queue string, d *memoryDelivery, delay time.Duration) {
+	if delay > 0 {
+		time.AfterFunc(delay, func() { b.push(queue, d, 0) })
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	q := b.queue(queue)
+	q.jobs = append(q.jobs, d)
+	q.notify()
+}
+
+// queue must be called with the mutex held.
+func (b *MemoryBackend) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{signal: make(chan struct{}, 1)}
+		b.queues[name] = q
+	}
+	return q
+}
+
+func (q *memoryQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+type memoryDelivery struct {
+	backend *MemoryBackend
+	queue   string
+	body    []byte
+	attempt int
+}
+
+func (d *memoryDelivery) Body() []byte { return d.body }
+
+func (d *memoryDelivery) Attempt() int { return d.attempt }
+
+func (d *memoryDelivery) Ack(context.Context) error { return nil }
+
+func (d *memoryDelivery) Retry(_ context.Context, delay time.Duration) error {
+	next := *d
+	next.attempt++
+	d.backend.push(d.queue, &next, delay)
+	return nil
+}
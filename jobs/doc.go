@@ -0,0 +1,16 @@
+// Package jobs runs asynchronous work through endpoints: jobs are enqueued
+// to a queue of a Backend, in memory, Redis or Amazon SQS, and a Worker
+// receives them and invokes an endpoint with each, retrying failed jobs
+// after a backoff, and moving those that keep failing to a dead-letter
+// queue. Since jobs are handled by endpoints, they get the same middlewares,
+// e.g. for tracing, logging, metrics or rate limiting, as RPCs do.
+//
+//	queue := jobs.NewQueue(backend, "emails", jobs.EncodeJSON[SendEmail])
+//	queue.Enqueue(ctx, SendEmail{To: "alice@example.com"})
+//
+//	worker := jobs.NewWorker(backend, "emails", sendEmailEndpoint, jobs.DecodeJSON[SendEmail],
+//		jobs.WorkerConcurrency[SendEmail, struct{}](8),
+//		jobs.WorkerDeadLetter[SendEmail, struct{}]("emails.dead"),
+//	)
+//	go worker.Run(ctx)
+package jobs
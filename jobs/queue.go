@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// EncodeFunc encodes a job for a backend.
+type EncodeFunc[REQ any] func(context.Context, REQ) ([]byte, error)
+
+// DecodeFunc decodes a job received from a backend.
+type DecodeFunc[REQ any] func(context.Context, []byte) (REQ, error)
+
+// EncodeJSON is an EncodeFunc encoding jobs as JSON.
+func EncodeJSON[REQ any](_ context.Context, job REQ) ([]byte, error) {
+	return json.Marshal(job)
+}
+
+// DecodeJSON is a DecodeFunc decoding jobs from JSON.
+func DecodeJSON[REQ any](_ context.Context, body []byte) (REQ, error) {
+	var job REQ
+	err := json.Unmarshal(body, &job)
+	return job, err
+}
+
+// Queue enqueues jobs of type REQ to a queue of a backend.
+type Queue[REQ any] struct {
+	backend Backend
+	name    string
+	enc     EncodeFunc[REQ]
+}
+
+// NewQueue returns a Queue enqueuing jobs to the named queue of the backend,
+// encoded with enc.
+func NewQueue[REQ any](backend Backend, name string, enc EncodeFunc[REQ]) *Queue[REQ] {
+	return &Queue[REQ]{backend: backend, name: name, enc: enc}
+}
+
+// Enqueue adds the job to the queue.
+func (q *Queue[REQ]) Enqueue(ctx context.Context, job REQ) error {
+	return q.Schedule(ctx, job, 0)
+}
+
+// Schedule adds the job to the queue, to be run after the delay. Backends may
+// limit the delay, e.g. to 15 minutes for SQS.
+func (q *Queue[REQ]) Schedule(ctx context.Context, job REQ, delay time.Duration) error {
+	body, err := q.enc(ctx, job)
+	if err != nil {
+		return err
+	}
+	return q.backend.Enqueue(ctx, q.name, body, delay)
+}
+
+// Endpoint returns an endpoint enqueuing its requests as jobs, e.g. to put
+// middlewares in front of the queue, or to switch a service between doing
+// the work synchronously and asynchronously.
+func (q *Queue[REQ]) Endpoint() endpoint.Endpoint[REQ, struct{}] {
+	return func(ctx context.Context, job REQ) (struct{}, error) {
+		return struct{}{}, q.Enqueue(ctx, job)
+	}
+}
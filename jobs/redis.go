@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBackend is a Backend storing jobs in Redis. Every queue is made of a
+// list of ready jobs, a sorted set of delayed jobs, and a sorted set of the
+// jobs being run, each leased to its worker until a deadline. Leases are
+// extended while jobs run, like the visibility timeout of SQS messages, so
+// that only the jobs of workers that died are delivered again, once their
+// lease expires. Due jobs and expired leases are moved back to the ready list
+// by the workers as they poll the queue.
+//
+// The keys of a queue share the hash tag {queue}, so that they live in the
+// same slot of a Redis Cluster.
+type RedisBackend struct {
+	client redis.UniversalClient
+	poll   time.Duration
+	lease  time.Duration
+}
+
+// RedisOption sets an optional parameter for Redis backends.
+type RedisOption func(*RedisBackend)
+
+// RedisPollInterval sets how often an empty queue is polled for jobs. By
+// default, it's 1 second.
+func RedisPollInterval(d time.Duration) RedisOption {
+	return func(b *RedisBackend) { b.poll = d }
+}
+
+// RedisLease sets the lease of received jobs, and thus how long a job is kept
+// from other workers if its worker dies. By default, it's 30 seconds. Leases
+// are extended every half of the lease, so shorter leases than 10
+// milliseconds are raised to 10 milliseconds.
+func RedisLease(d time.Duration) RedisOption {
+	return func(b *RedisBackend) { b.lease = max(d, redisMinLease) }
+}
+
+const redisMinLease = 10 * time.Millisecond
+
+// NewRedisBackend returns a RedisBackend using the client.
+func NewRedisBackend(client redis.UniversalClient, options ...RedisOption) *RedisBackend {
+	b := &RedisBackend{client: client, poll: time.Second, lease: 30 * time.Second}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// Jobs are stored as a random ID of redisIDLen hex digits, followed by their
+// body, so that identical bodies are distinct jobs.
+const redisIDLen = 32
+
+// The scripts take the keys of the queue in the order of redisKeys.
+var (
+	redisReceive = redis.NewScript(`
+for _, key in ipairs({KEYS[2], KEYS[3]}) do
+	for _, job in ipairs(redis.call('ZRANGEBYSCORE', key, '-inf', ARGV[1], 'LIMIT', 0, 100)) do
+		redis.call('ZREM', key, job)
+		redis.call('LPUSH', KEYS[1], job)
+	end
+end
+local job = redis.call('RPOP', KEYS[1])
+if not job then
+	return false
+end
+redis.call('ZADD', KEYS[3], ARGV[2], job)
+return {job, redis.call('HINCRBY', KEYS[4], string.sub(job, 1, 32), 1)}
+`)
+	redisAck = redis.NewScript(`
+if redis.call('ZREM', KEYS[3], ARGV[1]) == 1 then
+	redis.call('HDEL', KEYS[4], string.sub(ARGV[1], 1, 32))
+end
+return 0
+`)
+	redisRetry = redis.NewScript(`
+if redis.call('ZREM', KEYS[3], ARGV[1]) == 1 then
+	redis.call('ZADD', KEYS[2], ARGV[2], ARGV[1])
+end
+return 0
+`)
+)
+
+// redisKeys returns the keys of the queue: its ready list, its delayed and
+// leased sets, and the hash of the attempts of its jobs.
+func redisKeys(queue string) []string {
+	prefix := "{" + queue + "}:"
+	return []string{prefix + "ready", prefix + "delayed", prefix + "leases", prefix + "attempts"}
+}
+
+// Enqueue implements Backend.
+func (b *RedisBackend) Enqueue(ctx context.Context, queue string, body []byte, delay time.Duration) error {
+	id := make([]byte, redisIDLen/2)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	job := hex.EncodeToString(id) + string(body)
+	keys := redisKeys(queue)
+	if delay > 0 {
+		return b.client.ZAdd(ctx, keys[1], redis.Z{Score: score(time.Now().Add(delay)), Member: job}).Err()
+	}
+	return b.client.LPush(ctx, keys[0], job).Err()
+}
+
+// Receive implements Backend, polling the queue until a job is ready.
+func (b *RedisBackend) Receive(ctx context.Context, queue string) (Delivery, error) {
+	keys := redisKeys(queue)
+	for {
+		now := time.Now()
+		res, err := redisReceive.Run(ctx, b.client, keys, score(now), score(now.Add(b.lease))).Slice()
+		if err == nil && len(res) == 2 {
+			job, _ := res[0].(string)
+			attempt, _ := res[1].(int64)
+			return newRedisDelivery(b, keys, job, int(attempt)), nil
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+
+		t := time.NewTimer(b.poll)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// score returns the score of t in the sorted sets, in milliseconds.
+func score(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+type redisDelivery struct {
+	backend *RedisBackend
+	keys    []string
+	job     string
+	attempt int
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+func newRedisDelivery(b *RedisBackend, keys []string, job string, attempt int) *redisDelivery {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &redisDelivery{backend: b, keys: keys, job: job, attempt: attempt, stop: cancel, stopped: make(chan struct{})}
+	go d.extend(ctx)
+	return d
+}
+
+// extend extends the lease of the job until ctx is done. Failures are
+// ignored: at worst, the job is delivered again.
+func (d *redisDelivery) extend(ctx context.Context) {
+	defer close(d.stopped)
+	ticker := time.NewTicker(d.backend.lease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.backend.client.ZAddXX(ctx, d.keys[2], redis.Z{Score: score(time.Now().Add(d.backend.lease)), Member: d.job})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// settle stops extending the lease, before the delivery is settled.
+func (d *redisDelivery) settle() {
+	d.stop()
+	<-d.stopped
+}
+
+func (d *redisDelivery) Body() []byte {
+	if len(d.job) < redisIDLen {
+		return nil
+	}
+	return []byte(d.job[redisIDLen:])
+}
+
+func (d *redisDelivery) Attempt() int {
+	if d.attempt < 1 {
+		return 1
+	}
+	return d.attempt
+}
+
+func (d *redisDelivery) Ack(ctx context.Context) error {
+	d.settle()
+	return redisAck.Run(ctx, d.backend.client, d.keys, d.job).Err()
+}
+
+func (d *redisDelivery) Retry(ctx context.Context, delay time.Duration) error {
+	d.settle()
+	return redisRetry.Run(ctx, d.backend.client, d.keys, d.job, score(time.Now().Add(delay))).Err()
+}
@@ -0,0 +1,141 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/a69/kit.go/jobs"
+)
+
+func newRedisBackend(t *testing.T, options ...jobs.RedisOption) (*jobs.RedisBackend, *redis.Client) {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	return jobs.NewRedisBackend(client, append([]jobs.RedisOption{jobs.RedisPollInterval(10 * time.Millisecond)}, options...)...), client
+}
+
+func TestRedisBackend(t *testing.T) {
+	backend, client := newRedisBackend(t)
+	ctx := context.Background()
+
+	if err := backend.Enqueue(ctx, "emails", []byte(`{"to":"alice"}`), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.Enqueue(ctx, "emails", []byte(`{"to":"bob"}`), 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := backend.Receive(ctx, "emails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"to":"alice"}`, string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 1, d.Attempt(); want != have {
+		t.Errorf("Attempt: want %d, have %d", want, have)
+	}
+	if err := d.Retry(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// The delayed job is delivered once due, the retried one isn't yet.
+	start := time.Now()
+	d, err = backend.Receive(ctx, "emails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"to":"bob"}`, string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("delayed job delivered after %v", elapsed)
+	}
+	if err := d.Ack(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retrying without delay delivers the job again, as its next attempt.
+	if n, err := client.ZCard(ctx, "{emails}:delayed").Result(); err != nil || n != 1 {
+		t.Fatalf("want 1 delayed job, have %d (%v)", n, err)
+	}
+	client.ZAdd(ctx, "{emails}:delayed", redis.Z{Score: 0, Member: client.ZRange(ctx, "{emails}:delayed", 0, 0).Val()[0]})
+	d, err = backend.Receive(ctx, "emails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"to":"alice"}`, string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 2, d.Attempt(); want != have {
+		t.Errorf("Attempt: want %d, have %d", want, have)
+	}
+	if err := d.Ack(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"{emails}:ready", "{emails}:delayed", "{emails}:leases", "{emails}:attempts"} {
+		if n := client.Exists(ctx, key).Val(); n != 0 {
+			t.Errorf("%s: want no key after Ack", key)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := backend.Receive(ctx, "emails"); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRedisBackendLeases(t *testing.T) {
+	backend, client := newRedisBackend(t, jobs.RedisLease(200*time.Millisecond))
+	ctx := context.Background()
+	backend.Enqueue(ctx, "emails", []byte("long job"), 0)
+
+	d, err := backend.Receive(ctx, "emails")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The job runs longer than its lease, which is extended.
+	short, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	if _, err := backend.Receive(short, "emails"); err != context.DeadlineExceeded {
+		t.Fatalf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	if err := d.Ack(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The jobs of workers that died are delivered again once their lease
+	// expired.
+	client.ZAdd(ctx, "{emails}:leases", redis.Z{Score: 0, Member: "0123456789abcdef0123456789abcdef" + "orphan"})
+	d, err = backend.Receive(ctx, "emails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "orphan", string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestRedisBackendShortLease(t *testing.T) {
+	for _, lease := range []time.Duration{0, -time.Second, time.Nanosecond} {
+		backend, _ := newRedisBackend(t, jobs.RedisLease(lease))
+		ctx := context.Background()
+		backend.Enqueue(ctx, "emails", []byte("job"), 0)
+
+		// Extending the lease every half of the lease doesn't panic.
+		d, err := backend.Receive(ctx, "emails")
+		if err != nil {
+			t.Fatalf("%v: %v", lease, err)
+		}
+		if err := d.Ack(ctx); err != nil {
+			t.Errorf("%v: %v", lease, err)
+		}
+	}
+}
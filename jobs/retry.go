@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// RetryPolicy decides whether a job that failed on the given attempt is
+// retried, and after which delay.
+type RetryPolicy func(attempt int, err error) (delay time.Duration, retry bool)
+
+// BackoffPolicy returns a RetryPolicy retrying failed jobs until they were
+// attempted maxAttempts times, after the delays of the backoff: the delay
+// before the second attempt is backoff(1).
+func BackoffPolicy(maxAttempts int, backoff endpoint.Backoff) RetryPolicy {
+	return func(attempt int, _ error) (time.Duration, bool) {
+		if attempt >= maxAttempts {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+}
+
+// ExponentialBackoff returns a RetryPolicy retrying failed jobs until they
+// were attempted maxAttempts times, after delays doubling from initial up to
+// max, randomized by up to 50% in either direction, so that jobs failing
+// together don't retry together.
+func ExponentialBackoff(maxAttempts int, initial, max time.Duration) RetryPolicy {
+	return BackoffPolicy(maxAttempts, endpoint.Jitter(endpoint.ExponentialBackoff(initial, max), 0.5))
+}
+
+// NoRetry is a RetryPolicy never retrying failed jobs.
+func NoRetry(int, error) (time.Duration, bool) { return 0, false }
+
+// Permanent wraps err, so that the job that failed with it isn't retried,
+// whatever the retry policy, e.g. for invalid jobs.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
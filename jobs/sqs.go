@@ -0,0 +1,172 @@
+package jobs
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// SQS limits the delay of messages to 15 minutes, and their visibility
+// timeout to 12 hours. Visibility timeouts are extended every half of the
+// timeout, in whole seconds, so they can't be shorter than 2 seconds.
+const (
+	sqsMaxDelay      = 15 * time.Minute
+	sqsMinVisibility = 2 * time.Second
+	sqsMaxVisibility = 12 * time.Hour
+)
+
+// SQSBackend is a Backend storing jobs in Amazon SQS queues, named by their
+// URL. Job bodies are base64-encoded, as SQS only accepts some Unicode
+// characters in messages. Received messages that aren't valid base64, e.g.
+// sent by other producers, are delivered as is.
+//
+// Jobs are settled by deleting their message, or by changing its visibility
+// timeout to the retry delay. While a job runs, its visibility timeout is
+// extended every half of the timeout, so that it isn't delivered again to
+// another worker, however long it runs.
+type SQSBackend struct {
+	client     sqsiface.SQSAPI
+	wait       time.Duration
+	visibility time.Duration
+}
+
+// SQSOption sets an optional parameter for SQS backends.
+type SQSOption func(*SQSBackend)
+
+// SQSVisibilityTimeout sets the visibility timeout of received messages, and
+// thus how long a job is kept from other workers if its worker dies. By
+// default, it's 30 seconds. It's rounded down to whole seconds, and clamped
+// between 2 seconds and 12 hours.
+func SQSVisibilityTimeout(d time.Duration) SQSOption {
+	return func(b *SQSBackend) {
+		b.visibility = min(max(d, sqsMinVisibility), sqsMaxVisibility).Truncate(time.Second)
+	}
+}
+
+// NewSQSBackend returns an SQSBackend using the client.
+func NewSQSBackend(client sqsiface.SQSAPI, options ...SQSOption) *SQSBackend {
+	b := &SQSBackend{client: client, wait: 20 * time.Second, visibility: 30 * time.Second}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// Enqueue implements Backend. Delays are capped to 15 minutes.
+func (b *SQSBackend) Enqueue(ctx context.Context, queue string, body []byte, delay time.Duration) error {
+	if delay > sqsMaxDelay {
+		delay = sqsMaxDelay
+	}
+	_, err := b.client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(queue),
+		MessageBody:  aws.String(base64.StdEncoding.EncodeToString(body)),
+		DelaySeconds: aws.Int64(int64(delay / time.Second)),
+	})
+	return err
+}
+
+// Receive implements Backend, long polling the queue.
+func (b *SQSBackend) Receive(ctx context.Context, queue string) (Delivery, error) {
+	for {
+		out, err := b.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queue),
+			MaxNumberOfMessages: aws.Int64(1),
+			WaitTimeSeconds:     aws.Int64(int64(b.wait / time.Second)),
+			VisibilityTimeout:   aws.Int64(int64(b.visibility / time.Second)),
+			AttributeNames:      []*string{aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(out.Messages) > 0 {
+			return newSQSDelivery(b, queue, out.Messages[0]), nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type sqsDelivery struct {
+	backend *SQSBackend
+	queue   string
+	msg     *sqs.Message
+	body    []byte
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+func newSQSDelivery(b *SQSBackend, queue string, msg *sqs.Message) *sqsDelivery {
+	body, err := base64.StdEncoding.DecodeString(aws.StringValue(msg.Body))
+	if err != nil {
+		body = []byte(aws.StringValue(msg.Body))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &sqsDelivery{backend: b, queue: queue, msg: msg, body: body, stop: cancel, stopped: make(chan struct{})}
+	go d.extend(ctx)
+	return d
+}
+
+// extend extends the visibility timeout of the message until ctx is done.
+// Failures are ignored: at worst, the job is delivered again.
+func (d *sqsDelivery) extend(ctx context.Context) {
+	defer close(d.stopped)
+	ticker := time.NewTicker(d.backend.visibility / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.backend.client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(d.queue),
+				ReceiptHandle:     d.msg.ReceiptHandle,
+				VisibilityTimeout: aws.Int64(int64(d.backend.visibility / time.Second)),
+			})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// settle stops extending the visibility timeout, before the delivery is
+// settled.
+func (d *sqsDelivery) settle() {
+	d.stop()
+	<-d.stopped
+}
+
+func (d *sqsDelivery) Body() []byte { return d.body }
+
+func (d *sqsDelivery) Attempt() int {
+	n, err := strconv.Atoi(aws.StringValue(d.msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (d *sqsDelivery) Ack(ctx context.Context) error {
+	d.settle()
+	_, err := d.backend.client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(d.queue),
+		ReceiptHandle: d.msg.ReceiptHandle,
+	})
+	return err
+}
+
+func (d *sqsDelivery) Retry(ctx context.Context, delay time.Duration) error {
+	d.settle()
+	if delay > sqsMaxVisibility {
+		delay = sqsMaxVisibility
+	}
+	_, err := d.backend.client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(d.queue),
+		ReceiptHandle:     d.msg.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(int64(delay / time.Second)),
+	})
+	return err
+}
@@ -0,0 +1,176 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	"github.com/a69/kit.go/jobs"
+)
+
+// fakeSQS records the calls of an SQSBackend.
+type fakeSQS struct {
+	sqsiface.SQSAPI
+	mtx        sync.Mutex
+	sent       []*sqs.SendMessageInput
+	received   []*sqs.Message
+	deleted    []string
+	visibility map[string]int64
+	extended   map[string]int
+}
+
+func (f *fakeSQS) SendMessageWithContext(_ aws.Context, in *sqs.SendMessageInput, _ ...request.Option) (*sqs.SendMessageOutput, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.sent = append(f.sent, in)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ReceiveMessageWithContext(_ aws.Context, in *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	out := &sqs.ReceiveMessageOutput{}
+	if len(f.received) > 0 {
+		out.Messages, f.received = f.received[:1], f.received[1:]
+	}
+	return out, nil
+}
+
+func (f *fakeSQS) DeleteMessageWithContext(_ aws.Context, in *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleted = append(f.deleted, aws.StringValue(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibilityWithContext(_ aws.Context, in *sqs.ChangeMessageVisibilityInput, _ ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.visibility[aws.StringValue(in.ReceiptHandle)] = aws.Int64Value(in.VisibilityTimeout)
+	f.extended[aws.StringValue(in.ReceiptHandle)]++
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func TestSQSBackend(t *testing.T) {
+	const queue = "https://sqs.eu-west-1.amazonaws.com/123456789012/emails"
+	client := &fakeSQS{visibility: map[string]int64{}, extended: map[string]int{}}
+	backend := jobs.NewSQSBackend(client)
+	ctx := context.Background()
+
+	if err := backend.Enqueue(ctx, queue, []byte(`{"to":"alice"}`), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, len(client.sent); want != have {
+		t.Fatalf("want %d sent, have %d", want, have)
+	}
+	if want, have := int64(900), aws.Int64Value(client.sent[0].DelaySeconds); want != have {
+		t.Errorf("DelaySeconds: want %d, have %d", want, have)
+	}
+	if want, have := base64.StdEncoding.EncodeToString([]byte(`{"to":"alice"}`)), aws.StringValue(client.sent[0].MessageBody); want != have {
+		t.Errorf("MessageBody: want %s, have %s", want, have)
+	}
+
+	client.received = []*sqs.Message{{
+		Body:          client.sent[0].MessageBody,
+		ReceiptHandle: aws.String("r1"),
+		Attributes:    map[string]*string{sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String("2")},
+	}, {
+		Body:          aws.String(`{"to":"bob"}`),
+		ReceiptHandle: aws.String("r2"),
+	}}
+
+	d, err := backend.Receive(ctx, queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"to":"alice"}`, string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 2, d.Attempt(); want != have {
+		t.Errorf("Attempt: want %d, have %d", want, have)
+	}
+	if err := d.Retry(ctx, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int64(30), client.visibility["r1"]; want != have {
+		t.Errorf("VisibilityTimeout: want %d, have %d", want, have)
+	}
+
+	d, err = backend.Receive(ctx, queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, d.Attempt(); want != have {
+		t.Errorf("Attempt: want %d, have %d", want, have)
+	}
+	if err := d.Ack(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []string{"r2"}, client.deleted; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("deleted: want %v, have %v", want, have)
+	}
+}
+
+func TestSQSBackendExtendsVisibility(t *testing.T) {
+	client := &fakeSQS{visibility: map[string]int64{}, extended: map[string]int{}}
+	backend := jobs.NewSQSBackend(client, jobs.SQSVisibilityTimeout(2*time.Second))
+	client.received = []*sqs.Message{{
+		Body:          aws.String(base64.StdEncoding.EncodeToString([]byte("long job"))),
+		ReceiptHandle: aws.String("r1"),
+	}}
+
+	d, err := backend.Receive(context.Background(), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "long job", string(d.Body()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+
+	// The job runs longer than half of the visibility timeout.
+	time.Sleep(1200 * time.Millisecond)
+	client.mtx.Lock()
+	extended, visibility := client.extended["r1"], client.visibility["r1"]
+	client.mtx.Unlock()
+	if want, have := 1, extended; want != have {
+		t.Errorf("extensions: want %d, have %d", want, have)
+	}
+	if want, have := int64(2), visibility; want != have {
+		t.Errorf("VisibilityTimeout: want %d, have %d", want, have)
+	}
+
+	// Settling stops the extensions.
+	if err := d.Ack(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1200 * time.Millisecond)
+	client.mtx.Lock()
+	defer client.mtx.Unlock()
+	if want, have := 1, client.extended["r1"]; want != have {
+		t.Errorf("extensions after Ack: want %d, have %d", want, have)
+	}
+}
+
+func TestSQSBackendShortVisibility(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second, 500 * time.Millisecond} {
+		client := &fakeSQS{visibility: map[string]int64{}, extended: map[string]int{}}
+		backend := jobs.NewSQSBackend(client, jobs.SQSVisibilityTimeout(timeout))
+		client.received = []*sqs.Message{{Body: aws.String(""), ReceiptHandle: aws.String("r1")}}
+
+		// Extending the visibility every half of the timeout doesn't panic.
+		d, err := backend.Receive(context.Background(), "queue")
+		if err != nil {
+			t.Fatalf("%v: %v", timeout, err)
+		}
+		if err := d.Ack(context.Background()); err != nil {
+			t.Errorf("%v: %v", timeout, err)
+		}
+	}
+}
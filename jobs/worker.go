@@ -0,0 +1,222 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// Worker receives the jobs of a queue, and invokes an endpoint with each of
+// them, in a pool of goroutines.
+//
+// Jobs for which the endpoint returns an error, or a response whose Failed
+// method returns one, see endpoint.Failer, or panics, are retried as decided
+// by the retry policy. Panics are passed to the error handler as a
+// *transport.PanicError. Once the policy gives up, or if the job can't be decoded, or
+// the error was wrapped with Permanent, the job is moved to the dead-letter
+// queue, if any, and removed.
+type Worker[REQ any, RES any] struct {
+	backend      Backend
+	queue        string
+	e            endpoint.Endpoint[REQ, RES]
+	dec          DecodeFunc[REQ]
+	concurrency  int
+	timeout      time.Duration
+	retry        RetryPolicy
+	deadLetter   string
+	errorHandler transport.ErrorHandler
+	jobs         metrics.Counter
+	duration     metrics.Histogram
+	backoff      endpoint.Backoff // after failures of the backend
+}
+
+// defaultBackoff is how long workers wait after failures of the backend.
+var defaultBackoff = endpoint.Jitter(endpoint.ExponentialBackoff(time.Second, time.Minute), 0.2)
+
+// NewWorker returns a Worker invoking the endpoint with the jobs of the named
+// queue of the backend, decoded with dec.
+func NewWorker[REQ any, RES any](
+	backend Backend,
+	queue string,
+	e endpoint.Endpoint[REQ, RES],
+	dec DecodeFunc[REQ],
+	options ...WorkerOption[REQ, RES],
+) *Worker[REQ, RES] {
+	w := &Worker[REQ, RES]{
+		backend:      backend,
+		queue:        queue,
+		e:            e,
+		dec:          dec,
+		concurrency:  1,
+		retry:        ExponentialBackoff(5, time.Second, time.Minute),
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+		backoff:      defaultBackoff,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// WorkerOption sets an optional parameter for workers.
+type WorkerOption[REQ any, RES any] func(*Worker[REQ, RES])
+
+// WorkerConcurrency sets the number of jobs run at once. By default, it's 1.
+func WorkerConcurrency[REQ any, RES any](n int) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.concurrency = n }
+}
+
+// WorkerTimeout bounds the time each job may run. By default, jobs aren't
+// bounded.
+func WorkerTimeout[REQ any, RES any](timeout time.Duration) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.timeout = timeout }
+}
+
+// WorkerRetry sets the retry policy of failed jobs. By default, jobs are
+// attempted 5 times, with an ExponentialBackoff from 1s up to 1m.
+func WorkerRetry[REQ any, RES any](policy RetryPolicy) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.retry = policy }
+}
+
+// WorkerDeadLetter sets the queue, of the same backend, to which jobs are
+// moved once they failed for good. By default, they're dropped, after being
+// passed to the error handler.
+func WorkerDeadLetter[REQ any, RES any](queue string) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.deadLetter = queue }
+}
+
+// WorkerErrorHandler is used to handle the errors of jobs, and of the
+// backend. By default, errors are ignored.
+func WorkerErrorHandler[REQ any, RES any](errorHandler transport.ErrorHandler) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.errorHandler = errorHandler }
+}
+
+// WorkerMetrics counts the jobs in the counter, and observes their duration,
+// in seconds, in the histogram, both labeled with "outcome": "success",
+// "retry" or "dead". Either may be nil.
+func WorkerMetrics[REQ any, RES any](jobs metrics.Counter, duration metrics.Histogram) WorkerOption[REQ, RES] {
+	return func(w *Worker[REQ, RES]) { w.jobs, w.duration = jobs, duration }
+}
+
+// Run receives and runs jobs until ctx is done, and returns once the jobs in
+// progress are settled. Jobs in progress aren't canceled with ctx; use
+// WorkerTimeout to bound them.
+func (w *Worker[REQ, RES]) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker[REQ, RES]) loop(ctx context.Context) {
+	failures := 0
+	for {
+		d, err := w.backend.Receive(ctx, w.queue)
+		if ctx.Err() != nil {
+			if err == nil {
+				w.handle(context.WithoutCancel(ctx), d)
+			}
+			return
+		}
+		if err != nil {
+			w.errorHandler.Handle(ctx, err)
+			failures++
+			t := time.NewTimer(w.backoff(failures))
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+			continue
+		}
+		failures = 0
+		w.handle(context.WithoutCancel(ctx), d)
+	}
+}
+
+// handle runs the job, and settles its delivery.
+func (w *Worker[REQ, RES]) handle(ctx context.Context, d Delivery) {
+	start := time.Now()
+	err := w.run(ctx, d)
+
+	outcome := "success"
+	switch {
+	case err == nil:
+		err = d.Ack(ctx)
+	default:
+		w.errorHandler.Handle(ctx, err)
+		delay, retry := w.retry(d.Attempt(), err)
+		if retry && !IsPermanent(err) {
+			outcome = "retry"
+			err = d.Retry(ctx, delay)
+			break
+		}
+		outcome = "dead"
+		if w.deadLetter != "" {
+			if err = w.backend.Enqueue(ctx, w.deadLetter, d.Body(), 0); err != nil {
+				// Leave the job to be delivered again, rather than losing it,
+				// once the dead-letter queue may have recovered.
+				w.errorHandler.Handle(ctx, err)
+				err = d.Retry(ctx, w.backoff(d.Attempt()))
+				break
+			}
+		}
+		err = d.Ack(ctx)
+	}
+	if err != nil {
+		w.errorHandler.Handle(ctx, err)
+	}
+
+	if w.jobs != nil {
+		w.jobs.With("outcome", outcome).Add(1)
+	}
+	if w.duration != nil {
+		w.duration.With("outcome", outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (w *Worker[REQ, RES]) run(ctx context.Context, d Delivery) error {
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+	job, err := w.decode(ctx, d.Body())
+	if err != nil {
+		return Permanent(err)
+	}
+	response, err := w.invoke(ctx, job)
+	if err != nil {
+		return err
+	}
+	return endpoint.Failed(response)
+}
+
+// decode recovers panics as errors, as does invoke, so that a panicking job
+// fails like any other rather than crashing the worker.
+func (w *Worker[REQ, RES]) decode(ctx context.Context, body []byte) (job REQ, err error) {
+	defer recoverPanic(transport.PhaseDecode, &err)
+	return w.dec(ctx, body)
+}
+
+func (w *Worker[REQ, RES]) invoke(ctx context.Context, job REQ) (response RES, err error) {
+	defer recoverPanic(transport.PhaseEndpoint, &err)
+	return w.e(ctx, job)
+}
+
+func recoverPanic(phase transport.Phase, err *error) {
+	if v := recover(); v != nil {
+		*err = transport.NewPanicError(phase, v)
+	}
+}
@@ -0,0 +1,247 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/jobs"
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/transport"
+)
+
+type email struct {
+	To string `json:"to"`
+}
+
+// outcomes is a metrics.Counter counting jobs by outcome.
+type outcomes struct {
+	mtx    *sync.Mutex
+	counts map[string]int
+	label  string
+}
+
+func newOutcomes() outcomes {
+	return outcomes{mtx: &sync.Mutex{}, counts: map[string]int{}}
+}
+
+func (o outcomes) With(labelValues ...string) metrics.Counter {
+	o.label = labelValues[1]
+	return o
+}
+
+func (o outcomes) Add(delta float64) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.counts[o.label] += int(delta)
+}
+
+func (o outcomes) get(label string) int {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.counts[label]
+}
+
+// runUntil runs the worker until the condition holds, or the test times out.
+func runUntil(t *testing.T, w interface{ Run(context.Context) }, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func TestWorker(t *testing.T) {
+	var (
+		backend = jobs.NewMemoryBackend()
+		queue   = jobs.NewQueue(backend, "emails", jobs.EncodeJSON[email])
+		counts  = newOutcomes()
+		mtx     sync.Mutex
+		sent    = map[string]int{}
+		errDown = errors.New("mail server down")
+	)
+	e := func(_ context.Context, job email) (struct{}, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		sent[job.To]++
+		switch {
+		case job.To == "flaky" && sent[job.To] < 3:
+			return struct{}{}, errDown
+		case job.To == "broken":
+			return struct{}{}, errDown
+		case job.To == "invalid":
+			return struct{}{}, jobs.Permanent(errors.New("invalid address"))
+		}
+		return struct{}{}, nil
+	}
+	w := jobs.NewWorker(backend, "emails", e, jobs.DecodeJSON[email],
+		jobs.WorkerConcurrency[email, struct{}](4),
+		jobs.WorkerRetry[email, struct{}](jobs.ExponentialBackoff(3, time.Millisecond, 2*time.Millisecond)),
+		jobs.WorkerDeadLetter[email, struct{}]("emails.dead"),
+		jobs.WorkerMetrics[email, struct{}](counts, nil),
+	)
+
+	for _, to := range []string{"alice", "flaky", "broken", "invalid"} {
+		if err := queue.Enqueue(context.Background(), email{To: to}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend.Enqueue(context.Background(), "emails", []byte("not json"), 0)
+
+	runUntil(t, w, func() bool { return counts.get("success")+counts.get("dead") == 5 })
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	for to, want := range map[string]int{"alice": 1, "flaky": 3, "broken": 3, "invalid": 1} {
+		if have := sent[to]; want != have {
+			t.Errorf("%s: want %d attempts, have %d", to, want, have)
+		}
+	}
+	for outcome, want := range map[string]int{"success": 2, "retry": 4, "dead": 3} {
+		if have := counts.get(outcome); want != have {
+			t.Errorf("%s: want %d, have %d", outcome, want, have)
+		}
+	}
+	if want, have := 3, backend.Len("emails.dead"); want != have {
+		t.Errorf("dead letters: want %d, have %d", want, have)
+	}
+	if want, have := 0, backend.Len("emails"); want != have {
+		t.Errorf("remaining: want %d, have %d", want, have)
+	}
+}
+
+type failed struct{ err error }
+
+func (r failed) Failed() error { return r.err }
+
+func TestWorkerFailer(t *testing.T) {
+	var (
+		backend = jobs.NewMemoryBackend()
+		counts  = newOutcomes()
+	)
+	w := jobs.NewWorker(backend, "q",
+		func(context.Context, email) (failed, error) { return failed{errors.New("business error")}, nil },
+		jobs.DecodeJSON[email],
+		jobs.WorkerRetry[email, failed](jobs.NoRetry),
+		jobs.WorkerMetrics[email, failed](counts, nil),
+	)
+	jobs.NewQueue(backend, "q", jobs.EncodeJSON[email]).Enqueue(context.Background(), email{To: "alice"})
+
+	runUntil(t, w, func() bool { return counts.get("dead") == 1 })
+	if want, have := 0, counts.get("success"); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	policy := jobs.ExponentialBackoff(4, time.Second, 3*time.Second)
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 3 * time.Second} {
+		delay, retry := policy(attempt, errors.New("dang"))
+		if !retry {
+			t.Errorf("attempt %d: want retry", attempt)
+		}
+		if delay < max/2 || delay > max*3/2 {
+			t.Errorf("attempt %d: want delay in [%s, %s], have %s", attempt, max/2, max*3/2, delay)
+		}
+	}
+	if _, retry := policy(4, errors.New("dang")); retry {
+		t.Error("attempt 4: want no retry")
+	}
+}
+
+func TestWorkerPanic(t *testing.T) {
+	var (
+		backend = jobs.NewMemoryBackend()
+		counts  = newOutcomes()
+		errs    = make(chan error, 1)
+	)
+	e := func(context.Context, email) (struct{}, error) { panic("boom") }
+	w := jobs.NewWorker(backend, "emails", e, jobs.DecodeJSON[email],
+		jobs.WorkerRetry[email, struct{}](jobs.NoRetry),
+		jobs.WorkerMetrics[email, struct{}](counts, nil),
+		jobs.WorkerErrorHandler[email, struct{}](transport.ErrorHandlerFunc(func(_ context.Context, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})),
+	)
+	jobs.NewQueue(backend, "emails", jobs.EncodeJSON[email]).Enqueue(context.Background(), email{To: "alice"})
+
+	runUntil(t, w, func() bool { return counts.get("dead") == 1 })
+	var pe *transport.PanicError
+	if err := <-errs; !errors.As(err, &pe) || pe.Phase != transport.PhaseEndpoint {
+		t.Errorf("want a panic error in the endpoint, have %v", err)
+	}
+}
+
+// failingDeadLetters fails to enqueue to its dead-letter queue, and records
+// the delays of the retries.
+type failingDeadLetters struct {
+	*jobs.MemoryBackend
+	mtx     sync.Mutex
+	retries []time.Duration
+}
+
+func (b *failingDeadLetters) Enqueue(ctx context.Context, queue string, body []byte, delay time.Duration) error {
+	if queue == "dead" {
+		return errors.New("dead-letter queue down")
+	}
+	return b.MemoryBackend.Enqueue(ctx, queue, body, delay)
+}
+
+func (b *failingDeadLetters) Receive(ctx context.Context, queue string) (jobs.Delivery, error) {
+	d, err := b.MemoryBackend.Receive(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+	return recordingDelivery{d, b}, nil
+}
+
+func (b *failingDeadLetters) retried() []time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return append([]time.Duration(nil), b.retries...)
+}
+
+type recordingDelivery struct {
+	jobs.Delivery
+	backend *failingDeadLetters
+}
+
+func (d recordingDelivery) Retry(ctx context.Context, delay time.Duration) error {
+	d.backend.mtx.Lock()
+	d.backend.retries = append(d.backend.retries, delay)
+	d.backend.mtx.Unlock()
+	return d.Delivery.Retry(ctx, delay)
+}
+
+func TestWorkerDeadLetterFailure(t *testing.T) {
+	backend := &failingDeadLetters{MemoryBackend: jobs.NewMemoryBackend()}
+	e := func(context.Context, email) (struct{}, error) { return struct{}{}, errors.New("dang") }
+	w := jobs.NewWorker(backend, "emails", e, jobs.DecodeJSON[email],
+		jobs.WorkerRetry[email, struct{}](jobs.NoRetry),
+		jobs.WorkerDeadLetter[email, struct{}]("dead"),
+	)
+	jobs.NewQueue(backend, "emails", jobs.EncodeJSON[email]).Enqueue(context.Background(), email{To: "alice"})
+
+	// The job is left to be delivered again, after a backoff rather than
+	// right away.
+	runUntil(t, w, func() bool { return len(backend.retried()) > 0 })
+	if delay := backend.retried()[0]; delay < 500*time.Millisecond {
+		t.Errorf("want a backoff before the job is delivered again, have %v", delay)
+	}
+}
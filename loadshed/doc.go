@@ -0,0 +1,8 @@
+// Package loadshed implements load shedding: under overload, rejecting some
+// requests right away, so that the others still complete within their SLO,
+// rather than all of them slowing down until they time out.
+//
+// Overload is detected from a pluggable Signal, such as the Go scheduler
+// latency, the number of requests in flight, or a latency percentile, and
+// requests are shed by priority: sheddable ones first, critical ones never.
+package loadshed
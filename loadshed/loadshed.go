@@ -0,0 +1,130 @@
+package loadshed
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics"
+)
+
+// ErrShed is returned by the Middleware for requests it rejects.
+var ErrShed = errors.New("request shed due to overload")
+
+// Signal reports the current load of the service, e.g. a latency in seconds,
+// a CPU utilization or a queue depth. It's called for every request, so it
+// must be cheap; signals that are expensive to compute should be sampled in
+// the background, like SchedulerLatency does.
+type Signal interface {
+	Load() float64
+}
+
+// SignalFunc is an adapter to allow the use of ordinary functions as Signals.
+type SignalFunc func() float64
+
+// Load calls f().
+func (f SignalFunc) Load() float64 { return f() }
+
+// Priority is the class of a request, which decides how early it's shed.
+type Priority int
+
+const (
+	// PrioritySheddable requests, like prefetches, batch jobs or retries,
+	// are shed first: from the low threshold on.
+	PrioritySheddable Priority = iota
+
+	// PriorityDefault requests are shed from halfway between the low and
+	// high thresholds on. Requests without a priority have this one.
+	PriorityDefault
+
+	// PriorityCritical requests, like health checks or checkouts, are never
+	// shed.
+	PriorityCritical
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PrioritySheddable:
+		return "sheddable"
+	case PriorityDefault:
+		return "default"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context carrying the priority of the request,
+// typically set by a transport RequestFunc from a header or the method name.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority of the request, which is
+// PriorityDefault unless set with WithPriority.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityDefault
+}
+
+// Option sets an optional parameter for the Middleware.
+type Option func(*options)
+
+type options struct {
+	shed metrics.Counter
+}
+
+// ShedCounter sets a counter incremented for every request shed, labeled
+// with "priority".
+func ShedCounter(c metrics.Counter) Option {
+	return func(o *options) { o.shed = c }
+}
+
+// Middleware returns an endpoint.Middleware that sheds requests once the load
+// reported by the signal exceeds low. The fraction of requests shed grows
+// linearly with the load, until all requests are shed once it reaches high:
+// sheddable requests over the whole range, default ones over its upper half,
+// and critical ones never. Shed requests fail with ErrShed, without calling
+// the endpoint.
+func Middleware[REQ any, RES any](signal Signal, low, high float64, opts ...Option) endpoint.Middleware[REQ, RES] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			p := PriorityFromContext(ctx)
+			if p < PriorityCritical {
+				if f := shedFraction(signal.Load(), low, high, p); f > 0 && rand.Float64() < f {
+					if o.shed != nil {
+						o.shed.With("priority", p.String()).Add(1)
+					}
+					return response, ErrShed
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// shedFraction returns the fraction of requests of the given priority to
+// shed at the given load.
+func shedFraction(load, low, high float64, p Priority) float64 {
+	if p == PriorityDefault {
+		low = (low + high) / 2
+	}
+	switch {
+	case load <= low:
+		return 0
+	case load >= high:
+		return 1
+	default:
+		return (load - low) / (high - low)
+	}
+}
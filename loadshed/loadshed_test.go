@@ -0,0 +1,109 @@
+package loadshed_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/loadshed"
+)
+
+func TestMiddleware(t *testing.T) {
+	var (
+		load float64
+		ep   = loadshed.Middleware[struct{}, struct{}](loadshed.SignalFunc(func() float64 { return load }), 10, 20)(endpoint.Nop)
+		shed = func(ctx context.Context) (n int) {
+			for i := 0; i < 1000; i++ {
+				if _, err := ep(ctx, struct{}{}); errors.Is(err, loadshed.ErrShed) {
+					n++
+				}
+			}
+			return n
+		}
+		sheddable = loadshed.WithPriority(context.Background(), loadshed.PrioritySheddable)
+		critical  = loadshed.WithPriority(context.Background(), loadshed.PriorityCritical)
+	)
+
+	for _, tc := range []struct {
+		load                        float64
+		sheddable, normal, critical [2]int // min, max shed out of 1000
+	}{
+		{load: 5, sheddable: [2]int{0, 0}, normal: [2]int{0, 0}, critical: [2]int{0, 0}},
+		{load: 15, sheddable: [2]int{400, 600}, normal: [2]int{0, 0}, critical: [2]int{0, 0}},
+		{load: 17.5, sheddable: [2]int{650, 850}, normal: [2]int{400, 600}, critical: [2]int{0, 0}},
+		{load: 25, sheddable: [2]int{1000, 1000}, normal: [2]int{1000, 1000}, critical: [2]int{0, 0}},
+	} {
+		load = tc.load
+		for name, c := range map[string]struct {
+			ctx    context.Context
+			bounds [2]int
+		}{
+			"sheddable": {sheddable, tc.sheddable},
+			"default":   {context.Background(), tc.normal},
+			"critical":  {critical, tc.critical},
+		} {
+			if n := shed(c.ctx); n < c.bounds[0] || n > c.bounds[1] {
+				t.Errorf("load %v, %s: want %d..%d shed, have %d", tc.load, name, c.bounds[0], c.bounds[1], n)
+			}
+		}
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	var (
+		f       loadshed.InFlight
+		release = make(chan struct{})
+		started = make(chan struct{})
+		ep      = loadshed.Track[struct{}, struct{}](&f)(func(context.Context, struct{}) (struct{}, error) {
+			started <- struct{}{}
+			<-release
+			return struct{}{}, nil
+		})
+	)
+	for i := 0; i < 3; i++ {
+		go ep(context.Background(), struct{}{})
+		<-started
+	}
+	if want, have := 3.0, f.Load(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for f.Load() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if want, have := 0.0, f.Load(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSchedulerLatency(t *testing.T) {
+	s := loadshed.NewSchedulerLatency(10 * time.Millisecond)
+	defer s.Stop()
+
+	done := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					time.Sleep(time.Microsecond)
+				}
+			}
+		}()
+	}
+	defer close(done)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.Load() <= 0 {
+		t.Errorf("want positive scheduler latency, have %v", s.Load())
+	}
+	s.Stop() // idempotent
+}
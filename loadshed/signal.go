@@ -0,0 +1,127 @@
+package loadshed
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// InFlight is a Signal reporting the number of requests in flight, i.e. the
+// depth of the service's queue. Requests are counted by the Track middleware.
+type InFlight struct {
+	n atomic.Int64
+}
+
+// Load implements Signal.
+func (f *InFlight) Load() float64 { return float64(f.n.Load()) }
+
+// Track returns an endpoint.Middleware counting the requests in flight in f.
+// Put it inside the shedding Middleware, so that shed requests aren't
+// counted.
+func Track[REQ any, RES any](f *InFlight) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (RES, error) {
+			f.n.Add(1)
+			defer f.n.Add(-1)
+			return next(ctx, request)
+		}
+	}
+}
+
+const schedLatencies = "/sched/latencies:seconds"
+
+// SchedulerLatency is a Signal reporting the 99th percentile of the time
+// goroutines spent waiting to run, in seconds, over the last sampling
+// interval. It rises as soon as the process runs out of CPU, well before
+// request latencies do, which makes it a good general-purpose overload
+// signal.
+type SchedulerLatency struct {
+	load atomic.Uint64 // float64 bits
+
+	once  sync.Once
+	quitc chan struct{}
+}
+
+// NewSchedulerLatency returns a SchedulerLatency sampling the runtime's
+// scheduler latency histogram every interval, until stopped.
+func NewSchedulerLatency(interval time.Duration) *SchedulerLatency {
+	s := &SchedulerLatency{quitc: make(chan struct{})}
+	go s.loop(interval)
+	return s
+}
+
+// Load implements Signal.
+func (s *SchedulerLatency) Load() float64 {
+	return math.Float64frombits(s.load.Load())
+}
+
+// Stop stops sampling.
+func (s *SchedulerLatency) Stop() {
+	s.once.Do(func() { close(s.quitc) })
+}
+
+func (s *SchedulerLatency) loop(interval time.Duration) {
+	sample := []metrics.Sample{{Name: schedLatencies}}
+	metrics.Read(sample)
+	prev := copyHistogram(sample[0].Value)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			metrics.Read(sample)
+			cur := copyHistogram(sample[0].Value)
+			s.load.Store(math.Float64bits(percentile(prev, cur, 0.99)))
+			prev = cur
+		case <-s.quitc:
+			return
+		}
+	}
+}
+
+func copyHistogram(v metrics.Value) *metrics.Float64Histogram {
+	if v.Kind() != metrics.KindFloat64Histogram {
+		return nil
+	}
+	h := v.Float64Histogram()
+	return &metrics.Float64Histogram{
+		Counts:  append([]uint64(nil), h.Counts...),
+		Buckets: h.Buckets,
+	}
+}
+
+// percentile returns the upper bound of the bucket holding the q quantile of
+// the observations made between prev and cur.
+func percentile(prev, cur *metrics.Float64Histogram, q float64) float64 {
+	if prev == nil || cur == nil || len(prev.Counts) != len(cur.Counts) {
+		return 0
+	}
+	var total uint64
+	for i := range cur.Counts {
+		total += cur.Counts[i] - prev.Counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	var (
+		rank = uint64(math.Ceil(q * float64(total)))
+		seen uint64
+	)
+	for i := range cur.Counts {
+		seen += cur.Counts[i] - prev.Counts[i]
+		if seen >= rank {
+			upper := cur.Buckets[i+1]
+			if math.IsInf(upper, 1) {
+				upper = cur.Buckets[i]
+			}
+			return upper
+		}
+	}
+	return 0
+}
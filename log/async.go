@@ -0,0 +1,210 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDropped is returned by an AsyncLogger's Log method when the record was
+// dropped because the buffer was full.
+var ErrDropped = errors.New("log record dropped: buffer full")
+
+// ErrClosed is returned by an AsyncLogger's Log and Flush methods once it's
+// closed.
+var ErrClosed = errors.New("logger closed")
+
+// OverflowPolicy decides what an AsyncLogger does with a record when its
+// buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the buffer, so that no record is lost,
+	// at the cost of making callers as slow as the wrapped logger.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the record being logged.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest buffered record to make room for
+	// the one being logged.
+	OverflowDropOldest
+)
+
+// AsyncOption sets an optional parameter for an AsyncLogger.
+type AsyncOption func(*asyncOptions)
+
+type asyncOptions struct {
+	size         int
+	overflow     OverflowPolicy
+	errorHandler func(error)
+}
+
+// AsyncBufferSize sets the number of records the AsyncLogger buffers. The
+// default is 1024.
+func AsyncBufferSize(n int) AsyncOption {
+	return func(o *asyncOptions) { o.size = n }
+}
+
+// AsyncOverflow sets what happens to records logged while the buffer is
+// full. The default is OverflowBlock.
+func AsyncOverflow(policy OverflowPolicy) AsyncOption {
+	return func(o *asyncOptions) { o.overflow = policy }
+}
+
+// AsyncErrorHandler sets a function called with the errors returned by the
+// wrapped logger, which would otherwise be lost, since they occur after Log
+// returned. It's called from the background goroutine.
+func AsyncErrorHandler(f func(error)) AsyncOption {
+	return func(o *asyncOptions) { o.errorHandler = f }
+}
+
+type asyncRecord struct {
+	keyvals []interface{}
+	flushed chan struct{} // set for flush markers only
+}
+
+// AsyncLogger is a Logger that buffers records and passes them to the wrapped
+// logger from a background goroutine, which takes encoding and writing off
+// the hot path of callers.
+//
+// Keyvals are copied, and Valuers bound with With are evaluated, when Log is
+// called, but the values themselves are only encoded later. Values that may be
+// modified after being logged, like maps or pointers to structs, must not be
+// logged, or must be copied first.
+//
+// Call Close when shutting down, so that buffered records aren't lost.
+type AsyncLogger struct {
+	next    Logger
+	opts    asyncOptions
+	queue   chan asyncRecord
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mtx    sync.RWMutex
+	closed bool
+}
+
+// NewAsyncLogger returns an AsyncLogger wrapping next, and starts its
+// background goroutine.
+func NewAsyncLogger(next Logger, options ...AsyncOption) *AsyncLogger {
+	opts := asyncOptions{size: 1024}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.size < 1 {
+		opts.size = 1
+	}
+	l := &AsyncLogger{
+		next:  next,
+		opts:  opts,
+		queue: make(chan asyncRecord, opts.size),
+		done:  make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+// Log implements Logger. It only returns an error if the record was dropped,
+// or the logger is closed; errors of the wrapped logger go to the
+// AsyncErrorHandler.
+func (l *AsyncLogger) Log(keyvals ...interface{}) error {
+	r := asyncRecord{keyvals: append([]interface{}(nil), keyvals...)}
+
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	if l.closed {
+		return ErrClosed
+	}
+
+	switch l.opts.overflow {
+	case OverflowDropNewest:
+		select {
+		case l.queue <- r:
+		default:
+			l.dropped.Add(1)
+			return ErrDropped
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case l.queue <- r:
+				return nil
+			default:
+			}
+			select {
+			case old := <-l.queue:
+				if old.flushed != nil {
+					// Never drop a flush marker; put it back and wait.
+					l.queue <- old
+					continue
+				}
+				l.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		l.queue <- r
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped so far because the buffer
+// was full.
+func (l *AsyncLogger) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Flush waits until all records logged before it was called are passed to
+// the wrapped logger, or ctx is done.
+func (l *AsyncLogger) Flush(ctx context.Context) error {
+	marker := asyncRecord{flushed: make(chan struct{})}
+
+	l.mtx.RLock()
+	if l.closed {
+		l.mtx.RUnlock()
+		return ErrClosed
+	}
+	select {
+	case l.queue <- marker:
+		l.mtx.RUnlock()
+	case <-ctx.Done():
+		l.mtx.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-marker.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close passes the buffered records to the wrapped logger, and stops the
+// background goroutine. Records logged after Close are rejected with
+// ErrClosed. Close is safe to call more than once.
+func (l *AsyncLogger) Close() error {
+	l.mtx.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.queue)
+	}
+	l.mtx.Unlock()
+	<-l.done
+	return nil
+}
+
+func (l *AsyncLogger) loop() {
+	defer close(l.done)
+	for r := range l.queue {
+		if r.flushed != nil {
+			close(r.flushed)
+			continue
+		}
+		if err := l.next.Log(r.keyvals...); err != nil && l.opts.errorHandler != nil {
+			l.opts.errorHandler(err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package log_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/a69/kit.go/log"
+)
+
+type blockingLogger struct {
+	mtx     sync.Mutex
+	release chan struct{}
+	records [][]interface{}
+}
+
+func (l *blockingLogger) Log(keyvals ...interface{}) error {
+	<-l.release
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.records = append(l.records, keyvals)
+	return nil
+}
+
+func (l *blockingLogger) values() []interface{} {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	var values []interface{}
+	for _, r := range l.records {
+		values = append(values, r[1])
+	}
+	return values
+}
+
+func TestAsyncLoggerFlushAndClose(t *testing.T) {
+	next := &blockingLogger{release: make(chan struct{})}
+	close(next.release)
+	logger := log.NewAsyncLogger(next)
+
+	keyvals := []interface{}{"n", 1}
+	logger.Log(keyvals...)
+	keyvals[1] = 2 // keyvals are copied
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []interface{}{1}, next.values(); len(have) != 1 || want[0] != have[0] {
+		t.Fatalf("want %v, have %v", want, have)
+	}
+
+	logger.Log("n", 3)
+	logger.Close()
+	if want, have := 2, len(next.values()); want != have {
+		t.Errorf("want %d records after Close, have %d", want, have)
+	}
+	if want, have := log.ErrClosed, logger.Log("n", 4); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	logger.Close() // no panic
+}
+
+func TestAsyncLoggerDropNewest(t *testing.T) {
+	next := &blockingLogger{release: make(chan struct{})}
+	logger := log.NewAsyncLogger(next, log.AsyncBufferSize(2), log.AsyncOverflow(log.OverflowDropNewest))
+
+	// The background goroutine holds one record while blocked, so the buffer
+	// fills up after three.
+	var dropped int
+	for i := 0; i < 10; i++ {
+		if err := logger.Log("n", i); errors.Is(err, log.ErrDropped) {
+			dropped++
+		}
+	}
+	close(next.release)
+	logger.Close()
+
+	if want, have := uint64(dropped), logger.Dropped(); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := 10, dropped+len(next.values()); want != have {
+		t.Errorf("want %d records written or dropped, have %d", want, have)
+	}
+	if values := next.values(); len(values) < 2 || values[0] != 0 || values[1] != 1 {
+		t.Errorf("oldest records weren't kept: %v", values)
+	}
+}
+
+func TestAsyncLoggerDropOldest(t *testing.T) {
+	next := &blockingLogger{release: make(chan struct{})}
+	logger := log.NewAsyncLogger(next, log.AsyncBufferSize(2), log.AsyncOverflow(log.OverflowDropOldest))
+
+	for i := 0; i < 10; i++ {
+		if err := logger.Log("n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(next.release)
+	logger.Close()
+
+	values := next.values()
+	if want, have := 10, int(logger.Dropped())+len(values); want != have {
+		t.Errorf("want %d records written or dropped, have %d", want, have)
+	}
+	if last := values[len(values)-1]; last != 9 {
+		t.Errorf("newest record wasn't kept: %v", values)
+	}
+}
+
+func TestAsyncLoggerFlushContext(t *testing.T) {
+	next := &blockingLogger{release: make(chan struct{})}
+	logger := log.NewAsyncLogger(next)
+	logger.Log("n", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if want, have := context.Canceled, logger.Flush(ctx); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	close(next.release)
+	logger.Close()
+}
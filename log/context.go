@@ -0,0 +1,30 @@
+package log
+
+import "context"
+
+// ContextFunc returns keyvals describing the request ctx belongs to, e.g.
+// its trace and span IDs, or nil if there are none. The tracing packages
+// provide ContextFuncs for their tracers, and the correlation package one for
+// request IDs.
+type ContextFunc func(ctx context.Context) (keyvals []interface{})
+
+// WithContext returns a logger adding the keyvals returned by the
+// ContextFuncs for ctx to every log record, so that the records logged while
+// handling a request can be correlated with each other and with its trace:
+//
+//	logger := log.WithContext(ctx, logger, zipkin.LogKeyvals, correlation.LogKeyvals)
+//	logger.Log("msg", "charging card") // trace_id=… span_id=… request_id=… msg="charging card"
+//
+// The keyvals are computed once, when WithContext is called, so call it again
+// for a context with a different span. If none of the ContextFuncs return
+// keyvals, logger is returned as is.
+func WithContext(ctx context.Context, logger Logger, fs ...ContextFunc) Logger {
+	var keyvals []interface{}
+	for _, f := range fs {
+		keyvals = append(keyvals, f(ctx)...)
+	}
+	if len(keyvals) == 0 {
+		return logger
+	}
+	return With(logger, keyvals...)
+}
@@ -0,0 +1,25 @@
+package log_test
+
+import (
+	"context"
+	"os"
+
+	"github.com/a69/kit.go/log"
+)
+
+func Example_context() {
+	type requestIDKey struct{}
+	requestID := func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"request_id", id}
+		}
+		return nil
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "7f3a")
+	logger := log.WithContext(ctx, log.NewLogfmtLogger(os.Stdout), requestID)
+	logger.Log("msg", "charging card")
+
+	// Output:
+	// request_id=7f3a msg="charging card"
+}
@@ -135,3 +135,26 @@ func Example_syncWriter() {
 	// taskID=1 event="task complete"
 	// taskID=2 event="task complete"
 }
+
+func Example_jsonPreset() {
+	logger := log.NewJSONLoggerPreset(os.Stdout, log.PresetGCP, log.PresetGCPProject("my-project"))
+
+	logger.Log(
+		"level", "warn",
+		"msg", "cache miss",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	)
+
+	// Output:
+	// {"logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":true,"message":"cache miss","severity":"WARNING"}
+}
+
+func Example_async() {
+	logger := log.NewAsyncLogger(log.NewLogfmtLogger(os.Stdout), log.AsyncBufferSize(4096))
+	defer logger.Close()
+
+	logger.Log("msg", "handled request", "took", "1ms")
+
+	// Output:
+	// msg="handled request" took=1ms
+}
@@ -0,0 +1,223 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-kit/log"
+)
+
+// JSONPreset selects the field conventions used by NewJSONLoggerPreset.
+type JSONPreset int
+
+const (
+	// PresetDefault leaves keys untouched, like NewJSONLogger.
+	PresetDefault JSONPreset = iota
+
+	// PresetECS follows the Elastic Common Schema: @timestamp, log.level,
+	// message, trace.id, span.id.
+	PresetECS
+
+	// PresetGCP follows Google Cloud Logging's structured logging fields:
+	// time, severity, message, logging.googleapis.com/trace,
+	// logging.googleapis.com/spanId and logging.googleapis.com/trace_sampled.
+	// Cloud Logging expects the trace as a resource name that includes the
+	// project, so trace IDs are only written to logging.googleapis.com/trace
+	// when the project is given with PresetGCPProject; otherwise they keep
+	// the "trace_id" key.
+	PresetGCP
+
+	// PresetOTel follows the OpenTelemetry log data model: Timestamp,
+	// SeverityText, SeverityNumber, Body, TraceId, SpanId, TraceFlags.
+	PresetOTel
+)
+
+// Conventional keys recognized by NewJSONLoggerPreset.
+const (
+	presetTimestampKey   = "ts"
+	presetLevelKey       = "level"
+	presetMessageKey     = "msg"
+	presetTraceIDKey     = "trace_id"
+	presetSpanIDKey      = "span_id"
+	presetTraceFlagsKey  = "trace_flags"
+	presetTraceParentKey = "traceparent"
+)
+
+// PresetOption sets an optional parameter for NewJSONLoggerPreset.
+type PresetOption func(*presetOptions)
+
+type presetOptions struct {
+	gcpProject string
+}
+
+// PresetGCPProject sets the Google Cloud project ID used by PresetGCP to
+// format trace IDs as "projects/<project>/traces/<trace id>".
+func PresetGCPProject(project string) PresetOption {
+	return func(o *presetOptions) { o.gcpProject = project }
+}
+
+// NewJSONLoggerPreset returns a Logger that encodes keyvals to the Writer as a
+// single JSON object, like NewJSONLogger, but first renames the conventional
+// keys and translates level values to match the field names expected by the
+// given log pipeline. Keys that aren't recognized are passed through as-is.
+//
+// The recognized keys are the ones used by DefaultTimestamp, the level package
+// and most Go kit components: "ts", "level", "msg", "trace_id", "span_id" and
+// "trace_flags". A "traceparent" key holding a W3C traceparent header value is
+// split into its trace ID, span ID and flags.
+//
+// PresetDefault and unknown presets return a plain JSON logger.
+func NewJSONLoggerPreset(w io.Writer, preset JSONPreset, options ...PresetOption) Logger {
+	next := log.NewJSONLogger(w)
+	p, ok := presets[preset]
+	if !ok {
+		return next
+	}
+	var opts presetOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return LoggerFunc(func(keyvals ...interface{}) error {
+		out := make([]interface{}, 0, len(keyvals)+4)
+		for i := 0; i < len(keyvals); i += 2 {
+			k, v := keyvals[i], interface{}(ErrMissingValue)
+			if i+1 < len(keyvals) {
+				v = keyvals[i+1]
+			}
+			key, _ := k.(string)
+			switch key {
+			case presetTraceParentKey:
+				traceID, spanID, flags, ok := parseTraceParent(fmt.Sprint(v))
+				if !ok {
+					out = append(out, k, v)
+					continue
+				}
+				out = append(out, p.traceID(opts, traceID)...)
+				out = append(out, p.keys[presetSpanIDKey], spanID)
+				out = append(out, p.traceFlags(flags)...)
+			case presetTraceIDKey:
+				out = append(out, p.traceID(opts, fmt.Sprint(v))...)
+			case presetLevelKey:
+				out = append(out, p.level(fmt.Sprint(v))...)
+			case presetTraceFlagsKey:
+				out = append(out, p.traceFlags(fmt.Sprint(v))...)
+			default:
+				if renamed, ok := p.keys[key]; ok {
+					k = renamed
+				}
+				out = append(out, k, v)
+			}
+		}
+		return next.Log(out...)
+	})
+}
+
+type preset struct {
+	keys       map[string]string
+	traceID    func(presetOptions, string) []interface{}
+	level      func(string) []interface{}
+	traceFlags func(string) []interface{}
+}
+
+var presets = map[JSONPreset]preset{
+	PresetECS: {
+		keys: map[string]string{
+			presetTimestampKey: "@timestamp",
+			presetMessageKey:   "message",
+			presetSpanIDKey:    "span.id",
+		},
+		traceID: func(_ presetOptions, id string) []interface{} {
+			return []interface{}{"trace.id", id}
+		},
+		level: func(l string) []interface{} {
+			return []interface{}{"log.level", strings.ToLower(l)}
+		},
+		traceFlags: func(string) []interface{} { return nil },
+	},
+	PresetGCP: {
+		keys: map[string]string{
+			presetTimestampKey: "time",
+			presetMessageKey:   "message",
+			presetSpanIDKey:    "logging.googleapis.com/spanId",
+		},
+		traceID: func(o presetOptions, id string) []interface{} {
+			if o.gcpProject == "" {
+				return []interface{}{presetTraceIDKey, id}
+			}
+			return []interface{}{"logging.googleapis.com/trace", "projects/" + o.gcpProject + "/traces/" + id}
+		},
+		level: func(l string) []interface{} {
+			return []interface{}{"severity", gcpSeverity(l)}
+		},
+		traceFlags: func(f string) []interface{} {
+			return []interface{}{"logging.googleapis.com/trace_sampled", sampled(f)}
+		},
+	},
+	PresetOTel: {
+		keys: map[string]string{
+			presetTimestampKey: "Timestamp",
+			presetMessageKey:   "Body",
+			presetSpanIDKey:    "SpanId",
+		},
+		traceID: func(_ presetOptions, id string) []interface{} {
+			return []interface{}{"TraceId", id}
+		},
+		level: func(l string) []interface{} {
+			text, number := otelSeverity(l)
+			return []interface{}{"SeverityText", text, "SeverityNumber", number}
+		},
+		traceFlags: func(f string) []interface{} {
+			return []interface{}{"TraceFlags", f}
+		},
+	},
+}
+
+func gcpSeverity(l string) string {
+	switch strings.ToLower(l) {
+	case "debug":
+		return "DEBUG"
+	case "info":
+		return "INFO"
+	case "warn", "warning":
+		return "WARNING"
+	case "error":
+		return "ERROR"
+	default:
+		return strings.ToUpper(l)
+	}
+}
+
+func otelSeverity(l string) (string, int) {
+	switch strings.ToLower(l) {
+	case "debug":
+		return "DEBUG", 5
+	case "info":
+		return "INFO", 9
+	case "warn", "warning":
+		return "WARN", 13
+	case "error":
+		return "ERROR", 17
+	default:
+		return strings.ToUpper(l), 0
+	}
+}
+
+// sampled reports whether the sampled bit is set in hex-encoded trace flags.
+func sampled(flags string) bool {
+	var f uint8
+	if _, err := fmt.Sscanf(flags, "%02x", &f); err != nil {
+		return false
+	}
+	return f&0x01 == 0x01
+}
+
+// parseTraceParent splits a W3C traceparent header value of the form
+// version-traceid-spanid-flags.
+func parseTraceParent(s string) (traceID, spanID, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", "", false
+	}
+	return parts[1], parts[2], parts[3], true
+}
@@ -0,0 +1,79 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/log"
+)
+
+func TestJSONLoggerPreset(t *testing.T) {
+	keyvals := []interface{}{
+		"ts", "2024-01-02T03:04:05Z",
+		"level", "warn",
+		"msg", "cache miss",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"key", "value",
+	}
+	for _, tc := range []struct {
+		name    string
+		preset  log.JSONPreset
+		options []log.PresetOption
+		want    string
+	}{
+		{
+			name:   "default",
+			preset: log.PresetDefault,
+			want:   `{"key":"value","level":"warn","msg":"cache miss","traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01","ts":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:   "unknown",
+			preset: log.JSONPreset(99),
+			want:   `{"key":"value","level":"warn","msg":"cache miss","traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01","ts":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:   "ECS",
+			preset: log.PresetECS,
+			want:   `{"@timestamp":"2024-01-02T03:04:05Z","key":"value","log.level":"warn","message":"cache miss","span.id":"00f067aa0ba902b7","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+		{
+			name:    "GCP",
+			preset:  log.PresetGCP,
+			options: []log.PresetOption{log.PresetGCPProject("my-project")},
+			want:    `{"key":"value","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":true,"message":"cache miss","severity":"WARNING","time":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:   "GCP without project",
+			preset: log.PresetGCP,
+			want:   `{"key":"value","logging.googleapis.com/spanId":"00f067aa0ba902b7","logging.googleapis.com/trace_sampled":true,"message":"cache miss","severity":"WARNING","time":"2024-01-02T03:04:05Z","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+		{
+			name:   "OTel",
+			preset: log.PresetOTel,
+			want:   `{"Body":"cache miss","SeverityNumber":13,"SeverityText":"WARN","SpanId":"00f067aa0ba902b7","Timestamp":"2024-01-02T03:04:05Z","TraceFlags":"01","TraceId":"4bf92f3577b34da6a3ce929d0e0e4736","key":"value"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewJSONLoggerPreset(&buf, tc.preset, tc.options...)
+			if err := logger.Log(keyvals...); err != nil {
+				t.Fatal(err)
+			}
+			if want, have := tc.want, strings.TrimSpace(buf.String()); want != have {
+				t.Errorf("want %s, have %s", want, have)
+			}
+		})
+	}
+}
+
+func TestJSONLoggerPresetTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewJSONLoggerPreset(&buf, log.PresetGCP, log.PresetGCPProject("my-project"))
+	logger.Log("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736", "trace_flags", "00")
+
+	want := `{"logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736","logging.googleapis.com/trace_sampled":false}`
+	if have := strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,85 @@
+package level
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-kit/log"
+)
+
+// Dynamic is a level filter whose level can be changed while it's in use, so
+// that operators can e.g. enable debug logging on a live instance without
+// redeploying it. It's safe for concurrent use.
+type Dynamic struct {
+	next    log.Logger
+	options []Option
+	current atomic.Pointer[dynamicFilter]
+}
+
+type dynamicFilter struct {
+	level  Value
+	logger log.Logger
+}
+
+// NewDynamic wraps next, letting log events at or above lvl pass. The options
+// are applied to the filter in addition to the level, like with NewFilter.
+func NewDynamic(next log.Logger, lvl Value, options ...Option) *Dynamic {
+	d := &Dynamic{next: next, options: options}
+	d.SetLevel(lvl)
+	return d
+}
+
+// Log implements log.Logger.
+func (d *Dynamic) Log(keyvals ...interface{}) error {
+	return d.current.Load().logger.Log(keyvals...)
+}
+
+// Level returns the current level.
+func (d *Dynamic) Level() Value {
+	return d.current.Load().level
+}
+
+// SetLevel changes the level. It takes effect for log events logged after it
+// returns.
+func (d *Dynamic) SetLevel(lvl Value) {
+	options := append(d.options[:len(d.options):len(d.options)], Allow(lvl))
+	d.current.Store(&dynamicFilter{level: lvl, logger: NewFilter(d.next, options...)})
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// ServeHTTP implements http.Handler, as a local control endpoint meant to be
+// mounted on an admin server. GET returns the current level as a JSON object,
+// e.g. {"level":"info"}. PUT and POST set it from the "level" query parameter,
+// or from a JSON object of the same form in the body, and return the new
+// level.
+func (d *Dynamic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		name := r.URL.Query().Get("level")
+		if name == "" {
+			var body levelBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "missing level", http.StatusBadRequest)
+				return
+			}
+			name = body.Level
+		}
+		lvl, err := Parse(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d.SetLevel(lvl)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(levelBody{Level: d.Level().String()})
+}
@@ -0,0 +1,53 @@
+package level_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/log/level"
+)
+
+func TestDynamic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := level.NewDynamic(log.NewLogfmtLogger(&buf), level.InfoValue())
+
+	level.Debug(logger).Log("msg", "hidden")
+	level.Info(logger).Log("msg", "shown")
+	logger.SetLevel(level.DebugValue())
+	level.Debug(logger).Log("msg", "debug")
+
+	if want, have := "level=info msg=shown\nlevel=debug msg=debug\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestDynamicServeHTTP(t *testing.T) {
+	logger := level.NewDynamic(log.NewNopLogger(), level.WarnValue())
+
+	for _, tc := range []struct {
+		method, target, body string
+		code                 int
+		want                 string
+	}{
+		{"GET", "/", "", http.StatusOK, `{"level":"warn"}`},
+		{"PUT", "/?level=debug", "", http.StatusOK, `{"level":"debug"}`},
+		{"POST", "/", `{"level":"ERROR"}`, http.StatusOK, `{"level":"error"}`},
+		{"PUT", "/?level=verbose", "", http.StatusBadRequest, "invalid level string"},
+		{"POST", "/", "", http.StatusBadRequest, "missing level"},
+		{"DELETE", "/", "", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{"GET", "/", "", http.StatusOK, `{"level":"error"}`},
+	} {
+		rec := httptest.NewRecorder()
+		logger.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		if want, have := tc.code, rec.Code; want != have {
+			t.Errorf("%s %s: want status %d, have %d", tc.method, tc.target, want, have)
+		}
+		if want, have := tc.want, strings.TrimSpace(rec.Body.String()); want != have {
+			t.Errorf("%s %s: want %q, have %q", tc.method, tc.target, want, have)
+		}
+	}
+}
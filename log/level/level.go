@@ -37,6 +37,11 @@ func NewFilter(next log.Logger, options ...Option) log.Logger {
 // Option sets a parameter for the leveled logger.
 type Option = level.Option
 
+// Allow allows log events at or above the given level to pass.
+func Allow(v Value) Option {
+	return level.Allow(v)
+}
+
 // AllowAll is an alias for AllowDebug.
 func AllowAll() Option {
 	return level.AllowAll()
@@ -67,6 +72,22 @@ func AllowNone() Option {
 	return level.AllowNone()
 }
 
+// ErrInvalidLevelString is returned by Parse for strings that aren't level
+// names.
+var ErrInvalidLevelString = level.ErrInvalidLevelString
+
+// Parse returns the level Value named by the string, which must be one of
+// "debug", "info", "warn" or "error", in any case.
+func Parse(lvl string) (Value, error) {
+	return level.Parse(lvl)
+}
+
+// ParseDefault is like Parse, but returns def for strings that aren't level
+// names.
+func ParseDefault(lvl string, def Value) Value {
+	return level.ParseDefault(lvl, def)
+}
+
 // ErrNotAllowed sets the error to return from Log when it squelches a log
 // event disallowed by the configured Allow[Level] option. By default,
 // ErrNotAllowed is nil; in this case the log event is squelched with no
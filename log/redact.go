@@ -0,0 +1,192 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces redacted values.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the keys whose values are redacted by a redacting
+// logger created without the RedactKeys option. Keys are matched regardless
+// of case.
+var DefaultRedactKeys = []string{
+	"authorization", "proxy-authorization", "cookie", "set-cookie",
+	"password", "passwd", "secret", "client_secret",
+	"token", "access_token", "refresh_token", "id_token", "api_key", "apikey", "x-api-key",
+}
+
+// Patterns matching common personal data in values, for use with
+// RedactPatterns.
+var (
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	CardNumberPattern = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+)
+
+// RedactOption sets an optional parameter for NewRedactingLogger and
+// NewRedactor.
+type RedactOption func(*Redactor)
+
+// RedactKeys sets the keys whose values are redacted, replacing
+// DefaultRedactKeys. Keys are matched regardless of case.
+func RedactKeys(keys ...string) RedactOption {
+	return func(r *Redactor) {
+		r.keys = map[string]struct{}{}
+		for _, k := range keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// RedactPatterns redacts the parts of string values, under any key, matching
+// one of the patterns, e.g. EmailPattern. By default, values are only
+// redacted based on their key.
+func RedactPatterns(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *Redactor) { r.patterns = append(r.patterns, patterns...) }
+}
+
+// RedactValues applies f to every value that isn't redacted based on its key,
+// e.g. the Redact method of the gRPC transport's Redactor, which strips
+// sensitive fields from logged protobuf messages.
+func RedactValues(f func(v interface{}) interface{}) RedactOption {
+	return func(r *Redactor) { r.funcs = append(r.funcs, f) }
+}
+
+// RedactHash replaces redacted values with a truncated SHA-256 hash of the
+// value, prefixed with "sha256:", rather than RedactedValue. Records logging
+// the same secret can then be correlated without revealing it. The salt
+// protects low-entropy values, like card numbers, from being recovered by
+// brute force, and should be kept secret.
+func RedactHash(salt string) RedactOption {
+	return func(r *Redactor) { r.hash, r.salt = true, salt }
+}
+
+// Redactor redacts sensitive values, as a redacting logger does. Use it to
+// redact values that don't go through a logger, e.g. the bodies of audit
+// records, the same way. It's safe for concurrent use.
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	funcs    []func(interface{}) interface{}
+	hash     bool
+	salt     string
+}
+
+// NewRedactor returns a Redactor redacting the values of DefaultRedactKeys,
+// unless set otherwise with the options.
+func NewRedactor(options ...RedactOption) *Redactor {
+	r := &Redactor{}
+	RedactKeys(DefaultRedactKeys...)(r)
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// NewRedactingLogger returns a Logger that redacts sensitive values before
+// passing log records to next, to keep secrets out of log aggregation. The
+// values of sensitive keys are replaced, as are the values of sensitive keys
+// within http.Header, map[string]string and map[string]interface{} values,
+// so that e.g. request headers can be logged as is. The keyvals passed to Log
+// aren't modified.
+func NewRedactingLogger(next Logger, options ...RedactOption) Logger {
+	r := NewRedactor(options...)
+	return LoggerFunc(func(keyvals ...interface{}) error {
+		redacted := make([]interface{}, len(keyvals))
+		copy(redacted, keyvals)
+		for i := 1; i < len(redacted); i += 2 {
+			redacted[i] = r.Redact(fmt.Sprint(redacted[i-1]), redacted[i])
+		}
+		return next.Log(redacted...)
+	})
+}
+
+// Keys returns the sensitive keys, in lowercase and sorted.
+func (r *Redactor) Keys() []string {
+	keys := make([]string, 0, len(r.keys))
+	for k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Sensitive reports whether the values of key are redacted.
+func (r *Redactor) Sensitive(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+// Redact returns v redacted as the value of key in a log record: replaced if
+// key is sensitive, and otherwise with the values of sensitive keys within
+// it, and the parts of strings matching the patterns, replaced. Maps and
+// slices are copied rather than modified.
+func (r *Redactor) Redact(key string, v interface{}) interface{} {
+	if r.Sensitive(key) {
+		return r.Replacement(v)
+	}
+	for _, f := range r.funcs {
+		v = f(v)
+	}
+	switch v := v.(type) {
+	case string:
+		return r.Scrub(v)
+	case http.Header:
+		out := make(http.Header, len(v))
+		for k, vs := range v {
+			if r.Sensitive(k) {
+				vs = []string{r.Replacement(strings.Join(vs, ", "))}
+			}
+			out[k] = vs
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			if r.Sensitive(k) {
+				s = r.Replacement(s)
+			} else {
+				s = r.Scrub(s)
+			}
+			out[k] = s
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, x := range v {
+			out[k] = r.Redact(k, x)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = r.Redact("", x)
+		}
+		return out
+	}
+	return v
+}
+
+// Replacement returns the replacement for a sensitive value: RedactedValue,
+// or its hash with RedactHash.
+func (r *Redactor) Replacement(v interface{}) string {
+	if !r.hash {
+		return RedactedValue
+	}
+	sum := sha256.Sum256([]byte(r.salt + fmt.Sprint(v)))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Scrub redacts the parts of s matching the patterns.
+func (r *Redactor) Scrub(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllStringFunc(s, func(match string) string { return r.Replacement(match) })
+	}
+	return s
+}
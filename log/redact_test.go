@@ -0,0 +1,80 @@
+package log_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/log"
+)
+
+func TestRedactingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewRedactingLogger(log.NewLogfmtLogger(&buf), log.RedactPatterns(log.EmailPattern))
+
+	keyvals := []interface{}{"user", "alice@example.com", "Password", "hunter2", "msg", "login"}
+	logger.Log(keyvals...)
+
+	if want, have := `user=[REDACTED] Password=[REDACTED] msg=login`+"\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "hunter2", keyvals[3]; want != have {
+		t.Errorf("keyvals modified: want %q, have %q", want, have)
+	}
+}
+
+func TestRedactingLoggerHeaders(t *testing.T) {
+	var have http.Header
+	logger := log.NewRedactingLogger(log.LoggerFunc(func(keyvals ...interface{}) error {
+		have = keyvals[1].(http.Header)
+		return nil
+	}))
+
+	header := http.Header{"Authorization": {"Bearer abc"}, "Accept": {"application/json"}}
+	logger.Log("headers", header)
+
+	if want, have := log.RedactedValue, have.Get("Authorization"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "application/json", have.Get("Accept"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "Bearer abc", header.Get("Authorization"); want != have {
+		t.Errorf("header modified: want %q, have %q", want, have)
+	}
+}
+
+func TestRedactingLoggerHash(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewRedactingLogger(log.NewLogfmtLogger(&buf), log.RedactKeys("card"), log.RedactHash("salt"))
+
+	logger.Log("card", "4111111111111111", "token", "not sensitive anymore")
+	logger.Log("card", "4111111111111111")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasPrefix(lines[0], "card=sha256:") || strings.Contains(lines[0], "4111") {
+		t.Errorf("card not hashed: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[0], `token="not sensitive anymore"`) {
+		t.Errorf("RedactKeys didn't replace the defaults: %q", lines[0])
+	}
+	if want, have := strings.Fields(lines[0])[0], lines[1]; want != have {
+		t.Errorf("hashes differ: %q, %q", want, have)
+	}
+}
+
+func TestRedactingLoggerValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewRedactingLogger(log.NewLogfmtLogger(&buf), log.RedactValues(func(v interface{}) interface{} {
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return v
+	}))
+
+	logger.Log("msg", "hello", "n", 1)
+	if want, have := "msg=HELLO n=1\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
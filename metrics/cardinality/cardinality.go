@@ -0,0 +1,227 @@
+// Package cardinality guards metrics against unbounded label cardinality.
+//
+// Labels derived from request data, like a path, a tenant or a user agent,
+// can take any number of values, and every value creates a new time series
+// in the backend. The wrappers in this package replace values outside an
+// allowlist, or beyond a limit on the number of distinct values, with a
+// single overflow value, "other" by default.
+//
+//	requests := cardinality.NewCounter(prometheus.NewCounterFrom(opts, []string{"method", "tenant"}),
+//		cardinality.Allow("method", "GET", "POST", "PUT", "DELETE"),
+//		cardinality.Limit("tenant", 100),
+//	)
+//	requests.With("method", r.Method, "tenant", tenant).Add(1)
+package cardinality
+
+import (
+	"context"
+	"sync"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// DefaultOverflow is the value replacing label values that are rejected.
+const DefaultOverflow = "other"
+
+// Option sets an optional parameter for the guarded metrics.
+type Option func(*guard)
+
+// Allow restricts the values of label to the given ones. Other values are
+// replaced with the overflow value.
+func Allow(label string, values ...string) Option {
+	return func(g *guard) {
+		p := g.policy(label)
+		if p.allowed == nil {
+			p.allowed = map[string]struct{}{}
+		}
+		for _, v := range values {
+			p.allowed[v] = struct{}{}
+		}
+	}
+}
+
+// Limit restricts label to n distinct values: the first n values seen are
+// kept, and later ones are replaced with the overflow value. Values given to
+// Allow, if any, don't count towards the limit.
+func Limit(label string, n int) Option {
+	return func(g *guard) { g.policy(label).limit = n }
+}
+
+// DefaultLimit applies Limit to every label without a policy of its own. By
+// default, such labels are left alone.
+func DefaultLimit(n int) Option {
+	return func(g *guard) { g.defaultLimit = n }
+}
+
+// Overflow sets the value replacing rejected label values. The default is
+// DefaultOverflow.
+func Overflow(value string) Option {
+	return func(g *guard) { g.overflow = value }
+}
+
+// OnOverflow sets a function called with every rejected label value, e.g. to
+// log it. It's called synchronously, with an internal lock held, so it should
+// return quickly and not use the guarded metric.
+func OnOverflow(f func(label, value string)) Option {
+	return func(g *guard) { g.onOverflow = f }
+}
+
+type policy struct {
+	allowed map[string]struct{}
+	limit   int
+	seen    map[string]struct{}
+}
+
+// guard is shared by a guarded metric and all the metrics derived from it
+// with With, so that limits apply to the metric as a whole.
+type guard struct {
+	mtx          sync.Mutex
+	policies     map[string]*policy
+	defaultLimit int
+	overflow     string
+	onOverflow   func(label, value string)
+}
+
+func newGuard(options []Option) *guard {
+	g := &guard{policies: map[string]*policy{}, overflow: DefaultOverflow}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+func (g *guard) policy(label string) *policy {
+	p, ok := g.policies[label]
+	if !ok {
+		p = &policy{}
+		g.policies[label] = p
+	}
+	return p
+}
+
+// apply returns labelValues with the rejected values replaced. The slice
+// passed in isn't modified.
+func (g *guard) apply(labelValues []string) []string {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	var out []string
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		label, value := labelValues[i], labelValues[i+1]
+		if g.accept(label, value) {
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), labelValues...)
+		}
+		out[i+1] = g.overflow
+		if g.onOverflow != nil {
+			g.onOverflow(label, value)
+		}
+	}
+	if out == nil {
+		return labelValues
+	}
+	return out
+}
+
+func (g *guard) accept(label, value string) bool {
+	p, ok := g.policies[label]
+	if !ok {
+		if g.defaultLimit <= 0 {
+			return true
+		}
+		p = &policy{limit: g.defaultLimit}
+		g.policies[label] = p
+	}
+	if _, ok := p.allowed[value]; ok {
+		return true
+	}
+	if p.limit <= 0 {
+		// Allowlist only, or no policy at all.
+		return p.allowed == nil
+	}
+	if _, ok := p.seen[value]; ok {
+		return true
+	}
+	if len(p.seen) >= p.limit {
+		return false
+	}
+	if p.seen == nil {
+		p.seen = map[string]struct{}{}
+	}
+	p.seen[value] = struct{}{}
+	return true
+}
+
+// Counter is a metrics.Counter whose label values are guarded.
+type Counter struct {
+	c metrics.Counter
+	g *guard
+}
+
+// NewCounter wraps c, guarding the label values given to With.
+func NewCounter(c metrics.Counter, options ...Option) *Counter {
+	return &Counter{c: c, g: newGuard(options)}
+}
+
+// With implements metrics.Counter.
+func (c *Counter) With(labelValues ...string) metrics.Counter {
+	return &Counter{c: c.c.With(c.g.apply(labelValues)...), g: c.g}
+}
+
+// Add implements metrics.Counter.
+func (c *Counter) Add(delta float64) { c.c.Add(delta) }
+
+// Gauge is a metrics.Gauge whose label values are guarded.
+type Gauge struct {
+	gg metrics.Gauge
+	g  *guard
+}
+
+// NewGauge wraps g, guarding the label values given to With.
+func NewGauge(g metrics.Gauge, options ...Option) *Gauge {
+	return &Gauge{gg: g, g: newGuard(options)}
+}
+
+// With implements metrics.Gauge.
+func (g *Gauge) With(labelValues ...string) metrics.Gauge {
+	return &Gauge{gg: g.gg.With(g.g.apply(labelValues)...), g: g.g}
+}
+
+// Set implements metrics.Gauge.
+func (g *Gauge) Set(value float64) { g.gg.Set(value) }
+
+// Add implements metrics.Gauge.
+func (g *Gauge) Add(delta float64) { g.gg.Add(delta) }
+
+// Histogram is a metrics.Histogram whose label values are guarded.
+type Histogram struct {
+	h metrics.Histogram
+	g *guard
+}
+
+// NewHistogram wraps h, guarding the label values given to With.
+func NewHistogram(h metrics.Histogram, options ...Option) *Histogram {
+	return &Histogram{h: h, g: newGuard(options)}
+}
+
+// With implements metrics.Histogram.
+func (h *Histogram) With(labelValues ...string) metrics.Histogram {
+	return &Histogram{h: h.h.With(h.g.apply(labelValues)...), g: h.g}
+}
+
+// Observe implements metrics.Histogram.
+func (h *Histogram) Observe(value float64) { h.h.Observe(value) }
+
+// ObserveContext forwards to the wrapped histogram's ObserveContext method,
+// so that exemplars keep working, or to Observe if it has none.
+func (h *Histogram) ObserveContext(ctx context.Context, value float64) {
+	if o, ok := h.h.(interface {
+		ObserveContext(context.Context, float64)
+	}); ok {
+		o.ObserveContext(ctx, value)
+		return
+	}
+	h.h.Observe(value)
+}
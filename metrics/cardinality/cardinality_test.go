@@ -0,0 +1,73 @@
+package cardinality_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/a69/kit.go/metrics/cardinality"
+	"github.com/a69/kit.go/metrics/metricstest"
+)
+
+func TestAllow(t *testing.T) {
+	c := metricstest.NewCounter("requests")
+	guarded := cardinality.NewCounter(c, cardinality.Allow("method", "GET", "POST"))
+
+	guarded.With("method", "GET").Add(1)
+	guarded.With("method", "BREW").Add(1)
+	guarded.With("method", "PROPFIND").Add(1)
+
+	if want, have := 1.0, c.ValueWith("method", "GET"); want != have {
+		t.Errorf("GET: want %v, have %v", want, have)
+	}
+	if want, have := 2.0, c.ValueWith("method", cardinality.DefaultOverflow); want != have {
+		t.Errorf("other: want %v, have %v", want, have)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	var rejected []string
+	h := metricstest.NewHistogram("duration")
+	guarded := cardinality.NewHistogram(h,
+		cardinality.Limit("tenant", 2),
+		cardinality.Overflow("_overflow"),
+		cardinality.OnOverflow(func(label, value string) { rejected = append(rejected, label+"="+value) }),
+	)
+
+	for i := 0; i < 4; i++ {
+		guarded.With("tenant", "t"+strconv.Itoa(i), "method", "GET").Observe(1)
+	}
+	guarded.With("tenant", "t0", "method", "GET").Observe(1) // seen before, still kept
+
+	if want, have := 2, len(h.ObservationsWith("tenant", "t0", "method", "GET")); want != have {
+		t.Errorf("t0: want %d, have %d", want, have)
+	}
+	if want, have := 2, len(h.ObservationsWith("tenant", "_overflow", "method", "GET")); want != have {
+		t.Errorf("overflow: want %d, have %d", want, have)
+	}
+	if want, have := []string{"tenant=t2", "tenant=t3"}, rejected; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestDefaultLimit(t *testing.T) {
+	g := metricstest.NewGauge("queue")
+	guarded := cardinality.NewGauge(g, cardinality.DefaultLimit(1))
+
+	// Limits are shared by metrics derived with With.
+	child := guarded.With("queue", "a")
+	child.Set(1)
+	child.With("queue", "b").Set(2)
+	guarded.With("queue", "c").Set(3)
+
+	if want, have := 1.0, g.ValueWith("queue", "a"); want != have {
+		t.Errorf("a: want %v, have %v", want, have)
+	}
+	for _, lvs := range g.LabelSets() {
+		for i := 1; i < len(lvs); i += 2 {
+			if v := lvs[i]; v != "a" && v != cardinality.DefaultOverflow {
+				t.Errorf("unexpected label value %q", v)
+			}
+		}
+	}
+}
@@ -131,25 +131,64 @@ func (d *Dogstatsd) WriteLoop(ctx context.Context, c <-chan time.Time, w io.Writ
 // connection to the network and address. Like WriteLoop, this method blocks
 // until ctx is canceled, so clients probably want to start it in its own
 // goroutine. For typical usage, create a time.Ticker and pass its C channel to
-// this method.
+// this method; its period is the flush interval.
+//
+// Over UDP, observations are batched into packets of at most
+// MaxUDPPacketSize bytes. Use the "unixgram" network and the path of the
+// agent's socket as address to send over a Unix domain socket instead, which
+// doesn't drop packets silently, and batches observations into packets of at
+// most MaxUnixPacketSize bytes.
 func (d *Dogstatsd) SendLoop(ctx context.Context, c <-chan time.Time, network, address string) {
-	d.WriteLoop(ctx, c, conn.NewDefaultManager(network, address, d.logger))
+	size := MaxUDPPacketSize
+	if strings.HasPrefix(network, "unix") {
+		size = MaxUnixPacketSize
+	}
+	w := conn.NewDefaultManager(network, address, d.logger)
+	for {
+		select {
+		case <-c:
+			if _, err := d.writeTo(w, size); err != nil {
+				d.logger.Log("during", "WriteTo", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
+// Maximum sizes of the packets written by SendLoop. Observations are never
+// split across packets, so a packet may only exceed them if it holds a single
+// observation.
+const (
+	// MaxUDPPacketSize fits in the usual Ethernet MTU.
+	MaxUDPPacketSize = 1432
+
+	// MaxUnixPacketSize is the default buffer size of the agent's socket.
+	MaxUnixPacketSize = 8192
+)
+
 // WriteTo flushes the buffered content of the metrics to the writer, in
 // DogStatsD format. WriteTo abides best-effort semantics, so observations are
 // lost if there is a problem with the write. Clients should be sure to call
 // WriteTo regularly, ideally through the WriteLoop or SendLoop helper methods.
+// Observations are batched into writes of at most MaxUDPPacketSize bytes, so
+// that each write to a UDP connection is sent as a single packet.
 func (d *Dogstatsd) WriteTo(w io.Writer) (count int64, err error) {
-	var n int
+	return d.writeTo(w, MaxUDPPacketSize)
+}
 
-	d.counters.Reset().Walk(func(name string, lvs lv.LabelValues, values []float64) bool {
-		n, err = fmt.Fprintf(w, "%s%s:%f|c%s%s\n", d.prefix, name, sum(values), sampling(d.rates.Get(name)), d.tagValues(lvs))
-		if err != nil {
-			return false
+func (d *Dogstatsd) writeTo(w io.Writer, size int) (count int64, err error) {
+	pw := &packetWriter{w: w, size: size}
+	defer func() {
+		if ferr := pw.flush(); err == nil {
+			err = ferr
 		}
-		count += int64(n)
-		return true
+		count = pw.count
+	}()
+
+	d.counters.Reset().Walk(func(name string, lvs lv.LabelValues, values []float64) bool {
+		err = pw.writeLine(fmt.Appendf(pw.line[:0], "%s%s:%f|c%s%s\n", d.prefix, name, sum(values), sampling(d.rates.Get(name)), d.tagValues(lvs)))
+		return err == nil
 	})
 	if err != nil {
 		return count, err
@@ -158,12 +197,8 @@ func (d *Dogstatsd) WriteTo(w io.Writer) (count int64, err error) {
 	d.mtx.RLock()
 	for _, root := range d.gauges {
 		root.walk(func(name string, lvs lv.LabelValues, value float64) bool {
-			n, err = fmt.Fprintf(w, "%s%s:%f|g%s\n", d.prefix, name, value, d.tagValues(lvs))
-			if err != nil {
-				return false
-			}
-			count += int64(n)
-			return true
+			err = pw.writeLine(fmt.Appendf(pw.line[:0], "%s%s:%f|g%s\n", d.prefix, name, value, d.tagValues(lvs)))
+			return err == nil
 		})
 	}
 	d.mtx.RUnlock()
@@ -171,11 +206,10 @@ func (d *Dogstatsd) WriteTo(w io.Writer) (count int64, err error) {
 	d.timings.Reset().Walk(func(name string, lvs lv.LabelValues, values []float64) bool {
 		sampleRate := d.rates.Get(name)
 		for _, value := range values {
-			n, err = fmt.Fprintf(w, "%s%s:%f|ms%s%s\n", d.prefix, name, value, sampling(sampleRate), d.tagValues(lvs))
+			err = pw.writeLine(fmt.Appendf(pw.line[:0], "%s%s:%f|ms%s%s\n", d.prefix, name, value, sampling(sampleRate), d.tagValues(lvs)))
 			if err != nil {
 				return false
 			}
-			count += int64(n)
 		}
 		return true
 	})
@@ -186,19 +220,45 @@ func (d *Dogstatsd) WriteTo(w io.Writer) (count int64, err error) {
 	d.histograms.Reset().Walk(func(name string, lvs lv.LabelValues, values []float64) bool {
 		sampleRate := d.rates.Get(name)
 		for _, value := range values {
-			n, err = fmt.Fprintf(w, "%s%s:%f|h%s%s\n", d.prefix, name, value, sampling(sampleRate), d.tagValues(lvs))
+			err = pw.writeLine(fmt.Appendf(pw.line[:0], "%s%s:%f|h%s%s\n", d.prefix, name, value, sampling(sampleRate), d.tagValues(lvs)))
 			if err != nil {
 				return false
 			}
-			count += int64(n)
 		}
 		return true
 	})
-	if err != nil {
-		return count, err
+	return count, err
+}
+
+// packetWriter batches lines into writes of at most size bytes, without
+// splitting lines across writes.
+type packetWriter struct {
+	w     io.Writer
+	size  int
+	buf   []byte
+	line  []byte // scratch space for formatting lines
+	count int64
+}
+
+func (p *packetWriter) writeLine(line []byte) error {
+	p.line = line
+	if len(p.buf) > 0 && len(p.buf)+len(line) > p.size {
+		if err := p.flush(); err != nil {
+			return err
+		}
 	}
+	p.buf = append(p.buf, line...)
+	return nil
+}
 
-	return count, err
+func (p *packetWriter) flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	n, err := p.w.Write(p.buf)
+	p.count += int64(n)
+	p.buf = p.buf[:0]
+	return err
 }
 
 func sum(a []float64) float64 {
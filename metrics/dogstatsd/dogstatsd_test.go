@@ -1,6 +1,7 @@
 package dogstatsd
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/a69/kit.go/metrics/teststat"
@@ -88,3 +89,45 @@ func TestTimingSampled(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type recordingWriter struct{ writes []string }
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriteToPackets(t *testing.T) {
+	d := New("packets.", log.NewNopLogger())
+	h := d.NewHistogram("h", 1.0).With("label", "value")
+	for i := 0; i < 1000; i++ {
+		h.Observe(float64(i))
+	}
+
+	w := &recordingWriter{}
+	n, err := d.writeTo(w, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines, total int
+	for _, write := range w.writes {
+		if len(write) > 100 {
+			t.Fatalf("write of %d bytes exceeds the packet size", len(write))
+		}
+		if !strings.HasSuffix(write, "\n") {
+			t.Fatalf("line split across writes: %q", write)
+		}
+		lines += strings.Count(write, "\n")
+		total += len(write)
+	}
+	if want, have := 1000, lines; want != have {
+		t.Errorf("lines: want %d, have %d", want, have)
+	}
+	if want, have := int64(total), n; want != have {
+		t.Errorf("count: want %d, have %d", want, have)
+	}
+	if len(w.writes) >= lines {
+		t.Errorf("lines weren't batched: %d writes", len(w.writes))
+	}
+}
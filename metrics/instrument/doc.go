@@ -0,0 +1,19 @@
+// Package instrument provides an endpoint middleware recording the rate,
+// errors, and duration of requests (RED), the metrics every service should
+// have for each of its methods.
+//
+// The metrics can be created from a provider, one set per method:
+//
+//	p := provider.NewPrometheusProvider("myservice", "api")
+//	sum = instrument.Middleware[SumRequest, SumResponse](instrument.NewMetrics(p, "sum"))(sum)
+//
+// or by hand, which allows a single set of labeled metrics to be shared by
+// all methods:
+//
+//	m := &instrument.Metrics{
+//		Requests: requests.With("method", "sum"),
+//		Errors:   errors.With("method", "sum"),
+//		Failures: failures.With("method", "sum"),
+//		Duration: duration.With("method", "sum"),
+//	}
+package instrument
@@ -0,0 +1,83 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/metrics/provider"
+)
+
+// Metrics are the metrics recorded by the Middleware. Any of them may be nil.
+type Metrics struct {
+	// Requests is incremented for every request.
+	Requests metrics.Counter
+
+	// Errors is incremented for every request the endpoint returned an error
+	// for, e.g. because a transport or dependency failed.
+	Errors metrics.Counter
+
+	// Failures is incremented for every request the endpoint returned a
+	// failed response for, as reported by endpoint.Failed. These are
+	// business errors, which the caller is to blame for.
+	Failures metrics.Counter
+
+	// Duration observes the duration of every request, in seconds.
+	Duration metrics.Histogram
+}
+
+// DefaultBuckets is the number of buckets NewMetrics asks the provider for.
+const DefaultBuckets = 50
+
+// NewMetrics returns the Metrics for a method, created with the provider and
+// named after the method: method_requests_total, method_errors_total,
+// method_failures_total, and method_request_duration_seconds.
+func NewMetrics(p provider.Provider, method string) *Metrics {
+	return &Metrics{
+		Requests: p.NewCounter(method + "_requests_total"),
+		Errors:   p.NewCounter(method + "_errors_total"),
+		Failures: p.NewCounter(method + "_failures_total"),
+		Duration: p.NewHistogram(method+"_request_duration_seconds", DefaultBuckets),
+	}
+}
+
+// contextObserver is implemented by histograms able to attach an exemplar
+// from the context to observations, like the Prometheus one.
+type contextObserver interface {
+	ObserveContext(ctx context.Context, value float64)
+}
+
+// Middleware returns an endpoint.Middleware recording the Metrics for every
+// request. Requests that fail with an error aren't counted as failures, even
+// if their response reports one.
+func Middleware[REQ any, RES any](m *Metrics) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			defer func(begin time.Time) {
+				if m.Requests != nil {
+					m.Requests.Add(1)
+				}
+				switch {
+				case err != nil:
+					if m.Errors != nil {
+						m.Errors.Add(1)
+					}
+				case endpoint.Failed(response) != nil:
+					if m.Failures != nil {
+						m.Failures.Add(1)
+					}
+				}
+				if m.Duration != nil {
+					d := time.Since(begin).Seconds()
+					if o, ok := m.Duration.(contextObserver); ok {
+						o.ObserveContext(ctx, d)
+					} else {
+						m.Duration.Observe(d)
+					}
+				}
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package instrument_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/a69/kit.go/metrics/instrument"
+	"github.com/a69/kit.go/metrics/metricstest"
+)
+
+type response struct{ err error }
+
+func (r response) Failed() error { return r.err }
+
+func TestMiddleware(t *testing.T) {
+	p := metricstest.NewProvider()
+	mw := instrument.Middleware[error, response](instrument.NewMetrics(p, "sum"))
+	e := mw(func(_ context.Context, request error) (response, error) {
+		if request != nil && request.Error() == "transport" {
+			return response{}, request
+		}
+		return response{err: request}, nil
+	})
+
+	for _, request := range []error{nil, nil, errors.New("transport"), errors.New("business")} {
+		e(context.Background(), request)
+	}
+
+	for name, want := range map[string]float64{
+		"sum_requests_total": 4,
+		"sum_errors_total":   1,
+		"sum_failures_total": 1,
+	} {
+		if have := p.Counter(name).Value(); want != have {
+			t.Errorf("%s: want %v, have %v", name, want, have)
+		}
+	}
+	if want, have := 4, p.Histogram("sum_request_duration_seconds").Count(); want != have {
+		t.Errorf("duration: want %d observations, have %d", want, have)
+	}
+}
+
+func TestMiddlewarePartialMetrics(t *testing.T) {
+	requests := metricstest.NewCounter("requests")
+	e := instrument.Middleware[error, response](&instrument.Metrics{Requests: requests})(
+		func(context.Context, error) (response, error) { return response{}, errors.New("boom") },
+	)
+	e(context.Background(), nil)
+	if want, have := 1.0, requests.Value(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,312 @@
+// Package metricstest provides in-memory Counters, Gauges and Histograms that
+// record everything they're given, so tests can assert on the instrumentation
+// of middlewares and services without scraping a real metrics backend.
+//
+// Every metric created by a Provider, or directly with a constructor, keeps
+// one time series per label set. A label set is identified by its label/value
+// pairs regardless of order, so c.With("method", "GET", "code", "200") and
+// c.With("code", "200").With("method", "GET") update the same series.
+package metricstest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// Provider creates and remembers metrics by name. It implements the
+// provider.Provider interface, so it can stand in for a real backend.
+type Provider struct {
+	mtx        sync.Mutex
+	counters   map[string]*Counter
+	gauges     map[string]*Gauge
+	histograms map[string]*Histogram
+}
+
+// NewProvider returns an empty Provider.
+func NewProvider() *Provider {
+	return &Provider{
+		counters:   map[string]*Counter{},
+		gauges:     map[string]*Gauge{},
+		histograms: map[string]*Histogram{},
+	}
+}
+
+// NewCounter returns the Counter with the given name, creating it if needed.
+func (p *Provider) NewCounter(name string) metrics.Counter { return p.Counter(name) }
+
+// NewGauge returns the Gauge with the given name, creating it if needed.
+func (p *Provider) NewGauge(name string) metrics.Gauge { return p.Gauge(name) }
+
+// NewHistogram returns the Histogram with the given name, creating it if
+// needed. The number of buckets is ignored, since all observations are kept.
+func (p *Provider) NewHistogram(name string, _ int) metrics.Histogram { return p.Histogram(name) }
+
+// Stop implements provider.Provider. It does nothing.
+func (p *Provider) Stop() {}
+
+// Counter returns the Counter with the given name, creating it if needed, for
+// inspection.
+func (p *Provider) Counter(name string) *Counter {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	c, ok := p.counters[name]
+	if !ok {
+		c = NewCounter(name)
+		p.counters[name] = c
+	}
+	return c
+}
+
+// Gauge returns the Gauge with the given name, creating it if needed, for
+// inspection.
+func (p *Provider) Gauge(name string) *Gauge {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	g, ok := p.gauges[name]
+	if !ok {
+		g = NewGauge(name)
+		p.gauges[name] = g
+	}
+	return g
+}
+
+// Histogram returns the Histogram with the given name, creating it if needed,
+// for inspection.
+func (p *Provider) Histogram(name string) *Histogram {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	h, ok := p.histograms[name]
+	if !ok {
+		h = NewHistogram(name)
+		p.histograms[name] = h
+	}
+	return h
+}
+
+// Counter is an in-memory Counter.
+type Counter struct {
+	Name string
+	lvs  []string
+	s    *series
+}
+
+// NewCounter returns a new, empty Counter.
+func NewCounter(name string) *Counter {
+	return &Counter{Name: name, s: newSeries()}
+}
+
+// With implements metrics.Counter. The returned Counter shares its series with
+// the receiver.
+func (c *Counter) With(labelValues ...string) metrics.Counter {
+	return &Counter{Name: c.Name, lvs: with(c.lvs, labelValues), s: c.s}
+}
+
+// Add implements metrics.Counter.
+func (c *Counter) Add(delta float64) {
+	c.s.update(c.lvs, func(v *values) { v.last += delta })
+}
+
+// Value returns the current value for the Counter's own label set.
+func (c *Counter) Value() float64 { return c.ValueWith() }
+
+// ValueWith returns the current value for the label set made of the Counter's
+// labels plus the given ones, or 0 if that series was never updated.
+func (c *Counter) ValueWith(labelValues ...string) float64 {
+	return c.s.get(with(c.lvs, labelValues)).last
+}
+
+// LabelSets returns the label sets that have been updated, each as a sorted
+// list of label/value pairs.
+func (c *Counter) LabelSets() [][]string { return c.s.labelSets() }
+
+// Gauge is an in-memory Gauge.
+type Gauge struct {
+	Name string
+	lvs  []string
+	s    *series
+}
+
+// NewGauge returns a new, empty Gauge.
+func NewGauge(name string) *Gauge {
+	return &Gauge{Name: name, s: newSeries()}
+}
+
+// With implements metrics.Gauge. The returned Gauge shares its series with the
+// receiver.
+func (g *Gauge) With(labelValues ...string) metrics.Gauge {
+	return &Gauge{Name: g.Name, lvs: with(g.lvs, labelValues), s: g.s}
+}
+
+// Set implements metrics.Gauge.
+func (g *Gauge) Set(value float64) {
+	g.s.update(g.lvs, func(v *values) { v.last = value; v.all = append(v.all, value) })
+}
+
+// Add implements metrics.Gauge.
+func (g *Gauge) Add(delta float64) {
+	g.s.update(g.lvs, func(v *values) { v.last += delta; v.all = append(v.all, v.last) })
+}
+
+// Value returns the current value for the Gauge's own label set.
+func (g *Gauge) Value() float64 { return g.ValueWith() }
+
+// ValueWith returns the current value for the label set made of the Gauge's
+// labels plus the given ones, or 0 if that series was never updated.
+func (g *Gauge) ValueWith(labelValues ...string) float64 {
+	return g.s.get(with(g.lvs, labelValues)).last
+}
+
+// History returns every value the Gauge took for its own label set, in order.
+func (g *Gauge) History() []float64 { return g.s.get(g.lvs).all }
+
+// LabelSets returns the label sets that have been updated, each as a sorted
+// list of label/value pairs.
+func (g *Gauge) LabelSets() [][]string { return g.s.labelSets() }
+
+// Histogram is an in-memory Histogram keeping every observation.
+type Histogram struct {
+	Name string
+	lvs  []string
+	s    *series
+}
+
+// NewHistogram returns a new, empty Histogram.
+func NewHistogram(name string) *Histogram {
+	return &Histogram{Name: name, s: newSeries()}
+}
+
+// With implements metrics.Histogram. The returned Histogram shares its series
+// with the receiver.
+func (h *Histogram) With(labelValues ...string) metrics.Histogram {
+	return &Histogram{Name: h.Name, lvs: with(h.lvs, labelValues), s: h.s}
+}
+
+// Observe implements metrics.Histogram.
+func (h *Histogram) Observe(value float64) {
+	h.s.update(h.lvs, func(v *values) { v.last = value; v.all = append(v.all, value) })
+}
+
+// Observations returns the observations for the Histogram's own label set, in
+// order.
+func (h *Histogram) Observations() []float64 { return h.ObservationsWith() }
+
+// ObservationsWith returns the observations for the label set made of the
+// Histogram's labels plus the given ones, in order.
+func (h *Histogram) ObservationsWith(labelValues ...string) []float64 {
+	return h.s.get(with(h.lvs, labelValues)).all
+}
+
+// Count returns the number of observations for the Histogram's own label set.
+func (h *Histogram) Count() int { return len(h.Observations()) }
+
+// Sum returns the sum of the observations for the Histogram's own label set.
+func (h *Histogram) Sum() (sum float64) {
+	for _, o := range h.Observations() {
+		sum += o
+	}
+	return sum
+}
+
+// Buckets returns a snapshot of cumulative bucket counts for the Histogram's
+// own label set, Prometheus style: the i-th count is the number of
+// observations less than or equal to upperBounds[i]. upperBounds must be
+// sorted in ascending order.
+func (h *Histogram) Buckets(upperBounds ...float64) []int {
+	counts := make([]int, len(upperBounds))
+	for _, o := range h.Observations() {
+		for i, bound := range upperBounds {
+			if o <= bound {
+				counts[i]++
+			}
+		}
+	}
+	return counts
+}
+
+// LabelSets returns the label sets that have been observed, each as a sorted
+// list of label/value pairs.
+func (h *Histogram) LabelSets() [][]string { return h.s.labelSets() }
+
+// series holds the values of a metric for each label set.
+type series struct {
+	mtx    sync.Mutex
+	values map[string]*values
+	labels map[string][]string
+}
+
+type values struct {
+	last float64
+	all  []float64
+}
+
+func newSeries() *series {
+	return &series{values: map[string]*values{}, labels: map[string][]string{}}
+}
+
+func (s *series) update(lvs []string, f func(*values)) {
+	pairs := canonical(lvs)
+	key := strings.Join(pairs, "\xff")
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		v = &values{}
+		s.values[key] = v
+		s.labels[key] = pairs
+	}
+	f(v)
+}
+
+func (s *series) get(lvs []string) values {
+	key := strings.Join(canonical(lvs), "\xff")
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		return values{}
+	}
+	return values{last: v.last, all: append([]float64(nil), v.all...)}
+}
+
+func (s *series) labelSets() [][]string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	sets := make([][]string, 0, len(s.labels))
+	for _, pairs := range s.labels {
+		sets = append(sets, append([]string(nil), pairs...))
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return strings.Join(sets[i], "\xff") < strings.Join(sets[j], "\xff")
+	})
+	return sets
+}
+
+// with appends label values, padding an odd list like lv.LabelValues does.
+func with(lvs, labelValues []string) []string {
+	if len(labelValues)%2 != 0 {
+		labelValues = append(labelValues, "unknown")
+	}
+	return append(append([]string(nil), lvs...), labelValues...)
+}
+
+// canonical returns the label/value pairs sorted by label. Later values for
+// the same label override earlier ones.
+func canonical(lvs []string) []string {
+	m := make(map[string]string, len(lvs)/2)
+	for i := 0; i+1 < len(lvs); i += 2 {
+		m[lvs[i]] = lvs[i+1]
+	}
+	labels := make([]string, 0, len(m))
+	for label := range m {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	pairs := make([]string, 0, 2*len(labels))
+	for _, label := range labels {
+		pairs = append(pairs, label, m[label])
+	}
+	return pairs
+}
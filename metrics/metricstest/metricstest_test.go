@@ -0,0 +1,70 @@
+package metricstest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	"github.com/a69/kit.go/metrics/provider"
+)
+
+var _ provider.Provider = (*metricstest.Provider)(nil) // API check
+
+func TestCounter(t *testing.T) {
+	p := metricstest.NewProvider()
+	requests := p.NewCounter("requests")
+
+	requests.With("method", "GET", "code", "200").Add(1)
+	requests.With("code", "200").With("method", "GET").Add(2)
+	requests.With("method", "POST", "code", "500").Add(1)
+
+	c := p.Counter("requests")
+	if want, have := 3.0, c.ValueWith("method", "GET", "code", "200"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 0.0, c.ValueWith("method", "PUT"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	want := [][]string{{"code", "200", "method", "GET"}, {"code", "500", "method", "POST"}}
+	if have := c.LabelSets(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := metricstest.NewGauge("depth")
+	g.Set(3)
+	g.Add(-1)
+	g.With("queue", "a").Set(7)
+
+	if want, have := 2.0, g.Value(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := []float64{3, 2}, g.History(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 7.0, g.ValueWith("queue", "a"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := metricstest.NewHistogram("latency")
+	for _, v := range []float64{0.05, 0.2, 0.7, 3} {
+		h.Observe(v)
+	}
+	h.With("method", "GET").Observe(1)
+
+	if want, have := 4, h.Count(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 3.95, h.Sum(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := []int{1, 2, 3, 4}, h.Buckets(0.1, 0.5, 1, 5); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := []float64{1}, h.ObservationsWith("method", "GET"); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
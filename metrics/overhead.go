@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// NamedMiddleware pairs a middleware with the name its overhead is reported
+// under.
+type NamedMiddleware[REQ any, RES any] struct {
+	Name       string
+	Middleware endpoint.Middleware[REQ, RES]
+}
+
+// EndpointLayer is the "middleware" label value under which OverheadChain
+// reports the time spent in the innermost endpoint.
+const EndpointLayer = "endpoint"
+
+// Overhead wraps a middleware so that the time spent in it, excluding the time
+// spent in the endpoint it wraps, is observed in seconds on h with the label
+// "middleware" set to name. If the middleware calls its next endpoint several
+// times, e.g. to retry, all of those calls are excluded.
+//
+// Overhead is opt-in self-instrumentation: wrap only the layers you want to
+// measure, and leave the rest of the stack untouched.
+func Overhead[REQ any, RES any](h Histogram, name string, m endpoint.Middleware[REQ, RES]) endpoint.Middleware[REQ, RES] {
+	return overhead(h, name, m, time.Now)
+}
+
+func overhead[REQ any, RES any](h Histogram, name string, m endpoint.Middleware[REQ, RES], now func() time.Time) endpoint.Middleware[REQ, RES] {
+	h = h.With("middleware", name)
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		key := &overheadKey{}
+		inner := func(ctx context.Context, request REQ) (RES, error) {
+			defer func(begin time.Time) {
+				// The middleware may call next concurrently, e.g. to hedge.
+				if d, ok := ctx.Value(key).(*atomic.Int64); ok {
+					d.Add(int64(now().Sub(begin)))
+				}
+			}(now())
+			return next(ctx, request)
+		}
+		wrapped := m(inner)
+		return func(ctx context.Context, request REQ) (RES, error) {
+			var innerDuration atomic.Int64
+			defer func(begin time.Time) {
+				overhead := now().Sub(begin) - time.Duration(innerDuration.Load())
+				if overhead < 0 {
+					overhead = 0
+				}
+				h.Observe(overhead.Seconds())
+			}(now())
+			return wrapped(context.WithValue(ctx, key, &innerDuration), request)
+		}
+	}
+}
+
+// OverheadChain is like endpoint.Chain, but every layer is wrapped with
+// Overhead under its own name. The time spent in the innermost endpoint is
+// observed on the same histogram under the name EndpointLayer, so the cost of
+// the whole stack can be compared against the work it fronts.
+func OverheadChain[REQ any, RES any](h Histogram, layers ...NamedMiddleware[REQ, RES]) endpoint.Middleware[REQ, RES] {
+	return overheadChain(h, time.Now, layers...)
+}
+
+func overheadChain[REQ any, RES any](h Histogram, now func() time.Time, layers ...NamedMiddleware[REQ, RES]) endpoint.Middleware[REQ, RES] {
+	endpointHistogram := h.With("middleware", EndpointLayer)
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		inner := next
+		next = func(ctx context.Context, request REQ) (RES, error) {
+			defer func(begin time.Time) {
+				endpointHistogram.Observe(now().Sub(begin).Seconds())
+			}(now())
+			return inner(ctx, request)
+		}
+		for i := len(layers) - 1; i >= 0; i-- { // reverse
+			next = overhead(h, layers[i].Name, layers[i].Middleware, now)(next)
+		}
+		return next
+	}
+}
+
+// overheadKey is allocated once per wrapped endpoint, so nested Overhead
+// layers never see each other's accumulators.
+type overheadKey struct{ _ byte }
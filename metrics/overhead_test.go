@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+func TestOverheadChain(t *testing.T) {
+	var (
+		h     = newLabeledHistogram()
+		clock = &fakeClock{now: time.Now()}
+		sleep = func(d time.Duration) endpoint.Middleware[int, int] {
+			return func(next endpoint.Endpoint[int, int]) endpoint.Endpoint[int, int] {
+				return func(ctx context.Context, request int) (int, error) {
+					clock.sleep(d)
+					return next(ctx, request)
+				}
+			}
+		}
+		e = overheadChain[int, int](h, clock.Now,
+			NamedMiddleware[int, int]{Name: "fast", Middleware: sleep(0)},
+			NamedMiddleware[int, int]{Name: "slow", Middleware: sleep(50 * time.Millisecond)},
+		)(func(_ context.Context, request int) (int, error) {
+			clock.sleep(100 * time.Millisecond)
+			return request, nil
+		})
+	)
+
+	if _, err := e(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]float64{
+		"fast":        0.000,
+		"slow":        0.050,
+		EndpointLayer: 0.100,
+	} {
+		obs := h.observations(name)
+		if len(obs) != 1 {
+			t.Fatalf("%s: want 1 observation, have %d", name, len(obs))
+		}
+		if have := obs[0]; want != have {
+			t.Errorf("%s: want %.3f, have %.3f", name, want, have)
+		}
+	}
+}
+
+func TestOverheadRepeatedCalls(t *testing.T) {
+	var (
+		h     = newLabeledHistogram()
+		clock = &fakeClock{now: time.Now()}
+		twice = func(next endpoint.Endpoint[int, int]) endpoint.Endpoint[int, int] {
+			return func(ctx context.Context, request int) (int, error) {
+				clock.sleep(10 * time.Millisecond)
+				next(ctx, request)
+				return next(ctx, request)
+			}
+		}
+		e = overhead[int, int](h, "twice", twice, clock.Now)(func(_ context.Context, request int) (int, error) {
+			clock.sleep(50 * time.Millisecond)
+			return request, nil
+		})
+	)
+
+	if _, err := e(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if have := h.observations("twice"); len(have) != 1 || have[0] != 0.010 {
+		t.Errorf("want a single observation of 0.010, have %v", have)
+	}
+}
+
+func TestOverheadConcurrentCalls(t *testing.T) {
+	var (
+		h     = newLabeledHistogram()
+		hedge = func(next endpoint.Endpoint[int, int]) endpoint.Endpoint[int, int] {
+			return func(ctx context.Context, request int) (int, error) {
+				var wg sync.WaitGroup
+				for i := 0; i < 4; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						next(ctx, request)
+					}()
+				}
+				wg.Wait()
+				return request, nil
+			}
+		}
+		e = Overhead[int, int](h, "hedge", hedge)(func(_ context.Context, request int) (int, error) {
+			return request, nil
+		})
+	)
+
+	// Run with -race: the calls of next accumulate their durations
+	// concurrently.
+	if _, err := e(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if have := h.observations("hedge"); len(have) != 1 || have[0] < 0 {
+		t.Errorf("want a single observation, have %v", have)
+	}
+}
+
+// fakeClock is a clock that only moves when it's told to sleep.
+type fakeClock struct {
+	mtx sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type labeledHistogram struct {
+	mtx  *sync.Mutex
+	obs  map[string][]float64
+	name string
+}
+
+func newLabeledHistogram() *labeledHistogram {
+	return &labeledHistogram{mtx: &sync.Mutex{}, obs: map[string][]float64{}}
+}
+
+func (h *labeledHistogram) With(labelValues ...string) Histogram {
+	return &labeledHistogram{mtx: h.mtx, obs: h.obs, name: labelValues[len(labelValues)-1]}
+}
+
+func (h *labeledHistogram) Observe(value float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.obs[h.name] = append(h.obs[h.name], value)
+}
+
+func (h *labeledHistogram) observations(name string) []float64 {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.obs[name]
+}
@@ -5,38 +5,100 @@
 package prometheus
 
 import (
+	"context"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/a69/kit.go/metrics"
 	"github.com/a69/kit.go/metrics/internal/lv"
 )
 
+// ExemplarFunc returns the labels of the exemplar to attach to an
+// observation made with the given context, or nil to attach none.
+type ExemplarFunc func(ctx context.Context) prometheus.Labels
+
+// TraceIDExemplar returns an ExemplarFunc labeling exemplars with the trace ID
+// returned by traceID, which lets dashboards link samples to their traces. For
+// example, with Zipkin:
+//
+//	prometheus.TraceIDExemplar(func(ctx context.Context) string {
+//		if span := zipkin.SpanFromContext(ctx); span != nil {
+//			return span.Context().TraceID.String()
+//		}
+//		return ""
+//	})
+//
+// Observations without a trace ID get no exemplar.
+func TraceIDExemplar(traceID func(ctx context.Context) string) ExemplarFunc {
+	return func(ctx context.Context) prometheus.Labels {
+		if id := traceID(ctx); id != "" {
+			return prometheus.Labels{"trace_id": id}
+		}
+		return nil
+	}
+}
+
+// Option sets an optional parameter for Counters and Histograms.
+type Option func(*options)
+
+type options struct {
+	exemplar ExemplarFunc
+}
+
+// WithExemplars makes AddContext and ObserveContext attach the exemplars
+// returned by f. Exemplars are only exposed in the OpenMetrics format, which
+// must be enabled with promhttp.HandlerOpts.EnableOpenMetrics. By default, no
+// exemplars are attached.
+func WithExemplars(f ExemplarFunc) Option {
+	return func(o *options) { o.exemplar = f }
+}
+
+func makeOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// exemplarFor returns the exemplar labels for ctx, or nil.
+func (o options) exemplarFor(ctx context.Context) prometheus.Labels {
+	if o.exemplar == nil || ctx == nil {
+		return nil
+	}
+	return o.exemplar(ctx)
+}
+
 // Counter implements Counter, via a Prometheus CounterVec.
 type Counter struct {
-	cv  *prometheus.CounterVec
-	lvs lv.LabelValues
+	cv   *prometheus.CounterVec
+	lvs  lv.LabelValues
+	opts options
 }
 
 // NewCounterFrom constructs and registers a Prometheus CounterVec,
 // and returns a usable Counter object.
-func NewCounterFrom(opts prometheus.CounterOpts, labelNames []string) *Counter {
+func NewCounterFrom(opts prometheus.CounterOpts, labelNames []string, options ...Option) *Counter {
 	cv := prometheus.NewCounterVec(opts, labelNames)
 	prometheus.MustRegister(cv)
-	return NewCounter(cv)
+	return NewCounter(cv, options...)
 }
 
 // NewCounter wraps the CounterVec and returns a usable Counter object.
-func NewCounter(cv *prometheus.CounterVec) *Counter {
+func NewCounter(cv *prometheus.CounterVec, options ...Option) *Counter {
 	return &Counter{
-		cv: cv,
+		cv:   cv,
+		opts: makeOptions(options),
 	}
 }
 
 // With implements Counter.
 func (c *Counter) With(labelValues ...string) metrics.Counter {
 	return &Counter{
-		cv:  c.cv,
-		lvs: c.lvs.With(labelValues...),
+		cv:   c.cv,
+		lvs:  c.lvs.With(labelValues...),
+		opts: c.opts,
 	}
 }
 
@@ -45,6 +107,18 @@ func (c *Counter) Add(delta float64) {
 	c.cv.With(makeLabels(c.lvs...)).Add(delta)
 }
 
+// AddContext is like Add, but attaches the exemplar for ctx, if any.
+func (c *Counter) AddContext(ctx context.Context, delta float64) {
+	counter := c.cv.With(makeLabels(c.lvs...))
+	if labels := c.opts.exemplarFor(ctx); labels != nil {
+		if ea, ok := counter.(prometheus.ExemplarAdder); ok {
+			ea.AddWithExemplar(delta, labels)
+			return
+		}
+	}
+	counter.Add(delta)
+}
+
 // Gauge implements Gauge, via a Prometheus GaugeVec.
 type Gauge struct {
 	gv  *prometheus.GaugeVec
@@ -124,30 +198,52 @@ func (s *Summary) Observe(value float64) {
 // between a Histogram and a Summary is that Histograms require predefined
 // quantile buckets, and can be statistically aggregated.
 type Histogram struct {
-	hv  *prometheus.HistogramVec
-	lvs lv.LabelValues
+	hv   *prometheus.HistogramVec
+	lvs  lv.LabelValues
+	opts options
 }
 
 // NewHistogramFrom constructs and registers a Prometheus HistogramVec,
 // and returns a usable Histogram object.
-func NewHistogramFrom(opts prometheus.HistogramOpts, labelNames []string) *Histogram {
+func NewHistogramFrom(opts prometheus.HistogramOpts, labelNames []string, options ...Option) *Histogram {
 	hv := prometheus.NewHistogramVec(opts, labelNames)
 	prometheus.MustRegister(hv)
-	return NewHistogram(hv)
+	return NewHistogram(hv, options...)
+}
+
+// NewNativeHistogramFrom is like NewHistogramFrom, but makes the histogram a
+// native one, with sparse, exponential buckets that don't need to be
+// predefined. Unless set in opts, the bucket growth factor is 1.1, the number
+// of buckets is limited to 160, and buckets are reset at most once an hour
+// when that limit is reached. Classic buckets are still exposed if opts has
+// any, for scrapers without native histogram support.
+func NewNativeHistogramFrom(opts prometheus.HistogramOpts, labelNames []string, options ...Option) *Histogram {
+	if opts.NativeHistogramBucketFactor <= 1 {
+		opts.NativeHistogramBucketFactor = 1.1
+	}
+	if opts.NativeHistogramMaxBucketNumber == 0 {
+		opts.NativeHistogramMaxBucketNumber = 160
+	}
+	if opts.NativeHistogramMinResetDuration == 0 {
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return NewHistogramFrom(opts, labelNames, options...)
 }
 
 // NewHistogram wraps the HistogramVec and returns a usable Histogram object.
-func NewHistogram(hv *prometheus.HistogramVec) *Histogram {
+func NewHistogram(hv *prometheus.HistogramVec, options ...Option) *Histogram {
 	return &Histogram{
-		hv: hv,
+		hv:   hv,
+		opts: makeOptions(options),
 	}
 }
 
 // With implements Histogram.
 func (h *Histogram) With(labelValues ...string) metrics.Histogram {
 	return &Histogram{
-		hv:  h.hv,
-		lvs: h.lvs.With(labelValues...),
+		hv:   h.hv,
+		lvs:  h.lvs.With(labelValues...),
+		opts: h.opts,
 	}
 }
 
@@ -156,6 +252,18 @@ func (h *Histogram) Observe(value float64) {
 	h.hv.With(makeLabels(h.lvs...)).Observe(value)
 }
 
+// ObserveContext is like Observe, but attaches the exemplar for ctx, if any.
+func (h *Histogram) ObserveContext(ctx context.Context, value float64) {
+	observer := h.hv.With(makeLabels(h.lvs...))
+	if labels := h.opts.exemplarFor(ctx); labels != nil {
+		if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(value, labels)
+			return
+		}
+	}
+	observer.Observe(value)
+}
+
 func makeLabels(labelValues ...string) prometheus.Labels {
 	labels := prometheus.Labels{}
 	for i := 0; i < len(labelValues); i += 2 {
@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"context"
 	"io/ioutil"
 	"math"
 	"math/rand"
@@ -213,3 +214,83 @@ func TestInconsistentLabelCardinality(t *testing.T) {
 		"a", "1", "b", "2", "c", "KABOOM!",
 	).Add(123)
 }
+
+func TestNativeHistogram(t *testing.T) {
+	NewNativeHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: "test",
+		Subsystem: "prometheus",
+		Name:      "native_histogram",
+		Help:      "This is the help string for the native histogram.",
+	}, []string{"x"}).With("x", "1").Observe(0.042)
+
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_prometheus_native_histogram" {
+			continue
+		}
+		h := family.GetMetric()[0].GetHistogram()
+		if want, have := uint64(1), h.GetSampleCount(); want != have {
+			t.Errorf("sample count: want %d, have %d", want, have)
+		}
+		if h.GetSchema() == 0 && len(h.GetPositiveSpan()) == 0 {
+			t.Errorf("not a native histogram: %v", h)
+		}
+		return
+	}
+	t.Fatal("histogram not registered")
+}
+
+func TestExemplars(t *testing.T) {
+	type traceKey struct{}
+	exemplars := TraceIDExemplar(func(ctx context.Context) string {
+		id, _ := ctx.Value(traceKey{}).(string)
+		return id
+	})
+
+	var (
+		registry = stdprometheus.NewRegistry()
+		hv       = stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "h", Help: "h"}, []string{"x"})
+		cv       = stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "c", Help: "c"}, []string{"x"})
+	)
+	registry.MustRegister(hv, cv)
+
+	histogram := NewHistogram(hv, WithExemplars(exemplars)).With("x", "1").(*Histogram)
+	counter := NewCounter(cv, WithExemplars(exemplars)).With("x", "1").(*Counter)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "abc123")
+	histogram.ObserveContext(ctx, 0.3)
+	histogram.ObserveContext(context.Background(), 0.3) // no trace, no exemplar
+	counter.AddContext(ctx, 1)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		var labels []string
+		metric := family.GetMetric()[0]
+		switch family.GetName() {
+		case "h":
+			if want, have := uint64(2), metric.GetHistogram().GetSampleCount(); want != have {
+				t.Errorf("sample count: want %d, have %d", want, have)
+			}
+			for _, b := range metric.GetHistogram().GetBucket() {
+				if e := b.GetExemplar(); e != nil {
+					for _, l := range e.GetLabel() {
+						labels = append(labels, l.GetName()+"="+l.GetValue())
+					}
+				}
+			}
+		case "c":
+			for _, l := range metric.GetCounter().GetExemplar().GetLabel() {
+				labels = append(labels, l.GetName()+"="+l.GetValue())
+			}
+		}
+		if want, have := []string{"trace_id=abc123"}, labels; !reflect.DeepEqual(want, have) {
+			t.Errorf("%s: want exemplar labels %v, have %v", family.GetName(), want, have)
+		}
+	}
+}
@@ -6,24 +6,48 @@ import (
 )
 
 type dogstatsdProvider struct {
-	d    *dogstatsd.Dogstatsd
-	stop func()
+	d     *dogstatsd.Dogstatsd
+	stop  func()
+	rates map[string]float64
+}
+
+// DogstatsdOption sets an optional parameter for the Dogstatsd Provider.
+type DogstatsdOption func(*dogstatsdProvider)
+
+// DogstatsdSampleRate sets the client-side sample rate of the counters,
+// histograms, and timings with the given name, which is 1.0 by default.
+// Sampling high-frequency metrics reduces the traffic to the agent, which
+// scales the received values back up.
+func DogstatsdSampleRate(name string, rate float64) DogstatsdOption {
+	return func(p *dogstatsdProvider) { p.rates[name] = rate }
 }
 
 // NewDogstatsdProvider wraps the given Dogstatsd object and stop func and
 // returns a Provider that produces Dogstatsd metrics. A typical stop function
 // would be ticker.Stop from the ticker passed to the SendLoop helper method.
-func NewDogstatsdProvider(d *dogstatsd.Dogstatsd, stop func()) Provider {
-	return &dogstatsdProvider{
-		d:    d,
-		stop: stop,
+func NewDogstatsdProvider(d *dogstatsd.Dogstatsd, stop func(), options ...DogstatsdOption) Provider {
+	p := &dogstatsdProvider{
+		d:     d,
+		stop:  stop,
+		rates: map[string]float64{},
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+func (p *dogstatsdProvider) rate(name string) float64 {
+	if rate, ok := p.rates[name]; ok {
+		return rate
 	}
+	return 1.0
 }
 
-// NewCounter implements Provider, returning a new Dogstatsd Counter with a
-// sample rate of 1.0.
+// NewCounter implements Provider, returning a new Dogstatsd Counter with the
+// sample rate set for its name, or 1.0.
 func (p *dogstatsdProvider) NewCounter(name string) metrics.Counter {
-	return p.d.NewCounter(name, 1.0)
+	return p.d.NewCounter(name, p.rate(name))
 }
 
 // NewGauge implements Provider.
@@ -32,9 +56,10 @@ func (p *dogstatsdProvider) NewGauge(name string) metrics.Gauge {
 }
 
 // NewHistogram implements Provider, returning a new Dogstatsd Histogram (note:
-// not a Timing) with a sample rate of 1.0. The buckets argument is ignored.
+// not a Timing) with the sample rate set for its name, or 1.0. The buckets
+// argument is ignored.
 func (p *dogstatsdProvider) NewHistogram(name string, _ int) metrics.Histogram {
-	return p.d.NewHistogram(name, 1.0)
+	return p.d.NewHistogram(name, p.rate(name))
 }
 
 // Stop implements Provider, invoking the stop function passed at construction.
@@ -0,0 +1,235 @@
+// Package runtimemetrics samples the Go runtime's own metrics, like the
+// number of goroutines, heap size, GC pauses and scheduler latency, into
+// metrics created by any provider, so that every service exposes the same
+// runtime metrics under the same names.
+//
+//	c := runtimemetrics.NewCollector(provider.NewPrometheusProvider("myservice", ""))
+//	ticker := time.NewTicker(10 * time.Second)
+//	defer ticker.Stop()
+//	go c.CollectLoop(ctx, ticker.C)
+package runtimemetrics
+
+import (
+	"context"
+	"math"
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/metrics"
+	"github.com/a69/kit.go/metrics/provider"
+)
+
+// Names of the metrics created by NewCollector.
+const (
+	Goroutines       = "go_goroutines"
+	GOMAXPROCS       = "go_gomaxprocs"
+	HeapObjectsBytes = "go_heap_objects_bytes"
+	HeapGoalBytes    = "go_heap_goal_bytes"
+	MemoryTotalBytes = "go_memory_total_bytes"
+	GCCycles         = "go_gc_cycles_total"
+	GCPauseSeconds   = "go_gc_pause_seconds"
+	SchedLatencyP50  = "go_sched_latency_p50_seconds"
+	SchedLatencyP99  = "go_sched_latency_p99_seconds"
+)
+
+// maxPauses is the number of GC pauses observed per collection, at most. The
+// runtime rarely does more between collections, and it bounds the cost of
+// catching up after a long interval.
+const maxPauses = 1000
+
+var (
+	gauges = map[string]string{
+		"/sched/goroutines:goroutines":       Goroutines,
+		"/sched/gomaxprocs:threads":          GOMAXPROCS,
+		"/memory/classes/heap/objects:bytes": HeapObjectsBytes,
+		"/gc/heap/goal:bytes":                HeapGoalBytes,
+		"/memory/classes/total:bytes":        MemoryTotalBytes,
+	}
+	gcCycles     = "/gc/cycles/total:gc-cycles"
+	gcPauses     = "/sched/pauses/total/gc:seconds"
+	schedLatency = "/sched/latencies:seconds"
+)
+
+// Collector samples the runtime's metrics. Each collection updates the gauges
+// to their current values, adds the GC cycles since the last collection to the
+// counter, observes the GC pauses since the last collection, and sets the
+// scheduler latency gauges to the percentiles of the time goroutines spent
+// waiting to run since the last collection.
+type Collector struct {
+	mtx     sync.Mutex
+	samples []rtmetrics.Sample
+	gauges  []metrics.Gauge // by sample index, nil for non-gauges
+	cycles  metrics.Counter
+	pauses  metrics.Histogram
+	p50     metrics.Gauge
+	p99     metrics.Gauge
+
+	prevCycles  uint64
+	prevPauses  *rtmetrics.Float64Histogram
+	prevLatency *rtmetrics.Float64Histogram
+}
+
+// NewCollector returns a Collector creating its metrics with p. Metrics not
+// supported by the running Go version are skipped.
+func NewCollector(p provider.Provider) *Collector {
+	supported := map[string]bool{}
+	for _, d := range rtmetrics.All() {
+		supported[d.Name] = true
+	}
+
+	c := &Collector{}
+	for name, metric := range gauges {
+		if supported[name] {
+			c.samples = append(c.samples, rtmetrics.Sample{Name: name})
+			c.gauges = append(c.gauges, p.NewGauge(metric))
+		}
+	}
+	if supported[gcCycles] {
+		c.samples = append(c.samples, rtmetrics.Sample{Name: gcCycles})
+		c.gauges = append(c.gauges, nil)
+		c.cycles = p.NewCounter(GCCycles)
+	}
+	if supported[gcPauses] {
+		c.samples = append(c.samples, rtmetrics.Sample{Name: gcPauses})
+		c.gauges = append(c.gauges, nil)
+		c.pauses = p.NewHistogram(GCPauseSeconds, 50)
+	}
+	if supported[schedLatency] {
+		c.samples = append(c.samples, rtmetrics.Sample{Name: schedLatency})
+		c.gauges = append(c.gauges, nil)
+		c.p50 = p.NewGauge(SchedLatencyP50)
+		c.p99 = p.NewGauge(SchedLatencyP99)
+	}
+
+	// The first collection only sets the baselines of the cumulative metrics,
+	// so that what happened before the collector was created isn't reported
+	// as having happened during its first interval.
+	c.read(false)
+	return c
+}
+
+// Collect samples the runtime's metrics once.
+func (c *Collector) Collect() {
+	c.read(true)
+}
+
+// CollectLoop calls Collect every time the passed channel fires, until ctx is
+// canceled. For typical usage, create a time.Ticker and pass its C channel to
+// this method.
+func (c *Collector) CollectLoop(ctx context.Context, ch <-chan time.Time) {
+	for {
+		select {
+		case <-ch:
+			c.Collect()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Collector) read(report bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	rtmetrics.Read(c.samples)
+	for i, s := range c.samples {
+		switch s.Name {
+		case gcCycles:
+			cur := s.Value.Uint64()
+			if report && cur > c.prevCycles {
+				c.cycles.Add(float64(cur - c.prevCycles))
+			}
+			c.prevCycles = cur
+		case gcPauses:
+			cur := copyHistogram(s.Value)
+			if report {
+				observeDelta(c.pauses, c.prevPauses, cur)
+			}
+			c.prevPauses = cur
+		case schedLatency:
+			cur := copyHistogram(s.Value)
+			if report {
+				c.p50.Set(percentile(c.prevLatency, cur, 0.50))
+				c.p99.Set(percentile(c.prevLatency, cur, 0.99))
+			}
+			c.prevLatency = cur
+		default:
+			if report {
+				c.gauges[i].Set(value(s.Value))
+			}
+		}
+	}
+}
+
+func value(v rtmetrics.Value) float64 {
+	switch v.Kind() {
+	case rtmetrics.KindUint64:
+		return float64(v.Uint64())
+	case rtmetrics.KindFloat64:
+		return v.Float64()
+	default:
+		return 0
+	}
+}
+
+func copyHistogram(v rtmetrics.Value) *rtmetrics.Float64Histogram {
+	if v.Kind() != rtmetrics.KindFloat64Histogram {
+		return nil
+	}
+	h := v.Float64Histogram()
+	return &rtmetrics.Float64Histogram{
+		Counts:  append([]uint64(nil), h.Counts...),
+		Buckets: h.Buckets,
+	}
+}
+
+// bucketValue returns the value representing the observations in bucket i:
+// its upper bound, or its lower bound for the last, unbounded bucket.
+func bucketValue(h *rtmetrics.Float64Histogram, i int) float64 {
+	if upper := h.Buckets[i+1]; !math.IsInf(upper, 1) {
+		return upper
+	}
+	return h.Buckets[i]
+}
+
+// observeDelta observes the observations made between prev and cur on h, up
+// to maxPauses of them.
+func observeDelta(h metrics.Histogram, prev, cur *rtmetrics.Float64Histogram) {
+	if prev == nil || cur == nil || len(prev.Counts) != len(cur.Counts) {
+		return
+	}
+	observed := 0
+	for i := range cur.Counts {
+		for n := cur.Counts[i] - prev.Counts[i]; n > 0 && observed < maxPauses; n-- {
+			h.Observe(bucketValue(cur, i))
+			observed++
+		}
+	}
+}
+
+// percentile returns the value of the bucket holding the q quantile of the
+// observations made between prev and cur, or 0 if there were none.
+func percentile(prev, cur *rtmetrics.Float64Histogram, q float64) float64 {
+	if prev == nil || cur == nil || len(prev.Counts) != len(cur.Counts) {
+		return 0
+	}
+	var total uint64
+	for i := range cur.Counts {
+		total += cur.Counts[i] - prev.Counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	var (
+		rank = uint64(math.Ceil(q * float64(total)))
+		seen uint64
+	)
+	for i := range cur.Counts {
+		seen += cur.Counts[i] - prev.Counts[i]
+		if seen >= rank {
+			return bucketValue(cur, i)
+		}
+	}
+	return 0
+}
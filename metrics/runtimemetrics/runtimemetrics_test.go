@@ -0,0 +1,41 @@
+package runtimemetrics_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	"github.com/a69/kit.go/metrics/runtimemetrics"
+)
+
+func TestCollector(t *testing.T) {
+	p := metricstest.NewProvider()
+	c := runtimemetrics.NewCollector(p)
+
+	if want, have := 0, len(p.Gauge(runtimemetrics.Goroutines).History()); want != have {
+		t.Errorf("gauges set before the first collection: %d values", have)
+	}
+
+	runtime.GC()
+	runtime.GC()
+	c.Collect()
+
+	if have := p.Gauge(runtimemetrics.Goroutines).Value(); have < 1 {
+		t.Errorf("goroutines: have %v", have)
+	}
+	if want, have := float64(runtime.GOMAXPROCS(0)), p.Gauge(runtimemetrics.GOMAXPROCS).Value(); want != have {
+		t.Errorf("gomaxprocs: want %v, have %v", want, have)
+	}
+	if have := p.Gauge(runtimemetrics.HeapGoalBytes).Value(); have <= 0 {
+		t.Errorf("heap goal: have %v", have)
+	}
+	if have := p.Counter(runtimemetrics.GCCycles).Value(); have < 2 {
+		t.Errorf("gc cycles: want at least 2, have %v", have)
+	}
+	if have := p.Histogram(runtimemetrics.GCPauseSeconds).Count(); have < 2 {
+		t.Errorf("gc pauses: want at least 2 observations, have %d", have)
+	}
+	if want, have := 1, len(p.Gauge(runtimemetrics.SchedLatencyP99).History()); want != have {
+		t.Errorf("sched latency: want %d value, have %d", want, have)
+	}
+}
@@ -0,0 +1,21 @@
+// Package outbox implements the transactional outbox pattern, solving the
+// dual-write problem of services that change their database and publish
+// events about it: rather than publishing to the broker directly, which may
+// fail after the transaction committed, or succeed before it rolled back,
+// messages are stored in an outbox table within the business transaction,
+// and a Relay publishes them afterwards, e.g. over NATS or AMQP.
+//
+// Messages are published at least once, in the order they were added, as far
+// as the Store can tell; see SQLStore for the limits of its ordering. A
+// message may be published again if the relay fails between publishing it
+// and marking it as published, so consumers should deduplicate messages by
+// their ID, which the NATS and AMQP publishers pass to the broker, e.g. as
+// the Nats-Msg-Id header that JetStream deduplicates on.
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	...
+//	if _, err := tx.ExecContext(ctx, "INSERT INTO orders ...", ...); err != nil { ... }
+//	if err := store.Add(ctx, tx, outbox.Message{Topic: "orders.placed", Payload: payload}); err != nil { ... }
+//	if err := tx.Commit(); err != nil { ... }
+//	relay.Notify()
+package outbox
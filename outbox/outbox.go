@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Message is a message stored in the outbox, to be published.
+type Message struct {
+	// ID uniquely identifies the message, so that consumers can deduplicate
+	// it. If empty, a random ID is assigned when the message is added.
+	ID string
+
+	// Topic is where the message is published, e.g. a NATS subject or an
+	// AMQP routing key.
+	Topic string
+
+	Headers map[string]string
+	Payload []byte
+
+	// CreatedAt is when the message was added, set by the store.
+	CreatedAt time.Time
+}
+
+// Store persists the messages of the outbox.
+type Store interface {
+	// Pending returns a batch of up to limit messages that weren't published
+	// yet, in the order they were added. Stores shared by several relays
+	// lock the messages of a batch until it's done, so that other relays
+	// skip them rather than publish them too.
+	Pending(ctx context.Context, limit int) (Batch, error)
+}
+
+// Batch is a batch of pending messages returned by a Store.
+type Batch interface {
+	// Messages returns the messages of the batch.
+	Messages() []Message
+
+	// Done records that the messages with the given IDs were published, and
+	// releases the batch. It must be called once for every batch, even if
+	// none of its messages were published.
+	Done(ctx context.Context, published ...string) error
+}
+
+// Publisher publishes the messages of the outbox to a broker. It must only
+// return once the broker acknowledged the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc is an adapter to allow the use of ordinary functions as
+// Publishers, e.g. to publish to Kafka.
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+// Publish implements Publisher.
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
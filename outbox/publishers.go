@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrNotConfirmed is returned by AMQP publishers whose channel isn't in
+	// confirm mode.
+	ErrNotConfirmed = errors.New("AMQP channel not in confirm mode")
+
+	// ErrNacked is returned by AMQP publishers when the broker rejected a
+	// message.
+	ErrNacked = errors.New("AMQP message nacked by the broker")
+)
+
+// NATSPublisher returns a Publisher publishing messages to JetStream, on the
+// subject of their topic. The ID of messages is sent as their Nats-Msg-Id,
+// so that the stream drops the duplicates published within its duplicate
+// window.
+func NATSPublisher(js jetstream.Publisher) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		m := nats.NewMsg(msg.Topic)
+		m.Data = msg.Payload
+		for k, v := range msg.Headers {
+			m.Header.Set(k, v)
+		}
+		_, err := js.PublishMsg(ctx, m, jetstream.WithMsgID(msg.ID))
+		return err
+	})
+}
+
+// AMQPChannel is the part of *amqp.Channel used by AMQPPublisher.
+type AMQPChannel interface {
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error)
+}
+
+// AMQPPublisher returns a Publisher publishing persistent messages to the
+// exchange, with the topic of messages as routing key, and their ID as
+// message ID. The channel must be in confirm mode, so that messages are only
+// marked as published once the broker confirmed them.
+func AMQPPublisher(ch AMQPChannel, exchange string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		headers := amqp.Table{}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, msg.Topic, false, false, amqp.Publishing{
+			Headers:      headers,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.ID,
+			Timestamp:    msg.CreatedAt,
+			Body:         msg.Payload,
+		})
+		if err != nil {
+			return err
+		}
+		if confirm == nil {
+			return ErrNotConfirmed
+		}
+		ok, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNacked
+		}
+		return nil
+	})
+}
@@ -0,0 +1,52 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/a69/kit.go/outbox"
+)
+
+type jetStream struct {
+	jetstream.Publisher
+	msgs []*nats.Msg
+	opts [][]jetstream.PublishOpt
+}
+
+func (js *jetStream) PublishMsg(_ context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	js.msgs = append(js.msgs, msg)
+	js.opts = append(js.opts, opts)
+	return &jetstream.PubAck{}, nil
+}
+
+func TestNATSPublisher(t *testing.T) {
+	js := &jetStream{}
+	err := outbox.NATSPublisher(js).Publish(context.Background(), outbox.Message{
+		ID:      "a",
+		Topic:   "orders.placed",
+		Headers: map[string]string{"Tenant": "acme"},
+		Payload: []byte(`{"id":1}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, len(js.msgs); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	msg := js.msgs[0]
+	if want, have := "orders.placed", msg.Subject; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := `{"id":1}`, string(msg.Data); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := "acme", msg.Header.Get("Tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, len(js.opts[0]); want != have {
+		t.Errorf("want %d publish option, have %d", want, have)
+	}
+}
@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// Relay publishes the pending messages of a store, polling it periodically,
+// and whenever it's notified of new messages.
+type Relay struct {
+	store        Store
+	publisher    Publisher
+	interval     time.Duration
+	batch        int
+	errorHandler transport.ErrorHandler
+	notify       chan struct{}
+}
+
+// NewRelay returns a Relay publishing the messages of the store with the
+// publisher.
+func NewRelay(store Store, publisher Publisher, options ...RelayOption) *Relay {
+	r := &Relay{
+		store:        store,
+		publisher:    publisher,
+		interval:     time.Second,
+		batch:        100,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+		notify:       make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// RelayOption sets an optional parameter for relays.
+type RelayOption func(*Relay)
+
+// RelayInterval sets the period at which the store is polled. By default,
+// it's 1s.
+func RelayInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) { r.interval = interval }
+}
+
+// RelayBatchSize sets the number of messages read from the store at once. By
+// default, it's 100.
+func RelayBatchSize(n int) RelayOption {
+	return func(r *Relay) { r.batch = n }
+}
+
+// RelayErrorHandler is used to handle the errors of the store and of the
+// publisher. By default, errors are ignored.
+func RelayErrorHandler(errorHandler transport.ErrorHandler) RelayOption {
+	return func(r *Relay) { r.errorHandler = errorHandler }
+}
+
+// Notify wakes the relay up, e.g. after a transaction adding messages
+// committed, so that they're published right away rather than at the next
+// poll.
+func (r *Relay) Notify() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes messages until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			r.errorHandler.Handle(ctx, err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush publishes the pending messages, in order, and returns how many were
+// published. It stops at the first message that fails to publish, so that
+// it's retried before the messages following it.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	var n int
+	for {
+		batch, err := r.store.Pending(ctx, r.batch)
+		if err != nil {
+			return n, err
+		}
+		msgs := batch.Messages()
+		var published []string
+		for _, msg := range msgs {
+			if err = r.publisher.Publish(ctx, msg); err != nil {
+				break
+			}
+			published = append(published, msg.ID)
+		}
+		if err := batch.Done(ctx, published...); err != nil {
+			return n, err
+		}
+		n += len(published)
+		if err != nil || len(msgs) < r.batch {
+			return n, err
+		}
+	}
+}
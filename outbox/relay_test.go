@@ -0,0 +1,125 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/outbox"
+)
+
+// memoryStore is a Store keeping messages in memory.
+type memoryStore struct {
+	mtx       sync.Mutex
+	msgs      []outbox.Message
+	published map[string]bool
+}
+
+func (s *memoryStore) add(msgs ...outbox.Message) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.msgs = append(s.msgs, msgs...)
+}
+
+func (s *memoryStore) Pending(_ context.Context, limit int) (outbox.Batch, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var pending []outbox.Message
+	for _, msg := range s.msgs {
+		if !s.published[msg.ID] && len(pending) < limit {
+			pending = append(pending, msg)
+		}
+	}
+	return memoryBatch{s, pending}, nil
+}
+
+type memoryBatch struct {
+	s    *memoryStore
+	msgs []outbox.Message
+}
+
+func (b memoryBatch) Messages() []outbox.Message { return b.msgs }
+
+func (b memoryBatch) Done(_ context.Context, ids ...string) error {
+	b.s.mtx.Lock()
+	defer b.s.mtx.Unlock()
+	for _, id := range ids {
+		b.s.published[id] = true
+	}
+	return nil
+}
+
+func TestRelayFlush(t *testing.T) {
+	var (
+		store     = &memoryStore{published: map[string]bool{}}
+		published []string
+		fail      = "c"
+		errDown   = errors.New("broker down")
+	)
+	relay := outbox.NewRelay(store, outbox.PublisherFunc(func(_ context.Context, msg outbox.Message) error {
+		if msg.ID == fail {
+			return errDown
+		}
+		published = append(published, msg.ID)
+		return nil
+	}), outbox.RelayBatchSize(2))
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		store.add(outbox.Message{ID: id, Topic: "orders"})
+	}
+
+	n, err := relay.Flush(context.Background())
+	if !errors.Is(err, errDown) {
+		t.Errorf("want %v, have %v", errDown, err)
+	}
+	if want, have := 2, n; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	fail = ""
+	if n, err = relay.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 3, n; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := "a b c d e", strings.Join(published, " "); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRelayNotify(t *testing.T) {
+	var (
+		store     = &memoryStore{published: map[string]bool{}}
+		published = make(chan string, 1)
+	)
+	relay := outbox.NewRelay(store, outbox.PublisherFunc(func(_ context.Context, msg outbox.Message) error {
+		published <- msg.ID
+		return nil
+	}), outbox.RelayInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	store.add(outbox.Message{ID: "a"})
+	relay.Notify()
+	select {
+	case id := <-published:
+		if want, have := "a", id; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,198 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Execer executes statements, e.g. an *sql.Tx, or an *sql.DB.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLStore is a Store keeping messages in a table of an SQL database, which
+// must have the following columns, e.g. in PostgreSQL:
+//
+//	CREATE TABLE outbox (
+//		seq          BIGSERIAL PRIMARY KEY,
+//		id           VARCHAR(64) NOT NULL UNIQUE,
+//		topic        TEXT NOT NULL,
+//		headers      TEXT NOT NULL,
+//		payload      BYTEA NOT NULL,
+//		created_at   TIMESTAMP NOT NULL,
+//		published_at TIMESTAMP
+//	);
+//	CREATE INDEX outbox_pending ON outbox (seq) WHERE published_at IS NULL;
+//
+// seq orders the messages, and is assigned by the database, e.g. with
+// AUTO_INCREMENT in MySQL. Headers are stored as a JSON object.
+//
+// Pending locks the rows of a batch with SELECT ... FOR UPDATE SKIP LOCKED,
+// as supported by PostgreSQL 9.5 and MySQL 8, within a transaction that
+// lasts until the batch is done. Several relays can thus share the table,
+// e.g. one per replica of a service, without publishing the same messages,
+// though each publishes its own batches only.
+//
+// seq is assigned when a message is added, not when its transaction
+// commits, so a transaction committing after a later one makes its messages
+// visible after the later ones were possibly published: messages are
+// published in seq order among the committed ones, not strictly in seq
+// order. Consumers that need a strict order, e.g. per aggregate, should
+// carry a version in the messages, and reorder or discard stale ones.
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+	now         func() time.Time
+}
+
+// SQLOption sets an optional parameter for SQL stores.
+type SQLOption func(*SQLStore)
+
+// SQLTable sets the name of the outbox table. By default, it's "outbox".
+func SQLTable(table string) SQLOption {
+	return func(s *SQLStore) { s.table = table }
+}
+
+// SQLDollarPlaceholders numbers the placeholders of statements, i.e. $1, $2,
+// and so on, as PostgreSQL requires. By default, ? placeholders are used.
+func SQLDollarPlaceholders() SQLOption {
+	return func(s *SQLStore) { s.placeholder = func(n int) string { return "$" + strconv.Itoa(n) } }
+}
+
+// NewSQLStore returns an SQLStore reading and marking the messages in db.
+func NewSQLStore(db *sql.DB, options ...SQLOption) *SQLStore {
+	s := &SQLStore{
+		db:          db,
+		table:       "outbox",
+		placeholder: func(int) string { return "?" },
+		now:         time.Now,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Add stores the messages in the outbox with tx, which should be the
+// transaction of the business changes the messages are about, so that they
+// are published if and only if it commits.
+func (s *SQLStore) Add(ctx context.Context, tx Execer, msgs ...Message) error {
+	query := "INSERT INTO " + s.table + " (id, topic, headers, payload, created_at) VALUES (" + s.placeholders(1, 5) + ")"
+	now := s.now().UTC()
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = newID()
+		}
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return err
+		}
+		if msg.Payload == nil {
+			msg.Payload = []byte{}
+		}
+		if _, err := tx.ExecContext(ctx, query, msg.ID, msg.Topic, string(headers), msg.Payload, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending implements Store. The rows of the batch stay locked until it's
+// done.
+func (s *SQLStore) Pending(ctx context.Context, limit int) (Batch, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.pending(ctx, tx, limit)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &sqlBatch{s: s, tx: tx, msgs: msgs}, nil
+}
+
+func (s *SQLStore) pending(ctx context.Context, tx *sql.Tx, limit int) ([]Message, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, topic, headers, payload, created_at FROM "+s.table+
+			" WHERE published_at IS NULL ORDER BY seq LIMIT "+strconv.Itoa(limit)+
+			" FOR UPDATE SKIP LOCKED",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []Message
+	for rows.Next() {
+		var (
+			msg     Message
+			headers string
+		)
+		if err := rows.Scan(&msg.ID, &msg.Topic, &headers, &msg.Payload, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(headers), &msg.Headers); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+// sqlBatch is a batch of an SQLStore, whose rows are locked by tx.
+type sqlBatch struct {
+	s    *SQLStore
+	tx   *sql.Tx
+	msgs []Message
+}
+
+// Messages implements Batch.
+func (b *sqlBatch) Messages() []Message { return b.msgs }
+
+// Done implements Batch, marking the published messages and committing the
+// transaction of the batch.
+func (b *sqlBatch) Done(ctx context.Context, published ...string) error {
+	if len(published) > 0 {
+		args := []interface{}{b.s.now().UTC()}
+		for _, id := range published {
+			args = append(args, id)
+		}
+		if _, err := b.tx.ExecContext(ctx,
+			"UPDATE "+b.s.table+" SET published_at = "+b.s.placeholder(1)+
+				" WHERE id IN ("+b.s.placeholders(2, len(published))+")",
+			args...,
+		); err != nil {
+			b.tx.Rollback()
+			return err
+		}
+	}
+	return b.tx.Commit()
+}
+
+// Purge deletes the messages published more than age ago, and returns how
+// many were deleted.
+func (s *SQLStore) Purge(ctx context.Context, age time.Duration) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM "+s.table+" WHERE published_at IS NOT NULL AND published_at < "+s.placeholder(1),
+		s.now().UTC().Add(-age),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// placeholders returns n placeholders, numbered from first.
+func (s *SQLStore) placeholders(first, n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = s.placeholder(first + i)
+	}
+	return strings.Join(p, ", ")
+}
@@ -0,0 +1,165 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/outbox"
+)
+
+// recorder is a database/sql driver recording the statements it executes,
+// and returning rows for queries.
+type recorder struct {
+	mtx     sync.Mutex
+	execs   []string
+	args    [][]driver.Value
+	rows    [][]driver.Value
+	commits int
+}
+
+func (r *recorder) Open(string) (driver.Conn, error) { return conn{r}, nil }
+
+type conn struct{ r *recorder }
+
+func (c conn) Prepare(query string) (driver.Stmt, error) { return stmt{c.r, query}, nil }
+func (c conn) Close() error                              { return nil }
+func (c conn) Begin() (driver.Tx, error)                 { return tx{c.r}, nil }
+
+type tx struct{ r *recorder }
+
+func (t tx) Commit() error {
+	t.r.mtx.Lock()
+	defer t.r.mtx.Unlock()
+	t.r.commits++
+	return nil
+}
+
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	r     *recorder
+	query string
+}
+
+func (s stmt) Close() error  { return nil }
+func (s stmt) NumInput() int { return -1 }
+
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.r.mtx.Lock()
+	defer s.r.mtx.Unlock()
+	s.r.execs = append(s.r.execs, s.query)
+	s.r.args = append(s.r.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s stmt) Query([]driver.Value) (driver.Rows, error) {
+	s.r.mtx.Lock()
+	defer s.r.mtx.Unlock()
+	s.r.execs = append(s.r.execs, s.query)
+	return &rows{values: s.r.rows}, nil
+}
+
+type rows struct{ values [][]driver.Value }
+
+func (r *rows) Columns() []string { return []string{"id", "topic", "headers", "payload", "created_at"} }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var recorders atomic.Int32
+
+func openRecorder(t *testing.T) (*sql.DB, *recorder) {
+	r := &recorder{}
+	name := "outbox-recorder-" + strconv.Itoa(int(recorders.Add(1)))
+	sql.Register(name, r)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, r
+}
+
+func TestSQLStore(t *testing.T) {
+	var (
+		db, r = openRecorder(t)
+		store = outbox.NewSQLStore(db, outbox.SQLTable("events"), outbox.SQLDollarPlaceholders())
+		ctx   = context.Background()
+	)
+
+	if err := store.Add(ctx, db, outbox.Message{ID: "a", Topic: "orders.placed", Headers: map[string]string{"k": "v"}, Payload: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "INSERT INTO events (id, topic, headers, payload, created_at) VALUES ($1, $2, $3, $4, $5)", r.execs[0]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := `{"k":"v"}`, r.args[0][2]; want != have {
+		t.Errorf("headers: want %v, have %v", want, have)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	r.rows = [][]driver.Value{{"a", "orders.placed", `{"k":"v"}`, []byte("{}"), now}}
+	batch, err := store.Pending(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "SELECT id, topic, headers, payload, created_at FROM events WHERE published_at IS NULL ORDER BY seq LIMIT 10 FOR UPDATE SKIP LOCKED", r.execs[1]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	msgs := batch.Messages()
+	if want, have := 1, len(msgs); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	if msg := msgs[0]; msg.ID != "a" || msg.Topic != "orders.placed" || msg.Headers["k"] != "v" || string(msg.Payload) != "{}" || !msg.CreatedAt.Equal(now) {
+		t.Errorf("unexpected message %+v", msg)
+	}
+
+	if want, have := 0, r.commits; want != have {
+		t.Errorf("commits before done: want %d, have %d", want, have)
+	}
+	if err := batch.Done(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "UPDATE events SET published_at = $1 WHERE id IN ($2, $3)", r.execs[2]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, r.commits; want != have {
+		t.Errorf("commits after done: want %d, have %d", want, have)
+	}
+
+	if _, err := store.Purge(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "DELETE FROM events WHERE published_at IS NOT NULL AND published_at < $1", r.execs[3]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestSQLStoreGeneratesIDs(t *testing.T) {
+	db, r := openRecorder(t)
+	store := outbox.NewSQLStore(db)
+	if err := store.Add(context.Background(), db, outbox.Message{Topic: "a"}, outbox.Message{Topic: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(r.execs[0], "VALUES (?, ?, ?, ?, ?)") {
+		t.Errorf("unexpected statement %q", r.execs[0])
+	}
+	id1, id2 := r.args[0][0].(string), r.args[1][0].(string)
+	if id1 == "" || id1 == id2 {
+		t.Errorf("want distinct IDs, have %q and %q", id1, id2)
+	}
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/internal/window"
+)
+
+// ErrThrottled is returned by the adaptive throttler for the requests it
+// rejects locally, without sending them.
+var ErrThrottled = errors.New("request throttled by client")
+
+// AdaptiveThrottle implements client-side adaptive throttling, as described
+// in the Google SRE book: it tracks how many requests a client sent to a
+// downstream, and how many of them the downstream accepted, over a sliding
+// window. Once the downstream rejects more requests than it accepts, by a
+// factor K, new requests are rejected locally with a probability of
+//
+//	max(0, (requests - K*accepts) / (requests + 1))
+//
+// so that an overloaded downstream spends no resources rejecting them.
+// Unlike a fixed client-side rate limit, the throttle needs no tuning: it
+// follows the capacity of the downstream, and stops throttling as soon as
+// it accepts requests again.
+//
+// An AdaptiveThrottle should be shared by the clients of a downstream.
+type AdaptiveThrottle struct {
+	k          float64
+	isRejected func(error) bool
+	timeNow    func() time.Time
+	random     func() float64
+
+	mtx    sync.Mutex
+	window *window.Window // counts requests and accepts
+}
+
+// NewAdaptiveThrottle returns an AdaptiveThrottle.
+func NewAdaptiveThrottle(options ...AdaptiveOption) *AdaptiveThrottle {
+	a := &AdaptiveThrottle{
+		k:          2,
+		isRejected: IsRejected,
+		timeNow:    time.Now,
+		random:     rand.Float64,
+		window:     window.New(2 * time.Minute),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// AdaptiveOption sets an optional parameter for adaptive throttles.
+type AdaptiveOption func(*AdaptiveThrottle)
+
+// AdaptiveK sets the multiplier of accepts requests may exceed before any is
+// throttled. Lower values throttle more aggressively: with a K of 1.1, at
+// most 10% of the requests sent reach an overloaded downstream only to be
+// rejected. By default, it's 2.
+func AdaptiveK(k float64) AdaptiveOption {
+	return func(a *AdaptiveThrottle) { a.k = k }
+}
+
+// AdaptiveWindow sets the sliding window requests and accepts are counted
+// over. By default, it's 2m.
+func AdaptiveWindow(d time.Duration) AdaptiveOption {
+	return func(a *AdaptiveThrottle) { a.window = window.New(d) }
+}
+
+// AdaptiveRejected sets the function reporting whether an error means the
+// downstream rejected a request because of overload. By default, it's
+// IsRejected.
+func AdaptiveRejected(isRejected func(error) bool) AdaptiveOption {
+	return func(a *AdaptiveThrottle) { a.isRejected = isRejected }
+}
+
+// IsRejected reports whether err means a request was rejected because of
+// overload: it's ErrLimited, or it has the 429 Too Many Requests status
+// code of an HTTP error, or the RESOURCE_EXHAUSTED code of a gRPC status.
+func IsRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrLimited) {
+		return true
+	}
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) && sc.StatusCode() == http.StatusTooManyRequests {
+		return true
+	}
+	if s, ok := status.FromError(err); ok && s.Code() == codes.ResourceExhausted {
+		return true
+	}
+	return false
+}
+
+// RejectProbability returns the probability with which requests are
+// currently throttled.
+func (a *AdaptiveThrottle) RejectProbability() float64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.probability(a.timeNow())
+}
+
+func (a *AdaptiveThrottle) probability(now time.Time) float64 {
+	requests, accepts := a.window.Counts(now)
+	return math.Max(0, (float64(requests)-a.k*float64(accepts))/(float64(requests)+1))
+}
+
+// allow counts a request, and reports whether it may be sent.
+func (a *AdaptiveThrottle) allow() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	now := a.timeNow()
+	p := a.probability(now)
+	a.window.Add(now, 1, 0)
+	return p == 0 || a.random() >= p
+}
+
+// accept counts a request accepted by the downstream.
+func (a *AdaptiveThrottle) accept() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.window.Add(a.timeNow(), 0, 1)
+}
+
+// NewAdaptiveThrottler returns an endpoint.Middleware throttling requests
+// with the adaptive throttle. Throttled requests fail with ErrThrottled,
+// without calling the endpoint. Requests count as accepted unless they fail
+// with an error, or a failed response as reported by endpoint.Failed, that
+// the throttle considers a rejection.
+func NewAdaptiveThrottler[REQ any, RES any](a *AdaptiveThrottle) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (res RES, err error) {
+			if !a.allow() {
+				err = ErrThrottled
+				return
+			}
+			res, err = next(ctx, request)
+			failure := err
+			if failure == nil {
+				failure = endpoint.Failed(res)
+			}
+			if failure == nil || !a.isRejected(failure) {
+				a.accept()
+			}
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/ratelimit"
+)
+
+func TestAdaptiveThrottler(t *testing.T) {
+	var (
+		throttle = ratelimit.NewAdaptiveThrottle(ratelimit.AdaptiveWindow(50 * time.Millisecond))
+		sent     int
+		reject   = true
+		e        = ratelimit.NewAdaptiveThrottler[struct{}, struct{}](throttle)(func(context.Context, struct{}) (struct{}, error) {
+			sent++
+			if reject {
+				return struct{}{}, ratelimit.ErrLimited
+			}
+			return struct{}{}, nil
+		})
+	)
+
+	var throttled int
+	for i := 0; i < 1000; i++ {
+		if _, err := e(context.Background(), struct{}{}); errors.Is(err, ratelimit.ErrThrottled) {
+			throttled++
+		}
+	}
+	if sent == 0 || sent > 100 {
+		t.Errorf("want a few requests sent, have %d", sent)
+	}
+	if want, have := 1000, sent+throttled; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if p := throttle.RejectProbability(); p < 0.9 {
+		t.Errorf("want a reject probability over 0.9, have %v", p)
+	}
+
+	// Once the rejections leave the window, requests go through again.
+	time.Sleep(100 * time.Millisecond)
+	reject = false
+	if want, have := 0.0, throttle.RejectProbability(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := e(context.Background(), struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) StatusCode() int { return int(e) }
+
+func TestIsRejected(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{ratelimit.ErrLimited, true},
+		{&ratelimit.RateLimitedError{Limit: 1}, true},
+		{fmt.Errorf("call: %w", statusError(429)), true},
+		{statusError(503), false},
+		{status.Error(codes.ResourceExhausted, "slow down"), true},
+		{status.Error(codes.Unavailable, "down"), false},
+	} {
+		if have := ratelimit.IsRejected(tc.err); tc.want != have {
+			t.Errorf("%v: want %v, have %v", tc.err, tc.want, have)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// CostFunc returns the number of tokens a request consumes, e.g. its page
+// size or batch length. Costs below one are treated as one.
+type CostFunc[REQ any] func(REQ) int
+
+// AllowNer dictates whether or not a request of a given cost is acceptable to
+// run. The Limiter from "golang.org/x/time/rate" already implements this
+// interface, one is able to use that in NewErroringCostLimiter without any
+// modifications.
+type AllowNer interface {
+	AllowN(t time.Time, n int) bool
+}
+
+// WaitNer dictates how long a request of a given cost must be delayed. The
+// Limiter from "golang.org/x/time/rate" already implements this interface,
+// one is able to use that in NewDelayingCostLimiter without any
+// modifications.
+type WaitNer interface {
+	WaitN(ctx context.Context, n int) error
+}
+
+// NewErroringCostLimiter is like NewErroringLimiter, but every request
+// consumes as many tokens as the cost function says, rather than exactly one.
+// Requests whose cost exceeds the remaining tokens are rejected with
+// ErrLimited, or a *RateLimitedError if the limiter is a *rate.Limiter or
+// implements Describer.
+//
+// Requests whose cost exceeds the limiter's burst are always rejected. For a
+// *rate.Limiter or a DynamicLimiter, their error matches ErrCostExceedsBurst
+// with errors.Is, as well as ErrLimited, so that they can be told apart from
+// requests worth retrying.
+func NewErroringCostLimiter[REQ any, RES any](limit AllowNer, cost CostFunc[REQ]) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (res RES, err error) {
+			now, n := time.Now(), costOf(cost, request)
+			if !limit.AllowN(now, n) {
+				err = limited(limit, now, n)
+				return
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// NewDelayingCostLimiter is like NewDelayingLimiter, but every request
+// consumes as many tokens as the cost function says, rather than exactly one.
+// Requests are delayed until enough tokens are available.
+//
+// Requests whose cost exceeds the limiter's burst are never admitted, and fail
+// at once. For a *rate.Limiter or a DynamicLimiter, their error matches
+// ErrCostExceedsBurst with errors.Is.
+func NewDelayingCostLimiter[REQ any, RES any](limit WaitNer, cost CostFunc[REQ]) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (res RES, err error) {
+			n := costOf(cost, request)
+			if l, ok := limit.(*rate.Limiter); ok && l.Limit() != rate.Inf && n > l.Burst() {
+				err = ErrCostExceedsBurst
+				return
+			}
+			if err = limit.WaitN(ctx, n); err != nil {
+				return
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+func costOf[REQ any](cost CostFunc[REQ], request REQ) int {
+	if n := cost(request); n > 1 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limits configures a DynamicLimiter: requests are allowed at Rate per second
+// on average, with bursts of up to Burst requests.
+type Limits struct {
+	Rate  rate.Limit `json:"rate"`
+	Burst int        `json:"burst"`
+}
+
+// DynamicLimiter is a token bucket limiter whose limits can be changed at
+// runtime, e.g. from a configuration watcher or by on-call engineers through
+// its admin endpoint, to loosen or clamp them during an incident without
+// rebuilding the middleware chain. It implements Allower, Waiter, AllowNer and
+// WaitNer, so it can be used with any of the limiter middlewares, and
+// Describer, so that rejected clients are told when to retry.
+type DynamicLimiter struct {
+	mtx sync.RWMutex
+	l   *rate.Limiter
+}
+
+// NewDynamicLimiter returns a DynamicLimiter with the given initial limits.
+func NewDynamicLimiter(limits Limits) *DynamicLimiter {
+	return &DynamicLimiter{l: rate.NewLimiter(limits.Rate, limits.Burst)}
+}
+
+// Limits returns the current limits.
+func (d *DynamicLimiter) Limits() Limits {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return Limits{Rate: d.l.Limit(), Burst: d.l.Burst()}
+}
+
+// Set changes the limits. The rate and burst change together, as seen by
+// concurrent requests, and tokens already in the bucket are kept, up to the
+// new burst.
+func (d *DynamicLimiter) Set(limits Limits) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	now := time.Now()
+	d.l.SetLimitAt(now, limits.Rate)
+	d.l.SetBurstAt(now, limits.Burst)
+}
+
+// Allow implements Allower.
+func (d *DynamicLimiter) Allow() bool {
+	return d.AllowN(time.Now(), 1)
+}
+
+// AllowN implements AllowNer.
+func (d *DynamicLimiter) AllowN(t time.Time, n int) bool {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return d.l.AllowN(t, n)
+}
+
+// Describe implements Describer.
+func (d *DynamicLimiter) Describe(t time.Time, n int) *RateLimitedError {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return describe(d.l, t, n)
+}
+
+// Wait implements Waiter.
+func (d *DynamicLimiter) Wait(ctx context.Context) error {
+	return d.WaitN(ctx, 1)
+}
+
+// WaitN implements WaitNer. A request that's already waiting keeps the delay
+// it was given under the limits in place when it started waiting.
+func (d *DynamicLimiter) WaitN(ctx context.Context, n int) error {
+	d.mtx.RLock()
+	r := d.l.ReserveN(time.Now(), n)
+	d.mtx.RUnlock()
+	if !r.OK() {
+		return fmt.Errorf("ratelimit: WaitN(n=%d): %w", n, ErrCostExceedsBurst)
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.Cancel()
+		return fmt.Errorf("ratelimit: WaitN(n=%d) would exceed context deadline", n)
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// ServeHTTP implements http.Handler, as an admin endpoint. GET returns the
+// current limits as JSON. PUT sets them from a JSON body of the same form,
+// e.g. {"rate":100,"burst":20}, and returns the new limits.
+func (d *DynamicLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var limits Limits
+		if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if limits.Rate < 0 || limits.Burst < 0 {
+			http.Error(w, "rate and burst must not be negative", http.StatusBadRequest)
+			return
+		}
+		d.Set(limits)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(d.Limits())
+}
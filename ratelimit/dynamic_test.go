@@ -0,0 +1,74 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/a69/kit.go/ratelimit"
+)
+
+func TestDynamicLimiter(t *testing.T) {
+	limiter := ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: rate.Every(time.Minute), Burst: 1})
+	e := ratelimit.NewErroringLimiter[struct{}, struct{}](limiter)(nopEndpoint)
+
+	testSuccessThenFailure(t, e, ratelimit.ErrLimited.Error())
+
+	// Loosening the limits takes effect without rebuilding the endpoint.
+	limiter.Set(ratelimit.Limits{Rate: rate.Inf})
+	for i := 0; i < 100; i++ {
+		if _, err := e(context.Background(), struct{}{}); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	// Clamping them does too.
+	limiter.Set(ratelimit.Limits{Rate: rate.Every(time.Minute), Burst: 1})
+	var allowed int
+	for i := 0; i < 10; i++ {
+		if _, err := e(context.Background(), struct{}{}); err == nil {
+			allowed++
+		}
+	}
+	if allowed > 1 {
+		t.Errorf("want at most 1 request allowed, have %d", allowed)
+	}
+}
+
+func TestDynamicLimiterDelaying(t *testing.T) {
+	limiter := ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: rate.Every(time.Minute), Burst: 1})
+	testSuccessThenFailure(
+		t,
+		ratelimit.NewDelayingLimiter[struct{}, struct{}](limiter)(nopEndpoint),
+		"exceed context deadline")
+}
+
+func TestDynamicLimiterHandler(t *testing.T) {
+	limiter := ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: 10, Burst: 5})
+
+	for _, tc := range []struct {
+		method, body string
+		status       int
+		want         string
+	}{
+		{http.MethodGet, "", http.StatusOK, `{"rate":10,"burst":5}`},
+		{http.MethodPut, `{"rate":100,"burst":20}`, http.StatusOK, `{"rate":100,"burst":20}`},
+		{http.MethodPut, `{"rate":-1,"burst":20}`, http.StatusBadRequest, "rate and burst must not be negative"},
+		{http.MethodPost, `{"rate":1,"burst":1}`, http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{http.MethodGet, "", http.StatusOK, `{"rate":100,"burst":20}`},
+	} {
+		rec := httptest.NewRecorder()
+		limiter.ServeHTTP(rec, httptest.NewRequest(tc.method, "/", strings.NewReader(tc.body)))
+		if want, have := tc.status, rec.Code; want != have {
+			t.Errorf("%s %s: want %d, have %d", tc.method, tc.body, want, have)
+		}
+		if want, have := tc.want, strings.TrimSpace(rec.Body.String()); want != have {
+			t.Errorf("%s %s: want %s, have %s", tc.method, tc.body, want, have)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrCostExceedsBurst is matched, with errors.Is, by the errors of the cost
+// limiters for requests costing more tokens than the limiter's burst. Such
+// requests can never be admitted, however long they wait, so clients shouldn't
+// retry them.
+var ErrCostExceedsBurst = errors.New("request cost exceeds rate limit burst")
+
+// RateLimitedError is returned instead of ErrLimited by the erroring limiter
+// middlewares when the limiter is a *rate.Limiter or implements Describer. It
+// tells the client when it may retry. errors.Is reports it as ErrLimited.
+//
+// It implements the StatusCoder and Headerer interfaces of the HTTP transport,
+// so the DefaultErrorEncoder answers with 429 Too Many Requests, a Retry-After
+// header, and the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers. See the gRPC transport's RateLimitInterceptor for gRPC.
+type RateLimitedError struct {
+	// Limit is the maximum number of requests allowed in a burst.
+	Limit int
+
+	// Remaining is the number of requests that would currently be allowed.
+	Remaining int
+
+	// Reset is how long until the rejected request would be allowed. It's
+	// zero if the request can never be allowed, e.g. because its cost
+	// exceeds Limit.
+	Reset time.Duration
+
+	// Cost is the number of tokens the rejected request needed. If it exceeds
+	// Limit, errors.Is reports the error as ErrCostExceedsBurst too.
+	Cost int
+}
+
+// Error implements the error interface.
+func (e *RateLimitedError) Error() string { return ErrLimited.Error() }
+
+// Is makes errors.Is(err, ErrLimited) true, and errors.Is(err,
+// ErrCostExceedsBurst) true if Cost exceeds Limit.
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrLimited || target == ErrCostExceedsBurst && e.Cost > e.Limit
+}
+
+// StatusCode implements the HTTP transport's StatusCoder.
+func (e *RateLimitedError) StatusCode() int { return http.StatusTooManyRequests }
+
+// Headers implements the HTTP transport's Headerer.
+func (e *RateLimitedError) Headers() http.Header {
+	reset := strconv.Itoa(e.ResetSeconds())
+	h := http.Header{}
+	h.Set("RateLimit-Limit", strconv.Itoa(e.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(e.Remaining))
+	h.Set("RateLimit-Reset", reset)
+	if e.Reset > 0 {
+		h.Set("Retry-After", reset)
+	}
+	return h
+}
+
+// ResetSeconds returns Reset rounded up to whole seconds, as expected by the
+// Retry-After header.
+func (e *RateLimitedError) ResetSeconds() int {
+	return int(math.Ceil(e.Reset.Seconds()))
+}
+
+// Describer is implemented by limiters that can explain why a request of the
+// given cost, made at time t, is rejected. DynamicLimiter implements it.
+type Describer interface {
+	Describe(t time.Time, n int) *RateLimitedError
+}
+
+// limited returns the error the erroring middlewares reject requests with.
+func limited(limit interface{}, t time.Time, n int) error {
+	switch l := limit.(type) {
+	case Describer:
+		if err := l.Describe(t, n); err != nil {
+			return err
+		}
+	case *rate.Limiter:
+		return describe(l, t, n)
+	}
+	return ErrLimited
+}
+
+// describe explains why l rejects a request of cost n made at time t.
+func describe(l *rate.Limiter, t time.Time, n int) *RateLimitedError {
+	var (
+		limit  = l.Limit()
+		burst  = l.Burst()
+		tokens = l.TokensAt(t)
+		err    = &RateLimitedError{Limit: burst, Remaining: int(math.Max(tokens, 0)), Cost: n}
+	)
+	if n <= burst && limit > 0 && limit != rate.Inf && tokens < float64(n) {
+		err.Reset = time.Duration((float64(n) - tokens) / float64(limit) * float64(time.Second))
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/a69/kit.go/ratelimit"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestRateLimitedError(t *testing.T) {
+	var (
+		limiter = ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: rate.Every(10 * time.Second), Burst: 2})
+		e       = ratelimit.NewErroringLimiter[struct{}, struct{}](limiter)(nopEndpoint)
+		err     error
+	)
+	for i := 0; i < 3; i++ {
+		_, err = e(context.Background(), struct{}{})
+	}
+
+	if !errors.Is(err, ratelimit.ErrLimited) {
+		t.Fatalf("want %v, have %v", ratelimit.ErrLimited, err)
+	}
+	var rle *ratelimit.RateLimitedError
+	if !errors.As(err, &rle) {
+		t.Fatalf("want *RateLimitedError, have %T", err)
+	}
+	if want, have := 2, rle.Limit; want != have {
+		t.Errorf("limit: want %d, have %d", want, have)
+	}
+	if want, have := 0, rle.Remaining; want != have {
+		t.Errorf("remaining: want %d, have %d", want, have)
+	}
+	if rle.Reset <= 9*time.Second || rle.Reset > 10*time.Second {
+		t.Errorf("reset: want about 10s, have %v", rle.Reset)
+	}
+
+	rec := httptest.NewRecorder()
+	httptransport.DefaultErrorEncoder(context.Background(), err, rec)
+	if want, have := http.StatusTooManyRequests, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	for header, want := range map[string]string{
+		"Retry-After":         "10",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "10",
+	} {
+		if have := rec.Header().Get(header); want != have {
+			t.Errorf("%s: want %q, have %q", header, want, have)
+		}
+	}
+}
+
+func TestCostLimiterDescribesRejection(t *testing.T) {
+	var (
+		limiter = ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: 1, Burst: 5})
+		cost    = func(n int) int { return n }
+		e       = ratelimit.NewErroringCostLimiter[int, struct{}](limiter, cost)(func(context.Context, int) (struct{}, error) { return struct{}{}, nil })
+	)
+	_, err := e(context.Background(), 10)
+	var rle *ratelimit.RateLimitedError
+	if !errors.As(err, &rle) {
+		t.Fatalf("want *RateLimitedError, have %v", err)
+	}
+	if want, have := time.Duration(0), rle.Reset; want != have {
+		t.Errorf("cost above burst: want reset %v, have %v", want, have)
+	}
+}
+
+func TestRateLimiterDescribesRejection(t *testing.T) {
+	var (
+		limiter = rate.NewLimiter(rate.Every(10*time.Second), 1)
+		e       = ratelimit.NewErroringLimiter[struct{}, struct{}](limiter)(nopEndpoint)
+		err     error
+	)
+	for i := 0; i < 2; i++ {
+		_, err = e(context.Background(), struct{}{})
+	}
+
+	var rle *ratelimit.RateLimitedError
+	if !errors.As(err, &rle) {
+		t.Fatalf("want *RateLimitedError, have %v", err)
+	}
+	if want, have := 10, rle.ResetSeconds(); want != have {
+		t.Errorf("reset: want %ds, have %ds", want, have)
+	}
+	if want, have := "10", rle.Headers().Get("Retry-After"); want != have {
+		t.Errorf("Retry-After: want %q, have %q", want, have)
+	}
+}
@@ -3,12 +3,15 @@ package ratelimit
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/a69/kit.go/endpoint"
 )
 
 // ErrLimited is returned in the request path when the rate limiter is
-// triggered and the request is rejected.
+// triggered and the request is rejected. Limiters that can tell when to retry
+// return a *RateLimitedError instead, which matches ErrLimited with errors.Is
+// but not with ==, so compare errors with errors.Is(err, ErrLimited).
 var ErrLimited = errors.New("rate limit exceeded")
 
 // Allower dictates whether or not a request is acceptable to run.
@@ -20,12 +23,17 @@ type Allower interface {
 
 // NewErroringLimiter returns an endpoint.Middleware that acts as a rate
 // limiter. Requests that would exceed the
-// maximum request rate are simply rejected with an error.
+// maximum request rate are simply rejected with an error: a
+// *RateLimitedError if the limiter is a *rate.Limiter or implements
+// Describer, and ErrLimited otherwise.
+//
+// Callers that compared the error with == ErrLimited, as was possible when
+// ErrLimited was always returned, must use errors.Is(err, ErrLimited) now.
 func NewErroringLimiter[REQ any, RES any](limit Allower) endpoint.Middleware[REQ, RES] {
 	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
 		return func(ctx context.Context, request REQ) (res RES, err error) {
 			if !limit.Allow() {
-				err = ErrLimited
+				err = limited(limit, time.Now(), 1)
 				return
 			}
 			return next(ctx, request)
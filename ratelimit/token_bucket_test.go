@@ -2,6 +2,7 @@ package ratelimit_test
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +24,30 @@ func TestXRateErroring(t *testing.T) {
 
 }
 
+func TestXRateErroringIs(t *testing.T) {
+	var (
+		e   = ratelimit.NewErroringLimiter[struct{}, struct{}](rate.NewLimiter(rate.Every(time.Minute), 1))(nopEndpoint)
+		err error
+	)
+	for i := 0; i < 2; i++ {
+		_, err = e(context.Background(), struct{}{})
+	}
+	// A *rate.Limiter yields a *RateLimitedError, which only errors.Is
+	// reports as ErrLimited.
+	if err == ratelimit.ErrLimited {
+		t.Errorf("want a *RateLimitedError, have %v", err)
+	}
+	if !errors.Is(err, ratelimit.ErrLimited) {
+		t.Errorf("want errors.Is(err, ErrLimited), have %v", err)
+	}
+
+	// Other Allowers still yield ErrLimited itself.
+	e = ratelimit.NewErroringLimiter[struct{}, struct{}](ratelimit.AllowerFunc(func() bool { return false }))(nopEndpoint)
+	if _, err := e(context.Background(), struct{}{}); err != ratelimit.ErrLimited {
+		t.Errorf("want %v, have %v", ratelimit.ErrLimited, err)
+	}
+}
+
 func TestXRateDelaying(t *testing.T) {
 	limit := rate.NewLimiter(rate.Every(time.Minute), 1)
 	testSuccessThenFailure(
@@ -45,3 +70,74 @@ func testSuccessThenFailure(t *testing.T, e endpoint.Endpoint[struct{}, struct{}
 		t.Errorf("expected `%s`: %v\n", failContains, err)
 	}
 }
+
+func TestXRateErroringCost(t *testing.T) {
+	var (
+		limit = rate.NewLimiter(rate.Every(time.Minute), 10)
+		cost  = func(n int) int { return n }
+		e     = ratelimit.NewErroringCostLimiter[int, struct{}](limit, cost)(func(context.Context, int) (struct{}, error) {
+			return struct{}{}, nil
+		})
+	)
+	if _, err := e(context.Background(), 7); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, err := e(context.Background(), 4); !errors.Is(err, ratelimit.ErrLimited) {
+		t.Errorf("want %v, have %v", ratelimit.ErrLimited, err)
+	}
+	if _, err := e(context.Background(), 3); err != nil {
+		t.Errorf("unexpected: %v", err)
+	}
+}
+
+func TestXRateDelayingCost(t *testing.T) {
+	var (
+		limit = rate.NewLimiter(rate.Every(time.Minute), 5)
+		cost  = func(n int) int { return n }
+		e     = ratelimit.NewDelayingCostLimiter[int, struct{}](limit, cost)(func(context.Context, int) (struct{}, error) {
+			return struct{}{}, nil
+		})
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := e(ctx, 5); err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	if _, err := e(ctx, 1); err == nil {
+		t.Error("expected the second request to exceed the deadline")
+	}
+}
+
+type costLimiter interface {
+	ratelimit.AllowNer
+	ratelimit.WaitNer
+}
+
+func TestCostExceedsBurst(t *testing.T) {
+	var (
+		cost = func(n int) int { return n }
+		next = func(context.Context, int) (struct{}, error) { return struct{}{}, nil }
+	)
+	for name, newLimiter := range map[string]func() costLimiter{
+		"rate": func() costLimiter { return rate.NewLimiter(rate.Every(time.Minute), 5) },
+		"dynamic": func() costLimiter {
+			return ratelimit.NewDynamicLimiter(ratelimit.Limits{Rate: rate.Every(time.Minute), Burst: 5})
+		},
+	} {
+		erroring := ratelimit.NewErroringCostLimiter[int, struct{}](newLimiter(), cost)(next)
+		if _, err := erroring(context.Background(), 6); !errors.Is(err, ratelimit.ErrCostExceedsBurst) || !errors.Is(err, ratelimit.ErrLimited) {
+			t.Errorf("%s erroring: want %v, have %v", name, ratelimit.ErrCostExceedsBurst, err)
+		}
+		if _, err := erroring(context.Background(), 5); err != nil {
+			t.Errorf("%s erroring: unexpected: %v", name, err)
+		}
+		if _, err := erroring(context.Background(), 1); errors.Is(err, ratelimit.ErrCostExceedsBurst) || !errors.Is(err, ratelimit.ErrLimited) {
+			t.Errorf("%s erroring: want %v only, have %v", name, ratelimit.ErrLimited, err)
+		}
+
+		delaying := ratelimit.NewDelayingCostLimiter[int, struct{}](newLimiter(), cost)(next)
+		if _, err := delaying(context.Background(), 6); !errors.Is(err, ratelimit.ErrCostExceedsBurst) {
+			t.Errorf("%s delaying: want %v, have %v", name, ratelimit.ErrCostExceedsBurst, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc"
+
+	"github.com/a69/kit.go/sd"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+// HTTPServer returns an Actor serving srv on ln. It's stopped with
+// srv.Shutdown, which lets requests in flight complete, and closed
+// forcefully if they don't by the end of the shutdown timeout.
+func HTTPServer(name string, srv *http.Server, ln net.Listener) Actor {
+	return Actor{
+		Name: name,
+		Run: func() error {
+			if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				srv.Close()
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// DrainingHTTPServer is like HTTPServer, for servers whose handlers are
+// wrapped by the drainer. It's stopped by draining them first, which rejects
+// new requests, including those of connections kept alive, and waits for
+// the ones in flight, before shutting the server down.
+func DrainingHTTPServer(name string, srv *http.Server, ln net.Listener, d *httptransport.Drainer) Actor {
+	a := HTTPServer(name, srv, ln)
+	shutdown := a.Stop
+	a.Stop = func(ctx context.Context) error {
+		if err := d.Drain(ctx); err != nil {
+			srv.Close()
+			return err
+		}
+		return shutdown(ctx)
+	}
+	return a
+}
+
+// GRPCServer returns an Actor serving srv on ln. It's stopped with
+// srv.GracefulStop, which lets calls in flight complete, and stopped
+// forcefully if they don't by the end of the shutdown timeout.
+func GRPCServer(name string, srv *grpc.Server, ln net.Listener) Actor {
+	return Actor{
+		Name: name,
+		Run:  func() error { return srv.Serve(ln) },
+		Stop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				srv.Stop()
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// NATSSubscription returns an Actor for a subscription already made, e.g.
+// with a Go kit NATS subscriber's ServeMsg method as handler. It's stopped by
+// draining the subscription, which lets the messages already received be
+// handled, and unsubscribed forcefully if they aren't by the end of the
+// shutdown timeout.
+func NATSSubscription(name string, sub *nats.Subscription) Actor {
+	stopc := make(chan struct{})
+	return Actor{
+		Name: name,
+		Run: func() error {
+			<-stopc
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			defer close(stopc)
+			if err := sub.Drain(); err != nil {
+				return err
+			}
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			for sub.IsValid() {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					sub.Unsubscribe()
+					return ctx.Err()
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// AMQPConsumer returns an Actor passing the deliveries of a consumer to
+// handle, e.g. a Go kit AMQP subscriber's ServeDelivery method, one at a
+// time. It's stopped by calling cancel, typically
+//
+//	func() error { return ch.Cancel(consumerTag, false) }
+//
+// which makes the server stop sending deliveries, and waiting for the ones
+// already sent to be handled, until the end of the shutdown timeout.
+func AMQPConsumer(name string, deliveries <-chan amqp.Delivery, handle func(*amqp.Delivery), cancel func() error) Actor {
+	done := make(chan struct{})
+	return Actor{
+		Name: name,
+		Run: func() error {
+			defer close(done)
+			for d := range deliveries {
+				handle(&d)
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if err := cancel(); err != nil {
+				return err
+			}
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Registrar returns an Actor registering the instance with r when started,
+// and deregistering it when stopped. It then waits for drainDelay, or the end
+// of the shutdown timeout, before letting the actors added before it be
+// stopped, which gives clients time to notice the instance is gone.
+func Registrar(r sd.Registrar, drainDelay time.Duration) Actor {
+	stopc := make(chan struct{})
+	return Actor{
+		Name: "registrar",
+		Run: func() error {
+			r.Register()
+			<-stopc
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			defer close(stopc)
+			r.Deregister()
+			select {
+			case <-time.After(drainDelay):
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	}
+}
+
+// Worker returns an Actor running f, e.g. a background job loop. The context
+// passed to f is canceled when the actor is stopped, and f returning
+// context.Canceled afterwards isn't an error. Stop waits for f to return,
+// until the end of the shutdown timeout.
+func Worker(name string, f func(ctx context.Context) error) Actor {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	return Actor{
+		Name: name,
+		Run: func() error {
+			defer close(done)
+			err := f(ctx)
+			if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		},
+		Stop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	}
+}
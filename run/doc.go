@@ -0,0 +1,24 @@
+// Package run runs the components of a service, like its HTTP and gRPC
+// servers, message subscribers, service discovery registrations and
+// background workers, and shuts them down gracefully.
+//
+// Components are Actors, started in the order they're added. An actor with a
+// Ready channel gates the startup: the actors added after it, like the
+// servers depending on it, are started once it's ready. The first actor to
+// return, a signal, or the cancellation of the context passed to Run,
+// triggers the shutdown: actors are stopped in reverse order, each given the
+// rest of the shutdown timeout to drain. Registrations are typically added
+// last, so that the instance is registered once its servers are started, and
+// deregistered before they stop accepting requests.
+//
+//	r := run.New(run.Logger(logger))
+//	r.Add(
+//		run.HTTPServer("http", &http.Server{Handler: handler}, httpListener),
+//		run.GRPCServer("grpc", grpcServer, grpcListener),
+//		run.Registrar(registrar, 5*time.Second),
+//	)
+//	if err := r.Run(context.Background()); err != nil {
+//		logger.Log("err", err)
+//		os.Exit(1)
+//	}
+package run
@@ -0,0 +1,197 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/a69/kit.go/log"
+)
+
+// Actor is a long-running component of a service.
+type Actor struct {
+	// Name identifies the actor in logs and errors.
+	Name string
+
+	// Run runs the actor, and blocks until it's stopped or fails.
+	Run func() error
+
+	// Stop makes Run return, gracefully if possible. It should give up once
+	// ctx is done, which is when the shutdown timeout expires.
+	Stop func(ctx context.Context) error
+
+	// Ready, if not nil, is closed once the actor is ready, e.g. once a
+	// connection it depends on is established. The actors added after it
+	// aren't started until then. A nil Ready means the actor is ready as soon
+	// as it's started, like servers given a listener already bound.
+	Ready <-chan struct{}
+}
+
+// Option sets an optional parameter for a Runner.
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	signals []os.Signal
+	logger  log.Logger
+}
+
+// ShutdownTimeout sets the time given to all actors to stop, once shutdown
+// is triggered. The default is 30 seconds.
+func ShutdownTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// Signals sets the signals triggering the shutdown. The default is SIGINT and
+// SIGTERM. Pass no signals to disable signal handling.
+func Signals(signals ...os.Signal) Option {
+	return func(o *options) { o.signals = signals }
+}
+
+// Logger sets the logger the Runner logs the lifecycle of actors to. By
+// default, nothing is logged.
+func Logger(logger log.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// Runner runs a set of actors until one of them returns.
+type Runner struct {
+	actors []Actor
+	opts   options
+}
+
+// New returns a Runner without actors.
+func New(opts ...Option) *Runner {
+	o := options{
+		timeout: 30 * time.Second,
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		logger:  log.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Runner{opts: o}
+}
+
+// Add adds actors, to be started after those already added, and stopped
+// before them.
+func (r *Runner) Add(actors ...Actor) {
+	r.actors = append(r.actors, actors...)
+}
+
+// Error is returned by Run when an actor failed, and triggered the shutdown.
+type Error struct {
+	Actor string
+	Err   error
+}
+
+func (e *Error) Error() string { return e.Actor + ": " + e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrShutdownTimeout is returned by Run, joined with any other error, when
+// some actors didn't return within the shutdown timeout.
+var ErrShutdownTimeout = errors.New("shutdown timeout: some actors didn't stop")
+
+type result struct {
+	actor int
+	err   error
+}
+
+// Run starts the actors in order, each once the previous one is ready, and
+// waits until one of them returns, a signal is received, or ctx is canceled.
+// It then stops the actors started in reverse order, and waits for them to
+// return, within the shutdown timeout. Actors not started yet when the
+// shutdown is triggered are never started. It returns an *Error if an actor
+// failed, joined with the errors returned by Stop, if any. A shutdown
+// triggered by a signal, ctx, or an actor returning nil, returns nil if all
+// actors stop cleanly.
+func (r *Runner) Run(ctx context.Context) error {
+	if len(r.actors) == 0 {
+		return nil
+	}
+
+	var sigc chan os.Signal
+	if len(r.opts.signals) > 0 {
+		sigc = make(chan os.Signal, 1)
+		signal.Notify(sigc, r.opts.signals...)
+		defer signal.Stop(sigc)
+	}
+
+	var (
+		results  = make(chan result, len(r.actors))
+		errs     []error
+		returned = make([]bool, len(r.actors))
+		started  int
+		pending  int
+	)
+
+	// wait waits until ready is closed, and returns false, or until the
+	// shutdown is triggered, and returns true.
+	wait := func(ready <-chan struct{}) bool {
+		select {
+		case <-ready:
+			return false
+		case res := <-results:
+			a := r.actors[res.actor]
+			returned[res.actor], pending = true, pending-1
+			r.opts.logger.Log("actor", a.Name, "event", "returned", "err", res.err)
+			if res.err != nil {
+				errs = append(errs, &Error{Actor: a.Name, Err: res.err})
+			}
+		case sig := <-sigc:
+			r.opts.logger.Log("event", "signal", "signal", sig)
+		case <-ctx.Done():
+			r.opts.logger.Log("event", "canceled", "err", ctx.Err())
+		}
+		return true
+	}
+
+	shutdown := false
+	for i, a := range r.actors {
+		r.opts.logger.Log("actor", a.Name, "event", "start")
+		go func(i int, a Actor) {
+			results <- result{actor: i, err: a.Run()}
+		}(i, a)
+		started, pending = i+1, pending+1
+		if a.Ready == nil {
+			continue
+		}
+		if shutdown = wait(a.Ready); shutdown {
+			break
+		}
+		r.opts.logger.Log("actor", a.Name, "event", "ready")
+	}
+	if !shutdown {
+		wait(nil)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), r.opts.timeout)
+	defer cancel()
+
+	for i := started - 1; i >= 0; i-- {
+		a := r.actors[i]
+		if returned[i] || a.Stop == nil {
+			continue
+		}
+		r.opts.logger.Log("actor", a.Name, "event", "stop")
+		if err := a.Stop(shutdownCtx); err != nil {
+			r.opts.logger.Log("actor", a.Name, "event", "stop", "err", err)
+			errs = append(errs, &Error{Actor: a.Name, Err: err})
+		}
+	}
+
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			r.opts.logger.Log("actor", r.actors[res.actor].Name, "event", "returned", "err", res.err)
+		case <-shutdownCtx.Done():
+			return errors.Join(append(errs, ErrShutdownTimeout)...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,307 @@
+package run_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/run"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) Events() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// blocking returns an actor whose Run blocks until Stop is called.
+func blocking(name string, rec *recorder) run.Actor {
+	stopc := make(chan struct{})
+	return run.Actor{
+		Name: name,
+		Run: func() error {
+			<-stopc
+			return nil
+		},
+		Stop: func(context.Context) error {
+			rec.record("stop " + name)
+			close(stopc)
+			return nil
+		},
+	}
+}
+
+func TestRunStopOrder(t *testing.T) {
+	var (
+		rec    = &recorder{}
+		failed = errors.New("failed")
+		r      = run.New(run.Signals())
+	)
+	r.Add(blocking("a", rec), blocking("b", rec))
+	r.Add(run.Actor{Name: "c", Run: func() error { return failed }})
+	r.Add(blocking("d", rec))
+
+	err := r.Run(context.Background())
+	var runErr *run.Error
+	if !errors.As(err, &runErr) {
+		t.Fatalf("want *run.Error, have %v", err)
+	}
+	if want, have := "c", runErr.Actor; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if !errors.Is(err, failed) {
+		t.Errorf("want %v, have %v", failed, err)
+	}
+	if want, have := []string{"stop d", "stop b", "stop a"}, rec.Events(); !equal(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRunStartOrder(t *testing.T) {
+	var (
+		rec         = &recorder{}
+		r           = run.New(run.Signals())
+		ctx, cancel = context.WithCancel(context.Background())
+		ready       = make(chan struct{})
+		errc        = make(chan error, 1)
+	)
+	db := blocking("db", rec)
+	db.Ready = ready
+	server := blocking("server", rec)
+	serverRun := server.Run
+	server.Run = func() error {
+		rec.record("run server")
+		return serverRun()
+	}
+	r.Add(db, server)
+	go func() { errc <- r.Run(ctx) }()
+
+	// The server isn't started until the database is ready.
+	time.Sleep(20 * time.Millisecond)
+	if want, have := 0, len(rec.Events()); want != have {
+		t.Fatalf("want %d events, have %v", want, rec.Events())
+	}
+	close(ready)
+	for len(rec.Events()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	if want, have := []string{"run server", "stop server", "stop db"}, rec.Events(); !equal(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRunFailedBeforeReady(t *testing.T) {
+	var (
+		rec    = &recorder{}
+		failed = errors.New("failed")
+		r      = run.New(run.Signals())
+	)
+	r.Add(blocking("a", rec))
+	r.Add(run.Actor{
+		Name:  "db",
+		Run:   func() error { return failed },
+		Ready: make(chan struct{}),
+	})
+	r.Add(blocking("server", rec))
+
+	// The actors after the failed one are never started, nor stopped.
+	if err := r.Run(context.Background()); !errors.Is(err, failed) {
+		t.Errorf("want %v, have %v", failed, err)
+	}
+	if want, have := []string{"stop a"}, rec.Events(); !equal(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	var (
+		rec         = &recorder{}
+		r           = run.New(run.Signals())
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	r.Add(blocking("a", rec))
+	cancel()
+	if err := r.Run(ctx); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	if want, have := []string{"stop a"}, rec.Events(); !equal(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	var (
+		r           = run.New(run.Signals(), run.ShutdownTimeout(10*time.Millisecond))
+		ctx, cancel = context.WithCancel(context.Background())
+		release     = make(chan struct{})
+	)
+	defer close(release)
+	r.Add(run.Actor{
+		Name: "stuck",
+		Run: func() error {
+			<-release
+			return nil
+		},
+		Stop: func(context.Context) error { return nil },
+	})
+	cancel()
+	if err := r.Run(ctx); !errors.Is(err, run.ErrShutdownTimeout) {
+		t.Errorf("want %v, have %v", run.ErrShutdownTimeout, err)
+	}
+}
+
+func TestWorker(t *testing.T) {
+	var (
+		r           = run.New(run.Signals())
+		ctx, cancel = context.WithCancel(context.Background())
+		started     = make(chan struct{})
+	)
+	r.Add(run.Worker("worker", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	go func() {
+		<-started
+		cancel()
+	}()
+	if err := r.Run(ctx); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		inflight = make(chan struct{})
+		srv      = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(inflight)
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte("ok"))
+		})}
+		r           = run.New(run.Signals())
+		ctx, cancel = context.WithCancel(context.Background())
+		errc        = make(chan error, 1)
+	)
+	r.Add(run.HTTPServer("http", srv, ln))
+	go func() { errc <- r.Run(ctx) }()
+
+	// A request in flight when the shutdown starts completes.
+	respc := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		respc <- err
+	}()
+	<-inflight
+	cancel()
+	if err := <-respc; err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDrainingHTTPServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		inflight = make(chan struct{})
+		release  = make(chan struct{})
+		drainer  = httptransport.NewDrainer()
+		srv      = &http.Server{Handler: drainer.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				close(inflight)
+				<-release
+			}
+			w.Write([]byte("ok"))
+		}))}
+		r           = run.New(run.Signals())
+		ctx, cancel = context.WithCancel(context.Background())
+		errc        = make(chan error, 1)
+		url         = "http://" + ln.Addr().String()
+	)
+	r.Add(run.DrainingHTTPServer("http", srv, ln, drainer))
+	go func() { errc <- r.Run(ctx) }()
+
+	// A connection kept alive before the shutdown starts.
+	client := &http.Client{}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	respc := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			t.Error(err)
+		}
+		respc <- resp
+	}()
+	<-inflight
+	cancel()
+	for !drainer.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New requests are rejected while the one in flight completes.
+	resp, err = client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if want, have := http.StatusServiceUnavailable, resp.StatusCode; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	close(release)
+	if resp := <-respc; resp == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("want the request in flight to complete, have %v", resp)
+	} else {
+		resp.Body.Close()
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
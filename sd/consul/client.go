@@ -16,6 +16,20 @@ type Client interface {
 	Service(service, tag string, passingOnly bool, queryOpts *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error)
 }
 
+// TTLClient is a Client that can also pass TTL health checks and toggle the
+// maintenance mode of services, as needed by the TTLRegistrar. The Client
+// returned by NewClient implements it.
+type TTLClient interface {
+	Client
+
+	// UpdateTTL sets the status of a TTL check, resetting its timer.
+	UpdateTTL(checkID, output, status string, q *consul.QueryOptions) error
+
+	// EnableServiceMaintenance puts a service in maintenance mode, so that
+	// it's reported critical until it's deregistered.
+	EnableServiceMaintenance(serviceID, reason string, q *consul.QueryOptions) error
+}
+
 type client struct {
 	consul *consul.Client
 }
@@ -31,9 +45,20 @@ func (c *client) Register(r *consul.AgentServiceRegistration) error {
 }
 
 func (c *client) Deregister(r *consul.AgentServiceRegistration) error {
-	return c.consul.Agent().ServiceDeregister(r.ID)
+	return c.consul.Agent().ServiceDeregisterOpts(r.ID, &consul.QueryOptions{
+		Namespace: r.Namespace,
+		Partition: r.Partition,
+	})
 }
 
 func (c *client) Service(service, tag string, passingOnly bool, queryOpts *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
 	return c.consul.Health().Service(service, tag, passingOnly, queryOpts)
 }
+
+func (c *client) UpdateTTL(checkID, output, status string, q *consul.QueryOptions) error {
+	return c.consul.Agent().UpdateTTLOpts(checkID, output, status, q)
+}
+
+func (c *client) EnableServiceMaintenance(serviceID, reason string, q *consul.QueryOptions) error {
+	return c.consul.Agent().EnableServiceMaintenanceOpts(serviceID, reason, q)
+}
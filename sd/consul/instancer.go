@@ -3,10 +3,15 @@ package consul
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	consul "github.com/hashicorp/consul/api"
 
+	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/sd"
 	"github.com/a69/kit.go/sd/internal/instance"
 	"github.com/a69/kit.go/util/conn"
@@ -26,13 +31,80 @@ type Instancer struct {
 	service     string
 	tags        []string
 	passingOnly bool
+	namespace   string
+	partition   string
+	filter      string
+	address     string
+	meta        []string
+	waitTime    time.Duration
+	backoff     endpoint.Backoff
+	minInterval time.Duration
 	quitc       chan struct{}
 }
 
+// InstancerOption sets an optional parameter for Instancers.
+type InstancerOption func(*Instancer)
+
+// Namespace queries the service in the given Consul Enterprise namespace,
+// instead of the one configured on the client.
+func Namespace(namespace string) InstancerOption {
+	return func(s *Instancer) { s.namespace = namespace }
+}
+
+// Partition queries the service in the given Consul Enterprise admin
+// partition, instead of the one configured on the client.
+func Partition(partition string) InstancerOption {
+	return func(s *Instancer) { s.partition = partition }
+}
+
+// Filter passes a filter expression, e.g. `Service.Meta.version == "2"`, to
+// Consul, which only returns the matching service entries. Unlike tags,
+// filters are evaluated by Consul itself.
+func Filter(expression string) InstancerOption {
+	return func(s *Instancer) { s.filter = expression }
+}
+
+// TaggedAddress makes instances use the service's tagged address with the
+// given name, e.g. "wan" or "lan_ipv4", whenever it's set. Entries without it
+// fall back to the service address, and then to the node address.
+func TaggedAddress(name string) InstancerOption {
+	return func(s *Instancer) { s.address = name }
+}
+
+// InstanceMeta appends the values of the given service metadata keys to each
+// instance string, encoded as a query, e.g. "10.0.0.1:8080?version=2&zone=a".
+// Keys missing from an entry's metadata are omitted. Use ParseInstance to
+// split such strings in an sd.Factory.
+func InstanceMeta(keys ...string) InstancerOption {
+	return func(s *Instancer) { s.meta = append(s.meta, keys...) }
+}
+
+// WaitTime sets the maximum duration of each blocking query. By default,
+// Consul's own default of 5 minutes applies.
+func WaitTime(d time.Duration) InstancerOption {
+	return func(s *Instancer) { s.waitTime = d }
+}
+
+// RetryBackoff sets how long to wait before querying Consul again after an
+// error, or after a response with an unusable index. Attempts are counted from
+// 1 and reset by every successful query. By default, the wait starts at 10ms
+// and roughly doubles, with jitter, up to a minute.
+func RetryBackoff(b endpoint.Backoff) InstancerOption {
+	return func(s *Instancer) { s.backoff = b }
+}
+
+// MinQueryInterval sets the minimum time between the start of two successive
+// blocking queries. Services whose index changes constantly would otherwise be
+// queried in a tight loop. To also coalesce the updates of a flapping
+// service, use sd.DebounceUpdates on the Endpointer.
+func MinQueryInterval(d time.Duration) InstancerOption {
+	return func(s *Instancer) { s.minInterval = d }
+}
+
 // NewInstancer returns a Consul instancer that publishes instances for the
 // requested service. It only returns instances for which all of the passed tags
 // are present.
-func NewInstancer(client Client, logger log.Logger, service string, tags []string, passingOnly bool) *Instancer {
+func NewInstancer(client Client, logger log.Logger, service string, tags []string, passingOnly bool, options ...InstancerOption) *Instancer {
 	s := &Instancer{
 		cache:       instance.NewCache(),
 		client:      client,
@@ -42,6 +114,9 @@ func NewInstancer(client Client, logger log.Logger, service string, tags []strin
 		passingOnly: passingOnly,
 		quitc:       make(chan struct{}),
 	}
+	for _, option := range options {
+		option(s)
+	}
 
 	instances, index, err := s.getInstances(defaultIndex, nil)
 	if err == nil {
@@ -65,31 +140,60 @@ func (s *Instancer) loop(lastIndex uint64) {
 		instances []string
 		err       error
 		d         time.Duration = 10 * time.Millisecond
+		attempt   int
 		index     uint64
+		last      time.Time
 	)
+	wait := func(d time.Duration) bool {
+		select {
+		case <-time.After(d):
+			return true
+		case <-s.quitc:
+			return false
+		}
+	}
+	retry := func() bool {
+		attempt++
+		if s.backoff != nil {
+			return wait(s.backoff(attempt))
+		}
+		ok := wait(d)
+		d = conn.Exponential(d)
+		return ok
+	}
 	for {
+		if s.minInterval > 0 {
+			if !wait(time.Until(last.Add(s.minInterval))) {
+				return
+			}
+			last = time.Now()
+		}
 		instances, index, err = s.getInstances(lastIndex, s.quitc)
 		switch {
 		case errors.Is(err, errStopped):
 			return // stopped via quitc
 		case err != nil:
 			s.logger.Log("err", err)
-			time.Sleep(d)
-			d = conn.Exponential(d)
+			if !retry() {
+				return
+			}
 			s.cache.Update(sd.Event{Err: err})
 		case index == defaultIndex:
 			s.logger.Log("err", "index is not sane")
-			time.Sleep(d)
-			d = conn.Exponential(d)
+			if !retry() {
+				return
+			}
 		case index < lastIndex:
 			s.logger.Log("err", "index is less than previous; resetting to default")
 			lastIndex = defaultIndex
-			time.Sleep(d)
-			d = conn.Exponential(d)
+			if !retry() {
+				return
+			}
 		default:
 			lastIndex = index
 			s.cache.Update(sd.Event{Instances: instances})
 			d = 10 * time.Millisecond
+			attempt = 0
 		}
 	}
 }
@@ -119,6 +223,10 @@ func (s *Instancer) getInstances(lastIndex uint64, interruptc chan struct{}) ([]
 	go func() {
 		entries, meta, err := s.client.Service(s.service, tag, s.passingOnly, &consul.QueryOptions{
 			WaitIndex: lastIndex,
+			WaitTime:  s.waitTime,
+			Namespace: s.namespace,
+			Partition: s.partition,
+			Filter:    s.filter,
 		})
 		if err != nil {
 			errc <- err
@@ -128,7 +236,7 @@ func (s *Instancer) getInstances(lastIndex uint64, interruptc chan struct{}) ([]
 			entries = filterEntries(entries, s.tags[1:]...)
 		}
 		resc <- response{
-			instances: makeInstances(entries),
+			instances: s.makeInstances(entries),
 			index:     meta.LastIndex,
 		}
 	}()
@@ -174,14 +282,48 @@ ENTRIES:
 	return es
 }
 
-func makeInstances(entries []*consul.ServiceEntry) []string {
+func (s *Instancer) makeInstances(entries []*consul.ServiceEntry) []string {
 	instances := make([]string, len(entries))
 	for i, entry := range entries {
-		addr := entry.Node.Address
+		addr, port := entry.Node.Address, entry.Service.Port
 		if entry.Service.Address != "" {
 			addr = entry.Service.Address
 		}
-		instances[i] = fmt.Sprintf("%s:%d", addr, entry.Service.Port)
+		if tagged, ok := entry.Service.TaggedAddresses[s.address]; ok && s.address != "" && tagged.Address != "" {
+			addr, port = tagged.Address, tagged.Port
+		}
+		instances[i] = net.JoinHostPort(addr, strconv.Itoa(port))
+
+		if len(s.meta) > 0 {
+			values := url.Values{}
+			for _, key := range s.meta {
+				if value, ok := entry.Service.Meta[key]; ok {
+					values.Set(key, value)
+				}
+			}
+			if len(values) > 0 {
+				instances[i] += "?" + values.Encode()
+			}
+		}
 	}
 	return instances
 }
+
+// ParseInstance splits an instance string produced by an Instancer with the
+// InstanceMeta option into its address and metadata. Instance strings without
+// metadata yield a nil map.
+func ParseInstance(instance string) (addr string, meta map[string]string, err error) {
+	addr, query, ok := strings.Cut(instance, "?")
+	if !ok {
+		return addr, nil, nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", nil, err
+	}
+	meta = make(map[string]string, len(values))
+	for key := range values {
+		meta[key] = values.Get(key)
+	}
+	return addr, meta, nil
+}
@@ -2,8 +2,10 @@ package consul
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
@@ -325,3 +327,179 @@ func TestInstancerLoopIndex(t *testing.T) {
 
 	time.Sleep(2 * time.Second)
 }
+
+type queryTestClient struct {
+	Client
+	queries chan *consul.QueryOptions
+}
+
+func (c *queryTestClient) Service(service, tag string, passingOnly bool, queryOpts *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
+	select {
+	case c.queries <- queryOpts:
+	default:
+	}
+	return c.Client.Service(service, tag, passingOnly, queryOpts)
+}
+
+func TestInstancerOptions(t *testing.T) {
+	var (
+		logger = log.NewNopLogger()
+		client = &queryTestClient{
+			Client: newTestClient([]*consul.ServiceEntry{
+				{
+					Node: &consul.Node{Address: "10.0.0.1"},
+					Service: &consul.AgentService{
+						Service: "search",
+						Port:    8000,
+						Meta:    map[string]string{"version": "2", "zone": "eu-1", "owner": "search-team"},
+						TaggedAddresses: map[string]consul.ServiceAddress{
+							"wan": {Address: "203.0.113.1", Port: 443},
+						},
+					},
+				},
+				{
+					Node:    &consul.Node{Address: "10.0.0.2"},
+					Service: &consul.AgentService{Service: "search", Port: 8000},
+				},
+			}),
+			queries: make(chan *consul.QueryOptions, 1),
+		}
+	)
+
+	s := NewInstancer(client, logger, "search", nil, true,
+		Namespace("team-a"),
+		Partition("eu"),
+		Filter(`Service.Meta.version == "2"`),
+		TaggedAddress("wan"),
+		InstanceMeta("version", "zone", "missing"),
+		WaitTime(time.Minute),
+	)
+	defer s.Stop()
+
+	q := <-client.queries
+	if want, have := "team-a", q.Namespace; want != have {
+		t.Errorf("namespace: want %q, have %q", want, have)
+	}
+	if want, have := "eu", q.Partition; want != have {
+		t.Errorf("partition: want %q, have %q", want, have)
+	}
+	if want, have := `Service.Meta.version == "2"`, q.Filter; want != have {
+		t.Errorf("filter: want %q, have %q", want, have)
+	}
+	if want, have := time.Minute, q.WaitTime; want != have {
+		t.Errorf("wait time: want %v, have %v", want, have)
+	}
+
+	state := s.cache.State()
+	if want, have := []string{"10.0.0.2:8000", "203.0.113.1:443?version=2&zone=eu-1"}, state.Instances; fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	addr, meta, err := ParseInstance(state.Instances[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "203.0.113.1:443", addr; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "eu-1", meta["zone"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+type churnTestClient struct {
+	Client
+	mtx   sync.Mutex
+	index uint64
+	err   error
+}
+
+func (c *churnTestClient) Service(service, tag string, passingOnly bool, queryOpts *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
+	time.Sleep(time.Millisecond)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+	c.index++
+	entries := consulState[:1+c.index%2]
+	return entries, &consul.QueryMeta{LastIndex: c.index}, nil
+}
+
+func TestInstancerRetryBackoff(t *testing.T) {
+	var (
+		client   = &churnTestClient{err: errors.New("consul unavailable")}
+		attempts = make(chan int, 100)
+		backoff  = func(attempt int) time.Duration {
+			attempts <- attempt
+			return time.Millisecond
+		}
+	)
+
+	s := NewInstancer(client, log.NewNopLogger(), "search", nil, true, RetryBackoff(backoff))
+	defer s.Stop()
+
+	for want := 1; want <= 3; want++ {
+		select {
+		case have := <-attempts:
+			if want != have {
+				t.Errorf("want attempt %d, have %d", want, have)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for retry")
+		}
+	}
+
+	// A successful query resets the attempts.
+	client.mtx.Lock()
+	client.err = nil
+	client.mtx.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	client.mtx.Lock()
+	client.err = errors.New("consul unavailable again")
+	client.mtx.Unlock()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case have := <-attempts:
+			if have == 1 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("attempts weren't reset")
+		}
+	}
+}
+
+type timedTestClient struct {
+	churnTestClient
+	queries []time.Time
+}
+
+func (c *timedTestClient) Service(service, tag string, passingOnly bool, queryOpts *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
+	c.mtx.Lock()
+	c.queries = append(c.queries, time.Now())
+	c.mtx.Unlock()
+	return c.churnTestClient.Service(service, tag, passingOnly, queryOpts)
+}
+
+func TestInstancerMinQueryInterval(t *testing.T) {
+	client := &timedTestClient{}
+	s := NewInstancer(client, log.NewNopLogger(), "search", nil, true, MinQueryInterval(20*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+	s.Stop()
+
+	client.mtx.Lock()
+	defer client.mtx.Unlock()
+	// The index changes with every query, which would otherwise be repeated
+	// every millisecond. The first query, by NewInstancer, isn't limited.
+	if have := len(client.queries); have < 3 || have > 12 {
+		t.Errorf("want between 3 and 12 queries, have %d", have)
+	}
+	for i := 2; i < len(client.queries); i++ {
+		if d := client.queries[i].Sub(client.queries[i-1]); d < 20*time.Millisecond {
+			t.Errorf("query %d: %v after the previous one", i, d)
+		}
+	}
+}
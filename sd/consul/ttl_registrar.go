@@ -0,0 +1,192 @@
+package consul
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	stdconsul "github.com/hashicorp/consul/api"
+
+	"github.com/go-kit/log"
+)
+
+// TTLRegistrar registers a service with TTL health checks, and keeps passing
+// those checks from a background loop for as long as it's registered. The TTL is
+// a multiple of the heartbeat interval, so that a few missed heartbeats, e.g.
+// during long GC pauses, don't make the service flap to critical.
+//
+// On Deregister, the service is first put in maintenance mode, so that
+// consumers stop routing to it right away, and then deregistered.
+type TTLRegistrar struct {
+	client       TTLClient
+	registration *stdconsul.AgentServiceRegistration
+	checkIDs     []string
+	interval     time.Duration
+	logger       log.Logger
+
+	mtx   sync.Mutex
+	quitc chan struct{}
+	donec chan struct{}
+}
+
+// NewTTLRegistrar returns a TTLRegistrar for the provided registration,
+// passing its TTL checks, in Check and Checks, every interval. Other kinds of
+// checks are left to Consul. If the registration has no check, a TTL check of
+// three intervals is added. If interval isn't positive, it's a third of the
+// shortest TTL of the checks, or 10 seconds if there's none.
+func NewTTLRegistrar(client TTLClient, r *stdconsul.AgentServiceRegistration, interval time.Duration, logger log.Logger) *TTLRegistrar {
+	checks := r.Checks
+	if r.Check != nil {
+		checks = append(stdconsul.AgentServiceChecks{r.Check}, checks...)
+	}
+	if interval <= 0 {
+		interval = 10 * time.Second
+		for _, check := range checks {
+			if ttl, err := time.ParseDuration(check.TTL); err == nil && ttl > 0 && ttl/3 < interval {
+				interval = ttl / 3
+			}
+		}
+	}
+	if len(checks) == 0 {
+		check := *r
+		check.Check = &stdconsul.AgentServiceCheck{TTL: (3 * interval).String()}
+		r = &check
+		checks = stdconsul.AgentServiceChecks{r.Check}
+	}
+
+	// The agent names the checks it's not given an ID for after the service,
+	// numbering them if there are several.
+	serviceID := r.ID
+	if serviceID == "" {
+		serviceID = r.Name
+	}
+	var checkIDs []string
+	for i, check := range checks {
+		if check.TTL == "" {
+			continue
+		}
+		checkID := check.CheckID
+		if checkID == "" {
+			checkID = "service:" + serviceID
+			if len(checks) > 1 {
+				checkID += ":" + strconv.Itoa(i+1)
+			}
+		}
+		checkIDs = append(checkIDs, checkID)
+	}
+
+	return &TTLRegistrar{
+		client:       client,
+		registration: r,
+		checkIDs:     checkIDs,
+		interval:     interval,
+		logger:       log.With(logger, "service", r.Name, "tags", fmt.Sprint(r.Tags), "address", r.Address),
+	}
+}
+
+// Register implements sd.Registrar. It registers the service, passes its
+// check and starts the heartbeat loop.
+func (p *TTLRegistrar) Register() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.quitc != nil {
+		return // already registered
+	}
+	if err := p.client.Register(p.registration); err != nil {
+		p.logger.Log("err", err)
+	} else {
+		p.logger.Log("action", "register")
+	}
+	p.heartbeat()
+
+	p.quitc, p.donec = make(chan struct{}), make(chan struct{})
+	go p.loop(p.quitc, p.donec)
+}
+
+// Deregister implements sd.Registrar. It stops the heartbeat loop, puts the
+// service in maintenance mode and deregisters it.
+func (p *TTLRegistrar) Deregister() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.quitc == nil {
+		return // not registered
+	}
+	close(p.quitc)
+	<-p.donec
+	p.quitc, p.donec = nil, nil
+
+	if err := p.client.EnableServiceMaintenance(p.serviceID(), "shutting down", p.queryOptions()); err != nil {
+		p.logger.Log("during", "maintenance", "err", err)
+	}
+	if err := p.client.Deregister(p.registration); err != nil {
+		p.logger.Log("err", err)
+	} else {
+		p.logger.Log("action", "deregister")
+	}
+}
+
+// Close deregisters the service. It implements io.Closer, so that the
+// TTLRegistrar can be closed with other resources on shutdown.
+func (p *TTLRegistrar) Close() error {
+	p.Deregister()
+	return nil
+}
+
+func (p *TTLRegistrar) loop(quitc <-chan struct{}, donec chan<- struct{}) {
+	defer close(donec)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.heartbeat()
+		case <-quitc:
+			return
+		}
+	}
+}
+
+// heartbeat passes the TTL checks. If that fails, e.g. because the agent was
+// restarted and lost the registration, the service is registered again.
+func (p *TTLRegistrar) heartbeat() {
+	err := p.pass()
+	if err == nil {
+		return
+	}
+	p.logger.Log("during", "heartbeat", "err", err)
+	if err := p.client.Register(p.registration); err != nil {
+		p.logger.Log("during", "reregister", "err", err)
+		return
+	}
+	if err := p.pass(); err != nil {
+		p.logger.Log("during", "heartbeat", "err", err)
+	}
+}
+
+// pass passes every TTL check, and returns the first error.
+func (p *TTLRegistrar) pass() error {
+	var first error
+	for _, checkID := range p.checkIDs {
+		if err := p.client.UpdateTTL(checkID, "", stdconsul.HealthPassing, p.queryOptions()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (p *TTLRegistrar) queryOptions() *stdconsul.QueryOptions {
+	return &stdconsul.QueryOptions{
+		Namespace: p.registration.Namespace,
+		Partition: p.registration.Partition,
+	}
+}
+
+func (p *TTLRegistrar) serviceID() string {
+	if p.registration.ID != "" {
+		return p.registration.ID
+	}
+	return p.registration.Name
+}
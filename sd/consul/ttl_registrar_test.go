@@ -0,0 +1,153 @@
+package consul
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	stdconsul "github.com/hashicorp/consul/api"
+
+	"github.com/go-kit/log"
+)
+
+type ttlTestClient struct {
+	*testClient
+
+	mtx         sync.Mutex
+	checks      []string
+	maintenance []string
+	namespaces  []string
+	lost        bool
+}
+
+var _ TTLClient = &ttlTestClient{}
+
+func (c *ttlTestClient) Register(r *stdconsul.AgentServiceRegistration) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.lost = false
+	return c.testClient.Register(r)
+}
+
+func (c *ttlTestClient) Deregister(r *stdconsul.AgentServiceRegistration) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.testClient.Deregister(r)
+}
+
+func (c *ttlTestClient) UpdateTTL(checkID, _, status string, q *stdconsul.QueryOptions) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.namespaces = append(c.namespaces, q.Namespace+"/"+q.Partition)
+	if c.lost {
+		return errors.New("unknown check")
+	}
+	c.checks = append(c.checks, checkID+"="+status)
+	return nil
+}
+
+func (c *ttlTestClient) EnableServiceMaintenance(serviceID, _ string, q *stdconsul.QueryOptions) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.maintenance = append(c.maintenance, q.Namespace+"/"+q.Partition+"/"+serviceID)
+	return nil
+}
+
+func (c *ttlTestClient) heartbeats() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.checks)
+}
+
+func TestTTLRegistrar(t *testing.T) {
+	client := &ttlTestClient{testClient: newTestClient(nil)}
+	p := NewTTLRegistrar(client, testRegistration, 10*time.Millisecond, log.NewNopLogger())
+
+	p.Register()
+	client.mtx.Lock()
+	if want, have := "service:my-id=passing", client.checks[0]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	client.mtx.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	if have := client.heartbeats(); have < 3 {
+		t.Errorf("want at least 3 heartbeats, have %d", have)
+	}
+
+	// The agent lost the registration; the next heartbeat registers again.
+	client.mtx.Lock()
+	client.lost = true
+	client.entries = nil
+	client.mtx.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	client.mtx.Lock()
+	if want, have := 1, len(client.entries); want != have {
+		t.Errorf("entries after re-registration: want %d, have %d", want, have)
+	}
+	client.mtx.Unlock()
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 0, len(client.entries); want != have {
+		t.Errorf("entries: want %d, have %d", want, have)
+	}
+	if want, have := []string{"//my-id"}, client.maintenance; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("maintenance: want %v, have %v", want, have)
+	}
+	n := client.heartbeats()
+	time.Sleep(30 * time.Millisecond)
+	if want, have := n, client.heartbeats(); want != have {
+		t.Errorf("heartbeats after Close: want %d, have %d", want, have)
+	}
+}
+
+func TestTTLRegistrarDefaultCheck(t *testing.T) {
+	p := NewTTLRegistrar(&ttlTestClient{testClient: newTestClient(nil)}, testRegistration, time.Second, log.NewNopLogger())
+	if want, have := "3s", p.registration.Check.TTL; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if testRegistration.Check != nil {
+		t.Errorf("the original registration was modified")
+	}
+}
+
+func TestTTLRegistrarDefaultInterval(t *testing.T) {
+	client := &ttlTestClient{testClient: newTestClient(nil)}
+	if want, have := 10*time.Second, NewTTLRegistrar(client, testRegistration, 0, log.NewNopLogger()).interval; want != have {
+		t.Errorf("without checks: want %v, have %v", want, have)
+	}
+	r := *testRegistration
+	r.Checks = stdconsul.AgentServiceChecks{{TTL: "30s"}, {TTL: "6s"}}
+	if want, have := 2*time.Second, NewTTLRegistrar(client, &r, -time.Second, log.NewNopLogger()).interval; want != have {
+		t.Errorf("with checks: want %v, have %v", want, have)
+	}
+}
+
+func TestTTLRegistrarChecks(t *testing.T) {
+	client := &ttlTestClient{testClient: newTestClient(nil)}
+	r := *testRegistration
+	r.Namespace, r.Partition = "team", "part"
+	r.Check = &stdconsul.AgentServiceCheck{TTL: "30s"}
+	r.Checks = stdconsul.AgentServiceChecks{
+		{HTTP: "http://my-address:12345/health", Interval: "10s"},
+		{CheckID: "db", TTL: "30s"},
+	}
+	p := NewTTLRegistrar(client, &r, time.Hour, log.NewNopLogger())
+
+	p.Register()
+	p.Deregister()
+	client.mtx.Lock()
+	defer client.mtx.Unlock()
+	if want, have := []string{"service:my-id:1=passing", "db=passing"}, client.checks; !reflect.DeepEqual(want, have) {
+		t.Errorf("checks: want %v, have %v", want, have)
+	}
+	if want, have := []string{"team/part", "team/part"}, client.namespaces; !reflect.DeepEqual(want, have) {
+		t.Errorf("heartbeat namespaces: want %v, have %v", want, have)
+	}
+	if want, have := []string{"team/part/my-id"}, client.maintenance; !reflect.DeepEqual(want, have) {
+		t.Errorf("maintenance: want %v, have %v", want, have)
+	}
+}
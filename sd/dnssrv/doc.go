@@ -1,2 +1,3 @@
-// Package dnssrv provides an Instancer implementation for DNS SRV records.
+// Package dnssrv provides an Instancer implementation for DNS SRV records, and
+// for the A and AAAA records of a host.
 package dnssrv
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
+	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/sd"
 	"github.com/a69/kit.go/sd/internal/instance"
 	"github.com/go-kit/log"
@@ -16,8 +18,13 @@ import (
 // port set to zero.
 var ErrPortZero = errors.New("resolver returned SRV record with port 0")
 
-// Instancer yields instances from the named DNS SRV record. The name is
-// resolved on a fixed schedule. Priorities and weights are ignored.
+// Instancer yields instances from the named DNS SRV record, or from the A and
+// AAAA records of a host. The name is resolved periodically, optionally with
+// jitter, and, with a TTLResolver, as soon as the records expire, if that's
+// before the end of the interval. Without one, the lookup functions of package
+// net don't report TTLs, so the interval should be no longer than the TTLs
+// the DNS server hands out, or changes will be seen late. Priorities and
+// weights are ignored.
 type Instancer struct {
 	cache  *instance.Cache
 	name   string
@@ -25,13 +32,77 @@ type Instancer struct {
 	quit   chan struct{}
 }
 
-// NewInstancer returns a DNS SRV instancer.
+// Option sets an optional parameter for Instancers.
+type Option func(*options)
+
+type options struct {
+	jitter     float64
+	lookupHost LookupHost
+	resolver   Resolver
+}
+
+// Jitter randomizes the lookup interval by up to the given fraction in either
+// direction, e.g. 0.1 turns a 30s interval into one between 27s and 33s. It
+// keeps a fleet of clients started at the same time from hitting the DNS
+// server in lockstep. By default, there's no jitter.
+func Jitter(fraction float64) Option {
+	return func(o *options) { o.jitter = fraction }
+}
+
+// HostLookup sets the function used by NewARecordInstancer to resolve a host.
+// By default, net.LookupHost is used.
+func HostLookup(lookup LookupHost) Option {
+	return func(o *options) { o.lookupHost = lookup }
+}
+
+// TTLResolver sets the Resolver used to resolve names along with the TTLs of
+// their records, e.g. a DNSResolver. The next lookup then happens when the
+// records expire, if that's before the end of the interval. It takes
+// precedence over HostLookup. By default, names are resolved by package net,
+// every interval.
+func TTLResolver(r Resolver) Option {
+	return func(o *options) { o.resolver = r }
+}
+
+// NewInstancer returns a DNS SRV instancer, resolving the name every ttl, or
+// as soon as the records expire with a TTLResolver.
 func NewInstancer(
 	name string,
 	ttl time.Duration,
 	logger log.Logger,
+	opts ...Option,
+) *Instancer {
+	o := makeOptions(opts)
+	resolve := resolveSRV(name, func(name string) ([]*net.SRV, time.Duration, error) {
+		_, addrs, err := net.LookupSRV("", "", name)
+		return addrs, 0, err
+	})
+	if o.resolver != nil {
+		resolve = resolveSRV(name, o.resolver.LookupSRV)
+	}
+	return newInstancer(name, nil, schedule(ttl, o.jitter), resolve, logger)
+}
+
+// NewARecordInstancer returns an instancer that resolves the A and AAAA records
+// of host every ttl, or as soon as they expire, like for NewInstancer, pairing
+// every address with port. It's the simplest possible discovery for headless
+// Kubernetes services, or Consul DNS, when SRV records aren't available.
+func NewARecordInstancer(
+	host string,
+	port int,
+	ttl time.Duration,
+	logger log.Logger,
+	opts ...Option,
 ) *Instancer {
-	return NewInstancerDetailed(name, time.NewTicker(ttl), net.LookupSRV, logger)
+	o := makeOptions(opts)
+	resolve := resolveHost(host, port, func(host string) ([]string, time.Duration, error) {
+		addrs, err := o.lookupHost(host)
+		return addrs, 0, err
+	})
+	if o.resolver != nil {
+		resolve = resolveHost(host, port, o.resolver.LookupHost)
+	}
+	return newInstancer(host, nil, schedule(ttl, o.jitter), resolve, logger)
 }
 
 // NewInstancerDetailed is the same as NewInstancer, but allows users to
@@ -42,6 +113,26 @@ func NewInstancerDetailed(
 	refresh *time.Ticker,
 	lookup Lookup,
 	logger log.Logger,
+) *Instancer {
+	return newInstancer(name, refresh, nil, resolveSRV(name, func(name string) ([]*net.SRV, time.Duration, error) {
+		_, addrs, err := lookup("", "", name)
+		return addrs, 0, err
+	}), logger)
+}
+
+// resolveFunc resolves the instances, and the TTL of their records, zero if
+// unknown.
+type resolveFunc func() ([]string, time.Duration, error)
+
+// newInstancer returns an Instancer resolving the name on every tick of
+// refresh, if it's not nil, or else after the delay returned by next, given
+// the TTL of the records last resolved.
+func newInstancer(
+	name string,
+	refresh *time.Ticker,
+	next func(ttl time.Duration) time.Duration,
+	resolve resolveFunc,
+	logger log.Logger,
 ) *Instancer {
 	p := &Instancer{
 		cache:  instance.NewCache(),
@@ -50,7 +141,7 @@ func NewInstancerDetailed(
 		quit:   make(chan struct{}),
 	}
 
-	instances, err := p.resolve(lookup)
+	instances, ttl, err := resolve()
 	if err == nil {
 		logger.Log("name", name, "instances", len(instances))
 	} else {
@@ -58,7 +149,7 @@ func NewInstancerDetailed(
 	}
 	p.cache.Update(sd.Event{Instances: instances, Err: err})
 
-	go p.loop(refresh, lookup)
+	go p.loop(refresh, next, ttl, resolve)
 	return p
 }
 
@@ -67,17 +158,29 @@ func (in *Instancer) Stop() {
 	close(in.quit)
 }
 
-func (in *Instancer) loop(t *time.Ticker, lookup Lookup) {
-	defer t.Stop()
+func (in *Instancer) loop(refresh *time.Ticker, next func(ttl time.Duration) time.Duration, ttl time.Duration, resolve resolveFunc) {
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+	ticks := timer.C
+	if refresh != nil {
+		defer refresh.Stop()
+		ticks = refresh.C
+	}
 	for {
+		if refresh == nil {
+			timer.Reset(next(ttl))
+		}
 		select {
-		case <-t.C:
-			instances, err := in.resolve(lookup)
+		case <-ticks:
+			instances, t, err := resolve()
 			if err != nil {
 				in.logger.Log("name", in.name, "err", err)
 				in.cache.Update(sd.Event{Err: err})
+				ttl = 0
 				continue // don't replace potentially-good with bad
 			}
+			ttl = t
 			in.cache.Update(sd.Event{Instances: instances})
 
 		case <-in.quit:
@@ -86,19 +189,59 @@ func (in *Instancer) loop(t *time.Ticker, lookup Lookup) {
 	}
 }
 
-func (in *Instancer) resolve(lookup Lookup) ([]string, error) {
-	_, addrs, err := lookup("", "", in.name)
-	if err != nil {
-		return nil, err
+func resolveSRV(name string, lookup func(name string) ([]*net.SRV, time.Duration, error)) resolveFunc {
+	return func() ([]string, time.Duration, error) {
+		addrs, ttl, err := lookup(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		instances := make([]string, len(addrs))
+		for i, addr := range addrs {
+			if addr.Port == 0 {
+				return nil, 0, ErrPortZero
+			}
+			instances[i] = net.JoinHostPort(addr.Target, fmt.Sprint(addr.Port))
+		}
+		return instances, ttl, nil
 	}
-	instances := make([]string, len(addrs))
-	for i, addr := range addrs {
-		if addr.Port == 0 {
-			return nil, ErrPortZero
+}
+
+func resolveHost(host string, port int, lookup func(host string) ([]string, time.Duration, error)) resolveFunc {
+	return func() ([]string, time.Duration, error) {
+		if port == 0 {
+			return nil, 0, ErrPortZero
+		}
+		addrs, ttl, err := lookup(host)
+		if err != nil {
+			return nil, 0, err
+		}
+		instances := make([]string, len(addrs))
+		for i, addr := range addrs {
+			instances[i] = net.JoinHostPort(addr, strconv.Itoa(port))
+		}
+		return instances, ttl, nil
+	}
+}
+
+func makeOptions(opts []Option) options {
+	o := options{lookupHost: net.LookupHost}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// schedule returns the delay before the next lookup: the interval, or the TTL
+// of the records if it's known and shorter, randomized by up to the jitter
+// fraction in either direction.
+func schedule(interval time.Duration, jitter float64) func(ttl time.Duration) time.Duration {
+	return func(ttl time.Duration) time.Duration {
+		d := interval
+		if ttl > 0 && ttl < d {
+			d = ttl
 		}
-		instances[i] = net.JoinHostPort(addr.Target, fmt.Sprint(addr.Port))
+		return endpoint.Jitter(func(int) time.Duration { return d }, jitter)(1)
 	}
-	return instances, nil
 }
 
 // Register implements Instancer.
@@ -2,6 +2,7 @@ package dnssrv
 
 import (
 	"net"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -98,3 +99,84 @@ func TestIssue892(t *testing.T) {
 type nopCloser struct{}
 
 func (nopCloser) Close() error { return nil }
+
+func TestARecordInstancer(t *testing.T) {
+	var (
+		addrs  = []string{"10.0.0.1", "::1"}
+		lookup = func(host string) ([]string, error) {
+			if want, have := "svc.internal", host; want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+			return addrs, nil
+		}
+		instancer = NewARecordInstancer("svc.internal", 8080, time.Hour, log.NewNopLogger(), HostLookup(lookup), Jitter(0.1))
+	)
+	defer instancer.Stop()
+
+	state := instancer.cache.State()
+	if state.Err != nil {
+		t.Fatal(state.Err)
+	}
+	if want, have := []string{"10.0.0.1:8080", "[::1]:8080"}, state.Instances; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	next := schedule(time.Minute, 0)
+	for _, tc := range []struct {
+		ttl, want time.Duration
+	}{
+		{0, time.Minute},
+		{time.Second, time.Second},
+		{time.Hour, time.Minute},
+	} {
+		if have := next(tc.ttl); tc.want != have {
+			t.Errorf("ttl %v: want %v, have %v", tc.ttl, tc.want, have)
+		}
+	}
+
+	next = schedule(time.Minute, 0.5)
+	for i := 0; i < 100; i++ {
+		if d := next(0); d < 30*time.Second || d > 90*time.Second {
+			t.Fatalf("want a delay within 50%% of %v, have %v", time.Minute, d)
+		}
+	}
+}
+
+type ttlResolver struct {
+	lookups atomic.Uint64
+	ttl     time.Duration
+}
+
+func (r *ttlResolver) LookupSRV(string) ([]*net.SRV, time.Duration, error) {
+	r.lookups.Add(1)
+	return []*net.SRV{{Target: "1.0.0.1", Port: 80}}, r.ttl, nil
+}
+
+func (r *ttlResolver) LookupHost(string) ([]string, time.Duration, error) {
+	r.lookups.Add(1)
+	return []string{"1.0.0.1"}, r.ttl, nil
+}
+
+func TestRecordTTL(t *testing.T) {
+	// Records expiring before the end of the interval are resolved again.
+	r := &ttlResolver{ttl: 10 * time.Millisecond}
+	instancer := NewInstancer("some.service.internal", time.Hour, log.NewNopLogger(), TTLResolver(r))
+	defer instancer.Stop()
+	for deadline := time.Now().Add(time.Second); r.lookups.Load() < 3; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want lookups every TTL, have %d", r.lookups.Load())
+		}
+	}
+
+	// Longer TTLs don't delay lookups past the interval.
+	r = &ttlResolver{ttl: time.Hour}
+	instancer = NewARecordInstancer("svc.internal", 8080, 10*time.Millisecond, log.NewNopLogger(), TTLResolver(r))
+	defer instancer.Stop()
+	for deadline := time.Now().Add(time.Second); r.lookups.Load() < 3; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want lookups every interval, have %d", r.lookups.Load())
+		}
+	}
+}
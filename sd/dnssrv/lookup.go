@@ -5,3 +5,7 @@ import "net"
 // Lookup is a function that resolves a DNS SRV record to multiple addresses.
 // It has the same signature as net.LookupSRV.
 type Lookup func(service, proto, name string) (cname string, addrs []*net.SRV, err error)
+
+// LookupHost is a function that resolves a host to its addresses, from its A
+// and AAAA records. It has the same signature as net.LookupHost.
+type LookupHost func(host string) (addrs []string, err error)
@@ -0,0 +1,127 @@
+package dnssrv
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Resolver resolves names like Lookup and LookupHost, also returning the
+// smallest TTL of the records answered, which the lookup functions of package
+// net don't report. A TTL of zero means it's unknown.
+type Resolver interface {
+	LookupSRV(name string) (addrs []*net.SRV, ttl time.Duration, err error)
+	LookupHost(host string) (addrs []string, ttl time.Duration, err error)
+}
+
+// DNSResolver is a Resolver querying DNS servers directly. Names are taken as
+// fully qualified: search domains aren't applied.
+type DNSResolver struct {
+	client    *dns.Client
+	tcpClient *dns.Client
+	servers   []string
+}
+
+// NewDNSResolver returns a DNSResolver querying the servers, as host:port, in
+// order until one of them answers. With no servers, those of /etc/resolv.conf
+// are queried. Queries are sent over UDP, advertising an EDNS0 buffer size,
+// and retried over TCP if the answer is truncated anyway, as it can be for
+// services with many instances.
+func NewDNSResolver(servers ...string) (*DNSResolver, error) {
+	if len(servers) == 0 {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range config.Servers {
+			servers = append(servers, net.JoinHostPort(s, config.Port))
+		}
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no DNS servers")
+	}
+	return &DNSResolver{
+		client:    &dns.Client{Timeout: 5 * time.Second},
+		tcpClient: &dns.Client{Net: "tcp", Timeout: 5 * time.Second},
+		servers:   servers,
+	}, nil
+}
+
+// LookupSRV implements Resolver.
+func (r *DNSResolver) LookupSRV(name string) ([]*net.SRV, time.Duration, error) {
+	answer, ttl, err := r.exchange(name, dns.TypeSRV)
+	if err != nil {
+		return nil, 0, err
+	}
+	var addrs []*net.SRV
+	for _, rr := range answer {
+		if srv, ok := rr.(*dns.SRV); ok {
+			addrs = append(addrs, &net.SRV{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight})
+		}
+	}
+	return addrs, ttl, nil
+}
+
+// LookupHost implements Resolver, from the A and AAAA records of host.
+func (r *DNSResolver) LookupHost(host string) ([]string, time.Duration, error) {
+	var (
+		addrs []string
+		ttl   time.Duration
+	)
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		answer, t, err := r.exchange(host, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, rr := range answer {
+			switch rr := rr.(type) {
+			case *dns.A:
+				addrs = append(addrs, rr.A.String())
+			case *dns.AAAA:
+				addrs = append(addrs, rr.AAAA.String())
+			}
+		}
+		ttl = minTTL(ttl, t)
+	}
+	return addrs, ttl, nil
+}
+
+// exchange queries the servers for the records of name, and returns the
+// answer, with its smallest TTL.
+func (r *DNSResolver) exchange(name string, qtype uint16) ([]dns.RR, time.Duration, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.SetEdns0(4096, false)
+	var err error
+	for _, server := range r.servers {
+		var in *dns.Msg
+		if in, _, err = r.client.Exchange(m, server); err != nil {
+			continue
+		}
+		if in.Truncated {
+			if in, _, err = r.tcpClient.Exchange(m, server); err != nil {
+				continue
+			}
+		}
+		if in.Rcode != dns.RcodeSuccess {
+			return nil, 0, fmt.Errorf("lookup %s: %s", name, dns.RcodeToString[in.Rcode])
+		}
+		var ttl time.Duration
+		for _, rr := range in.Answer {
+			ttl = minTTL(ttl, time.Duration(rr.Header().Ttl)*time.Second)
+		}
+		return in.Answer, ttl, nil
+	}
+	return nil, 0, err
+}
+
+// minTTL returns the smallest of two TTLs, zero being unknown.
+func minTTL(a, b time.Duration) time.Duration {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,124 @@
+package dnssrv
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func newDNSServer(t *testing.T, records ...string) string {
+	t.Helper()
+	var rrs []dns.RR
+	for _, s := range records {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rrs = append(rrs, rr)
+	}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		q := req.Question[0]
+		found := false
+		for _, rr := range rrs {
+			if rr.Header().Name != q.Name {
+				continue
+			}
+			found = true
+			if rr.Header().Rrtype == q.Qtype {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		if !found {
+			m.Rcode = dns.RcodeNameError
+		}
+		if w.LocalAddr().Network() == "udp" {
+			size := dns.MinMsgSize
+			if opt := req.IsEdns0(); opt != nil {
+				size = int(opt.UDPSize())
+			}
+			m.Truncate(size)
+		}
+		w.WriteMsg(m)
+	})
+	for _, srv := range []*dns.Server{{PacketConn: pc, Handler: handler}, {Listener: l, Handler: handler}} {
+		started := make(chan struct{})
+		srv.NotifyStartedFunc = func() { close(started) }
+		go srv.ActivateAndServe()
+		t.Cleanup(func() { srv.Shutdown() })
+		<-started
+	}
+	return pc.LocalAddr().String()
+}
+
+func TestDNSResolver(t *testing.T) {
+	server := newDNSServer(t,
+		"_http._tcp.svc.internal. 30 IN SRV 0 0 8080 a.svc.internal.",
+		"_http._tcp.svc.internal. 10 IN SRV 0 0 8081 b.svc.internal.",
+		"a.svc.internal. 60 IN A 10.0.0.1",
+		"a.svc.internal. 20 IN AAAA ::1",
+	)
+	r, err := NewDNSResolver(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, ttl, err := r.LookupSRV("_http._tcp.svc.internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []*net.SRV{{Target: "a.svc.internal.", Port: 8080}, {Target: "b.svc.internal.", Port: 8081}}, addrs; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 10*time.Second, ttl; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	hosts, ttl, err := r.LookupHost("a.svc.internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := []string{"10.0.0.1", "::1"}, hosts; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 20*time.Second, ttl; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	if _, _, err := r.LookupHost("nope.svc.internal"); err == nil {
+		t.Error("want an error for an unknown name, have none")
+	}
+}
+
+func TestDNSResolverTruncated(t *testing.T) {
+	// Too many records for a UDP answer, even with EDNS0.
+	var records []string
+	for i := 0; i < 300; i++ {
+		records = append(records, fmt.Sprintf("_http._tcp.svc.internal. 30 IN SRV 0 0 8080 instance-%d.svc.internal.", i))
+	}
+	r, err := NewDNSResolver(newDNSServer(t, records...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, _, err := r.LookupSRV("_http._tcp.svc.internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := len(records), len(addrs); want != have {
+		t.Errorf("want %d records, have %d", want, have)
+	}
+}
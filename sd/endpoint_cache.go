@@ -18,10 +18,15 @@ type endpointCache[REQ any, RES any] struct {
 	mtx                sync.RWMutex
 	factory            Factory[REQ, RES]
 	cache              map[string]endpointCloser[REQ, RES]
+	evicted            map[string]evictedEndpoint[REQ, RES]
+	sweep              *time.Timer
 	err                error
 	endpoints          []endpoint.Endpoint[REQ, RES]
+	instanceEndpoints  []InstanceEndpoint[REQ, RES]
 	logger             log.Logger
 	invalidateDeadline time.Time
+	invalidated        bool
+	lastUpdate         time.Time
 	timeNow            func() time.Time
 }
 
@@ -30,12 +35,20 @@ type endpointCloser[REQ any, RES any] struct {
 	io.Closer
 }
 
+// evictedEndpoint is the endpoint of an instance that disappeared, kept open
+// for the EvictionDelay in case it reappears.
+type evictedEndpoint[REQ any, RES any] struct {
+	endpointCloser[REQ, RES]
+	since time.Time
+}
+
 // newEndpointCache returns a new, empty endpointCache.
 func newEndpointCache[REQ any, RES any](factory Factory[REQ, RES], logger log.Logger, options endpointerOptions) *endpointCache[REQ, RES] {
 	return &endpointCache[REQ, RES]{
 		options: options,
 		factory: factory,
 		cache:   map[string]endpointCloser[REQ, RES]{},
+		evicted: map[string]evictedEndpoint[REQ, RES]{},
 		logger:  logger,
 		timeNow: time.Now,
 	}
@@ -53,18 +66,25 @@ func (c *endpointCache[REQ, RES]) Update(event Event) {
 	if event.Err == nil {
 		c.updateCache(event.Instances)
 		c.err = nil
+		c.invalidated = false
+		c.lastUpdate = c.timeNow()
+		c.reportHealth(Healthy)
+		if m := c.options.metrics; m.Instances != nil {
+			m.Instances.Set(float64(len(event.Instances)))
+		}
+		if m := c.options.metrics; m.LastUpdate != nil {
+			m.LastUpdate.Set(float64(c.lastUpdate.UnixNano()) / float64(time.Second))
+		}
 		return
 	}
 
 	// Sad path. Something's gone wrong in sd.
 	c.logger.Log("err", event.Err)
-	if !c.options.invalidateOnError {
-		return // keep returning the last known endpoints on error
-	}
 	if c.err != nil {
 		return // already in the error state, do nothing & keep original error
 	}
 	c.err = event.Err
+	c.reportHealth(Degraded)
 	// set new deadline to invalidate Endpoints unless non-error Event is received
 	c.invalidateDeadline = c.timeNow().Add(c.options.invalidateTimeout)
 	return
@@ -84,37 +104,112 @@ func (c *endpointCache[REQ, RES]) updateCache(instances []string) {
 			continue
 		}
 
+		// If it was evicted recently, revive it.
+		if ev, ok := c.evicted[instance]; ok {
+			cache[instance] = ev.endpointCloser
+			delete(c.evicted, instance)
+			continue
+		}
+
 		// If it doesn't exist, create it.
 		service, closer, err := c.factory(instance)
 		if err != nil {
 			c.logger.Log("instance", instance, "err", err)
+			if m := c.options.metrics; m.FactoryFailures != nil {
+				m.FactoryFailures.Add(1)
+			}
 			continue
 		}
 		cache[instance] = endpointCloser[REQ, RES]{service, closer}
 	}
 
-	// Close any leftover endpoints.
-	for _, sc := range c.cache {
-		if sc.Closer != nil {
-			sc.Closer.Close()
+	// Close any leftover endpoints, or keep them around for a while.
+	now := c.timeNow()
+	for instance, sc := range c.cache {
+		if c.options.evictionDelay > 0 {
+			c.evicted[instance] = evictedEndpoint[REQ, RES]{sc, now}
+			continue
 		}
+		c.close(sc)
 	}
+	c.sweepEvicted(now)
 
 	// Populate the slice of endpoints.
 	endpoints := make([]endpoint.Endpoint[REQ, RES], 0, len(cache))
+	ies := make([]InstanceEndpoint[REQ, RES], 0, len(cache))
 	for _, instance := range instances {
 		// A bad factory may mean an instance is not present.
 		if _, ok := cache[instance]; !ok {
 			continue
 		}
 		endpoints = append(endpoints, cache[instance].Endpoint)
+		ies = append(ies, InstanceEndpoint[REQ, RES]{Instance: instance, Endpoint: cache[instance].Endpoint})
 	}
 
 	// Swap and trigger GC for old copies.
 	c.endpoints = endpoints
+	c.instanceEndpoints = ies
 	c.cache = cache
 }
 
+func (c *endpointCache[REQ, RES]) close(sc endpointCloser[REQ, RES]) {
+	if sc.Closer != nil {
+		sc.Closer.Close()
+	}
+	if m := c.options.metrics; m.Evictions != nil {
+		m.Evictions.Add(1)
+	}
+}
+
+// sweepEvicted closes the evicted endpoints whose delay is over, and
+// schedules itself for the next one. It must be called with the lock held.
+func (c *endpointCache[REQ, RES]) sweepEvicted(now time.Time) {
+	var next time.Duration
+	for instance, ev := range c.evicted {
+		left := c.options.evictionDelay - now.Sub(ev.since)
+		if left <= 0 {
+			c.close(ev.endpointCloser)
+			delete(c.evicted, instance)
+			continue
+		}
+		if next == 0 || left < next {
+			next = left
+		}
+	}
+	if c.sweep != nil {
+		c.sweep.Stop()
+		c.sweep = nil
+	}
+	if next > 0 {
+		c.sweep = time.AfterFunc(next, func() {
+			c.mtx.Lock()
+			defer c.mtx.Unlock()
+			c.sweepEvicted(c.timeNow())
+		})
+	}
+}
+
+// closeEvicted closes all evicted endpoints at once. It must be called with
+// the lock held.
+func (c *endpointCache[REQ, RES]) closeEvicted() {
+	for instance, ev := range c.evicted {
+		c.close(ev.endpointCloser)
+		delete(c.evicted, instance)
+	}
+	if c.sweep != nil {
+		c.sweep.Stop()
+		c.sweep = nil
+	}
+}
+
+// Close closes the evicted endpoints and stops their sweep. Endpoints that
+// are still yielded are left open, as they may be in use.
+func (c *endpointCache[REQ, RES]) Close() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.closeEvicted()
+}
+
 // Endpoints yields the current set of (presumably identical) endpoints, ordered
 // lexicographically by the corresponding instance string.
 func (c *endpointCache[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
@@ -122,7 +217,7 @@ func (c *endpointCache[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], er
 	// concurrently, so to minimize contention we use a shared R-lock.
 	c.mtx.RLock()
 
-	if c.err == nil || c.timeNow().Before(c.invalidateDeadline) {
+	if !c.shouldInvalidate() {
 		defer c.mtx.RUnlock()
 		return c.endpoints, nil
 	}
@@ -134,10 +229,112 @@ func (c *endpointCache[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], er
 	defer c.mtx.Unlock()
 
 	// re-check condition due to a race between RUnlock() and Lock().
-	if c.err == nil || c.timeNow().Before(c.invalidateDeadline) {
+	if !c.shouldInvalidate() {
 		return c.endpoints, nil
 	}
 
 	c.updateCache(nil) // close any remaining active endpoints
+	c.closeEvicted()
+	if !c.invalidated {
+		c.invalidated = true
+		c.reportHealth(Invalidated)
+		if c.options.metrics.Invalidations != nil {
+			c.options.metrics.Invalidations.Add(1)
+		}
+	}
 	return nil, c.err
 }
+
+// InstanceEndpoints is like Endpoints, but pairs every endpoint with its
+// instance string.
+func (c *endpointCache[REQ, RES]) InstanceEndpoints() ([]InstanceEndpoint[REQ, RES], error) {
+	// Endpoints takes care of invalidation.
+	if _, err := c.Endpoints(); err != nil {
+		return nil, err
+	}
+
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.instanceEndpoints, nil
+}
+
+// shouldInvalidate must be called with at least a read lock held.
+func (c *endpointCache[REQ, RES]) shouldInvalidate() bool {
+	return c.err != nil && c.options.invalidateOnError && !c.timeNow().Before(c.invalidateDeadline)
+}
+
+// State returns a snapshot of the cache's health.
+func (c *endpointCache[REQ, RES]) State() EndpointerState {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	state := EndpointerState{
+		Health:     Healthy,
+		Err:        c.err,
+		LastUpdate: c.lastUpdate,
+		Endpoints:  len(c.endpoints),
+	}
+	switch {
+	case c.invalidated || c.shouldInvalidate():
+		state.Health = Invalidated
+		state.Endpoints = 0
+	case c.err != nil:
+		state.Health = Degraded
+	}
+	return state
+}
+
+func (c *endpointCache[REQ, RES]) reportHealth(h Health) {
+	if c.options.metrics.Health != nil {
+		c.options.metrics.Health.Set(float64(h))
+	}
+}
+
+// Health describes how much an Endpointer's view of service discovery can be
+// trusted.
+type Health int
+
+const (
+	// Healthy means the last event from the Instancer carried instances.
+	Healthy Health = iota
+
+	// Degraded means the last event from the Instancer was an error, and the
+	// Endpointer keeps serving the last known, possibly stale, endpoints.
+	Degraded
+
+	// Invalidated means the Endpointer was configured with InvalidateOnError,
+	// the timeout has elapsed, and it now returns an error instead of
+	// endpoints.
+	Invalidated
+)
+
+func (h Health) String() string {
+	switch h {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	case Invalidated:
+		return "invalidated"
+	default:
+		return "unknown"
+	}
+}
+
+// EndpointerState is a snapshot of an Endpointer's health, suitable for
+// readiness probes.
+type EndpointerState struct {
+	Health     Health
+	Err        error     // the error that moved the Endpointer out of Healthy, if any
+	LastUpdate time.Time // time of the last successful update; zero if none yet
+	Endpoints  int       // number of endpoints currently served
+}
+
+// Staleness returns the time elapsed since the last successful update. If
+// there was never a successful update, it returns a negative duration.
+func (s EndpointerState) Staleness() time.Duration {
+	if s.LastUpdate.IsZero() {
+		return -1
+	}
+	return time.Since(s.LastUpdate)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics/generic"
 	"github.com/go-kit/log"
 )
 
@@ -144,6 +145,157 @@ func TestEndpointCacheErrorAndTimeout(t *testing.T) {
 	assertEndpointsError(t, cache, "sd error") // expect original error
 }
 
+func TestEndpointCacheState(t *testing.T) {
+	var (
+		health        = generic.NewGauge("health")
+		invalidations = generic.NewCounter("invalidations")
+		timeOut       = 100 * time.Millisecond
+		opts          = endpointerOptions{invalidateOnError: true, invalidateTimeout: timeOut}
+	)
+	HealthMetrics(health, invalidations)(&opts)
+	cache := newEndpointCache(func(string) (endpoint.Endpoint[any, any], io.Closer, error) {
+		return endpoint.Nop[any, any], nil, nil
+	}, log.NewNopLogger(), opts)
+	timeNow := time.Now()
+	cache.timeNow = func() time.Time { return timeNow }
+
+	assertState := func(want Health, endpoints int) {
+		t.Helper()
+		state := cache.State()
+		if state.Health != want {
+			t.Errorf("health: want %s, have %s", want, state.Health)
+		}
+		if state.Endpoints != endpoints {
+			t.Errorf("endpoints: want %d, have %d", endpoints, state.Endpoints)
+		}
+		if have := health.Value(); have != float64(want) {
+			t.Errorf("health gauge: want %v, have %v", float64(want), have)
+		}
+	}
+
+	cache.Update(Event{Instances: []string{"a", "b"}})
+	assertState(Healthy, 2)
+	if want, have := timeNow, cache.State().LastUpdate; !want.Equal(have) {
+		t.Errorf("last update: want %v, have %v", want, have)
+	}
+
+	cache.Update(Event{Err: errors.New("sd error")})
+	assertState(Degraded, 2)
+
+	timeNow = timeNow.Add(2 * timeOut)
+	assertEndpointsError(t, cache, "sd error")
+	assertState(Invalidated, 0)
+	assertEndpointsError(t, cache, "sd error")
+	if want, have := 1.0, invalidations.Value(); want != have {
+		t.Errorf("invalidations: want %v, have %v", want, have)
+	}
+
+	cache.Update(Event{Instances: []string{"a"}})
+	assertState(Healthy, 1)
+}
+
+func TestEndpointCacheMetrics(t *testing.T) {
+	var (
+		m = Metrics{
+			Instances:       generic.NewGauge("instances"),
+			FactoryFailures: generic.NewCounter("factory_failures"),
+			Evictions:       generic.NewCounter("evictions"),
+			LastUpdate:      generic.NewGauge("last_update"),
+		}
+		opts = endpointerOptions{invalidateOnError: true}
+	)
+	DiscoveryMetrics(m)(&opts)
+	cache := newEndpointCache(func(instance string) (endpoint.Endpoint[any, any], io.Closer, error) {
+		if instance == "bad" {
+			return nil, nil, errors.New("bad instance")
+		}
+		return endpoint.Nop[any, any], nil, nil
+	}, log.NewNopLogger(), opts)
+	timeNow := time.Unix(1700000000, 0)
+	cache.timeNow = func() time.Time { return timeNow }
+
+	cache.Update(Event{Instances: []string{"a", "b", "bad"}})
+	if want, have := 3.0, m.Instances.(*generic.Gauge).Value(); want != have {
+		t.Errorf("instances: want %v, have %v", want, have)
+	}
+	if want, have := 1.0, m.FactoryFailures.(*generic.Counter).Value(); want != have {
+		t.Errorf("factory failures: want %v, have %v", want, have)
+	}
+	if want, have := 1700000000.0, m.LastUpdate.(*generic.Gauge).Value(); want != have {
+		t.Errorf("last update: want %v, have %v", want, have)
+	}
+
+	cache.Update(Event{Instances: []string{"a"}})
+	if want, have := 1.0, m.Evictions.(*generic.Counter).Value(); want != have {
+		t.Errorf("evictions: want %v, have %v", want, have)
+	}
+
+	// Invalidation evicts the remaining endpoint, but leaves the last update
+	// alone.
+	timeNow = timeNow.Add(time.Minute)
+	cache.Update(Event{Err: errors.New("sd error")})
+	assertEndpointsError(t, cache, "sd error")
+	if want, have := 2.0, m.Evictions.(*generic.Counter).Value(); want != have {
+		t.Errorf("evictions: want %v, have %v", want, have)
+	}
+	if want, have := 1700000000.0, m.LastUpdate.(*generic.Gauge).Value(); want != have {
+		t.Errorf("last update: want %v, have %v", want, have)
+	}
+}
+
+func TestEndpointCacheEvictionDelay(t *testing.T) {
+	var (
+		ca      = make(closer)
+		cb      = make(closer)
+		c       = map[string]io.Closer{"a": ca, "b": cb}
+		created = map[string]int{}
+		f       = func(instance string) (endpoint.Endpoint[any, any], io.Closer, error) {
+			created[instance]++
+			return endpoint.Nop[any, any], c[instance], nil
+		}
+		opts = endpointerOptions{invalidateOnError: true}
+	)
+	EvictionDelay(time.Minute)(&opts)
+	cache := newEndpointCache(f, log.NewNopLogger(), opts)
+	timeNow := time.Unix(1700000000, 0)
+	cache.timeNow = func() time.Time { return timeNow }
+
+	// b flaps, and its endpoint is reused.
+	cache.Update(Event{Instances: []string{"a", "b"}})
+	cache.Update(Event{Instances: []string{"a"}})
+	assertEndpointsLen(t, cache, 1)
+	select {
+	case <-cb:
+		t.Fatal("endpoint b closed, not good")
+	default:
+	}
+	cache.Update(Event{Instances: []string{"a", "b"}})
+	assertEndpointsLen(t, cache, 2)
+	if want, have := 1, created["b"]; want != have {
+		t.Errorf("want %d endpoints created for b, have %d", want, have)
+	}
+
+	// b is gone for good.
+	cache.Update(Event{Instances: []string{"a"}})
+	timeNow = timeNow.Add(2 * time.Minute)
+	cache.Update(Event{Instances: []string{"a"}})
+	select {
+	case <-cb:
+	default:
+		t.Fatal("didn't close the deleted instance")
+	}
+
+	// Invalidation closes everything at once.
+	cache.Update(Event{Instances: []string{}})
+	cache.Update(Event{Err: errors.New("sd error")})
+	assertEndpointsError(t, cache, "sd error")
+	select {
+	case <-ca:
+	default:
+		t.Fatal("didn't close the evicted instance on invalidation")
+	}
+}
+
 func TestBadFactory(t *testing.T) {
 	cache := newEndpointCache[any, any](func(string) (endpoint.Endpoint[any, any], io.Closer, error) {
 		return nil, nil, errors.New("bad factory")
@@ -182,3 +334,22 @@ func assertEndpointsError(t *testing.T, cache *endpointCache[any, any], wantErr
 type closer chan struct{}
 
 func (c closer) Close() error { close(c); return nil }
+
+func TestMetricsOptionsCombine(t *testing.T) {
+	var (
+		health    = generic.NewGauge("health")
+		instances = generic.NewGauge("instances")
+	)
+	for _, options := range [][]EndpointerOption{
+		{HealthMetrics(health, nil), DiscoveryMetrics(Metrics{Instances: instances})},
+		{DiscoveryMetrics(Metrics{Instances: instances}), HealthMetrics(health, nil)},
+	} {
+		var opts endpointerOptions
+		for _, option := range options {
+			option(&opts)
+		}
+		if opts.metrics.Health != health || opts.metrics.Instances != instances {
+			t.Errorf("want both metrics set, have %+v", opts.metrics)
+		}
+	}
+}
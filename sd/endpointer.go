@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/metrics"
 	"github.com/go-kit/log"
 )
 
@@ -15,6 +16,23 @@ type Endpointer[REQ any, RES any] interface {
 	Endpoints() ([]endpoint.Endpoint[REQ, RES], error)
 }
 
+// InstanceEndpoint pairs an endpoint with the instance string it was created
+// from.
+type InstanceEndpoint[REQ any, RES any] struct {
+	Instance string
+	Endpoint endpoint.Endpoint[REQ, RES]
+}
+
+// InstanceEndpointer is implemented by Endpointers that know which instance
+// each of their endpoints belongs to. Balancers that need a stable identity for
+// endpoints, e.g. to hash requests onto them, check for it. Like the slice
+// returned by Endpoints, the one returned by InstanceEndpoints may be shared,
+// and must not be modified.
+type InstanceEndpointer[REQ any, RES any] interface {
+	Endpointer[REQ, RES]
+	InstanceEndpoints() ([]InstanceEndpoint[REQ, RES], error)
+}
+
 // FixedEndpointer yields a fixed set of endpoints.
 type FixedEndpointer[REQ any, RES any] []endpoint.Endpoint[REQ, RES]
 
@@ -34,6 +52,8 @@ func NewEndpointer[REQ any, RES any](src Instancer, f Factory[REQ, RES], logger
 		cache:     newEndpointCache(f, logger, opts),
 		instancer: src,
 		ch:        make(chan Event),
+		done:      make(chan struct{}),
+		debounce:  opts.debounce,
 	}
 	go se.receive()
 	src.Register(se.ch)
@@ -57,9 +77,101 @@ func InvalidateOnError(timeout time.Duration) EndpointerOption {
 	}
 }
 
+// HealthMetrics returns EndpointerOption that reports the Endpointer's health
+// as it changes. It's a shorthand for DiscoveryMetrics, setting the Health and
+// Invalidations fields of Metrics. Either metric may be nil.
+func HealthMetrics(health metrics.Gauge, invalidations metrics.Counter) EndpointerOption {
+	return DiscoveryMetrics(Metrics{Health: health, Invalidations: invalidations})
+}
+
+// Metrics instruments an Endpointer, and thereby the Instancer it subscribes
+// to. Nil fields are ignored. To tell Instancers apart, pass metrics with an
+// identifying label value, e.g. counter.With("service", "addsvc").
+type Metrics struct {
+	// Health is set to the numeric value of the current Health as it
+	// changes: 0 when healthy, 1 when degraded and 2 when invalidated.
+	Health metrics.Gauge
+
+	// Invalidations is incremented every time the Endpointer drops its
+	// endpoints because of InvalidateOnError.
+	Invalidations metrics.Counter
+
+	// Instances is set to the number of instances in each successful update
+	// from the Instancer.
+	Instances metrics.Gauge
+
+	// FactoryFailures is incremented whenever the Factory fails to create an
+	// endpoint for an instance.
+	FactoryFailures metrics.Counter
+
+	// Evictions is incremented for every endpoint that's closed, because its
+	// instance disappeared or because the Endpointer was invalidated.
+	Evictions metrics.Counter
+
+	// LastUpdate is set to the time, in fractional seconds since the Unix
+	// epoch, at which the Endpointer applied the last event from the
+	// Instancer that carried instances rather than an error. Instancers only
+	// send events when the instances change, or when they recover from an
+	// error, so the gauge marks the last change in the set of instances, not
+	// the last time service discovery was reached: a stable set of instances
+	// leaves it behind. It's left alone on errors and invalidation.
+	LastUpdate metrics.Gauge
+}
+
+// DiscoveryMetrics returns EndpointerOption that reports the given metrics.
+// Its nil fields leave those set by previous options alone, so that it can be
+// combined with HealthMetrics.
+func DiscoveryMetrics(m Metrics) EndpointerOption {
+	return func(opts *endpointerOptions) {
+		if m.Health != nil {
+			opts.metrics.Health = m.Health
+		}
+		if m.Invalidations != nil {
+			opts.metrics.Invalidations = m.Invalidations
+		}
+		if m.Instances != nil {
+			opts.metrics.Instances = m.Instances
+		}
+		if m.FactoryFailures != nil {
+			opts.metrics.FactoryFailures = m.FactoryFailures
+		}
+		if m.Evictions != nil {
+			opts.metrics.Evictions = m.Evictions
+		}
+		if m.LastUpdate != nil {
+			opts.metrics.LastUpdate = m.LastUpdate
+		}
+	}
+}
+
+// DebounceUpdates returns EndpointerOption that coalesces the events of the
+// Instancer, to spare the endpoints from rapid instance churn. The first event
+// after a quiet period is applied at once, and the events following it within
+// the period are coalesced into a single update, at its end, with the latest
+// instances.
+func DebounceUpdates(period time.Duration) EndpointerOption {
+	return func(opts *endpointerOptions) {
+		opts.debounce = period
+	}
+}
+
+// EvictionDelay returns EndpointerOption that keeps the endpoints of instances
+// that disappear open, but no longer yielded, for the given delay before
+// closing them. If the instance reappears in the meantime, e.g. because it
+// flapped in service discovery, its endpoint is reused instead of created
+// anew by the Factory. Invalidation still closes endpoints at once.
+func EvictionDelay(delay time.Duration) EndpointerOption {
+	return func(opts *endpointerOptions) {
+		opts.evictionDelay = delay
+	}
+}
+
 type endpointerOptions struct {
 	invalidateOnError bool
 	invalidateTimeout time.Duration
+	metrics           Metrics
+	debounce          time.Duration
+	evictionDelay     time.Duration
 }
 
 // DefaultEndpointer implements an Endpointer interface.
@@ -70,21 +182,75 @@ type DefaultEndpointer[REQ any, RES any] struct {
 	cache     *endpointCache[REQ, RES]
 	instancer Instancer
 	ch        chan Event
+	done      chan struct{}
+	debounce  time.Duration
 }
 
 func (de *DefaultEndpointer[_, _]) receive() {
-	for event := range de.ch {
-		de.cache.Update(event)
+	defer close(de.done)
+	if de.debounce <= 0 {
+		for event := range de.ch {
+			de.cache.Update(event)
+		}
+		return
+	}
+
+	var (
+		timer   *time.Timer
+		timeout <-chan time.Time // nil while quiet
+		pending *Event
+	)
+	for {
+		select {
+		case event, ok := <-de.ch:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if timeout != nil {
+				pending = &event
+				continue
+			}
+			de.cache.Update(event)
+			timer = time.NewTimer(de.debounce)
+			timeout = timer.C
+
+		case <-timeout:
+			if pending == nil {
+				timeout = nil
+				continue
+			}
+			de.cache.Update(*pending)
+			pending = nil
+			timer.Reset(de.debounce)
+		}
 	}
 }
 
-// Close deregisters DefaultEndpointer from the Instancer and stops the internal go-routine.
+// Close deregisters DefaultEndpointer from the Instancer, stops the internal
+// go-routine, and closes the endpoints kept open by EvictionDelay.
 func (de *DefaultEndpointer[_, _]) Close() {
 	de.instancer.Deregister(de.ch)
 	close(de.ch)
+	<-de.done
+	de.cache.Close()
 }
 
 // Endpoints implements Endpointer.
 func (de *DefaultEndpointer[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
 	return de.cache.Endpoints()
 }
+
+// InstanceEndpoints implements InstanceEndpointer.
+func (de *DefaultEndpointer[REQ, RES]) InstanceEndpoints() ([]InstanceEndpoint[REQ, RES], error) {
+	return de.cache.InstanceEndpoints()
+}
+
+// State returns a snapshot of the Endpointer's health, so that readiness
+// probes can reflect the state of service discovery instead of silently
+// serving stale endpoints.
+func (de *DefaultEndpointer[REQ, RES]) State() EndpointerState {
+	return de.cache.State()
+}
@@ -2,6 +2,7 @@ package sd_test
 
 import (
 	"io"
+	"sync"
 	"testing"
 	"time"
 
@@ -83,3 +84,95 @@ func within(d time.Duration, f func() bool) bool {
 	}
 	return false
 }
+
+func TestDebounceUpdates(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		created = map[string]int{}
+		f       = func(instance string) (endpoint.Endpoint[any, any], io.Closer, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			created[instance]++
+			return endpoint.Nop[any, any], nil, nil
+		}
+		instancer = &mockInstancer{instance.NewCache()}
+	)
+	instancer.Update(sd.Event{Instances: []string{"a"}})
+
+	endpointer := sd.NewEndpointer(instancer, f, log.NewNopLogger(), sd.DebounceUpdates(100*time.Millisecond))
+	defer endpointer.Close()
+
+	// The first event is applied at once.
+	if !within(50*time.Millisecond, func() bool {
+		endpoints, _ := endpointer.Endpoints()
+		return len(endpoints) == 1
+	}) {
+		t.Fatal("didn't apply the initial event in time")
+	}
+
+	// The churn following it is coalesced into the latest instances.
+	instancer.Update(sd.Event{Instances: []string{"a", "b"}})
+	instancer.Update(sd.Event{Instances: []string{"a"}})
+	instancer.Update(sd.Event{Instances: []string{"a", "b"}})
+	instancer.Update(sd.Event{Instances: []string{"a", "c"}})
+
+	if !within(time.Second, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return created["c"] == 1
+	}) {
+		t.Fatal("didn't apply the coalesced event in time")
+	}
+	if endpoints, err := endpointer.Endpoints(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if want, have := 2, len(endpoints); want != have {
+		t.Errorf("want %d endpoints, have %d", want, have)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if want, have := 1, created["a"]; want != have {
+		t.Errorf("want %d endpoints created for a, have %d", want, have)
+	}
+	if want, have := 0, created["b"]; want != have {
+		t.Errorf("want %d endpoints created for b, have %d", want, have)
+	}
+}
+
+func TestEndpointerCloseClosesEvicted(t *testing.T) {
+	var (
+		ca = make(closer)
+		f  = func(instance string) (endpoint.Endpoint[any, any], io.Closer, error) {
+			return endpoint.Nop[any, any], ca, nil
+		}
+		instancer = &mockInstancer{instance.NewCache()}
+	)
+	instancer.Update(sd.Event{Instances: []string{"a"}})
+
+	endpointer := sd.NewEndpointer(instancer, f, log.NewNopLogger(), sd.EvictionDelay(time.Hour))
+	if !within(time.Second, func() bool {
+		endpoints, _ := endpointer.Endpoints()
+		return len(endpoints) == 1
+	}) {
+		t.Fatal("didn't apply the initial event in time")
+	}
+
+	instancer.Update(sd.Event{Instances: []string{}})
+	if !within(time.Second, func() bool {
+		endpoints, _ := endpointer.Endpoints()
+		return len(endpoints) == 0
+	}) {
+		t.Fatal("didn't evict the endpoint in time")
+	}
+	select {
+	case <-ca:
+		t.Fatal("closed the evicted endpoint before its delay")
+	default:
+	}
+
+	endpointer.Close()
+	select {
+	case <-ca:
+	default:
+		t.Error("Close didn't close the evicted endpoint")
+	}
+}
@@ -0,0 +1,241 @@
+package eureka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hudl/fargo"
+)
+
+// ActionType describes how an instance changed in a delta fetch.
+type ActionType string
+
+// Action types reported by the Eureka delta endpoint.
+const (
+	ActionAdded    ActionType = "ADDED"
+	ActionModified ActionType = "MODIFIED"
+	ActionDeleted  ActionType = "DELETED"
+)
+
+// Change is a single entry of a delta fetch.
+type Change struct {
+	Action   ActionType
+	Instance *fargo.Instance
+}
+
+// Delta is the result of a delta fetch.
+type Delta struct {
+	Changes []Change
+
+	// HashCode is the hash code of the whole registry once the changes are
+	// applied; see HashCode.
+	HashCode string
+}
+
+// Registry is the content of the whole registry.
+type Registry struct {
+	Instances []*fargo.Instance
+
+	// HashCode is the hash code of the registry; see HashCode.
+	HashCode string
+}
+
+// Client speaks the parts of the Eureka REST API that fargo doesn't cover:
+// fetches of the whole registry and of its deltas, and status overrides. It
+// uses JSON, and tries the service URLs in order until one of them answers.
+type Client struct {
+	client *http.Client
+	urls   []string
+}
+
+// NewClient returns a Client for the given Eureka service URLs, e.g.
+// "http://eureka:8761/eureka". A nil client means http.DefaultClient.
+func NewClient(client *http.Client, serviceURLs ...string) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	urls := make([]string, len(serviceURLs))
+	for i, u := range serviceURLs {
+		urls[i] = strings.TrimSuffix(u, "/")
+	}
+	return &Client{client: client, urls: urls}
+}
+
+// GetApp returns a single application, with all of its instances.
+func (c *Client) GetApp(name string) (*fargo.Application, error) {
+	var r fargo.GetAppResponseJson
+	if err := c.do(http.MethodGet, "/apps/"+url.PathEscape(name), &r); err != nil {
+		return nil, err
+	}
+	r.Application.ParseAllMetadata()
+	return &r.Application, nil
+}
+
+// GetApps returns the whole registry, with all the instances of all
+// applications.
+func (c *Client) GetApps() (*Registry, error) {
+	changes, hashCode, err := c.getApps("/apps")
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{HashCode: hashCode}
+	for _, change := range changes {
+		r.Instances = append(r.Instances, change.Instance)
+	}
+	return r, nil
+}
+
+// GetDelta returns the changes to the whole registry in the last few minutes,
+// as retained by the Eureka server (three minutes by default). Callers are
+// expected to poll it more frequently than that.
+func (c *Client) GetDelta() (*Delta, error) {
+	changes, hashCode, err := c.getApps("/apps/delta")
+	if err != nil {
+		return nil, err
+	}
+	return &Delta{Changes: changes, HashCode: hashCode}, nil
+}
+
+// getApps returns the instances listed by the applications resource at path,
+// with their action types if it's a delta, and the hash code of the
+// registry.
+func (c *Client) getApps(path string) ([]Change, string, error) {
+	var r struct {
+		Applications struct {
+			HashCode    string   `json:"apps__hashcode"`
+			Application flexible `json:"application"`
+		} `json:"applications"`
+	}
+	if err := c.do(http.MethodGet, path, &r); err != nil {
+		return nil, "", err
+	}
+
+	var apps []struct {
+		Instance flexible `json:"instance"`
+	}
+	if err := r.Applications.Application.decode(&apps); err != nil {
+		return nil, "", err
+	}
+	var changes []Change
+	for _, app := range apps {
+		var raws []json.RawMessage
+		if err := app.Instance.decode(&raws); err != nil {
+			return nil, "", err
+		}
+		for _, raw := range raws {
+			var (
+				inst   fargo.Instance
+				action struct {
+					ActionType ActionType `json:"actionType"`
+				}
+			)
+			if err := json.Unmarshal(raw, &inst); err != nil {
+				return nil, "", err
+			}
+			if err := json.Unmarshal(raw, &action); err != nil {
+				return nil, "", err
+			}
+			changes = append(changes, Change{Action: action.ActionType, Instance: &inst})
+		}
+	}
+	return changes, r.Applications.HashCode, nil
+}
+
+// HashCode returns the hash code of a registry with instances of the given
+// statuses, as computed by Eureka: the number of instances of every status,
+// in the order of the statuses, e.g. "DOWN_1_UP_4_". Clients applying deltas
+// compare it with the hash code of the delta to check they're in sync.
+func HashCode(statuses []fargo.StatusType) string {
+	counts := map[fargo.StatusType]int{}
+	for _, status := range statuses {
+		counts[status]++
+	}
+	keys := make([]string, 0, len(counts))
+	for status := range counts {
+		keys = append(keys, string(status))
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, status := range keys {
+		fmt.Fprintf(&b, "%s_%d_", status, counts[fargo.StatusType(status)])
+	}
+	return b.String()
+}
+
+// OverrideStatus sets the status of a registered instance, taking precedence
+// over the status it reports itself in heartbeats. This is typically used to
+// take an instance OUT_OF_SERVICE without stopping it.
+func (c *Client) OverrideStatus(instance *fargo.Instance, status fargo.StatusType) error {
+	path := fmt.Sprintf("/apps/%s/%s/status?value=%s", url.PathEscape(instance.App), url.PathEscape(instance.Id()), url.QueryEscape(string(status)))
+	return c.do(http.MethodPut, path, nil)
+}
+
+// RemoveStatusOverride removes a status override, so the status reported by
+// the instance itself applies again.
+func (c *Client) RemoveStatusOverride(instance *fargo.Instance) error {
+	path := fmt.Sprintf("/apps/%s/%s/status", url.PathEscape(instance.App), url.PathEscape(instance.Id()))
+	return c.do(http.MethodDelete, path, nil)
+}
+
+func (c *Client) do(method, path string, v interface{}) error {
+	var err error
+	for _, base := range c.urls {
+		if err = c.try(method, base+path, v); err == nil {
+			return nil
+		}
+		if _, ok := err.(*fargoUnsuccessfulHTTPResponse); ok {
+			return err // The server answered; another one won't know better.
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no Eureka service URLs")
+	}
+	return err
+}
+
+func (c *Client) try(method, u string, v interface{}) error {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return &fargoUnsuccessfulHTTPResponse{statusCode: resp.StatusCode, messagePrefix: method + " " + req.URL.Path}
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// flexible holds a JSON value that Eureka encodes as an object when there's a
+// single element, and as an array otherwise.
+type flexible json.RawMessage
+
+func (f *flexible) UnmarshalJSON(b []byte) error {
+	*f = append((*f)[:0], b...)
+	return nil
+}
+
+func (f flexible) decode(v interface{}) error {
+	b := bytes.TrimSpace(f)
+	switch {
+	case len(b) == 0 || bytes.Equal(b, []byte("null")):
+		return nil
+	case b[0] != '[':
+		b = append(append([]byte{'['}, b...), ']')
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,129 @@
+package eureka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hudl/fargo"
+)
+
+const deltaJSON = `{"applications": {
+	"versions__delta": "3",
+	"apps__hashcode": "UP_2_",
+	"application": [
+		{"name": "GO-KIT", "instance": [
+			{"instanceId": "a", "app": "GO-KIT", "ipAddr": "10.0.0.1", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "securePort": {"$": 443, "@enabled": "false"}, "actionType": "ADDED"},
+			{"instanceId": "b", "app": "GO-KIT", "ipAddr": "10.0.0.2", "status": "UP", "port": {"$": "8080", "@enabled": "true"}, "securePort": {"$": 443, "@enabled": "false"}, "actionType": "DELETED"}
+		]},
+		{"name": "OTHER", "instance":
+			{"instanceId": "c", "app": "OTHER", "ipAddr": "10.0.0.3", "status": "OUT_OF_SERVICE", "port": {"$": 9090, "@enabled": "true"}, "securePort": {"$": 443, "@enabled": "false"}, "actionType": "MODIFIED"}
+		}
+	]
+}}`
+
+func TestClientGetDelta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, have := "/eureka/apps/delta", r.URL.Path; want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+		w.Write([]byte(deltaJSON))
+	}))
+	defer server.Close()
+
+	delta, err := NewClient(nil, server.URL+"/eureka/").GetDelta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "UP_2_", delta.HashCode; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	changes := delta.Changes
+	if want, have := 3, len(changes); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	for i, want := range []struct {
+		action ActionType
+		id     string
+		port   int
+		status fargo.StatusType
+	}{
+		{ActionAdded, "a", 8080, fargo.UP},
+		{ActionDeleted, "b", 8080, fargo.UP},
+		{ActionModified, "c", 9090, fargo.OUTOFSERVICE},
+	} {
+		have := changes[i]
+		if have.Action != want.action || have.Instance.Id() != want.id || have.Instance.Port != want.port || have.Instance.Status != want.status {
+			t.Errorf("change %d: want %+v, have %s %s %d %s", i, want, have.Action, have.Instance.Id(), have.Instance.Port, have.Instance.Status)
+		}
+	}
+}
+
+func TestClientGetApps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, have := "/eureka/apps", r.URL.Path; want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+		w.Write([]byte(deltaJSON))
+	}))
+	defer server.Close()
+
+	registry, err := NewClient(nil, server.URL+"/eureka/").GetApps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "UP_2_", registry.HashCode; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	var ids []string
+	for _, inst := range registry.Instances {
+		ids = append(ids, inst.Id())
+	}
+	if want, have := "a b c", strings.Join(ids, " "); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"applications": {"application": []}}`))
+	}))
+	defer server.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	if _, err := NewClient(nil, down.URL, server.URL).GetDelta(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistrarStatusOverride(t *testing.T) {
+	var method, path, value string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, value = r.Method, r.URL.Path, r.URL.Query().Get("value")
+	}))
+	defer server.Close()
+
+	instance := &fargo.Instance{InstanceId: "i-1", App: appNameTest}
+	registrar := NewRegistrar(&testConnection{}, instance, loggerTest)
+	if want, have := ErrNoStatusOverrider, registrar.OverrideStatus(fargo.OUTOFSERVICE); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	registrar = NewRegistrar(&testConnection{}, instance, loggerTest, WithStatusOverrider(NewClient(nil, server.URL)))
+	if err := registrar.OverrideStatus(fargo.OUTOFSERVICE); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "PUT /apps/go-kit/i-1/status OUT_OF_SERVICE", method+" "+path+" "+value; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if err := registrar.RemoveStatusOverride(); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "DELETE /apps/go-kit/i-1/status", method+" "+path; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,188 @@
+package eureka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hudl/fargo"
+
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+	"github.com/go-kit/log"
+)
+
+// Matches official Netflix Java client defaults.
+const (
+	defaultFetchInterval = 30 * time.Second
+	defaultResyncPeriod  = 5 * time.Minute
+)
+
+// The methods of Client used by the DeltaInstancer.
+type deltaConnection interface {
+	GetApps() (*Registry, error)
+	GetDelta() (*Delta, error)
+}
+
+// DeltaInstancer yields the instances of the given app that are UP, like the
+// Instancer, but after the initial fetch it only polls Eureka for changes to
+// the registry. That is much cheaper for the Eureka servers when there are
+// many consumers of large apps. A full fetch is made again after any error,
+// and periodically, since Eureka only retains changes for a few minutes.
+//
+// Full fetches get the whole registry rather than the app, since deltas are
+// checked against the hash code of the whole registry: if the statuses of
+// the instances don't add up to it once a delta is applied, some changes
+// were missed and the registry is fetched again.
+//
+// The status of an instance is the one computed by Eureka, so instances taken
+// out of service with a status override disappear from the Instancer until
+// the override is removed.
+type DeltaInstancer struct {
+	cache     *instance.Cache
+	conn      deltaConnection
+	app       string
+	logger    log.Logger
+	instances map[string]*fargo.Instance
+	statuses  map[string]fargo.StatusType // of all the instances, by app and ID
+	quitc     chan chan struct{}
+}
+
+// NewDeltaInstancer returns a DeltaInstancer polling for changes every
+// interval, or every 30 seconds if interval is zero.
+func NewDeltaInstancer(conn deltaConnection, app string, interval time.Duration, logger log.Logger) *DeltaInstancer {
+	if interval <= 0 {
+		interval = defaultFetchInterval
+	}
+	s := &DeltaInstancer{
+		cache:  instance.NewCache(),
+		conn:   conn,
+		app:    app,
+		logger: log.With(logger, "app", app),
+		quitc:  make(chan chan struct{}),
+	}
+	s.fetch()
+	go s.loop(interval, int(defaultResyncPeriod/interval))
+	return s
+}
+
+// Stop terminates the Instancer.
+func (s *DeltaInstancer) Stop() {
+	q := make(chan struct{})
+	s.quitc <- q
+	<-q
+}
+
+func (s *DeltaInstancer) loop(interval time.Duration, resyncEvery int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for polls := 1; ; polls++ {
+		select {
+		case <-ticker.C:
+			if s.instances == nil || (resyncEvery > 0 && polls%resyncEvery == 0) {
+				s.fetch()
+			} else {
+				s.fetchDelta()
+			}
+		case q := <-s.quitc:
+			close(q)
+			return
+		}
+	}
+}
+
+func (s *DeltaInstancer) fetch() {
+	r, err := s.conn.GetApps()
+	if err != nil {
+		s.fail("GetApps", err)
+		return
+	}
+	s.instances = map[string]*fargo.Instance{}
+	s.statuses = map[string]fargo.StatusType{}
+	for _, inst := range r.Instances {
+		s.statuses[statusKey(inst)] = inst.Status
+		if strings.EqualFold(inst.App, s.app) {
+			s.instances[inst.Id()] = inst
+		}
+	}
+	s.update()
+}
+
+func (s *DeltaInstancer) fetchDelta() {
+	delta, err := s.conn.GetDelta()
+	if err != nil {
+		s.fail("GetDelta", err)
+		return
+	}
+	var changed bool
+	for _, c := range delta.Changes {
+		switch c.Action {
+		case ActionAdded, ActionModified:
+			s.statuses[statusKey(c.Instance)] = c.Instance.Status
+		case ActionDeleted:
+			delete(s.statuses, statusKey(c.Instance))
+		default:
+			continue
+		}
+		if !strings.EqualFold(c.Instance.App, s.app) {
+			continue
+		}
+		if c.Action == ActionDeleted {
+			delete(s.instances, c.Instance.Id())
+		} else {
+			s.instances[c.Instance.Id()] = c.Instance
+		}
+		changed = true
+	}
+	if delta.HashCode != "" && delta.HashCode != s.hashCode() {
+		s.logger.Log("during", "GetDelta", "err", "hash code mismatch", "want", delta.HashCode, "have", s.hashCode())
+		s.fetch()
+		return
+	}
+	if changed {
+		s.update()
+	}
+}
+
+// hashCode returns the hash code of the registry as known to the Instancer.
+func (s *DeltaInstancer) hashCode() string {
+	statuses := make([]fargo.StatusType, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		statuses = append(statuses, status)
+	}
+	return HashCode(statuses)
+}
+
+func statusKey(inst *fargo.Instance) string {
+	return strings.ToUpper(inst.App) + "/" + inst.Id()
+}
+
+func (s *DeltaInstancer) fail(during string, err error) {
+	s.logger.Log("during", during, "err", err)
+	s.instances = nil // force a full fetch next time
+	s.cache.Update(sd.Event{Err: err})
+}
+
+func (s *DeltaInstancer) update() {
+	instances := make([]string, 0, len(s.instances))
+	for _, inst := range s.instances {
+		if inst.Status == fargo.UP {
+			instances = append(instances, fmt.Sprintf("%s:%d", inst.IPAddr, inst.Port))
+		}
+	}
+	sort.Strings(instances)
+	s.logger.Log("instances", len(instances))
+	s.cache.Update(sd.Event{Instances: instances})
+}
+
+// Register implements Instancer.
+func (s *DeltaInstancer) Register(ch chan<- sd.Event) {
+	s.cache.Register(ch)
+}
+
+// Deregister implements Instancer.
+func (s *DeltaInstancer) Deregister(ch chan<- sd.Event) {
+	s.cache.Deregister(ch)
+}
@@ -0,0 +1,154 @@
+package eureka
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hudl/fargo"
+
+	"github.com/a69/kit.go/sd"
+)
+
+var _ sd.Instancer = (*DeltaInstancer)(nil) // API check
+
+type testDeltaConnection struct {
+	mtx      sync.Mutex
+	registry *Registry
+	delta    *Delta
+	err      error
+	fetches  int
+}
+
+func (c *testDeltaConnection) GetApps() (*Registry, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.fetches++
+	return c.registry, c.err
+}
+
+func (c *testDeltaConnection) GetDelta() (*Delta, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delta := c.delta
+	c.delta = &Delta{}
+	return delta, c.err
+}
+
+func (c *testDeltaConnection) set(changes []Change, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.delta, c.err = &Delta{Changes: changes}, err
+}
+
+func (c *testDeltaConnection) fetchCount() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.fetches
+}
+
+func expectInstances(t *testing.T, events <-chan sd.Event, want ...string) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Err == nil && (reflect.DeepEqual(want, e.Instances) || len(want)+len(e.Instances) == 0) {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %v", want)
+		}
+	}
+}
+
+func TestDeltaInstancer(t *testing.T) {
+	conn := &testDeltaConnection{
+		registry: &Registry{Instances: []*fargo.Instance{instanceTest1}},
+		delta:    &Delta{},
+	}
+	s := NewDeltaInstancer(conn, appNameTest, 10*time.Millisecond, loggerTest)
+	defer s.Stop()
+
+	events := make(chan sd.Event, 16)
+	s.Register(events)
+	defer s.Deregister(events)
+
+	expect := func(want ...string) {
+		t.Helper()
+		expectInstances(t, events, want...)
+	}
+	expect("192.168.0.1:8080")
+
+	outOfService := *instanceTest1
+	outOfService.Status = fargo.OUTOFSERVICE
+	conn.set([]Change{
+		{Action: ActionAdded, Instance: instanceTest2},
+		{Action: ActionModified, Instance: &outOfService},
+		{Action: ActionAdded, Instance: &fargo.Instance{App: "other", IPAddr: "10.0.0.1", Port: 80, Status: fargo.UP}},
+	}, nil)
+	expect("192.168.0.2:8080")
+
+	conn.set([]Change{{Action: ActionDeleted, Instance: instanceTest2}}, nil)
+	expect()
+
+	// After an error, the next poll is a full fetch.
+	conn.set(nil, errTest)
+	time.Sleep(30 * time.Millisecond)
+	conn.set(nil, nil)
+	expect("192.168.0.1:8080")
+	if fetches := conn.fetchCount(); fetches < 2 {
+		t.Errorf("want a full fetch after an error, have %d fetches", fetches)
+	}
+}
+
+func TestDeltaInstancerHashCodeMismatch(t *testing.T) {
+	other := &fargo.Instance{InstanceId: "c", App: "other", IPAddr: "10.0.0.3", Port: 80, Status: fargo.DOWN}
+	conn := &testDeltaConnection{
+		registry: &Registry{Instances: []*fargo.Instance{instanceTest1, other}},
+		delta:    &Delta{},
+	}
+	s := NewDeltaInstancer(conn, appNameTest, 10*time.Millisecond, loggerTest)
+	defer s.Stop()
+
+	events := make(chan sd.Event, 16)
+	s.Register(events)
+	defer s.Deregister(events)
+	expectInstances(t, events, "192.168.0.1:8080")
+
+	// A delta that adds up to the hash code is applied as is.
+	conn.mtx.Lock()
+	conn.delta = &Delta{Changes: []Change{{Action: ActionAdded, Instance: instanceTest2}}, HashCode: "DOWN_1_UP_2_"}
+	conn.mtx.Unlock()
+	expectInstances(t, events, "192.168.0.1:8080", "192.168.0.2:8080")
+	if want, have := 1, conn.fetchCount(); want != have {
+		t.Errorf("want %d fetches, have %d", want, have)
+	}
+
+	// One that doesn't means changes were missed: the registry is fetched
+	// again, and is the source of truth.
+	conn.mtx.Lock()
+	conn.registry = &Registry{Instances: []*fargo.Instance{instanceTest2}}
+	conn.delta = &Delta{HashCode: "UP_1_"}
+	conn.mtx.Unlock()
+	expectInstances(t, events, "192.168.0.2:8080")
+	if want, have := 2, conn.fetchCount(); want != have {
+		t.Errorf("want %d fetches, have %d", want, have)
+	}
+}
+
+func TestHashCode(t *testing.T) {
+	for _, tc := range []struct {
+		statuses []fargo.StatusType
+		want     string
+	}{
+		{nil, ""},
+		{[]fargo.StatusType{fargo.UP}, "UP_1_"},
+		{[]fargo.StatusType{fargo.UP, fargo.OUTOFSERVICE, fargo.DOWN, fargo.UP}, "DOWN_1_OUT_OF_SERVICE_1_UP_2_"},
+	} {
+		if have := HashCode(tc.statuses); tc.want != have {
+			t.Errorf("%v: want %q, have %q", tc.statuses, tc.want, have)
+		}
+	}
+}
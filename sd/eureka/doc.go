@@ -1,2 +1,7 @@
-// Package eureka provides Instancer and Registrar implementations for Netflix OSS's Eureka
+// Package eureka provides Instancer and Registrar implementations for Netflix OSS's Eureka.
+//
+// The Instancer and Registrar use a fargo connection for registration,
+// heartbeat renewal and full fetches of an app. The Client fills in what fargo
+// lacks: the DeltaInstancer uses it to poll only for changes to the registry,
+// and Registrars use it to override the status of their instance.
 package eureka
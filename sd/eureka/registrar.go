@@ -1,6 +1,7 @@
 package eureka
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -40,19 +41,45 @@ type Registrar struct {
 	instance *fargo.Instance
 	logger   log.Logger
 	quitc    chan chan struct{}
+	status   StatusOverrider
 	sync.Mutex
 }
 
+// StatusOverrider sets and removes status overrides of registered instances.
+// It's implemented by Client.
+type StatusOverrider interface {
+	OverrideStatus(instance *fargo.Instance, status fargo.StatusType) error
+	RemoveStatusOverride(instance *fargo.Instance) error
+}
+
+// ErrNoStatusOverrider is returned when overriding the status of a Registrar
+// that wasn't given a StatusOverrider.
+var ErrNoStatusOverrider = errors.New("eureka: registrar has no StatusOverrider")
+
+// RegistrarOption sets an optional parameter for Registrars.
+type RegistrarOption func(*Registrar)
+
+// WithStatusOverrider enables OverrideStatus and RemoveStatusOverride on the
+// Registrar. Fargo connections don't support status overrides, so a Client
+// is typically passed here.
+func WithStatusOverrider(o StatusOverrider) RegistrarOption {
+	return func(r *Registrar) { r.status = o }
+}
+
 var _ sd.Registrar = (*Registrar)(nil)
 
 // NewRegistrar returns an Eureka Registrar acting on behalf of the provided
 // Fargo connection and instance. See the integration test for usage examples.
-func NewRegistrar(conn fargoConnection, instance *fargo.Instance, logger log.Logger) *Registrar {
-	return &Registrar{
+func NewRegistrar(conn fargoConnection, instance *fargo.Instance, logger log.Logger, options ...RegistrarOption) *Registrar {
+	r := &Registrar{
 		conn:     conn,
 		instance: instance,
 		logger:   log.With(logger, "service", instance.App, "address", fmt.Sprintf("%s:%d", instance.IPAddr, instance.Port)),
 	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
 }
 
 // Register implements sd.Registrar.
@@ -87,6 +114,25 @@ func (r *Registrar) Deregister() {
 	r.quitc = nil
 }
 
+// OverrideStatus overrides the status of the registered instance in Eureka,
+// e.g. to take it OUT_OF_SERVICE while it keeps sending heartbeats. Consumers
+// stop seeing the instance without it having to deregister.
+func (r *Registrar) OverrideStatus(status fargo.StatusType) error {
+	if r.status == nil {
+		return ErrNoStatusOverrider
+	}
+	return r.status.OverrideStatus(r.instance, status)
+}
+
+// RemoveStatusOverride puts the registered instance back to the status it
+// reports itself.
+func (r *Registrar) RemoveStatusOverride() error {
+	if r.status == nil {
+		return ErrNoStatusOverrider
+	}
+	return r.status.RemoveStatusOverride(r.instance)
+}
+
 func (r *Registrar) loop() {
 	var renewalInterval time.Duration
 	if r.instance.LeaseInfo.RenewalIntervalInSecs > 0 {
@@ -0,0 +1,203 @@
+package sd
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/metrics"
+	"github.com/go-kit/log"
+)
+
+// FailoverInstancer wraps a primary Instancer and switches to a fallback
+// Instancer, e.g. DNS or a static list, while the primary is stale. The
+// primary is stale once it has reported errors or empty instance lists for
+// longer than the configured period, which includes never having reported any
+// instances since the FailoverInstancer was created. As soon as the primary
+// reports instances again, the FailoverInstancer switches back.
+//
+// Instancers only send events when something changes, so a primary that has
+// been quiet, but whose last event carried instances, is never stale.
+type FailoverInstancer struct {
+	primary    Instancer
+	fallback   Instancer
+	pch, fch   chan Event
+	staleAfter time.Duration
+	merge      bool
+	logger     log.Logger
+	active     metrics.Gauge
+	switches   metrics.Counter
+	quitc      chan struct{}
+	donec      chan struct{}
+
+	// Owned by the loop goroutine.
+	primaryEvent  *Event
+	lastGood      []string
+	failingSince  time.Time
+	fallbackEvent *Event
+	failedOver    bool
+
+	mtx         sync.Mutex
+	state       Event
+	subscribers map[chan<- Event]struct{}
+}
+
+var _ Instancer = (*FailoverInstancer)(nil)
+
+// FailoverOption sets an optional parameter for FailoverInstancers.
+type FailoverOption func(*FailoverInstancer)
+
+// FailoverMerge makes the FailoverInstancer merge the fallback's instances
+// with the last instances reported by the primary while it's stale, instead
+// of replacing them.
+func FailoverMerge() FailoverOption {
+	return func(f *FailoverInstancer) { f.merge = true }
+}
+
+// FailoverMetrics reports the source in use, 0 for the primary and 1 for the
+// fallback, to the active gauge, and counts switches in either direction with
+// the switches counter. Either metric may be nil.
+func FailoverMetrics(active metrics.Gauge, switches metrics.Counter) FailoverOption {
+	return func(f *FailoverInstancer) { f.active, f.switches = active, switches }
+}
+
+// NewFailoverInstancer returns a FailoverInstancer subscribed to both
+// Instancers, failing over once the primary has been stale for staleAfter.
+func NewFailoverInstancer(primary, fallback Instancer, staleAfter time.Duration, logger log.Logger, options ...FailoverOption) *FailoverInstancer {
+	f := &FailoverInstancer{
+		primary:      primary,
+		fallback:     fallback,
+		pch:          make(chan Event),
+		fch:          make(chan Event),
+		staleAfter:   staleAfter,
+		logger:       logger,
+		quitc:        make(chan struct{}),
+		donec:        make(chan struct{}),
+		failingSince: time.Now(),
+		subscribers:  map[chan<- Event]struct{}{},
+	}
+	for _, option := range options {
+		option(f)
+	}
+	if f.active != nil {
+		f.active.Set(0)
+	}
+	go f.loop()
+	primary.Register(f.pch)
+	fallback.Register(f.fch)
+	return f
+}
+
+func (f *FailoverInstancer) loop() {
+	defer close(f.donec)
+
+	timer := time.NewTimer(f.staleAfter)
+	defer timer.Stop()
+
+	for {
+		select {
+		case event := <-f.pch:
+			f.primaryEvent = &event
+			switch {
+			case event.Err == nil && len(event.Instances) > 0:
+				f.lastGood, f.failingSince = event.Instances, time.Time{}
+			case f.failingSince.IsZero():
+				f.failingSince = time.Now()
+			}
+		case event := <-f.fch:
+			f.fallbackEvent = &event
+		case <-timer.C:
+		case <-f.quitc:
+			return
+		}
+
+		f.evaluate()
+		if !f.failingSince.IsZero() && !f.failedOver {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(f.failingSince.Add(f.staleAfter)))
+		}
+	}
+}
+
+func (f *FailoverInstancer) evaluate() {
+	stale := !f.failingSince.IsZero() && time.Since(f.failingSince) >= f.staleAfter
+	if stale != f.failedOver {
+		f.failedOver = stale
+		action, active := "failback", 0.
+		if stale {
+			action, active = "failover", 1.
+		}
+		f.logger.Log("action", action)
+		if f.active != nil {
+			f.active.Set(active)
+		}
+		if f.switches != nil {
+			f.switches.Add(1)
+		}
+	}
+
+	var state Event
+	switch {
+	case !f.failedOver || f.fallbackEvent == nil:
+		if f.primaryEvent == nil {
+			return
+		}
+		state = *f.primaryEvent
+	case f.merge && f.fallbackEvent.Err == nil:
+		seen := map[string]struct{}{}
+		for _, instances := range [][]string{f.lastGood, f.fallbackEvent.Instances} {
+			for _, instance := range instances {
+				if _, ok := seen[instance]; !ok {
+					seen[instance] = struct{}{}
+					state.Instances = append(state.Instances, instance)
+				}
+			}
+		}
+		sort.Strings(state.Instances)
+	default:
+		state = *f.fallbackEvent
+	}
+	f.update(state)
+}
+
+func (f *FailoverInstancer) update(state Event) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if reflect.DeepEqual(f.state, state) {
+		return
+	}
+	f.state = state
+	for ch := range f.subscribers {
+		ch <- copyEvent(state)
+	}
+}
+
+// Register implements Instancer.
+func (f *FailoverInstancer) Register(ch chan<- Event) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.subscribers[ch] = struct{}{}
+	ch <- copyEvent(f.state)
+}
+
+// Deregister implements Instancer.
+func (f *FailoverInstancer) Deregister(ch chan<- Event) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.subscribers, ch)
+}
+
+// Stop implements Instancer. It unsubscribes from both Instancers, but doesn't
+// stop them.
+func (f *FailoverInstancer) Stop() {
+	f.primary.Deregister(f.pch)
+	f.fallback.Deregister(f.fch)
+	close(f.quitc)
+	<-f.donec
+}
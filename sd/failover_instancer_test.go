@@ -0,0 +1,92 @@
+package sd_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/metrics/generic"
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+	"github.com/go-kit/log"
+)
+
+func TestFailoverInstancer(t *testing.T) {
+	var (
+		primary  = instance.NewCache()
+		fallback = sd.FixedInstancer{"dns:80"}
+		active   = generic.NewGauge("active")
+		switches = generic.NewCounter("switches")
+	)
+	primary.Update(sd.Event{Instances: []string{"consul:80"}})
+
+	f := sd.NewFailoverInstancer(primary, fallback, 20*time.Millisecond, log.NewNopLogger(), sd.FailoverMetrics(active, switches))
+	defer f.Stop()
+
+	events := make(chan sd.Event, 16)
+	f.Register(events)
+	defer f.Deregister(events)
+
+	expect := func(want sd.Event) {
+		t.Helper()
+		awaitEvent(t, events, func(have sd.Event) bool { return reflect.DeepEqual(want, have) })
+	}
+	expect(sd.Event{Instances: []string{"consul:80"}})
+
+	// A quiet but healthy primary isn't stale.
+	time.Sleep(50 * time.Millisecond)
+	if want, have := 0., active.Value(); want != have {
+		t.Errorf("active: want %v, have %v", want, have)
+	}
+
+	// Errors are passed through until the primary has been failing for long
+	// enough, then the fallback takes over.
+	failure := errors.New("consul unreachable")
+	primary.Update(sd.Event{Err: failure})
+	expect(sd.Event{Err: failure})
+	expect(sd.Event{Instances: []string{"dns:80"}})
+	if want, have := 1., active.Value(); want != have {
+		t.Errorf("active: want %v, have %v", want, have)
+	}
+
+	primary.Update(sd.Event{Instances: []string{"consul:81"}})
+	expect(sd.Event{Instances: []string{"consul:81"}})
+	if want, have := 0., active.Value(); want != have {
+		t.Errorf("active: want %v, have %v", want, have)
+	}
+	if want, have := 2., switches.Value(); want != have {
+		t.Errorf("switches: want %v, have %v", want, have)
+	}
+}
+
+func TestFailoverInstancerMerge(t *testing.T) {
+	var (
+		primary  = instance.NewCache()
+		fallback = sd.FixedInstancer{"dns:80", "consul:80"}
+	)
+	primary.Update(sd.Event{Instances: []string{"consul:80", "consul:81"}})
+
+	f := sd.NewFailoverInstancer(primary, fallback, 10*time.Millisecond, log.NewNopLogger(), sd.FailoverMerge())
+	defer f.Stop()
+
+	events := make(chan sd.Event, 16)
+	f.Register(events)
+	defer f.Deregister(events)
+
+	primary.Update(sd.Event{Err: errors.New("failure")})
+	want := sd.Event{Instances: []string{"consul:80", "consul:81", "dns:80"}}
+	awaitEvent(t, events, func(have sd.Event) bool { return reflect.DeepEqual(want, have) })
+}
+
+func TestFailoverInstancerNeverReported(t *testing.T) {
+	f := sd.NewFailoverInstancer(instance.NewCache(), sd.FixedInstancer{"dns:80"}, 10*time.Millisecond, log.NewNopLogger())
+	defer f.Stop()
+
+	events := make(chan sd.Event, 16)
+	f.Register(events)
+	defer f.Deregister(events)
+
+	want := sd.Event{Instances: []string{"dns:80"}}
+	awaitEvent(t, events, func(have sd.Event) bool { return reflect.DeepEqual(want, have) })
+}
@@ -0,0 +1,258 @@
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointSlice is the subset of a discovery.k8s.io/v1 EndpointSlice the
+// Instancer needs.
+type EndpointSlice struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	AddressType string     `json:"addressType"`
+	Endpoints   []Endpoint `json:"endpoints"`
+	Ports       []Port     `json:"ports"`
+}
+
+// Endpoint is a single backend of an EndpointSlice.
+type Endpoint struct {
+	Addresses  []string `json:"addresses"`
+	Conditions struct {
+		Ready       *bool `json:"ready"`
+		Serving     *bool `json:"serving"`
+		Terminating *bool `json:"terminating"`
+	} `json:"conditions"`
+	Zone     string `json:"zone,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// Ready reports whether the endpoint should receive traffic. Per the API
+// conventions, a nil ready condition means ready.
+func (e Endpoint) Ready() bool {
+	return e.Conditions.Ready == nil || *e.Conditions.Ready
+}
+
+// Port is a named port exposed by all endpoints of an EndpointSlice.
+type Port struct {
+	Name     string `json:"name"`
+	Port     int32  `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// EndpointSliceList is the result of listing EndpointSlices.
+type EndpointSliceList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []EndpointSlice `json:"items"`
+}
+
+// Watch event types, as sent by the Kubernetes API.
+const (
+	EventAdded    = "ADDED"
+	EventModified = "MODIFIED"
+	EventDeleted  = "DELETED"
+	EventBookmark = "BOOKMARK"
+	EventError    = "ERROR"
+)
+
+// WatchEvent is a single change to an EndpointSlice.
+type WatchEvent struct {
+	Type   string        `json:"type"`
+	Object EndpointSlice `json:"object"`
+}
+
+// Client is a minimal interface to the Kubernetes API.
+type Client interface {
+	// ListEndpointSlices returns all EndpointSlices of the given Service.
+	ListEndpointSlices(ctx context.Context, namespace, service string) (*EndpointSliceList, error)
+
+	// WatchEndpointSlices streams changes to the EndpointSlices of the given
+	// Service that happened after resourceVersion. The channel is closed when
+	// the watch ends, which the API server does periodically, or when ctx is
+	// canceled.
+	WatchEndpointSlices(ctx context.Context, namespace, service, resourceVersion string) (<-chan WatchEvent, error)
+}
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ErrNotInCluster is returned by NewInClusterClient when the process doesn't
+// run in a Kubernetes pod.
+var ErrNotInCluster = errors.New("not running in a Kubernetes cluster")
+
+type client struct {
+	base   *url.URL
+	token  func() (string, error)
+	client *http.Client
+}
+
+// NewClient returns a Client talking to the API server at base, e.g.
+// https://10.0.0.1:443, using the given HTTP client and bearer token. The
+// token may be empty, e.g. when talking to a local kubectl proxy.
+func NewClient(base *url.URL, httpClient *http.Client, token string) Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &client{base: base, token: func() (string, error) { return token, nil }, client: httpClient}
+}
+
+// NewInClusterClient returns a Client configured from the service account
+// mounted into every pod, the same way client-go's rest.InClusterConfig does.
+// Projected service account tokens expire and are rotated by the kubelet, so
+// like client-go the token file is read again whenever it changes.
+func NewInClusterClient() (Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+	token := &tokenFile{path: serviceAccountDir + "/token"}
+	if _, err := token.get(); err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid service account CA certificate")
+	}
+	httpClient := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}}
+	base := &url.URL{Scheme: "https", Host: net.JoinHostPort(host, port)}
+	return &client{base: base, token: token.get, client: httpClient}, nil
+}
+
+// tokenFile caches a bearer token read from a file until the file's
+// modification time changes.
+type tokenFile struct {
+	path string
+
+	mtx     sync.Mutex
+	modTime time.Time
+	token   string
+}
+
+func (f *tokenFile) get() (string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		if f.token != "" {
+			return f.token, nil // keep the last token while the file is swapped
+		}
+		return "", err
+	}
+	if f.token != "" && fi.ModTime().Equal(f.modTime) {
+		return f.token, nil
+	}
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		if f.token != "" {
+			return f.token, nil
+		}
+		return "", err
+	}
+	f.modTime, f.token = fi.ModTime(), strings.TrimSpace(string(b))
+	return f.token, nil
+}
+
+// InClusterNamespace returns the namespace of the pod the process runs in.
+func InClusterNamespace() (string, error) {
+	b, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (c *client) ListEndpointSlices(ctx context.Context, namespace, service string) (*EndpointSliceList, error) {
+	resp, err := c.get(ctx, namespace, service, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var list EndpointSliceList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (c *client) WatchEndpointSlices(ctx context.Context, namespace, service, resourceVersion string) (<-chan WatchEvent, error) {
+	resp, err := c.get(ctx, namespace, service, url.Values{
+		"watch":               {"1"},
+		"resourceVersion":     {resourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		dec := json.NewDecoder(bufio.NewReader(resp.Body))
+		for {
+			var event WatchEvent
+			if err := dec.Decode(&event); err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (c *client) get(ctx context.Context, namespace, service string, query url.Values) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("labelSelector", "kubernetes.io/service-name="+service)
+	u := *c.base
+	u.Path = fmt.Sprintf("/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices", url.PathEscape(namespace))
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("kubernetes API: %s", resp.Status)
+	}
+	return resp, nil
+}
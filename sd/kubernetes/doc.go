@@ -0,0 +1,8 @@
+// Package kubernetes provides an Instancer implementation for Kubernetes. It
+// watches the EndpointSlices of a Service, so services running in a cluster
+// can use sd.NewEndpointer without a separate discovery system.
+//
+// The package talks to the Kubernetes API directly over HTTP, rather than
+// through client-go, to keep that dependency out of Go kit. Users who already
+// run client-go informers can implement the Client interface on top of them.
+package kubernetes
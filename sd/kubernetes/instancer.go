@@ -0,0 +1,241 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+	"github.com/go-kit/log"
+)
+
+// ErrWatchExpired is reported when the API server ends a watch with an error
+// event, typically because the resource version is too old. The Instancer
+// recovers by listing the EndpointSlices again.
+var ErrWatchExpired = errors.New("endpointslice watch expired")
+
+// Instancer yields instances for a Kubernetes Service, by watching its
+// EndpointSlices. Only endpoints that are ready are yielded, as host:port
+// strings, for the named port of the Service.
+type Instancer struct {
+	cache     *instance.Cache
+	client    Client
+	namespace string
+	service   string
+	port      string
+	logger    log.Logger
+	backoff   endpoint.Backoff
+	slices    map[string]EndpointSlice
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// InstancerOption sets an optional parameter for Instancers.
+type InstancerOption func(*Instancer)
+
+// RetryBackoff sets how long to wait before listing or watching the
+// EndpointSlices again after an error, or after a watch that ended without any
+// event. Attempts are counted from 1 and reset by every watch that delivered
+// events. By default, the wait starts at 500ms and doubles up to 30 seconds.
+func RetryBackoff(b endpoint.Backoff) InstancerOption {
+	return func(s *Instancer) { s.backoff = b }
+}
+
+// NewInstancer returns a Kubernetes Instancer for the given Service. The port
+// is the name of the Service port to use; it may be empty if the Service
+// exposes a single, unnamed port. The first list of EndpointSlices is
+// performed before NewInstancer returns, and subsequent changes are watched
+// in the background until Stop is called.
+func NewInstancer(client Client, namespace, service, port string, logger log.Logger, options ...InstancerOption) *Instancer {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Instancer{
+		cache:     instance.NewCache(),
+		client:    client,
+		namespace: namespace,
+		service:   service,
+		port:      port,
+		logger:    log.With(logger, "namespace", namespace, "service", service),
+		backoff:   endpoint.ExponentialBackoff(500*time.Millisecond, 30*time.Second),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	version, err := s.list(ctx)
+	if err != nil {
+		s.logger.Log("err", err)
+	}
+	go s.loop(ctx, version, err != nil)
+	return s
+}
+
+// Stop terminates the Instancer.
+func (s *Instancer) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// Register implements Instancer.
+func (s *Instancer) Register(ch chan<- sd.Event) {
+	s.cache.Register(ch)
+}
+
+// Deregister implements Instancer.
+func (s *Instancer) Deregister(ch chan<- sd.Event) {
+	s.cache.Deregister(ch)
+}
+
+func (s *Instancer) loop(ctx context.Context, version string, relist bool) {
+	defer close(s.done)
+	var attempt int
+	retry := func() bool {
+		attempt++
+		return sleep(ctx, s.backoff(attempt))
+	}
+	for {
+		if relist {
+			var err error
+			if version, err = s.list(ctx); err != nil {
+				s.logger.Log("during", "list", "err", err)
+				if !retry() {
+					return
+				}
+				continue
+			}
+			relist = false
+		}
+
+		events, err := s.client.WatchEndpointSlices(ctx, s.namespace, s.service, version)
+		if err != nil {
+			s.logger.Log("during", "watch", "err", err)
+			s.cache.Update(sd.Event{Err: err})
+			relist = true
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		var n int
+		version, relist, n = s.consume(events, version)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A watch that fails or ends without any event, e.g. because the API
+		// server keeps expiring it, is retried with backoff rather than in a
+		// tight loop.
+		if relist || n == 0 {
+			if !retry() {
+				return
+			}
+			continue
+		}
+		attempt = 0
+	}
+}
+
+// consume applies events until the watch ends. It returns the resource
+// version to resume watching from, or whether a new list is needed, and the
+// number of events received.
+func (s *Instancer) consume(events <-chan WatchEvent, version string) (_ string, relist bool, n int) {
+	for event := range events {
+		n++
+		switch event.Type {
+		case EventAdded, EventModified:
+			s.slices[event.Object.Metadata.Name] = event.Object
+		case EventDeleted:
+			delete(s.slices, event.Object.Metadata.Name)
+		case EventBookmark:
+			version = event.Object.Metadata.ResourceVersion
+			continue
+		case EventError:
+			s.logger.Log("during", "watch", "err", ErrWatchExpired)
+			for range events {
+				// drain
+			}
+			return "", true, n
+		default:
+			continue
+		}
+		version = event.Object.Metadata.ResourceVersion
+		s.update()
+	}
+	return version, false, n
+}
+
+func (s *Instancer) list(ctx context.Context) (string, error) {
+	list, err := s.client.ListEndpointSlices(ctx, s.namespace, s.service)
+	if err != nil {
+		s.cache.Update(sd.Event{Err: err})
+		return "", err
+	}
+	s.slices = make(map[string]EndpointSlice, len(list.Items))
+	for _, slice := range list.Items {
+		s.slices[slice.Metadata.Name] = slice
+	}
+	s.update()
+	return list.Metadata.ResourceVersion, nil
+}
+
+func (s *Instancer) update() {
+	instances := instancesFrom(s.slices, s.port)
+	s.logger.Log("instances", len(instances))
+	s.cache.Update(sd.Event{Instances: instances})
+}
+
+// instancesFrom returns the deduplicated host:port strings of all ready
+// endpoints in the slices, for the named port.
+func instancesFrom(slices map[string]EndpointSlice, portName string) []string {
+	set := map[string]struct{}{}
+	for _, slice := range slices {
+		port, ok := findPort(slice.Ports, portName)
+		if !ok {
+			continue
+		}
+		for _, e := range slice.Endpoints {
+			if !e.Ready() {
+				continue
+			}
+			for _, addr := range e.Addresses {
+				set[net.JoinHostPort(addr, strconv.Itoa(int(port)))] = struct{}{}
+			}
+		}
+	}
+	instances := make([]string, 0, len(set))
+	for instance := range set {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+func findPort(ports []Port, name string) (int32, bool) {
+	for _, p := range ports {
+		if p.Name == name {
+			return p.Port, true
+		}
+	}
+	if name == "" && len(ports) == 1 {
+		return ports[0].Port, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,254 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/sd"
+	"github.com/go-kit/log"
+)
+
+var _ sd.Instancer = (*Instancer)(nil) // API check
+
+func TestInstancer(t *testing.T) {
+	client := newFakeClient()
+	client.list <- list("1", slice("a", "1", "http", 8080, sliceEndpoint(true, "10.0.0.1"), sliceEndpoint(false, "10.0.0.2")))
+
+	s := NewInstancer(client, "default", "search", "http", log.NewNopLogger())
+	defer s.Stop()
+
+	assertInstances(t, s, "10.0.0.1:8080")
+
+	client.watch <- WatchEvent{Type: EventAdded, Object: slice("b", "2", "http", 8080, sliceEndpoint(true, "10.0.0.3"))}
+	assertInstances(t, s, "10.0.0.1:8080", "10.0.0.3:8080")
+
+	client.watch <- WatchEvent{Type: EventModified, Object: slice("a", "3", "http", 8080, sliceEndpoint(true, "10.0.0.1", "10.0.0.2"))}
+	assertInstances(t, s, "10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080")
+
+	client.watch <- WatchEvent{Type: EventDeleted, Object: slice("b", "4", "http", 8080)}
+	assertInstances(t, s, "10.0.0.1:8080", "10.0.0.2:8080")
+}
+
+func TestInstancerRelistsAfterWatchError(t *testing.T) {
+	client := newFakeClient()
+	client.list <- list("1", slice("a", "1", "", 80, sliceEndpoint(true, "10.0.0.1")))
+
+	s := NewInstancer(client, "default", "search", "", log.NewNopLogger())
+	defer s.Stop()
+	assertInstances(t, s, "10.0.0.1:80")
+
+	client.list <- list("9", slice("a", "9", "", 80, sliceEndpoint(true, "10.0.0.9")))
+	client.watch <- WatchEvent{Type: EventError}
+	close(client.watch)
+	assertInstances(t, s, "10.0.0.9:80")
+}
+
+func TestInstancerBacksOffEmptyWatches(t *testing.T) {
+	client := newFakeClient()
+	client.list <- list("1", slice("a", "1", "", 80, sliceEndpoint(true, "10.0.0.1")))
+	close(client.watch) // every watch ends at once
+
+	s := NewInstancer(client, "default", "search", "", log.NewNopLogger())
+	time.Sleep(time.Second)
+	s.Stop()
+
+	// Watches are retried after 0.5s, then 1s, and so on.
+	if have := client.watches.Load(); have > 2 {
+		t.Errorf("want at most 2 watches, have %d", have)
+	}
+}
+
+func TestInstancerRetryBackoff(t *testing.T) {
+	client := newFakeClient()
+	client.list <- list("1", slice("a", "1", "", 80, sliceEndpoint(true, "10.0.0.1")))
+	close(client.watch)
+
+	var attempts []int
+	backoff := func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		if attempt == 3 {
+			return time.Hour
+		}
+		return time.Millisecond
+	}
+	s := NewInstancer(client, "default", "search", "", log.NewNopLogger(), RetryBackoff(backoff))
+	deadline := time.Now().Add(time.Second)
+	for client.watches.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+
+	if want, have := []int{1, 2, 3}, attempts; !reflect.DeepEqual(want, have) {
+		t.Errorf("want attempts %v, have %v", want, have)
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, have := "/apis/discovery.k8s.io/v1/namespaces/default/endpointslices", r.URL.Path; want != have {
+			t.Errorf("path: want %q, have %q", want, have)
+		}
+		if want, have := "kubernetes.io/service-name=search", r.URL.Query().Get("labelSelector"); want != have {
+			t.Errorf("selector: want %q, have %q", want, have)
+		}
+		if want, have := "Bearer secret", r.Header.Get("Authorization"); want != have {
+			t.Errorf("authorization: want %q, have %q", want, have)
+		}
+		if r.URL.Query().Get("watch") == "1" {
+			for i := 0; i < 2; i++ {
+				json.NewEncoder(w).Encode(WatchEvent{Type: EventAdded, Object: slice(fmt.Sprint(i), "2", "", 80)})
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(EndpointSliceList{Items: []EndpointSlice{slice("a", "1", "", 80)}})
+	}))
+	defer server.Close()
+
+	base, _ := url.Parse(server.URL)
+	client := NewClient(base, server.Client(), "secret")
+
+	slices, err := client.ListEndpointSlices(context.Background(), "default", "search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, len(slices.Items); want != have {
+		t.Fatalf("want %d slices, have %d", want, have)
+	}
+
+	events, err := client.WatchEndpointSlices(context.Background(), "default", "search", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for range events {
+		n++
+	}
+	if want, have := 2, n; want != have {
+		t.Errorf("want %d events, have %d", want, have)
+	}
+}
+
+func TestClientRotatedToken(t *testing.T) {
+	var authorization atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(EndpointSliceList{})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse(server.URL)
+	client := &client{base: base, token: (&tokenFile{path: path}).get, client: server.Client()}
+
+	for _, want := range []string{"first", "second"} {
+		if want == "second" {
+			if err := os.WriteFile(path, []byte(want), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			later := time.Now().Add(time.Minute)
+			if err := os.Chtimes(path, later, later); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := client.ListEndpointSlices(context.Background(), "default", "search"); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "Bearer "+want, authorization.Load(); want != have {
+			t.Errorf("authorization: want %q, have %q", want, have)
+		}
+	}
+}
+
+func assertInstances(t *testing.T, s *Instancer, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		have := s.cache.State().Instances
+		if reflect.DeepEqual(want, have) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want %v, have %v", want, have)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func list(version string, slices ...EndpointSlice) *EndpointSliceList {
+	var l EndpointSliceList
+	l.Metadata.ResourceVersion = version
+	l.Items = slices
+	return &l
+}
+
+func slice(name, version, portName string, port int32, endpoints ...Endpoint) EndpointSlice {
+	var s EndpointSlice
+	s.Metadata.Name = name
+	s.Metadata.ResourceVersion = version
+	s.Endpoints = endpoints
+	s.Ports = []Port{{Name: portName, Port: port}}
+	return s
+}
+
+func sliceEndpoint(ready bool, addresses ...string) Endpoint {
+	var e Endpoint
+	e.Addresses = addresses
+	e.Conditions.Ready = &ready
+	return e
+}
+
+type fakeClient struct {
+	list    chan *EndpointSliceList
+	watch   chan WatchEvent
+	watches atomic.Int32
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		list:  make(chan *EndpointSliceList, 1),
+		watch: make(chan WatchEvent),
+	}
+}
+
+func (c *fakeClient) ListEndpointSlices(ctx context.Context, _, _ string) (*EndpointSliceList, error) {
+	select {
+	case list := <-c.list:
+		return list, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *fakeClient) WatchEndpointSlices(ctx context.Context, _, _, _ string) (<-chan WatchEvent, error) {
+	c.watches.Add(1)
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case event, ok := <-c.watch:
+				if !ok {
+					return
+				}
+				events <- event
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
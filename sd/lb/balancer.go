@@ -2,8 +2,10 @@ package lb
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
 )
 
 // Balancer yields endpoints according to some heuristic.
@@ -13,3 +15,44 @@ type Balancer[REQ any, RES any] interface {
 
 // ErrNoEndpoints is returned when no qualifying endpoints are available.
 var ErrNoEndpoints = errors.New("no endpoints available")
+
+// instanceEndpoints returns the endpoints of s along with an identity for each
+// of them: its instance string if s implements sd.InstanceEndpointer, and its
+// position otherwise.
+func instanceEndpoints[REQ any, RES any](s sd.Endpointer[REQ, RES]) ([]string, []endpoint.Endpoint[REQ, RES], error) {
+	if ie, ok := s.(sd.InstanceEndpointer[REQ, RES]); ok {
+		ies, err := ie.InstanceEndpoints()
+		if err != nil {
+			return nil, nil, err
+		}
+		instances := make([]string, len(ies))
+		endpoints := make([]endpoint.Endpoint[REQ, RES], len(ies))
+		for i, ie := range ies {
+			instances[i], endpoints[i] = ie.Instance, ie.Endpoint
+		}
+		return instances, endpoints, nil
+	}
+
+	endpoints, err := s.Endpoints()
+	if err != nil {
+		return nil, nil, err
+	}
+	instances := make([]string, len(endpoints))
+	for i := range endpoints {
+		instances[i] = strconv.Itoa(i)
+	}
+	return instances, endpoints, nil
+}
+
+// endpointsOf returns the endpoints of the InstanceEndpoints, for the
+// Endpointers that implement Endpoints with their InstanceEndpoints.
+func endpointsOf[REQ any, RES any](ies []sd.InstanceEndpoint[REQ, RES], err error) ([]endpoint.Endpoint[REQ, RES], error) {
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]endpoint.Endpoint[REQ, RES], len(ies))
+	for i, ie := range ies {
+		endpoints[i] = ie.Endpoint
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,175 @@
+package lb
+
+import (
+	"context"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// KeyFunc extracts the key a request is routed by, e.g. a user or session ID.
+type KeyFunc[REQ any] func(ctx context.Context, request REQ) string
+
+// HashFunc hashes keys and instances onto the ring.
+type HashFunc func(s string) uint32
+
+// ConsistentHashOption sets an optional parameter for consistent hashing.
+type ConsistentHashOption func(*consistentHashOptions)
+
+type consistentHashOptions struct {
+	replicas int
+	hash     HashFunc
+}
+
+// Replicas sets the number of points every instance gets on the ring. More
+// points spread keys more evenly, at the cost of memory and a slower rebuild
+// of the ring when instances change. The default is 100.
+func Replicas(n int) ConsistentHashOption {
+	return func(o *consistentHashOptions) { o.replicas = n }
+}
+
+// Hash sets the hash function of the ring. The default is the IEEE CRC-32 of
+// the string, as computed by crc32.ChecksumIEEE.
+func Hash(f HashFunc) ConsistentHashOption {
+	return func(o *consistentHashOptions) { o.hash = f }
+}
+
+// NewConsistentHash returns an endpoint that routes every request to an
+// endpoint chosen by consistent hashing of its key, so that requests with the
+// same key keep going to the same instance, and only about 1/n of the keys
+// move when an instance is added or removed. This enables cache and session
+// affinity.
+//
+// The instances are identified by their instance strings if s implements
+// sd.InstanceEndpointer, as the Endpointer returned by sd.NewEndpointer does.
+// Other Endpointers only provide the position of each endpoint, so keys move
+// more when their endpoints change.
+func NewConsistentHash[REQ any, RES any](s sd.Endpointer[REQ, RES], key KeyFunc[REQ], options ...ConsistentHashOption) endpoint.Endpoint[REQ, RES] {
+	opts := consistentHashOptions{replicas: 100, hash: crc32String}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.replicas < 1 {
+		opts.replicas = 1
+	}
+	ch := &consistentHash[REQ, RES]{s: s, key: key, opts: opts}
+	return ch.serve
+}
+
+type consistentHash[REQ any, RES any] struct {
+	s    sd.Endpointer[REQ, RES]
+	key  KeyFunc[REQ]
+	opts consistentHashOptions
+	ring atomic.Pointer[hashRing] // of the last instances seen
+}
+
+func (ch *consistentHash[REQ, RES]) serve(ctx context.Context, request REQ) (response RES, err error) {
+	var e endpoint.Endpoint[REQ, RES]
+	if ie, ok := ch.s.(sd.InstanceEndpointer[REQ, RES]); ok {
+		ies, err := ie.InstanceEndpoints()
+		if err != nil {
+			return response, err
+		}
+		if len(ies) <= 0 {
+			return response, ErrNoEndpoints
+		}
+		r := ch.ringOf(len(ies), func(i int) string { return ies[i].Instance })
+		e = ies[r.lookup(ch.key(ctx, request))].Endpoint
+	} else {
+		endpoints, err := ch.s.Endpoints()
+		if err != nil {
+			return response, err
+		}
+		if len(endpoints) <= 0 {
+			return response, ErrNoEndpoints
+		}
+		e = endpoints[ch.ringOf(len(endpoints), nil).lookup(ch.key(ctx, request))]
+	}
+	return e(ctx, request)
+}
+
+// ringOf returns the ring of the n instances named by instance, or identified
+// by their position if instance is nil. The ring is only built when the
+// instances change; in the steady state, it's shared without locking.
+func (ch *consistentHash[REQ, RES]) ringOf(n int, instance func(i int) string) *hashRing {
+	if r := ch.ring.Load(); r != nil && r.holds(n, instance) {
+		return r
+	}
+	r := newHashRing(n, instance, ch.opts)
+	ch.ring.Store(r)
+	return r
+}
+
+// hashRing is immutable once built.
+type hashRing struct {
+	hash       HashFunc
+	positional bool
+	instances  []string
+	points     []ringPoint
+}
+
+type ringPoint struct {
+	hash  uint32
+	index int // into the instances
+}
+
+func newHashRing(n int, instance func(i int) string, opts consistentHashOptions) *hashRing {
+	r := &hashRing{
+		hash:       opts.hash,
+		positional: instance == nil,
+		instances:  make([]string, n),
+		points:     make([]ringPoint, 0, n*opts.replicas),
+	}
+	for i := range r.instances {
+		if r.positional {
+			r.instances[i] = strconv.Itoa(i)
+		} else {
+			r.instances[i] = instance(i)
+		}
+		for j := 0; j < opts.replicas; j++ {
+			h := r.hash(r.instances[i] + "#" + strconv.Itoa(j))
+			r.points = append(r.points, ringPoint{hash: h, index: i})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i].hash < r.points[j].hash })
+	return r
+}
+
+// holds reports whether the ring was built for the given instances.
+func (r *hashRing) holds(n int, instance func(i int) string) bool {
+	if r.positional != (instance == nil) || len(r.instances) != n {
+		return false
+	}
+	for i := 0; !r.positional && i < n; i++ {
+		if r.instances[i] != instance(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the index of the instance owning key.
+func (r *hashRing) lookup(key string) int {
+	h := r.hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].index
+}
+
+var ieeeTable = crc32.MakeTable(crc32.IEEE)
+
+// crc32String is crc32.ChecksumIEEE, without converting s to a slice, which
+// would allocate on every request.
+func crc32String(s string) uint32 {
+	crc := ^uint32(0)
+	for i := 0; i < len(s); i++ {
+		crc = ieeeTable[byte(crc)^s[i]] ^ (crc >> 8)
+	}
+	return ^crc
+}
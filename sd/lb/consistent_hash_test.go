@@ -0,0 +1,105 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+	"github.com/go-kit/log"
+)
+
+func TestConsistentHash(t *testing.T) {
+	var (
+		cache   = instance.NewCache()
+		factory = func(instance string) (endpoint.Endpoint[string, string], io.Closer, error) {
+			return func(context.Context, string) (string, error) { return instance, nil }, nil, nil
+		}
+		endpointer = sd.NewEndpointer[string, string](cache, factory, log.NewNopLogger())
+		key        = func(_ context.Context, request string) string { return request }
+		e          = NewConsistentHash[string, string](endpointer, key)
+	)
+	defer endpointer.Close()
+
+	update := func(instances ...string) {
+		t.Helper()
+		cache.Update(sd.Event{Instances: instances})
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if endpoints, _ := endpointer.Endpoints(); len(endpoints) == len(instances) {
+				return
+			}
+		}
+		t.Fatal("timeout waiting for endpoints")
+	}
+	route := func() map[string]string {
+		routes := map[string]string{}
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprintf("user-%d", i)
+			instance, err := e(context.Background(), k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			routes[k] = instance
+		}
+		return routes
+	}
+
+	if _, err := e(context.Background(), "user-1"); err != ErrNoEndpoints {
+		t.Errorf("want %v, have %v", ErrNoEndpoints, err)
+	}
+
+	update("a:80", "b:80", "c:80", "d:80")
+	before := route()
+	counts := map[string]int{}
+	for _, instance := range before {
+		counts[instance]++
+	}
+	for instance, n := range counts {
+		if n < 150 || n > 350 {
+			t.Errorf("%s: %d of 1000 keys, want about 250", instance, n)
+		}
+	}
+
+	// Only the keys of the removed instance move.
+	update("a:80", "b:80", "d:80")
+	for k, instance := range route() {
+		if was := before[k]; was != "c:80" && was != instance {
+			t.Errorf("%s: moved from %s to %s", k, was, instance)
+		}
+	}
+}
+
+func TestConsistentHashAllocs(t *testing.T) {
+	var (
+		cache   = instance.NewCache()
+		factory = func(instance string) (endpoint.Endpoint[string, string], io.Closer, error) {
+			return func(context.Context, string) (string, error) { return instance, nil }, nil, nil
+		}
+		endpointer = sd.NewEndpointer[string, string](cache, factory, log.NewNopLogger())
+		key        = func(_ context.Context, request string) string { return request }
+		e          = NewConsistentHash[string, string](endpointer, key)
+	)
+	defer endpointer.Close()
+	cache.Update(sd.Event{Instances: []string{"a:80", "b:80", "c:80"}})
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if endpoints, _ := endpointer.Endpoints(); len(endpoints) == 3 {
+			break
+		}
+	}
+
+	// The ring is only built when the instances change.
+	if allocs := testing.AllocsPerRun(100, func() { e(context.Background(), "user-1") }); allocs != 0 {
+		t.Errorf("want no allocations per request, have %v", allocs)
+	}
+
+	for _, s := range []string{"", "user-1", "a:80#42"} {
+		if want, have := crc32.ChecksumIEEE([]byte(s)), crc32String(s); want != have {
+			t.Errorf("%q: want %08x, have %08x", s, want, have)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lb
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// DrainOption sets an optional parameter for the Drainer.
+type DrainOption func(*drainOptions)
+
+type drainOptions struct {
+	when func(instance string) bool
+}
+
+// DrainWhen marks instances as draining based on their instance string, in
+// addition to those drained explicitly. This lets instances announce their own
+// maintenance through service discovery metadata, e.g. with the consul
+// package's InstanceMeta option:
+//
+//	lb.DrainWhen(func(instance string) bool {
+//		_, meta, _ := consul.ParseInstance(instance)
+//		return meta["draining"] == "true"
+//	})
+func DrainWhen(f func(instance string) bool) DrainOption {
+	return func(o *drainOptions) { o.when = f }
+}
+
+// Drainer wraps an Endpointer and hides the endpoints of instances that are
+// marked as draining, so that balancers built on top of it stop sending them
+// new requests. Requests already in flight aren't affected, which allows an
+// instance to be taken down for maintenance without failing any of them.
+//
+// Instances are identified by their instance string if the wrapped Endpointer
+// implements sd.InstanceEndpointer, as the Endpointer returned by
+// sd.NewEndpointer does, and by position otherwise.
+type Drainer[REQ any, RES any] struct {
+	s    sd.Endpointer[REQ, RES]
+	opts drainOptions
+
+	mtx      sync.RWMutex
+	draining map[string]struct{}
+}
+
+var _ sd.InstanceEndpointer[any, any] = (*Drainer[any, any])(nil)
+
+// NewDrainer returns a Drainer wrapping s, with no instances draining.
+func NewDrainer[REQ any, RES any](s sd.Endpointer[REQ, RES], options ...DrainOption) *Drainer[REQ, RES] {
+	var opts drainOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return &Drainer[REQ, RES]{
+		s:        s,
+		opts:     opts,
+		draining: map[string]struct{}{},
+	}
+}
+
+// Drain marks the given instances as draining.
+func (d *Drainer[REQ, RES]) Drain(instances ...string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, instance := range instances {
+		d.draining[instance] = struct{}{}
+	}
+}
+
+// Undrain returns the given instances to service.
+func (d *Drainer[REQ, RES]) Undrain(instances ...string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, instance := range instances {
+		delete(d.draining, instance)
+	}
+}
+
+// Draining returns the instances drained explicitly, in sorted order.
+func (d *Drainer[REQ, RES]) Draining() []string {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	instances := make([]string, 0, len(d.draining))
+	for instance := range d.draining {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+// Endpoints implements sd.Endpointer.
+func (d *Drainer[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
+	return endpointsOf(d.InstanceEndpoints())
+}
+
+// InstanceEndpoints implements sd.InstanceEndpointer.
+func (d *Drainer[REQ, RES]) InstanceEndpoints() ([]sd.InstanceEndpoint[REQ, RES], error) {
+	instances, endpoints, err := instanceEndpoints(d.s)
+	if err != nil {
+		return nil, err
+	}
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	ies := make([]sd.InstanceEndpoint[REQ, RES], 0, len(endpoints))
+	for i, instance := range instances {
+		if d.isDraining(instance) {
+			continue
+		}
+		ies = append(ies, sd.InstanceEndpoint[REQ, RES]{Instance: instance, Endpoint: endpoints[i]})
+	}
+	return ies, nil
+}
+
+func (d *Drainer[REQ, RES]) isDraining(instance string) bool {
+	if _, ok := d.draining[instance]; ok {
+		return true
+	}
+	return d.opts.when != nil && d.opts.when(instance)
+}
+
+// ServeHTTP implements http.Handler, as a local control endpoint meant to be
+// mounted on an admin server. GET returns the drained instances as a JSON
+// array. POST drains, and DELETE undrains, the instances given by the
+// "instance" query parameter, which may be repeated.
+func (d *Drainer[REQ, RES]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	instances := r.URL.Query()["instance"]
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodDelete:
+		if len(instances) == 0 {
+			http.Error(w, "missing instance parameter", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			d.Drain(instances...)
+		} else {
+			d.Undrain(instances...)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(d.Draining())
+}
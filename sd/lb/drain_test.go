@@ -0,0 +1,108 @@
+package lb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+	"github.com/go-kit/log"
+)
+
+func TestDrainer(t *testing.T) {
+	var (
+		cache   = instance.NewCache()
+		factory = func(instance string) (endpoint.Endpoint[string, string], io.Closer, error) {
+			return func(context.Context, string) (string, error) { return instance, nil }, nil, nil
+		}
+		endpointer = sd.NewEndpointer[string, string](cache, factory, log.NewNopLogger())
+		drainer    = NewDrainer[string, string](endpointer, DrainWhen(func(instance string) bool {
+			return strings.HasSuffix(instance, "?draining")
+		}))
+		rr = NewRoundRobin[string, string](drainer)
+	)
+	defer endpointer.Close()
+	cache.Update(sd.Event{Instances: []string{"a", "b", "c?draining"}})
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if endpoints, _ := endpointer.Endpoints(); len(endpoints) == 3 {
+			break
+		}
+	}
+
+	served := func() map[string]int {
+		t.Helper()
+		m := map[string]int{}
+		for i := 0; i < 6; i++ {
+			e, err := rr.Endpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			instance, _ := e(context.Background(), "")
+			m[instance]++
+		}
+		return m
+	}
+
+	if want, have := map[string]int{"a": 3, "b": 3}, served(); !equalCounts(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	drainer.Drain("a")
+	if want, have := map[string]int{"b": 6}, served(); !equalCounts(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	drainer.Drain("b")
+	if _, err := rr.Endpoint(); err != ErrNoEndpoints {
+		t.Errorf("want %v, have %v", ErrNoEndpoints, err)
+	}
+
+	drainer.Undrain("a", "b")
+	if want, have := map[string]int{"a": 3, "b": 3}, served(); !equalCounts(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestDrainerHandler(t *testing.T) {
+	drainer := NewDrainer[any, any](sd.FixedEndpointer[any, any]{})
+
+	for _, tc := range []struct {
+		method, target string
+		status         int
+		body           string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "[]"},
+		{http.MethodPost, "/?instance=b&instance=a", http.StatusOK, `["a","b"]`},
+		{http.MethodDelete, "/?instance=b", http.StatusOK, `["a"]`},
+		{http.MethodPost, "/", http.StatusBadRequest, "missing instance parameter"},
+		{http.MethodPut, "/?instance=a", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{http.MethodGet, "/", http.StatusOK, `["a"]`},
+	} {
+		rec := httptest.NewRecorder()
+		drainer.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+		if want, have := tc.status, rec.Code; want != have {
+			t.Errorf("%s %s: want %d, have %d", tc.method, tc.target, want, have)
+		}
+		if want, have := tc.body, strings.TrimSpace(rec.Body.String()); want != have {
+			t.Errorf("%s %s: want %q, have %q", tc.method, tc.target, want, have)
+		}
+	}
+}
+
+func equalCounts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
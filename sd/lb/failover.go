@@ -0,0 +1,57 @@
+package lb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/a69/kit.go/circuitbreaker"
+	"github.com/a69/kit.go/endpoint"
+)
+
+// NewFailover returns a load balancer that yields the endpoints of primary,
+// guarded by the breaker, and fails over to secondary, e.g. the balancer of
+// another region, while the breaker is open or primary has no endpoints.
+// Requests rejected by the breaker while it's half-open and all of its
+// probes are taken are sent to secondary as well.
+//
+// Failing back is automatic: once the breaker's open duration is over, its
+// probes go to primary again, and all requests do once they succeed. The
+// breaker should be dedicated to primary, and the options, e.g. IsFailure,
+// select the errors counted as its failures.
+func NewFailover[REQ any, RES any](primary, secondary Balancer[REQ, RES], b *circuitbreaker.Breaker, options ...circuitbreaker.Option) Balancer[REQ, RES] {
+	return &failover[REQ, RES]{
+		primary:   primary,
+		secondary: secondary,
+		b:         b,
+		guard:     circuitbreaker.Middleware[REQ, RES](b, options...),
+	}
+}
+
+type failover[REQ any, RES any] struct {
+	primary   Balancer[REQ, RES]
+	secondary Balancer[REQ, RES]
+	b         *circuitbreaker.Breaker
+	guard     endpoint.Middleware[REQ, RES]
+}
+
+func (f *failover[REQ, RES]) Endpoint() (endpoint.Endpoint[REQ, RES], error) {
+	if f.b.State() == circuitbreaker.StateOpen {
+		return f.secondary.Endpoint()
+	}
+	e, err := f.primary.Endpoint()
+	if err != nil {
+		return f.secondary.Endpoint()
+	}
+	guarded := f.guard(e)
+	return func(ctx context.Context, request REQ) (RES, error) {
+		response, err := guarded(ctx, request)
+		if !errors.Is(err, circuitbreaker.ErrOpen) {
+			return response, err
+		}
+		e, serr := f.secondary.Endpoint()
+		if serr != nil {
+			return response, err
+		}
+		return e(ctx, request)
+	}, nil
+}
@@ -0,0 +1,143 @@
+package lb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/circuitbreaker"
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/lb"
+)
+
+func TestFailover(t *testing.T) {
+	var (
+		primaryErr error
+		primary    = sd.FixedEndpointer[string, string]{
+			func(context.Context, string) (string, error) { return "primary", primaryErr },
+		}
+		secondary = sd.FixedEndpointer[string, string]{
+			func(context.Context, string) (string, error) { return "secondary", nil },
+		}
+		breaker = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(3),
+			circuitbreaker.OpenDuration(50*time.Millisecond),
+		)
+		balancer = lb.NewFailover(lb.NewRoundRobin[string, string](primary), lb.NewRoundRobin[string, string](secondary), breaker)
+	)
+
+	call := func() (string, error) {
+		e, err := balancer.Endpoint()
+		if err != nil {
+			return "", err
+		}
+		return e(context.Background(), "")
+	}
+
+	if want, have := "primary", mustCall(t, call); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	primaryErr = errors.New("unavailable")
+	for i := 0; i < 2; i++ {
+		if _, err := call(); err != primaryErr {
+			t.Fatalf("want %v, have %v", primaryErr, err)
+		}
+	}
+	if want, have := circuitbreaker.StateOpen, breaker.State(); want != have {
+		t.Fatalf("want %s, have %s", want, have)
+	}
+	if want, have := "secondary", mustCall(t, call); want != have {
+		t.Errorf("while open: want %q, have %q", want, have)
+	}
+
+	// Once the breaker is half-open, the probe fails back to the primary.
+	primaryErr = nil
+	time.Sleep(60 * time.Millisecond)
+	if want, have := "primary", mustCall(t, call); want != have {
+		t.Errorf("after open duration: want %q, have %q", want, have)
+	}
+	if want, have := circuitbreaker.StateClosed, breaker.State(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestFailoverNoEndpoints(t *testing.T) {
+	var (
+		primary   = sd.FixedEndpointer[string, string]{}
+		secondary = sd.FixedEndpointer[string, string]{
+			func(context.Context, string) (string, error) { return "secondary", nil },
+		}
+		balancer = lb.NewFailover(lb.NewRoundRobin[string, string](primary), lb.NewRoundRobin[string, string](secondary), circuitbreaker.NewBreaker())
+	)
+
+	e, err := balancer.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := e(context.Background(), ""); have != "secondary" {
+		t.Errorf("want %q, have %q", "secondary", have)
+	}
+
+	balancer = lb.NewFailover(lb.NewRoundRobin[string, string](primary), lb.NewRoundRobin[string, string](primary), circuitbreaker.NewBreaker())
+	if _, err := balancer.Endpoint(); err != lb.ErrNoEndpoints {
+		t.Errorf("want %v, have %v", lb.ErrNoEndpoints, err)
+	}
+}
+
+func TestFailoverHalfOpenRejection(t *testing.T) {
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+		primary = sd.FixedEndpointer[string, string]{
+			func(ctx context.Context, s string) (string, error) {
+				if s == "fail" {
+					return "", errors.New("unavailable")
+				}
+				close(entered)
+				<-release
+				return "primary", nil
+			},
+		}
+		secondary = sd.FixedEndpointer[string, string]{
+			func(context.Context, string) (string, error) { return "secondary", nil },
+		}
+		breaker = circuitbreaker.NewBreaker(
+			circuitbreaker.MinRequests(1),
+			circuitbreaker.OpenDuration(10*time.Millisecond),
+		)
+		balancer = lb.NewFailover(lb.NewRoundRobin[string, string](primary), lb.NewRoundRobin[string, string](secondary), breaker)
+	)
+
+	e, _ := balancer.Endpoint()
+	e(context.Background(), "fail")
+	time.Sleep(20 * time.Millisecond)
+
+	// The first request is the probe, blocked until released; the next one
+	// is rejected by the breaker and sent to the secondary instead.
+	probe, _ := balancer.Endpoint()
+	other, _ := balancer.Endpoint()
+	done := make(chan string)
+	go func() {
+		response, _ := probe(context.Background(), "")
+		done <- response
+	}()
+	<-entered
+	if have, _ := other(context.Background(), ""); have != "secondary" {
+		t.Errorf("want %q, have %q", "secondary", have)
+	}
+	close(release)
+	if have := <-done; have != "primary" {
+		t.Errorf("want %q, have %q", "primary", have)
+	}
+}
+
+func mustCall(t *testing.T, call func() (string, error)) string {
+	t.Helper()
+	response, err := call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
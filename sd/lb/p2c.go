@@ -0,0 +1,159 @@
+package lb
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// P2COption sets an optional parameter for the P2C balancer.
+type P2COption func(*p2cOptions)
+
+type p2cOptions struct {
+	decay time.Duration
+	now   func() time.Time
+}
+
+// DecayTime sets how quickly the P2C balancer forgets past latencies: an
+// observation's weight drops to about a third after d has passed. The default
+// is 10 seconds.
+func DecayTime(d time.Duration) P2COption {
+	return func(o *p2cOptions) { o.decay = d }
+}
+
+// NewP2C returns a load balancer using the power of two choices: for every
+// request, it samples two endpoints at random and picks the one with the lower
+// cost, which is its exponentially weighted moving average latency multiplied
+// by the number of requests in flight to it, plus one. This avoids the herd
+// behavior of always picking the best endpoint, while steering traffic away
+// from slow or overloaded endpoints much better than random or round robin
+// balancing.
+//
+// Latency is measured by the endpoints the balancer returns, so they must be
+// used for exactly one request each. Endpoints are tracked by instance string
+// if s implements sd.InstanceEndpointer, and by position otherwise.
+func NewP2C[REQ any, RES any](s sd.Endpointer[REQ, RES], seed int64, options ...P2COption) Balancer[REQ, RES] {
+	opts := p2cOptions{decay: 10 * time.Second, now: time.Now}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &p2c[REQ, RES]{
+		s:     s,
+		r:     rand.New(rand.NewSource(seed)),
+		opts:  opts,
+		stats: map[string]*p2cStats{},
+	}
+}
+
+type p2c[REQ any, RES any] struct {
+	s    sd.Endpointer[REQ, RES]
+	opts p2cOptions
+
+	mtx   sync.Mutex
+	r     *rand.Rand
+	stats map[string]*p2cStats
+}
+
+func (p *p2c[REQ, RES]) Endpoint() (endpoint.Endpoint[REQ, RES], error) {
+	instances, endpoints, err := instanceEndpoints(p.s)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) <= 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	p.mtx.Lock()
+	// Forget endpoints that are gone.
+	if len(p.stats) > len(instances) {
+		present := make(map[string]bool, len(instances))
+		for _, instance := range instances {
+			present[instance] = true
+		}
+		for instance := range p.stats {
+			if !present[instance] {
+				delete(p.stats, instance)
+			}
+		}
+	}
+	i := p.r.Intn(len(endpoints))
+	if len(endpoints) > 1 {
+		j := p.r.Intn(len(endpoints) - 1)
+		if j >= i {
+			j++
+		}
+		if p.statsFor(instances[j]).cost() < p.statsFor(instances[i]).cost() {
+			i = j
+		}
+	}
+	stats := p.statsFor(instances[i])
+	p.mtx.Unlock()
+
+	next := endpoints[i]
+	return func(ctx context.Context, request REQ) (RES, error) {
+		begin := stats.start(p.opts)
+		defer stats.done(p.opts, begin)
+		return next(ctx, request)
+	}, nil
+}
+
+// statsFor must be called with the mutex held.
+func (p *p2c[REQ, RES]) statsFor(instance string) *p2cStats {
+	s, ok := p.stats[instance]
+	if !ok {
+		s = &p2cStats{}
+		p.stats[instance] = s
+	}
+	return s
+}
+
+// p2cStats tracks the load of a single endpoint.
+type p2cStats struct {
+	mtx      sync.Mutex
+	inflight int
+	ewma     float64 // seconds
+	updated  time.Time
+}
+
+// cost of sending one more request to the endpoint. Endpoints that haven't
+// completed a request yet cost nothing while idle, so that they're probed,
+// but are avoided while the probe is in flight.
+func (s *p2cStats) cost() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.updated.IsZero() {
+		if s.inflight > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return s.ewma * float64(s.inflight+1)
+}
+
+func (s *p2cStats) start(opts p2cOptions) time.Time {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.inflight++
+	return opts.now()
+}
+
+func (s *p2cStats) done(opts p2cOptions, begin time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.inflight--
+
+	now := opts.now()
+	rtt := now.Sub(begin).Seconds()
+	if s.updated.IsZero() {
+		s.ewma, s.updated = rtt, now
+		return
+	}
+	w := math.Exp(-float64(now.Sub(s.updated)) / float64(opts.decay))
+	s.ewma = s.ewma*w + rtt*(1-w)
+	s.updated = now
+}
@@ -0,0 +1,69 @@
+package lb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+func TestP2C(t *testing.T) {
+	var (
+		counts    = make([]int, 3)
+		endpoints = make([]endpoint.Endpoint[any, any], 3)
+	)
+	for i := range endpoints {
+		i := i
+		endpoints[i] = func(context.Context, any) (any, error) {
+			counts[i]++
+			if i == 0 {
+				time.Sleep(5 * time.Millisecond) // the slow one
+			}
+			return nil, nil
+		}
+	}
+	balancer := NewP2C[any, any](sd.FixedEndpointer[any, any](endpoints), 12345)
+
+	for i := 0; i < 300; i++ {
+		e, err := balancer.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		e(context.Background(), nil)
+	}
+
+	// The slow endpoint is only picked when it's sampled twice, which P2C
+	// never does, or when its latency has decayed, which takes seconds.
+	if have := counts[0]; have > 10 {
+		t.Errorf("slow endpoint got %d of 300 requests", have)
+	}
+	if have := counts[1] + counts[2]; have < 290 {
+		t.Errorf("fast endpoints got %d of 300 requests", have)
+	}
+}
+
+func TestP2CInflight(t *testing.T) {
+	var (
+		counts    = make([]int, 2)
+		endpoints = []endpoint.Endpoint[any, any]{
+			func(context.Context, any) (any, error) { counts[0]++; return nil, nil },
+			func(context.Context, any) (any, error) { counts[1]++; return nil, nil },
+		}
+		balancer = NewP2C[any, any](sd.FixedEndpointer[any, any](endpoints), 1).(*p2c[any, any])
+		now      = time.Now()
+	)
+
+	// Both are equally fast, but the first one is busy.
+	balancer.stats["0"] = &p2cStats{ewma: 0.001, updated: now, inflight: 5}
+	balancer.stats["1"] = &p2cStats{ewma: 0.001, updated: now}
+
+	for i := 0; i < 10; i++ {
+		e, _ := balancer.Endpoint()
+		e(context.Background(), nil)
+	}
+	if want, have := []int{0, 10}, counts; want[0] != have[0] || want[1] != have[1] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
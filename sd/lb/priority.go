@@ -0,0 +1,110 @@
+package lb
+
+import (
+	"sort"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// PriorityFunc returns the priority of an instance, typically parsed from
+// metadata carried in its instance string, like a ZoneFunc. Lower values
+// are preferred: priority 0 instances are the primary ones, priority 1
+// instances the first fallback, and so on. Instances without a priority
+// should return 0.
+type PriorityFunc func(instance string) int
+
+// PriorityOption sets an optional parameter for Priority.
+type PriorityOption func(*priorityOptions)
+
+type priorityOptions struct {
+	minHealthy int
+}
+
+// MinHealthy sets the number of instances the preferred priorities need to
+// take all the requests. With fewer, the instances of the next priority are
+// added, until there are enough of them. The default is 1, which only fails
+// over once all the instances of a priority are gone.
+func MinHealthy(n int) PriorityOption {
+	return func(o *priorityOptions) { o.minHealthy = n }
+}
+
+// Priority wraps an Endpointer and only yields the instances of the best
+// priority, failing over to the instances of the next priorities when it
+// doesn't have enough healthy ones, like the priority levels of Envoy, e.g.
+// to prefer the instances of the local region and fail over to another
+// region. Balancers built on top of it, such as NewRandom or
+// NewWeightedRandom, only see the instances of the priorities in use.
+//
+// Instances are healthy as long as they're yielded by the wrapped
+// Endpointer: their health is checked by the service discovery system, and
+// they may be drained with a Drainer. Priorities are only known if the
+// wrapped Endpointer implements sd.InstanceEndpointer, as the Endpointer
+// returned by sd.NewEndpointer does. Otherwise, all instances have priority
+// 0.
+type Priority[REQ any, RES any] struct {
+	s          sd.Endpointer[REQ, RES]
+	priorityOf PriorityFunc
+	opts       priorityOptions
+}
+
+var _ sd.InstanceEndpointer[any, any] = (*Priority[any, any])(nil)
+
+// NewPriority returns a Priority wrapping s, with the priorities of
+// instances returned by priorityOf.
+func NewPriority[REQ any, RES any](s sd.Endpointer[REQ, RES], priorityOf PriorityFunc, options ...PriorityOption) *Priority[REQ, RES] {
+	opts := priorityOptions{minHealthy: 1}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &Priority[REQ, RES]{
+		s:          s,
+		priorityOf: priorityOf,
+		opts:       opts,
+	}
+}
+
+// Endpoints implements sd.Endpointer.
+func (p *Priority[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
+	return endpointsOf(p.InstanceEndpoints())
+}
+
+// InstanceEndpoints implements sd.InstanceEndpointer.
+func (p *Priority[REQ, RES]) InstanceEndpoints() ([]sd.InstanceEndpoint[REQ, RES], error) {
+	ie, ok := p.s.(sd.InstanceEndpointer[REQ, RES])
+	if !ok {
+		_, endpoints, err := instanceEndpoints(p.s)
+		if err != nil {
+			return nil, err
+		}
+		ies := make([]sd.InstanceEndpoint[REQ, RES], len(endpoints))
+		for i, e := range endpoints {
+			ies[i] = sd.InstanceEndpoint[REQ, RES]{Endpoint: e}
+		}
+		return ies, nil
+	}
+
+	ies, err := ie.InstanceEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	levels := map[int][]sd.InstanceEndpoint[REQ, RES]{}
+	for _, ie := range ies {
+		priority := p.priorityOf(ie.Instance)
+		levels[priority] = append(levels[priority], ie)
+	}
+	priorities := make([]int, 0, len(levels))
+	for priority := range levels {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+
+	var healthy []sd.InstanceEndpoint[REQ, RES]
+	for _, priority := range priorities {
+		healthy = append(healthy, levels[priority]...)
+		if len(healthy) >= p.opts.minHealthy {
+			break
+		}
+	}
+	return healthy, nil
+}
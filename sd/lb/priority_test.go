@@ -0,0 +1,43 @@
+package lb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func priorityOf(instance string) int {
+	_, priority, _ := strings.Cut(instance, "/")
+	p, _ := strconv.Atoi(priority)
+	return p
+}
+
+func TestPriority(t *testing.T) {
+	instances := func(p *Priority[string, string]) string {
+		t.Helper()
+		ies, err := p.InstanceEndpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, ie := range ies {
+			names = append(names, ie.Instance)
+		}
+		return fmt.Sprint(names)
+	}
+
+	all := fixedInstances{"c/2", "a/0", "b/1", "d/1"}
+	if want, have := "[a/0]", instances(NewPriority[string, string](all, priorityOf)); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := "[b/1 d/1]", instances(NewPriority[string, string](fixedInstances{"c/2", "b/1", "d/1"}, priorityOf)); want != have {
+		t.Errorf("failover: want %s, have %s", want, have)
+	}
+	if want, have := "[a/0 b/1 d/1]", instances(NewPriority[string, string](all, priorityOf, MinHealthy(2))); want != have {
+		t.Errorf("spill: want %s, have %s", want, have)
+	}
+	if want, have := "[a/0 b/1 d/1 c/2]", instances(NewPriority[string, string](all, priorityOf, MinHealthy(10))); want != have {
+		t.Errorf("not enough: want %s, have %s", want, have)
+	}
+}
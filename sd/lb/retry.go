@@ -62,6 +62,40 @@ func alwaysRetry(int, error) (keepTrying bool, replacement error) {
 // the callback returns false, or until the timeout is elapsed, whichever comes
 // first.
 func RetryWithCallback[REQ any, RES any](timeout time.Duration, b Balancer[REQ, RES], cb Callback) endpoint.Endpoint[REQ, RES] {
+	return retry(timeout, b, cb, nil)
+}
+
+// RetryOption sets an optional parameter for RetryWithBackoff.
+type RetryOption func(*retryOptions)
+
+type retryOptions struct {
+	retryable endpoint.Classifier
+}
+
+// Retryable sets the classifier deciding which errors are worth retrying.
+// Other errors, e.g. business errors that another instance would return just
+// the same, fail fast instead of burning the retry budget. By default, all
+// errors are retried.
+func Retryable(c endpoint.Classifier) RetryOption {
+	return func(o *retryOptions) { o.retryable = c }
+}
+
+// RetryWithBackoff is like Retry, but waits between attempts as told by
+// backoff, e.g. endpoint.Jitter(endpoint.ExponentialBackoff(10*time.Millisecond,
+// time.Second), 0.2), instead of retrying back-to-back. The wait counts
+// against the timeout.
+func RetryWithBackoff[REQ any, RES any](max int, timeout time.Duration, b Balancer[REQ, RES], backoff endpoint.Backoff, options ...RetryOption) endpoint.Endpoint[REQ, RES] {
+	opts := retryOptions{retryable: endpoint.RetryAll}
+	for _, option := range options {
+		option(&opts)
+	}
+	cb := func(n int, err error) (keepTrying bool, replacement error) {
+		return n < max && opts.retryable(err), nil
+	}
+	return retry(timeout, b, cb, backoff)
+}
+
+func retry[REQ any, RES any](timeout time.Duration, b Balancer[REQ, RES], cb Callback, backoff endpoint.Backoff) endpoint.Endpoint[REQ, RES] {
 	if cb == nil {
 		cb = alwaysRetry
 	}
@@ -112,6 +146,14 @@ func RetryWithCallback[REQ any, RES any](timeout time.Duration, b Balancer[REQ,
 					err = final
 					return
 				}
+				if backoff != nil {
+					select {
+					case <-newctx.Done():
+						err = newctx.Err()
+						return
+					case <-time.After(backoff(i)):
+					}
+				}
 				continue
 			}
 		}
@@ -139,3 +139,66 @@ func TestHandleNilCallback(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRetryWithBackoff(t *testing.T) {
+	var (
+		calls      []time.Time
+		endpointer = sd.FixedEndpointer[any, any]{
+			func(context.Context, interface{}) (interface{}, error) {
+				calls = append(calls, time.Now())
+				if len(calls) < 3 {
+					return nil, errors.New("unavailable")
+				}
+				return struct{}{}, nil
+			},
+		}
+		rr    = lb.NewRoundRobin[any, any](endpointer)
+		retry = lb.RetryWithBackoff(5, time.Second, rr, endpoint.ExponentialBackoff(20*time.Millisecond, 0))
+	)
+	if _, err := retry(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 3, len(calls); want != have {
+		t.Fatalf("calls: want %d, have %d", want, have)
+	}
+	if have := calls[1].Sub(calls[0]); have < 20*time.Millisecond {
+		t.Errorf("first backoff: want at least 20ms, have %v", have)
+	}
+	if have := calls[2].Sub(calls[1]); have < 40*time.Millisecond {
+		t.Errorf("second backoff: want at least 40ms, have %v", have)
+	}
+}
+
+func TestRetryWithBackoffTimeout(t *testing.T) {
+	var (
+		endpointer = sd.FixedEndpointer[any, any]{
+			func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("unavailable") },
+		}
+		rr    = lb.NewRoundRobin[any, any](endpointer)
+		retry = lb.RetryWithBackoff(5, 10*time.Millisecond, rr, endpoint.ConstantBackoff(time.Hour))
+	)
+	_, err := retry(context.Background(), struct{}{})
+	if want, have := context.DeadlineExceeded, err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRetryableFailsFast(t *testing.T) {
+	var (
+		calls       int
+		errNotFound = errors.New("not found")
+		endpointer  = sd.FixedEndpointer[any, any]{
+			func(context.Context, interface{}) (interface{}, error) { calls++; return nil, errNotFound },
+		}
+		rr        = lb.NewRoundRobin[any, any](endpointer)
+		retryable = func(err error) bool { return err != errNotFound }
+		retry     = lb.RetryWithBackoff(5, time.Second, rr, endpoint.ConstantBackoff(0), lb.Retryable(retryable))
+	)
+	_, err := retry(context.Background(), struct{}{})
+	if want, have := errNotFound, err.(lb.RetryError).Final; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
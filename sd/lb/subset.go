@@ -0,0 +1,116 @@
+package lb
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"sync/atomic"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// Subset wraps an Endpointer and only exposes a deterministic subset of its
+// instances, so that in a very large fleet every client keeps connections to
+// a handful of backends, rather than to all of them.
+//
+// The subset is chosen by rendezvous hashing of the client ID with every
+// instance: a client keeps the same subset across restarts, different clients
+// get different subsets, which spreads the load evenly over the fleet, and
+// when an instance is added or removed, only the clients that had it in their
+// subset, or now do, see a change.
+//
+// Instances are identified by their instance string if the wrapped Endpointer
+// implements sd.InstanceEndpointer, as the Endpointer returned by
+// sd.NewEndpointer does, and by position otherwise. The factory is still
+// called for every instance, so it should connect lazily, on first use, for
+// the subset to actually limit the number of connections.
+type Subset[REQ any, RES any] struct {
+	s        sd.Endpointer[REQ, RES]
+	clientID string
+	size     int
+	picked   atomic.Pointer[subset] // of the last instances seen
+}
+
+// subset is immutable once picked.
+type subset struct {
+	instances []string
+	indexes   []int
+}
+
+var _ sd.InstanceEndpointer[any, any] = (*Subset[any, any])(nil)
+
+// NewSubset returns a Subset of at most size instances of s, chosen for the
+// given client ID, typically the hostname or pod name of the client. A size
+// below 1 is taken as 1.
+func NewSubset[REQ any, RES any](s sd.Endpointer[REQ, RES], clientID string, size int) *Subset[REQ, RES] {
+	return &Subset[REQ, RES]{s: s, clientID: clientID, size: max(size, 1)}
+}
+
+// Endpoints implements sd.Endpointer.
+func (ss *Subset[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
+	return endpointsOf(ss.InstanceEndpoints())
+}
+
+// InstanceEndpoints implements sd.InstanceEndpointer. The instances are
+// returned in the order of the wrapped Endpointer.
+func (ss *Subset[REQ, RES]) InstanceEndpoints() ([]sd.InstanceEndpoint[REQ, RES], error) {
+	instances, endpoints, err := instanceEndpoints(ss.s)
+	if err != nil {
+		return nil, err
+	}
+	picked := ss.pick(instances)
+	ies := make([]sd.InstanceEndpoint[REQ, RES], len(picked))
+	for i, index := range picked {
+		ies[i] = sd.InstanceEndpoint[REQ, RES]{Instance: instances[index], Endpoint: endpoints[index]}
+	}
+	return ies, nil
+}
+
+// pick returns the indexes of the subset of instances. The subset is only
+// picked again when the instances change; in the steady state, it's shared
+// without locking.
+func (ss *Subset[REQ, RES]) pick(instances []string) []int {
+	if p := ss.picked.Load(); p != nil && slices.Equal(p.instances, instances) {
+		return p.indexes
+	}
+	p := &subset{instances: instances, indexes: Pick(ss.clientID, instances, ss.size)}
+	ss.picked.Store(p)
+	return p.indexes
+}
+
+// Pick returns the indexes, in increasing order, of the size instances that
+// make up the subset of the given client, or of all instances if there are no
+// more than size of them. A size below 1 is taken as 1.
+func Pick(clientID string, instances []string, size int) []int {
+	size = max(size, 1)
+	indexes := make([]int, len(instances))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if len(instances) <= size {
+		return indexes
+	}
+	scores := make([]uint64, len(instances))
+	for i, instance := range instances {
+		h := fnv.New64a()
+		h.Write([]byte(clientID))
+		h.Write([]byte{0})
+		h.Write([]byte(instance))
+		scores[i] = mix(h.Sum64())
+	}
+	sort.Slice(indexes, func(a, b int) bool { return scores[indexes[a]] > scores[indexes[b]] })
+	indexes = indexes[:size]
+	sort.Ints(indexes)
+	return indexes
+}
+
+// mix finalizes a hash so that similar inputs get unrelated scores.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,75 @@
+package lb
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// WeightFunc returns the weight of an instance, typically parsed from
+// metadata carried in its instance string, like a ZoneFunc. Instances
+// without a weight should return 1.
+type WeightFunc func(instance string) float64
+
+// NewWeightedRandom returns a load balancer that selects instances randomly,
+// in proportion to their weight, e.g. to send more traffic to bigger
+// machines, or a little to a canary. Instances with a weight of zero or less
+// are never selected, unless all of them are, in which case instances are
+// selected uniformly.
+//
+// Weights are only known if s implements sd.InstanceEndpointer, as the
+// Endpointer returned by sd.NewEndpointer does. Otherwise, all instances
+// have the same weight.
+func NewWeightedRandom[REQ any, RES any](s sd.Endpointer[REQ, RES], weightOf WeightFunc, seed int64) Balancer[REQ, RES] {
+	return &weightedRandom[REQ, RES]{
+		s:        s,
+		weightOf: weightOf,
+		r:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+type weightedRandom[REQ any, RES any] struct {
+	s        sd.Endpointer[REQ, RES]
+	weightOf WeightFunc
+
+	mtx sync.Mutex
+	r   *rand.Rand
+}
+
+func (w *weightedRandom[REQ, RES]) Endpoint() (endpoint.Endpoint[REQ, RES], error) {
+	instances, endpoints, err := instanceEndpoints(w.s)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) <= 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	var (
+		cumulative = make([]float64, len(instances))
+		total      float64
+	)
+	if _, ok := w.s.(sd.InstanceEndpointer[REQ, RES]); ok {
+		for i, instance := range instances {
+			if weight := w.weightOf(instance); weight > 0 {
+				total += weight
+			}
+			cumulative[i] = total
+		}
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if total <= 0 {
+		return endpoints[w.r.Intn(len(endpoints))], nil
+	}
+	x := w.r.Float64() * total
+	i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > x })
+	if i == len(cumulative) {
+		i--
+	}
+	return endpoints[i], nil
+}
@@ -0,0 +1,58 @@
+package lb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func weightOf(instance string) float64 {
+	_, weight, _ := strings.Cut(instance, "/")
+	w, _ := strconv.ParseFloat(weight, 64)
+	return w
+}
+
+func TestWeightedRandom(t *testing.T) {
+	var (
+		balancer = NewWeightedRandom[string, string](fixedInstances{"a/1", "b/3", "c/0"}, weightOf, 1)
+		counts   = map[string]int{}
+	)
+	for i := 0; i < 4000; i++ {
+		e, err := balancer.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance, _ := e(context.Background(), "")
+		counts[instance]++
+	}
+	if have := counts["a/1"]; have < 850 || have > 1150 {
+		t.Errorf("a: want about 1000, have %d", have)
+	}
+	if have := counts["b/3"]; have < 2850 || have > 3150 {
+		t.Errorf("b: want about 3000, have %d", have)
+	}
+	if want, have := 0, counts["c/0"]; want != have {
+		t.Errorf("c: want %d, have %d", want, have)
+	}
+}
+
+func TestWeightedRandomZeroWeights(t *testing.T) {
+	balancer := NewWeightedRandom[string, string](fixedInstances{"a/0", "b/0"}, weightOf, 1)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		e, err := balancer.Endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		instance, _ := e(context.Background(), "")
+		seen[instance] = true
+	}
+	if want, have := 2, len(seen); want != have {
+		t.Errorf("want %d instances selected, have %d", want, have)
+	}
+
+	if _, err := NewWeightedRandom[string, string](fixedInstances{}, weightOf, 1).Endpoint(); err != ErrNoEndpoints {
+		t.Errorf("want %v, have %v", ErrNoEndpoints, err)
+	}
+}
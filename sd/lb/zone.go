@@ -0,0 +1,168 @@
+package lb
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+// ZoneFunc returns the zone of an instance, typically parsed from metadata
+// carried in its instance string, e.g. with the consul package's InstanceMeta
+// option:
+//
+//	func(instance string) string {
+//		_, meta, _ := consul.ParseInstance(instance)
+//		return meta["zone"]
+//	}
+//
+// Instances in an unknown zone should return the empty string.
+type ZoneFunc func(instance string) string
+
+// ZoneOption sets an optional parameter for ZoneAware.
+type ZoneOption func(*zoneOptions)
+
+type zoneOptions struct {
+	spillover float64
+	minLocal  int
+}
+
+// Spillover sets the fraction of requests sent to other zones even while the
+// local zone has enough instances, which keeps connections to them warm and
+// sheds some load from busy zones. The default is 0.
+func Spillover(ratio float64) ZoneOption {
+	return func(o *zoneOptions) { o.spillover = ratio }
+}
+
+// MinLocal sets the number of instances the local zone needs to be preferred.
+// With fewer, requests are spread over the instances of all zones, so that a
+// zone that's mostly down isn't overloaded. The default is 1.
+func MinLocal(n int) ZoneOption {
+	return func(o *zoneOptions) { o.minLocal = n }
+}
+
+// ZoneAware wraps an Endpointer and prefers the instances in the local zone,
+// to save cross-zone latency and traffic costs. Balancers built on top of it
+// see, for every request, either the local instances or, for the spillover
+// fraction of requests, the instances of the other zones.
+//
+// Zones are only known if the wrapped Endpointer implements
+// sd.InstanceEndpointer, as the Endpointer returned by sd.NewEndpointer does.
+// Otherwise, all instances are considered remote.
+type ZoneAware[REQ any, RES any] struct {
+	s      sd.Endpointer[REQ, RES]
+	zone   string
+	zoneOf ZoneFunc
+	opts   zoneOptions
+	zones  atomic.Pointer[zoneGroups] // of the last instances seen
+
+	mtx sync.Mutex
+	r   *rand.Rand
+}
+
+// zoneGroups is immutable once grouped.
+type zoneGroups struct {
+	instances []string
+	local     []bool // by instance
+}
+
+var _ sd.InstanceEndpointer[any, any] = (*ZoneAware[any, any])(nil)
+
+// NewZoneAware returns a ZoneAware wrapping s, preferring the instances for
+// which zoneOf returns zone.
+func NewZoneAware[REQ any, RES any](s sd.Endpointer[REQ, RES], zone string, zoneOf ZoneFunc, seed int64, options ...ZoneOption) *ZoneAware[REQ, RES] {
+	opts := zoneOptions{minLocal: 1}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &ZoneAware[REQ, RES]{
+		s:      s,
+		zone:   zone,
+		zoneOf: zoneOf,
+		opts:   opts,
+		r:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Endpoints implements sd.Endpointer.
+func (z *ZoneAware[REQ, RES]) Endpoints() ([]endpoint.Endpoint[REQ, RES], error) {
+	return endpointsOf(z.InstanceEndpoints())
+}
+
+// InstanceEndpoints implements sd.InstanceEndpointer.
+func (z *ZoneAware[REQ, RES]) InstanceEndpoints() ([]sd.InstanceEndpoint[REQ, RES], error) {
+	var local, remote []sd.InstanceEndpoint[REQ, RES]
+	if ie, ok := z.s.(sd.InstanceEndpointer[REQ, RES]); ok {
+		ies, err := ie.InstanceEndpoints()
+		if err != nil {
+			return nil, err
+		}
+		g := z.groupsOf(ies)
+		for i, ie := range ies {
+			if g.local[i] {
+				local = append(local, ie)
+			} else {
+				remote = append(remote, ie)
+			}
+		}
+	} else {
+		_, endpoints, err := instanceEndpoints(z.s)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range endpoints {
+			remote = append(remote, sd.InstanceEndpoint[REQ, RES]{Endpoint: e})
+		}
+	}
+
+	switch {
+	case len(local) == 0:
+		return remote, nil
+	case len(local) < z.opts.minLocal:
+		return append(local, remote...), nil
+	case len(remote) > 0 && z.spill():
+		return remote, nil
+	default:
+		return local, nil
+	}
+}
+
+// groupsOf returns which of the instances are local. Zones are only looked up
+// again when the instances change; in the steady state, the groups are shared
+// without locking.
+func (z *ZoneAware[REQ, RES]) groupsOf(ies []sd.InstanceEndpoint[REQ, RES]) *zoneGroups {
+	if g := z.zones.Load(); g != nil && g.holds(len(ies), func(i int) string { return ies[i].Instance }) {
+		return g
+	}
+	g := &zoneGroups{instances: make([]string, len(ies)), local: make([]bool, len(ies))}
+	for i, ie := range ies {
+		g.instances[i] = ie.Instance
+		g.local[i] = z.zoneOf(ie.Instance) == z.zone
+	}
+	z.zones.Store(g)
+	return g
+}
+
+// holds reports whether the groups were made for the given instances.
+func (g *zoneGroups) holds(n int, instance func(i int) string) bool {
+	if len(g.instances) != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if g.instances[i] != instance(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (z *ZoneAware[REQ, RES]) spill() bool {
+	if z.opts.spillover <= 0 {
+		return false
+	}
+	z.mtx.Lock()
+	defer z.mtx.Unlock()
+	return z.r.Float64() < z.opts.spillover
+}
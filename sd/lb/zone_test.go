@@ -0,0 +1,173 @@
+package lb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+type fixedInstances []string
+
+func (f fixedInstances) Endpoints() ([]endpoint.Endpoint[string, string], error) {
+	_, endpoints, err := instanceEndpoints[string, string](f)
+	return endpoints, err
+}
+
+func (f fixedInstances) InstanceEndpoints() ([]sd.InstanceEndpoint[string, string], error) {
+	ies := make([]sd.InstanceEndpoint[string, string], len(f))
+	for i, instance := range f {
+		instance := instance
+		ies[i] = sd.InstanceEndpoint[string, string]{
+			Instance: instance,
+			Endpoint: func(context.Context, string) (string, error) { return instance, nil },
+		}
+	}
+	return ies, nil
+}
+
+func zoneOf(instance string) string {
+	zone, _, _ := strings.Cut(instance, "/")
+	return zone
+}
+
+func TestZoneAware(t *testing.T) {
+	count := func(z *ZoneAware[string, string]) map[string]int {
+		t.Helper()
+		rr := NewRoundRobin[string, string](z)
+		zones := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			e, err := rr.Endpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			instance, _ := e(context.Background(), "")
+			zones[zoneOf(instance)]++
+		}
+		return zones
+	}
+	instances := fixedInstances{"a/1", "a/2", "b/1", "b/2", "c/1"}
+
+	if want, have := 1000, count(NewZoneAware[string, string](instances, "a", zoneOf, 1))["a"]; want != have {
+		t.Errorf("local: want %d, have %d", want, have)
+	}
+
+	zones := count(NewZoneAware[string, string](instances, "a", zoneOf, 1, Spillover(0.2)))
+	if have := zones["b"] + zones["c"]; have < 150 || have > 250 {
+		t.Errorf("spillover: want about 200, have %d", have)
+	}
+
+	zones = count(NewZoneAware[string, string](instances, "a", zoneOf, 1, MinLocal(3)))
+	if have := zones["a"]; have < 300 || have > 500 {
+		t.Errorf("min local: want about 400, have %d", have)
+	}
+
+	if want, have := 0, count(NewZoneAware[string, string](instances, "d", zoneOf, 1))["d"]; want != have {
+		t.Errorf("no local instances: want %d, have %d", want, have)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	var instances fixedInstances
+	for i := 0; i < 100; i++ {
+		instances = append(instances, fmt.Sprintf("10.0.0.%d:8080", i))
+	}
+
+	subset := func(clientID string, instances fixedInstances) []string {
+		t.Helper()
+		ies, err := NewSubset[string, string](instances, clientID, 10).InstanceEndpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked := make([]string, len(ies))
+		for i, ie := range ies {
+			picked[i] = ie.Instance
+		}
+		return picked
+	}
+
+	first := subset("client-1", instances)
+	if want, have := 10, len(first); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	if want, have := strings.Join(first, ","), strings.Join(subset("client-1", instances), ","); want != have {
+		t.Errorf("not deterministic: want %s, have %s", want, have)
+	}
+
+	// Removing an instance outside of the subset doesn't change it.
+	removed := -1
+	for i, instance := range instances {
+		if !strings.Contains(strings.Join(first, ","), instance) {
+			removed = i
+			break
+		}
+	}
+	fewer := append(append(fixedInstances{}, instances[:removed]...), instances[removed+1:]...)
+	if want, have := strings.Join(first, ","), strings.Join(subset("client-1", fewer), ","); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+
+	// Across many clients, every instance gets a fair share.
+	load := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		for _, instance := range subset(fmt.Sprintf("client-%d", i), instances) {
+			load[instance]++
+		}
+	}
+	for _, instance := range instances {
+		if have := load[instance]; have < 50 || have > 150 {
+			t.Errorf("%s: want about 100 clients, have %d", instance, have)
+		}
+	}
+
+	if want, have := 3, len(subset("client-1", instances[:3])); want != have {
+		t.Errorf("small fleet: want %d, have %d", want, have)
+	}
+}
+
+func TestSubsetSize(t *testing.T) {
+	instances := fixedInstances{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}
+	for _, size := range []int{0, -1} {
+		ies, err := NewSubset[string, string](instances, "client-1", size).InstanceEndpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := 1, len(ies); want != have {
+			t.Errorf("size %d: want %d instances, have %d", size, want, have)
+		}
+	}
+}
+
+func TestZoneAwareGroupsOnChange(t *testing.T) {
+	var (
+		lookups   int
+		instances = fixedInstances{"a/1", "b/1", "a/2"}
+		z         = NewZoneAware[string, string](&instances, "a", func(instance string) string {
+			lookups++
+			return zoneOf(instance)
+		}, 1)
+	)
+	for i := 0; i < 10; i++ {
+		if _, err := z.InstanceEndpoints(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := 3, lookups; want != have {
+		t.Errorf("want %d zone lookups, have %d", want, have)
+	}
+
+	instances = append(instances, "b/2")
+	ies, err := z.InstanceEndpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 7, lookups; want != have {
+		t.Errorf("want %d zone lookups, have %d", want, have)
+	}
+	if want, have := 2, len(ies); want != have {
+		t.Errorf("want %d local instances, have %d", want, have)
+	}
+}
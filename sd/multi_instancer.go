@@ -0,0 +1,133 @@
+package sd
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// MultiInstancer merges the instances of several Instancers, e.g. a Consul
+// Instancer for the local datacenter and a FixedInstancer as a fallback, or one
+// Instancer per datacenter. Instances reported by more than one source are
+// de-duplicated.
+//
+// The aggregate is healthy as long as any source is: sources whose latest
+// Event carries an error are left out of the union until they recover, and an
+// error is only reported once every source that has reported anything is
+// failing. Sources that haven't sent their first Event yet are ignored.
+type MultiInstancer struct {
+	sources []Instancer
+	chans   []chan Event
+	wg      sync.WaitGroup
+
+	mtx         sync.Mutex
+	latest      []*Event
+	state       Event
+	subscribers map[chan<- Event]struct{}
+}
+
+var _ Instancer = (*MultiInstancer)(nil)
+
+// NewMultiInstancer returns a MultiInstancer subscribed to the given sources.
+func NewMultiInstancer(sources ...Instancer) *MultiInstancer {
+	m := &MultiInstancer{
+		sources:     sources,
+		chans:       make([]chan Event, len(sources)),
+		latest:      make([]*Event, len(sources)),
+		subscribers: map[chan<- Event]struct{}{},
+	}
+	for i, src := range sources {
+		ch := make(chan Event)
+		m.chans[i] = ch
+		m.wg.Add(1)
+		go m.receive(i, ch)
+		src.Register(ch)
+	}
+	return m
+}
+
+func (m *MultiInstancer) receive(i int, ch <-chan Event) {
+	defer m.wg.Done()
+	for event := range ch {
+		m.update(i, event)
+	}
+}
+
+func (m *MultiInstancer) update(i int, event Event) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.latest[i] = &event
+
+	var (
+		healthy bool
+		errs    []error
+		seen    = map[string]struct{}{}
+		merged  = []string{}
+	)
+	for _, e := range m.latest {
+		switch {
+		case e == nil:
+			continue
+		case e.Err != nil:
+			errs = append(errs, e.Err)
+			continue
+		}
+		healthy = true
+		for _, instance := range e.Instances {
+			if _, ok := seen[instance]; !ok {
+				seen[instance] = struct{}{}
+				merged = append(merged, instance)
+			}
+		}
+	}
+
+	state := Event{Err: errors.Join(errs...)}
+	if healthy {
+		sort.Strings(merged)
+		state = Event{Instances: merged}
+	}
+	if reflect.DeepEqual(m.state, state) {
+		return
+	}
+	m.state = state
+	for ch := range m.subscribers {
+		ch <- copyEvent(state)
+	}
+}
+
+// Register implements Instancer.
+func (m *MultiInstancer) Register(ch chan<- Event) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.subscribers[ch] = struct{}{}
+	ch <- copyEvent(m.state)
+}
+
+// Deregister implements Instancer.
+func (m *MultiInstancer) Deregister(ch chan<- Event) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.subscribers, ch)
+}
+
+// Stop implements Instancer. It unsubscribes from the sources, but doesn't
+// stop them, since they may be shared with other consumers.
+func (m *MultiInstancer) Stop() {
+	for i, src := range m.sources {
+		src.Deregister(m.chans[i])
+		close(m.chans[i])
+	}
+	m.wg.Wait()
+}
+
+func copyEvent(e Event) Event {
+	if e.Instances == nil {
+		return e
+	}
+	instances := make([]string, len(e.Instances))
+	copy(instances, e.Instances)
+	e.Instances = instances
+	return e
+}
@@ -0,0 +1,82 @@
+package sd_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/sd"
+	"github.com/a69/kit.go/sd/internal/instance"
+)
+
+func TestMultiInstancer(t *testing.T) {
+	var (
+		primary  = instance.NewCache()
+		fallback = sd.FixedInstancer{"10.0.0.9:80", "10.0.0.1:80"}
+	)
+	primary.Update(sd.Event{Instances: []string{"10.0.0.1:80", "10.0.0.2:80"}})
+
+	m := sd.NewMultiInstancer(primary, fallback)
+	defer m.Stop()
+
+	events := make(chan sd.Event, 16)
+	m.Register(events)
+	defer m.Deregister(events)
+
+	expect := func(want sd.Event) {
+		t.Helper()
+		awaitEvent(t, events, func(have sd.Event) bool { return reflect.DeepEqual(want, have) })
+	}
+	expect(sd.Event{Instances: []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.9:80"}})
+
+	// A failing source drops out, but the aggregate stays healthy.
+	failure := errors.New("consul unreachable")
+	primary.Update(sd.Event{Err: failure})
+	expect(sd.Event{Instances: []string{"10.0.0.1:80", "10.0.0.9:80"}})
+
+	primary.Update(sd.Event{Instances: []string{"10.0.0.3:80"}})
+	expect(sd.Event{Instances: []string{"10.0.0.1:80", "10.0.0.3:80", "10.0.0.9:80"}})
+}
+
+func TestMultiInstancerAllFailing(t *testing.T) {
+	var (
+		a, b    = instance.NewCache(), instance.NewCache()
+		failure = errors.New("failure")
+	)
+	a.Update(sd.Event{Instances: []string{"a:80"}})
+	b.Update(sd.Event{Instances: []string{"b:80"}})
+
+	m := sd.NewMultiInstancer(a, b)
+	defer m.Stop()
+
+	a.Update(sd.Event{Err: failure})
+	b.Update(sd.Event{Err: failure})
+
+	events := make(chan sd.Event, 16)
+	m.Register(events)
+	defer m.Deregister(events)
+	have := awaitEvent(t, events, func(e sd.Event) bool { return e.Err != nil })
+	if !errors.Is(have.Err, failure) {
+		t.Errorf("want %v, have %v", failure, have.Err)
+	}
+	if len(have.Instances) != 0 {
+		t.Errorf("want no instances, have %v", have.Instances)
+	}
+}
+
+func awaitEvent(t *testing.T, events <-chan sd.Event, match func(sd.Event) bool) sd.Event {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case e := <-events:
+			if match(e) {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("timeout waiting for event")
+			return sd.Event{}
+		}
+	}
+}
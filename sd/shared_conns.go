@@ -0,0 +1,117 @@
+package sd
+
+import (
+	"io"
+	"sync"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// SharedConns keeps a single connection per instance, e.g. a *grpc.ClientConn,
+// and shares it between the factories of several Endpointers, one per method
+// of the same service. Connections are reference counted: each endpoint built
+// on a connection holds a reference, and the connection is closed once the
+// last endpoint using it is closed by its Endpointer.
+type SharedConns[C io.Closer] struct {
+	dial  func(instance string) (C, error)
+	mtx   sync.Mutex
+	conns map[string]*sharedConn[C]
+}
+
+// sharedConn is the connection to an instance, which is being dialed until
+// ready is closed. If dialing failed, err is set, and the sharedConn has been
+// removed from the conns of its SharedConns.
+type sharedConn[C io.Closer] struct {
+	ready chan struct{}
+	conn  C
+	err   error
+	refs  int
+}
+
+// NewSharedConns returns a SharedConns that opens connections with dial.
+func NewSharedConns[C io.Closer](dial func(instance string) (C, error)) *SharedConns[C] {
+	return &SharedConns[C]{
+		dial:  dial,
+		conns: map[string]*sharedConn[C]{},
+	}
+}
+
+// Acquire returns the connection to instance, dialing it if there's none yet,
+// and a Closer releasing the reference. Closing the Closer more than once has
+// no further effect. Connections are dialed without holding up acquisitions
+// of other instances; concurrent acquisitions of the same instance wait for
+// its dial, and share its outcome.
+func (s *SharedConns[C]) Acquire(instance string) (C, io.Closer, error) {
+	s.mtx.Lock()
+	sc, ok := s.conns[instance]
+	if !ok {
+		sc = &sharedConn[C]{ready: make(chan struct{})}
+		s.conns[instance] = sc
+	}
+	sc.refs++
+	s.mtx.Unlock()
+
+	if ok {
+		<-sc.ready
+	} else {
+		conn, err := s.dial(instance)
+		if err != nil {
+			s.mtx.Lock()
+			if s.conns[instance] == sc {
+				delete(s.conns, instance)
+			}
+			s.mtx.Unlock()
+		}
+		sc.conn, sc.err = conn, err
+		close(sc.ready)
+	}
+	if sc.err != nil {
+		return sc.conn, nil, sc.err
+	}
+
+	var once sync.Once
+	release := closerFunc(func() (err error) {
+		once.Do(func() { err = s.release(instance, sc) })
+		return err
+	})
+	return sc.conn, release, nil
+}
+
+func (s *SharedConns[C]) release(instance string, sc *sharedConn[C]) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	sc.refs--
+	if sc.refs > 0 {
+		return nil
+	}
+	if s.conns[instance] == sc {
+		delete(s.conns, instance)
+	}
+	return sc.conn.Close()
+}
+
+// Len returns the number of open connections, including the ones being
+// dialed.
+func (s *SharedConns[C]) Len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+// SharedFactory returns a Factory building endpoints on the connections of s.
+// Use one SharedFactory per method, all with the same SharedConns, to make
+// every method of an instance share one connection.
+func SharedFactory[C io.Closer, REQ any, RES any](s *SharedConns[C], build func(conn C) endpoint.Endpoint[REQ, RES]) Factory[REQ, RES] {
+	return func(instance string) (endpoint.Endpoint[REQ, RES], io.Closer, error) {
+		conn, release, err := s.Acquire(instance)
+		if err != nil {
+			return nil, nil, err
+		}
+		return build(conn), release, nil
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
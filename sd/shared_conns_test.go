@@ -0,0 +1,142 @@
+package sd_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/sd"
+)
+
+type testConn struct {
+	instance string
+	closed   int
+}
+
+func (c *testConn) Close() error { c.closed++; return nil }
+
+func TestSharedConns(t *testing.T) {
+	var (
+		dialed []*testConn
+		conns  = sd.NewSharedConns(func(instance string) (*testConn, error) {
+			c := &testConn{instance: instance}
+			dialed = append(dialed, c)
+			return c, nil
+		})
+		build = func(c *testConn) endpoint.Endpoint[struct{}, string] {
+			return func(context.Context, struct{}) (string, error) { return c.instance, nil }
+		}
+		sum    = sd.SharedFactory(conns, build)
+		concat = sd.SharedFactory(conns, build)
+	)
+
+	_, c1, err := sum("a:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, c2, err := concat("a:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := e(context.Background(), struct{}{}); have != "a:80" {
+		t.Errorf("want a:80, have %s", have)
+	}
+	if want, have := 1, len(dialed); want != have {
+		t.Fatalf("dialed: want %d, have %d", want, have)
+	}
+
+	c1.Close()
+	c1.Close() // no effect
+	if want, have := 0, dialed[0].closed; want != have {
+		t.Errorf("closed after first release: want %d, have %d", want, have)
+	}
+	c2.Close()
+	if want, have := 1, dialed[0].closed; want != have {
+		t.Errorf("closed after last release: want %d, have %d", want, have)
+	}
+	if want, have := 0, conns.Len(); want != have {
+		t.Errorf("open: want %d, have %d", want, have)
+	}
+
+	// A new endpoint dials again.
+	if _, _, err := sum("a:80"); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, len(dialed); want != have {
+		t.Errorf("dialed: want %d, have %d", want, have)
+	}
+}
+
+func TestSharedConnsDialOutsideLock(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		dials   = map[string]int{}
+		unblock = make(chan struct{})
+		errDial = errors.New("dial failed")
+		conns   = sd.NewSharedConns(func(instance string) (*testConn, error) {
+			mtx.Lock()
+			dials[instance]++
+			mtx.Unlock()
+			switch instance {
+			case "slow:80":
+				<-unblock
+			case "bad:80":
+				return nil, errDial
+			}
+			return &testConn{instance: instance}, nil
+		})
+		connc = make(chan *testConn, 2)
+	)
+	acquire := func() {
+		conn, _, err := conns.Acquire("slow:80")
+		if err != nil {
+			t.Error(err)
+		}
+		connc <- conn
+	}
+
+	go acquire()
+	for !func() bool { mtx.Lock(); defer mtx.Unlock(); return dials["slow:80"] == 1 }() {
+		time.Sleep(time.Millisecond)
+	}
+	go acquire()
+
+	// Other instances are dialed while the slow one is.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, _, err := conns.Acquire("fast:80"); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dial of another instance blocked")
+	}
+
+	// Concurrent acquisitions share the dialed connection.
+	close(unblock)
+	if c1, c2 := <-connc, <-connc; c1 == nil || c1 != c2 {
+		t.Errorf("want one shared connection, have %p and %p", c1, c2)
+	}
+	if want, have := 1, dials["slow:80"]; want != have {
+		t.Errorf("dials: want %d, have %d", want, have)
+	}
+
+	// A failed dial isn't kept.
+	for i := 0; i < 2; i++ {
+		if _, _, err := conns.Acquire("bad:80"); err != errDial {
+			t.Errorf("want %v, have %v", errDial, err)
+		}
+	}
+	if want, have := 2, dials["bad:80"]; want != have {
+		t.Errorf("dials: want %d, have %d", want, have)
+	}
+	if want, have := 2, conns.Len(); want != have {
+		t.Errorf("open: want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,20 @@
+// Package slo instruments endpoints against a service level objective: it
+// classifies every request as good or bad, from its error and latency, and
+// reports how fast the error budget of the objective is being burned.
+//
+// A burn rate of 1 spends the error budget exactly over the SLO period; a
+// burn rate of 14.4 spends the budget of 30 days in 2 days. Burn rates are
+// computed over several windows at once, as gauges labeled with "window",
+// so that alerts can require both a long and a short window to burn, which
+// catches fast burns quickly without paging for blips:
+//
+//	t := slo.NewTracker(slo.Objective{Target: 0.999, Latency: 300 * time.Millisecond},
+//		slo.BurnRateGauge(burnRate),
+//	)
+//	e = slo.Middleware[Request, Response](t)(e)
+//
+// The burn rate can also shed load, through the loadshed package, before
+// the budget runs out:
+//
+//	e = loadshed.Middleware[Request, Response](t.Signal(5*time.Minute, time.Hour), 14.4, 28.8)(e)
+package slo
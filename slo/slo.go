@@ -0,0 +1,198 @@
+package slo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/internal/window"
+	"github.com/a69/kit.go/loadshed"
+	"github.com/a69/kit.go/metrics"
+)
+
+// Objective defines what a good request is, and how many of them must be.
+type Objective struct {
+	// Target is the fraction of requests that must be good, e.g. 0.999.
+	Target float64
+
+	// Latency is the duration above which requests are bad, even if they
+	// succeeded. Zero means requests are only judged by their error.
+	Latency time.Duration
+}
+
+// DefaultWindows are the windows burn rates are computed over by default,
+// the ones of the usual multi-window, multi-burn-rate alerts.
+var DefaultWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// Tracker tracks the good and bad requests of an objective, over several
+// windows.
+type Tracker struct {
+	objective Objective
+	isBad     func(error) bool
+	burnRate  metrics.Gauge
+	requests  metrics.Counter
+	timeNow   func() time.Time
+
+	mtx     sync.Mutex
+	windows []*namedWindow
+}
+
+// NewTracker returns a Tracker for the objective.
+func NewTracker(objective Objective, options ...Option) *Tracker {
+	t := &Tracker{
+		objective: objective,
+		isBad:     func(err error) bool { return !errors.Is(err, context.Canceled) },
+		timeNow:   time.Now,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	if t.windows == nil {
+		for _, size := range DefaultWindows {
+			t.windows = append(t.windows, newWindow(size))
+		}
+	}
+	return t
+}
+
+// Option sets an optional parameter for trackers.
+type Option func(*Tracker)
+
+// Windows sets the windows burn rates are computed over. By default, they're
+// DefaultWindows.
+func Windows(windows ...time.Duration) Option {
+	return func(t *Tracker) {
+		t.windows = nil
+		for _, size := range windows {
+			t.windows = append(t.windows, newWindow(size))
+		}
+	}
+}
+
+// ErrorClassifier sets the function reporting whether a request that failed
+// with err is bad. By default, all errors are bad, except context.Canceled:
+// requests abandoned by their caller aren't the service's fault. Responses
+// that failed, as reported by endpoint.Failed, are business errors, and
+// always good.
+func ErrorClassifier(isBad func(err error) bool) Option {
+	return func(t *Tracker) { t.isBad = isBad }
+}
+
+// BurnRateGauge sets a gauge set to the burn rate of every window after
+// every request, labeled with "window", e.g. "5m" or "6h".
+func BurnRateGauge(g metrics.Gauge) Option {
+	return func(t *Tracker) { t.burnRate = g }
+}
+
+// RequestCounter sets a counter incremented for every request, labeled with
+// "outcome", either "good" or "bad".
+func RequestCounter(c metrics.Counter) Option {
+	return func(t *Tracker) { t.requests = c }
+}
+
+// Observe records a request that took d and failed with err, if not nil.
+func (t *Tracker) Observe(d time.Duration, err error) {
+	bad := err != nil && t.isBad(err) || t.objective.Latency > 0 && d > t.objective.Latency
+	if t.requests != nil {
+		outcome := "good"
+		if bad {
+			outcome = "bad"
+		}
+		t.requests.With("outcome", outcome).Add(1)
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	now := t.timeNow()
+	for _, w := range t.windows {
+		if bad {
+			w.Add(now, 0, 1)
+		} else {
+			w.Add(now, 1, 0)
+		}
+		if t.burnRate != nil {
+			t.burnRate.With("window", w.name).Set(t.burn(w.Counts(now)))
+		}
+	}
+}
+
+// BurnRate returns the burn rate over the window of the given size, i.e.
+// the fraction of bad requests over it divided by the error budget. It
+// returns 0 for windows the tracker doesn't have.
+func (t *Tracker) BurnRate(size time.Duration) float64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	now := t.timeNow()
+	for _, w := range t.windows {
+		if w.Size() == size {
+			return t.burn(w.Counts(now))
+		}
+	}
+	return 0
+}
+
+func (t *Tracker) burn(good, bad uint64) float64 {
+	total := good + bad
+	if total == 0 {
+		return 0
+	}
+	budget := 1 - t.objective.Target
+	if budget <= 0 {
+		if bad > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return float64(bad) / float64(total) / budget
+}
+
+// Signal returns a loadshed.Signal reporting the lowest of the burn rates
+// over the short and long windows, so that load is only shed while both
+// windows burn the budget too fast: the long window tells the burn is
+// significant, and the short one that it's still going on.
+func (t *Tracker) Signal(short, long time.Duration) loadshed.Signal {
+	return loadshed.SignalFunc(func() float64 {
+		return math.Min(t.BurnRate(short), t.BurnRate(long))
+	})
+}
+
+// Middleware returns an endpoint.Middleware observing every request with
+// the tracker.
+func Middleware[REQ any, RES any](t *Tracker) endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		return func(ctx context.Context, request REQ) (response RES, err error) {
+			defer func(begin time.Time) {
+				t.Observe(t.timeNow().Sub(begin), err)
+			}(t.timeNow())
+			return next(ctx, request)
+		}
+	}
+}
+
+// namedWindow counts the good and bad requests over a sliding window, and
+// names it for the burn rate gauge.
+type namedWindow struct {
+	*window.Window
+	name string
+}
+
+func newWindow(size time.Duration) *namedWindow {
+	return &namedWindow{Window: window.New(size), name: windowName(size)}
+}
+
+// windowName returns the shortest name of a window size, e.g. "5m" rather
+// than "5m0s".
+func windowName(size time.Duration) string {
+	name := size.String()
+	if strings.HasSuffix(name, "m0s") {
+		name = strings.TrimSuffix(name, "0s")
+	}
+	if strings.HasSuffix(name, "h0m") {
+		name = strings.TrimSuffix(name, "0m")
+	}
+	return name
+}
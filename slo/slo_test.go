@@ -0,0 +1,86 @@
+package slo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+)
+
+func TestTracker(t *testing.T) {
+	var (
+		now      = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		burnRate = metricstest.NewGauge("burn_rate")
+		requests = metricstest.NewCounter("requests")
+		tracker  = NewTracker(Objective{Target: 0.99, Latency: time.Second},
+			Windows(5*time.Minute, time.Hour),
+			BurnRateGauge(burnRate),
+			RequestCounter(requests),
+		)
+	)
+	tracker.timeNow = func() time.Time { return now }
+
+	// 1 bad request out of 10 is 10 times the budget of 1%.
+	for i := 0; i < 8; i++ {
+		tracker.Observe(10*time.Millisecond, nil)
+	}
+	tracker.Observe(2*time.Second, nil)
+	tracker.Observe(10*time.Millisecond, context.Canceled)
+	for _, size := range []time.Duration{5 * time.Minute, time.Hour} {
+		if want, have := 10.0, tracker.BurnRate(size); !approx(want, have) {
+			t.Errorf("%s: want %v, have %v", size, want, have)
+		}
+	}
+	if want, have := 10.0, burnRate.ValueWith("window", "5m"); !approx(want, have) {
+		t.Errorf("gauge: want %v, have %v", want, have)
+	}
+	if want, have := 1.0, requests.ValueWith("outcome", "bad"); want != have {
+		t.Errorf("bad requests: want %v, have %v", want, have)
+	}
+
+	// The bad request leaves the short window, not the long one.
+	now = now.Add(10 * time.Minute)
+	tracker.Observe(10*time.Millisecond, nil)
+	if want, have := 0.0, tracker.BurnRate(5*time.Minute); want != have {
+		t.Errorf("5m: want %v, have %v", want, have)
+	}
+	if want, have := 1/11.0/0.01, tracker.BurnRate(time.Hour); !approx(want, have) {
+		t.Errorf("1h: want %v, have %v", want, have)
+	}
+	if want, have := 0.0, tracker.Signal(5*time.Minute, time.Hour).Load(); want != have {
+		t.Errorf("signal: want %v, have %v", want, have)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tracker := NewTracker(Objective{Target: 0.9}, Windows(time.Minute))
+	e := Middleware[error, struct{}](tracker)(func(_ context.Context, err error) (struct{}, error) {
+		return struct{}{}, err
+	})
+	e(context.Background(), nil)
+	e(context.Background(), errors.New("boom"))
+	if want, have := 5.0, tracker.BurnRate(time.Minute); !approx(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestWindowName(t *testing.T) {
+	for size, want := range map[time.Duration]string{
+		30 * time.Second: "30s",
+		5 * time.Minute:  "5m",
+		time.Hour:        "1h",
+		90 * time.Minute: "1h30m",
+		72 * time.Hour:   "72h",
+	} {
+		if have := windowName(size); want != have {
+			t.Errorf("%s: want %q, have %q", size, want, have)
+		}
+	}
+}
+
+func approx(want, have float64) bool {
+	d := want - have
+	return d < 1e-9 && d > -1e-9
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+)
+
+// DebugHeader is the HTTP header that forces a request to be sampled by every
+// tracer it passes through, regardless of sampler decisions. It's meant for
+// debugging specific production requests, e.g. with
+//
+//	curl -H 'X-Debug-Trace: 1' https://api.example.com/...
+//
+// Any value that strconv.ParseBool accepts as true enables it.
+//
+// Since every sampled request costs the tracing backend, the header is only
+// honoured by servers that opt in, by installing the DebugToContext request
+// func of their transport package before their tracing middleware. Services
+// exposed to untrusted clients should only opt in with a validator, e.g. one
+// checking that the caller is an operator, or behind a trusted edge that
+// strips the header from external requests.
+const DebugHeader = "X-Debug-Trace"
+
+// DebugMetadataKey is the gRPC metadata counterpart of DebugHeader.
+const DebugMetadataKey = "x-debug-trace"
+
+type forceSampleKey struct{}
+
+// WithForceSample returns a context in which tracers sample every span,
+// overriding their samplers. The flag is propagated to downstream services by
+// the tracing client middlewares, or by the ContextToDebug request funcs of
+// the HTTP and gRPC transports.
+func WithForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+// ForceSampled reports whether spans started from ctx must be sampled.
+func ForceSampled(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceSampleKey{}).(bool)
+	return forced
+}
+
+// DebugRequested reports whether a DebugHeader or DebugMetadataKey value asks
+// for the request to be sampled.
+func DebugRequested(value string) bool {
+	debug, err := strconv.ParseBool(value)
+	return err == nil && debug
+}
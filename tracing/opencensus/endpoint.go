@@ -37,7 +37,7 @@ func TraceEndpoint[REQ any, RES any](name string, options ...EndpointOption) end
 				}
 			}
 
-			ctx, span := trace.StartSpan(ctx, name)
+			ctx, span := trace.StartSpan(ctx, name, trace.WithSampler(sampler(ctx, nil)))
 			if len(cfg.Attributes) > 0 {
 				span.AddAttributes(cfg.Attributes...)
 			}
@@ -50,35 +50,55 @@ func TraceEndpoint[REQ any, RES any](name string, options ...EndpointOption) end
 			}
 
 			defer func() {
-				if err != nil {
-					if lberr, ok := err.(lb.RetryError); ok {
-						// handle errors originating from lb.Retry
-						attrs := make([]trace.Attribute, 0, len(lberr.RawErrors))
-						for idx, rawErr := range lberr.RawErrors {
-							attrs = append(attrs, trace.StringAttribute(
-								"gokit.retry.error."+strconv.Itoa(idx+1), rawErr.Error(),
-							))
-						}
-						span.AddAttributes(attrs...)
-						span.SetStatus(trace.Status{
-							Code:    trace.StatusCodeUnknown,
-							Message: lberr.Final.Error(),
-						})
-						return
-					}
-					// generic error
-					span.SetStatus(trace.Status{
-						Code:    trace.StatusCodeUnknown,
-						Message: err.Error(),
-					})
+				failure := cfg.failure(response, err)
+				if failure == nil {
+					// no errors identified
+					span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
 					return
 				}
 
-				// no errors identified
-				span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+				if lberr, ok := failure.(lb.RetryError); ok {
+					// handle errors originating from lb.Retry
+					attrs := make([]trace.Attribute, 0, len(lberr.RawErrors))
+					for idx, rawErr := range lberr.RawErrors {
+						attrs = append(attrs, trace.StringAttribute(
+							"gokit.retry.error."+strconv.Itoa(idx+1), rawErr.Error(),
+						))
+					}
+					span.AddAttributes(attrs...)
+					failure = lberr.Final
+				}
+
+				if cfg.GetErrorStatus != nil {
+					span.SetStatus(cfg.GetErrorStatus(failure))
+					return
+				}
+				span.SetStatus(trace.Status{
+					Code:    trace.StatusCodeUnknown,
+					Message: failure.Error(),
+				})
 			}()
 			response, err = next(ctx, request)
 			return
 		}
 	}
 }
+
+// failure returns the error marking the span as errored, if any: the endpoint
+// error if IsError accepts it, or else the business error carried by the
+// response if IsBusinessError accepts it.
+func (o *EndpointOptions) failure(response interface{}, err error) error {
+	if err != nil {
+		if o.IsError == nil || o.IsError(err) {
+			return err
+		}
+		return nil
+	}
+	if o.IgnoreBusinessError || o.IsBusinessError == nil {
+		return nil
+	}
+	if failure := endpoint.Failed(response); failure != nil && o.IsBusinessError(failure) {
+		return failure
+	}
+	return nil
+}
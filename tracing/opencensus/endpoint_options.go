@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"go.opencensus.io/trace"
+
+	"github.com/a69/kit.go/endpoint"
 )
 
 // EndpointOptions holds the options for tracing an endpoint
@@ -25,6 +27,22 @@ type EndpointOptions struct {
 	// GetAttributes is an optional function that can extract trace attributes
 	// from the context and add them to the span.
 	GetAttributes func(ctx context.Context) []trace.Attribute
+
+	// IsError is an optional function that reports whether an error returned
+	// by the endpoint marks the span as errored. Expected errors, like "not
+	// found", can be left out to keep error rates meaningful. If nil, every
+	// error does.
+	IsError endpoint.Classifier
+
+	// IsBusinessError is an optional function that reports whether a business
+	// error, identified through the endpoint.Failer interface, marks the span
+	// as errored. If nil, or if IgnoreBusinessError is set, none does.
+	IsBusinessError endpoint.Classifier
+
+	// GetErrorStatus is an optional function that returns the status of a
+	// span marked as errored. If nil, the status has the unknown code and the
+	// error message.
+	GetErrorStatus func(err error) trace.Status
 }
 
 // EndpointOption allows for functional options to our OpenCensus endpoint
@@ -68,3 +86,28 @@ func WithSpanAttributes(fn func(ctx context.Context) []trace.Attribute) Endpoint
 		o.GetAttributes = fn
 	}
 }
+
+// WithErrorClassifier sets the function that reports whether an error returned
+// by the endpoint marks the span as errored.
+func WithErrorClassifier(isError endpoint.Classifier) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.IsError = isError
+	}
+}
+
+// WithBusinessErrorClassifier sets the function that reports whether a
+// business error, identified through the endpoint.Failer interface, marks the
+// span as errored.
+func WithBusinessErrorClassifier(isError endpoint.Classifier) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.IsBusinessError = isError
+	}
+}
+
+// WithErrorStatus sets the function that returns the status of a span marked
+// as errored, e.g. to map domain errors to OpenCensus status codes.
+func WithErrorStatus(fn func(err error) trace.Status) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.GetErrorStatus = fn
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/sd"
 	"github.com/a69/kit.go/sd/lb"
+	"github.com/a69/kit.go/tracing"
 	"github.com/a69/kit.go/tracing/opencensus"
 )
 
@@ -184,3 +185,63 @@ func TestTraceEndpoint(t *testing.T) {
 		t.Fatalf("incorrect attribute count, wanted %d, got %d", want, have)
 	}
 }
+
+func TestTraceEndpointForceSample(t *testing.T) {
+	e := &recordingExporter{}
+	trace.RegisterExporter(e)
+	defer trace.UnregisterExporter(e)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	mw := opencensus.TraceEndpoint[any, any](span1)
+	mw(endpoint.Nop[any, any])(context.Background(), nil)
+	if want, have := 0, len(e.Flush()); want != have {
+		t.Fatalf("unforced: want %d spans, have %d", want, have)
+	}
+
+	mw(endpoint.Nop[any, any])(tracing.WithForceSample(context.Background()), nil)
+	if want, have := 1, len(e.Flush()); want != have {
+		t.Fatalf("forced: want %d spans, have %d", want, have)
+	}
+}
+
+func TestTraceEndpointErrorClassification(t *testing.T) {
+	ctx := context.Background()
+
+	e := &recordingExporter{}
+	trace.RegisterExporter(e)
+	defer trace.UnregisterExporter(e)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	var (
+		isError         = func(err error) bool { return err != err1 }
+		isBusinessError = func(err error) bool { return err == err3 }
+		errorStatus     = func(err error) trace.Status {
+			return trace.Status{Code: trace.StatusCodeUnavailable, Message: err.Error()}
+		}
+		options = []opencensus.EndpointOption{
+			opencensus.WithErrorClassifier(isError),
+			opencensus.WithBusinessErrorClassifier(isBusinessError),
+			opencensus.WithErrorStatus(errorStatus),
+		}
+	)
+
+	for _, tc := range []struct {
+		req  interface{}
+		want int32
+	}{
+		{err1, trace.StatusCodeOK},                               // expected error
+		{err2, trace.StatusCodeUnavailable},                      // unexpected error
+		{failedResponse{err: err3}, trace.StatusCodeUnavailable}, // business error
+		{failedResponse{err: err4}, trace.StatusCodeOK},          // other business error
+	} {
+		opencensus.TraceEndpoint[any, any]("classified", options...)(passEndpoint)(ctx, tc.req)
+		spans := e.Flush()
+		if want, have := 1, len(spans); want != have {
+			t.Fatalf("incorrect number of spans, wanted %d, got %d", want, have)
+		}
+		if want, have := tc.want, spans[0].Code; want != have {
+			t.Errorf("%v: incorrect status code, wanted %d, got %d", tc.req, want, have)
+		}
+	}
+}
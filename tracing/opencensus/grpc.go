@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/a69/kit.go/tracing"
 	kitgrpc "github.com/a69/kit.go/transport/grpc"
 )
 
@@ -35,13 +36,16 @@ func GRPCClientTrace[REQ any, RES any](options ...TracerOption) kitgrpc.ClientOp
 			ctx, span := trace.StartSpan(
 				ctx,
 				name,
-				trace.WithSampler(cfg.Sampler),
+				trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				trace.WithSpanKind(trace.SpanKindClient),
 			)
 
 			if !cfg.Public {
 				traceContextBinary := string(propagation.Binary(span.SpanContext()))
 				(*md)[propagationKey] = append((*md)[propagationKey], traceContextBinary)
+				if tracing.ForceSampled(ctx) {
+					md.Set(tracing.DebugMetadataKey, "1")
+				}
 			}
 
 			return ctx
@@ -105,7 +109,7 @@ func GRPCServerTrace[REQ any, RES any](options ...TracerOption) kitgrpc.ServerOp
 						name,
 						parentContext,
 						trace.WithSpanKind(trace.SpanKindServer),
-						trace.WithSampler(cfg.Sampler),
+						trace.WithSampler(sampler(ctx, cfg.Sampler)),
 					)
 					return ctx
 				}
@@ -114,7 +118,7 @@ func GRPCServerTrace[REQ any, RES any](options ...TracerOption) kitgrpc.ServerOp
 				ctx,
 				name,
 				trace.WithSpanKind(trace.SpanKindServer),
-				trace.WithSampler(cfg.Sampler),
+				trace.WithSampler(sampler(ctx, cfg.Sampler)),
 			)
 			if ok {
 				span.AddLink(
@@ -8,6 +8,7 @@ import (
 	"go.opencensus.io/plugin/ochttp/propagation/b3"
 	"go.opencensus.io/trace"
 
+	"github.com/a69/kit.go/tracing"
 	kithttp "github.com/a69/kit.go/transport/http"
 )
 
@@ -37,7 +38,7 @@ func HTTPClientTrace[REQ any, RES any](options ...TracerOption) kithttp.ClientOp
 			ctx, span := trace.StartSpan(
 				ctx,
 				name,
-				trace.WithSampler(cfg.Sampler),
+				trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				trace.WithSpanKind(trace.SpanKindClient),
 			)
 
@@ -50,6 +51,9 @@ func HTTPClientTrace[REQ any, RES any](options ...TracerOption) kithttp.ClientOp
 
 			if !cfg.Public {
 				cfg.HTTPPropagate.SpanContextToRequest(span.SpanContext(), req)
+				if tracing.ForceSampled(ctx) {
+					req.Header.Set(tracing.DebugHeader, "1")
+				}
 			}
 
 			return ctx
@@ -110,9 +114,17 @@ func HTTPServerTrace[REQ any, RES any](options ...TracerOption) kithttp.ServerOp
 				ok          bool
 			)
 
-			if cfg.Name != "" {
+			var route string
+			if cfg.Route != nil {
+				route = cfg.Route(req)
+			}
+
+			switch {
+			case cfg.Name != "":
 				name = cfg.Name
-			} else {
+			case route != "":
+				name = req.Method + " " + route
+			default:
 				name = req.Method + " " + req.URL.Path
 			}
 
@@ -123,14 +135,14 @@ func HTTPServerTrace[REQ any, RES any](options ...TracerOption) kithttp.ServerOp
 					name,
 					spanContext,
 					trace.WithSpanKind(trace.SpanKindServer),
-					trace.WithSampler(cfg.Sampler),
+					trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				)
 			} else {
 				ctx, span = trace.StartSpan(
 					ctx,
 					name,
 					trace.WithSpanKind(trace.SpanKindServer),
-					trace.WithSampler(cfg.Sampler),
+					trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				)
 				if ok {
 					span.AddLink(trace.Link{
@@ -146,6 +158,9 @@ func HTTPServerTrace[REQ any, RES any](options ...TracerOption) kithttp.ServerOp
 				trace.StringAttribute(ochttp.MethodAttribute, req.Method),
 				trace.StringAttribute(ochttp.PathAttribute, req.URL.Path),
 			)
+			if route != "" {
+				span.AddAttributes(trace.StringAttribute("http.route", route))
+			}
 
 			return ctx
 		},
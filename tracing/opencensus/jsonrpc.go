@@ -8,6 +8,7 @@ import (
 	"go.opencensus.io/plugin/ochttp/propagation/b3"
 	"go.opencensus.io/trace"
 
+	"github.com/a69/kit.go/tracing"
 	kithttp "github.com/a69/kit.go/transport/http"
 	jsonrpc "github.com/a69/kit.go/transport/http/jsonrpc"
 )
@@ -38,7 +39,7 @@ func JSONRPCClientTrace[REQ any, RES any](options ...TracerOption) jsonrpc.Clien
 			ctx, span := trace.StartSpan(
 				ctx,
 				name,
-				trace.WithSampler(cfg.Sampler),
+				trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				trace.WithSpanKind(trace.SpanKindClient),
 			)
 
@@ -51,6 +52,9 @@ func JSONRPCClientTrace[REQ any, RES any](options ...TracerOption) jsonrpc.Clien
 
 			if !cfg.Public {
 				cfg.HTTPPropagate.SpanContextToRequest(span.SpanContext(), req)
+				if tracing.ForceSampled(ctx) {
+					req.Header.Set(tracing.DebugHeader, "1")
+				}
 			}
 
 			return ctx
@@ -129,14 +133,14 @@ func JSONRPCServerTrace(options ...TracerOption) jsonrpc.ServerOption {
 					name,
 					spanContext,
 					trace.WithSpanKind(trace.SpanKindServer),
-					trace.WithSampler(cfg.Sampler),
+					trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				)
 			} else {
 				ctx, span = trace.StartSpan(
 					ctx,
 					name,
 					trace.WithSpanKind(trace.SpanKindServer),
-					trace.WithSampler(cfg.Sampler),
+					trace.WithSampler(sampler(ctx, cfg.Sampler)),
 				)
 				if ok {
 					span.AddLink(trace.Link{
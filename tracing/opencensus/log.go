@@ -0,0 +1,19 @@
+package opencensus
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+)
+
+// LogKeyvals returns the IDs of the span in ctx, if any, as "trace_id" and
+// "span_id" keyvals. It's a log.ContextFunc, correlating log records with
+// traces.
+func LogKeyvals(ctx context.Context) []interface{} {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	sc := span.SpanContext()
+	return []interface{}{"trace_id", sc.TraceID.String(), "span_id", sc.SpanID.String()}
+}
@@ -0,0 +1,26 @@
+package opencensus_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opencensus.io/trace"
+
+	"github.com/a69/kit.go/tracing/opencensus"
+)
+
+func TestLogKeyvals(t *testing.T) {
+	if have := opencensus.LogKeyvals(context.Background()); have != nil {
+		t.Errorf("want no keyvals without a span, have %v", have)
+	}
+
+	ctx, span := trace.StartSpan(context.Background(), "test")
+	defer span.End()
+
+	sc := span.SpanContext()
+	want := []interface{}{"trace_id", sc.TraceID.String(), "span_id", sc.SpanID.String()}
+	if have := opencensus.LogKeyvals(ctx); fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,28 @@
+package opencensus
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"github.com/a69/kit.go/transport"
+)
+
+// PhaseAnnotations returns a transport.PhaseFunc that adds an annotation to
+// the request's span for every phase, with the phase's duration in
+// microseconds and its error, if any. Use it with the ServerPhase option of
+// the HTTP and gRPC servers, together with HTTPServerTrace or GRPCServerTrace.
+func PhaseAnnotations() transport.PhaseFunc {
+	return func(ctx context.Context, phase transport.Phase, _ time.Time, took time.Duration, err error) {
+		span := trace.FromContext(ctx)
+		if span == nil {
+			return
+		}
+		attrs := []trace.Attribute{trace.Int64Attribute("duration_us", took.Microseconds())}
+		if err != nil {
+			attrs = append(attrs, trace.StringAttribute("error", err.Error()))
+		}
+		span.Annotate(attrs, string(phase))
+	}
+}
@@ -0,0 +1,43 @@
+package opencensus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+
+	"github.com/a69/kit.go/tracing/opencensus"
+	"github.com/a69/kit.go/transport"
+)
+
+func TestPhaseAnnotations(t *testing.T) {
+	e := &recordingExporter{}
+	trace.RegisterExporter(e)
+	defer trace.UnregisterExporter(e)
+
+	ctx, span := trace.StartSpan(context.Background(), "phases", trace.WithSampler(trace.AlwaysSample()))
+	f := opencensus.PhaseAnnotations()
+	f(ctx, transport.PhaseDecode, time.Now(), 1500*time.Microsecond, nil)
+	f(ctx, transport.PhaseEndpoint, time.Now(), time.Millisecond, errors.New("dang"))
+	span.End()
+
+	spans := e.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	annotations := spans[0].Annotations
+	if want, have := 2, len(annotations); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	if want, have := "decode", annotations[0].Message; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := int64(1500), annotations[0].Attributes["duration_us"]; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := "dang", annotations[1].Attributes["error"]; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -1,9 +1,13 @@
 package opencensus
 
 import (
+	"context"
+
 	"go.opencensus.io/plugin/ochttp/propagation/b3"
 	"go.opencensus.io/trace"
 	"go.opencensus.io/trace/propagation"
+
+	"github.com/a69/kit.go/tracing"
 )
 
 // defaultHTTPPropagate holds OpenCensus' default HTTP propagation format which
@@ -61,10 +65,31 @@ func WithHTTPPropagation(p propagation.HTTPFormat) TracerOption {
 	}
 }
 
+// WithRouteName names HTTP server spans after the route the request matched,
+// as returned by the RouteFunc, e.g. "GET /profiles/{id}", rather than after
+// the request method and path. The route is also set as the "http.route"
+// attribute. WithName takes precedence, and requests without a known route
+// get the default name. If used on a non HTTP transport this is a noop.
+func WithRouteName(route tracing.RouteFunc) TracerOption {
+	return func(o *TracerOptions) {
+		o.Route = route
+	}
+}
+
 // TracerOptions holds configuration for our tracing middlewares
 type TracerOptions struct {
 	Sampler       trace.Sampler
 	Name          string
 	Public        bool
 	HTTPPropagate propagation.HTTPFormat
+	Route         tracing.RouteFunc
+}
+
+// sampler returns the sampler to use for a span started from ctx: s, unless
+// sampling is forced with tracing.WithForceSample.
+func sampler(ctx context.Context, s trace.Sampler) trace.Sampler {
+	if tracing.ForceSampled(ctx) {
+		return trace.AlwaysSample()
+	}
+	return s
 }
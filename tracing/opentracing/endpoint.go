@@ -10,6 +10,7 @@ import (
 
 	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/sd/lb"
+	"github.com/a69/kit.go/tracing"
 )
 
 // TraceEndpoint returns a Middleware that wraps the `next` Endpoint in an
@@ -38,10 +39,10 @@ func TraceEndpoint[REQ any, RES any](tracer opentracing.Tracer, operationName st
 			if parentSpan := opentracing.SpanFromContext(ctx); parentSpan != nil {
 				span = tracer.StartSpan(
 					operationName,
-					opentracing.ChildOf(parentSpan.Context()),
+					withSampling(ctx, opentracing.ChildOf(parentSpan.Context()))...,
 				)
 			} else {
-				span = tracer.StartSpan(operationName)
+				span = tracer.StartSpan(operationName, withSampling(ctx)...)
 			}
 			defer span.Finish()
 
@@ -54,29 +55,35 @@ func TraceEndpoint[REQ any, RES any](tracer opentracing.Tracer, operationName st
 			ctx = opentracing.ContextWithSpan(ctx, span)
 
 			defer func() {
-				if err != nil {
-					if lbErr, ok := err.(lb.RetryError); ok {
-						// handle errors originating from lb.Retry
-						fields := make([]otlog.Field, 0, len(lbErr.RawErrors))
-						for idx, rawErr := range lbErr.RawErrors {
-							fields = append(fields, otlog.String(
-								"gokit.retry.error."+strconv.Itoa(idx+1), rawErr.Error(),
-							))
-						}
-
-						otext.LogError(span, lbErr, fields...)
-
-						return
+				failure := cfg.failure(response, err)
+				if failure == nil {
+					return
+				}
+
+				if cfg.GetErrorTags != nil {
+					applyTags(span, cfg.GetErrorTags(failure))
+				}
+
+				if lbErr, ok := failure.(lb.RetryError); ok {
+					// handle errors originating from lb.Retry
+					fields := make([]otlog.Field, 0, len(lbErr.RawErrors))
+					for idx, rawErr := range lbErr.RawErrors {
+						fields = append(fields, otlog.String(
+							"gokit.retry.error."+strconv.Itoa(idx+1), rawErr.Error(),
+						))
 					}
 
-					// generic error
-					otext.LogError(span, err)
+					otext.LogError(span, lbErr, fields...)
 
 					return
 				}
+
+				// generic error
+				otext.LogError(span, failure)
 			}()
 
-			return next(ctx, request)
+			response, err = next(ctx, request)
+			return response, err
 		}
 	}
 }
@@ -106,3 +113,12 @@ func applyTags(span opentracing.Span, tags opentracing.Tags) {
 		span.SetTag(key, value)
 	}
 }
+
+// withSampling adds a sampling priority tag to opts if sampling is forced in
+// ctx with tracing.WithForceSample.
+func withSampling(ctx context.Context, opts ...opentracing.StartSpanOption) []opentracing.StartSpanOption {
+	if tracing.ForceSampled(ctx) {
+		opts = append(opts, opentracing.Tag{Key: string(otext.SamplingPriority), Value: uint16(1)})
+	}
+	return opts
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/opentracing/opentracing-go"
+
+	"github.com/a69/kit.go/endpoint"
 )
 
 // EndpointOptions holds the options for tracing an endpoint
@@ -25,6 +27,21 @@ type EndpointOptions struct {
 	// GetTags is an optional function that can extract tags
 	// from the context and add them to the span.
 	GetTags func(ctx context.Context) opentracing.Tags
+
+	// IsError is an optional function that reports whether an error returned
+	// by the endpoint marks the span as errored. Expected errors, like "not
+	// found", can be left out to keep error rates meaningful. If nil, every
+	// error does.
+	IsError endpoint.Classifier
+
+	// IsBusinessError is an optional function that reports whether a business
+	// error, identified through the endpoint.Failer interface, marks the span
+	// as errored. If nil, or if IgnoreBusinessError is set, none does.
+	IsBusinessError endpoint.Classifier
+
+	// GetErrorTags is an optional function that returns additional tags to set
+	// on a span marked as errored, e.g. an error code.
+	GetErrorTags func(err error) opentracing.Tags
 }
 
 // EndpointOption allows for functional options to endpoint tracing middleware.
@@ -72,3 +89,47 @@ func WithTagsFunc(getTags func(ctx context.Context) opentracing.Tags) EndpointOp
 		o.GetTags = getTags
 	}
 }
+
+// WithErrorClassifier sets the function that reports whether an error returned
+// by the endpoint marks the span as errored.
+func WithErrorClassifier(isError endpoint.Classifier) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.IsError = isError
+	}
+}
+
+// WithBusinessErrorClassifier sets the function that reports whether a
+// business error, identified through the endpoint.Failer interface, marks the
+// span as errored.
+func WithBusinessErrorClassifier(isError endpoint.Classifier) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.IsBusinessError = isError
+	}
+}
+
+// WithErrorTagsFunc sets the function that returns additional tags to set on a
+// span marked as errored.
+func WithErrorTagsFunc(getErrorTags func(err error) opentracing.Tags) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.GetErrorTags = getErrorTags
+	}
+}
+
+// failure returns the error marking the span as errored, if any: the endpoint
+// error if IsError accepts it, or else the business error carried by the
+// response if IsBusinessError accepts it.
+func (o *EndpointOptions) failure(response interface{}, err error) error {
+	if err != nil {
+		if o.IsError == nil || o.IsError(err) {
+			return err
+		}
+		return nil
+	}
+	if o.IgnoreBusinessError || o.IsBusinessError == nil {
+		return nil
+	}
+	if failure := endpoint.Failed(response); failure != nil && o.IsBusinessError(failure) {
+		return failure
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/sd"
 	"github.com/a69/kit.go/sd/lb"
+	"github.com/a69/kit.go/tracing"
 	kitot "github.com/a69/kit.go/tracing/opentracing"
 )
 
@@ -392,3 +393,59 @@ func TestTraceClient(t *testing.T) {
 		t.Fatalf("Want %q, have %q", want, have)
 	}
 }
+
+func TestTraceEndpointForceSample(t *testing.T) {
+	tracer := mocktracer.New()
+	tracedEndpoint := kitot.TraceEndpoint[struct{}, struct{}](tracer, "testOp")(endpoint.Nop[struct{}, struct{}])
+	if _, err := tracedEndpoint(tracing.WithForceSample(context.Background()), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	finishedSpans := tracer.FinishedSpans()
+	if want, have := 1, len(finishedSpans); want != have {
+		t.Fatalf("Want %v span(s), found %v", want, have)
+	}
+	if want, have := uint16(1), finishedSpans[0].Tag(string(otext.SamplingPriority)); want != have {
+		t.Errorf("Want sampling priority %v, have %v", want, have)
+	}
+}
+
+func TestTraceEndpointErrorClassification(t *testing.T) {
+	tracer := mocktracer.New()
+	errNotFound := errors.New("not found")
+
+	options := []kitot.EndpointOption{
+		kitot.WithErrorClassifier(func(err error) bool { return err != errNotFound }),
+		kitot.WithBusinessErrorClassifier(func(err error) bool { return err == err2 }),
+		kitot.WithErrorTagsFunc(func(err error) opentracing.Tags {
+			return opentracing.Tags{"error.kind": "test"}
+		}),
+	}
+
+	for _, tc := range []struct {
+		response interface{}
+		err      error
+		want     bool
+	}{
+		{nil, errNotFound, false},
+		{nil, err1, true},
+		{failedResponse{err: err2}, nil, true},
+		{failedResponse{err: err3}, nil, false},
+	} {
+		tracer.Reset()
+		ep := func(context.Context, interface{}) (interface{}, error) { return tc.response, tc.err }
+		kitot.TraceEndpoint[interface{}, interface{}](tracer, "classified", options...)(ep)(context.Background(), nil)
+
+		finishedSpans := tracer.FinishedSpans()
+		if want, have := 1, len(finishedSpans); want != have {
+			t.Fatalf("Want %v span(s), found %v", want, have)
+		}
+		span := finishedSpans[0]
+		if want, have := tc.want, span.Tag(string(otext.Error)) == true; want != have {
+			t.Errorf("%v %v: want errored %v, have %v", tc.response, tc.err, want, have)
+		}
+		if want, have := tc.want, span.Tag("error.kind") == "test"; want != have {
+			t.Errorf("%v %v: want error tags %v, have %v", tc.response, tc.err, want, have)
+		}
+	}
+}
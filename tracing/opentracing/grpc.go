@@ -9,6 +9,7 @@ import (
 	"github.com/opentracing/opentracing-go/ext"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/a69/kit.go/tracing"
 	"github.com/go-kit/log"
 )
 
@@ -18,6 +19,10 @@ import (
 func ContextToGRPC(tracer opentracing.Tracer, logger log.Logger) func(ctx context.Context, md *metadata.MD) context.Context {
 	return func(ctx context.Context, md *metadata.MD) context.Context {
 		if span := opentracing.SpanFromContext(ctx); span != nil {
+			if tracing.ForceSampled(ctx) {
+				md.Set(tracing.DebugMetadataKey, "1")
+			}
+
 			// There's nothing we can do with an error here.
 			if err := tracer.Inject(span.Context(), opentracing.TextMap, metadataReaderWriter{md}); err != nil {
 				logger.Log("err", err)
@@ -39,7 +44,7 @@ func GRPCToContext(tracer opentracing.Tracer, operationName string, logger log.L
 		if err != nil && err != opentracing.ErrSpanContextNotFound {
 			logger.Log("err", err)
 		}
-		span = tracer.StartSpan(operationName, ext.RPCServerOption(wireContext))
+		span = tracer.StartSpan(operationName, withSampling(ctx, ext.RPCServerOption(wireContext))...)
 		return opentracing.ContextWithSpan(ctx, span)
 	}
 }
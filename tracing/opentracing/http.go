@@ -9,6 +9,7 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 
+	"github.com/a69/kit.go/tracing"
 	kithttp "github.com/a69/kit.go/transport/http"
 	"github.com/go-kit/log"
 )
@@ -33,6 +34,10 @@ func ContextToHTTP(tracer opentracing.Tracer, logger log.Logger) kithttp.Request
 				ext.PeerHostname.Set(span, req.URL.Host)
 			}
 
+			if tracing.ForceSampled(ctx) {
+				req.Header.Set(tracing.DebugHeader, "1")
+			}
+
 			// There's nothing we can do with any errors here.
 			if err = tracer.Inject(
 				span.Context(),
@@ -63,7 +68,7 @@ func HTTPToContext(tracer opentracing.Tracer, operationName string, logger log.L
 			logger.Log("err", err)
 		}
 
-		span = tracer.StartSpan(operationName, ext.RPCServerOption(wireContext))
+		span = tracer.StartSpan(operationName, withSampling(ctx, ext.RPCServerOption(wireContext))...)
 		ext.HTTPMethod.Set(span, req.Method)
 		ext.HTTPUrl.Set(span, req.URL.String())
 		return opentracing.ContextWithSpan(ctx, span)
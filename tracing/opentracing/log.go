@@ -0,0 +1,54 @@
+package opentracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// LogKeyvals returns a log.ContextFunc adding the IDs of the span in the
+// context, if any, as "trace_id" and "span_id" keyvals, which correlates log
+// records with traces.
+//
+// The OpenTracing API doesn't expose span IDs, so they're read from the
+// headers the tracer injects the span context into. The B3 (Zipkin), W3C
+// traceparent, Jaeger and basictracer formats are recognized. For other
+// tracers, no keyvals are added.
+func LogKeyvals(tracer opentracing.Tracer) func(ctx context.Context) []interface{} {
+	return func(ctx context.Context) []interface{} {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return nil
+		}
+		carrier := opentracing.TextMapCarrier{}
+		if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+			return nil
+		}
+		headers := make(map[string]string, len(carrier))
+		for k, v := range carrier {
+			headers[strings.ToLower(k)] = v
+		}
+		traceID, spanID := spanIDs(headers)
+		if traceID == "" {
+			return nil
+		}
+		return []interface{}{"trace_id", traceID, "span_id", spanID}
+	}
+}
+
+func spanIDs(headers map[string]string) (traceID, spanID string) {
+	if traceID := headers["x-b3-traceid"]; traceID != "" {
+		return traceID, headers["x-b3-spanid"]
+	}
+	if parts := strings.Split(headers["traceparent"], "-"); len(parts) == 4 {
+		return parts[1], parts[2]
+	}
+	if parts := strings.Split(headers["uber-trace-id"], ":"); len(parts) == 4 {
+		return parts[0], parts[1]
+	}
+	if traceID := headers["ot-tracer-traceid"]; traceID != "" {
+		return traceID, headers["ot-tracer-spanid"]
+	}
+	return "", ""
+}
@@ -0,0 +1,45 @@
+package opentracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+
+	kitot "github.com/a69/kit.go/tracing/opentracing"
+)
+
+func TestLogKeyvals(t *testing.T) {
+	ztr, _ := zipkin.NewTracer(recorder.NewReporter())
+	tracer := zipkinot.Wrap(ztr)
+	keyvals := kitot.LogKeyvals(tracer)
+
+	if have := keyvals(context.Background()); have != nil {
+		t.Errorf("want no keyvals without a span, have %v", have)
+	}
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	sc := span.Context().(zipkinot.SpanContext)
+
+	have := keyvals(opentracing.ContextWithSpan(context.Background(), span))
+	want := []interface{}{"trace_id", sc.TraceID.String(), "span_id", sc.ID.String()}
+	if fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestLogKeyvalsUnknownFormat(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+
+	if have := kitot.LogKeyvals(tracer)(opentracing.ContextWithSpan(context.Background(), span)); have != nil {
+		t.Errorf("want no keyvals, have %v", have)
+	}
+}
@@ -0,0 +1,132 @@
+package opentracing
+
+import (
+	"context"
+	"net/http"
+
+	stdnats "github.com/nats-io/nats.go"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	stdamqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/tracing"
+	kitamqp "github.com/a69/kit.go/transport/amqp"
+	kitnats "github.com/a69/kit.go/transport/nats"
+	"github.com/go-kit/log"
+)
+
+// ContextToNATS returns a NATS RequestFunc that injects an OpenTracing Span
+// found in `ctx` into the headers of the outgoing message. If no such Span can
+// be found, the RequestFunc is a noop.
+func ContextToNATS(tracer opentracing.Tracer, logger log.Logger) kitnats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			ext.MessageBusDestination.Set(span, msg.Subject)
+			if msg.Header == nil {
+				msg.Header = stdnats.Header{}
+			}
+			if tracing.ForceSampled(ctx) {
+				msg.Header.Set(tracing.DebugHeader, "1")
+			}
+			// nats.Header has the same layout as http.Header.
+			if err := tracer.Inject(
+				span.Context(),
+				opentracing.HTTPHeaders,
+				opentracing.HTTPHeadersCarrier(http.Header(msg.Header)),
+			); err != nil {
+				logger.Log("err", err)
+			}
+		}
+		return ctx
+	}
+}
+
+// NATSToContext returns a NATS RequestFunc that tries to join with an
+// OpenTracing trace found in the headers of `msg` and starts a new consumer
+// Span called `operationName` accordingly. If no trace could be found, the
+// Span will be a trace root. The Span is incorporated in the returned Context
+// and can be retrieved with opentracing.SpanFromContext(ctx).
+func NATSToContext(tracer opentracing.Tracer, operationName string, logger log.Logger) kitnats.RequestFunc {
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		header := http.Header(msg.Header)
+		wireContext, err := tracer.Extract(
+			opentracing.HTTPHeaders,
+			opentracing.HTTPHeadersCarrier(header),
+		)
+		if err != nil && err != opentracing.ErrSpanContextNotFound {
+			logger.Log("err", err)
+		}
+
+		return startConsumerSpan(ctx, tracer, operationName, wireContext, msg.Subject)
+	}
+}
+
+// ContextToAMQP returns an AMQP RequestFunc that injects an OpenTracing Span
+// found in `ctx` into the headers of the outgoing publishing. If no such Span
+// can be found, the RequestFunc is a noop.
+func ContextToAMQP(tracer opentracing.Tracer, logger log.Logger) kitamqp.RequestFunc {
+	return func(ctx context.Context, pub *stdamqp.Publishing, _ *stdamqp.Delivery) context.Context {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			if pub.Headers == nil {
+				pub.Headers = stdamqp.Table{}
+			}
+			if tracing.ForceSampled(ctx) {
+				pub.Headers[tracing.DebugHeader] = "1"
+			}
+			if err := tracer.Inject(
+				span.Context(),
+				opentracing.TextMap,
+				amqpHeadersCarrier(pub.Headers),
+			); err != nil {
+				logger.Log("err", err)
+			}
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext returns an AMQP RequestFunc that tries to join with an
+// OpenTracing trace found in the headers of the delivery and starts a new
+// consumer Span called `operationName` accordingly. If no trace could be
+// found, the Span will be a trace root. The Span is incorporated in the
+// returned Context and can be retrieved with opentracing.SpanFromContext(ctx).
+func AMQPToContext(tracer opentracing.Tracer, operationName string, logger log.Logger) kitamqp.RequestFunc {
+	return func(ctx context.Context, _ *stdamqp.Publishing, deliv *stdamqp.Delivery) context.Context {
+		wireContext, err := tracer.Extract(
+			opentracing.TextMap,
+			amqpHeadersCarrier(deliv.Headers),
+		)
+		if err != nil && err != opentracing.ErrSpanContextNotFound {
+			logger.Log("err", err)
+		}
+
+		return startConsumerSpan(ctx, tracer, operationName, wireContext, deliv.RoutingKey)
+	}
+}
+
+func startConsumerSpan(ctx context.Context, tracer opentracing.Tracer, operationName string, wireContext opentracing.SpanContext, destination string) context.Context {
+	span := tracer.StartSpan(operationName, withSampling(ctx, opentracing.ChildOf(wireContext), ext.SpanKindConsumer)...)
+	ext.MessageBusDestination.Set(span, destination)
+	return opentracing.ContextWithSpan(ctx, span)
+}
+
+// amqpHeadersCarrier satisfies both TextMapWriter and TextMapReader, using
+// the string values of AMQP headers.
+type amqpHeadersCarrier stdamqp.Table
+
+// Set implements opentracing.TextMapWriter.
+func (c amqpHeadersCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+// ForeachKey implements opentracing.TextMapReader.
+func (c amqpHeadersCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		if s, ok := v.(string); ok {
+			if err := handler(k, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
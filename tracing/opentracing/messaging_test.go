@@ -0,0 +1,68 @@
+package opentracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	kitot "github.com/a69/kit.go/tracing/opentracing"
+	"github.com/go-kit/log"
+)
+
+func TestTraceNATSRoundtrip(t *testing.T) {
+	logger := log.NewNopLogger()
+	tracer := mocktracer.New()
+
+	beforeSpan := tracer.StartSpan("to_inject").(*mocktracer.MockSpan)
+	defer beforeSpan.Finish()
+	beforeCtx := opentracing.ContextWithSpan(context.Background(), beforeSpan)
+
+	msg := &nats.Msg{Subject: "orders.placed"}
+	kitot.ContextToNATS(tracer, logger)(beforeCtx, msg)
+
+	joinCtx := kitot.NATSToContext(tracer, "joined", logger)(context.Background(), msg)
+	checkJoined(t, beforeSpan, opentracing.SpanFromContext(joinCtx).(*mocktracer.MockSpan))
+}
+
+func TestTraceAMQPRoundtrip(t *testing.T) {
+	logger := log.NewNopLogger()
+	tracer := mocktracer.New()
+
+	beforeSpan := tracer.StartSpan("to_inject").(*mocktracer.MockSpan)
+	defer beforeSpan.Finish()
+	beforeCtx := opentracing.ContextWithSpan(context.Background(), beforeSpan)
+
+	pub := &amqp.Publishing{}
+	kitot.ContextToAMQP(tracer, logger)(beforeCtx, pub, nil)
+
+	deliv := &amqp.Delivery{RoutingKey: "orders.placed", Headers: pub.Headers}
+	joinCtx := kitot.AMQPToContext(tracer, "joined", logger)(context.Background(), &amqp.Publishing{}, deliv)
+	checkJoined(t, beforeSpan, opentracing.SpanFromContext(joinCtx).(*mocktracer.MockSpan))
+}
+
+func checkJoined(t *testing.T, beforeSpan, joinedSpan *mocktracer.MockSpan) {
+	t.Helper()
+	beforeContext := beforeSpan.Context().(mocktracer.MockSpanContext)
+	joinedContext := joinedSpan.Context().(mocktracer.MockSpanContext)
+
+	if want, have := beforeContext.TraceID, joinedContext.TraceID; want != have {
+		t.Errorf("Want TraceID %d, have %d", want, have)
+	}
+	if want, have := beforeContext.SpanID, joinedSpan.ParentID; want != have {
+		t.Errorf("Want ParentID %d, have %d", want, have)
+	}
+	if want, have := "joined", joinedSpan.OperationName; want != have {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+	if want, have := ext.SpanKindConsumerEnum, joinedSpan.Tag(string(ext.SpanKind)); want != have {
+		t.Errorf("Want %v, have %v", want, have)
+	}
+	if want, have := "orders.placed", joinedSpan.Tag(string(ext.MessageBusDestination)); want != have {
+		t.Errorf("Want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,32 @@
+package opentracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+
+	"github.com/a69/kit.go/transport"
+)
+
+// PhaseAnnotations returns a transport.PhaseFunc that logs an event on the
+// request's span for every phase, with the phase's duration and its error, if
+// any. Use it with the ServerPhase option of the HTTP and gRPC servers,
+// together with HTTPToContext or GRPCToContext.
+func PhaseAnnotations() transport.PhaseFunc {
+	return func(ctx context.Context, phase transport.Phase, start time.Time, took time.Duration, err error) {
+		span := opentracing.SpanFromContext(ctx)
+		if span == nil {
+			return
+		}
+		fields := []otlog.Field{
+			otlog.String("event", string(phase)),
+			otlog.Int64("duration_us", took.Microseconds()),
+		}
+		if err != nil {
+			fields = append(fields, otlog.Error(err))
+		}
+		span.LogFields(fields...)
+	}
+}
@@ -0,0 +1,52 @@
+package opentracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
+	kitot "github.com/a69/kit.go/tracing/opentracing"
+	"github.com/a69/kit.go/transport"
+)
+
+func TestPhaseAnnotations(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("phases").(*mocktracer.MockSpan)
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+
+	f := kitot.PhaseAnnotations()
+	f(context.Background(), transport.PhaseDecode, time.Now(), time.Millisecond, nil) // no span, no-op
+	f(ctx, transport.PhaseDecode, time.Now(), 1500*time.Microsecond, nil)
+	f(ctx, transport.PhaseEndpoint, time.Now(), time.Millisecond, errors.New("dang"))
+	span.Finish()
+
+	logs := span.Logs()
+	if want, have := 2, len(logs); want != have {
+		t.Fatalf("want %d logs, have %d", want, have)
+	}
+	fields := func(record mocktracer.MockLogRecord) map[string]interface{} {
+		m := map[string]interface{}{}
+		for _, f := range record.Fields {
+			m[f.Key] = f.ValueString
+		}
+		return m
+	}
+	decode, endpoint := fields(logs[0]), fields(logs[1])
+	for key, want := range map[string]string{"event": "decode", "duration_us": "1500"} {
+		if have := decode[key]; want != have {
+			t.Errorf("decode %s: want %q, have %q", key, want, have)
+		}
+	}
+	if _, ok := decode["error.object"]; ok {
+		t.Errorf("decode: want no error field")
+	}
+	for key, want := range map[string]string{"event": "endpoint", "duration_us": "1000", "error.object": "dang"} {
+		if have := endpoint[key]; want != have {
+			t.Errorf("endpoint %s: want %q, have %q", key, want, have)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	stdhttp "net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteFunc returns the route template an HTTP request was matched against,
+// e.g. "/profiles/{id}", or "" if it's unknown. Naming server spans after the
+// route rather than the request path keeps their number bounded, which most
+// tracing backends require to aggregate them.
+//
+// Routers not supported out of the box are easily adapted, e.g. chi versions
+// older than v5.2 with
+//
+//	func(r *http.Request) string {
+//		return chi.RouteContext(r.Context()).RoutePattern()
+//	}
+type RouteFunc func(r *stdhttp.Request) string
+
+// MuxRoute is a RouteFunc for requests routed by gorilla/mux.
+func MuxRoute(r *stdhttp.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
+
+// PatternRoute is a RouteFunc for requests routed by routers that set
+// http.Request.Pattern: the standard library's http.ServeMux, and chi since
+// v5.2. The method of method-specific patterns, like "GET /profiles/{id}", is
+// stripped.
+func PatternRoute(r *stdhttp.Request) string {
+	pattern := r.Pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i:], " \t")
+	}
+	return pattern
+}
@@ -0,0 +1,39 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/a69/kit.go/tracing"
+)
+
+func TestMuxRoute(t *testing.T) {
+	var have string
+	r := mux.NewRouter()
+	r.HandleFunc("/profiles/{id}", func(w http.ResponseWriter, r *http.Request) { have = tracing.MuxRoute(r) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/profiles/1234", nil))
+
+	if want := "/profiles/{id}"; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if have := tracing.MuxRoute(httptest.NewRequest(http.MethodGet, "/", nil)); have != "" {
+		t.Errorf("want no route, have %q", have)
+	}
+}
+
+func TestPatternRoute(t *testing.T) {
+	for _, pattern := range []string{"/profiles/{id}", "GET /profiles/{id}"} {
+		var have string
+		m := http.NewServeMux()
+		m.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) { have = tracing.PatternRoute(r) })
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/profiles/1234", nil))
+
+		if want := "/profiles/{id}"; want != have {
+			t.Errorf("%s: want %q, have %q", pattern, want, have)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/openzipkin/zipkin-go/model"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/tracing"
 )
 
 // TraceEndpoint returns an Endpoint middleware, tracing a Go kit endpoint.
@@ -20,6 +21,9 @@ func TraceEndpoint[REQ any, RES any](tracer *zipkin.Tracer, name string) endpoin
 			if parentSpan := zipkin.SpanFromContext(ctx); parentSpan != nil {
 				sc = parentSpan.Context()
 			}
+			if tracing.ForceSampled(ctx) {
+				sc.Debug = true
+			}
 			sp := tracer.StartSpan(name, zipkin.Parent(sc))
 			defer sp.Finish()
 
@@ -8,6 +8,7 @@ import (
 	"github.com/openzipkin/zipkin-go/reporter/recorder"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/tracing"
 	zipkinkit "github.com/a69/kit.go/tracing/zipkin"
 )
 
@@ -29,3 +30,23 @@ func TestTraceEndpoint(t *testing.T) {
 		t.Fatalf("incorrect span name, wanted %s, got %s", want, have)
 	}
 }
+
+func TestTraceEndpointForceSample(t *testing.T) {
+	rec := recorder.NewReporter()
+	tr, _ := zipkin.NewTracer(rec, zipkin.WithSampler(zipkin.NeverSample))
+	mw := zipkinkit.TraceEndpoint[struct{}, struct{}](tr, spanName)
+
+	mw(endpoint.Nop[struct{}, struct{}])(context.Background(), struct{}{})
+	if want, have := 0, len(rec.Flush()); want != have {
+		t.Fatalf("unforced: want %d spans, have %d", want, have)
+	}
+
+	mw(endpoint.Nop[struct{}, struct{}])(tracing.WithForceSample(context.Background()), struct{}{})
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("forced: want %d spans, have %d", want, have)
+	}
+	if !spans[0].Debug {
+		t.Error("want debug span")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/a69/kit.go/tracing"
 	kitgrpc "github.com/a69/kit.go/transport/grpc"
 	"github.com/go-kit/log"
 )
@@ -54,6 +55,9 @@ func GRPCClientTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 			if parent := zipkin.SpanFromContext(ctx); parent != nil {
 				spanContext = parent.Context()
 			}
+			if tracing.ForceSampled(ctx) {
+				spanContext.Debug = true
+			}
 
 			span := tracer.StartSpan(
 				name,
@@ -67,6 +71,9 @@ func GRPCClientTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 				if err := b3.InjectGRPC(md)(span.Context()); err != nil {
 					config.logger.Log("err", err)
 				}
+				if tracing.ForceSampled(ctx) {
+					md.Set(tracing.DebugMetadataKey, "1")
+				}
 			}
 
 			return zipkin.NewContext(ctx, span)
@@ -159,6 +166,10 @@ func GRPCServerTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 					config.logger.Log("err", spanContext.Err)
 				}
 			}
+			// As in HTTPServerTrace, x-b3-flags stays zipkin's own business.
+			if tracing.ForceSampled(ctx) {
+				spanContext.Debug = true
+			}
 
 			span := tracer.StartSpan(
 				name,
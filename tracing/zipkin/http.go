@@ -9,6 +9,7 @@ import (
 	"github.com/openzipkin/zipkin-go/model"
 	"github.com/openzipkin/zipkin-go/propagation/b3"
 
+	"github.com/a69/kit.go/tracing"
 	kithttp "github.com/a69/kit.go/transport/http"
 	"github.com/go-kit/log"
 )
@@ -53,6 +54,9 @@ func HTTPClientTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 			if parent := zipkin.SpanFromContext(ctx); parent != nil {
 				spanContext = parent.Context()
 			}
+			if tracing.ForceSampled(ctx) {
+				spanContext.Debug = true
+			}
 
 			tags := map[string]string{
 				string(zipkin.TagHTTPMethod): req.Method,
@@ -72,6 +76,9 @@ func HTTPClientTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 				if err := b3.InjectHTTP(req)(span.Context()); err != nil {
 					config.logger.Log("err", err)
 				}
+				if tracing.ForceSampled(ctx) {
+					req.Header.Set(tracing.DebugHeader, "1")
+				}
 			}
 
 			return zipkin.NewContext(ctx, span)
@@ -148,9 +155,25 @@ func HTTPServerTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 				name        string
 			)
 
-			if config.name != "" {
+			tags := map[string]string{
+				string(zipkin.TagHTTPMethod): req.Method,
+				string(zipkin.TagHTTPPath):   req.URL.Path,
+			}
+
+			var route string
+			if config.route != nil {
+				route = config.route(req)
+			}
+			if route != "" {
+				tags[string(zipkin.TagHTTPRoute)] = route
+			}
+
+			switch {
+			case config.name != "":
 				name = config.name
-			} else {
+			case route != "":
+				name = req.Method + " " + route
+			default:
 				name = req.Method
 			}
 
@@ -166,10 +189,10 @@ func HTTPServerTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerO
 					config.logger.Log("err", spanContext.Err)
 				}
 			}
-
-			tags := map[string]string{
-				string(zipkin.TagHTTPMethod): req.Method,
-				string(zipkin.TagHTTPPath):   req.URL.Path,
+			// An incoming B3 debug flag only marks this trace. Forcing every
+			// tracer to sample takes the server's opt-in, with kithttp.DebugToContext.
+			if tracing.ForceSampled(ctx) {
+				spanContext.Debug = true
 			}
 
 			span := tracer.StartSpan(
@@ -10,12 +10,14 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/gorilla/mux"
 	zipkin "github.com/openzipkin/zipkin-go"
 	"github.com/openzipkin/zipkin-go/model"
 	"github.com/openzipkin/zipkin-go/propagation/b3"
 	"github.com/openzipkin/zipkin-go/reporter/recorder"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/tracing"
 	zipkinkit "github.com/a69/kit.go/tracing/zipkin"
 	kithttp "github.com/a69/kit.go/transport/http"
 )
@@ -258,3 +260,98 @@ func TestHTTPServerTraceIsRequestBasedSampled(t *testing.T) {
 		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
 	}
 }
+
+func TestHTTPServerTraceRouteName(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+
+	router := mux.NewRouter()
+	router.Handle("/profiles/{id}", kithttp.NewServer(
+		endpoint.Nop[any, any],
+		func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		zipkinkit.HTTPServerTrace[any, any](tr, zipkinkit.RouteName(tracing.MuxRoute)),
+	))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/profiles/1234", nil))
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "GET /profiles/{id}", spans[0].Name; want != have {
+		t.Errorf("incorrect span name, want %s, have %s", want, have)
+	}
+	if want, have := "/profiles/{id}", spans[0].Tags["http.route"]; want != have {
+		t.Errorf("incorrect route tag, want %s, have %s", want, have)
+	}
+}
+
+func TestHTTPServerTraceDebugHeaderOptIn(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec, zipkin.WithSampler(zipkin.NeverSample))
+
+	for _, test := range []struct {
+		name    string
+		options []kithttp.ServerOption[any, any]
+		want    int
+	}{
+		{"ignored by default", nil, 0},
+		{"honoured on opt-in", []kithttp.ServerOption[any, any]{kithttp.ServerBefore[any, any](kithttp.DebugToContext(nil))}, 1},
+	} {
+		handler := kithttp.NewServer(
+			endpoint.Nop[any, any],
+			func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+			func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+			append(test.options, zipkinkit.HTTPServerTrace[any, any](tr))...,
+		)
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(tracing.DebugHeader, "1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := rec.Flush()
+		if want, have := test.want, len(spans); want != have {
+			t.Errorf("%s: want %d spans, have %d", test.name, want, have)
+		}
+		if len(spans) > 0 && !spans[0].Debug {
+			t.Errorf("%s: want a debug span", test.name)
+		}
+	}
+}
+
+func TestHTTPServerTraceB3DebugNotForced(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec, zipkin.WithSampler(zipkin.NeverSample))
+
+	var forced bool
+	handler := kithttp.NewServer(
+		func(ctx context.Context, _ any) (any, error) {
+			forced = tracing.ForceSampled(ctx)
+			return nil, nil
+		},
+		func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		zipkinkit.HTTPServerTrace[any, any](tr),
+	)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(b3.Flags, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Zipkin still honours its own debug flag, but without the server's
+	// opt-in the other tracers aren't forced to sample.
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("want %d spans, have %d", want, have)
+	}
+	if !spans[0].Debug {
+		t.Error("want a debug span")
+	}
+	if forced {
+		t.Error("want the context not force-sampled")
+	}
+}
@@ -0,0 +1,19 @@
+package zipkin
+
+import (
+	"context"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+)
+
+// LogKeyvals returns the IDs of the span in ctx, if any, as "trace_id" and
+// "span_id" keyvals. It's a log.ContextFunc, correlating log records with
+// traces.
+func LogKeyvals(ctx context.Context) []interface{} {
+	span := zipkin.SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	sc := span.Context()
+	return []interface{}{"trace_id", sc.TraceID.String(), "span_id", sc.ID.String()}
+}
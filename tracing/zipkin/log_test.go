@@ -0,0 +1,32 @@
+package zipkin_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+
+	kitzipkin "github.com/a69/kit.go/tracing/zipkin"
+)
+
+func TestLogKeyvals(t *testing.T) {
+	if have := kitzipkin.LogKeyvals(context.Background()); have != nil {
+		t.Errorf("want no keyvals without a span, have %v", have)
+	}
+
+	tr, _ := zipkin.NewTracer(recorder.NewReporter())
+	span := tr.StartSpan("test")
+	defer span.Finish()
+
+	keyvals := kitzipkin.LogKeyvals(zipkin.NewContext(context.Background(), span))
+	want := []interface{}{"trace_id", span.Context().TraceID.String(), "span_id", span.Context().ID.String()}
+	if len(keyvals) != len(want) {
+		t.Fatalf("want %v, have %v", want, keyvals)
+	}
+	for i := range want {
+		if want[i] != keyvals[i] {
+			t.Errorf("want %v, have %v", want, keyvals)
+		}
+	}
+}
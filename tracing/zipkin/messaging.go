@@ -0,0 +1,185 @@
+package zipkin
+
+import (
+	"context"
+	"net/http"
+
+	stdnats "github.com/nats-io/nats.go"
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	stdamqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/tracing"
+	kitamqp "github.com/a69/kit.go/transport/amqp"
+	kitnats "github.com/a69/kit.go/transport/nats"
+	"github.com/go-kit/log"
+)
+
+// b3Keys are the B3 headers, in their lower case wire form.
+var b3Keys = []string{b3.TraceID, b3.SpanID, b3.ParentSpanID, b3.Sampled, b3.Flags, b3.Context}
+
+// ContextToNATS returns a NATS RequestFunc that injects the Zipkin span found
+// in ctx into the headers of the outgoing message, so that the trace is
+// continued by the subscriber. Wrap the publisher endpoint with TraceEndpoint
+// to create the span. If no span can be found, the RequestFunc is a noop.
+func ContextToNATS(options ...TracerOption) kitnats.RequestFunc {
+	config := messagingOptions(options)
+	return func(ctx context.Context, msg *stdnats.Msg) context.Context {
+		if msg.Header == nil {
+			msg.Header = stdnats.Header{}
+		}
+		inject(ctx, config, func(k, v string) { msg.Header.Set(k, v) })
+		return ctx
+	}
+}
+
+// ContextToAMQP returns an AMQP RequestFunc that injects the Zipkin span found
+// in ctx into the headers of the outgoing publishing, so that the trace is
+// continued by the subscriber. Wrap the publisher endpoint with TraceEndpoint
+// to create the span. If no span can be found, the RequestFunc is a noop.
+func ContextToAMQP(options ...TracerOption) kitamqp.RequestFunc {
+	config := messagingOptions(options)
+	return func(ctx context.Context, pub *stdamqp.Publishing, _ *stdamqp.Delivery) context.Context {
+		if pub.Headers == nil {
+			pub.Headers = stdamqp.Table{}
+		}
+		inject(ctx, config, func(k, v string) { pub.Headers[k] = v })
+		return ctx
+	}
+}
+
+// NATSSubscriberTrace enables native Zipkin tracing of a Go kit NATS
+// transport Subscriber. It continues the trace propagated in the message
+// headers, e.g. by ContextToNATS, with a consumer span named after the
+// subject, unless the Name TracerOption is used.
+func NATSSubscriberTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerOption) kitnats.SubscriberOption[REQ, RES] {
+	config := messagingOptions(options)
+
+	subscriberBefore := kitnats.SubscriberBefore[REQ, RES](
+		func(ctx context.Context, msg *stdnats.Msg) context.Context {
+			get := func(k string) string {
+				if v := msg.Header.Get(k); v != "" {
+					return v
+				}
+				return msg.Header.Get(http.CanonicalHeaderKey(k))
+			}
+			return startConsumerSpan(ctx, tracer, config, msg.Subject, get)
+		},
+	)
+
+	subscriberFinalizer := kitnats.SubscriberFinalizer[REQ, RES](
+		func(ctx context.Context, _ *stdnats.Msg) { finishSpan(ctx) },
+	)
+
+	return func(s *kitnats.Subscriber[REQ, RES]) {
+		subscriberBefore(s)
+		subscriberFinalizer(s)
+	}
+}
+
+// AMQPSubscriberTrace enables native Zipkin tracing of a Go kit AMQP
+// transport Subscriber. It continues the trace propagated in the delivery
+// headers, e.g. by ContextToAMQP, with a consumer span named after the
+// routing key, unless the Name TracerOption is used.
+func AMQPSubscriberTrace[REQ any, RES any](tracer *zipkin.Tracer, options ...TracerOption) kitamqp.SubscriberOption[REQ, RES] {
+	config := messagingOptions(options)
+
+	subscriberBefore := kitamqp.SubscriberBefore[REQ, RES](
+		func(ctx context.Context, _ *stdamqp.Publishing, deliv *stdamqp.Delivery) context.Context {
+			get := func(k string) string {
+				if v, ok := deliv.Headers[k].(string); ok {
+					return v
+				}
+				v, _ := deliv.Headers[http.CanonicalHeaderKey(k)].(string)
+				return v
+			}
+			return startConsumerSpan(ctx, tracer, config, deliv.RoutingKey, get)
+		},
+	)
+
+	subscriberFinalizer := kitamqp.SubscriberFinalizer[REQ, RES](
+		func(ctx context.Context, _ *stdamqp.Delivery) { finishSpan(ctx) },
+	)
+
+	return func(s *kitamqp.Subscriber[REQ, RES]) {
+		subscriberBefore(s)
+		subscriberFinalizer(s)
+	}
+}
+
+func messagingOptions(options []TracerOption) tracerOptions {
+	config := tracerOptions{
+		tags:      make(map[string]string),
+		name:      "",
+		logger:    log.NewNopLogger(),
+		propagate: true,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+func inject(ctx context.Context, config tracerOptions, set func(k, v string)) {
+	span := zipkin.SpanFromContext(ctx)
+	if span == nil || !config.propagate {
+		return
+	}
+	m := b3.Map{}
+	if err := m.Inject()(span.Context()); err != nil {
+		config.logger.Log("err", err)
+		return
+	}
+	for k, v := range m {
+		set(k, v)
+	}
+	if tracing.ForceSampled(ctx) {
+		set(tracing.DebugHeader, "1")
+	}
+}
+
+func startConsumerSpan(ctx context.Context, tracer *zipkin.Tracer, config tracerOptions, destination string, get func(k string) string) context.Context {
+	var spanContext model.SpanContext
+	if config.propagate {
+		m := b3.Map{}
+		for _, k := range b3Keys {
+			if v := get(k); v != "" {
+				m[k] = v
+			}
+		}
+		spanContext = tracer.Extract(m.Extract)
+		if spanContext.Err != nil {
+			config.logger.Log("err", spanContext.Err)
+		}
+	}
+	// Other tracers are forced to sample only with the subscriber's opt-in,
+	// e.g. the NATS transport's DebugToContext, not by the message's B3 flags.
+	if tracing.ForceSampled(ctx) {
+		spanContext.Debug = true
+	}
+
+	name := config.name
+	if name == "" {
+		name = destination
+	}
+
+	span := tracer.StartSpan(
+		name,
+		zipkin.Kind(model.Consumer),
+		zipkin.Tags(config.tags),
+		zipkin.Tags(map[string]string{"messaging.destination": destination}),
+		zipkin.Parent(spanContext),
+		zipkin.FlushOnFinish(false),
+	)
+
+	return zipkin.NewContext(ctx, span)
+}
+
+func finishSpan(ctx context.Context) {
+	if span := zipkin.SpanFromContext(ctx); span != nil {
+		span.Finish()
+		// send span to the Reporter
+		span.Flush()
+	}
+}
@@ -0,0 +1,80 @@
+package zipkin_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/endpoint"
+	kitzipkin "github.com/a69/kit.go/tracing/zipkin"
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+func TestNATSTracePropagation(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+
+	parentSpan := tr.StartSpan("publish")
+	msg := &nats.Msg{Subject: "orders.placed"}
+	kitzipkin.ContextToNATS()(zipkin.NewContext(context.Background(), parentSpan), msg)
+
+	subscriber := natstransport.NewSubscriber[struct{}, struct{}](
+		endpoint.Nop[struct{}, struct{}],
+		func(context.Context, *nats.Msg) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, string, *nats.Conn, struct{}) error { return nil },
+		kitzipkin.NATSSubscriberTrace[struct{}, struct{}](tr),
+	)
+	subscriber.ServeMsg(nil)(msg)
+
+	checkConsumerSpan(t, rec.Flush(), parentSpan, "orders.placed")
+}
+
+func TestAMQPTracePropagation(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+
+	parentSpan := tr.StartSpan("publish")
+	pub := &amqp.Publishing{}
+	kitzipkin.ContextToAMQP()(zipkin.NewContext(context.Background(), parentSpan), pub, nil)
+
+	subscriber := amqptransport.NewSubscriber[struct{}, struct{}](
+		endpoint.Nop[struct{}, struct{}],
+		func(context.Context, *amqp.Delivery) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, *amqp.Publishing, struct{}) error { return nil },
+		amqptransport.SubscriberResponsePublisher[struct{}, struct{}](amqptransport.NopResponsePublisher),
+		kitzipkin.AMQPSubscriberTrace[struct{}, struct{}](tr),
+	)
+	subscriber.ServeDelivery(nil)(&amqp.Delivery{RoutingKey: "orders.placed", Headers: pub.Headers})
+
+	checkConsumerSpan(t, rec.Flush(), parentSpan, "orders.placed")
+}
+
+func checkConsumerSpan(t *testing.T, spans []model.SpanModel, parent zipkin.Span, name string) {
+	t.Helper()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	span := spans[0]
+	if want, have := parent.Context().TraceID, span.TraceID; want != have {
+		t.Errorf("incorrect TraceID, want %+v, have %+v", want, have)
+	}
+	if span.ParentID == nil || *span.ParentID != parent.Context().ID {
+		t.Errorf("incorrect parent ID, want %s, have %v", parent.Context().ID, span.ParentID)
+	}
+	if want, have := model.Consumer, span.Kind; want != have {
+		t.Errorf("incorrect kind, want %s, have %s", want, have)
+	}
+	if want, have := name, span.Name; want != have {
+		t.Errorf("incorrect name, want %s, have %s", want, have)
+	}
+}
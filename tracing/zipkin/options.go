@@ -3,6 +3,7 @@ package zipkin
 import (
 	"net/http"
 
+	"github.com/a69/kit.go/tracing"
 	"github.com/go-kit/log"
 )
 
@@ -58,10 +59,22 @@ func RequestSampler(sampleFunc func(r *http.Request) bool) TracerOption {
 	}
 }
 
+// RouteName names HTTP server spans after the route the request matched, as
+// returned by the RouteFunc, e.g. "GET /profiles/{id}", rather than after the
+// request method alone. The route is also set as the "http.route" tag. The
+// Name TracerOption takes precedence, and requests without a known route get
+// the default name.
+func RouteName(route tracing.RouteFunc) TracerOption {
+	return func(o *tracerOptions) {
+		o.route = route
+	}
+}
+
 type tracerOptions struct {
 	tags           map[string]string
 	name           string
 	logger         log.Logger
 	propagate      bool
 	requestSampler func(r *http.Request) bool
+	route          tracing.RouteFunc
 }
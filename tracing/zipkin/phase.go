@@ -0,0 +1,28 @@
+package zipkin
+
+import (
+	"context"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+
+	"github.com/a69/kit.go/transport"
+)
+
+// PhaseAnnotations returns a transport.PhaseFunc that annotates the request's
+// span with the start and end of every phase, e.g. "decode.start" and
+// "decode.end". Use it with the ServerPhase option of the HTTP and gRPC
+// servers, together with HTTPServerTrace or GRPCServerTrace.
+func PhaseAnnotations() transport.PhaseFunc {
+	return func(ctx context.Context, phase transport.Phase, start time.Time, took time.Duration, err error) {
+		span := zipkin.SpanFromContext(ctx)
+		if span == nil {
+			return
+		}
+		span.Annotate(start, string(phase)+".start")
+		span.Annotate(start.Add(took), string(phase)+".end")
+		if err != nil {
+			span.Tag(string(phase)+".error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package zipkin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+
+	zipkinkit "github.com/a69/kit.go/tracing/zipkin"
+	"github.com/a69/kit.go/transport"
+)
+
+func TestPhaseAnnotations(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+	span := tr.StartSpan("phases")
+	ctx := zipkin.NewContext(context.Background(), span)
+
+	var (
+		f     = zipkinkit.PhaseAnnotations()
+		start = time.Now()
+	)
+	f(context.Background(), transport.PhaseDecode, start, time.Millisecond, nil) // no span, no-op
+	f(ctx, transport.PhaseDecode, start, time.Millisecond, nil)
+	f(ctx, transport.PhaseEndpoint, start.Add(time.Millisecond), 2*time.Millisecond, errors.New("dang"))
+	span.Finish()
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("want %d spans, have %d", want, have)
+	}
+	annotations := spans[0].Annotations
+	if want, have := 4, len(annotations); want != have {
+		t.Fatalf("want %d annotations, have %d", want, have)
+	}
+	for i, want := range []struct {
+		value string
+		at    time.Time
+	}{
+		{"decode.start", start},
+		{"decode.end", start.Add(time.Millisecond)},
+		{"endpoint.start", start.Add(time.Millisecond)},
+		{"endpoint.end", start.Add(3 * time.Millisecond)},
+	} {
+		if have := annotations[i]; want.value != have.Value || !want.at.Equal(have.Timestamp) {
+			t.Errorf("annotation %d: want %s at %v, have %s at %v", i, want.value, want.at, have.Value, have.Timestamp)
+		}
+	}
+	if want, have := "dang", spans[0].Tags["endpoint.error"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if _, ok := spans[0].Tags["decode.error"]; ok {
+		t.Error("want no decode.error tag")
+	}
+}
@@ -0,0 +1,97 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrDeliverySettled is returned by an Acknowledger that already acked or
+// nacked its delivery.
+var ErrDeliverySettled = errors.New("amqp: delivery already acked or nacked")
+
+// AckPolicy determines how a Subscriber acknowledges the deliveries it
+// handles.
+type AckPolicy int
+
+const (
+	// AckNone leaves acknowledging deliveries to the consumer's auto-ack, or
+	// to request functions and error encoders such as SetAckAfterEndpoint and
+	// SingleNackRequeueErrorEncoder. It's the default.
+	AckNone AckPolicy = iota
+
+	// AckOnReceive acks deliveries as soon as they're received, before
+	// they're decoded, so that they're delivered at most once.
+	AckOnReceive
+
+	// AckOnSuccess acks deliveries once the response is published, and nacks
+	// them if they fail to decode, the endpoint returns an error, the response
+	// fails to encode or publish, or any of them panics, so that they're
+	// delivered at least once. See SubscriberNackRequeue.
+	AckOnSuccess
+
+	// AckManual leaves acknowledging deliveries to the endpoint, with the
+	// Acknowledger returned by AcknowledgerFromContext. Deliveries the
+	// endpoint neither acks nor nacks stay unacknowledged, e.g. until a
+	// worker the endpoint handed them to is done.
+	AckManual
+)
+
+// SubscriberAckPolicy sets how the subscriber acknowledges deliveries. The
+// channel must consume with auto-ack disabled for any policy but AckNone,
+// and the error encoder must not ack or nack deliveries itself, as the broker
+// closes channels acknowledging deliveries twice.
+func SubscriberAckPolicy[REQ any, RES any](policy AckPolicy) SubscriberOption[REQ, RES] {
+	return func(s *Subscriber[REQ, RES]) { s.ackPolicy = policy }
+}
+
+// SubscriberNackRequeue sets whether the deliveries nacked by the AckOnSuccess
+// policy are requeued, which they are by default. Deliveries failing to decode
+// are never requeued, as they would fail again; route them to a dead letter
+// exchange to keep them.
+func SubscriberNackRequeue[REQ any, RES any](requeue bool) SubscriberOption[REQ, RES] {
+	return func(s *Subscriber[REQ, RES]) { s.nackRequeue = requeue }
+}
+
+// Acknowledger acks or nacks the delivery handled by a Subscriber with the
+// AckManual policy. Only the first call to either method has any effect.
+type Acknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
+type acknowledgerKey struct{}
+
+// AcknowledgerFromContext returns the Acknowledger of the delivery handled by
+// a Subscriber with the AckManual policy.
+func AcknowledgerFromContext(ctx context.Context) (Acknowledger, bool) {
+	a, ok := ctx.Value(acknowledgerKey{}).(Acknowledger)
+	return a, ok
+}
+
+// deliveryAcknowledger settles a delivery once.
+type deliveryAcknowledger struct {
+	mtx     sync.Mutex
+	deliv   *amqp.Delivery
+	settled bool
+}
+
+func (a *deliveryAcknowledger) Ack() error {
+	return a.settle(func() error { return a.deliv.Ack(false) })
+}
+
+func (a *deliveryAcknowledger) Nack(requeue bool) error {
+	return a.settle(func() error { return a.deliv.Nack(false, requeue) })
+}
+
+func (a *deliveryAcknowledger) settle(f func() error) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.settled {
+		return ErrDeliverySettled
+	}
+	a.settled = true
+	return f()
+}
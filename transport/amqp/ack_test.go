@@ -0,0 +1,115 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// acknowledger records how deliveries are settled.
+type acknowledger struct {
+	settled []string
+}
+
+func (a *acknowledger) Ack(tag uint64, multiple bool) error {
+	a.settled = append(a.settled, "ack")
+	return nil
+}
+
+func (a *acknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.settled = append(a.settled, fmt.Sprintf("nack requeue=%v", requeue))
+	return nil
+}
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error {
+	a.settled = append(a.settled, fmt.Sprintf("reject requeue=%v", requeue))
+	return nil
+}
+
+func TestSubscriberAckPolicy(t *testing.T) {
+	var (
+		errDecode   = errors.New("bad delivery")
+		errEndpoint = errors.New("endpoint failed")
+	)
+	for _, testcase := range []struct {
+		name    string
+		options []amqptransport.SubscriberOption[string, string]
+		body    string
+		want    string
+	}{
+		{"none", nil, "ok", ""},
+		{"on receive", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnReceive)}, "fail", "ack"},
+		{"on success", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnSuccess)}, "ok", "ack"},
+		{"on success, endpoint error", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnSuccess)}, "fail", "nack requeue=true"},
+		{"on success, decode error", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnSuccess)}, "", "nack requeue=false"},
+		{"on success, no requeue", []amqptransport.SubscriberOption[string, string]{
+			amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnSuccess),
+			amqptransport.SubscriberNackRequeue[string, string](false),
+		}, "fail", "nack requeue=false"},
+		{"manual", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckManual)}, "nack", "nack requeue=true"},
+		{"manual, unsettled", []amqptransport.SubscriberOption[string, string]{amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckManual)}, "ok", ""},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			sub := amqptransport.NewSubscriber(
+				func(ctx context.Context, request string) (string, error) {
+					switch request {
+					case "fail":
+						return "", errEndpoint
+					case "nack":
+						a, ok := amqptransport.AcknowledgerFromContext(ctx)
+						if !ok {
+							t.Fatal("no Acknowledger in context")
+						}
+						a.Nack(true)
+						if want, have := amqptransport.ErrDeliverySettled, a.Ack(); want != have {
+							t.Errorf("want %v, have %v", want, have)
+						}
+					}
+					return request, nil
+				},
+				func(_ context.Context, d *amqp.Delivery) (string, error) {
+					if len(d.Body) == 0 {
+						return "", errDecode
+					}
+					return string(d.Body), nil
+				},
+				func(context.Context, *amqp.Publishing, string) error { return nil },
+				append(testcase.options, amqptransport.SubscriberResponsePublisher[string, string](amqptransport.NopResponsePublisher))...,
+			)
+
+			a := &acknowledger{}
+			sub.ServeDelivery(&mockChannel{})(&amqp.Delivery{Acknowledger: a, Body: []byte(testcase.body)})
+			if want, have := testcase.want, strings.Join(a.settled, ", "); want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestSubscriberAckOnSuccessPanic(t *testing.T) {
+	sub := amqptransport.NewSubscriber(
+		func(context.Context, string) (string, error) { panic("boom") },
+		func(_ context.Context, d *amqp.Delivery) (string, error) { return string(d.Body), nil },
+		func(context.Context, *amqp.Publishing, string) error { return nil },
+		amqptransport.SubscriberAckPolicy[string, string](amqptransport.AckOnSuccess),
+		amqptransport.SubscriberResponsePublisher[string, string](amqptransport.NopResponsePublisher),
+	)
+
+	a := &acknowledger{}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("want the panic to propagate")
+			}
+		}()
+		sub.ServeDelivery(&mockChannel{})(&amqp.Delivery{Acknowledger: a, Body: []byte("ok")})
+	}()
+	if want, have := "nack requeue=true", strings.Join(a.settled, ", "); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
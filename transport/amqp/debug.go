@@ -0,0 +1,22 @@
+package amqp
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/tracing"
+)
+
+// DebugToContext returns a RequestFunc that forces trace sampling in the
+// context if the delivery carries a tracing.DebugHeader and allow accepts it.
+// A nil allow accepts every delivery, and should only be used if every
+// publisher is trusted. Particularly useful for subscribers.
+func DebugToContext(allow func(*amqp.Delivery) bool) RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		if debug, ok := d.Headers[tracing.DebugHeader].(string); ok && tracing.DebugRequested(debug) && (allow == nil || allow(d)) {
+			ctx = tracing.WithForceSample(ctx)
+		}
+		return ctx
+	}
+}
@@ -0,0 +1,22 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/tracing"
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+)
+
+func TestDebugToContext(t *testing.T) {
+	ctx := context.Background()
+	deliv := &amqp.Delivery{Headers: amqp.Table{tracing.DebugHeader: "1"}}
+	if !tracing.ForceSampled(amqptransport.DebugToContext(nil)(ctx, nil, deliv)) {
+		t.Error("want force sampled subscriber context")
+	}
+	if tracing.ForceSampled(amqptransport.DebugToContext(func(*amqp.Delivery) bool { return false })(ctx, nil, deliv)) {
+		t.Error("want no force sampling for a rejected delivery")
+	}
+}
@@ -0,0 +1,265 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrHeaderType is returned when a header holds a value that can't be
+// decoded to the type of the Header reading it.
+var ErrHeaderType = errors.New("unexpected header type")
+
+// Header is an AMQP header carrying values of type T, e.g. a tenant ID, a
+// trace context or the claims of a caller. It converts values to and from
+// the types AMQP tables support, and moves them between messages and
+// contexts with its RequestFuncs, so that services share header names and
+// types instead of handling amqp.Table values by hand:
+//
+//	var tenantHeader = amqp.StringHeader("tenant")
+//
+//	amqp.SubscriberBefore[Request, Response](tenantHeader.DeliveryToContext())
+//	amqp.PublisherBefore[Request, Response](tenantHeader.ContextToPublishing())
+//
+//	tenant, ok := tenantHeader.Value(ctx)
+type Header[T any] struct {
+	name   string
+	encode func(T) (interface{}, error)
+	decode func(interface{}) (T, error)
+}
+
+// NewHeader returns a Header named name, converting values with encode and
+// decode. Encode must return a type supported by AMQP tables, and decode is
+// given the value of the header as received.
+func NewHeader[T any](name string, encode func(T) (interface{}, error), decode func(interface{}) (T, error)) Header[T] {
+	return Header[T]{name: name, encode: encode, decode: decode}
+}
+
+// StringHeader returns a Header carrying strings. Byte slices are accepted
+// as well, as some clients send strings as such.
+func StringHeader(name string) Header[string] {
+	return NewHeader(name,
+		func(s string) (interface{}, error) { return s, nil },
+		func(v interface{}) (string, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case []byte:
+				return string(v), nil
+			}
+			return "", ErrHeaderType
+		},
+	)
+}
+
+// IntHeader returns a Header carrying integers. Integers of any size are
+// accepted, as clients pick the smallest AMQP type holding a value.
+func IntHeader(name string) Header[int64] {
+	return NewHeader(name,
+		func(n int64) (interface{}, error) { return n, nil },
+		func(v interface{}) (int64, error) {
+			switch v := v.(type) {
+			case int:
+				return int64(v), nil
+			case int8:
+				return int64(v), nil
+			case int16:
+				return int64(v), nil
+			case int32:
+				return int64(v), nil
+			case int64:
+				return v, nil
+			case uint8:
+				return int64(v), nil
+			case uint16:
+				return int64(v), nil
+			case uint32:
+				return int64(v), nil
+			}
+			return 0, ErrHeaderType
+		},
+	)
+}
+
+// BoolHeader returns a Header carrying booleans.
+func BoolHeader(name string) Header[bool] {
+	return NewHeader(name,
+		func(b bool) (interface{}, error) { return b, nil },
+		func(v interface{}) (bool, error) {
+			b, ok := v.(bool)
+			if !ok {
+				return false, ErrHeaderType
+			}
+			return b, nil
+		},
+	)
+}
+
+// TimeHeader returns a Header carrying timestamps. AMQP timestamps have a
+// precision of one second.
+func TimeHeader(name string) Header[time.Time] {
+	return NewHeader(name,
+		func(t time.Time) (interface{}, error) { return t, nil },
+		func(v interface{}) (time.Time, error) {
+			t, ok := v.(time.Time)
+			if !ok {
+				return time.Time{}, ErrHeaderType
+			}
+			return t, nil
+		},
+	)
+}
+
+// MapHeader returns a Header carrying string maps as nested tables, e.g. the
+// fields of a trace context.
+func MapHeader(name string) Header[map[string]string] {
+	return NewHeader(name,
+		func(m map[string]string) (interface{}, error) {
+			t := amqp.Table{}
+			for k, v := range m {
+				t[k] = v
+			}
+			return t, nil
+		},
+		func(v interface{}) (map[string]string, error) {
+			t, ok := v.(amqp.Table)
+			if !ok {
+				return nil, ErrHeaderType
+			}
+			m := make(map[string]string, len(t))
+			for k, v := range t {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("field %q: %w", k, ErrHeaderType)
+				}
+				m[k] = s
+			}
+			return m, nil
+		},
+	)
+}
+
+// JSONHeader returns a Header carrying values of any type, as JSON strings,
+// e.g. the claims of a caller.
+func JSONHeader[T any](name string) Header[T] {
+	return NewHeader(name,
+		func(v T) (interface{}, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		func(v interface{}) (T, error) {
+			var t T
+			var b []byte
+			switch v := v.(type) {
+			case string:
+				b = []byte(v)
+			case []byte:
+				b = v
+			default:
+				return t, ErrHeaderType
+			}
+			err := json.Unmarshal(b, &t)
+			return t, err
+		},
+	)
+}
+
+// Name returns the name of the header.
+func (h Header[T]) Name() string { return h.name }
+
+// Get returns the value of the header in the table, and whether it's set.
+// It fails if the value can't be decoded.
+func (h Header[T]) Get(t amqp.Table) (value T, ok bool, err error) {
+	v, ok := t[h.name]
+	if !ok {
+		return value, false, nil
+	}
+	if value, err = h.decode(v); err != nil {
+		return value, false, fmt.Errorf("header %q: %w", h.name, err)
+	}
+	return value, true, nil
+}
+
+// Set sets the header to value in the table, which is allocated if nil.
+func (h Header[T]) Set(t *amqp.Table, value T) error {
+	v, err := h.encode(value)
+	if err != nil {
+		return fmt.Errorf("header %q: %w", h.name, err)
+	}
+	if *t == nil {
+		*t = amqp.Table{}
+	}
+	(*t)[h.name] = v
+	return nil
+}
+
+type headerKey struct{ name string }
+
+// WithValue returns a copy of ctx carrying value as the header's value.
+func (h Header[T]) WithValue(ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, headerKey{h.name}, value)
+}
+
+// Value returns the value of the header carried by ctx, and whether there
+// is one.
+func (h Header[T]) Value(ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(headerKey{h.name}).(T)
+	return value, ok
+}
+
+// DeliveryToContext returns a RequestFunc moving the header from deliveries
+// to the context. Particularly useful for subscribers. Headers that can't be
+// decoded are ignored, as if they were missing.
+func (h Header[T]) DeliveryToContext() RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		if d == nil {
+			return ctx
+		}
+		return h.toContext(ctx, d.Headers)
+	}
+}
+
+// ContextToPublishing returns a RequestFunc moving the header from the
+// context to publishings. Particularly useful for publishers. Values that
+// can't be encoded are ignored.
+func (h Header[T]) ContextToPublishing() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		h.fromContext(ctx, &pub.Headers)
+		return ctx
+	}
+}
+
+// ContextToReply returns a SubscriberResponseFunc moving the header from
+// the context to the replies of subscribers.
+func (h Header[T]) ContextToReply() SubscriberResponseFunc {
+	return func(ctx context.Context, _ *amqp.Delivery, _ Channel, pub *amqp.Publishing) context.Context {
+		h.fromContext(ctx, &pub.Headers)
+		return ctx
+	}
+}
+
+// ReplyToContext returns a PublisherResponseFunc moving the header from the
+// replies received by publishers to the context.
+func (h Header[T]) ReplyToContext() PublisherResponseFunc {
+	return func(ctx context.Context, d *amqp.Delivery) context.Context {
+		return h.toContext(ctx, d.Headers)
+	}
+}
+
+func (h Header[T]) toContext(ctx context.Context, t amqp.Table) context.Context {
+	value, ok, err := h.Get(t)
+	if !ok || err != nil {
+		return ctx
+	}
+	return h.WithValue(ctx, value)
+}
+
+func (h Header[T]) fromContext(ctx context.Context, t *amqp.Table) {
+	if value, ok := h.Value(ctx); ok {
+		h.Set(t, value)
+	}
+}
@@ -0,0 +1,107 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+)
+
+type claims struct {
+	Subject string   `json:"sub"`
+	Scopes  []string `json:"scopes"`
+}
+
+func TestHeaders(t *testing.T) {
+	var (
+		tenant  = amqptransport.StringHeader("tenant")
+		attempt = amqptransport.IntHeader("attempt")
+		sent    = amqptransport.TimeHeader("sent-at")
+		trace   = amqptransport.MapHeader("trace")
+		caller  = amqptransport.JSONHeader[claims]("caller")
+		now     = time.Unix(1700000000, 0)
+		ctx     = context.Background()
+		pub     amqp.Publishing
+		before  = []amqptransport.RequestFunc{
+			tenant.ContextToPublishing(),
+			attempt.ContextToPublishing(),
+			sent.ContextToPublishing(),
+			trace.ContextToPublishing(),
+			caller.ContextToPublishing(),
+		}
+	)
+	ctx = tenant.WithValue(ctx, "acme")
+	ctx = attempt.WithValue(ctx, 3)
+	ctx = sent.WithValue(ctx, now)
+	ctx = trace.WithValue(ctx, map[string]string{"traceparent": "00-abc-def-01"})
+	ctx = caller.WithValue(ctx, claims{Subject: "ana", Scopes: []string{"orders"}})
+	for _, f := range before {
+		f(ctx, &pub, nil)
+	}
+	if err := pub.Headers.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Receivers see integers of the smallest type holding them.
+	pub.Headers["attempt"] = int8(3)
+	var (
+		d    = &amqp.Delivery{Headers: pub.Headers}
+		rctx = context.Background()
+	)
+	for _, f := range []amqptransport.RequestFunc{
+		tenant.DeliveryToContext(),
+		attempt.DeliveryToContext(),
+		sent.DeliveryToContext(),
+		trace.DeliveryToContext(),
+		caller.DeliveryToContext(),
+	} {
+		rctx = f(rctx, nil, d)
+	}
+	if v, _ := tenant.Value(rctx); v != "acme" {
+		t.Errorf("tenant: have %q", v)
+	}
+	if v, _ := attempt.Value(rctx); v != 3 {
+		t.Errorf("attempt: have %d", v)
+	}
+	if v, _ := sent.Value(rctx); !v.Equal(now) {
+		t.Errorf("sent at: have %s", v)
+	}
+	if v, _ := trace.Value(rctx); v["traceparent"] != "00-abc-def-01" {
+		t.Errorf("trace: have %v", v)
+	}
+	if v, _ := caller.Value(rctx); v.Subject != "ana" || len(v.Scopes) != 1 {
+		t.Errorf("caller: have %+v", v)
+	}
+}
+
+func TestHeaderGet(t *testing.T) {
+	h := amqptransport.IntHeader("attempt")
+	if _, ok, err := h.Get(amqp.Table{}); ok || err != nil {
+		t.Errorf("missing: want not ok and no error, have %v, %v", ok, err)
+	}
+	if _, _, err := h.Get(amqp.Table{"attempt": "three"}); !errors.Is(err, amqptransport.ErrHeaderType) {
+		t.Errorf("want %v, have %v", amqptransport.ErrHeaderType, err)
+	}
+
+	// Invalid headers are left out of the context.
+	ctx := h.DeliveryToContext()(context.Background(), nil, &amqp.Delivery{Headers: amqp.Table{"attempt": "three"}})
+	if _, ok := h.Value(ctx); ok {
+		t.Error("want no value")
+	}
+}
+
+func TestHeaderReplies(t *testing.T) {
+	var (
+		h   = amqptransport.BoolHeader("cached")
+		pub amqp.Publishing
+	)
+	h.ContextToReply()(h.WithValue(context.Background(), true), nil, nil, &pub)
+	ctx := h.ReplyToContext()(context.Background(), &amqp.Delivery{Headers: pub.Headers})
+	if v, ok := h.Value(ctx); !ok || !v {
+		t.Errorf("want true, have %v (%v)", v, ok)
+	}
+}
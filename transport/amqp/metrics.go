@@ -0,0 +1,43 @@
+package amqp
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// ObserveRequestSize returns a RequestFunc observing the size in bytes of
+// request bodies in h. As a SubscriberBefore, it observes the body of the
+// delivery received, and as a PublisherBefore, the body of the publishing
+// about to be sent.
+func ObserveRequestSize(h metrics.Histogram) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, deliv *amqp.Delivery) context.Context {
+		if deliv != nil {
+			h.Observe(float64(len(deliv.Body)))
+		} else if pub != nil {
+			h.Observe(float64(len(pub.Body)))
+		}
+		return ctx
+	}
+}
+
+// ObserveResponseSize returns a PublisherResponseFunc observing the size in
+// bytes of the body of response deliveries in h.
+func ObserveResponseSize(h metrics.Histogram) PublisherResponseFunc {
+	return func(ctx context.Context, deliv *amqp.Delivery) context.Context {
+		h.Observe(float64(len(deliv.Body)))
+		return ctx
+	}
+}
+
+// ObservePublishedSize wraps the ResponsePublisher of a subscriber,
+// observing the size in bytes of the body of the responses it publishes in
+// h.
+func ObservePublishedSize(h metrics.Histogram, next ResponsePublisher) ResponsePublisher {
+	return func(ctx context.Context, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) error {
+		h.Observe(float64(len(pub.Body)))
+		return next(ctx, deliv, ch, pub)
+	}
+}
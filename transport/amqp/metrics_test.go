@@ -0,0 +1,36 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+)
+
+func TestObserveSizes(t *testing.T) {
+	var (
+		h   = metricstest.NewHistogram("size")
+		ctx = context.Background()
+	)
+	amqptransport.ObserveRequestSize(h)(ctx, &amqp.Publishing{}, &amqp.Delivery{Body: []byte("abc")})
+	amqptransport.ObserveRequestSize(h)(ctx, &amqp.Publishing{Body: []byte("abcd")}, nil)
+	amqptransport.ObserveResponseSize(h)(ctx, &amqp.Delivery{Body: []byte("ab")})
+	published := false
+	amqptransport.ObservePublishedSize(h, func(context.Context, *amqp.Delivery, amqptransport.Channel, *amqp.Publishing) error {
+		published = true
+		return nil
+	})(ctx, &amqp.Delivery{}, nil, &amqp.Publishing{Body: []byte("a")})
+
+	if !published {
+		t.Error("response not published")
+	}
+	if want, have := 10.0, h.Sum(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 4, h.Count(); want != have {
+		t.Errorf("want %d observations, have %d", want, have)
+	}
+}
@@ -0,0 +1,294 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/a69/kit.go/util/conn"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrPublishingNacked is returned by the Publish method of a ChannelPool with
+// confirms if the broker negatively acknowledges the publishing.
+var ErrPublishingNacked = errors.New("amqp: publishing nacked by the broker")
+
+// PoolChannel is the subset of the methods of *amqp.Channel used by a
+// ChannelPool.
+type PoolChannel interface {
+	Channel
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	IsClosed() bool
+	Close() error
+}
+
+// ChannelOpener opens the channels of a ChannelPool.
+type ChannelOpener func() (PoolChannel, error)
+
+// ConnectionOpener returns a ChannelOpener opening channels on conn.
+func ConnectionOpener(conn *amqp.Connection) ChannelOpener {
+	return func() (PoolChannel, error) {
+		ch, err := conn.Channel()
+		if err != nil {
+			return nil, err
+		}
+		return ch, nil
+	}
+}
+
+// ManagedOpener returns a ChannelOpener opening channels on the current
+// connection of m, e.g. so that a ChannelPool outlives broker restarts.
+// Failing to open a channel invalidates the connection, and m dials a new
+// one, so that the next checkout of the pool succeeds.
+func ManagedOpener(m *conn.Managed[*amqp.Connection]) ChannelOpener {
+	return func() (PoolChannel, error) {
+		c, ok := m.Take()
+		if !ok {
+			return nil, amqp.ErrClosed
+		}
+		ch, err := c.Channel()
+		if err != nil {
+			m.Put(c, err)
+			return nil, err
+		}
+		return ch, nil
+	}
+}
+
+// ChannelPool is a Channel multiplexing publishings over a number of AMQP
+// channels, checked out in round-robin order, so that high-throughput
+// publishers aren't serialized on a single channel. Closed channels, e.g.
+// after a channel exception, are replaced by new ones when they're checked
+// out, and publishings failing because their channel was closed are retried
+// once on a new one.
+//
+// Consumers, e.g. of the reply queue of a Publisher, are bound to one of the
+// channels. The "amq.rabbitmq.reply-to" pseudo-queue requires publishing and
+// consuming on the same channel, so publishers using a pool need a reply
+// queue of their own.
+type ChannelPool struct {
+	open           ChannelOpener
+	confirms       bool
+	confirmTimeout time.Duration
+	slots          []*poolSlot
+	next           atomic.Uint64
+	closed         atomic.Bool
+}
+
+// ChannelPoolOption sets an optional parameter for channel pools.
+type ChannelPoolOption func(*ChannelPool)
+
+// ChannelPoolConfirms puts the channels in confirm mode. Publish then waits
+// for the broker to confirm the publishing, and returns ErrPublishingNacked
+// if it's negatively acknowledged, or amqp.ErrClosed if the channel is closed
+// before that.
+func ChannelPoolConfirms() ChannelPoolOption {
+	return func(p *ChannelPool) { p.confirms = true }
+}
+
+// ChannelPoolConfirmTimeout sets how long Publish waits for the broker to
+// confirm a publishing before giving up with context.DeadlineExceeded. By
+// default, it waits for 30 seconds. PublishWithContext waits as long as its
+// context allows instead.
+func ChannelPoolConfirmTimeout(d time.Duration) ChannelPoolOption {
+	return func(p *ChannelPool) { p.confirmTimeout = d }
+}
+
+// NewChannelPool opens size channels with open, and returns a pool of them.
+func NewChannelPool(open ChannelOpener, size int, options ...ChannelPoolOption) (*ChannelPool, error) {
+	if size < 1 {
+		size = 1
+	}
+	p := &ChannelPool{open: open, confirmTimeout: 30 * time.Second, slots: make([]*poolSlot, size)}
+	for _, option := range options {
+		option(p)
+	}
+	for i := range p.slots {
+		p.slots[i] = &poolSlot{}
+		if _, err := p.slots[i].channel(p); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Publish implements Channel, publishing on the next channel of the pool.
+// With confirms, it waits for the confirmation up to the confirm timeout.
+func (p *ChannelPool) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.confirmTimeout)
+	defer cancel()
+	return p.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// PublishWithContext is like Publish, but waits for the confirmation, if the
+// pool has confirms, until the context is done, returning its error.
+func (p *ChannelPool) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	s := p.checkout()
+	confirmed, err := s.publish(p, exchange, key, mandatory, immediate, msg)
+	if errors.Is(err, amqp.ErrClosed) && !p.closed.Load() {
+		confirmed, err = s.publish(p, exchange, key, mandatory, immediate, msg)
+	}
+	if err != nil || confirmed == nil {
+		return err
+	}
+	select {
+	case err := <-confirmed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume implements Channel, consuming on the next channel of the pool.
+func (p *ChannelPool) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	s := p.checkout()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	ch, err := s.channel(p)
+	if err != nil {
+		return nil, err
+	}
+	return ch.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// Close closes the channels of the pool.
+func (p *ChannelPool) Close() error {
+	p.closed.Store(true)
+	var first error
+	for _, s := range p.slots {
+		if s == nil {
+			continue
+		}
+		s.mtx.Lock()
+		if s.ch != nil {
+			if err := s.ch.Close(); err != nil && first == nil && !errors.Is(err, amqp.ErrClosed) {
+				first = err
+			}
+		}
+		s.mtx.Unlock()
+	}
+	return first
+}
+
+func (p *ChannelPool) checkout() *poolSlot {
+	return p.slots[(p.next.Add(1)-1)%uint64(len(p.slots))]
+}
+
+type poolSlot struct {
+	mtx      sync.Mutex
+	ch       PoolChannel
+	confirms *confirmTracker
+}
+
+// channel returns the channel of the slot, opening a new one if it's closed.
+// It's called with mtx held.
+func (s *poolSlot) channel(p *ChannelPool) (PoolChannel, error) {
+	if p.closed.Load() {
+		return nil, amqp.ErrClosed
+	}
+	if s.ch != nil && !s.ch.IsClosed() {
+		return s.ch, nil
+	}
+	ch, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	s.ch, s.confirms = ch, nil
+	if p.confirms {
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			s.ch = nil
+			return nil, err
+		}
+		s.confirms = newConfirmTracker(ch.NotifyPublish(make(chan amqp.Confirmation, 64)))
+	}
+	return ch, nil
+}
+
+// publish publishes msg, returning the channel its confirmation is sent to,
+// if the pool has confirms.
+func (s *poolSlot) publish(p *ChannelPool, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (<-chan error, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if err := msg.Headers.Validate(); err != nil {
+		return nil, err
+	}
+	ch, err := s.channel(p)
+	if err != nil {
+		return nil, err
+	}
+	var confirmed <-chan error
+	if s.confirms != nil {
+		confirmed = s.confirms.await()
+	}
+	if err := ch.Publish(exchange, key, mandatory, immediate, msg); err != nil {
+		if s.confirms != nil {
+			// Whether the delivery tag of a publishing that failed to be sent
+			// was used is up to the client library, so the tracker can't tell
+			// which tag the next confirmation is for. Replace the channel
+			// rather than route confirmations to the wrong publishings.
+			ch.Close()
+			s.ch, s.confirms = nil, nil
+		}
+		return nil, err
+	}
+	return confirmed, nil
+}
+
+// confirmTracker routes the confirmations of a channel to the publishings
+// awaiting them, by delivery tag, which the broker assigns in order starting
+// at 1.
+type confirmTracker struct {
+	mtx     sync.Mutex
+	tag     uint64
+	pending map[uint64]chan error
+	closed  bool
+}
+
+func newConfirmTracker(confirmations <-chan amqp.Confirmation) *confirmTracker {
+	t := &confirmTracker{pending: map[uint64]chan error{}}
+	go t.route(confirmations)
+	return t
+}
+
+// await returns the channel the confirmation of the next publishing is sent
+// to. It's called with the slot locked, before publishing.
+func (t *confirmTracker) await() <-chan error {
+	c := make(chan error, 1)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.closed {
+		c <- amqp.ErrClosed
+		return c
+	}
+	t.tag++
+	t.pending[t.tag] = c
+	return c
+}
+
+func (t *confirmTracker) route(confirmations <-chan amqp.Confirmation) {
+	for c := range confirmations {
+		t.mtx.Lock()
+		if pending, ok := t.pending[c.DeliveryTag]; ok {
+			delete(t.pending, c.DeliveryTag)
+			if c.Ack {
+				pending <- nil
+			} else {
+				pending <- ErrPublishingNacked
+			}
+		}
+		t.mtx.Unlock()
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.closed = true
+	for tag, pending := range t.pending {
+		pending <- amqp.ErrClosed
+		delete(t.pending, tag)
+	}
+}
@@ -0,0 +1,204 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// poolChannel records publishings, and acks them if in confirm mode, unless
+// nack is set. If silent is set, it doesn't confirm them at all, and if err is
+// set, it fails them.
+type poolChannel struct {
+	mtx       sync.Mutex
+	id        int
+	published int
+	closed    bool
+	nack      bool
+	silent    bool
+	err       error
+	confirms  chan amqp.Confirmation
+	tag       uint64
+}
+
+func (ch *poolChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+	if ch.err != nil {
+		return ch.err
+	}
+	ch.published++
+	if ch.confirms != nil && !ch.silent {
+		ch.tag++
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: !ch.nack}
+	}
+	return nil
+}
+
+func (ch *poolChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return make(chan amqp.Delivery), nil
+}
+
+func (ch *poolChannel) Confirm(noWait bool) error { return nil }
+
+func (ch *poolChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.confirms = confirm
+	return confirm
+}
+
+func (ch *poolChannel) IsClosed() bool {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	return ch.closed
+}
+
+func (ch *poolChannel) Close() error {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	if !ch.closed && ch.confirms != nil {
+		close(ch.confirms)
+	}
+	ch.closed = true
+	return nil
+}
+
+type poolOpener struct {
+	mtx    sync.Mutex
+	opened []*poolChannel
+}
+
+func (o *poolOpener) open() (amqptransport.PoolChannel, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	ch := &poolChannel{id: len(o.opened)}
+	o.opened = append(o.opened, ch)
+	return ch, nil
+}
+
+func TestChannelPoolRoundRobin(t *testing.T) {
+	var o poolOpener
+	pool, err := amqptransport.NewChannelPool(o.open, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 9; i++ {
+		if err := pool.Publish("", "key", false, false, amqp.Publishing{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := 3, len(o.opened); want != have {
+		t.Fatalf("want %d channels, have %d", want, have)
+	}
+	for _, ch := range o.opened {
+		if want, have := 3, ch.published; want != have {
+			t.Errorf("channel %d: want %d publishings, have %d", ch.id, want, have)
+		}
+	}
+}
+
+func TestChannelPoolReplacesClosedChannels(t *testing.T) {
+	var o poolOpener
+	pool, err := amqptransport.NewChannelPool(o.open, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	o.opened[0].Close()
+	for i := 0; i < 2; i++ {
+		if err := pool.Publish("", "key", false, false, amqp.Publishing{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := 3, len(o.opened); want != have {
+		t.Fatalf("want %d channels, have %d", want, have)
+	}
+	if want, have := 1, o.opened[2].published; want != have {
+		t.Errorf("want %d publishings on the new channel, have %d", want, have)
+	}
+}
+
+func TestChannelPoolConfirms(t *testing.T) {
+	var o poolOpener
+	pool, err := amqptransport.NewChannelPool(o.open, 2, amqptransport.ChannelPoolConfirms())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Publish("", "key", false, false, amqp.Publishing{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	o.opened[1].nack = true
+	pool.Publish("", "key", false, false, amqp.Publishing{})
+	if want, have := amqptransport.ErrPublishingNacked, pool.Publish("", "key", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	pool.Close()
+	if want, have := amqp.ErrClosed, pool.Publish("", "key", false, false, amqp.Publishing{}); !errors.Is(have, want) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestChannelPoolConfirmsReplaceFailedChannels(t *testing.T) {
+	var o poolOpener
+	pool, err := amqptransport.NewChannelPool(o.open, 1, amqptransport.ChannelPoolConfirms())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	errSend := errors.New("send failed")
+	o.opened[0].err = errSend
+	if want, have := errSend, pool.Publish("", "key", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if !o.opened[0].IsClosed() {
+		t.Error("want the failed channel closed")
+	}
+	if err := pool.Publish("", "key", false, false, amqp.Publishing{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, len(o.opened); want != have {
+		t.Errorf("want %d channels, have %d", want, have)
+	}
+}
+
+func TestChannelPoolConfirmTimeout(t *testing.T) {
+	var o poolOpener
+	pool, err := amqptransport.NewChannelPool(o.open, 1, amqptransport.ChannelPoolConfirms(), amqptransport.ChannelPoolConfirmTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	o.opened[0].silent = true
+	if want, have := context.DeadlineExceeded, pool.Publish("", "key", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if want, have := context.Canceled, pool.PublishWithContext(ctx, "", "key", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -2,6 +2,8 @@ package amqp
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/a69/kit.go/endpoint"
@@ -23,6 +25,7 @@ type Publisher[REQ any, RES any] struct {
 	after     []PublisherResponseFunc
 	deliverer Deliverer[REQ, RES]
 	timeout   time.Duration
+	replies   *replyConsumer
 }
 
 // NewPublisher constructs a usable Publisher for a single remote method.
@@ -40,6 +43,7 @@ func NewPublisher[REQ any, RES any](
 		dec:       dec,
 		deliverer: DefaultDeliverer[REQ, RES],
 		timeout:   10 * time.Second,
+		replies:   &replyConsumer{},
 	}
 	for _, option := range options {
 		option(p)
@@ -118,14 +122,32 @@ type Deliverer[REQ any, RES any] func(
 	*amqp.Publishing,
 ) (*amqp.Delivery, error)
 
+// ErrReplyConsumerClosed is returned by DefaultDeliverer for the requests
+// pending when the consumer of the reply queue is closed, e.g. because the
+// channel was closed.
+var ErrReplyConsumerClosed = errors.New("amqp: reply consumer closed")
+
 // DefaultDeliverer is a deliverer that publishes the specified Publishing
-// and returns the first Delivery object with the matching correlationId.
-// If the context times out while waiting for a reply, an error will be returned.
+// and returns the Delivery object with the matching correlationId.
+// Replies are received by a single consumer of the reply queue per
+// Publisher, started by the first request with the auto-ack and arguments
+// set in its context, which routes them to the pending requests by their
+// correlationId. The reply queue may be an exclusive queue, or the
+// "amq.rabbitmq.reply-to" pseudo-queue with auto-ack set.
+// If the context times out while waiting for a reply, an error will be
+// returned, and the reply is discarded if it arrives later.
 func DefaultDeliverer[REQ any, RES any](
 	ctx context.Context,
 	p Publisher[REQ, RES],
 	pub *amqp.Publishing,
 ) (*amqp.Delivery, error) {
+	reply, done := p.replies.await(pub.CorrelationId)
+	defer done()
+
+	if err := p.replies.start(ctx, p.ch, p.q.Name); err != nil {
+		return nil, err
+	}
+
 	err := p.ch.Publish(
 		getPublishExchange(ctx),
 		getPublishKey(ctx),
@@ -136,10 +158,56 @@ func DefaultDeliverer[REQ any, RES any](
 	if err != nil {
 		return nil, err
 	}
-	autoAck := getConsumeAutoAck(ctx)
 
-	msg, err := p.ch.Consume(
-		p.q.Name,
+	select {
+	case d, ok := <-reply:
+		if !ok {
+			return nil, ErrReplyConsumerClosed
+		}
+		return d, nil
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// replyConsumer consumes the reply queue of a Publisher, routing the replies
+// to the pending requests by their correlation ID.
+type replyConsumer struct {
+	mtx     sync.Mutex
+	running bool
+	pending map[string]chan *amqp.Delivery
+}
+
+// await registers a pending request, returning the channel its reply is sent
+// to, and a function unregistering it.
+func (r *replyConsumer) await(correlationID string) (<-chan *amqp.Delivery, func()) {
+	c := make(chan *amqp.Delivery, 1)
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.pending == nil {
+		r.pending = map[string]chan *amqp.Delivery{}
+	}
+	r.pending[correlationID] = c
+	return c, func() {
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		if r.pending[correlationID] == c {
+			delete(r.pending, correlationID)
+		}
+	}
+}
+
+// start consumes the queue, unless it's already consumed.
+func (r *replyConsumer) start(ctx context.Context, ch Channel, queue string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.running {
+		return nil
+	}
+	autoAck := getConsumeAutoAck(ctx)
+	deliveries, err := ch.Consume(
+		queue,
 		"", //consumer
 		autoAck,
 		false, //exclusive
@@ -148,24 +216,34 @@ func DefaultDeliverer[REQ any, RES any](
 		getConsumeArgs(ctx),
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	r.running = true
+	go r.route(deliveries, autoAck)
+	return nil
+}
 
-	for {
-		select {
-		case d := <-msg:
-			if d.CorrelationId == pub.CorrelationId {
-				if !autoAck {
-					d.Ack(false) //multiple
-				}
-				return &d, nil
-			}
-
-		case <-ctx.Done():
-			return nil, ctx.Err()
+func (r *replyConsumer) route(deliveries <-chan amqp.Delivery, autoAck bool) {
+	for d := range deliveries {
+		if !autoAck {
+			d.Ack(false) //multiple
+		}
+		r.mtx.Lock()
+		if c, ok := r.pending[d.CorrelationId]; ok {
+			delete(r.pending, d.CorrelationId)
+			c <- &d
 		}
+		r.mtx.Unlock()
 	}
 
+	// The pending requests fail, and the next one consumes the queue again.
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for id, c := range r.pending {
+		close(c)
+		delete(r.pending, id)
+	}
+	r.running = false
 }
 
 // SendAndForgetDeliverer delivers the supplied publishing and
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -268,3 +270,117 @@ func TestSendAndForgetPublisher(t *testing.T) {
 	}
 
 }
+
+// rpcChannel replies to every publishing, in reverse order of publishing once
+// batch requests are pending.
+type rpcChannel struct {
+	mtx        sync.Mutex
+	batch      int
+	published  []amqp.Publishing
+	consumes   int
+	deliveries chan amqp.Delivery
+}
+
+func (ch *rpcChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	ch.published = append(ch.published, msg)
+	if len(ch.published) == ch.batch {
+		for i := len(ch.published) - 1; i >= 0; i-- {
+			ch.deliveries <- amqp.Delivery{CorrelationId: ch.published[i].CorrelationId, Body: ch.published[i].Body}
+		}
+		ch.published = nil
+	}
+	return nil
+}
+
+func (ch *rpcChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	ch.consumes++
+	return ch.deliveries, nil
+}
+
+// TestPublisherConcurrentReplies tests that concurrent requests share a
+// single consumer of the reply queue, and get their own reply.
+func TestPublisherConcurrentReplies(t *testing.T) {
+	const n = 20
+	ch := &rpcChannel{batch: n, deliveries: make(chan amqp.Delivery, n)}
+	pub := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "amq.rabbitmq.reply-to"},
+		func(_ context.Context, p *amqp.Publishing, request int) error {
+			p.Body = []byte(strconv.Itoa(request))
+			return nil
+		},
+		func(_ context.Context, d *amqp.Delivery) (int, error) {
+			return strconv.Atoi(string(d.Body))
+		},
+		amqptransport.PublisherBefore[int, int](amqptransport.SetConsumeAutoAck(true)),
+		amqptransport.PublisherTimeout[int, int](time.Second),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := pub.Endpoint()(context.Background(), i)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want, have := i, response; want != have {
+				t.Errorf("want %d, have %d", want, have)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if want, have := 1, ch.consumes; want != have {
+		t.Errorf("want %d consumers, have %d", want, have)
+	}
+}
+
+// TestPublisherReplyConsumerClosed tests that pending requests fail when the
+// consumer of the reply queue is closed, and that it's consumed again.
+func TestPublisherReplyConsumerClosed(t *testing.T) {
+	ch := &rpcChannel{batch: 2, deliveries: make(chan amqp.Delivery, 2)}
+	pub := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		func(context.Context, *amqp.Publishing, struct{}) error { return nil },
+		func(context.Context, *amqp.Delivery) (struct{}, error) { return struct{}{}, nil },
+		amqptransport.PublisherTimeout[struct{}, struct{}](time.Second),
+	)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := pub.Endpoint()(context.Background(), struct{}{})
+		errc <- err
+	}()
+	for {
+		ch.mtx.Lock()
+		published := len(ch.published)
+		ch.mtx.Unlock()
+		if published == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(ch.deliveries)
+
+	if want, have := amqptransport.ErrReplyConsumerClosed, <-errc; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ch.mtx.Lock()
+	ch.batch, ch.published, ch.deliveries = 1, nil, make(chan amqp.Delivery, 1)
+	ch.mtx.Unlock()
+	if _, err := pub.Endpoint()(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, ch.consumes; want != have {
+		t.Errorf("want %d consumers, have %d", want, have)
+	}
+}
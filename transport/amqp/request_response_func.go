@@ -179,4 +179,7 @@ const (
 	// ContextKeyConsumeArgs is the value of consumeArgs field when calling
 	// amqp.Channel.Consume.
 	ContextKeyConsumeArgs
+	// ContextKeyVersion is the schema version of the delivery being handled
+	// by a Subscriber, or an empty string if it didn't carry one.
+	ContextKeyVersion
 )
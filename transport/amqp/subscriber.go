@@ -15,12 +15,16 @@ import (
 type Subscriber[REQ any, RES any] struct {
 	e                 endpoint.Endpoint[REQ, RES]
 	dec               DecodeRequestFunc[REQ]
+	decoders          map[string]DecodeRequestFunc[REQ]
 	enc               EncodeResponseFunc[RES]
 	before            []RequestFunc
 	after             []SubscriberResponseFunc
 	responsePublisher ResponsePublisher
 	errorEncoder      ErrorEncoder
 	errorHandler      transport.ErrorHandler
+	finalizer         []SubscriberFinalizerFunc
+	ackPolicy         AckPolicy
+	nackRequeue       bool
 }
 
 // NewSubscriber constructs a new subscriber, which provides a handler
@@ -38,6 +42,7 @@ func NewSubscriber[REQ any, RES any](
 		responsePublisher: DefaultResponsePublisher,
 		errorEncoder:      DefaultErrorEncoder,
 		errorHandler:      transport.NewLogErrorHandler(log.NewNopLogger()),
+		nackRequeue:       true,
 	}
 	for _, option := range options {
 		option(s)
@@ -92,6 +97,17 @@ func SubscriberErrorHandler[REQ any, RES any](errorHandler transport.ErrorHandle
 	return func(s *Subscriber[REQ, RES]) { s.errorHandler = errorHandler }
 }
 
+// SubscriberFinalizer is executed at the end of every delivery handled by the
+// subscriber. By default, no finalizer is registered.
+func SubscriberFinalizer[REQ any, RES any](f ...SubscriberFinalizerFunc) SubscriberOption[REQ, RES] {
+	return func(s *Subscriber[REQ, RES]) { s.finalizer = append(s.finalizer, f...) }
+}
+
+// SubscriberFinalizerFunc can be used to perform work at the end of a
+// delivery, after the response has been published or the error encoded, e.g.
+// to finish a span.
+type SubscriberFinalizerFunc func(ctx context.Context, deliv *amqp.Delivery)
+
 // ServeDelivery handles AMQP Delivery messages
 // It is strongly recommended to use *amqp.Channel as the
 // Channel interface implementation.
@@ -100,14 +116,61 @@ func (s *Subscriber[_, _]) ServeDelivery(ch Channel) func(deliv *amqp.Delivery)
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
+		if len(s.finalizer) > 0 {
+			defer func() {
+				for _, f := range s.finalizer {
+					f(ctx, deliv)
+				}
+			}()
+		}
+
+		var (
+			err       error
+			decodeErr bool
+			ok        bool // set once the response is published, so panics nack
+			acker     = &deliveryAcknowledger{deliv: deliv}
+		)
+		switch s.ackPolicy {
+		case AckOnReceive:
+			if err := acker.Ack(); err != nil {
+				s.errorHandler.Handle(ctx, err)
+			}
+		case AckOnSuccess:
+			defer func() {
+				var ackErr error
+				if ok {
+					ackErr = acker.Ack()
+				} else {
+					ackErr = acker.Nack(s.nackRequeue && !decodeErr)
+				}
+				if ackErr != nil {
+					s.errorHandler.Handle(ctx, ackErr)
+				}
+			}()
+		case AckManual:
+			ctx = context.WithValue(ctx, acknowledgerKey{}, Acknowledger(acker))
+		}
+
 		pub := amqp.Publishing{}
 
 		for _, f := range s.before {
 			ctx = f(ctx, &pub, deliv)
 		}
 
-		request, err := s.dec(ctx, deliv)
+		version := MessageVersion(deliv)
+		ctx = context.WithValue(ctx, ContextKeyVersion, version)
+
+		dec, err := s.decoder(version)
+		if err != nil {
+			decodeErr = true
+			s.errorHandler.Handle(ctx, err)
+			s.errorEncoder(ctx, err, deliv, ch, &pub)
+			return
+		}
+
+		request, err := dec(ctx, deliv)
 		if err != nil {
+			decodeErr = true
 			s.errorHandler.Handle(ctx, err)
 			s.errorEncoder(ctx, err, deliv, ch, &pub)
 			return
@@ -124,17 +187,18 @@ func (s *Subscriber[_, _]) ServeDelivery(ch Channel) func(deliv *amqp.Delivery)
 			ctx = f(ctx, deliv, ch, &pub)
 		}
 
-		if err := s.enc(ctx, &pub, response); err != nil {
+		if err = s.enc(ctx, &pub, response); err != nil {
 			s.errorHandler.Handle(ctx, err)
 			s.errorEncoder(ctx, err, deliv, ch, &pub)
 			return
 		}
 
-		if err := s.responsePublisher(ctx, deliv, ch, &pub); err != nil {
+		if err = s.responsePublisher(ctx, deliv, ch, &pub); err != nil {
 			s.errorHandler.Handle(ctx, err)
 			s.errorEncoder(ctx, err, deliv, ch, &pub)
 			return
 		}
+		ok = true
 	}
 
 }
@@ -421,3 +421,27 @@ var names = map[int]string{
 	436: "tusker",
 	437: "husky",
 }
+
+// TestSubscriberFinalizer checks that finalizers run after the endpoint
+// failed, with the context set by the before functions.
+func TestSubscriberFinalizer(t *testing.T) {
+	type key struct{}
+	var have interface{}
+	sub := amqptransport.NewSubscriber[struct{}, struct{}](
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, errors.New("err!") },
+		func(context.Context, *amqp.Delivery) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, *amqp.Publishing, struct{}) error { return nil },
+		amqptransport.SubscriberBefore[struct{}, struct{}](func(ctx context.Context, _ *amqp.Publishing, _ *amqp.Delivery) context.Context {
+			return context.WithValue(ctx, key{}, "before")
+		}),
+		amqptransport.SubscriberErrorEncoder[struct{}, struct{}](amqptransport.DefaultErrorEncoder),
+		amqptransport.SubscriberFinalizer[struct{}, struct{}](func(ctx context.Context, _ *amqp.Delivery) {
+			have = ctx.Value(key{})
+		}),
+	)
+	sub.ServeDelivery(&mockChannel{f: nullFunc})(&amqp.Delivery{})
+
+	if want := "before"; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
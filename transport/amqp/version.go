@@ -0,0 +1,87 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Schema versions are carried by messages in the version parameter of their
+// content type, e.g. "application/json; version=2". Producers that can't
+// change the content type may set the VersionHeader header instead.
+const (
+	// VersionParam is the content type parameter holding the schema version.
+	VersionParam = "version"
+
+	// VersionHeader is the header consulted when the content type doesn't
+	// carry a version.
+	VersionHeader = "schema-version"
+)
+
+// ErrUnsupportedVersion is returned by Subscribers with version-specific
+// decoders when a message carries a version none of them handles.
+var ErrUnsupportedVersion = errors.New("unsupported schema version")
+
+// MessageVersion returns the schema version of a delivery, or an empty string
+// if it doesn't carry one.
+func MessageVersion(deliv *amqp.Delivery) string {
+	if _, params, err := mime.ParseMediaType(deliv.ContentType); err == nil {
+		if version, ok := params[VersionParam]; ok {
+			return version
+		}
+	}
+	if version, ok := deliv.Headers[VersionHeader].(string); ok {
+		return version
+	}
+	return ""
+}
+
+// SetPublishVersion returns a RequestFunc that stamps the schema version onto
+// a Publishing, both as the version parameter of its content type and as the
+// VersionHeader header. Use it in Publishers, whose RequestFuncs run after the
+// request was encoded, so that the content type set by the encoder is kept.
+func SetPublishVersion(version string) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if pub.ContentType != "" {
+			if mediaType, params, err := mime.ParseMediaType(pub.ContentType); err == nil {
+				params[VersionParam] = version
+				pub.ContentType = mime.FormatMediaType(mediaType, params)
+			}
+		}
+		if pub.Headers == nil {
+			pub.Headers = amqp.Table{}
+		}
+		pub.Headers[VersionHeader] = version
+		return ctx
+	}
+}
+
+// SubscriberVersionDecoder registers a decoder for messages of the given
+// schema version. Messages without a version are still decoded by the
+// Subscriber's default decoder, but once any version decoder is registered,
+// messages with a version that has no decoder fail with
+// ErrUnsupportedVersion. The decoded version is available to later stages
+// under ContextKeyVersion.
+func SubscriberVersionDecoder[REQ any, RES any](version string, dec DecodeRequestFunc[REQ]) SubscriberOption[REQ, RES] {
+	return func(s *Subscriber[REQ, RES]) {
+		if s.decoders == nil {
+			s.decoders = map[string]DecodeRequestFunc[REQ]{}
+		}
+		s.decoders[version] = dec
+	}
+}
+
+// decoder picks the decoder for the given schema version.
+func (s *Subscriber[REQ, _]) decoder(version string) (DecodeRequestFunc[REQ], error) {
+	if version == "" || len(s.decoders) == 0 {
+		return s.dec, nil
+	}
+	dec, ok := s.decoders[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, version)
+	}
+	return dec, nil
+}
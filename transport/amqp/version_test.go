@@ -0,0 +1,82 @@
+package amqp_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestSetPublishVersion(t *testing.T) {
+	pub := amqp.Publishing{ContentType: "application/json; charset=utf-8"}
+	amqptransport.SetPublishVersion("2")(context.Background(), &pub, nil)
+
+	if want, have := "application/json; charset=utf-8; version=2", pub.ContentType; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	deliv := amqp.Delivery{ContentType: pub.ContentType}
+	if want, have := "2", amqptransport.MessageVersion(&deliv); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	deliv = amqp.Delivery{Headers: pub.Headers}
+	if want, have := "2", amqptransport.MessageVersion(&deliv); want != have {
+		t.Errorf("header: want %q, have %q", want, have)
+	}
+}
+
+func TestSubscriberVersionDecoder(t *testing.T) {
+	v2Decoder := func(_ context.Context, d *amqp.Delivery) (testReq, error) {
+		var obj struct {
+			Squadron int `json:"squadron"`
+		}
+		err := json.Unmarshal(d.Body, &obj)
+		return testReq{Squadron: obj.Squadron}, err
+	}
+	var versions []string
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		func(ctx context.Context, p *amqp.Publishing, res testRes) error {
+			versions = append(versions, ctx.Value(amqptransport.ContextKeyVersion).(string))
+			return amqptransport.EncodeJSONResponse(ctx, p, res)
+		},
+		amqptransport.SubscriberVersionDecoder[testReq, testRes]("2", v2Decoder),
+		amqptransport.SubscriberErrorEncoder[testReq, testRes](amqptransport.ReplyErrorEncoder),
+	)
+
+	outputChan := make(chan amqp.Publishing, 1)
+	ch := &mockChannel{f: nullFunc, c: outputChan}
+
+	for _, deliv := range []amqp.Delivery{
+		{ContentType: "application/json", Body: []byte(`{"s": 424}`)},
+		{ContentType: "application/json; version=2", Body: []byte(`{"squadron": 424}`)},
+	} {
+		sub.ServeDelivery(ch)(&deliv)
+		res, err := testResDecoder((<-outputChan).Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "tiger", res.Name; want != have {
+			t.Errorf("%s: want %q, have %q", deliv.ContentType, want, have)
+		}
+	}
+	if want, have := ",2", strings.Join(versions, ","); want != have {
+		t.Errorf("versions: want %q, have %q", want, have)
+	}
+
+	sub.ServeDelivery(ch)(&amqp.Delivery{
+		ContentType: "application/json",
+		Headers:     amqp.Table{amqptransport.VersionHeader: "3"},
+		Body:        []byte(`{}`),
+	})
+	res, err := decodeSubscriberError(<-outputChan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `unsupported schema version: "3"`, res.Error; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
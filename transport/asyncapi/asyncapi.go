@@ -0,0 +1,373 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Version is the version of the AsyncAPI specification the documents
+// conform to.
+const Version = "3.0.0"
+
+// Spec collects the channels a service receives from and sends to, and
+// marshals them as an AsyncAPI document. It's safe for concurrent use.
+type Spec struct {
+	mtx        sync.Mutex
+	info       info
+	servers    map[string]server
+	channels   map[string]*channel
+	operations map[string]*operation
+	messages   map[string]*message
+	msgNames   map[reflect.Type]string
+	schemas    *schemas
+}
+
+// Option sets an optional parameter for specs.
+type Option func(*Spec)
+
+// Description sets the description of the service.
+func Description(description string) Option {
+	return func(s *Spec) { s.info.Description = description }
+}
+
+// Server adds a server, e.g. Server("production", "nats.example.com:4222",
+// "nats"). Channels of a protocol are bound to the servers of that protocol.
+func Server(name, host, protocol string) Option {
+	return func(s *Spec) { s.servers[name] = server{Host: host, Protocol: protocol} }
+}
+
+// New returns an empty spec of the service.
+func New(title, version string, options ...Option) *Spec {
+	s := &Spec{
+		info:       info{Title: title, Version: version},
+		servers:    map[string]server{},
+		channels:   map[string]*channel{},
+		operations: map[string]*operation{},
+		messages:   map[string]*message{},
+		msgNames:   map[reflect.Type]string{},
+		schemas:    newSchemas(),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Channel describes where messages are exchanged.
+type Channel struct {
+	// Address is the subject, queue or routing key of the channel.
+	Address string
+
+	// Protocol is the protocol of the servers of the channel, e.g. "nats".
+	Protocol string
+
+	// Bindings are the protocol specific properties of the channel, and
+	// OperationBindings those of the operations on it, keyed by protocol.
+	Bindings          map[string]any
+	OperationBindings map[string]any
+}
+
+// OperationOption sets an optional parameter for operations.
+type OperationOption func(*operationConfig)
+
+type operationConfig struct {
+	id          string
+	summary     string
+	description string
+	noReply     bool
+}
+
+// OperationID sets the ID of the operation. By default, it's derived from the
+// action and the address of the channel, e.g. "receiveOrdersCreate".
+func OperationID(id string) OperationOption {
+	return func(c *operationConfig) { c.id = id }
+}
+
+// Summary sets the summary of the operation.
+func Summary(summary string) OperationOption {
+	return func(c *operationConfig) { c.summary = summary }
+}
+
+// OperationDescription sets the description of the operation.
+func OperationDescription(description string) OperationOption {
+	return func(c *operationConfig) { c.description = description }
+}
+
+// NoReply documents that no response is sent back, e.g. for AMQP subscribers
+// with NopResponsePublisher, or publishers with SendAndForgetDeliverer.
+func NoReply() OperationOption {
+	return func(c *operationConfig) { c.noReply = true }
+}
+
+// Receive documents that the service receives REQ messages on ch, and replies
+// with RES messages.
+func Receive[REQ any, RES any](s *Spec, ch Channel, options ...OperationOption) {
+	s.add("receive", ch, typeOf[REQ](), typeOf[RES](), options)
+}
+
+// Send documents that the service sends REQ messages on ch, and expects RES
+// messages in reply.
+func Send[REQ any, RES any](s *Spec, ch Channel, options ...OperationOption) {
+	s.add("send", ch, typeOf[REQ](), typeOf[RES](), options)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (s *Spec) add(action string, ch Channel, req, res reflect.Type, options []OperationOption) {
+	var config operationConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	chID := s.channel(ch)
+	id := config.id
+	if id == "" {
+		id = unique(action+upperFirst(chID), s.operations)
+	}
+	reqName := s.message(req, upperFirst(id)+"Request")
+	s.channels[chID].Messages[reqName] = ref("#/components/messages/" + reqName)
+
+	op := &operation{
+		Action:      action,
+		Channel:     ref("#/channels/" + chID),
+		Summary:     config.summary,
+		Description: config.description,
+		Messages:    []reference{ref("#/channels/" + chID + "/messages/" + reqName)},
+		Bindings:    ch.OperationBindings,
+	}
+	if !config.noReply {
+		resName := s.message(res, upperFirst(id)+"Response")
+		replyID := unique(id+"Reply", s.channels)
+		s.channels[replyID] = &channel{
+			Description: "The reply to " + id + ", sent to the reply address of the request.",
+			Messages:    map[string]reference{resName: ref("#/components/messages/" + resName)},
+			protocol:    ch.Protocol,
+		}
+		op.Reply = &reply{
+			Channel:  ref("#/channels/" + replyID),
+			Messages: []reference{ref("#/channels/" + replyID + "/messages/" + resName)},
+		}
+	}
+	s.operations[id] = op
+}
+
+// channel returns the ID of the channel, adding it unless a channel with the
+// same address and protocol already exists.
+func (s *Spec) channel(ch Channel) string {
+	for id, c := range s.channels {
+		if c.Address != nil && *c.Address == ch.Address && c.protocol == ch.Protocol {
+			return id
+		}
+	}
+	id := unique(channelID(ch.Address), s.channels)
+	address := ch.Address
+	s.channels[id] = &channel{
+		Address:  &address,
+		Messages: map[string]reference{},
+		Bindings: ch.Bindings,
+		protocol: ch.Protocol,
+	}
+	return id
+}
+
+// message returns the name of the message of type t, adding it unless it
+// already exists. Messages of unnamed types are named after the operation.
+func (s *Spec) message(t reflect.Type, fallback string) string {
+	if name, ok := s.msgNames[t]; ok {
+		return name
+	}
+	name := fallback
+	if n := typeName(derefType(t)); n != "" {
+		name = n
+	}
+	name = unique(name, s.messages)
+	s.msgNames[t] = name
+	s.messages[name] = &message{
+		Name:        name,
+		ContentType: "application/json",
+		Payload:     s.schemas.schema(t),
+	}
+	return name
+}
+
+// MarshalJSON implements json.Marshaler, returning the AsyncAPI document.
+func (s *Spec) MarshalJSON() ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	d := document{
+		AsyncAPI:           Version,
+		Info:               s.info,
+		DefaultContentType: "application/json",
+		Servers:            s.servers,
+		Channels:           map[string]channel{},
+		Operations:         s.operations,
+		Components: components{
+			Messages: s.messages,
+			Schemas:  s.schemas.components,
+		},
+	}
+	protocols := map[string]bool{}
+	for _, srv := range s.servers {
+		protocols[srv.Protocol] = true
+	}
+	for id, c := range s.channels {
+		cc := *c
+		// Only bind channels to servers if they don't all serve it.
+		if len(protocols) > 1 && cc.protocol != "" {
+			for name, srv := range s.servers {
+				if srv.Protocol == cc.protocol {
+					cc.Servers = append(cc.Servers, ref("#/servers/"+name))
+				}
+			}
+			sort.Slice(cc.Servers, func(i, j int) bool { return cc.Servers[i].Ref < cc.Servers[j].Ref })
+		}
+		d.Channels[id] = cc
+	}
+	return json.Marshal(d)
+}
+
+// ServeHTTP implements http.Handler, serving the AsyncAPI document.
+func (s *Spec) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b, err := s.MarshalJSON()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(b)
+}
+
+type document struct {
+	AsyncAPI           string                `json:"asyncapi"`
+	Info               info                  `json:"info"`
+	DefaultContentType string                `json:"defaultContentType"`
+	Servers            map[string]server     `json:"servers,omitempty"`
+	Channels           map[string]channel    `json:"channels"`
+	Operations         map[string]*operation `json:"operations"`
+	Components         components            `json:"components"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type server struct {
+	Host     string `json:"host"`
+	Protocol string `json:"protocol"`
+}
+
+type channel struct {
+	// Address is nil for reply channels, whose address is dynamic.
+	Address     *string              `json:"address"`
+	Description string               `json:"description,omitempty"`
+	Messages    map[string]reference `json:"messages"`
+	Servers     []reference          `json:"servers,omitempty"`
+	Bindings    map[string]any       `json:"bindings,omitempty"`
+
+	protocol string
+}
+
+type operation struct {
+	Action      string         `json:"action"`
+	Channel     reference      `json:"channel"`
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Messages    []reference    `json:"messages"`
+	Reply       *reply         `json:"reply,omitempty"`
+	Bindings    map[string]any `json:"bindings,omitempty"`
+}
+
+type reply struct {
+	Channel  reference   `json:"channel"`
+	Messages []reference `json:"messages"`
+}
+
+type message struct {
+	Name        string  `json:"name"`
+	ContentType string  `json:"contentType"`
+	Payload     *Schema `json:"payload"`
+}
+
+type components struct {
+	Messages map[string]*message `json:"messages,omitempty"`
+	Schemas  map[string]*Schema  `json:"schemas,omitempty"`
+}
+
+type reference struct {
+	Ref string `json:"$ref"`
+}
+
+func ref(s string) reference {
+	return reference{Ref: s}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// channelID returns the ID of the channel at address, e.g. "ordersCreate"
+// for "orders.create". NATS wildcards become "Any" and "All".
+func channelID(address string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(address, func(r rune) bool {
+		return r != '*' && r != '>' && !unicode.IsLetter(r) && !unicode.IsDigit(r) || r > unicode.MaxASCII
+	}) {
+		switch word {
+		case "*":
+			word = "Any"
+		case ">":
+			word = "All"
+		default:
+			word = strings.Trim(word, "*>")
+			if word == "" {
+				continue
+			}
+		}
+		if b.Len() == 0 {
+			word = strings.ToLower(word[:1]) + word[1:]
+		} else {
+			word = upperFirst(word)
+		}
+		b.WriteString(word)
+	}
+	if b.Len() == 0 {
+		return "channel"
+	}
+	return b.String()
+}
+
+// unique returns id, suffixed with a number if it's already taken in m.
+func unique[V any](id string, m map[string]V) string {
+	if _, ok := m[id]; !ok {
+		return id
+	}
+	for i := 2; ; i++ {
+		if _, ok := m[id+strconv.Itoa(i)]; !ok {
+			return id + strconv.Itoa(i)
+		}
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,172 @@
+package asyncapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	"github.com/a69/kit.go/transport/asyncapi"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type CreateOrder struct {
+	Audit
+	Customer string            `json:"customer"`
+	Items    []Item            `json:"items"`
+	Note     *string           `json:"note"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Total    int64             `json:"total,string"`
+	Parent   *CreateOrder      `json:"parent,omitempty"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+type OrderCreated struct {
+	ID  string `json:"id"`
+	Err error  `json:"-"`
+}
+
+type Event[T any] struct {
+	Data T `json:"data"`
+}
+
+func TestSpec(t *testing.T) {
+	spec := asyncapi.New("orders", "1.0.0",
+		asyncapi.Description("Takes orders."),
+		asyncapi.Server("nats", "localhost:4222", "nats"),
+		asyncapi.Server("rabbitmq", "localhost:5672", "amqp"),
+	)
+
+	sub := natstransport.NewSubscriber[CreateOrder, OrderCreated](endpoint.Nop[CreateOrder, OrderCreated], nil, nil)
+	asyncapi.NATSSubscriber(spec, "orders.create", "orders", sub, asyncapi.Summary("Creates an order."))
+
+	pub := natstransport.NewPublisher[Event[Item], struct{}](nil, "stock.*.reserve", nil, nil)
+	asyncapi.NATSPublisher(spec, pub)
+
+	apub := amqptransport.NewPublisher[Event[OrderCreated], struct{}](nil, nil, nil, nil)
+	asyncapi.AMQPPublisher(spec, "events", "orders.created", apub, asyncapi.NoReply(), asyncapi.OperationID("publishOrderCreated"))
+
+	asub := amqptransport.NewSubscriber[map[string]int, string](nil, nil, nil)
+	asyncapi.AMQPSubscriber(spec, "counts", asub)
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path []string
+		want any
+	}{
+		{[]string{"asyncapi"}, "3.0.0"},
+		{[]string{"info", "description"}, "Takes orders."},
+		{[]string{"servers", "nats", "protocol"}, "nats"},
+		{[]string{"channels", "ordersCreate", "address"}, "orders.create"},
+		{[]string{"channels", "ordersCreate", "servers"}, []any{map[string]any{"$ref": "#/servers/nats"}}},
+		{[]string{"channels", "ordersCreate", "messages", "CreateOrder", "$ref"}, "#/components/messages/CreateOrder"},
+		{[]string{"channels", "receiveOrdersCreateReply", "address"}, nil},
+		{[]string{"channels", "stockAnyReserve", "address"}, "stock.*.reserve"},
+		{[]string{"channels", "ordersCreated", "bindings", "amqp", "exchange", "name"}, "events"},
+		{[]string{"channels", "counts", "bindings", "amqp", "queue", "name"}, "counts"},
+		{[]string{"operations", "receiveOrdersCreate", "action"}, "receive"},
+		{[]string{"operations", "receiveOrdersCreate", "summary"}, "Creates an order."},
+		{[]string{"operations", "receiveOrdersCreate", "bindings", "nats", "queue"}, "orders"},
+		{[]string{"operations", "receiveOrdersCreate", "reply", "messages"}, []any{map[string]any{"$ref": "#/channels/receiveOrdersCreateReply/messages/OrderCreated"}}},
+		{[]string{"operations", "sendStockAnyReserve", "action"}, "send"},
+		{[]string{"operations", "publishOrderCreated", "reply"}, nil},
+		{[]string{"operations", "receiveCounts", "messages"}, []any{map[string]any{"$ref": "#/channels/counts/messages/ReceiveCountsRequest"}}},
+		{[]string{"components", "messages", "ReceiveCountsRequest", "payload"}, map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}}},
+		{[]string{"components", "messages", "String", "payload"}, map[string]any{"type": "string"}},
+		{[]string{"components", "messages", "EventItem", "payload", "$ref"}, "#/components/schemas/EventItem"},
+		{[]string{"components", "schemas", "EventItem", "properties", "data", "$ref"}, "#/components/schemas/Item"},
+		{[]string{"components", "schemas", "OrderCreated", "properties"}, map[string]any{"id": map[string]any{"type": "string"}}},
+	} {
+		if want, have := tc.want, lookup(doc, tc.path); !reflect.DeepEqual(want, have) {
+			t.Errorf("%v: want %v, have %v", tc.path, want, have)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	spec := asyncapi.New("orders", "1.0.0")
+	asyncapi.Receive[CreateOrder, OrderCreated](spec, asyncapi.Channel{Address: "orders"})
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]asyncapi.Schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := asyncapi.Schema{
+		Type: "object",
+		Properties: map[string]*asyncapi.Schema{
+			"created_at": {Type: "string", Format: "date-time"},
+			"customer":   {Type: "string"},
+			"items":      {Type: "array", Items: &asyncapi.Schema{Ref: "#/components/schemas/Item"}},
+			"note":       {Type: "string"},
+			"labels":     {Type: "object", AdditionalProperties: &asyncapi.Schema{Type: "string"}},
+			"total":      {Type: "string"},
+			"parent":     {Ref: "#/components/schemas/CreateOrder"},
+		},
+		Required: []string{"created_at", "customer", "items", "total"},
+	}
+	if have := doc.Components.Schemas["CreateOrder"]; !reflect.DeepEqual(want, have) {
+		have, _ := json.Marshal(have)
+		t.Errorf("want CreateOrder schema %+v, have %s", want, have)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	spec := asyncapi.New("orders", "1.0.0")
+	asyncapi.Send[Item, struct{}](spec, asyncapi.Channel{Address: "items"}, asyncapi.NoReply())
+
+	rec := httptest.NewRecorder()
+	spec.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/asyncapi.json", nil))
+	if want, have := "application/json; charset=utf-8", rec.Header().Get("Content-Type"); want != have {
+		t.Errorf("want Content-Type %q, have %q", want, have)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "send", lookup(doc, []string{"operations", "sendItems", "action"}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func lookup(doc map[string]any, path []string) any {
+	var v any = doc
+	for _, p := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
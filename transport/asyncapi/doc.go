@@ -0,0 +1,14 @@
+// Package asyncapi documents the message-driven transports of a service as an
+// AsyncAPI 3.0 document, the counterpart of OpenAPI for NATS and AMQP.
+//
+// The subscribers and publishers of the service are registered with a Spec,
+// which derives the JSON schemas of the payloads from their request and
+// response types, following the rules of encoding/json:
+//
+//	spec := asyncapi.New("orders", "1.0.0", asyncapi.Server("production", "nats:4222", "nats"))
+//	asyncapi.NATSSubscriber(spec, "orders.create", "orders", createSubscriber)
+//	asyncapi.NATSPublisher(spec, billingPublisher, asyncapi.Summary("Bills an order."))
+//	mux.Handle("/asyncapi.json", spec)
+//
+// Other channels are documented with Receive and Send.
+package asyncapi
@@ -0,0 +1,71 @@
+package asyncapi
+
+import (
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+// Versions of the protocol bindings.
+const (
+	natsBindingVersion = "0.1.0"
+	amqpBindingVersion = "0.3.0"
+)
+
+// NATSSubscriber documents a NATS subscriber serving the subject, as a member
+// of the queue group, if any. The subscriber itself only determines the
+// request and response types, as it doesn't know what it's subscribed to.
+func NATSSubscriber[REQ any, RES any](s *Spec, subject, queue string, _ *natstransport.Subscriber[REQ, RES], options ...OperationOption) {
+	Receive[REQ, RES](s, natsChannel(subject, queue), options...)
+}
+
+// NATSPublisher documents a NATS publisher sending requests to its subject.
+func NATSPublisher[REQ any, RES any](s *Spec, p *natstransport.Publisher[REQ, RES], options ...OperationOption) {
+	Send[REQ, RES](s, natsChannel(p.Subject(), ""), options...)
+}
+
+func natsChannel(subject, queue string) Channel {
+	ch := Channel{Address: subject, Protocol: "nats"}
+	if queue != "" {
+		ch.OperationBindings = map[string]any{
+			"nats": map[string]any{"queue": queue, "bindingVersion": natsBindingVersion},
+		}
+	}
+	return ch
+}
+
+// AMQPSubscriber documents an AMQP subscriber serving the deliveries of the
+// queue. The subscriber itself only determines the request and response
+// types.
+func AMQPSubscriber[REQ any, RES any](s *Spec, queue string, _ *amqptransport.Subscriber[REQ, RES], options ...OperationOption) {
+	Receive[REQ, RES](s, Channel{
+		Address:  queue,
+		Protocol: "amqp",
+		Bindings: map[string]any{
+			"amqp": map[string]any{
+				"is":             "queue",
+				"queue":          map[string]any{"name": queue},
+				"bindingVersion": amqpBindingVersion,
+			},
+		},
+	}, options...)
+}
+
+// AMQPPublisher documents an AMQP publisher sending requests to the exchange
+// with the routing key, as set with SetPublishExchange and SetPublishKey.
+// The default exchange is documented as such if exchange is empty.
+func AMQPPublisher[REQ any, RES any](s *Spec, exchange, key string, _ *amqptransport.Publisher[REQ, RES], options ...OperationOption) {
+	binding := map[string]any{
+		"is":             "routingKey",
+		"bindingVersion": amqpBindingVersion,
+	}
+	if exchange == "" {
+		binding["exchange"] = map[string]any{"name": "", "type": "default"}
+	} else {
+		binding["exchange"] = map[string]any{"name": exchange}
+	}
+	Send[REQ, RES](s, Channel{
+		Address:  key,
+		Protocol: "amqp",
+		Bindings: map[string]any{"amqp": binding},
+	}, options...)
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeError is returned by request decoders, and validation middlewares,
+// for requests that are malformed or invalid. Error encoders render it as a
+// client error listing the invalid fields, rather than the text of the
+// underlying error, e.g. that of json.Unmarshal, which is only logged.
+type DecodeError struct {
+	// Field is the path of the invalid field, e.g. "items.0.quantity", or
+	// empty if the request as a whole is malformed.
+	Field string `json:"field,omitempty"`
+
+	// Reason is why the field is invalid, as shown to clients.
+	Reason string `json:"reason"`
+
+	// Value is the invalid value, if it's safe to show to clients.
+	Value interface{} `json:"value,omitempty"`
+
+	// Err is the underlying error, if any. It's not shown to clients.
+	Err error `json:"-"`
+}
+
+// Error implements error.
+func (e *DecodeError) Error() string {
+	msg := e.Reason
+	if e.Field != "" {
+		msg = e.Field + ": " + msg
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors returns the decode errors in the tree of err, e.g. several of
+// them joined with errors.Join by a validation middleware, or nil if there
+// are none.
+func DecodeErrors(err error) []*DecodeError {
+	var errs []*DecodeError
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *DecodeError:
+			errs = append(errs, e)
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return errs
+}
+
+// JSONDecodeError converts an error returned by encoding/json while decoding
+// a request into a DecodeError, naming the offending field where possible,
+// e.g.
+//
+//	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+//		return req, transport.JSONDecodeError(err)
+//	}
+//
+// Nil errors, and errors already containing a DecodeError, are returned
+// unchanged.
+func JSONDecodeError(err error) error {
+	if err == nil || DecodeErrors(err) != nil {
+		return err
+	}
+	var (
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &typeErr):
+		return &DecodeError{Field: typeErr.Field, Reason: "must be " + jsonKind(typeErr.Type), Err: err}
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Reason: "malformed JSON at offset " + strconv.FormatInt(syntaxErr.Offset, 10), Err: err}
+	case errors.Is(err, io.EOF):
+		return &DecodeError{Reason: "empty body", Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{Reason: "truncated JSON", Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &DecodeError{Field: field, Reason: "unknown field", Err: err}
+	}
+	return &DecodeError{Reason: "malformed JSON", Err: err}
+}
+
+// jsonKind describes the JSON values decoded into t, without naming Go types.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	}
+	return "a valid value"
+}
@@ -0,0 +1,74 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/transport"
+)
+
+func TestJSONDecodeError(t *testing.T) {
+	type item struct {
+		Quantity int `json:"quantity"`
+	}
+	type order struct {
+		Customer string `json:"customer"`
+		Items    []item `json:"items"`
+	}
+	for _, tc := range []struct {
+		body   string
+		field  string
+		reason string
+	}{
+		{`{"customer": 1}`, "customer", "must be a string"},
+		{`{"items": [{"quantity": "two"}]}`, "items.0.quantity", "must be an integer"},
+		{`{"customer": "alice", "extra": true}`, "extra", "unknown field"},
+		{`{"customer": }`, "", "malformed JSON at offset 14"},
+		{``, "", "empty body"},
+		{`{"customer": "alice"`, "", "truncated JSON"},
+	} {
+		d := json.NewDecoder(strings.NewReader(tc.body))
+		d.DisallowUnknownFields()
+		var o order
+		errs := transport.DecodeErrors(transport.JSONDecodeError(d.Decode(&o)))
+		if len(errs) != 1 {
+			t.Errorf("%s: want 1 decode error, have %d", tc.body, len(errs))
+			continue
+		}
+		if want, have := tc.field, errs[0].Field; want != have {
+			t.Errorf("%s: want field %q, have %q", tc.body, want, have)
+		}
+		if want, have := tc.reason, errs[0].Reason; want != have {
+			t.Errorf("%s: want reason %q, have %q", tc.body, want, have)
+		}
+		if errs[0].Err == nil {
+			t.Errorf("%s: want underlying error, have nil", tc.body)
+		}
+	}
+
+	if err := transport.JSONDecodeError(nil); err != nil {
+		t.Errorf("want nil, have %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var (
+		customer = &transport.DecodeError{Field: "customer", Reason: "required"}
+		items    = &transport.DecodeError{Field: "items", Reason: "must not be empty"}
+	)
+	errs := transport.DecodeErrors(errors.Join(customer, errors.New("other"), items))
+	if want, have := 2, len(errs); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	if errs[0] != customer || errs[1] != items {
+		t.Errorf("want %v, have %v", []*transport.DecodeError{customer, items}, errs)
+	}
+	if errs := transport.DecodeErrors(errors.New("other")); errs != nil {
+		t.Errorf("want nil, have %v", errs)
+	}
+	if want, have := "customer: required", customer.Error(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,14 @@
+// Package errors maps business errors to the status codes of each transport,
+// so that services register the mapping of their errors once, rather than
+// repeating it in the error encoders of every transport.
+//
+//	var registry = kiterrors.NewRegistry()
+//
+//	func init() {
+//		registry.Register(ErrNotFound, kiterrors.Mapping{HTTP: http.StatusNotFound})
+//		registry.Register(ErrInvalidOrder, kiterrors.Mapping{HTTP: http.StatusBadRequest, JSONRPC: jsonrpc.InvalidParamsError})
+//	}
+//
+// The registry then provides error encoders for HTTP and JSON-RPC servers,
+// and an interceptor for gRPC servers.
+package errors
@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/transport"
+	httptransport "github.com/a69/kit.go/transport/http"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+// HTTPErrorEncoder returns an ErrorEncoder for HTTP servers, writing the error
+// as a JSON object, e.g. {"error": "not found"}, with the HTTP status of its
+// mapping. Decode errors are written as by httptransport.DefaultErrorEncoder,
+// listing the invalid fields. Errors mapped to a 5xx status are written with
+// the text of the status instead of their own, e.g. {"error": "Internal
+// Server Error"}, as they may hold details of the server. If the error
+// implements Headerer, the provided headers will be applied to the response.
+func (r *Registry) HTTPErrorEncoder() httptransport.ErrorEncoder {
+	return func(_ context.Context, err error, w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if headerer, ok := err.(httptransport.Headerer); ok {
+			for k, values := range headerer.Headers() {
+				for _, v := range values {
+					w.Header().Add(k, v)
+				}
+			}
+		}
+		code := r.Lookup(err).HTTP
+		w.WriteHeader(code)
+		if fields := transport.DecodeErrors(err); fields != nil {
+			w.Write(httptransport.DecodeErrorBody(fields))
+			return
+		}
+		msg := err.Error()
+		if code >= 500 {
+			msg = http.StatusText(code)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": msg,
+		})
+	}
+}
+
+// JSONRPCErrorEncoder returns an ErrorEncoder for JSON-RPC servers, writing
+// the error with the JSON-RPC code of its mapping, as jsonrpc.DefaultErrorEncoder
+// does. Errors implementing jsonrpc.ErrorCoder keep their own code and
+// message. Like for HTTPErrorEncoder, errors mapped to a 5xx HTTP status are
+// written with the generic message of their code, e.g. "Internal JSON-RPC
+// error.", instead of their own.
+func (r *Registry) JSONRPCErrorEncoder() httptransport.ErrorEncoder {
+	return func(ctx context.Context, err error, w http.ResponseWriter) {
+		if _, ok := err.(jsonrpc.ErrorCoder); !ok {
+			m := r.Lookup(err)
+			coded := codedError{error: err, code: m.JSONRPC}
+			if m.HTTP >= 500 {
+				if coded.message = jsonrpc.ErrorMessage(m.JSONRPC); coded.message == "" {
+					coded.message = jsonrpc.ErrorMessage(jsonrpc.InternalError)
+				}
+			}
+			err = coded
+		}
+		jsonrpc.DefaultErrorEncoder(ctx, err, w)
+	}
+}
+
+// codedError adds a JSON-RPC code to an error, keeping its headers, and
+// replaces its message, if set.
+type codedError struct {
+	error
+	code    int
+	message string
+}
+
+func (e codedError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	return e.error.Error()
+}
+
+func (e codedError) ErrorCode() int { return e.code }
+
+func (e codedError) Unwrap() error { return e.error }
+
+func (e codedError) Headers() http.Header {
+	if headerer, ok := e.error.(httptransport.Headerer); ok {
+		return headerer.Headers()
+	}
+	return nil
+}
+
+// GRPCError returns err as a gRPC status error, with the gRPC code of its
+// mapping, so that it's returned to clients with that code. Like for
+// HTTPErrorEncoder, errors mapped to a server-side code, i.e. Unknown,
+// Internal, Unavailable or DataLoss, get the name of the code as message,
+// e.g. "Internal", instead of their own. Errors that already carry a status,
+// and nil, are returned unchanged.
+func (r *Registry) GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := r.Lookup(err).GRPC
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return status.Error(code, code.String())
+	}
+	return status.Error(code, err.Error())
+}
+
+// UnaryServerInterceptor returns an interceptor converting the errors of
+// unary handlers with GRPCError, e.g.
+//
+//	grpc.NewServer(grpc.UnaryInterceptor(registry.UnaryServerInterceptor()))
+func (r *Registry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, r.GRPCError(err)
+	}
+}
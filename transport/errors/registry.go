@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/a69/kit.go/transport"
+	httptransport "github.com/a69/kit.go/transport/http"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+// Mapping is the status of an error in each transport. Zero fields take the
+// fallback of the registry, except a zero GRPC code, which is derived from
+// the HTTP status if it's set.
+type Mapping struct {
+	HTTP    int
+	GRPC    codes.Code
+	JSONRPC int
+}
+
+// Registry maps errors to their statuses. It's safe for concurrent use, but
+// errors are typically registered once, at init.
+type Registry struct {
+	mtx      sync.RWMutex
+	entries  []entry
+	fallback Mapping
+}
+
+type entry struct {
+	match   func(error) bool
+	mapping Mapping
+}
+
+// NewRegistry returns an empty registry, mapping all errors to the fallback
+// mapping, i.e. HTTP 500, codes.Internal and jsonrpc.InternalError, unless
+// set with SetFallback.
+func NewRegistry() *Registry {
+	return &Registry{
+		fallback: Mapping{
+			HTTP:    http.StatusInternalServerError,
+			GRPC:    codes.Internal,
+			JSONRPC: jsonrpc.InternalError,
+		},
+	}
+}
+
+// DefaultRegistry is the registry of Register and Lookup.
+var DefaultRegistry = NewRegistry()
+
+// Register maps errors matching target, as reported by errors.Is, to the
+// mapping in DefaultRegistry.
+func Register(target error, m Mapping) { DefaultRegistry.Register(target, m) }
+
+// Lookup returns the mapping of err in DefaultRegistry.
+func Lookup(err error) Mapping { return DefaultRegistry.Lookup(err) }
+
+// Register maps errors matching target, as reported by errors.Is, to the
+// mapping. Errors are looked up in the order they're registered.
+func (r *Registry) Register(target error, m Mapping) {
+	r.RegisterFunc(func(err error) bool { return errors.Is(err, target) }, m)
+}
+
+// RegisterFunc maps the errors for which match returns true to the mapping,
+// e.g. errors of a type, matched with errors.As.
+func (r *Registry) RegisterFunc(match func(error) bool, m Mapping) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.entries = append(r.entries, entry{match, m})
+}
+
+// SetFallback sets the mapping of unregistered errors. Its zero fields keep
+// their previous value.
+func (r *Registry) SetFallback(m Mapping) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.fallback = r.complete(m)
+}
+
+// Lookup returns the mapping of err, i.e. that of the first registered error
+// it matches, or the fallback. Unregistered errors implementing
+// httptransport.StatusCoder are mapped to their status code, e.g. the 415 of
+// httptransport.ErrUnsupportedMediaType, and those containing
+// transport.DecodeErrors to HTTP 400, codes.InvalidArgument and
+// jsonrpc.InvalidParamsError.
+func (r *Registry) Lookup(err error) Mapping {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	for _, e := range r.entries {
+		if e.match(err) {
+			return r.complete(e.mapping)
+		}
+	}
+	var sc httptransport.StatusCoder
+	if errors.As(err, &sc) {
+		if code := sc.StatusCode(); code != 0 {
+			return r.complete(Mapping{HTTP: code})
+		}
+	}
+	if transport.DecodeErrors(err) != nil {
+		return r.complete(Mapping{HTTP: http.StatusBadRequest, JSONRPC: jsonrpc.InvalidParamsError})
+	}
+	return r.fallback
+}
+
+func (r *Registry) complete(m Mapping) Mapping {
+	if m.GRPC == codes.OK && m.HTTP != 0 {
+		m.GRPC = GRPCCode(m.HTTP)
+	}
+	if m.HTTP == 0 {
+		m.HTTP = r.fallback.HTTP
+	}
+	if m.GRPC == codes.OK {
+		m.GRPC = r.fallback.GRPC
+	}
+	if m.JSONRPC == 0 {
+		m.JSONRPC = r.fallback.JSONRPC
+	}
+	return m
+}
+
+// GRPCCode returns the gRPC code corresponding to the HTTP status, following
+// the mapping of google.rpc.Code to HTTP statuses.
+func GRPCCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499: // client closed request
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if status >= 400 && status < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
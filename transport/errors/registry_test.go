@@ -0,0 +1,173 @@
+package errors_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/transport"
+	kiterrors "github.com/a69/kit.go/transport/errors"
+	httptransport "github.com/a69/kit.go/transport/http"
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+var (
+	errNotFound = errors.New("not found")
+	errInvalid  = errors.New("invalid")
+	errUnknown  = errors.New("unknown")
+	errDown     = errors.New("down")
+)
+
+type limitError struct{ retry int }
+
+func (e limitError) Error() string { return "limited" }
+
+func (e limitError) Headers() http.Header {
+	return http.Header{"Retry-After": []string{fmt.Sprint(e.retry)}}
+}
+
+func newRegistry() *kiterrors.Registry {
+	r := kiterrors.NewRegistry()
+	r.Register(errNotFound, kiterrors.Mapping{HTTP: http.StatusNotFound})
+	r.Register(errDown, kiterrors.Mapping{HTTP: http.StatusServiceUnavailable})
+	r.Register(errInvalid, kiterrors.Mapping{HTTP: http.StatusBadRequest, GRPC: codes.FailedPrecondition, JSONRPC: jsonrpc.InvalidParamsError})
+	r.RegisterFunc(func(err error) bool {
+		var le limitError
+		return errors.As(err, &le)
+	}, kiterrors.Mapping{HTTP: http.StatusTooManyRequests})
+	return r
+}
+
+func TestLookup(t *testing.T) {
+	r := newRegistry()
+	for _, tc := range []struct {
+		err  error
+		want kiterrors.Mapping
+	}{
+		{errNotFound, kiterrors.Mapping{HTTP: http.StatusNotFound, GRPC: codes.NotFound, JSONRPC: jsonrpc.InternalError}},
+		{fmt.Errorf("get: %w", errNotFound), kiterrors.Mapping{HTTP: http.StatusNotFound, GRPC: codes.NotFound, JSONRPC: jsonrpc.InternalError}},
+		{errInvalid, kiterrors.Mapping{HTTP: http.StatusBadRequest, GRPC: codes.FailedPrecondition, JSONRPC: jsonrpc.InvalidParamsError}},
+		{limitError{}, kiterrors.Mapping{HTTP: http.StatusTooManyRequests, GRPC: codes.ResourceExhausted, JSONRPC: jsonrpc.InternalError}},
+		{errUnknown, kiterrors.Mapping{HTTP: http.StatusInternalServerError, GRPC: codes.Internal, JSONRPC: jsonrpc.InternalError}},
+		{&transport.DecodeError{Reason: "malformed JSON"}, kiterrors.Mapping{HTTP: http.StatusBadRequest, GRPC: codes.InvalidArgument, JSONRPC: jsonrpc.InvalidParamsError}},
+		{fmt.Errorf("decode: %w", httptransport.ErrUnsupportedMediaType), kiterrors.Mapping{HTTP: http.StatusUnsupportedMediaType, GRPC: codes.FailedPrecondition, JSONRPC: jsonrpc.InternalError}},
+	} {
+		if want, have := tc.want, r.Lookup(tc.err); want != have {
+			t.Errorf("%v: want %+v, have %+v", tc.err, want, have)
+		}
+	}
+
+	r.SetFallback(kiterrors.Mapping{HTTP: http.StatusServiceUnavailable})
+	want := kiterrors.Mapping{HTTP: http.StatusServiceUnavailable, GRPC: codes.Unavailable, JSONRPC: jsonrpc.InternalError}
+	if have := r.Lookup(errUnknown); want != have {
+		t.Errorf("fallback: want %+v, have %+v", want, have)
+	}
+}
+
+func TestHTTPErrorEncoder(t *testing.T) {
+	encode := newRegistry().HTTPErrorEncoder()
+
+	rec := httptest.NewRecorder()
+	encode(context.Background(), errNotFound, rec)
+	if want, have := http.StatusNotFound, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "not found", body["error"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	encode(context.Background(), limitError{retry: 3}, rec)
+	if want, have := http.StatusTooManyRequests, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := "3", rec.Header().Get("Retry-After"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	encode(context.Background(), httptransport.ErrNotAcceptable, rec)
+	if want, have := http.StatusNotAcceptable, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	encode(context.Background(), errors.New("dial db: password=hunter2"), rec)
+	if want, have := http.StatusInternalServerError, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	body = nil
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusText(http.StatusInternalServerError), body["error"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestJSONRPCErrorEncoder(t *testing.T) {
+	encode := newRegistry().JSONRPCErrorEncoder()
+	for _, tc := range []struct {
+		err     error
+		want    int
+		message string
+	}{
+		{errInvalid, jsonrpc.InvalidParamsError, "invalid"},
+		{errNotFound, jsonrpc.InternalError, "not found"},
+		{errors.New("dial db: password=hunter2"), jsonrpc.InternalError, jsonrpc.ErrorMessage(jsonrpc.InternalError)},
+		{jsonrpc.Error{Code: jsonrpc.MethodNotFoundError, Message: "no such method"}, jsonrpc.MethodNotFoundError, "no such method"},
+	} {
+		rec := httptest.NewRecorder()
+		encode(context.Background(), tc.err, rec)
+		var resp jsonrpc.Response
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil {
+			t.Fatalf("%v: no error in response", tc.err)
+		}
+		if want, have := tc.want, resp.Error.Code; want != have {
+			t.Errorf("%v: want %d, have %d", tc.err, want, have)
+		}
+		if want, have := tc.message, resp.Error.Message; want != have {
+			t.Errorf("%v: want %q, have %q", tc.err, want, have)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	intercept := newRegistry().UnaryServerInterceptor()
+	for _, tc := range []struct {
+		err     error
+		want    codes.Code
+		message string
+	}{
+		{nil, codes.OK, ""},
+		{errNotFound, codes.NotFound, "not found"},
+		{errors.New("dial db: password=hunter2"), codes.Internal, "Internal"},
+		{fmt.Errorf("%w: dial db", errDown), codes.Unavailable, "Unavailable"},
+		{status.Error(codes.Aborted, "aborted"), codes.Aborted, "aborted"},
+	} {
+		_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, tc.err
+		})
+		if want, have := tc.want, status.Code(err); want != have {
+			t.Errorf("%v: want %s, have %s", tc.err, want, have)
+		}
+		if want, have := tc.message, status.Convert(err).Message(); want != have {
+			t.Errorf("%v: want %q, have %q", tc.err, want, have)
+		}
+	}
+}
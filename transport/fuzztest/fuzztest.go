@@ -0,0 +1,162 @@
+// Package fuzztest feeds arbitrary input through transport decoders, the
+// first code of a service to handle untrusted bytes, and fails when they
+// misbehave: a decoder must never panic, and must report bad input with a
+// proper error, never with a nil pointer wrapped in the error interface or
+// an empty message.
+//
+// Decoders are adapted to Targets, and fuzzed with Go's native fuzzing:
+//
+//	func FuzzDecodeSumRequest(f *testing.F) {
+//		fuzztest.Fuzz(f, fuzztest.HTTPBody(http.MethodPost, "/sum", decodeSumRequest),
+//			[]byte(`{"a":1,"b":2}`),
+//		)
+//	}
+//
+// Without -fuzz, the seeds run as regular test cases. Services with many
+// decoders can register them all in a Set, fuzzed by a single target.
+package fuzztest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	amqptransport "github.com/a69/kit.go/transport/amqp"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+// Target feeds data to the code under test, and fails t if it misbehaves.
+type Target func(t testing.TB, data []byte)
+
+// Bytes returns a Target passing data as is to dec, e.g. an awslambda or
+// jobs decoder, or the body decoder of a message.
+func Bytes[REQ any](dec func(context.Context, []byte) (REQ, error)) Target {
+	return func(t testing.TB, data []byte) {
+		t.Helper()
+		check(t, func() error {
+			_, err := dec(context.Background(), data)
+			return err
+		})
+	}
+}
+
+// HTTPRequest returns a Target parsing data as an HTTP/1.1 request, headers
+// and body included, and decoding it with dec. Data that doesn't parse as a
+// request is ignored, as it never reaches decoders.
+func HTTPRequest[REQ any](dec httptransport.DecodeRequestFunc[REQ]) Target {
+	return func(t testing.TB, data []byte) {
+		t.Helper()
+		r, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return
+		}
+		check(t, func() error {
+			_, err := dec(context.Background(), r)
+			return err
+		})
+	}
+}
+
+// HTTPBody returns a Target sending data as the JSON body of a request with
+// the method and target, and decoding it with dec. It's quicker to find bad
+// bodies with HTTPBody than with HTTPRequest, which mostly mutates headers.
+func HTTPBody[REQ any](method, target string, dec httptransport.DecodeRequestFunc[REQ]) Target {
+	return func(t testing.TB, data []byte) {
+		t.Helper()
+		r := httptest.NewRequest(method, target, bytes.NewReader(data))
+		r.Header.Set("Content-Type", "application/json")
+		check(t, func() error {
+			_, err := dec(context.Background(), r)
+			return err
+		})
+	}
+}
+
+// HTTPHandler returns a Target parsing data as an HTTP/1.1 request, and
+// serving it with h, so that requests go through routing and the decoders
+// of the servers of h, as they would in production. Data that doesn't parse
+// as a request is ignored.
+func HTTPHandler(h http.Handler) Target {
+	return func(t testing.TB, data []byte) {
+		t.Helper()
+		r, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return
+		}
+		r.RemoteAddr = "192.0.2.1:1234"
+		check(t, func() error {
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			return nil
+		})
+	}
+}
+
+// AMQPDelivery returns a Target decoding a JSON delivery with data as body
+// with dec.
+func AMQPDelivery[REQ any](dec amqptransport.DecodeRequestFunc[REQ]) Target {
+	return func(t testing.TB, data []byte) {
+		t.Helper()
+		d := &amqp.Delivery{ContentType: "application/json", Body: data}
+		check(t, func() error {
+			_, err := dec(context.Background(), d)
+			return err
+		})
+	}
+}
+
+// Fuzz fuzzes target, starting from the seeds.
+func Fuzz(f *testing.F, target Target, seeds ...[]byte) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		target(t, data)
+	})
+}
+
+// check calls fn, failing t if it panics or returns a malformed error.
+func check(t testing.TB, fn func() error) {
+	t.Helper()
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("decoder panicked: %v\n%s", r, debug.Stack())
+			}
+		}()
+		err = fn()
+	}()
+	if err == nil {
+		return
+	}
+	if v := reflect.ValueOf(err); isNil(v) {
+		t.Fatalf("decoder returned a nil %s as error", v.Type())
+	}
+	var msg string
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("Error method of %T panicked: %v", err, r)
+			}
+		}()
+		msg = err.Error()
+	}()
+	if msg == "" {
+		t.Fatalf("decoder returned a %T error with an empty message", err)
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Interface, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package fuzztest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/a69/kit.go/transport/fuzztest"
+)
+
+// recorder is a testing.TB recording whether the test failed.
+type recorder struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed, r.msg = true, fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// run runs target in a goroutine, as Fatalf stops it.
+func run(target fuzztest.Target, data []byte) *recorder {
+	r := &recorder{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		target(r, data)
+	}()
+	<-done
+	return r
+}
+
+type sum struct{ A, B int }
+
+type nilError struct{}
+
+func (*nilError) Error() string { return "nil error" }
+
+func TestTargets(t *testing.T) {
+	var (
+		ok = func(_ context.Context, b []byte) (s sum, err error) {
+			err = json.Unmarshal(b, &s)
+			return s, err
+		}
+		panics = func(_ context.Context, b []byte) (sum, error) {
+			return sum{A: int(b[0])}, nil
+		}
+		typedNil = func(context.Context, []byte) (sum, error) {
+			var err *nilError
+			return sum{}, err
+		}
+		empty = func(context.Context, []byte) (sum, error) {
+			return sum{}, errors.New("")
+		}
+	)
+	for _, tc := range []struct {
+		name   string
+		target fuzztest.Target
+		data   string
+		failed bool
+	}{
+		{"valid", fuzztest.Bytes(ok), `{"A":1}`, false},
+		{"invalid", fuzztest.Bytes(ok), `{"A":`, false},
+		{"panic", fuzztest.Bytes(panics), ``, true},
+		{"typed nil", fuzztest.Bytes(typedNil), `{}`, true},
+		{"empty message", fuzztest.Bytes(empty), `{}`, true},
+		{"HTTP body", fuzztest.HTTPBody(http.MethodPost, "/sum", func(_ context.Context, r *http.Request) (s sum, err error) {
+			err = json.NewDecoder(r.Body).Decode(&s)
+			return s, err
+		}), `{"A":`, false},
+		{"HTTP request", fuzztest.HTTPRequest(func(_ context.Context, r *http.Request) (string, error) {
+			return r.Header.Values("X-Sum")[0], nil
+		}), "GET / HTTP/1.1\r\nHost: a\r\n\r\n", true},
+		{"unparseable HTTP request", fuzztest.HTTPRequest(func(context.Context, *http.Request) (string, error) {
+			panic("unreachable")
+		}), "garbage", false},
+		{"AMQP delivery", fuzztest.AMQPDelivery(func(_ context.Context, d *amqp.Delivery) (s sum, err error) {
+			err = json.Unmarshal(d.Body, &s)
+			return s, err
+		}), `[]`, false},
+		{"HTTP handler", fuzztest.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]int
+			m[r.URL.Path] = 1
+		})), "GET /sum HTTP/1.1\r\nHost: a\r\n\r\n", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := run(tc.target, []byte(tc.data))
+			if want, have := tc.failed, r.failed; want != have {
+				t.Errorf("want failed %v, have %v (%s)", want, have, r.msg)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	var (
+		set   fuzztest.Set
+		calls = map[string][]string{}
+	)
+	for _, name := range []string{"a", "b"} {
+		set.Register(name, func(_ testing.TB, data []byte) {
+			calls[name] = append(calls[name], string(data))
+		})
+	}
+	set.Check(t, []byte("x"), []byte("y"))
+	if want, have := "map[a:[x y] b:[x y]]", fmt.Sprint(calls); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func FuzzSet(f *testing.F) {
+	var set fuzztest.Set
+	set.Register("sum", fuzztest.Bytes(func(_ context.Context, b []byte) (s sum, err error) {
+		err = json.Unmarshal(b, &s)
+		return s, err
+	}), []byte(`{"A":1,"B":2}`))
+	set.Fuzz(f)
+}
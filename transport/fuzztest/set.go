@@ -0,0 +1,56 @@
+package fuzztest
+
+import (
+	"testing"
+)
+
+// Set is a set of named Targets, fuzzed by a single fuzz target. The first
+// byte of the fuzzed data selects the target, and the rest is its input, so
+// that the fuzzer explores all the decoders of a service at once.
+type Set struct {
+	names   []string
+	targets []Target
+	seeds   [][]byte
+}
+
+// Register adds target to the set, along with seeds for its input.
+func (s *Set) Register(name string, target Target, seeds ...[]byte) {
+	i := len(s.targets)
+	s.names = append(s.names, name)
+	s.targets = append(s.targets, target)
+	s.seeds = append(s.seeds, []byte{byte(i)})
+	for _, seed := range seeds {
+		s.seeds = append(s.seeds, append([]byte{byte(i)}, seed...))
+	}
+}
+
+// Fuzz fuzzes the targets of the set, starting from their seeds.
+func (s *Set) Fuzz(f *testing.F) {
+	if len(s.targets) == 0 {
+		f.Fatal("no targets registered")
+	}
+	for _, seed := range s.seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		i := int(data[0]) % len(s.targets)
+		t.Logf("target %s", s.names[i])
+		s.targets[i](t, data[1:])
+	})
+}
+
+// Check runs every target of the set with every input, as a subtest named
+// after the target. It's the way to keep inputs that once made a decoder
+// misbehave as regular test cases.
+func (s *Set) Check(t *testing.T, inputs ...[]byte) {
+	for i, target := range s.targets {
+		t.Run(s.names[i], func(t *testing.T) {
+			for _, input := range inputs {
+				target(t, input)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/a69/kit.go/auth/authz"
+	"github.com/go-kit/log"
+)
+
+// PermissionOptionName is the full name of the method option declaring the
+// permission required to call a method. Declare it in your own proto files as
+//
+//	package kit;
+//	import "google/protobuf/descriptor.proto";
+//	extend google.protobuf.MethodOptions { string permission = 50001; }
+//
+// and annotate methods with option (kit.permission) = "profiles.delete";. Like
+// the (kit.redact) option, it's found through the global registry.
+const PermissionOptionName = "kit.permission"
+
+// PublicPermission marks a method as callable without authorization.
+const PublicPermission = "public"
+
+// Permission is what's required to call a method: the subject must be allowed
+// to perform Action on Resource. An empty Resource defaults to the full method
+// name, unless the interceptor was given an AuthzResource function.
+type Permission struct {
+	Action   string
+	Resource string
+}
+
+// AuthzInterceptor is a gRPC server interceptor, for unary and streaming
+// methods, that enforces per-method permissions before the handler, and so the
+// endpoint, is invoked.
+// The permission of a method is looked up in the registry given with
+// AuthzMethods first, then in the (kit.permission) option of the method's
+// descriptor. Methods without a permission are denied, unless
+// AuthzAllowUnlisted is set.
+//
+// Calls without a subject fail with codes.Unauthenticated, denied calls with
+// codes.PermissionDenied, and calls for which the Authorizer fails with
+// codes.Unavailable and a generic message, the error being logged instead.
+type AuthzInterceptor struct {
+	authorizer     authz.Authorizer
+	subject        func(ctx context.Context) (string, bool)
+	methods        map[string]Permission
+	resource       func(ctx context.Context, fullMethod string, req interface{}) string
+	allowUnlisted  bool
+	logger         log.Logger
+	option         atomic.Value // protoreflect.ExtensionType, once found
+	fromDescriptor sync.Map     // full method name → Permission
+}
+
+// AuthzOption sets an optional parameter for AuthzInterceptors.
+type AuthzOption func(*AuthzInterceptor)
+
+// AuthzMethods registers permissions by full method name, e.g.
+// "/pb.Profiles/Delete", taking precedence over proto annotations.
+func AuthzMethods(methods map[string]Permission) AuthzOption {
+	return func(a *AuthzInterceptor) {
+		for method, p := range methods {
+			a.methods[method] = p
+		}
+	}
+}
+
+// AuthzResource derives the resource of a call from its request, e.g. the ID
+// of the profile being deleted. It's used for permissions without a Resource.
+// Streams are authorized when they're opened, before any message is received,
+// so f is given a nil request for them.
+func AuthzResource(f func(ctx context.Context, fullMethod string, req interface{}) string) AuthzOption {
+	return func(a *AuthzInterceptor) { a.resource = f }
+}
+
+// AuthzAllowUnlisted lets calls to methods without a permission through.
+func AuthzAllowUnlisted() AuthzOption {
+	return func(a *AuthzInterceptor) { a.allowUnlisted = true }
+}
+
+// AuthzLogger sets the logger the errors of the Authorizer are logged to. By
+// default, they aren't logged.
+func AuthzLogger(logger log.Logger) AuthzOption {
+	return func(a *AuthzInterceptor) { a.logger = logger }
+}
+
+// NewAuthzInterceptor returns an AuthzInterceptor asking the Authorizer about
+// the subject returned by subject, typically taken from a JWT or mTLS identity
+// put in the context by a previous interceptor.
+func NewAuthzInterceptor(a authz.Authorizer, subject func(ctx context.Context) (string, bool), options ...AuthzOption) *AuthzInterceptor {
+	i := &AuthzInterceptor{
+		authorizer: a,
+		subject:    subject,
+		methods:    map[string]Permission{},
+		logger:     log.NewNopLogger(),
+	}
+	for _, option := range options {
+		option(i)
+	}
+	return i
+}
+
+// Unary implements grpc.UnaryServerInterceptor.
+func (i *AuthzInterceptor) Unary(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := i.authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream implements grpc.StreamServerInterceptor.
+func (i *AuthzInterceptor) Stream(
+	srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if err := i.authorize(ss.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (i *AuthzInterceptor) authorize(ctx context.Context, fullMethod string, req interface{}) error {
+	p, ok := i.permission(fullMethod)
+	switch {
+	case !ok && i.allowUnlisted, ok && p.Action == PublicPermission:
+		return nil
+	case !ok:
+		return status.Errorf(codes.PermissionDenied, "no permission declared for %s", fullMethod)
+	}
+
+	subject, ok := i.subject(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	resource := p.Resource
+	if resource == "" {
+		resource = fullMethod
+		if i.resource != nil {
+			resource = i.resource(ctx, fullMethod, req)
+		}
+	}
+
+	allowed, err := i.authorizer.Authorize(ctx, subject, p.Action, resource)
+	if err != nil {
+		i.logger.Log("method", fullMethod, "subject", subject, "err", err)
+		return status.Error(codes.Unavailable, "authorization unavailable")
+	}
+	if !allowed {
+		return status.Error(codes.PermissionDenied, authz.ErrUnauthorized.Error())
+	}
+	return nil
+}
+
+func (i *AuthzInterceptor) permission(fullMethod string) (Permission, bool) {
+	if p, ok := i.methods[fullMethod]; ok {
+		return p, true
+	}
+	if p, ok := i.fromDescriptor.Load(fullMethod); ok {
+		return p.(Permission), p.(Permission).Action != ""
+	}
+	// Until the option is registered, methods aren't known to lack a
+	// permission, so nothing is cached.
+	option := i.permissionOption()
+	if option == nil {
+		return Permission{}, false
+	}
+	p := lookup(option, fullMethod)
+	i.fromDescriptor.Store(fullMethod, p)
+	return p, p.Action != ""
+}
+
+// permissionOption returns the (kit.permission) extension, or nil if it isn't
+// registered. Only a successful lookup is cached, so that an extension
+// registered after the first call is still found.
+func (i *AuthzInterceptor) permissionOption() protoreflect.ExtensionType {
+	if option, ok := i.option.Load().(protoreflect.ExtensionType); ok {
+		return option
+	}
+	option, err := protoregistry.GlobalTypes.FindExtensionByName(PermissionOptionName)
+	if err != nil {
+		return nil
+	}
+	i.option.Store(option)
+	return option
+}
+
+// lookup reads the permission option of a method given as "/pkg.Service/Method".
+func lookup(option protoreflect.ExtensionType, fullMethod string) Permission {
+	md := findMethod(fullMethod)
+	if md == nil {
+		return Permission{}
+	}
+	opts := md.Options()
+	if opts == nil || !proto.HasExtension(opts, option) {
+		return Permission{}
+	}
+	action, _ := proto.GetExtension(opts, option).(string)
+	return Permission{Action: action}
+}
+
+// findMethod looks up the descriptor of a method given as "/pkg.Service/Method"
+// in the global registry. It returns nil if the method isn't registered.
+func findMethod(fullMethod string) protoreflect.MethodDescriptor {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return nil
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(method))
+}
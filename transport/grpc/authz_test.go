@@ -0,0 +1,226 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/emptypb" // registers google/protobuf/empty.proto
+
+	"github.com/a69/kit.go/auth/authz"
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+	"github.com/go-kit/log"
+)
+
+// registerAnnotatedService registers, in the global registries, the
+// (kit.permission) option and a service whose Delete method requires the
+// "profiles.delete" permission.
+func registerAnnotatedService(t *testing.T) {
+	t.Helper()
+	if _, err := protoregistry.GlobalFiles.FindFileByPath("authztest/profiles.proto"); err == nil {
+		return
+	}
+
+	kit, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("authztest/kit.proto"),
+		Package:    proto.String("kit"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("permission"),
+			Number:   proto.Int32(50001),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+			JsonName: proto.String("permission"),
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	option := dynamicpb.NewExtensionType(kit.Extensions().Get(0))
+	if err := protoregistry.GlobalFiles.RegisterFile(kit); err != nil {
+		t.Fatal(err)
+	}
+	if err := protoregistry.GlobalTypes.RegisterExtension(option); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, option, "profiles.delete")
+	profiles, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("authztest/profiles.proto"),
+		Package:    proto.String("authztest"),
+		Dependency: []string{"google/protobuf/empty.proto", "authztest/kit.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Profiles"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Delete"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty"), Options: opts},
+				{Name: proto.String("Get"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty")},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(profiles); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type subjectKey struct{}
+
+// TestAuthzInterceptorLateRegistration must run before the other tests
+// register the (kit.permission) option.
+func TestAuthzInterceptorLateRegistration(t *testing.T) {
+	if _, err := protoregistry.GlobalTypes.FindExtensionByName(grpctransport.PermissionOptionName); err == nil {
+		t.Skip("the permission option is already registered")
+	}
+
+	var (
+		authorizer = authz.AuthorizerFunc(func(context.Context, string, string, string) (bool, error) {
+			return false, nil
+		})
+		subject     = func(context.Context) (string, bool) { return "alice", true }
+		interceptor = grpctransport.NewAuthzInterceptor(authorizer, subject, grpctransport.AuthzAllowUnlisted())
+		info        = &grpc.UnaryServerInfo{FullMethod: "/authztest.Profiles/Delete"}
+		handler     = func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	)
+	if _, err := interceptor.Unary(context.Background(), nil, info, handler); err != nil {
+		t.Fatalf("before registration: want unlisted, have %v", err)
+	}
+
+	// Once registered, the annotation is enforced.
+	registerAnnotatedService(t)
+	if _, err := interceptor.Unary(context.Background(), nil, info, handler); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("after registration: want %s, have %v", codes.PermissionDenied, err)
+	}
+}
+
+func TestAuthzInterceptor(t *testing.T) {
+	registerAnnotatedService(t)
+
+	var (
+		authorizer = authz.AuthorizerFunc(func(_ context.Context, subject, action, resource string) (bool, error) {
+			return subject == "admin" || (action == "read" && resource == "/authztest.Profiles/List"), nil
+		})
+		subject = func(ctx context.Context) (string, bool) {
+			s, ok := ctx.Value(subjectKey{}).(string)
+			return s, ok
+		}
+		interceptor = grpctransport.NewAuthzInterceptor(authorizer, subject, grpctransport.AuthzMethods(map[string]grpctransport.Permission{
+			"/authztest.Profiles/List":   {Action: "read"},
+			"/authztest.Profiles/Health": {Action: grpctransport.PublicPermission},
+		}))
+		handler = func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	)
+
+	for _, tc := range []struct {
+		method  string
+		subject string
+		want    codes.Code
+	}{
+		{"/authztest.Profiles/Delete", "admin", codes.OK}, // annotated
+		{"/authztest.Profiles/Delete", "alice", codes.PermissionDenied},
+		{"/authztest.Profiles/Delete", "", codes.Unauthenticated},
+		{"/authztest.Profiles/List", "alice", codes.OK},              // registry
+		{"/authztest.Profiles/Health", "", codes.OK},                 // public
+		{"/authztest.Profiles/Get", "admin", codes.PermissionDenied}, // not annotated
+	} {
+		ctx := context.Background()
+		if tc.subject != "" {
+			ctx = context.WithValue(ctx, subjectKey{}, tc.subject)
+		}
+		_, err := interceptor.Unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+		if want, have := tc.want, status.Code(err); want != have {
+			t.Errorf("%s as %q: want %s, have %s", tc.method, tc.subject, want, have)
+		}
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context { return s.ctx }
+
+func TestAuthzInterceptorStream(t *testing.T) {
+	registerAnnotatedService(t)
+
+	var (
+		authorizer = authz.AuthorizerFunc(func(_ context.Context, subject, _, _ string) (bool, error) {
+			return subject == "admin", nil
+		})
+		subject = func(ctx context.Context) (string, bool) {
+			s, ok := ctx.Value(subjectKey{}).(string)
+			return s, ok
+		}
+		interceptor = grpctransport.NewAuthzInterceptor(authorizer, subject)
+		info        = &grpc.StreamServerInfo{FullMethod: "/authztest.Profiles/Delete", IsServerStream: true}
+	)
+
+	for _, tc := range []struct {
+		subject string
+		want    codes.Code
+	}{
+		{"admin", codes.OK},
+		{"alice", codes.PermissionDenied},
+		{"", codes.Unauthenticated},
+	} {
+		ctx := context.Background()
+		if tc.subject != "" {
+			ctx = context.WithValue(ctx, subjectKey{}, tc.subject)
+		}
+		called := false
+		err := interceptor.Stream(nil, contextStream{ctx: ctx}, info, func(interface{}, grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+		if want, have := tc.want, status.Code(err); want != have {
+			t.Errorf("%q: want %s, have %s", tc.subject, want, have)
+		}
+		if want, have := tc.want == codes.OK, called; want != have {
+			t.Errorf("%q: want handler called %v, have %v", tc.subject, want, have)
+		}
+	}
+}
+
+func TestAuthzInterceptorUnavailable(t *testing.T) {
+	var (
+		logged     []interface{}
+		authorizer = authz.AuthorizerFunc(func(context.Context, string, string, string) (bool, error) {
+			return false, errors.New("dial policy db: password=hunter2")
+		})
+		interceptor = grpctransport.NewAuthzInterceptor(authorizer,
+			func(context.Context) (string, bool) { return "alice", true },
+			grpctransport.AuthzMethods(map[string]grpctransport.Permission{"/authztest.Profiles/List": {Action: "read"}}),
+			grpctransport.AuthzLogger(log.LoggerFunc(func(keyvals ...interface{}) error {
+				logged = append(logged, keyvals...)
+				return nil
+			})),
+		)
+	)
+	_, err := interceptor.Unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/authztest.Profiles/List"}, func(context.Context, interface{}) (interface{}, error) {
+		t.Error("handler called")
+		return nil, nil
+	})
+	if want, have := codes.Unavailable, status.Code(err); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if msg := status.Convert(err).Message(); strings.Contains(msg, "hunter2") {
+		t.Errorf("want a generic message, have %q", msg)
+	}
+	if !strings.Contains(fmt.Sprint(logged...), "hunter2") {
+		t.Errorf("want the error logged, have %v", logged)
+	}
+}
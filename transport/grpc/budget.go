@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// BudgetKey is the metadata key carrying the caller's remaining deadline
+// budget, in milliseconds, between services. gRPC already propagates its own
+// deadline; the budget header additionally carries deadlines that were
+// propagated with endpoint.WithBudget from other transports.
+const BudgetKey = "x-deadline-budget"
+
+// SetBudgetHeader is a ClientRequestFunc that writes the remaining deadline
+// budget of the context (see endpoint.Budget) into the BudgetKey metadata.
+// Requests without a deadline are left untouched.
+func SetBudgetHeader(ctx context.Context, md *metadata.MD) context.Context {
+	if deadline, ok := endpoint.Budget(ctx); ok {
+		remaining := time.Until(deadline).Milliseconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		(*md)[BudgetKey] = []string{strconv.FormatInt(remaining, 10)}
+	}
+	return ctx
+}
+
+// PopulateBudget is a ServerRequestFunc that reads the BudgetKey metadata and
+// attaches the corresponding deadline to the context with endpoint.WithBudget.
+// Combine it with the endpoint.DeadlineBudget middleware to enforce it.
+// Missing or malformed values are ignored, and so are values too large to be
+// a time.Duration.
+func PopulateBudget(ctx context.Context, md metadata.MD) context.Context {
+	values := md.Get(BudgetKey)
+	if len(values) == 0 {
+		return ctx
+	}
+	ms, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil || ms < 0 || ms > math.MaxInt64/int64(time.Millisecond) {
+		return ctx
+	}
+	return endpoint.WithBudget(ctx, time.Now().Add(time.Duration(ms)*time.Millisecond))
+}
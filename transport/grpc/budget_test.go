@@ -0,0 +1,61 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/endpoint"
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestBudgetRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	md := metadata.MD{}
+	grpctransport.SetBudgetHeader(ctx, &md)
+	if len(md.Get(grpctransport.BudgetKey)) == 0 {
+		t.Fatal("budget metadata not set")
+	}
+
+	deadline, ok := endpoint.Budget(grpctransport.PopulateBudget(context.Background(), md))
+	if !ok {
+		t.Fatal("no budget in server context")
+	}
+	if remaining := time.Until(deadline); remaining < 59*time.Second || remaining > time.Minute {
+		t.Errorf("unexpected remaining budget %v", remaining)
+	}
+
+	md = metadata.MD{}
+	grpctransport.SetBudgetHeader(context.Background(), &md)
+	if values := md.Get(grpctransport.BudgetKey); len(values) != 0 {
+		t.Errorf("want no budget without deadline, have %v", values)
+	}
+}
+
+func TestPopulateBudgetIgnoresInvalidValues(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"soon",
+		"-1",
+		"1.5",
+		"9223372036854775807",  // overflows time.Duration in milliseconds
+		"9223372036855",        // just too large
+		"99999999999999999999", // overflows int64
+	} {
+		md := metadata.Pairs(grpctransport.BudgetKey, value)
+		if deadline, ok := endpoint.Budget(grpctransport.PopulateBudget(context.Background(), md)); ok {
+			t.Errorf("%q: want no budget, have %v", value, deadline)
+		}
+	}
+
+	// The largest representable budget is accepted, and lies in the future.
+	md := metadata.Pairs(grpctransport.BudgetKey, "9223372036854")
+	deadline, ok := endpoint.Budget(grpctransport.PopulateBudget(context.Background(), md))
+	if !ok || !deadline.After(time.Now().Add(100*365*24*time.Hour)) {
+		t.Errorf("want a budget far in the future, have %v (%v)", deadline, ok)
+	}
+}
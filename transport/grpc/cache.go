@@ -0,0 +1,257 @@
+package grpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// CacheOption sets an optional parameter for ResponseCaches.
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	maxStale   time.Duration
+	maxEntries int
+	staleOn    func(error) bool
+	partition  func(context.Context) string
+}
+
+// CacheMaxStale lets the cache serve responses up to d past their TTL when the
+// upstream call fails with an error that CacheStaleOn accepts. By default,
+// expired responses are never served.
+func CacheMaxStale(d time.Duration) CacheOption {
+	return func(o *cacheOptions) { o.maxStale = d }
+}
+
+// CacheMaxEntries bounds the number of cached responses. The least recently
+// used ones are evicted first. The default is 1000.
+func CacheMaxEntries(n int) CacheOption {
+	return func(o *cacheOptions) { o.maxEntries = n }
+}
+
+// CacheStaleOn sets the errors for which stale responses are served. By
+// default, these are the Unavailable, DeadlineExceeded, ResourceExhausted and
+// Aborted status codes, and errors without a gRPC status, such as those
+// returned by open circuit breakers.
+func CacheStaleOn(f func(error) bool) CacheOption {
+	return func(o *cacheOptions) { o.staleOn = f }
+}
+
+// CacheKey sets the function returning the caller a request is made on behalf
+// of, e.g. the subject of a token put in the context. Responses are stored apart
+// for each caller, so that they're never served to someone else. By default,
+// the callers are told apart by the "authorization" metadata of the context,
+// outgoing or incoming, as returned by CallerCredentials. Credentials added by
+// ClientBefore functions run after the cache, so they aren't seen.
+func CacheKey(f func(ctx context.Context) string) CacheOption {
+	return func(o *cacheOptions) { o.partition = f }
+}
+
+// CallerCredentials is the default CacheKey function. It returns the values
+// of the "authorization" metadata of the context, outgoing and incoming, so
+// that a server forwarding its callers' credentials doesn't mix up their
+// responses.
+func CallerCredentials(ctx context.Context) string {
+	var b bytes.Buffer
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			b.WriteString("o:" + v + "\n")
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			b.WriteString("i:" + v + "\n")
+		}
+	}
+	return b.String()
+}
+
+// UpstreamUnavailable is the default CacheStaleOn classifier.
+func UpstreamUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// ResponseCache caches the responses of a read-only RPC on the client side,
+// keyed by the method, the caller, as set with CacheKey, and a hash of the
+// request. Requests that are protobuf messages are hashed in their
+// deterministic wire format, other requests in their JSON encoding. Requests
+// that can't be encoded aren't cached.
+//
+// Responses are shared between callers, so they must not be modified.
+// Responses that are protobuf messages are cloned when stored.
+type ResponseCache[REQ any, RES any] struct {
+	method  string
+	ttl     time.Duration
+	opts    cacheOptions
+	enabled bool
+	now     func() time.Time
+
+	mtx     sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List // of *cacheEntry, most recently used first
+}
+
+type cacheEntry struct {
+	key    [sha256.Size]byte
+	value  interface{}
+	stored time.Time
+}
+
+// NewResponseCache returns a cache for the RPC given by its full method name,
+// e.g. "/pb.Profiles/Get", whose responses are fresh for ttl.
+//
+// Only RPCs without side effects may be cached. If the method's descriptor is
+// registered, the cache checks that it declares
+//
+//	option idempotency_level = NO_SIDE_EFFECTS;
+//
+// and is disabled otherwise, passing all requests through. Methods without a
+// registered descriptor are trusted to be read-only.
+func NewResponseCache[REQ any, RES any](fullMethod string, ttl time.Duration, options ...CacheOption) *ResponseCache[REQ, RES] {
+	opts := cacheOptions{maxEntries: 1000, staleOn: UpstreamUnavailable, partition: CallerCredentials}
+	for _, option := range options {
+		option(&opts)
+	}
+	enabled := true
+	if md := findMethod(fullMethod); md != nil {
+		mo, _ := md.Options().(*descriptorpb.MethodOptions)
+		enabled = mo.GetIdempotencyLevel() == descriptorpb.MethodOptions_NO_SIDE_EFFECTS
+	}
+	return &ResponseCache[REQ, RES]{
+		method:  fullMethod,
+		ttl:     ttl,
+		opts:    opts,
+		enabled: enabled,
+		now:     time.Now,
+		entries: map[[sha256.Size]byte]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Middleware returns a Middleware that serves fresh responses from the cache,
+// and caches the responses of the next endpoint. Place it outside circuit
+// breakers and other fallbacks, e.g.
+//
+//	cache.Middleware()(circuitbreaker.Gobreaker[REQ, RES](cb)(client.Endpoint()))
+//
+// so that stale responses can be served while the breaker is open.
+func (c *ResponseCache[REQ, RES]) Middleware() endpoint.Middleware[REQ, RES] {
+	return func(next endpoint.Endpoint[REQ, RES]) endpoint.Endpoint[REQ, RES] {
+		if !c.enabled {
+			return next
+		}
+		return func(ctx context.Context, request REQ) (RES, error) {
+			key, ok := c.key(ctx, request)
+			if !ok {
+				return next(ctx, request)
+			}
+			cached, age, found := c.get(key)
+			if found && age < c.ttl {
+				return cached, nil
+			}
+
+			response, err := next(ctx, request)
+			if err == nil {
+				c.put(key, response)
+				return response, nil
+			}
+			if found && age < c.ttl+c.opts.maxStale && c.opts.staleOn(err) {
+				return cached, nil
+			}
+			return response, err
+		}
+	}
+}
+
+// Purge drops all cached responses.
+func (c *ResponseCache[REQ, RES]) Purge() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.entries = map[[sha256.Size]byte]*list.Element{}
+	c.lru.Init()
+}
+
+func (c *ResponseCache[REQ, RES]) key(ctx context.Context, request REQ) (key [sha256.Size]byte, ok bool) {
+	var (
+		b   []byte
+		err error
+	)
+	if m, isProto := any(request).(proto.Message); isProto {
+		b, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	} else {
+		b, err = json.Marshal(request)
+	}
+	if err != nil {
+		return key, false
+	}
+	h := sha256.New()
+	io.WriteString(h, c.method+"\x00"+c.opts.partition(ctx)+"\x00")
+	h.Write(b)
+	h.Sum(key[:0])
+	return key, true
+}
+
+func (c *ResponseCache[REQ, RES]) get(key [sha256.Size]byte) (response RES, age time.Duration, ok bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return response, 0, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	age = c.now().Sub(entry.stored)
+	if age >= c.ttl+c.opts.maxStale {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return response, 0, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value.(RES), age, true
+}
+
+func (c *ResponseCache[REQ, RES]) put(key [sha256.Size]byte, response RES) {
+	var value interface{} = response
+	if m, ok := value.(proto.Message); ok {
+		value = proto.Clone(m)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key: key, value: value, stored: c.now()}
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, stored: c.now()})
+	for c.opts.maxEntries > 0 && c.lru.Len() > c.opts.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,150 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestResponseCache(t *testing.T) {
+	var (
+		calls    int
+		failure  error
+		upstream = func(_ context.Context, id string) (*wrapperspb.StringValue, error) {
+			calls++
+			if failure != nil {
+				return nil, failure
+			}
+			return wrapperspb.String("item " + id), nil
+		}
+		cache = grpctransport.NewResponseCache[string, *wrapperspb.StringValue]("/cachetest.Items/Get", 20*time.Millisecond,
+			grpctransport.CacheMaxStale(time.Hour),
+		)
+		e = cache.Middleware()(upstream)
+	)
+
+	get := func(id string) (string, error) {
+		t.Helper()
+		res, err := e(context.Background(), id)
+		return res.GetValue(), err
+	}
+
+	for i := 0; i < 3; i++ {
+		if res, err := get("1"); err != nil || res != "item 1" {
+			t.Fatalf("want item 1, have %q, %v", res, err)
+		}
+	}
+	if _, err := get("2"); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+
+	// Once expired, stale responses are only served if the upstream is
+	// unavailable.
+	time.Sleep(30 * time.Millisecond)
+	failure = status.Error(codes.Unavailable, "connection refused")
+	if res, err := get("1"); err != nil || res != "item 1" {
+		t.Errorf("want stale item 1, have %q, %v", res, err)
+	}
+	failure = status.Error(codes.NotFound, "no such item")
+	if _, err := get("1"); status.Code(err) != codes.NotFound {
+		t.Errorf("want %v, have %v", codes.NotFound, err)
+	}
+	failure = errors.New("circuit breaker is open")
+	if res, err := get("2"); err != nil || res != "item 2" {
+		t.Errorf("want stale item 2, have %q, %v", res, err)
+	}
+	if _, err := get("3"); err != failure {
+		t.Errorf("want %v, have %v", failure, err)
+	}
+}
+
+func TestResponseCacheCallers(t *testing.T) {
+	var (
+		calls    int
+		upstream = func(ctx context.Context, id string) (*wrapperspb.StringValue, error) {
+			calls++
+			md, _ := metadata.FromOutgoingContext(ctx)
+			return wrapperspb.String(md.Get("authorization")[0] + "'s item " + id), nil
+		}
+		e = grpctransport.NewResponseCache[string, *wrapperspb.StringValue]("/cachetest.Items/Get", time.Hour).Middleware()(upstream)
+	)
+
+	for _, token := range []string{"alice", "bob", "alice"} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+		res, err := e(ctx, "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := token+"'s item 1", res.GetValue(); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestResponseCacheIdempotency(t *testing.T) {
+	if _, err := protoregistry.GlobalFiles.FindFileByPath("cachetest/items.proto"); err != nil {
+		fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:       proto.String("cachetest/items.proto"),
+			Package:    proto.String("cachetest"),
+			Dependency: []string{"google/protobuf/wrappers.proto"},
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("Items"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Get"),
+						InputType:  proto.String(".google.protobuf.StringValue"),
+						OutputType: proto.String(".google.protobuf.StringValue"),
+						Options:    &descriptorpb.MethodOptions{IdempotencyLevel: descriptorpb.MethodOptions_NO_SIDE_EFFECTS.Enum()},
+					},
+					{
+						Name:       proto.String("Put"),
+						InputType:  proto.String(".google.protobuf.StringValue"),
+						OutputType: proto.String(".google.protobuf.StringValue"),
+					},
+				},
+			}},
+		}, protoregistry.GlobalFiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for method, want := range map[string]int{"/cachetest.Items/Get": 1, "/cachetest.Items/Put": 2} {
+		var calls int
+		e := grpctransport.NewResponseCache[*wrapperspb.StringValue, *wrapperspb.StringValue](method, time.Hour).Middleware()(
+			func(_ context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+				calls++
+				return req, nil
+			},
+		)
+		for i := 0; i < 2; i++ {
+			if _, err := e(context.Background(), wrapperspb.String("x")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if want != calls {
+			t.Errorf("%s: want %d calls, have %d", method, want, calls)
+		}
+	}
+}
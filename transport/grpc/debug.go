@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/tracing"
+)
+
+// DebugToContext returns a ServerRequestFunc that forces trace sampling in the
+// context if the request metadata carries tracing.DebugMetadataKey and allow
+// accepts it. A nil allow accepts every request, and should only be used
+// behind a trusted edge.
+func DebugToContext(allow func(metadata.MD) bool) ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		if values := md.Get(tracing.DebugMetadataKey); len(values) > 0 && tracing.DebugRequested(values[0]) && (allow == nil || allow(md)) {
+			ctx = tracing.WithForceSample(ctx)
+		}
+		return ctx
+	}
+}
+
+// ContextToDebug returns a ClientRequestFunc that sets tracing.DebugMetadataKey
+// if sampling is forced in the context.
+func ContextToDebug() ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if tracing.ForceSampled(ctx) {
+			md.Set(tracing.DebugMetadataKey, "1")
+		}
+		return ctx
+	}
+}
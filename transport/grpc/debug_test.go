@@ -0,0 +1,26 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/tracing"
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestDebugToContext(t *testing.T) {
+	ctx := context.Background()
+	md := metadata.MD{}
+	grpctransport.ContextToDebug()(tracing.WithForceSample(ctx), &md)
+	if !tracing.ForceSampled(grpctransport.DebugToContext(nil)(ctx, md)) {
+		t.Error("want force sampled context on the server side")
+	}
+	if tracing.ForceSampled(grpctransport.DebugToContext(nil)(ctx, metadata.MD{})) {
+		t.Error("want no force sampling without metadata")
+	}
+	if tracing.ForceSampled(grpctransport.DebugToContext(func(metadata.MD) bool { return false })(ctx, md)) {
+		t.Error("want no force sampling for rejected metadata")
+	}
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/a69/kit.go/log/level"
+)
+
+// LogLevelServiceName is the name of the service registered by
+// RegisterLogLevelService. Its methods are
+//
+//	rpc GetLevel(google.protobuf.Empty) returns (google.protobuf.StringValue);
+//	rpc SetLevel(google.protobuf.StringValue) returns (google.protobuf.StringValue);
+//
+// and can be called with any gRPC client, e.g.
+//
+//	grpcurl -d '"debug"' host:port kit.log.LevelService/SetLevel
+//
+// given the service definition, since it isn't available through reflection.
+const LogLevelServiceName = "kit.log.LevelService"
+
+// RegisterLogLevelService registers a service getting and setting the level
+// of d on s, the gRPC counterpart of d's ServeHTTP method. SetLevel fails
+// with codes.InvalidArgument for strings that aren't level names, and both
+// methods return the current level. Like any admin endpoint, it should only be
+// exposed to operators, e.g. on a separate server, or behind an interceptor
+// authorizing the callers.
+func RegisterLogLevelService(s *grpc.Server, d *level.Dynamic) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: LogLevelServiceName,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "GetLevel",
+				Handler: logLevelHandler("GetLevel", func() interface{} { return new(emptypb.Empty) }, func(interface{}) error {
+					return nil
+				}),
+			},
+			{
+				MethodName: "SetLevel",
+				Handler: logLevelHandler("SetLevel", func() interface{} { return new(wrapperspb.StringValue) }, func(req interface{}) error {
+					lvl, err := level.Parse(req.(*wrapperspb.StringValue).GetValue())
+					if err != nil {
+						return status.Error(codes.InvalidArgument, err.Error())
+					}
+					d.SetLevel(lvl)
+					return nil
+				}),
+			},
+		},
+	}, d)
+}
+
+// logLevelHandler returns the handler of a log level service method, which
+// decodes the request into a new message, calls do with it, and returns the
+// current level. It mirrors the handlers generated by protoc-gen-go-grpc.
+func logLevelHandler(
+	method string, newRequest func() interface{}, do func(req interface{}) error,
+) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := newRequest()
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := do(req); err != nil {
+				return nil, err
+			}
+			return wrapperspb.String(srv.(*level.Dynamic).Level().String()), nil
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + LogLevelServiceName + "/" + method}
+		return interceptor(ctx, in, info, handler)
+	}
+}
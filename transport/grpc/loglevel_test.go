@@ -0,0 +1,62 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/a69/kit.go/log"
+	"github.com/a69/kit.go/log/level"
+	kitgrpc "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestLogLevelService(t *testing.T) {
+	logger := level.NewDynamic(log.NewNopLogger(), level.InfoValue())
+
+	lis := bufconn.Listen(1 << 16)
+	server := grpc.NewServer()
+	kitgrpc.RegisterLogLevelService(server, logger)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var (
+		ctx    = context.Background()
+		method = "/" + kitgrpc.LogLevelServiceName + "/"
+		out    = &wrapperspb.StringValue{}
+	)
+	if err := conn.Invoke(ctx, method+"GetLevel", &emptypb.Empty{}, out); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "info", out.GetValue(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if err := conn.Invoke(ctx, method+"SetLevel", wrapperspb.String("debug"), out); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := level.DebugValue(), logger.Level(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	err = conn.Invoke(ctx, method+"SetLevel", wrapperspb.String("verbose"), out)
+	if want, have := codes.InvalidArgument, status.Code(err); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// InterceptorMetrics are the metrics recorded by the metrics interceptors, of
+// servers and clients. Any of them may be nil.
+type InterceptorMetrics struct {
+	// Requests is incremented for every call, with a "method" label set to
+	// the full method name, and a "code" label set to the status code.
+	Requests metrics.Counter
+
+	// InFlight is the number of calls being handled.
+	InFlight metrics.Gauge
+
+	// Duration observes the duration of every call, in seconds, with the same
+	// labels as Requests.
+	Duration metrics.Histogram
+
+	// RequestSize and ResponseSize observe the size in bytes of every
+	// request and response message, with a "method" label.
+	RequestSize  metrics.Histogram
+	ResponseSize metrics.Histogram
+}
+
+// UnaryMetricsInterceptor returns a gRPC unary server interceptor recording
+// the metrics for every call at the transport level. Unlike endpoint
+// middlewares, it sees calls that fail before reaching the endpoint, e.g.
+// because they couldn't be decoded, and handlers that panic, which are
+// recorded with codes.Internal before the panic is propagated.
+func UnaryMetricsInterceptor(m InterceptorMetrics) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		defer m.begin(info.FullMethod)(&err)
+		m.observeSize(m.RequestSize, info.FullMethod, req)
+		resp, err = handler(ctx, req)
+		if err == nil {
+			m.observeSize(m.ResponseSize, info.FullMethod, resp)
+		}
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor is the streaming counterpart of
+// UnaryMetricsInterceptor. The sizes of all messages of a stream are
+// observed.
+func StreamMetricsInterceptor(m InterceptorMetrics) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) (err error) {
+		defer m.begin(info.FullMethod)(&err)
+		return handler(srv, &metricsStream{ServerStream: ss, m: m, method: info.FullMethod})
+	}
+}
+
+// UnaryClientMetricsInterceptor returns a gRPC unary client interceptor
+// recording the metrics for every call made, like UnaryMetricsInterceptor
+// does for the calls served.
+func UnaryClientMetricsInterceptor(m InterceptorMetrics) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) (err error) {
+		defer m.begin(method)(&err)
+		m.observeSize(m.RequestSize, method, req)
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			m.observeSize(m.ResponseSize, method, reply)
+		}
+		return err
+	}
+}
+
+// begin records the start of a call, and returns the function recording its
+// end, to be deferred so that it also runs when the handler panics.
+func (m InterceptorMetrics) begin(method string) func(*error) {
+	if m.InFlight != nil {
+		m.InFlight.Add(1)
+	}
+	begin := time.Now()
+	return func(err *error) {
+		r := recover()
+		code := status.Code(*err)
+		if r != nil {
+			code = codes.Internal
+		}
+		if m.InFlight != nil {
+			m.InFlight.Add(-1)
+		}
+		if m.Requests != nil {
+			m.Requests.With("method", method, "code", code.String()).Add(1)
+		}
+		if m.Duration != nil {
+			m.Duration.With("method", method, "code", code.String()).Observe(time.Since(begin).Seconds())
+		}
+		if r != nil {
+			panic(r)
+		}
+	}
+}
+
+func (m InterceptorMetrics) observeSize(h metrics.Histogram, method string, msg interface{}) {
+	if h == nil {
+		return
+	}
+	if pm, ok := msg.(proto.Message); ok {
+		h.With("method", method).Observe(float64(proto.Size(pm)))
+	}
+}
+
+type metricsStream struct {
+	grpc.ServerStream
+	m      InterceptorMetrics
+	method string
+}
+
+func (s *metricsStream) RecvMsg(msg interface{}) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.m.observeSize(s.m.RequestSize, s.method, msg)
+	}
+	return err
+}
+
+func (s *metricsStream) SendMsg(msg interface{}) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.m.observeSize(s.m.ResponseSize, s.method, msg)
+	}
+	return err
+}
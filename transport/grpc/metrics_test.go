@@ -0,0 +1,100 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	kitgrpc "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestUnaryMetricsInterceptor(t *testing.T) {
+	var (
+		requests    = metricstest.NewCounter("requests")
+		inFlight    = metricstest.NewGauge("in_flight")
+		reqSize     = metricstest.NewHistogram("request_size")
+		respSize    = metricstest.NewHistogram("response_size")
+		info        = &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"}
+		interceptor = kitgrpc.UnaryMetricsInterceptor(kitgrpc.InterceptorMetrics{
+			Requests:     requests,
+			InFlight:     inFlight,
+			RequestSize:  reqSize,
+			ResponseSize: respSize,
+		})
+	)
+
+	interceptor(context.Background(), wrapperspb.String("abc"), info, func(context.Context, interface{}) (interface{}, error) {
+		if want, have := 1.0, inFlight.Value(); want != have {
+			t.Errorf("in flight: want %v, have %v", want, have)
+		}
+		return wrapperspb.String("hello"), nil
+	})
+	interceptor(context.Background(), wrapperspb.String("abc"), info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "can't decode")
+	})
+	func() {
+		defer func() { recover() }()
+		interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		})
+	}()
+
+	for code, want := range map[codes.Code]float64{codes.OK: 1, codes.InvalidArgument: 1, codes.Internal: 1} {
+		if have := requests.ValueWith("method", info.FullMethod, "code", code.String()); want != have {
+			t.Errorf("%s: want %v, have %v", code, want, have)
+		}
+	}
+	if want, have := 0.0, inFlight.Value(); want != have {
+		t.Errorf("in flight: want %v, have %v", want, have)
+	}
+	if want, have := 2, len(reqSize.ObservationsWith("method", info.FullMethod)); want != have {
+		t.Errorf("request size: want %d observations, have %d", want, have)
+	}
+	if want, have := []float64{7}, respSize.ObservationsWith("method", info.FullMethod); len(have) != 1 || want[0] != have[0] {
+		t.Errorf("response size: want %v, have %v", want, have)
+	}
+}
+
+func TestUnaryClientMetricsInterceptor(t *testing.T) {
+	var (
+		requests    = metricstest.NewCounter("requests")
+		reqSize     = metricstest.NewHistogram("request_size")
+		respSize    = metricstest.NewHistogram("response_size")
+		method      = "/pkg.Service/Method"
+		interceptor = kitgrpc.UnaryClientMetricsInterceptor(kitgrpc.InterceptorMetrics{
+			Requests:     requests,
+			RequestSize:  reqSize,
+			ResponseSize: respSize,
+		})
+	)
+
+	reply := wrapperspb.String("")
+	err := interceptor(context.Background(), method, wrapperspb.String("abc"), reply, nil, func(_ context.Context, _ string, _, reply interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		reply.(*wrapperspb.StringValue).Value = "hello"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor(context.Background(), method, wrapperspb.String("abc"), reply, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "no connection")
+	})
+
+	for code, want := range map[codes.Code]float64{codes.OK: 1, codes.Unavailable: 1} {
+		if have := requests.ValueWith("method", method, "code", code.String()); want != have {
+			t.Errorf("%s: want %v, have %v", code, want, have)
+		}
+	}
+	if want, have := "[5 5]", fmt.Sprint(reqSize.ObservationsWith("method", method)); want != have {
+		t.Errorf("request size: want %s, have %s", want, have)
+	}
+	if want, have := "[7]", fmt.Sprint(respSize.ObservationsWith("method", method)); want != have {
+		t.Errorf("response size: want %s, have %s", want, have)
+	}
+}
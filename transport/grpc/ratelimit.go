@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/a69/kit.go/ratelimit"
+)
+
+// RateLimitedStatus converts rate limiting errors to a gRPC status with the
+// RESOURCE_EXHAUSTED code. If err is, or wraps, a *ratelimit.RateLimitedError
+// with a Reset, the status carries a RetryInfo detail telling the client when
+// to retry. It returns false for other errors.
+func RateLimitedStatus(err error) (*status.Status, bool) {
+	if !errors.Is(err, ratelimit.ErrLimited) {
+		return nil, false
+	}
+	st := status.New(codes.ResourceExhausted, err.Error())
+	var rle *ratelimit.RateLimitedError
+	if errors.As(err, &rle) && rle.Reset > 0 {
+		if detailed, derr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(rle.Reset)}); derr == nil {
+			st = detailed
+		}
+	}
+	return st, true
+}
+
+// RateLimitInterceptor is a grpc UnaryServerInterceptor that converts the
+// rate limiting errors returned by handlers with RateLimitedStatus, so that
+// clients get RESOURCE_EXHAUSTED rather than UNKNOWN. Chain it with the
+// Interceptor, e.g.
+//
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(kitgrpc.Interceptor, kitgrpc.RateLimitInterceptor))
+func RateLimitInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	resp, err = handler(ctx, req)
+	if st, ok := RateLimitedStatus(err); ok {
+		return resp, st.Err()
+	}
+	return resp, err
+}
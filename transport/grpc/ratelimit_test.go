@@ -0,0 +1,49 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/ratelimit"
+	kitgrpc "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	call := func(err error) error {
+		_, have := kitgrpc.RateLimitInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		})
+		return have
+	}
+
+	st := status.Convert(call(&ratelimit.RateLimitedError{Limit: 10, Reset: 1500 * time.Millisecond}))
+	if want, have := codes.ResourceExhausted, st.Code(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 1, len(st.Details()); want != have {
+		t.Fatalf("want %d details, have %d", want, have)
+	}
+	info, ok := st.Details()[0].(*errdetails.RetryInfo)
+	if !ok {
+		t.Fatalf("want *errdetails.RetryInfo, have %T", st.Details()[0])
+	}
+	if want, have := 1500*time.Millisecond, info.RetryDelay.AsDuration(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	if want, have := codes.ResourceExhausted, status.Code(call(ratelimit.ErrLimited)); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+
+	other := errors.New("other")
+	if want, have := other, call(other); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"sync/atomic"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// RedactOptionName is the full name of the field option that marks a field as
+// sensitive. Declare it in your own proto files as
+//
+//	package kit;
+//	import "google/protobuf/descriptor.proto";
+//	extend google.protobuf.FieldOptions { bool redact = 50000; }
+//
+// and annotate fields with [(kit.redact) = true]. The Redactor finds the
+// extension through the global registry, so no Go kit generated code is
+// required.
+const RedactOptionName = "kit.redact"
+
+// RedactedValue replaces the contents of sensitive string fields.
+const RedactedValue = "[REDACTED]"
+
+// Redactor strips sensitive fields from protobuf messages before they are
+// logged or attached to spans by debugging middlewares. A field is sensitive
+// if it's annotated with the (kit.redact) option, or if its full name, e.g.
+// "pb.LoginRequest.password", was registered with the Redactor.
+type Redactor struct {
+	fields map[protoreflect.FullName]struct{}
+	option atomic.Value // protoreflect.ExtensionType, once found
+}
+
+// NewRedactor returns a Redactor treating the given fields, in addition to
+// any annotated with the (kit.redact) option, as sensitive.
+func NewRedactor(sensitive ...protoreflect.FullName) *Redactor {
+	r := &Redactor{fields: map[protoreflect.FullName]struct{}{}}
+	for _, name := range sensitive {
+		r.fields[name] = struct{}{}
+	}
+	return r
+}
+
+// Redact returns a copy of v with its sensitive fields replaced: strings are
+// set to RedactedValue, and all other kinds are cleared. Nested messages,
+// lists and maps are redacted recursively. Values that aren't protobuf
+// messages are returned unchanged, so Redact can be applied to the
+// interface{} requests and responses seen by gRPC handlers and interceptors.
+func (r *Redactor) Redact(v interface{}) interface{} {
+	msg, ok := v.(proto.Message)
+	if !ok || msg == nil {
+		return v
+	}
+	clone := proto.Clone(msg)
+	r.redact(clone.ProtoReflect())
+	return clone
+}
+
+func (r *Redactor) redact(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case r.sensitive(fd):
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(RedactedValue))
+			} else {
+				m.Clear(fd)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					r.redact(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					r.redact(list.Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			r.redact(v.Message())
+		}
+		return true
+	})
+}
+
+func (r *Redactor) sensitive(fd protoreflect.FieldDescriptor) bool {
+	if _, ok := r.fields[fd.FullName()]; ok {
+		return true
+	}
+	option := r.redactOption()
+	if option == nil {
+		return false
+	}
+	opts := fd.Options()
+	if opts == nil || !proto.HasExtension(opts, option) {
+		return false
+	}
+	redact, _ := proto.GetExtension(opts, option).(bool)
+	return redact
+}
+
+// redactOption returns the (kit.redact) extension, or nil if it isn't
+// registered. Only a successful lookup is cached, so that an extension
+// registered after the first message was redacted is still found.
+func (r *Redactor) redactOption() protoreflect.ExtensionType {
+	if option, ok := r.option.Load().(protoreflect.ExtensionType); ok {
+		return option
+	}
+	option, err := protoregistry.GlobalTypes.FindExtensionByName(RedactOptionName)
+	if err != nil {
+		return nil
+	}
+	r.option.Store(option)
+	return option
+}
@@ -0,0 +1,220 @@
+package grpc_test
+
+import (
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+	"github.com/a69/kit.go/transport/grpc/_grpc_test/pb"
+)
+
+func TestRedactorRegistry(t *testing.T) {
+	var (
+		r        = grpctransport.NewRedactor(protoreflect.FullName("pb.TestRequest.a"), protoreflect.FullName("pb.TestRequest.b"))
+		original = &pb.TestRequest{A: "secret", B: 42}
+		redacted = r.Redact(original).(*pb.TestRequest)
+	)
+	if want, have := grpctransport.RedactedValue, redacted.A; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := int64(0), redacted.B; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if original.A != "secret" || original.B != 42 {
+		t.Errorf("original message was modified: %v", original)
+	}
+}
+
+func TestRedactorPassThrough(t *testing.T) {
+	r := grpctransport.NewRedactor()
+	if want, have := "plain", r.Redact("plain"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	msg := &pb.TestResponse{V: "visible"}
+	if want, have := "visible", r.Redact(msg).(*pb.TestResponse).V; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+// redactTypes builds, at run time, the (kit.redact) extension, and messages
+// using it, as protoc would generate them from
+//
+//	package kit;
+//	extend google.protobuf.FieldOptions { bool redact = 50000; }
+//
+//	package redacttest;
+//	message Credentials { string user = 1; string token = 2 [(kit.redact) = true]; }
+//	message Login {
+//	  Credentials credentials = 1;
+//	  repeated Credentials history = 2;
+//	  map<string, Credentials> by_host = 3;
+//	  repeated string passwords = 4 [(kit.redact) = true];
+//	}
+func redactTypes(t *testing.T) (protoreflect.ExtensionType, protoreflect.MessageDescriptor) {
+	t.Helper()
+	redactOnce.Do(func() { redactOption, redactLogin, redactErr = buildRedactTypes() })
+	if redactErr != nil {
+		t.Fatal(redactErr)
+	}
+	return redactOption, redactLogin
+}
+
+var (
+	redactOnce   sync.Once
+	redactOption protoreflect.ExtensionType
+	redactLogin  protoreflect.MessageDescriptor
+	redactErr    error
+)
+
+func buildRedactTypes() (protoreflect.ExtensionType, protoreflect.MessageDescriptor, error) {
+	kitFile, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("kit/redact.proto"),
+		Package:    proto.String("kit"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("redact"),
+			Number:   proto.Int32(50000),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(),
+			Extendee: proto.String(".google.protobuf.FieldOptions"),
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	option := dynamicpb.NewExtensionType(kitFile.Extensions().Get(0))
+
+	redacted := &descriptorpb.FieldOptions{}
+	proto.SetExtension(redacted, option, true)
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string, opts *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+			Options:  opts,
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg      = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	files := new(protoregistry.Files)
+	files.RegisterFile(descriptorpb.File_google_protobuf_descriptor_proto)
+	files.RegisterFile(kitFile)
+	testFile, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("redacttest/login.proto"),
+		Package:    proto.String("redacttest"),
+		Dependency: []string{"kit/redact.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Credentials"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("user", 1, optional, str, "", nil),
+				field("token", 2, optional, str, "", redacted),
+			},
+		}, {
+			Name: proto.String("Login"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("credentials", 1, optional, msg, ".redacttest.Credentials", nil),
+				field("history", 2, repeated, msg, ".redacttest.Credentials", nil),
+				field("by_host", 3, repeated, msg, ".redacttest.Login.ByHostEntry", nil),
+				field("passwords", 4, repeated, str, "", redacted),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("ByHostEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("key", 1, optional, str, "", nil),
+					field("value", 2, optional, msg, ".redacttest.Credentials", nil),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}, files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return option, testFile.Messages().ByName("Login"), nil
+}
+
+func TestRedactorOption(t *testing.T) {
+	option, loginDesc := redactTypes(t)
+	var (
+		credentialsDesc = loginDesc.Fields().ByName("credentials").Message()
+		fields          = func(d protoreflect.MessageDescriptor, name protoreflect.Name) protoreflect.FieldDescriptor {
+			return d.Fields().ByName(name)
+		}
+		credentials = func(user, token string) *dynamicpb.Message {
+			m := dynamicpb.NewMessage(credentialsDesc)
+			m.Set(fields(credentialsDesc, "user"), protoreflect.ValueOfString(user))
+			m.Set(fields(credentialsDesc, "token"), protoreflect.ValueOfString(token))
+			return m
+		}
+		login = dynamicpb.NewMessage(loginDesc)
+	)
+	login.Set(fields(loginDesc, "credentials"), protoreflect.ValueOfMessage(credentials("alice", "t0")))
+	history := login.Mutable(fields(loginDesc, "history")).List()
+	history.Append(protoreflect.ValueOfMessage(credentials("alice", "t1")))
+	history.Append(protoreflect.ValueOfMessage(credentials("alice", "t2")))
+	byHost := login.Mutable(fields(loginDesc, "by_host")).Map()
+	byHost.Set(protoreflect.ValueOfString("example.com").MapKey(), protoreflect.ValueOfMessage(credentials("bob", "t3")))
+	login.Mutable(fields(loginDesc, "passwords")).List().Append(protoreflect.ValueOfString("hunter2"))
+
+	// Redacting before the extension is registered doesn't keep the Redactor
+	// from finding it afterwards. The extension stays registered when the
+	// test is run again, e.g. with -count.
+	r := grpctransport.NewRedactor()
+	if _, err := protoregistry.GlobalTypes.FindExtensionByName(grpctransport.RedactOptionName); err != nil {
+		if have := r.Redact(login).(proto.Message).ProtoReflect(); have.Get(fields(loginDesc, "credentials")).Message().Get(fields(credentialsDesc, "token")).String() != "t0" {
+			t.Fatal("want nothing redacted before the extension is registered")
+		}
+		if err := protoregistry.GlobalTypes.RegisterExtension(option); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	redacted := r.Redact(login).(proto.Message).ProtoReflect()
+	token := func(m protoreflect.Message) string { return m.Get(fields(credentialsDesc, "token")).String() }
+	user := func(m protoreflect.Message) string { return m.Get(fields(credentialsDesc, "user")).String() }
+
+	nested := redacted.Get(fields(loginDesc, "credentials")).Message()
+	if want, have := grpctransport.RedactedValue, token(nested); want != have {
+		t.Errorf("nested: want %q, have %q", want, have)
+	}
+	if want, have := "alice", user(nested); want != have {
+		t.Errorf("nested: want %q, have %q", want, have)
+	}
+	list := redacted.Get(fields(loginDesc, "history")).List()
+	for i := 0; i < list.Len(); i++ {
+		if want, have := grpctransport.RedactedValue, token(list.Get(i).Message()); want != have {
+			t.Errorf("repeated %d: want %q, have %q", i, want, have)
+		}
+	}
+	entry := redacted.Get(fields(loginDesc, "by_host")).Map().Get(protoreflect.ValueOfString("example.com").MapKey()).Message()
+	if want, have := grpctransport.RedactedValue, token(entry); want != have {
+		t.Errorf("map: want %q, have %q", want, have)
+	}
+	if want, have := "bob", user(entry); want != have {
+		t.Errorf("map: want %q, have %q", want, have)
+	}
+	if redacted.Has(fields(loginDesc, "passwords")) {
+		t.Errorf("want sensitive repeated field cleared, have %v", redacted.Get(fields(loginDesc, "passwords")))
+	}
+	if want, have := "t0", token(login.Get(fields(loginDesc, "credentials")).Message()); want != have {
+		t.Errorf("original message was modified: want %q, have %q", want, have)
+	}
+}
@@ -2,9 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/transport"
@@ -27,6 +31,8 @@ type Server[REQ any, RES any] struct {
 	after        []ServerResponseFunc
 	finalizer    []ServerFinalizerFunc
 	errorHandler transport.ErrorHandler
+	phases       []transport.PhaseFunc
+	recover      bool
 }
 
 // NewServer constructs a new server, which implements wraps the provided
@@ -86,6 +92,22 @@ func ServerFinalizer[REQ any, RES any](f ...ServerFinalizerFunc) ServerOption[RE
 	return func(s *Server[REQ, RES]) { s.finalizer = append(s.finalizer, f...) }
 }
 
+// ServerPhase functions are called after the request is decoded, after the
+// endpoint returns, and after the response is encoded, with the time each of
+// these phases took. By default, no phase functions are registered.
+func ServerPhase[REQ any, RES any](f ...transport.PhaseFunc) ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.phases = append(s.phases, f...) }
+}
+
+// ServerRecover makes the server recover the panics raised while decoding
+// requests, running the endpoint, or encoding responses. The panics are
+// passed to the error handler as a *transport.PanicError, and the requests
+// fail with an Internal status, which doesn't disclose the panic to clients.
+// Panics raised by request and response functions aren't recovered.
+func ServerRecover[REQ any, RES any]() ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.recover = true }
+}
+
 // ServeGRPC implements the Handler interface.
 func (s Server[REQ, RES]) ServeGRPC(ctx context.Context, req interface{}) (retctx context.Context, resp interface{}, err error) {
 	// Retrieve gRPC metadata.
@@ -112,16 +134,20 @@ func (s Server[REQ, RES]) ServeGRPC(ctx context.Context, req interface{}) (retct
 		grpcResp interface{}
 	)
 
-	request, err = s.dec(ctx, req)
+	start := time.Now()
+	request, err = s.decode(ctx, req)
+	s.phase(ctx, transport.PhaseDecode, start, err)
 	if err != nil {
 		s.errorHandler.Handle(ctx, err)
-		return ctx, nil, err
+		return ctx, nil, internal(err)
 	}
 
-	response, err = s.e(ctx, request)
+	start = time.Now()
+	response, err = s.endpoint(ctx, request)
+	s.phase(ctx, transport.PhaseEndpoint, start, err)
 	if err != nil {
 		s.errorHandler.Handle(ctx, err)
-		return ctx, nil, err
+		return ctx, nil, internal(err)
 	}
 
 	var mdHeader, mdTrailer metadata.MD
@@ -129,10 +155,12 @@ func (s Server[REQ, RES]) ServeGRPC(ctx context.Context, req interface{}) (retct
 		ctx = f(ctx, &mdHeader, &mdTrailer)
 	}
 
-	grpcResp, err = s.enc(ctx, response)
+	start = time.Now()
+	grpcResp, err = s.encode(ctx, response)
+	s.phase(ctx, transport.PhaseEncode, start, err)
 	if err != nil {
 		s.errorHandler.Handle(ctx, err)
-		return ctx, nil, err
+		return ctx, nil, internal(err)
 	}
 
 	if len(mdHeader) > 0 {
@@ -152,6 +180,51 @@ func (s Server[REQ, RES]) ServeGRPC(ctx context.Context, req interface{}) (retct
 	return ctx, grpcResp, nil
 }
 
+// decode, endpoint and encode run the phases of ServeGRPC, turning the panics
+// they raise into errors if the server recovers them.
+func (s Server[REQ, _]) decode(ctx context.Context, req interface{}) (request REQ, err error) {
+	defer s.recoverPanic(transport.PhaseDecode, &err)
+	return s.dec(ctx, req)
+}
+
+func (s Server[REQ, RES]) endpoint(ctx context.Context, request REQ) (response RES, err error) {
+	defer s.recoverPanic(transport.PhaseEndpoint, &err)
+	return s.e(ctx, request)
+}
+
+func (s Server[_, RES]) encode(ctx context.Context, response RES) (grpcResp interface{}, err error) {
+	defer s.recoverPanic(transport.PhaseEncode, &err)
+	return s.enc(ctx, response)
+}
+
+func (s Server[_, _]) recoverPanic(phase transport.Phase, err *error) {
+	if !s.recover {
+		return
+	}
+	if v := recover(); v != nil {
+		*err = transport.NewPanicError(phase, v)
+	}
+}
+
+// internal replaces recovered panics with an Internal status for clients.
+func internal(err error) error {
+	var pe *transport.PanicError
+	if errors.As(err, &pe) {
+		return status.Error(codes.Internal, "internal error")
+	}
+	return err
+}
+
+func (s Server[REQ, RES]) phase(ctx context.Context, phase transport.Phase, start time.Time, err error) {
+	if len(s.phases) == 0 {
+		return
+	}
+	took := time.Since(start)
+	for _, f := range s.phases {
+		f(ctx, phase, start, took, err)
+	}
+}
+
 // ServerFinalizerFunc can be used to perform work at the end of an gRPC
 // request, after the response has been written to the client.
 type ServerFinalizerFunc func(ctx context.Context, err error)
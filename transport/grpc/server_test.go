@@ -0,0 +1,119 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/a69/kit.go/transport"
+	grpctransport "github.com/a69/kit.go/transport/grpc"
+)
+
+func TestServerRecover(t *testing.T) {
+	var (
+		ok   = func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil }
+		dec  = func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil }
+		enc  = func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil }
+		boom = func(context.Context, interface{}) (interface{}, error) { panic("boom") }
+	)
+	for _, tc := range []struct {
+		name        string
+		e, dec, enc func(context.Context, interface{}) (interface{}, error)
+		phase       transport.Phase
+	}{
+		{"decode", ok, boom, enc, transport.PhaseDecode},
+		{"endpoint", boom, dec, enc, transport.PhaseEndpoint},
+		{"encode", ok, dec, boom, transport.PhaseEncode},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled error
+			server := grpctransport.NewServer[interface{}, interface{}](tc.e, tc.dec, tc.enc,
+				grpctransport.ServerRecover[interface{}, interface{}](),
+				grpctransport.ServerErrorHandler[interface{}, interface{}](transport.ErrorHandlerFunc(func(_ context.Context, err error) { handled = err })),
+			)
+			_, _, err := server.ServeGRPC(context.Background(), struct{}{})
+			if want, have := codes.Internal, status.Code(err); want != have {
+				t.Errorf("want %s, have %s", want, have)
+			}
+			var pe *transport.PanicError
+			if !errors.As(handled, &pe) {
+				t.Fatalf("want *transport.PanicError, have %v", handled)
+			}
+			if want, have := tc.phase, pe.Phase; want != have {
+				t.Errorf("want %s, have %s", want, have)
+			}
+		})
+	}
+}
+
+func TestServerPhase(t *testing.T) {
+	type observation struct {
+		phase transport.Phase
+		took  time.Duration
+		err   error
+		value interface{}
+	}
+	type contextKey struct{}
+	var (
+		observed []observation
+		failure  = errors.New("encode failed")
+		server   = grpctransport.NewServer[interface{}, interface{}](
+			func(context.Context, interface{}) (interface{}, error) {
+				time.Sleep(10 * time.Millisecond)
+				return struct{}{}, nil
+			},
+			func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+			func(context.Context, interface{}) (interface{}, error) { return nil, failure },
+			grpctransport.ServerBefore[interface{}, interface{}](func(ctx context.Context, _ metadata.MD) context.Context {
+				return context.WithValue(ctx, contextKey{}, "span")
+			}),
+			grpctransport.ServerPhase[interface{}, interface{}](func(ctx context.Context, phase transport.Phase, _ time.Time, took time.Duration, err error) {
+				observed = append(observed, observation{phase, took, err, ctx.Value(contextKey{})})
+			}),
+		)
+	)
+	if _, _, err := server.ServeGRPC(context.Background(), struct{}{}); err != failure {
+		t.Errorf("want %v, have %v", failure, err)
+	}
+
+	if want, have := 3, len(observed); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	for i, phase := range []transport.Phase{transport.PhaseDecode, transport.PhaseEndpoint, transport.PhaseEncode} {
+		if want, have := phase, observed[i].phase; want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+		if want, have := "span", observed[i].value; want != have {
+			t.Errorf("%s: want the context of ServerBefore, have value %v", phase, have)
+		}
+	}
+	if have := observed[1].took; have < 10*time.Millisecond {
+		t.Errorf("endpoint: want at least 10ms, have %v", have)
+	}
+	if want, have := failure, observed[2].err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestServerPhaseStopsAtFailure(t *testing.T) {
+	var (
+		observed []transport.Phase
+		server   = grpctransport.NewServer[interface{}, interface{}](
+			func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+			func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("decode failed") },
+			func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+			grpctransport.ServerPhase[interface{}, interface{}](func(_ context.Context, phase transport.Phase, _ time.Time, _ time.Duration, _ error) {
+				observed = append(observed, phase)
+			}),
+		)
+	)
+	server.ServeGRPC(context.Background(), struct{}{})
+	if want, have := []transport.Phase{transport.PhaseDecode}, observed; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
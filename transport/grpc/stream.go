@@ -0,0 +1,134 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// StreamHandler should be called from the gRPC binding of server-streaming
+// methods of the service implementation, e.g.
+//
+//	func (b *binding) List(req *pb.ListRequest, stream pb.Service_ListServer) error {
+//		return b.list.ServeStream(req, stream)
+//	}
+type StreamHandler interface {
+	ServeStream(request interface{}, stream grpc.ServerStream) error
+}
+
+// StreamServer wraps a stream endpoint and implements StreamHandler.
+type StreamServer[REQ any, RES any] struct {
+	e            endpoint.StreamEndpoint[REQ, RES]
+	dec          DecodeRequestFunc[REQ]
+	enc          EncodeResponseFunc[RES]
+	before       []ServerRequestFunc
+	after        []ServerResponseFunc
+	finalizer    []ServerFinalizerFunc
+	errorHandler transport.ErrorHandler
+}
+
+// NewStreamServer constructs a new stream server, which wraps the provided
+// stream endpoint and implements the StreamHandler interface. Each response of
+// the endpoint is encoded with enc, and sent on the stream.
+func NewStreamServer[REQ any, RES any](
+	e endpoint.StreamEndpoint[REQ, RES],
+	dec DecodeRequestFunc[REQ],
+	enc EncodeResponseFunc[RES],
+	options ...StreamServerOption[REQ, RES],
+) *StreamServer[REQ, RES] {
+	s := &StreamServer[REQ, RES]{
+		e:            e,
+		dec:          dec,
+		enc:          enc,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// StreamServerOption sets an optional parameter for stream servers.
+type StreamServerOption[REQ any, RES any] func(*StreamServer[REQ, RES])
+
+// StreamServerBefore functions are executed on the gRPC request metadata
+// before the request is decoded.
+func StreamServerBefore[REQ any, RES any](before ...ServerRequestFunc) StreamServerOption[REQ, RES] {
+	return func(s *StreamServer[REQ, RES]) { s.before = append(s.before, before...) }
+}
+
+// StreamServerAfter functions are executed after the request is decoded, but
+// before the first response is sent. The header they set is sent with the
+// first response, and the trailer once the stream ends.
+func StreamServerAfter[REQ any, RES any](after ...ServerResponseFunc) StreamServerOption[REQ, RES] {
+	return func(s *StreamServer[REQ, RES]) { s.after = append(s.after, after...) }
+}
+
+// StreamServerErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored.
+func StreamServerErrorHandler[REQ any, RES any](errorHandler transport.ErrorHandler) StreamServerOption[REQ, RES] {
+	return func(s *StreamServer[REQ, RES]) { s.errorHandler = errorHandler }
+}
+
+// StreamServerFinalizer is executed at the end of every stream.
+// By default, no finalizer is registered.
+func StreamServerFinalizer[REQ any, RES any](f ...ServerFinalizerFunc) StreamServerOption[REQ, RES] {
+	return func(s *StreamServer[REQ, RES]) { s.finalizer = append(s.finalizer, f...) }
+}
+
+// ServeStream implements the StreamHandler interface.
+func (s StreamServer[REQ, RES]) ServeStream(req interface{}, stream grpc.ServerStream) (err error) {
+	ctx := stream.Context()
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+
+	if len(s.finalizer) > 0 {
+		defer func() {
+			for _, f := range s.finalizer {
+				f(ctx, err)
+			}
+		}()
+	}
+
+	for _, f := range s.before {
+		ctx = f(ctx, md)
+	}
+
+	request, err := s.dec(ctx, req)
+	if err != nil {
+		s.errorHandler.Handle(ctx, err)
+		return err
+	}
+
+	var mdHeader, mdTrailer metadata.MD
+	for _, f := range s.after {
+		ctx = f(ctx, &mdHeader, &mdTrailer)
+	}
+	if len(mdHeader) > 0 {
+		if err = stream.SetHeader(mdHeader); err != nil {
+			s.errorHandler.Handle(ctx, err)
+			return err
+		}
+	}
+	if len(mdTrailer) > 0 {
+		defer stream.SetTrailer(mdTrailer)
+	}
+
+	err = s.e(ctx, request, func(response RES) error {
+		grpcResp, err := s.enc(ctx, response)
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(grpcResp)
+	})
+	if err != nil {
+		s.errorHandler.Handle(ctx, err)
+		return err
+	}
+	return nil
+}
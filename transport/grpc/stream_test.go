@@ -0,0 +1,95 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/a69/kit.go/endpoint"
+	kitgrpc "github.com/a69/kit.go/transport/grpc"
+)
+
+// serverStream records the messages and metadata sent on a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	header  metadata.MD
+	trailer metadata.MD
+	sent    []string
+	fail    error
+}
+
+func (s *serverStream) Context() context.Context       { return s.ctx }
+func (s *serverStream) SetHeader(md metadata.MD) error { s.header = md; return nil }
+func (s *serverStream) SetTrailer(md metadata.MD)      { s.trailer = md }
+func (s *serverStream) SendMsg(m interface{}) error {
+	if s.fail != nil {
+		return s.fail
+	}
+	s.sent = append(s.sent, m.(*wrapperspb.StringValue).GetValue())
+	return nil
+}
+
+func TestStreamServer(t *testing.T) {
+	var (
+		finalized error
+		user      []string
+		server    = kitgrpc.NewStreamServer(
+			endpoint.FromChannel(func(ctx context.Context, prefix string) (<-chan string, error) {
+				c := make(chan string, 3)
+				for _, s := range []string{"a", "b", "c"} {
+					c <- prefix + s
+				}
+				close(c)
+				return c, nil
+			}),
+			func(_ context.Context, req interface{}) (string, error) {
+				return req.(*wrapperspb.StringValue).GetValue(), nil
+			},
+			func(_ context.Context, response string) (interface{}, error) {
+				return wrapperspb.String(response), nil
+			},
+			kitgrpc.StreamServerBefore[string, string](func(ctx context.Context, md metadata.MD) context.Context {
+				user = md.Get("user")
+				return ctx
+			}),
+			kitgrpc.StreamServerAfter[string, string](func(ctx context.Context, header *metadata.MD, trailer *metadata.MD) context.Context {
+				*header = metadata.Pairs("x-stream", "true")
+				*trailer = metadata.Pairs("x-count", "3")
+				return ctx
+			}),
+			kitgrpc.StreamServerFinalizer[string, string](func(_ context.Context, err error) { finalized = err }),
+		)
+		stream = &serverStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("user", "alice"))}
+	)
+
+	if err := server.ServeStream(wrapperspb.String("x-"), stream); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "x-a x-b x-c", strings.Join(stream.sent, " "); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "alice", strings.Join(user, ""); want != have {
+		t.Errorf("user: want %q, have %q", want, have)
+	}
+	if want, have := "true", stream.header.Get("x-stream"); len(have) != 1 || want != have[0] {
+		t.Errorf("header: want %q, have %q", want, have)
+	}
+	if want, have := "3", stream.trailer.Get("x-count"); len(have) != 1 || want != have[0] {
+		t.Errorf("trailer: want %q, have %q", want, have)
+	}
+
+	errGone := errors.New("client gone")
+	stream = &serverStream{ctx: context.Background(), fail: errGone}
+	if want, have := errGone, server.ServeStream(wrapperspb.String("x-"), stream); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := errGone, finalized; want != have {
+		t.Errorf("finalizer: want %v, have %v", want, have)
+	}
+}
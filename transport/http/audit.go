@@ -0,0 +1,295 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	kitlog "github.com/a69/kit.go/log"
+	"github.com/go-kit/log"
+)
+
+// AuditRecord describes a request handled by a server, as emitted by
+// ServerAudit.
+type AuditRecord struct {
+	Time       time.Time // when the request was received
+	Method     string
+	Route      string // the route pattern, if known, e.g. "GET /users/{id}"
+	Path       string
+	RemoteAddr string
+	Status     int
+	Latency    time.Duration
+
+	// RequestSize and ResponseSize are the number of body bytes read and
+	// written by the server.
+	RequestSize  int64
+	ResponseSize int64
+
+	// RequestBody and ResponseBody are the redacted bodies, truncated to the
+	// limit set with AuditBodyLimit, or empty if their content type isn't
+	// textual. The truncated flags are set if the captured bodies were cut off.
+	RequestBody           string
+	RequestBodyTruncated  bool
+	ResponseBody          string
+	ResponseBodyTruncated bool
+}
+
+// AuditSink receives the audit records of a server, after each response is
+// written. The context carries the values set by the request functions, e.g.
+// the authenticated principal.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditSinkFunc is an adapter to allow the use of ordinary functions as
+// AuditSinks.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord)
+
+// Audit calls f(ctx, record).
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// LogAuditSink returns an AuditSink logging each record as a single log
+// event to logger.
+func LogAuditSink(logger log.Logger) AuditSink {
+	return AuditSinkFunc(func(_ context.Context, r AuditRecord) {
+		logger.Log(
+			"audit", "http",
+			"time", r.Time,
+			"method", r.Method,
+			"route", r.Route,
+			"path", r.Path,
+			"remote_addr", r.RemoteAddr,
+			"status", r.Status,
+			"latency", r.Latency,
+			"request_size", r.RequestSize,
+			"response_size", r.ResponseSize,
+			"request_body", r.RequestBody,
+			"request_body_truncated", r.RequestBodyTruncated,
+			"response_body", r.ResponseBody,
+			"response_body_truncated", r.ResponseBodyTruncated,
+		)
+	})
+}
+
+// DefaultAuditBodyLimit is the number of body bytes captured by ServerAudit
+// unless set with AuditBodyLimit.
+const DefaultAuditBodyLimit = 4096
+
+// AuditOption sets an optional parameter for ServerAudit.
+type AuditOption func(*auditor)
+
+// AuditBodyLimit sets the number of bytes of the request and response bodies
+// captured in audit records. A limit of 0 disables capturing bodies.
+func AuditBodyLimit(n int) AuditOption {
+	return func(a *auditor) { a.limit = n }
+}
+
+// AuditRoute sets the function returning the route pattern of a request,
+// e.g. using mux.CurrentRoute(r).GetPathTemplate() with gorilla/mux. By
+// default, the pattern matched by http.ServeMux is used.
+func AuditRoute(route func(*http.Request) string) AuditOption {
+	return func(a *auditor) { a.route = route }
+}
+
+// AuditRedact sets how the captured bodies are redacted, with the options of
+// the redacting logger of package log, e.g. log.RedactKeys to replace the
+// sensitive keys of JSON objects and form values, log.RedactPatterns, or
+// log.RedactHash. By default, the values of log.DefaultRedactKeys are
+// redacted.
+func AuditRedact(options ...kitlog.RedactOption) AuditOption {
+	return func(a *auditor) { a.redactOptions = append(a.redactOptions, options...) }
+}
+
+// ServerAudit emits an AuditRecord to sink for every request handled by the
+// server, including those failing to decode and those rejected by the
+// ServerMiddleware, e.g. with 401 Unauthorized. Sensitive values are redacted
+// from the captured bodies, as by a redacting logger configured with the
+// options of AuditRedact: those of sensitive keys in JSON and form bodies,
+// even if truncated, and those matching the redaction patterns.
+func ServerAudit[REQ any, RES any](sink AuditSink, options ...AuditOption) ServerOption[REQ, RES] {
+	a := &auditor{
+		sink:  sink,
+		limit: DefaultAuditBodyLimit,
+		route: func(r *http.Request) string { return r.Pattern },
+	}
+	for _, option := range options {
+		option(a)
+	}
+	a.redactor = kitlog.NewRedactor(a.redactOptions...)
+	a.compile()
+
+	return func(s *Server[REQ, RES]) {
+		if a.limit > s.captureLimit {
+			s.captureLimit = a.limit
+		}
+		s.prepare = append(s.prepare, a.before)
+		ServerFinalizer[REQ, RES](a.finalize)(s)
+	}
+}
+
+type auditKey struct{}
+
+type auditState struct {
+	start time.Time
+	body  *auditReader
+}
+
+type auditor struct {
+	sink          AuditSink
+	limit         int
+	route         func(*http.Request) string
+	redactOptions []kitlog.RedactOption
+	redactor      *kitlog.Redactor
+
+	// fallbacks redact the values of sensitive keys from bodies that can't
+	// be parsed, e.g. because they're truncated. Their first group is kept,
+	// and their second group, the value, is redacted.
+	fallbacks []fallback
+}
+
+type fallback struct {
+	re     *regexp.Regexp
+	quoted bool // whether the replacement is quoted, for JSON
+}
+
+func (a *auditor) compile() {
+	keys := a.redactor.Keys()
+	if len(keys) == 0 {
+		return
+	}
+	for i, k := range keys {
+		keys[i] = regexp.QuoteMeta(k)
+	}
+	alt := strings.Join(keys, "|")
+	a.fallbacks = []fallback{
+		{regexp.MustCompile(`(?i)("(?:` + alt + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`), true},
+		{regexp.MustCompile(`(?i)((?:^|[&\s])(?:` + alt + `)=)([^&\s]*)`), false},
+	}
+}
+
+// redact replaces the values matched by the fallback in s.
+func (f fallback) redact(s string, r *kitlog.Redactor) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range f.re.FindAllStringSubmatchIndex(s, -1) {
+		value := strings.Trim(s[m[4]:m[5]], `"`)
+		b.WriteString(s[last:m[4]])
+		if f.quoted {
+			b.WriteString(`"` + r.Replacement(value) + `"`)
+		} else {
+			b.WriteString(r.Replacement(value))
+		}
+		last = m[5]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (a *auditor) before(ctx context.Context, r *http.Request) context.Context {
+	state := &auditState{start: time.Now()}
+	if r.Body != nil && r.Body != http.NoBody {
+		state.body = &auditReader{ReadCloser: r.Body, limit: a.limit}
+		r.Body = state.body
+	}
+	return context.WithValue(ctx, auditKey{}, state)
+}
+
+func (a *auditor) finalize(ctx context.Context, code int, r *http.Request) {
+	state, ok := ctx.Value(auditKey{}).(*auditState)
+	if !ok {
+		return
+	}
+	record := AuditRecord{
+		Time:       state.start,
+		Method:     r.Method,
+		Route:      a.route(r),
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		Status:     code,
+		Latency:    time.Since(state.start),
+	}
+	if state.body != nil {
+		record.RequestSize = state.body.read
+		record.RequestBody, record.RequestBodyTruncated = a.body(r.Header.Get("Content-Type"), state.body.captured.Bytes(), state.body.read)
+	}
+	if size, ok := ctx.Value(ContextKeyResponseSize).(int64); ok {
+		record.ResponseSize = size
+	}
+	if body, ok := ctx.Value(contextKeyResponseBody).(*bytes.Buffer); ok {
+		var contentType string
+		if h, ok := ctx.Value(ContextKeyResponseHeaders).(http.Header); ok {
+			contentType = h.Get("Content-Type")
+		}
+		record.ResponseBody, record.ResponseBodyTruncated = a.body(contentType, body.Bytes()[:min(body.Len(), a.limit)], record.ResponseSize)
+	}
+	a.sink.Audit(ctx, record)
+}
+
+// body returns the redacted body, and whether it's truncated.
+func (a *auditor) body(contentType string, captured []byte, size int64) (string, bool) {
+	if len(captured) == 0 {
+		return "", false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	truncated := int64(len(captured)) < size
+	switch {
+	case !truncated && (strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")):
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(captured))
+		d.UseNumber()
+		if err := d.Decode(&v); err == nil {
+			if b, err := json.Marshal(a.redactor.Redact("", v)); err == nil {
+				return string(b), false
+			}
+		}
+	case !truncated && mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(captured)); err == nil {
+			for k, vs := range values {
+				for i := range vs {
+					if a.redactor.Sensitive(k) {
+						vs[i] = a.redactor.Replacement(vs[i])
+					} else {
+						vs[i] = a.redactor.Scrub(vs[i])
+					}
+				}
+			}
+			return values.Encode(), false
+		}
+	case mediaType == "" || strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") ||
+		strings.Contains(mediaType, "xml") || mediaType == "application/x-www-form-urlencoded":
+	default:
+		return "", truncated
+	}
+
+	s := string(captured)
+	for _, f := range a.fallbacks {
+		s = f.redact(s, a.redactor)
+	}
+	return a.redactor.Scrub(s), truncated
+}
+
+// auditReader captures up to limit bytes of the request body as it's read.
+type auditReader struct {
+	io.ReadCloser
+	limit    int
+	captured bytes.Buffer
+	read     int64
+}
+
+func (r *auditReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if room := r.limit - r.captured.Len(); room > 0 {
+		r.captured.Write(p[:min(n, room)])
+	}
+	r.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,292 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/a69/kit.go/log"
+	httptransport "github.com/a69/kit.go/transport/http"
+	kitmux "github.com/a69/kit.go/transport/http/mux"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+)
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+	Email string `json:"email"`
+}
+
+func newLoginServer(sink httptransport.AuditSink, options ...httptransport.AuditOption) http.Handler {
+	server := httptransport.NewServer(
+		func(_ context.Context, req loginRequest) (loginResponse, error) {
+			return loginResponse{Token: "t0k3n", Email: req.User + "@example.com"}, nil
+		},
+		func(_ context.Context, r *http.Request) (req loginRequest, err error) {
+			if r.Header.Get("Content-Type") != "application/json" {
+				return req, errors.New("unsupported content type")
+			}
+			err = json.NewDecoder(r.Body).Decode(&req)
+			return req, err
+		},
+		httptransport.EncodeJSONResponse[loginResponse],
+		httptransport.ServerAudit[loginRequest, loginResponse](sink, options...),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("POST /login", server)
+	return mux
+}
+
+func TestServerAudit(t *testing.T) {
+	var records []httptransport.AuditRecord
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records = append(records, r)
+	})
+	handler := newLoginServer(sink, httptransport.AuditRedact(kitlog.RedactPatterns(kitlog.EmailPattern)))
+
+	body := `{"user":"alice","password":"hunter2"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want, have := 1, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	r := records[0]
+	if want, have := "POST /login", r.Route; want != have {
+		t.Errorf("want route %q, have %q", want, have)
+	}
+	if want, have := "/login", r.Path; want != have {
+		t.Errorf("want path %q, have %q", want, have)
+	}
+	if want, have := http.StatusOK, r.Status; want != have {
+		t.Errorf("want status %d, have %d", want, have)
+	}
+	if want, have := int64(len(body)), r.RequestSize; want != have {
+		t.Errorf("want request size %d, have %d", want, have)
+	}
+	if want, have := `{"password":"[REDACTED]","user":"alice"}`, r.RequestBody; want != have {
+		t.Errorf("want request body %s, have %s", want, have)
+	}
+	if want, have := `{"email":"[REDACTED]","token":"[REDACTED]"}`, r.ResponseBody; want != have {
+		t.Errorf("want response body %s, have %s", want, have)
+	}
+	if r.Latency <= 0 || r.Time.IsZero() {
+		t.Errorf("want latency and time, have %v and %v", r.Latency, r.Time)
+	}
+}
+
+func TestServerAuditTruncated(t *testing.T) {
+	var records []httptransport.AuditRecord
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records = append(records, r)
+	})
+	handler := newLoginServer(sink, httptransport.AuditBodyLimit(30))
+
+	body := `{"password":"hunter2","user":"alice"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	r := records[0]
+	if want, have := `{"password":"[REDACTED]","user":"`, r.RequestBody; want != have {
+		t.Errorf("want request body %s, have %s", want, have)
+	}
+	if !r.RequestBodyTruncated {
+		t.Errorf("want request body truncated")
+	}
+	if want, have := `{"token":"[REDACTED]","email":"alic`, r.ResponseBody; want != have {
+		t.Errorf("want response body %s, have %s", want, have)
+	}
+	if !r.ResponseBodyTruncated {
+		t.Errorf("want response body truncated")
+	}
+}
+
+func TestServerAuditForm(t *testing.T) {
+	var records []httptransport.AuditRecord
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records = append(records, r)
+	})
+	handler := newLoginServer(sink)
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("user=alice&password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The request is audited even though it fails to decode, but its body
+	// is only captured as far as it's read.
+	r := records[0]
+	if want, have := http.StatusInternalServerError, r.Status; want != have {
+		t.Errorf("want status %d, have %d", want, have)
+	}
+	if want, have := "", r.RequestBody; want != have {
+		t.Errorf("want request body %q, have %q", want, have)
+	}
+}
+
+func TestServerAuditHash(t *testing.T) {
+	var records []httptransport.AuditRecord
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records = append(records, r)
+	})
+	for _, limit := range []int{httptransport.DefaultAuditBodyLimit, 30} {
+		handler := newLoginServer(sink, httptransport.AuditRedact(kitlog.RedactHash("salt")), httptransport.AuditBodyLimit(limit))
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"password":"hunter2","user":"alice"}`))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The password is hashed alike, whether the body is parsed or truncated.
+	hashed := kitlog.NewRedactor(kitlog.RedactHash("salt")).Replacement("hunter2")
+	if want, have := `{"password":"`+hashed+`","user":"alice"}`, records[0].RequestBody; want != have {
+		t.Errorf("want request body %s, have %s", want, have)
+	}
+	if want, have := `{"password":"`+hashed+`","user":"`, records[1].RequestBody; want != have {
+		t.Errorf("want request body %s, have %s", want, have)
+	}
+}
+
+func TestServerAuditReadFrom(t *testing.T) {
+	records := make(chan httptransport.AuditRecord, 1)
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records <- r
+	})
+	const body = "a body copied with io.Copy"
+	server := httptest.NewServer(httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		func(_ context.Context, w http.ResponseWriter, _ struct{}) error {
+			if _, ok := w.(io.ReaderFrom); !ok {
+				t.Error("want io.ReaderFrom")
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			// Hide the WriterTo of strings.Reader, for io.Copy to use ReadFrom.
+			_, err := io.Copy(w, struct{ io.Reader }{strings.NewReader(body)})
+			return err
+		},
+		httptransport.ServerAudit[struct{}, struct{}](sink),
+	))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	r := <-records
+	if want, have := int64(len(body)), r.ResponseSize; want != have {
+		t.Errorf("want response size %d, have %d", want, have)
+	}
+	if want, have := body, r.ResponseBody; want != have {
+		t.Errorf("want response body %q, have %q", want, have)
+	}
+}
+
+func TestServerAuditRejectedByMiddleware(t *testing.T) {
+	var records []httptransport.AuditRecord
+	sink := httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+		records = append(records, r)
+	})
+	handler := httptransport.NewServer(
+		func(context.Context, loginRequest) (loginResponse, error) {
+			t.Error("the endpoint was called")
+			return loginResponse{}, nil
+		},
+		func(context.Context, *http.Request) (loginRequest, error) { return loginRequest{}, nil },
+		httptransport.EncodeJSONResponse[loginResponse],
+		httptransport.ServerMiddleware[loginRequest, loginResponse](authenticate),
+		httptransport.ServerAudit[loginRequest, loginResponse](sink),
+	)
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"user":"alice","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want, have := 1, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	r := records[0]
+	if want, have := http.StatusUnauthorized, r.Status; want != have {
+		t.Errorf("want status %d, have %d", want, have)
+	}
+	if want, have := "/login", r.Path; want != have {
+		t.Errorf("want path %q, have %q", want, have)
+	}
+	if want, have := "unauthorized\n", r.ResponseBody; want != have {
+		t.Errorf("want response body %q, have %q", want, have)
+	}
+	if r.Time.IsZero() {
+		t.Errorf("want time, have none")
+	}
+}
+
+func TestServerAuditSharedByRouter(t *testing.T) {
+	var records []httptransport.AuditRecord
+	r := mux.NewRouter()
+	router := kitmux.NewRouter(r,
+		httptransport.ServerMiddleware[any, any](authenticate),
+		httptransport.ServerAudit[any, any](httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+			records = append(records, r)
+		})),
+	)
+	kitmux.Handle(router, "POST", "/login",
+		func(_ context.Context, req loginRequest) (loginResponse, error) {
+			return loginResponse{Token: "t0k3n"}, nil
+		},
+		func(_ context.Context, r *http.Request) (req loginRequest, err error) {
+			err = json.NewDecoder(r.Body).Decode(&req)
+			return req, err
+		},
+		httptransport.EncodeJSONResponse[loginResponse],
+	)
+
+	for _, user := range []string{"alice", ""} {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"user":"alice","password":"hunter2"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if want, have := 2, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		if have := records[i].Status; want != have {
+			t.Errorf("record %d: want status %d, have %d", i, want, have)
+		}
+		if want, have := "/login", records[i].Route; want != have {
+			t.Errorf("record %d: want route %q, have %q", i, want, have)
+		}
+	}
+	if want, have := `{"password":"[REDACTED]","user":"alice"}`, records[0].RequestBody; want != have {
+		t.Errorf("want request body %s, have %s", want, have)
+	}
+}
+
+func TestLogAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newLoginServer(httptransport.LogAuditSink(log.NewLogfmtLogger(&buf)), httptransport.AuditBodyLimit(0))
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"user":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, want := range []string{"audit=http", `route="POST /login"`, "status=200", `request_body= `, "request_size=16"} {
+		if have := buf.String(); !strings.Contains(have, want) {
+			t.Errorf("want %q in %q", want, have)
+		}
+	}
+}
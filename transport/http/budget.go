@@ -0,0 +1,42 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+// BudgetHeader carries the caller's remaining deadline budget, in
+// milliseconds, between services.
+const BudgetHeader = "X-Deadline-Budget"
+
+// SetBudgetHeader is a client RequestFunc that writes the remaining deadline
+// budget of the context (see endpoint.Budget) into the BudgetHeader. Requests
+// without a deadline are left untouched.
+func SetBudgetHeader(ctx context.Context, r *http.Request) context.Context {
+	if deadline, ok := endpoint.Budget(ctx); ok {
+		remaining := time.Until(deadline).Milliseconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		r.Header.Set(BudgetHeader, strconv.FormatInt(remaining, 10))
+	}
+	return ctx
+}
+
+// PopulateBudget is a server RequestFunc that reads the BudgetHeader and
+// attaches the corresponding deadline to the context with endpoint.WithBudget.
+// Combine it with the endpoint.DeadlineBudget middleware to enforce it.
+// Missing or malformed headers are ignored, and so are values too large to be
+// a time.Duration.
+func PopulateBudget(ctx context.Context, r *http.Request) context.Context {
+	ms, err := strconv.ParseInt(r.Header.Get(BudgetHeader), 10, 64)
+	if err != nil || ms < 0 || ms > math.MaxInt64/int64(time.Millisecond) {
+		return ctx
+	}
+	return endpoint.WithBudget(ctx, time.Now().Add(time.Duration(ms)*time.Millisecond))
+}
@@ -0,0 +1,407 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseCache is a private HTTP cache for clients, storing the responses to
+// GET requests as permitted by their Cache-Control and Expires headers. Fresh
+// responses are served without contacting the upstream. Stale ones carrying
+// an ETag or Last-Modified validator are revalidated with a conditional
+// request, and served again if the upstream answers 304 Not Modified. If the
+// upstream can't be reached or fails with a 5xx status, stale responses are
+// served for as long as their stale-if-error directive, or the duration set
+// with CacheStaleIfError, allows.
+//
+// Responses to requests carrying credentials, i.e. an Authorization or a
+// Cookie header, are stored apart for each set of credentials, so that they
+// are never served to requests made on behalf of someone else.
+//
+// A ResponseCache is safe for concurrent use, and may be shared by clients.
+type ResponseCache struct {
+	mtx          sync.Mutex
+	entries      map[string]*list.Element
+	lru          *list.List
+	maxEntries   int
+	maxBodySize  int64
+	staleIfError time.Duration
+	timeNow      func() time.Time
+}
+
+// CacheOption sets an optional parameter for response caches.
+type CacheOption func(*ResponseCache)
+
+// CacheMaxEntries sets the number of responses kept by the cache, the least
+// recently used being evicted first. By default, 1000 responses are kept.
+func CacheMaxEntries(n int) CacheOption {
+	return func(c *ResponseCache) { c.maxEntries = n }
+}
+
+// CacheMaxBodySize sets the size of the largest response body stored by the
+// cache. By default, bodies of up to 1 MiB are stored.
+func CacheMaxBodySize(n int64) CacheOption {
+	return func(c *ResponseCache) { c.maxBodySize = n }
+}
+
+// CacheStaleIfError sets how long stale responses are served when the
+// upstream fails, for responses without a stale-if-error directive. By
+// default, only responses with the directive are served stale.
+func CacheStaleIfError(d time.Duration) CacheOption {
+	return func(c *ResponseCache) { c.staleIfError = d }
+}
+
+// NewResponseCache returns an empty ResponseCache.
+func NewResponseCache(options ...CacheOption) *ResponseCache {
+	c := &ResponseCache{
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		maxEntries:  1000,
+		maxBodySize: 1 << 20,
+		timeNow:     time.Now,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// ClientCache makes the client serve GET requests from the cache, as
+// described in ResponseCache.
+func ClientCache[REQ any, RES any](cache *ResponseCache) ClientOption[REQ, RES] {
+	return func(c *Client[REQ, RES]) { c.cache = cache }
+}
+
+// Middleware returns the cache as a ClientMiddleware, e.g. to cache the
+// responses of an HTTPClient shared by several clients, or to order the cache
+// relative to other middlewares.
+func (c *ResponseCache) Middleware() ClientMiddleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			return c.do(next, req)
+		})
+	}
+}
+
+type cacheEntry struct {
+	key        string
+	statusCode int
+	status     string
+	proto      string
+	header     http.Header
+	body       []byte
+	vary       http.Header // the request headers named by Vary
+	stored     time.Time   // when the response was received, or revalidated
+}
+
+// do sends req with client, unless it can be answered from the cache.
+func (c *ResponseCache) do(client HTTPClient, req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header)
+	if req.Method != http.MethodGet || reqCC.has("no-store") {
+		return client.Do(req)
+	}
+
+	key := cacheKey(req)
+	now := c.timeNow()
+	entry := c.get(key, req)
+	if entry != nil && !reqCC.has("no-cache") && entry.fresh(now) {
+		return entry.response(req, now), nil
+	}
+
+	outgoing := req
+	if entry != nil {
+		outgoing = entry.conditional(req)
+	}
+	resp, err := client.Do(outgoing)
+
+	if entry != nil {
+		switch {
+		case err == nil && resp.StatusCode == http.StatusNotModified:
+			drain(resp)
+			entry = c.revalidated(entry, resp.Header)
+			return entry.response(req, c.timeNow()), nil
+		case (err != nil || resp.StatusCode >= 500) && entry.staleIfError(now, c.staleIfError):
+			if err == nil {
+				drain(resp)
+			}
+			return entry.response(req, now), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && outgoing == req {
+		// The caller's own conditional request; nothing to store.
+		return resp, nil
+	}
+	return c.store(key, req, resp)
+}
+
+// cacheKey returns the key of the responses to req: its URL, and a digest of
+// its credentials if it carries some.
+func cacheKey(req *http.Request) string {
+	key := req.URL.String()
+	auth, cookies := req.Header.Values("Authorization"), req.Header.Values("Cookie")
+	if len(auth) == 0 && len(cookies) == 0 {
+		return key
+	}
+	h := sha256.New()
+	for _, v := range auth {
+		io.WriteString(h, "a:"+v+"\n")
+	}
+	for _, v := range cookies {
+		io.WriteString(h, "c:"+v+"\n")
+	}
+	return key + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ResponseCache) get(key string, req *http.Request) *cacheEntry {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := e.Value.(*cacheEntry)
+	for k, vs := range entry.vary {
+		if strings.Join(req.Header.Values(k), ",") != strings.Join(vs, ",") {
+			return nil
+		}
+	}
+	c.lru.MoveToFront(e)
+	return entry
+}
+
+// revalidated refreshes the entry with the headers of a 304 response.
+func (c *ResponseCache) revalidated(entry *cacheEntry, header http.Header) *cacheEntry {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	updated := *entry
+	updated.header = entry.header.Clone()
+	for k, vs := range header {
+		if k == "Content-Length" {
+			continue
+		}
+		updated.header[k] = vs
+	}
+	updated.stored = c.timeNow()
+	if e, ok := c.entries[entry.key]; ok && e.Value == entry {
+		e.Value = &updated
+	}
+	return &updated
+}
+
+// store stores resp if it's cacheable, and returns it with its body intact.
+func (c *ResponseCache) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	cc := parseCacheControl(resp.Header)
+	if !cacheable(resp, cc) {
+		if resp.StatusCode < 500 {
+			c.remove(key) // superseded
+		}
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > c.maxBodySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	entry := &cacheEntry{
+		key:        key,
+		statusCode: resp.StatusCode,
+		status:     resp.Status,
+		proto:      resp.Proto,
+		header:     resp.Header.Clone(),
+		body:       body,
+		stored:     c.timeNow(),
+	}
+	for _, v := range resp.Header.Values("Vary") {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				if entry.vary == nil {
+					entry.vary = http.Header{}
+				}
+				entry.vary[http.CanonicalHeaderKey(k)] = req.Header.Values(k)
+			}
+		}
+	}
+
+	c.mtx.Lock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+	} else {
+		c.entries[key] = c.lru.PushFront(entry)
+		for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	c.mtx.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
+
+func (c *ResponseCache) remove(key string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// cacheable reports whether the response may be stored, which requires
+// either an explicit lifetime or a validator.
+func cacheable(resp *http.Response, cc cacheControl) bool {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently,
+		http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+	if cc.has("no-store") || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	_, maxAge := cc.seconds("max-age")
+	return maxAge || resp.Header.Get("Expires") != "" ||
+		resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// lifetime returns how long the response is fresh for.
+func (e *cacheEntry) lifetime(cc cacheControl) time.Duration {
+	if cc.has("no-cache") {
+		return 0
+	}
+	if d, ok := cc.seconds("max-age"); ok {
+		return d
+	}
+	if expires, err := http.ParseTime(e.header.Get("Expires")); err == nil {
+		date, err := http.ParseTime(e.header.Get("Date"))
+		if err != nil {
+			date = e.stored
+		}
+		return expires.Sub(date)
+	}
+	return 0
+}
+
+// age returns the age of the response at now.
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	age := now.Sub(e.stored)
+	if s, err := strconv.ParseInt(e.header.Get("Age"), 10, 64); err == nil && s > 0 {
+		age += time.Duration(s) * time.Second
+	}
+	return age
+}
+
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return e.age(now) < e.lifetime(parseCacheControl(e.header))
+}
+
+// staleIfError reports whether the response may be served at now in place of
+// a failed one.
+func (e *cacheEntry) staleIfError(now time.Time, fallback time.Duration) bool {
+	cc := parseCacheControl(e.header)
+	if cc.has("must-revalidate") || cc.has("proxy-revalidate") {
+		return false
+	}
+	allowed := fallback
+	if d, ok := cc.seconds("stale-if-error"); ok {
+		allowed = d
+	}
+	return e.age(now)-e.lifetime(cc) < allowed
+}
+
+// conditional returns a copy of req validating the entry, unless the caller
+// made req conditional already.
+func (e *cacheEntry) conditional(req *http.Request) *http.Request {
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return req
+	}
+	etag, modified := e.header.Get("ETag"), e.header.Get("Last-Modified")
+	if etag == "" && modified == "" {
+		return req
+	}
+	r := req.Clone(req.Context())
+	if etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	if modified != "" {
+		r.Header.Set("If-Modified-Since", modified)
+	}
+	return r
+}
+
+func (e *cacheEntry) response(req *http.Request, now time.Time) *http.Response {
+	header := e.header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         e.proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+}
+
+// cacheControl holds the directives of a Cache-Control header, by lowercase
+// name.
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	s, err := strconv.ParseInt(cc[name], 10, 64)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+	return time.Duration(s) * time.Second, true
+}
@@ -0,0 +1,221 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func newCachingClient(t *testing.T, h http.HandlerFunc, options ...httptransport.CacheOption) func() (string, error) {
+	t.Helper()
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	client := httptransport.NewClient[any, string](
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, *interface{}) error { return nil },
+		func(_ context.Context, r *http.Response) (string, error) {
+			b, err := io.ReadAll(r.Body)
+			return string(b), err
+		},
+		httptransport.ClientCache[any, string](httptransport.NewResponseCache(options...)),
+	)
+	return func() (string, error) {
+		return client.Endpoint()(context.Background(), nil)
+	}
+}
+
+func TestClientCacheFresh(t *testing.T) {
+	var hits atomic.Int32
+	get := newCachingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("fresh"))
+	})
+
+	for i := 0; i < 3; i++ {
+		body, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "fresh", body; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	if want, have := int32(1), hits.Load(); want != have {
+		t.Errorf("want %d upstream requests, have %d", want, have)
+	}
+}
+
+func TestClientCacheNoStore(t *testing.T) {
+	var hits atomic.Int32
+	get := newCachingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("secret"))
+	})
+
+	get()
+	get()
+	if want, have := int32(2), hits.Load(); want != have {
+		t.Errorf("want %d upstream requests, have %d", want, have)
+	}
+}
+
+func TestClientCacheRevalidation(t *testing.T) {
+	var hits, notModified atomic.Int32
+	get := newCachingClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("validated"))
+	})
+
+	for i := 0; i < 3; i++ {
+		body, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "validated", body; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	if want, have := int32(3), hits.Load(); want != have {
+		t.Errorf("want %d upstream requests, have %d", want, have)
+	}
+	if want, have := int32(2), notModified.Load(); want != have {
+		t.Errorf("want %d 304 responses, have %d", want, have)
+	}
+}
+
+func TestClientCacheStaleIfError(t *testing.T) {
+	for _, testcase := range []struct {
+		name         string
+		cacheControl string
+		options      []httptransport.CacheOption
+		want         string
+	}{
+		{"directive", "max-age=0, stale-if-error=60", nil, "stale"},
+		{"option", "max-age=0", []httptransport.CacheOption{httptransport.CacheStaleIfError(time.Minute)}, "stale"},
+		{"must-revalidate", "max-age=0, must-revalidate, stale-if-error=60", nil, "failed"},
+		{"none", "max-age=0", nil, "failed"},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			var failing atomic.Bool
+			get := newCachingClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if failing.Load() {
+					w.WriteHeader(http.StatusBadGateway)
+					w.Write([]byte("failed"))
+					return
+				}
+				w.Header().Set("Cache-Control", testcase.cacheControl)
+				w.Write([]byte("stale"))
+			}, testcase.options...)
+
+			if _, err := get(); err != nil {
+				t.Fatal(err)
+			}
+			failing.Store(true)
+			body, err := get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, have := testcase.want, body; want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestClientCacheVary(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	cache := httptransport.NewResponseCache()
+	get := func(lang string) string {
+		client := httptransport.NewClient[any, string](
+			"GET",
+			mustParse(server.URL),
+			func(context.Context, *http.Request, *interface{}) error { return nil },
+			func(_ context.Context, r *http.Response) (string, error) {
+				b, err := io.ReadAll(r.Body)
+				return string(b), err
+			},
+			httptransport.ClientBefore[any, string](httptransport.SetRequestHeader("Accept-Language", lang)),
+			httptransport.ClientCache[any, string](cache),
+		)
+		body, err := client.Endpoint()(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	for _, lang := range []string{"en", "en", "fr", "fr"} {
+		if want, have := lang, get(lang); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	// The entry is replaced when the variant changes.
+	if want, have := int32(2), hits.Load(); want != have {
+		t.Errorf("want %d upstream requests, have %d", want, have)
+	}
+}
+
+func TestClientCacheCredentials(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	cache := httptransport.NewResponseCache()
+	get := func(token string) string {
+		client := httptransport.NewClient[any, string](
+			"GET",
+			mustParse(server.URL),
+			func(context.Context, *http.Request, *interface{}) error { return nil },
+			func(_ context.Context, r *http.Response) (string, error) {
+				b, err := io.ReadAll(r.Body)
+				return string(b), err
+			},
+			httptransport.ClientBefore[any, string](httptransport.SetRequestHeader("Authorization", token)),
+			httptransport.ClientCache[any, string](cache),
+		)
+		body, err := client.Endpoint()(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	for _, token := range []string{"Bearer alice", "Bearer bob", "Bearer alice", "Bearer bob"} {
+		if want, have := token, get(token); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	// Each user has their own entry.
+	if want, have := int32(2), hits.Load(); want != have {
+		t.Errorf("want %d upstream requests, have %d", want, have)
+	}
+}
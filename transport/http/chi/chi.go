@@ -0,0 +1,46 @@
+// Package chi registers servers on go-chi routers, applying options shared by
+// all of them, e.g. for tracing, logging, metrics or authentication, and
+// capturing their route pattern in the context.
+package chi
+
+import (
+	"net/http"
+
+	"github.com/a69/kit.go/endpoint"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+// Routes is the part of chi.Router used to register servers, so that this
+// package doesn't depend on a particular version of chi.
+type Routes interface {
+	Method(method, pattern string, h http.Handler)
+}
+
+// Router registers servers on a chi router.
+type Router struct {
+	routes  Routes
+	options []httptransport.ServerOption[any, any]
+}
+
+// NewRouter returns a Router registering servers on routes, typically a
+// chi.Router, with the shared options, see httptransport.ServerShared.
+func NewRouter(routes Routes, options ...httptransport.ServerOption[any, any]) *Router {
+	return &Router{routes: routes, options: options}
+}
+
+// Handle registers a server of the endpoint on the method and pattern of the
+// router. The server is constructed with the shared options of the router,
+// followed by the given options. The pattern is available to the server's
+// request functions and finalizers with httptransport.RouteFromContext. It's
+// the pattern as registered, i.e. relative to the mount point of subrouters.
+func Handle[REQ any, RES any](
+	r *Router,
+	method, pattern string,
+	e endpoint.Endpoint[REQ, RES],
+	dec httptransport.DecodeRequestFunc[REQ],
+	enc httptransport.EncodeResponseFunc[RES],
+	options ...httptransport.ServerOption[REQ, RES],
+) {
+	options = append([]httptransport.ServerOption[REQ, RES]{httptransport.ServerShared[REQ, RES](r.options...)}, options...)
+	r.routes.Method(method, pattern, httptransport.RouteHandler(pattern, httptransport.NewServer(e, dec, enc, options...)))
+}
@@ -0,0 +1,72 @@
+package chi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+	kitchi "github.com/a69/kit.go/transport/http/chi"
+)
+
+// serveMux adapts http.ServeMux to the Routes of chi routers.
+type serveMux struct{ *http.ServeMux }
+
+func (m serveMux) Method(method, pattern string, h http.Handler) {
+	m.Handle(method+" "+pattern, h)
+}
+
+func TestHandle(t *testing.T) {
+	var routes []string
+	m := http.NewServeMux()
+	router := kitchi.NewRouter(serveMux{m},
+		httptransport.ServerFinalizer[any, any](func(ctx context.Context, _ int, _ *http.Request) {
+			route, _ := httptransport.RouteFromContext(ctx)
+			routes = append(routes, route)
+		}),
+	)
+	kitchi.Handle(router, "GET", "/users/{id}",
+		func(_ context.Context, id string) (string, error) { return id, nil },
+		func(_ context.Context, r *http.Request) (string, error) { return r.PathValue("id"), nil },
+		func(_ context.Context, w http.ResponseWriter, id string) error {
+			_, err := w.Write([]byte(id))
+			return err
+		},
+	)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if want, have := "42", rec.Body.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := []string{"/users/{id}"}, routes; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSharedServerAudit(t *testing.T) {
+	var records []httptransport.AuditRecord
+	m := http.NewServeMux()
+	router := kitchi.NewRouter(serveMux{m},
+		httptransport.ServerAudit[any, any](httptransport.AuditSinkFunc(func(_ context.Context, r httptransport.AuditRecord) {
+			records = append(records, r)
+		})),
+	)
+	kitchi.Handle(router, "GET", "/users/{id}",
+		func(_ context.Context, id string) (string, error) { return id, nil },
+		func(_ context.Context, r *http.Request) (string, error) { return r.PathValue("id"), nil },
+		func(_ context.Context, w http.ResponseWriter, id string) error {
+			_, err := w.Write([]byte(id))
+			return err
+		},
+	)
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	if want, have := 1, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+	if want, have := "/users/{id}", records[0].Route; want != have {
+		t.Errorf("want route %q, have %q", want, have)
+	}
+}
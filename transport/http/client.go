@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -27,6 +28,8 @@ type Client[REQ any, RES any] struct {
 	after          []ClientResponseFunc
 	finalizer      []ClientFinalizerFunc
 	bufferedStream bool
+	cache          *ResponseCache
+	middleware     []ClientMiddleware
 }
 
 // NewClient constructs a usable Client for a single remote method.
@@ -34,6 +37,39 @@ func NewClient[REQ any, RES any](method string, tgt *url.URL, enc EncodeRequestF
 	return NewExplicitClient(makeCreateRequestFunc(method, tgt, enc), dec, options...)
 }
 
+// NewDynamicClient is like NewClient but selects the target URL of each
+// request with target, e.g. to route requests to the host of their tenant or
+// region, instead of binding a single one. A target set on the context with
+// WithTarget takes precedence over the one returned by target.
+func NewDynamicClient[REQ any, RES any](method string, target TargetFunc[REQ], enc EncodeRequestFunc[REQ], dec DecodeResponseFunc[RES], options ...ClientOption[REQ, RES]) *Client[REQ, RES] {
+	return NewExplicitClient(makeDynamicCreateRequestFunc(method, func(ctx context.Context, request REQ) (*url.URL, error) {
+		if tgt, ok := ctx.Value(targetKey{}).(*url.URL); ok {
+			return tgt, nil
+		}
+		return target(ctx, request)
+	}, enc), dec, options...)
+}
+
+// TargetFunc returns the target URL of a request made by a client created
+// with NewDynamicClient.
+type TargetFunc[REQ any] func(ctx context.Context, request REQ) (*url.URL, error)
+
+// ErrNoTarget is returned by clients when the target URL of a request is nil.
+var ErrNoTarget = errors.New("no target URL")
+
+type targetKey struct{}
+
+// WithTarget returns a context overriding the target URL of the requests
+// made with it by clients created with NewDynamicClient. Clients created with
+// NewClient always use the target they were created with. A nil target is
+// ignored.
+func WithTarget(ctx context.Context, target *url.URL) context.Context {
+	if target == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
 // NewExplicitClient is like NewClient but uses a CreateRequestFunc instead of a
 // method, target URL, and EncodeRequestFunc, which allows for more control over
 // the outgoing HTTP request.
@@ -88,6 +124,7 @@ func BufferedStream[REQ any, RES any](buffered bool) ClientOption[REQ, RES] {
 
 // Endpoint returns a usable Go kit endpoint that calls the remote HTTP endpoint.
 func (c Client[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
+	client := ChainClient(c.client, c.middleware...)
 	return func(ctx context.Context, request REQ) (response RES, err error) {
 		ctx, cancel := context.WithCancel(ctx)
 
@@ -115,7 +152,11 @@ func (c Client[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 			ctx = f(ctx, req)
 		}
 
-		resp, err = c.client.Do(req.WithContext(ctx))
+		if c.cache != nil {
+			resp, err = c.cache.do(client, req.WithContext(ctx))
+		} else {
+			resp, err = client.Do(req.WithContext(ctx))
+		}
 		if err != nil {
 			cancel()
 			return
@@ -191,8 +232,22 @@ func EncodeXMLRequest(c context.Context, r *http.Request, request interface{}) e
 //
 
 func makeCreateRequestFunc[REQ any](method string, target *url.URL, enc EncodeRequestFunc[REQ]) CreateRequestFunc[REQ] {
+	return makeDynamicCreateRequestFunc(method, func(context.Context, REQ) (*url.URL, error) {
+		return target, nil
+	}, enc)
+}
+
+func makeDynamicCreateRequestFunc[REQ any](method string, target TargetFunc[REQ], enc EncodeRequestFunc[REQ]) CreateRequestFunc[REQ] {
 	return func(ctx context.Context, request REQ) (*http.Request, error) {
-		req, err := http.NewRequest(method, target.String(), nil)
+		tgt, err := target(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if tgt == nil {
+			return nil, ErrNoTarget
+		}
+
+		req, err := http.NewRequest(method, tgt.String(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -3,6 +3,7 @@ package http_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -319,6 +320,85 @@ func TestNewExplicitClient(t *testing.T) {
 	}
 }
 
+func TestNewDynamicClient(t *testing.T) {
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s%s", name, r.URL.Path)
+		}))
+	}
+	eu, us := newServer("eu"), newServer("us")
+	defer eu.Close()
+	defer us.Close()
+
+	client := httptransport.NewDynamicClient(
+		"GET",
+		func(_ context.Context, region string) (*url.URL, error) {
+			switch region {
+			case "eu":
+				return mustParse(eu.URL + "/users"), nil
+			case "us":
+				return mustParse(us.URL + "/users"), nil
+			}
+			return nil, errors.New("unknown region")
+		},
+		func(context.Context, *http.Request, *string) error { return nil },
+		func(_ context.Context, resp *http.Response) (string, error) {
+			buf, err := ioutil.ReadAll(resp.Body)
+			return string(buf), err
+		},
+	)
+
+	for _, testcase := range []struct {
+		ctx     context.Context
+		request string
+		want    string
+	}{
+		{context.Background(), "eu", "eu/users"},
+		{context.Background(), "us", "us/users"},
+		{httptransport.WithTarget(context.Background(), mustParse(us.URL+"/override")), "eu", "us/override"},
+	} {
+		have, err := client.Endpoint()(testcase.ctx, testcase.request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := testcase.want; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+
+	if _, err := client.Endpoint()(context.Background(), "apac"); err == nil {
+		t.Error("want error for unknown region, have none")
+	}
+
+	// Clients with a static target ignore the override.
+	static := httptransport.NewClient(
+		"GET",
+		mustParse(eu.URL+"/static"),
+		func(context.Context, *http.Request, *string) error { return nil },
+		func(_ context.Context, resp *http.Response) (string, error) {
+			buf, err := ioutil.ReadAll(resp.Body)
+			return string(buf), err
+		},
+	)
+	have, err := static.Endpoint()(httptransport.WithTarget(context.Background(), mustParse(us.URL+"/override")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "eu/static"; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	nilTarget := httptransport.NewDynamicClient(
+		"GET",
+		func(context.Context, string) (*url.URL, error) { return nil, nil },
+		func(context.Context, *http.Request, *string) error { return nil },
+		func(context.Context, *http.Response) (string, error) { return "", nil },
+	)
+	if _, err := nilTarget.Endpoint()(httptransport.WithTarget(context.Background(), nil), ""); !errors.Is(err, httptransport.ErrNoTarget) {
+		t.Errorf("want %v, have %v", httptransport.ErrNoTarget, err)
+	}
+}
+
 func mustParse(s string) *url.URL {
 	u, err := url.Parse(s)
 	if err != nil {
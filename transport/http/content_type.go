@@ -0,0 +1,110 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned to the error encoder of a server
+	// for requests whose Content-Type isn't allowed by ServerContentTypes.
+	// It's encoded with status 415 by DefaultErrorEncoder.
+	ErrUnsupportedMediaType error = statusError{http.StatusUnsupportedMediaType, "unsupported media type"}
+
+	// ErrNotAcceptable is returned to the error encoder of a server for
+	// requests whose Accept header rules out all the media types set with
+	// ServerProduces. It's encoded with status 406 by DefaultErrorEncoder.
+	ErrNotAcceptable error = statusError{http.StatusNotAcceptable, "not acceptable"}
+)
+
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e statusError) Error() string   { return e.msg }
+func (e statusError) StatusCode() int { return e.code }
+
+// ServerContentTypes rejects the requests with a body whose Content-Type
+// isn't one of the given media types with ErrUnsupportedMediaType, before
+// they're decoded, e.g. so that a JSON decoder is never handed a form-encoded
+// or multipart body. Media types may end in a wildcard subtype, e.g.
+// "text/*". Parameters such as the charset are ignored.
+func ServerContentTypes[REQ any, RES any](mediaTypes ...string) ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.consumes = append(s.consumes, normalizeMediaTypes(mediaTypes)...) }
+}
+
+// ServerProduces sets the media types the response encoder of the server
+// produces, and rejects the requests whose Accept header doesn't allow any of
+// them with ErrNotAcceptable, before they're decoded. Requests without an
+// Accept header accept anything.
+func ServerProduces[REQ any, RES any](mediaTypes ...string) ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.produces = append(s.produces, normalizeMediaTypes(mediaTypes)...) }
+}
+
+// checkMediaTypes returns the error rejecting r, if any.
+func checkMediaTypes(r *http.Request, consumes, produces []string) error {
+	if len(consumes) > 0 && hasBody(r) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || !matchMediaType(consumes, mediaType) {
+			return ErrUnsupportedMediaType
+		}
+	}
+	if len(produces) > 0 && r.Header.Get("Accept") != "" && !acceptable(r.Header.Values("Accept"), produces) {
+		return ErrNotAcceptable
+	}
+	return nil
+}
+
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
+
+func normalizeMediaTypes(mediaTypes []string) []string {
+	normalized := make([]string, 0, len(mediaTypes))
+	for _, t := range mediaTypes {
+		if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+			t = mediaType
+		}
+		normalized = append(normalized, strings.ToLower(t))
+	}
+	return normalized
+}
+
+// matchMediaType reports whether the media type matches one of the patterns,
+// which may have a wildcard subtype.
+func matchMediaType(patterns []string, mediaType string) bool {
+	for _, p := range patterns {
+		if p == mediaType || p == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptable reports whether the media ranges of the Accept headers allow one
+// of the produced media types.
+func acceptable(accept []string, produces []string) bool {
+	for _, header := range accept {
+		for _, r := range strings.Split(header, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+			if err != nil {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			for _, t := range produces {
+				if matchMediaType([]string{mediaRange}, t) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestServerContentTypes(t *testing.T) {
+	var decoded bool
+	handler := httptransport.NewServer(
+		func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, *http.Request) (interface{}, error) { decoded = true; return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		httptransport.ServerContentTypes[interface{}, interface{}]("application/json", "text/*"),
+		httptransport.ServerProduces[interface{}, interface{}]("application/json"),
+	)
+
+	for _, testcase := range []struct {
+		name        string
+		body        string
+		contentType string
+		accept      string
+		want        int
+	}{
+		{"json", `{}`, "application/json", "", http.StatusOK},
+		{"json with charset", `{}`, "Application/JSON; charset=utf-8", "", http.StatusOK},
+		{"wildcard", `{}`, "text/plain", "", http.StatusOK},
+		{"no body", "", "", "", http.StatusOK},
+		{"form", "a=b", "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType},
+		{"multipart", "--x--", "multipart/form-data; boundary=x", "", http.StatusUnsupportedMediaType},
+		{"missing", `{}`, "", "", http.StatusUnsupportedMediaType},
+		{"accept json", `{}`, "application/json", "application/json", http.StatusOK},
+		{"accept any", `{}`, "application/json", "text/html, */*;q=0.1", http.StatusOK},
+		{"accept application", `{}`, "application/json", "application/*", http.StatusOK},
+		{"accept xml", `{}`, "application/json", "application/xml", http.StatusNotAcceptable},
+		{"accept q=0", `{}`, "application/json", "application/json;q=0, text/html", http.StatusNotAcceptable},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			decoded = false
+			req := httptest.NewRequest("POST", "/", strings.NewReader(testcase.body))
+			if testcase.contentType != "" {
+				req.Header.Set("Content-Type", testcase.contentType)
+			}
+			if testcase.accept != "" {
+				req.Header.Set("Accept", testcase.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if want, have := testcase.want, rec.Code; want != have {
+				t.Errorf("want %d, have %d", want, have)
+			}
+			if want, have := testcase.want == http.StatusOK, decoded; want != have {
+				t.Errorf("decoded: want %v, have %v", want, have)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/a69/kit.go/tracing"
+)
+
+// DebugToContext returns a RequestFunc that forces trace sampling in the
+// context if the request carries a tracing.DebugHeader and allow accepts it.
+// A nil allow accepts every request, and should only be used behind a trusted
+// edge. Particularly useful for servers.
+func DebugToContext(allow func(*http.Request) bool) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if tracing.DebugRequested(r.Header.Get(tracing.DebugHeader)) && (allow == nil || allow(r)) {
+			ctx = tracing.WithForceSample(ctx)
+		}
+		return ctx
+	}
+}
+
+// ContextToDebug returns a RequestFunc that sets the tracing.DebugHeader if
+// sampling is forced in the context. Particularly useful for clients.
+func ContextToDebug() RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if tracing.ForceSampled(ctx) {
+			r.Header.Set(tracing.DebugHeader, "1")
+		}
+		return ctx
+	}
+}
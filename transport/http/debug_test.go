@@ -0,0 +1,50 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/a69/kit.go/tracing"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestDebugToContext(t *testing.T) {
+	ctx := context.Background()
+	if tracing.ForceSampled(ctx) {
+		t.Fatal("background context shouldn't be force sampled")
+	}
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	httptransport.ContextToDebug()(ctx, req)
+	if have := req.Header.Get(tracing.DebugHeader); have != "" {
+		t.Errorf("want no header, have %q", have)
+	}
+
+	httptransport.ContextToDebug()(tracing.WithForceSample(ctx), req)
+	if !tracing.ForceSampled(httptransport.DebugToContext(nil)(ctx, req)) {
+		t.Error("want force sampled context on the server side")
+	}
+
+	for value, want := range map[string]bool{"1": true, "true": true, "0": false, "yes": false} {
+		req.Header.Set(tracing.DebugHeader, value)
+		if have := tracing.ForceSampled(httptransport.DebugToContext(nil)(ctx, req)); want != have {
+			t.Errorf("%q: want %v, have %v", value, want, have)
+		}
+	}
+}
+
+func TestDebugToContextValidator(t *testing.T) {
+	ctx := context.Background()
+	operator := func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer operator" }
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set(tracing.DebugHeader, "1")
+	if tracing.ForceSampled(httptransport.DebugToContext(operator)(ctx, req)) {
+		t.Error("want no force sampling for a rejected request")
+	}
+	req.Header.Set("Authorization", "Bearer operator")
+	if !tracing.ForceSampled(httptransport.DebugToContext(operator)(ctx, req)) {
+		t.Error("want force sampling for an allowed request")
+	}
+}
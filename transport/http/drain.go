@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Drainer tracks the requests in flight of the handlers it wraps, so that
+// they can be drained before a server shuts down: once draining starts, new
+// requests are rejected with 503 Service Unavailable, telling clients to
+// retry elsewhere, while the requests in flight are given time to complete.
+//
+// Unlike http.Server.Shutdown, which stops accepting connections, a Drainer
+// also turns away the requests arriving on connections kept alive, and
+// closes those connections, so that load balancers and clients move to
+// other instances right away. It can drain some of the handlers of a server
+// only, e.g. those of a Server, through ServerDrainer.
+type Drainer struct {
+	inFlight atomic.Int64
+	draining atomic.Bool
+
+	retryAfter time.Duration
+	interval   time.Duration
+	progress   func(inFlight int)
+}
+
+// NewDrainer returns a Drainer, not draining.
+func NewDrainer(options ...DrainerOption) *Drainer {
+	d := &Drainer{retryAfter: time.Second}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// DrainerOption sets an optional parameter for drainers.
+type DrainerOption func(*Drainer)
+
+// DrainRetryAfter sets the Retry-After header of the requests rejected while
+// draining. By default, it's 1s. Zero omits the header.
+func DrainRetryAfter(d time.Duration) DrainerOption {
+	return func(dr *Drainer) { dr.retryAfter = d }
+}
+
+// DrainProgress sets a function called with the number of requests still in
+// flight, every interval while draining, e.g. to log the progress of
+// shutdowns that take long.
+func DrainProgress(interval time.Duration, progress func(inFlight int)) DrainerOption {
+	return func(d *Drainer) { d.interval, d.progress = interval, progress }
+}
+
+// Handler wraps next, tracking its requests in flight, and rejecting new
+// ones while draining.
+func (d *Drainer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests are counted before checking whether the drainer is
+		// draining, so that Drain can't miss them.
+		d.inFlight.Add(1)
+		if d.draining.Load() {
+			d.inFlight.Add(-1)
+			w.Header().Set("Connection", "close")
+			if d.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.retryAfter.Seconds()))))
+			}
+			http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		defer d.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServerDrainer tracks the requests in flight of the server with d, from
+// before they're decoded until their response is written.
+func ServerDrainer[REQ any, RES any](d *Drainer) ServerOption[REQ, RES] {
+	return ServerMiddleware[REQ, RES](d.Handler)
+}
+
+// InFlight returns the number of requests in flight.
+func (d *Drainer) InFlight() int { return int(d.inFlight.Load()) }
+
+// Draining reports whether the drainer is draining.
+func (d *Drainer) Draining() bool { return d.draining.Load() }
+
+// Drain starts rejecting new requests, and waits for the ones in flight to
+// complete, or for ctx to be done, in which case it returns an error
+// wrapping the one of ctx. Draining can't be undone.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.draining.Store(true)
+
+	poll := time.NewTicker(10 * time.Millisecond)
+	defer poll.Stop()
+	var progress <-chan time.Time
+	if d.progress != nil && d.interval > 0 {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		progress = ticker.C
+	}
+	for {
+		n := d.InFlight()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-poll.C:
+		case <-progress:
+			d.progress(n)
+		case <-ctx.Done():
+			return fmt.Errorf("%d requests still in flight: %w", n, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestDrainer(t *testing.T) {
+	var (
+		reports  atomic.Int32
+		drainer  = httptransport.NewDrainer(httptransport.DrainProgress(5*time.Millisecond, func(int) { reports.Add(1) }))
+		entered  = make(chan struct{})
+		release  = make(chan struct{})
+		handlerc = make(chan struct{})
+		server   = httptransport.NewServer(
+			func(context.Context, struct{}) (struct{}, error) {
+				close(entered)
+				<-release
+				return struct{}{}, nil
+			},
+			func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+			httptransport.EncodeJSONResponse[struct{}],
+			httptransport.ServerDrainer[struct{}, struct{}](drainer),
+		)
+	)
+	go func() {
+		defer close(handlerc)
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-entered
+	if want, have := 1, drainer.InFlight(); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	// Draining times out while the request is in flight.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := drainer.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if reports.Load() == 0 {
+		t.Error("want progress reports")
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want, have := http.StatusServiceUnavailable, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := "1", rec.Header().Get("Retry-After"); want != have {
+		t.Errorf("Retry-After: want %q, have %q", want, have)
+	}
+
+	close(release)
+	<-handlerc
+	if err := drainer.Drain(context.Background()); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// StreamReader yields the messages of an incoming stream, one at a time.
+// Recv returns io.EOF once the peer has finished sending.
+type StreamReader[T any] interface {
+	Recv() (T, error)
+}
+
+// StreamWriter sends messages on an outgoing stream, one at a time. Each
+// message is flushed to the peer as soon as it's written.
+type StreamWriter[T any] interface {
+	Send(T) error
+}
+
+// DuplexEndpoint is the streaming counterpart of endpoint.Endpoint. It reads
+// requests from in while writing responses to out, in any interleaving, and
+// returns once it's done with both.
+type DuplexEndpoint[IN any, OUT any] func(ctx context.Context, in StreamReader[IN], out StreamWriter[OUT]) error
+
+// DecodeStreamFunc reads a single message from a stream. It must return io.EOF
+// when the stream ends cleanly between messages.
+type DecodeStreamFunc[T any] func(context.Context, *bufio.Reader) (T, error)
+
+// EncodeStreamFunc writes a single message to a stream.
+type EncodeStreamFunc[T any] func(context.Context, io.Writer, T) error
+
+// DecodeJSONStream is a DecodeStreamFunc for newline-delimited JSON. Empty
+// lines are skipped.
+func DecodeJSONStream[T any](_ context.Context, r *bufio.Reader) (msg T, err error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			return msg, json.Unmarshal(line, &msg)
+		}
+		if err != nil {
+			return msg, err
+		}
+	}
+}
+
+// EncodeJSONStream is an EncodeStreamFunc for newline-delimited JSON.
+func EncodeJSONStream[T any](_ context.Context, w io.Writer, msg T) error {
+	return json.NewEncoder(w).Encode(msg)
+}
+
+// DuplexServer wraps a DuplexEndpoint and implements http.Handler. The client
+// streams the request body while it reads the response body, which requires
+// HTTP/2, or an HTTP/1.1 client and server that both support full-duplex
+// exchanges. This is mostly useful for proxies and tunnels.
+type DuplexServer[IN any, OUT any] struct {
+	e            DuplexEndpoint[IN, OUT]
+	dec          DecodeStreamFunc[IN]
+	enc          EncodeStreamFunc[OUT]
+	before       []RequestFunc
+	errorEncoder ErrorEncoder
+	errorHandler transport.ErrorHandler
+}
+
+// NewDuplexServer constructs a new DuplexServer, which implements http.Handler
+// and wraps the provided DuplexEndpoint.
+func NewDuplexServer[IN any, OUT any](
+	e DuplexEndpoint[IN, OUT],
+	dec DecodeStreamFunc[IN],
+	enc EncodeStreamFunc[OUT],
+	options ...DuplexServerOption[IN, OUT],
+) *DuplexServer[IN, OUT] {
+	s := &DuplexServer[IN, OUT]{
+		e:            e,
+		dec:          dec,
+		enc:          enc,
+		errorEncoder: DefaultErrorEncoder,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// DuplexServerOption sets an optional parameter for duplex servers.
+type DuplexServerOption[IN any, OUT any] func(*DuplexServer[IN, OUT])
+
+// DuplexServerBefore functions are executed on the HTTP request object before
+// the stream is opened.
+func DuplexServerBefore[IN any, OUT any](before ...RequestFunc) DuplexServerOption[IN, OUT] {
+	return func(s *DuplexServer[IN, OUT]) { s.before = append(s.before, before...) }
+}
+
+// DuplexServerErrorEncoder is used to encode errors that occur before the
+// response stream has started, i.e. before the response header is written,
+// such as an HTTP/1.1 server that can't do full-duplex exchanges. Errors
+// that occur afterwards can't change the response status: they're passed to
+// the ErrorHandler, and abort the stream, so that the client fails to
+// receive rather than seeing a clean end of the stream.
+func DuplexServerErrorEncoder[IN any, OUT any](ee ErrorEncoder) DuplexServerOption[IN, OUT] {
+	return func(s *DuplexServer[IN, OUT]) { s.errorEncoder = ee }
+}
+
+// DuplexServerErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored.
+func DuplexServerErrorHandler[IN any, OUT any](errorHandler transport.ErrorHandler) DuplexServerOption[IN, OUT] {
+	return func(s *DuplexServer[IN, OUT]) { s.errorHandler = errorHandler }
+}
+
+// ServeHTTP implements http.Handler.
+func (s DuplexServer[IN, OUT]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	for _, f := range s.before {
+		ctx = f(ctx, r)
+	}
+
+	// HTTP/2 is always full duplex; HTTP/1.1 needs to be asked. The error is
+	// expected for HTTP/2, so it's only fatal for HTTP/1.
+	rc := http.NewResponseController(w)
+	if err := rc.EnableFullDuplex(); err != nil && r.ProtoMajor < 2 {
+		s.errorHandler.Handle(ctx, err)
+		s.errorEncoder(ctx, err, w)
+		return
+	}
+
+	// From here on, the header is written, and errors end the stream.
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.errorHandler.Handle(ctx, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	in := &streamReader[IN]{ctx: ctx, r: bufio.NewReader(r.Body), dec: s.dec}
+	out := &streamWriter[OUT]{ctx: ctx, w: w, flush: rc.Flush, enc: s.enc}
+	if err := s.e(ctx, in, out); err != nil {
+		s.errorHandler.Handle(ctx, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// DuplexClient opens full-duplex streams to a remote DuplexServer.
+type DuplexClient[OUT any, IN any] struct {
+	client HTTPClient
+	method string
+	tgt    *url.URL
+	enc    EncodeStreamFunc[OUT]
+	dec    DecodeStreamFunc[IN]
+	before []RequestFunc
+	after  []ClientResponseFunc
+}
+
+// NewDuplexClient constructs a usable DuplexClient for a single remote
+// streaming method. The underlying HTTPClient must support full-duplex
+// exchanges, e.g. an *http.Client speaking HTTP/2.
+func NewDuplexClient[OUT any, IN any](
+	method string,
+	tgt *url.URL,
+	enc EncodeStreamFunc[OUT],
+	dec DecodeStreamFunc[IN],
+	options ...DuplexClientOption[OUT, IN],
+) *DuplexClient[OUT, IN] {
+	c := &DuplexClient[OUT, IN]{
+		client: http.DefaultClient,
+		method: method,
+		tgt:    tgt,
+		enc:    enc,
+		dec:    dec,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// DuplexClientOption sets an optional parameter for duplex clients.
+type DuplexClientOption[OUT any, IN any] func(*DuplexClient[OUT, IN])
+
+// SetDuplexClient sets the underlying HTTP client used for streams.
+// By default, http.DefaultClient is used.
+func SetDuplexClient[OUT any, IN any](client HTTPClient) DuplexClientOption[OUT, IN] {
+	return func(c *DuplexClient[OUT, IN]) { c.client = client }
+}
+
+// DuplexClientBefore adds one or more RequestFuncs to be applied to the
+// outgoing HTTP request before the stream is opened.
+func DuplexClientBefore[OUT any, IN any](before ...RequestFunc) DuplexClientOption[OUT, IN] {
+	return func(c *DuplexClient[OUT, IN]) { c.before = append(c.before, before...) }
+}
+
+// DuplexClientAfter adds one or more ClientResponseFuncs, which are applied to
+// the HTTP response once its headers have been received.
+func DuplexClientAfter[OUT any, IN any](after ...ClientResponseFunc) DuplexClientOption[OUT, IN] {
+	return func(c *DuplexClient[OUT, IN]) { c.after = append(c.after, after...) }
+}
+
+// ErrStreamStatus is returned by Open when the server doesn't answer with a
+// 200 status.
+var ErrStreamStatus = errors.New("stream rejected by server")
+
+// Open starts a stream. It returns once the server has acknowledged the
+// stream by sending its response headers. Canceling ctx aborts the stream.
+func (c DuplexClient[OUT, IN]) Open(ctx context.Context) (*DuplexStream[OUT, IN], error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, c.method, c.tgt.String(), pr)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for _, f := range c.before {
+		ctx = f(ctx, req)
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		pw.CloseWithError(err)
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		pw.CloseWithError(ErrStreamStatus)
+		cancel()
+		return nil, ErrStreamStatus
+	}
+	for _, f := range c.after {
+		ctx = f(ctx, resp)
+	}
+
+	return &DuplexStream[OUT, IN]{
+		streamWriter: streamWriter[OUT]{ctx: ctx, w: pw, enc: c.enc},
+		streamReader: streamReader[IN]{ctx: ctx, r: bufio.NewReader(resp.Body), dec: c.dec},
+		body:         pw,
+		resp:         resp,
+		cancel:       cancel,
+	}, nil
+}
+
+// DuplexStream is the client side of an open stream. Send and Recv may be
+// called concurrently with each other, but neither may be called concurrently
+// with itself.
+type DuplexStream[OUT any, IN any] struct {
+	streamWriter[OUT]
+	streamReader[IN]
+	body   *io.PipeWriter
+	resp   *http.Response
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// CloseSend signals the server that no more messages will be sent. Responses
+// can still be received.
+func (s *DuplexStream[OUT, IN]) CloseSend() error {
+	return s.body.Close()
+}
+
+// Close tears down both directions of the stream.
+func (s *DuplexStream[OUT, IN]) Close() error {
+	var err error
+	s.once.Do(func() {
+		s.body.Close()
+		err = s.resp.Body.Close()
+		s.cancel()
+	})
+	return err
+}
+
+type streamReader[T any] struct {
+	ctx context.Context
+	r   *bufio.Reader
+	dec DecodeStreamFunc[T]
+}
+
+func (s *streamReader[T]) Recv() (T, error) {
+	return s.dec(s.ctx, s.r)
+}
+
+type streamWriter[T any] struct {
+	ctx   context.Context
+	w     io.Writer
+	flush func() error
+	enc   EncodeStreamFunc[T]
+}
+
+func (s *streamWriter[T]) Send(msg T) error {
+	if err := s.enc(s.ctx, s.w, msg); err != nil {
+		return err
+	}
+	if s.flush != nil {
+		return s.flush()
+	}
+	return nil
+}
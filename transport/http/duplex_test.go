@@ -0,0 +1,133 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestDuplexEcho(t *testing.T) {
+	upper := func(_ context.Context, in httptransport.StreamReader[string], out httptransport.StreamWriter[string]) error {
+		for {
+			msg, err := in.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := out.Send(strings.ToUpper(msg)); err != nil {
+				return err
+			}
+		}
+	}
+	server := httptest.NewUnstartedServer(httptransport.NewDuplexServer(
+		upper,
+		httptransport.DecodeJSONStream[string],
+		httptransport.EncodeJSONStream[string],
+	))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tgt, _ := url.Parse(server.URL)
+	client := httptransport.NewDuplexClient(
+		"POST",
+		tgt,
+		httptransport.EncodeJSONStream[string],
+		httptransport.DecodeJSONStream[string],
+		httptransport.SetDuplexClient[string, string](server.Client()),
+	)
+
+	stream, err := client.Open(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// Each response must arrive before the next request is sent, which is
+	// only possible if the exchange is really full duplex.
+	for _, msg := range []string{"a", "bc", "def"} {
+		if err := stream.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+		have, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.ToUpper(msg); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("want EOF, have %v", err)
+	}
+}
+
+func TestDuplexServerAbortsOnError(t *testing.T) {
+	failing := func(_ context.Context, in httptransport.StreamReader[string], out httptransport.StreamWriter[string]) error {
+		if err := out.Send("first"); err != nil {
+			return err
+		}
+		return errors.New("endpoint failed")
+	}
+	server := httptest.NewUnstartedServer(httptransport.NewDuplexServer(
+		failing,
+		httptransport.DecodeJSONStream[string],
+		httptransport.EncodeJSONStream[string],
+	))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tgt, _ := url.Parse(server.URL)
+	client := httptransport.NewDuplexClient(
+		"POST",
+		tgt,
+		httptransport.EncodeJSONStream[string],
+		httptransport.DecodeJSONStream[string],
+		httptransport.SetDuplexClient[string, string](server.Client()),
+	)
+
+	stream, err := client.Open(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if msg, err := stream.Recv(); err != nil || msg != "first" {
+		t.Fatalf("want first, have %q, %v", msg, err)
+	}
+	if _, err := stream.Recv(); err == nil || err == io.EOF {
+		t.Errorf("want the stream aborted, have %v", err)
+	}
+}
+
+func TestDuplexServerRequiresFullDuplex(t *testing.T) {
+	server := httptransport.NewDuplexServer(
+		func(context.Context, httptransport.StreamReader[string], httptransport.StreamWriter[string]) error {
+			t.Error("endpoint called")
+			return nil
+		},
+		httptransport.DecodeJSONStream[string],
+		httptransport.EncodeJSONStream[string],
+	)
+
+	// The recorder can't do full-duplex exchanges.
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if want, have := http.StatusInternalServerError, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type headerWriterKey struct{}
+
+// HeaderWriter lets endpoints set response headers while they run, before the
+// response is encoded, and send 103 Early Hints so that browsers can start
+// preloading resources while the response is still being computed. Servers
+// put a HeaderWriter in the context of every request; retrieve it with
+// HeaderWriterFromContext. It's safe for concurrent use, e.g. by goroutines
+// started by the endpoint.
+//
+// Headers set once the endpoint returned are ignored, as the server then
+// takes over the response headers to encode the response.
+type HeaderWriter struct {
+	mtx    sync.Mutex
+	w      http.ResponseWriter
+	closed bool
+}
+
+// HeaderWriterFromContext returns the HeaderWriter of the response to the
+// request being served.
+func HeaderWriterFromContext(ctx context.Context) (*HeaderWriter, bool) {
+	h, ok := ctx.Value(headerWriterKey{}).(*HeaderWriter)
+	return h, ok
+}
+
+func contextWithHeaderWriter(ctx context.Context, w http.ResponseWriter) (context.Context, *HeaderWriter) {
+	h := &HeaderWriter{w: w}
+	return context.WithValue(ctx, headerWriterKey{}, h), h
+}
+
+// close makes the HeaderWriter ignore further changes. Once it returns, the
+// headers of w are only changed by the caller.
+func (h *HeaderWriter) close() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.closed = true
+}
+
+// Set sets a response header, replacing any existing values.
+func (h *HeaderWriter) Set(key, value string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if !h.closed {
+		h.w.Header().Set(key, value)
+	}
+}
+
+// Add adds a value to a response header.
+func (h *HeaderWriter) Add(key, value string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if !h.closed {
+		h.w.Header().Add(key, value)
+	}
+}
+
+// Del deletes a response header.
+func (h *HeaderWriter) Del(key string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if !h.closed {
+		h.w.Header().Del(key)
+	}
+}
+
+// EarlyHints adds the given Link header values, e.g.
+// "</app.css>; rel=preload; as=style", and immediately sends them, with any
+// other headers set so far, in a 103 Early Hints informational response. The
+// headers are sent again with the final response. It may be called several
+// times, but only before the endpoint returns.
+func (h *HeaderWriter) EarlyHints(links ...string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.closed {
+		return
+	}
+	for _, link := range links {
+		h.w.Header().Add("Link", link)
+	}
+	h.w.WriteHeader(http.StatusEarlyHints)
+}
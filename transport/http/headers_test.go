@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"sync"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestHeaderWriter(t *testing.T) {
+	var finalCode int
+	handler := httptransport.NewServer(
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			h, ok := httptransport.HeaderWriterFromContext(ctx)
+			if !ok {
+				t.Fatal("no HeaderWriter in context")
+			}
+			h.EarlyHints("</app.css>; rel=preload; as=style")
+			h.Set("X-Computed", "yes")
+			return struct{}{}, nil
+		},
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+			_, err := w.Write([]byte("ok"))
+			return err
+		},
+		httptransport.ServerFinalizer[interface{}, interface{}](func(_ context.Context, code int, _ *http.Request) {
+			finalCode = code
+		}),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	var hints []textproto.MIMEHeader
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, header)
+			}
+			return nil
+		},
+	})
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want, have := 1, len(hints); want != have {
+		t.Fatalf("early hints: want %d, have %d", want, have)
+	}
+	if want, have := "</app.css>; rel=preload; as=style", hints[0].Get("Link"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "yes", resp.Header.Get("X-Computed"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := http.StatusOK, finalCode; want != have {
+		t.Errorf("finalizer: want %d, have %d", want, have)
+	}
+}
+
+func TestHeaderWriterAfterEndpoint(t *testing.T) {
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+	handler := httptransport.NewServer(
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			h, _ := httptransport.HeaderWriterFromContext(ctx)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-done
+				h.Set("X-Late", "yes") // races the encoder unless ignored
+			}()
+			return struct{}{}, nil
+		},
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+			close(done)
+			for i := 0; i < 100; i++ {
+				w.Header().Set("X-Encoded", "yes")
+			}
+			return nil
+		},
+	)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	wg.Wait()
+	if want, have := "", rec.Header().Get("X-Late"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 )
@@ -9,18 +10,62 @@ type interceptingWriter struct {
 	http.ResponseWriter
 	code    int
 	written int64
+
+	// body captures up to limit bytes of the response body, if not nil.
+	body  *bytes.Buffer
+	limit int
 }
 
 // WriteHeader may not be explicitly called, so care must be taken to
-// initialize w.code to its default value of http.StatusOK.
+// initialize w.code to its default value of http.StatusOK. Informational
+// responses, like 103 Early Hints, precede the final one, so they aren't
+// recorded.
 func (w *interceptingWriter) WriteHeader(code int) {
-	w.code = code
+	if code >= 200 {
+		w.code = code
+	}
 	w.ResponseWriter.WriteHeader(code)
 }
 
 func (w *interceptingWriter) Write(p []byte) (int, error) {
 	n, err := w.ResponseWriter.Write(p)
 	w.written += int64(n)
+	w.capture(p[:n])
+	return n, err
+}
+
+func (w *interceptingWriter) capture(p []byte) {
+	if w.body != nil {
+		if room := w.limit - w.body.Len(); room > 0 {
+			w.body.Write(p[:min(len(p), room)])
+		}
+	}
+}
+
+// interceptingReaderFrom is the io.ReaderFrom of an interceptingWriter, so
+// that bodies copied with io.Copy are counted and captured as written ones.
+type interceptingReaderFrom struct {
+	w  *interceptingWriter
+	rf io.ReaderFrom
+}
+
+func (r interceptingReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	if r.w.body != nil {
+		src = capturingReader{src, r.w}
+	}
+	n, err := r.rf.ReadFrom(src)
+	r.w.written += n
+	return n, err
+}
+
+type capturingReader struct {
+	io.Reader
+	w *interceptingWriter
+}
+
+func (r capturingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.w.capture(p[:n])
 	return n, err
 }
 
@@ -39,6 +84,9 @@ func (w *interceptingWriter) reimplementInterfaces() http.ResponseWriter {
 		fl, i3 = w.ResponseWriter.(http.Flusher)
 		rf, i4 = w.ResponseWriter.(io.ReaderFrom)
 	)
+	if i4 {
+		rf = interceptingReaderFrom{w, rf}
+	}
 
 	switch {
 	case !i0 && !i1 && !i2 && !i3 && !i4:
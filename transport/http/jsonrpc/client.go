@@ -2,8 +2,10 @@ package jsonrpc
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -30,6 +32,9 @@ type Client[REQ any, RES any] struct {
 	finalizer      httptransport.ClientFinalizerFunc
 	requestID      RequestIDGenerator
 	bufferedStream bool
+	gzip           bool
+	maxRequest     int64
+	maxResponse    int64
 }
 
 type clientRequest struct {
@@ -137,6 +142,27 @@ func BufferedStream[REQ any, RES any](buffered bool) ClientOption[REQ, RES] {
 	return func(c *Client[REQ, RES]) { c.bufferedStream = buffered }
 }
 
+// ClientGzip compresses request bodies with gzip, and asks the server for
+// gzip-compressed responses. The server must accept compressed requests, as
+// Servers in this package do. Compressed responses are always accepted.
+func ClientGzip[REQ any, RES any]() ClientOption[REQ, RES] {
+	return func(c *Client[REQ, RES]) { c.gzip = true }
+}
+
+// ClientMaxRequestSize makes the client fail with ErrMessageTooLarge, instead
+// of sending a request, if the encoded request exceeds n bytes before
+// compression. By default, requests are unbounded.
+func ClientMaxRequestSize[REQ any, RES any](n int64) ClientOption[REQ, RES] {
+	return func(c *Client[REQ, RES]) { c.maxRequest = n }
+}
+
+// ClientMaxResponseSize makes the client fail with ErrMessageTooLarge if the
+// response body exceeds n bytes, after decompression. By default, responses are
+// unbounded.
+func ClientMaxResponseSize[REQ any, RES any](n int64) ClientOption[REQ, RES] {
+	return func(c *Client[REQ, RES]) { c.maxResponse = n }
+}
+
 // Endpoint returns a usable endpoint that invokes the remote endpoint.
 func (c Client[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 	return func(ctx context.Context, request REQ) (response RES, err error) {
@@ -181,6 +207,23 @@ func (c Client[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 		if err != nil {
 			return
 		}
+		if c.maxRequest > 0 && int64(b.Len()) > c.maxRequest {
+			err = ErrMessageTooLarge
+			return
+		}
+		if c.gzip {
+			var zb bytes.Buffer
+			gz := gzip.NewWriter(&zb)
+			if _, err = gz.Write(b.Bytes()); err != nil {
+				return
+			}
+			if err = gz.Close(); err != nil {
+				return
+			}
+			req.Body = ioutil.NopCloser(&zb)
+			req.Header.Set("Content-Encoding", "gzip")
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
 
 		for _, f := range c.before {
 			ctx = f(ctx, req)
@@ -200,8 +243,15 @@ func (c Client[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 		}
 
 		// Decode the body into an object
-		var rpcRes Response
-		err = json.NewDecoder(resp.Body).Decode(&rpcRes)
+		var (
+			rpcRes Response
+			body   io.Reader
+		)
+		body, err = decodeBody(resp.Body, resp.Header.Get("Content-Encoding"), c.maxResponse)
+		if err != nil {
+			return
+		}
+		err = json.NewDecoder(body).Decode(&rpcRes)
 		if err != nil {
 			return
 		}
@@ -0,0 +1,105 @@
+package jsonrpc
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrMessageTooLarge is returned when a request or response body exceeds the
+// size limit configured on a Server or Client. For compressed bodies, the limit
+// applies to both the compressed and the decompressed size. Servers limit their
+// responses with ServerMaxResponseSize instead, answering with an error.
+var ErrMessageTooLarge = errors.New("jsonrpc: message too large")
+
+// limitReader returns a Reader that fails with ErrMessageTooLarge once more
+// than n bytes have been read from r. A limit of zero or less disables it.
+func limitReader(r io.Reader, n int64) io.Reader {
+	if n <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, n: n}
+}
+
+type limitedReader struct {
+	r io.Reader
+	n int64 // bytes remaining
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrMessageTooLarge
+	}
+	// Read one byte past the limit, to tell a body of exactly n bytes from a
+	// larger one.
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrMessageTooLarge
+	}
+	return n, err
+}
+
+// decodeBody returns a Reader for a request or response body with the given
+// Content-Encoding, which must be empty, "identity" or "gzip".
+func decodeBody(body io.Reader, encoding string, limit int64) (io.Reader, error) {
+	body = limitReader(body, limit)
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return limitReader(gz, limit), nil
+	default:
+		return nil, errors.New("unsupported content encoding " + encoding)
+	}
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding header allows a
+// gzip-compressed response.
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if strings.EqualFold(strings.TrimSpace(coding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter compresses everything written to the response. Close must
+// be called once the response is complete.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	return &gzipResponseWriter{ResponseWriter: w, gz: gzip.NewWriter(w)}
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	return w.gz.Write(p)
+}
+
+func (w *gzipResponseWriter) Close() error {
+	return w.gz.Close()
+}
@@ -0,0 +1,131 @@
+package jsonrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/transport/http/jsonrpc"
+)
+
+func newEchoServer(t *testing.T, options ...jsonrpc.ServerOption) *httptest.Server {
+	t.Helper()
+	ecm := jsonrpc.EndpointCodecMap{
+		"echo": jsonrpc.EndpointCodec[string, string]{
+			Endpoint: func(_ context.Context, s string) (string, error) { return s, nil },
+			Decode: func(_ context.Context, params json.RawMessage) (s string, err error) {
+				err = json.Unmarshal(params, &s)
+				return s, err
+			},
+			Encode: func(_ context.Context, s string) (json.RawMessage, error) { return json.Marshal(s) },
+		},
+	}
+	server := httptest.NewServer(jsonrpc.NewServer(ecm, options...))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGzip(t *testing.T) {
+	var requestEncoding string
+	server := newEchoServer(t,
+		jsonrpc.ServerGzip(),
+		jsonrpc.ServerBefore(func(ctx context.Context, r *http.Request) context.Context {
+			requestEncoding = r.Header.Get("Content-Encoding")
+			return ctx
+		}),
+	)
+	tgt, _ := url.Parse(server.URL)
+
+	var responseEncoding string
+	client := jsonrpc.NewClient[string, string](tgt, "echo",
+		jsonrpc.ClientGzip[string, string](),
+		jsonrpc.ClientAfter[string, string](func(ctx context.Context, r *http.Response) context.Context {
+			responseEncoding = r.Header.Get("Content-Encoding")
+			return ctx
+		}),
+	)
+
+	payload := strings.Repeat("batch ", 1000)
+	have, err := client.Endpoint()(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != have {
+		t.Errorf("want %d bytes echoed, have %d", len(payload), len(have))
+	}
+	if want, have := "gzip", requestEncoding; want != have {
+		t.Errorf("request encoding: want %q, have %q", want, have)
+	}
+	if want, have := "gzip", responseEncoding; want != have {
+		t.Errorf("response encoding: want %q, have %q", want, have)
+	}
+}
+
+func TestServerMaxRequestSize(t *testing.T) {
+	server := newEchoServer(t, jsonrpc.ServerMaxRequestSize(100))
+
+	for _, tc := range []struct {
+		params string
+		want   int
+	}{
+		{params: `"short"`, want: 0},
+		{params: `"` + strings.Repeat("x", 100) + `"`, want: jsonrpc.InvalidRequestError},
+	} {
+		resp, err := http.Post(server.URL, "application/json", body(`{"jsonrpc": "2.0", "method": "echo", "id": 1, "params": `+tc.params+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r jsonrpc.Response
+		err = json.NewDecoder(resp.Body).Decode(&r)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		have := 0
+		if r.Error != nil {
+			have = r.Error.Code
+		}
+		if tc.want != have {
+			t.Errorf("%d bytes of params: want error code %d, have %d", len(tc.params), tc.want, have)
+		}
+	}
+}
+
+func TestServerMaxResponseSize(t *testing.T) {
+	server := newEchoServer(t, jsonrpc.ServerGzip(), jsonrpc.ServerMaxResponseSize(500))
+	tgt, _ := url.Parse(server.URL)
+	client := jsonrpc.NewClient[string, string](tgt, "echo", jsonrpc.ClientGzip[string, string]())
+
+	if _, err := client.Endpoint()(context.Background(), "short"); err != nil {
+		t.Errorf("short response: %v", err)
+	}
+	var rpcerr jsonrpc.Error
+	if _, err := client.Endpoint()(context.Background(), strings.Repeat("x", 1000)); !errors.As(err, &rpcerr) || rpcerr.Code != jsonrpc.InternalError {
+		t.Errorf("long response: want error code %d, have %v", jsonrpc.InternalError, err)
+	}
+}
+
+func TestClientMaxSizes(t *testing.T) {
+	server := newEchoServer(t, jsonrpc.ServerGzip())
+	tgt, _ := url.Parse(server.URL)
+	payload := strings.Repeat("x", 1000)
+
+	client := jsonrpc.NewClient[string, string](tgt, "echo", jsonrpc.ClientMaxRequestSize[string, string](500))
+	if _, err := client.Endpoint()(context.Background(), payload); !errors.Is(err, jsonrpc.ErrMessageTooLarge) {
+		t.Errorf("request: want %v, have %v", jsonrpc.ErrMessageTooLarge, err)
+	}
+
+	// The limit applies to the decompressed response.
+	client = jsonrpc.NewClient[string, string](tgt, "echo",
+		jsonrpc.ClientGzip[string, string](),
+		jsonrpc.ClientMaxResponseSize[string, string](500),
+	)
+	if _, err := client.Endpoint()(context.Background(), payload); !errors.Is(err, jsonrpc.ErrMessageTooLarge) {
+		t.Errorf("response: want %v, have %v", jsonrpc.ErrMessageTooLarge, err)
+	}
+}
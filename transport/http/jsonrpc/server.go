@@ -3,10 +3,12 @@ package jsonrpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/a69/kit.go/transport"
 	httptransport "github.com/a69/kit.go/transport/http"
 	"github.com/go-kit/log"
 )
@@ -24,6 +26,9 @@ type Server struct {
 	errorEncoder httptransport.ErrorEncoder
 	finalizer    httptransport.ServerFinalizerFunc
 	logger       log.Logger
+	gzip         bool
+	maxRequest   int64
+	maxResponse  int64
 }
 
 // NewServer constructs a new server, which implements http.Server.
@@ -88,6 +93,27 @@ func ServerFinalizer(f httptransport.ServerFinalizerFunc) ServerOption {
 	return func(s *Server) { s.finalizer = f }
 }
 
+// ServerGzip enables gzip compression of responses to clients that accept it.
+// Gzip-compressed requests are always accepted.
+func ServerGzip() ServerOption {
+	return func(s *Server) { s.gzip = true }
+}
+
+// ServerMaxRequestSize limits request bodies to n bytes, after decompression.
+// Larger requests are rejected with an InvalidRequestError. By default, request
+// bodies are unbounded.
+func ServerMaxRequestSize(n int64) ServerOption {
+	return func(s *Server) { s.maxRequest = n }
+}
+
+// ServerMaxResponseSize limits the encoded results of responses to n bytes,
+// before compression. Larger results are replaced with an InternalError, so
+// that a runaway endpoint can't flood clients. By default, responses are
+// unbounded.
+func ServerMaxResponseSize(n int64) ServerOption {
+	return func(s *Server) { s.maxResponse = n }
+}
+
 // ServeHTTP implements http.Handler.
 func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -104,15 +130,27 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w = iw
 	}
 
+	if s.gzip && acceptsGzip(r) {
+		gw := newGzipResponseWriter(w)
+		defer gw.Close()
+		w = gw
+	}
+
 	for _, f := range s.before {
 		ctx = f(ctx, r)
 	}
 
 	// Decode the body into an  object
 	var req Request
-	err := json.NewDecoder(r.Body).Decode(&req)
+	body, err := decodeBody(r.Body, r.Header.Get("Content-Encoding"), s.maxRequest)
+	if err == nil {
+		err = json.NewDecoder(body).Decode(&req)
+	}
 	if err != nil {
-		rpcerr := parseError("JSON could not be decoded: " + err.Error())
+		var rpcerr error = parseError("JSON could not be decoded: " + err.Error())
+		if errors.Is(err, ErrMessageTooLarge) {
+			rpcerr = invalidRequestError(fmt.Sprintf("Request exceeds %d bytes.", s.maxRequest))
+		}
 		s.logger.Log("err", rpcerr)
 		s.errorEncoder(ctx, rpcerr, w)
 		return
@@ -140,6 +178,9 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		JSONRPC: Version,
 	}
 	res.Result, err = ecm.Handle(ctx, s.after, w, req.Params)
+	if err == nil && s.maxResponse > 0 && int64(len(res.Result)) > s.maxResponse {
+		err = internalError(fmt.Sprintf("Response exceeds %d bytes.", s.maxResponse))
+	}
 	if err != nil {
 		s.logger.Log("err", err)
 		s.errorEncoder(ctx, err, w)
@@ -156,6 +197,8 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // If the error implements ErrorCoder, the provided code will be set on the
 // response error.
 // If the error implements Headerer, the given headers will be set.
+// Errors containing transport.DecodeErrors are written with an
+// InvalidParamsError code, and the invalid fields as data.
 func DefaultErrorEncoder(ctx context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", ContentType)
 	if headerer, ok := err.(httptransport.Headerer); ok {
@@ -168,6 +211,9 @@ func DefaultErrorEncoder(ctx context.Context, err error, w http.ResponseWriter)
 		Code:    InternalError,
 		Message: err.Error(),
 	}
+	if fields := transport.DecodeErrors(err); fields != nil {
+		e.Code, e.Message, e.Data = InvalidParamsError, errorMessage[InvalidParamsError], fields
+	}
 	if sc, ok := err.(ErrorCoder); ok {
 		e.Code = sc.ErrorCode()
 	}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport"
 	"github.com/a69/kit.go/transport/http/jsonrpc"
 )
 
@@ -176,6 +177,35 @@ func TestServerErrorEncoder(t *testing.T) {
 	}
 }
 
+func TestServerDecodeError(t *testing.T) {
+	ecm := jsonrpc.EndpointCodecMap{
+		"add": jsonrpc.EndpointCodec[struct{}, struct{}]{
+			Endpoint: func(context.Context, struct{}) (struct{}, error) { return struct{}{}, nil },
+			Decode: func(context.Context, json.RawMessage) (struct{}, error) {
+				return struct{}{}, &transport.DecodeError{Field: "0", Reason: "must be a string"}
+			},
+			Encode: nopEncoder[struct{}],
+		},
+	}
+	handler := jsonrpc.NewServer(ecm)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	resp, _ := http.Post(server.URL, "application/json", addBody())
+	buf, _ := ioutil.ReadAll(resp.Body)
+	expectErrorCode(t, jsonrpc.InvalidParamsError, buf)
+	var r struct {
+		Error struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(buf, &r); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `[{"field":"0","reason":"must be a string"}]`, string(r.Error.Data); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
 func TestCanRejectNonPostRequest(t *testing.T) {
 	ecm := jsonrpc.EndpointCodecMap{}
 	handler := jsonrpc.NewServer(ecm)
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// HandlerMetrics are the metrics recorded by MetricsHandler for servers, and
+// by MetricsMiddleware for clients. Any of them may be nil.
+type HandlerMetrics struct {
+	// Requests is incremented for every request, with a "code" label set to
+	// the response status code.
+	Requests metrics.Counter
+
+	// InFlight is the number of requests being handled.
+	InFlight metrics.Gauge
+
+	// Duration observes the duration of every request, in seconds, with a
+	// "code" label set to the response status code.
+	Duration metrics.Histogram
+
+	// RequestSize observes the number of request body bytes read by the
+	// handler, or sent by the client.
+	RequestSize metrics.Histogram
+
+	// ResponseSize observes the number of response body bytes written by the
+	// handler, or read by the client.
+	ResponseSize metrics.Histogram
+}
+
+// MetricsHandler wraps next, recording the metrics for every request at the
+// transport level. Unlike endpoint middlewares, it sees requests that fail
+// before reaching the endpoint, e.g. because they couldn't be decoded, and
+// handlers that panic, which are recorded with status code 500 before the
+// panic is propagated.
+func MetricsHandler(m HandlerMetrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.InFlight != nil {
+			m.InFlight.Add(1)
+			defer m.InFlight.Add(-1)
+		}
+
+		var (
+			iw    = &interceptingWriter{ResponseWriter: w, code: http.StatusOK}
+			body  = &countingReader{ReadCloser: r.Body}
+			begin = time.Now()
+		)
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+
+		panicked := true
+		defer func() {
+			code := iw.code
+			if panicked {
+				code = http.StatusInternalServerError
+			}
+			m.record(strconv.Itoa(code), time.Since(begin))
+			if m.RequestSize != nil {
+				m.RequestSize.Observe(float64(body.read))
+			}
+			if m.ResponseSize != nil {
+				m.ResponseSize.Observe(float64(iw.written))
+			}
+		}()
+		next.ServeHTTP(iw.reimplementInterfaces(), r)
+		panicked = false
+	})
+}
+
+func (m HandlerMetrics) record(code string, took time.Duration) {
+	if m.Requests != nil {
+		m.Requests.With("code", code).Add(1)
+	}
+	if m.Duration != nil {
+		m.Duration.With("code", code).Observe(took.Seconds())
+	}
+}
+
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// MetricsMiddleware returns a ClientMiddleware recording m for every request
+// sent, like MetricsHandler does for the requests served. Requests failing
+// without a response are recorded with the "code" label set to "error".
+// Response sizes are recorded when the response body is closed, which the
+// Client does once the response is decoded, unless it's a BufferedStream.
+func MetricsMiddleware(m HandlerMetrics) ClientMiddleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if m.InFlight != nil {
+				m.InFlight.Add(1)
+				defer m.InFlight.Add(-1)
+			}
+
+			var (
+				body  = &countingReader{ReadCloser: req.Body}
+				begin = time.Now()
+			)
+			if req.Body != nil && req.Body != http.NoBody {
+				req.Body = body
+			}
+			resp, err := next.Do(req)
+			code := "error"
+			if err == nil {
+				code = strconv.Itoa(resp.StatusCode)
+			}
+			m.record(code, time.Since(begin))
+			if m.RequestSize != nil {
+				m.RequestSize.Observe(float64(body.read))
+			}
+			if err != nil || m.ResponseSize == nil || resp.Body == nil {
+				return resp, err
+			}
+			resp.Body = &observingReader{countingReader: countingReader{ReadCloser: resp.Body}, h: m.ResponseSize}
+			return resp, nil
+		})
+	}
+}
+
+// observingReader observes the number of bytes read in h when closed.
+type observingReader struct {
+	countingReader
+	h    metrics.Histogram
+	once sync.Once
+}
+
+func (r *observingReader) Close() error {
+	r.once.Do(func() { r.h.Observe(float64(r.read)) })
+	return r.ReadCloser.Close()
+}
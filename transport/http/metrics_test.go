@@ -0,0 +1,151 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	var (
+		requests = metricstest.NewCounter("requests")
+		inFlight = metricstest.NewGauge("in_flight")
+		duration = metricstest.NewHistogram("duration")
+		reqSize  = metricstest.NewHistogram("request_size")
+		respSize = metricstest.NewHistogram("response_size")
+		m        = httptransport.HandlerMetrics{
+			Requests:     requests,
+			InFlight:     inFlight,
+			Duration:     duration,
+			RequestSize:  reqSize,
+			ResponseSize: respSize,
+		}
+	)
+
+	h := httptransport.MetricsHandler(m, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, have := 1.0, inFlight.Value(); want != have {
+			t.Errorf("in flight: want %v, have %v", want, have)
+		}
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/bad" {
+			http.Error(w, "bad", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("abc")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/bad", nil))
+
+	if want, have := 1.0, requests.ValueWith("code", "200"); want != have {
+		t.Errorf("200: want %v, have %v", want, have)
+	}
+	if want, have := 1.0, requests.ValueWith("code", "400"); want != have {
+		t.Errorf("400: want %v, have %v", want, have)
+	}
+	if want, have := 1, len(duration.ObservationsWith("code", "400")); want != have {
+		t.Errorf("duration: want %d observations, have %d", want, have)
+	}
+	if want, have := 0.0, inFlight.Value(); want != have {
+		t.Errorf("in flight: want %v, have %v", want, have)
+	}
+	if want, have := []float64{3, 0}, reqSize.Observations(); want[0] != have[0] || want[1] != have[1] {
+		t.Errorf("request size: want %v, have %v", want, have)
+	}
+	if want, have := 5.0, respSize.Observations()[0]; want != have {
+		t.Errorf("response size: want %v, have %v", want, have)
+	}
+}
+
+func TestMetricsHandlerPanic(t *testing.T) {
+	requests := metricstest.NewCounter("requests")
+	h := httptransport.MetricsHandler(httptransport.HandlerMetrics{Requests: requests}, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("want panic to be propagated, have %v", r)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if want, have := 1.0, requests.ValueWith("code", "500"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	var (
+		requests   = metricstest.NewCounter("requests")
+		serverReq  = metricstest.NewHistogram("server_request_size")
+		serverResp = metricstest.NewHistogram("server_response_size")
+		clientReq  = metricstest.NewHistogram("client_request_size")
+		clientResp = metricstest.NewHistogram("client_response_size")
+	)
+	server := httptest.NewServer(httptransport.MetricsHandler(httptransport.HandlerMetrics{
+		RequestSize:  serverReq,
+		ResponseSize: serverResp,
+	}, httptransport.NewServer(
+		func(_ context.Context, s string) (string, error) { return strings.ToUpper(s), nil },
+		func(_ context.Context, r *http.Request) (string, error) {
+			b, err := io.ReadAll(r.Body)
+			return string(b), err
+		},
+		func(_ context.Context, w http.ResponseWriter, s string) error {
+			_, err := io.WriteString(w, s+"!")
+			return err
+		},
+	)))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client := httptransport.NewClient(http.MethodPost, u,
+		func(_ context.Context, r *http.Request, s *string) error {
+			r.Body = io.NopCloser(strings.NewReader(*s))
+			return nil
+		},
+		func(_ context.Context, resp *http.Response) (string, error) {
+			b, err := io.ReadAll(resp.Body)
+			return string(b), err
+		},
+		httptransport.ClientMiddlewares[string, string](httptransport.MetricsMiddleware(httptransport.HandlerMetrics{
+			Requests:     requests,
+			RequestSize:  clientReq,
+			ResponseSize: clientResp,
+		})),
+	)
+	response, err := client.Endpoint()(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "HELLO!", response; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1.0, requests.ValueWith("code", "200"); want != have {
+		t.Errorf("requests: want %v, have %v", want, have)
+	}
+	for _, tc := range []struct {
+		name string
+		h    *metricstest.Histogram
+		want float64
+	}{
+		{"server request", serverReq, 5},
+		{"server response", serverResp, 6},
+		{"client request", clientReq, 5},
+		{"client response", clientResp, 6},
+	} {
+		if want, have := tc.want, tc.h.Sum(); want != have {
+			t.Errorf("%s: want %v, have %v", tc.name, want, have)
+		}
+	}
+}
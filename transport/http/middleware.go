@@ -0,0 +1,60 @@
+package http
+
+import "net/http"
+
+// ServerMiddleware wraps the decoding, endpoint and encoding of each request
+// in standard net/http middlewares, the first being the outermost, so that
+// the ecosystem's middlewares, e.g. for authentication, compression or panic
+// recovery, can be reused by a server. Context values set by the middlewares
+// are visible to the ServerBefore functions and the endpoint, as are the
+// request headers they add. Response headers set with ServerHeaders are
+// written before the middlewares run.
+//
+// The middlewares run within the ServerFinalizer functions, which observe the
+// responses written by middlewares rejecting a request, e.g. with 401
+// Unauthorized, but not the context values the middlewares set.
+func ServerMiddleware[REQ any, RES any](middleware ...func(http.Handler) http.Handler) ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.middleware = append(s.middleware, middleware...) }
+}
+
+// Chain returns h wrapped in the middlewares, the first being the outermost,
+// e.g. to compose a Server with standard net/http middlewares applying to
+// the whole handler, finalizers included.
+func Chain(h http.Handler, middleware ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// HTTPClientFunc is an adapter to allow the use of ordinary functions as
+// HTTPClients.
+type HTTPClientFunc func(*http.Request) (*http.Response, error)
+
+// Do implements HTTPClient.
+func (f HTTPClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// ClientMiddleware is a chainable behavior modifier for HTTPClients, e.g. to
+// sign requests, retry them or record metrics, applying to each HTTP round
+// trip of a client rather than to its endpoint.
+type ClientMiddleware func(next HTTPClient) HTTPClient
+
+// ClientMiddlewares wraps the HTTP client of a client, as set with SetClient,
+// in the middlewares, the first being the outermost. They see the requests
+// after the ClientBefore functions, and the responses before the ClientAfter
+// functions. Responses served by a ClientCache don't reach them, but its
+// revalidation requests do.
+func ClientMiddlewares[REQ any, RES any](middleware ...ClientMiddleware) ClientOption[REQ, RES] {
+	return func(c *Client[REQ, RES]) { c.middleware = append(c.middleware, middleware...) }
+}
+
+// ChainClient returns client wrapped in the middlewares, the first being the
+// outermost.
+func ChainClient(client HTTPClient, middleware ...ClientMiddleware) HTTPClient {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		client = middleware[i](client)
+	}
+	return client
+}
@@ -0,0 +1,142 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+type userKey struct{}
+
+// authenticate is a typical net/http middleware, rejecting requests without
+// a user, and putting the user in the context of the others.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Header.Get("X-User")
+		if user == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		r.Header.Set("X-Authenticated", "true")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+func tag(name string, order *[]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*order = append(*order, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	var (
+		codes    = make(chan int, 1)
+		inBefore string
+	)
+	handler := httptransport.NewServer(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			return ctx.Value(userKey{}), nil
+		},
+		func(ctx context.Context, r *http.Request) (interface{}, error) { return nil, nil },
+		func(_ context.Context, w http.ResponseWriter, response interface{}) error {
+			_, err := w.Write([]byte(response.(string)))
+			return err
+		},
+		httptransport.ServerMiddleware[interface{}, interface{}](authenticate),
+		httptransport.ServerBefore[interface{}, interface{}](func(ctx context.Context, r *http.Request) context.Context {
+			inBefore = r.Header.Get("X-Authenticated")
+			return ctx
+		}),
+		httptransport.ServerFinalizer[interface{}, interface{}](func(_ context.Context, code int, _ *http.Request) {
+			codes <- code
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-User", "alice")
+	handler.ServeHTTP(rec, req)
+	if want, have := "alice", rec.Body.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "true", inBefore; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := http.StatusOK, <-codes; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if want, have := http.StatusUnauthorized, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := http.StatusUnauthorized, <-codes; want != have {
+		t.Errorf("finalizer: want %d, have %d", want, have)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	h := httptransport.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), tag("first", &order), tag("second", &order))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if want, have := "first second handler", strings.Join(order, " "); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestClientMiddlewares(t *testing.T) {
+	var order []string
+	sign := func(next httptransport.HTTPClient) httptransport.HTTPClient {
+		return httptransport.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "sign")
+			req.Header.Set("X-Signature", "signed")
+			return next.Do(req)
+		})
+	}
+	count := func(next httptransport.HTTPClient) httptransport.HTTPClient {
+		return httptransport.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "count")
+			return next.Do(req)
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Signature")))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	e := httptransport.NewClient(
+		"GET", u,
+		func(context.Context, *http.Request, *struct{}) error { return nil },
+		func(_ context.Context, resp *http.Response) (string, error) {
+			b, err := io.ReadAll(resp.Body)
+			return string(b), err
+		},
+		httptransport.ClientMiddlewares[struct{}, string](sign, count),
+		httptransport.SetClient[struct{}, string](server.Client()),
+	).Endpoint()
+
+	response, err := e(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "signed", response; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "sign count", strings.Join(order, " "); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,46 @@
+// Package mux registers servers on gorilla/mux routers, applying options
+// shared by all of them, e.g. for tracing, logging, metrics or
+// authentication, and capturing their route template in the context.
+package mux
+
+import (
+	"github.com/gorilla/mux"
+
+	"github.com/a69/kit.go/endpoint"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+// Router registers servers on a gorilla/mux router.
+type Router struct {
+	router  *mux.Router
+	options []httptransport.ServerOption[any, any]
+}
+
+// NewRouter returns a Router registering servers on router, with the shared
+// options, see httptransport.ServerShared.
+func NewRouter(router *mux.Router, options ...httptransport.ServerOption[any, any]) *Router {
+	return &Router{router: router, options: options}
+}
+
+// Handle registers a server of the endpoint on the method and path template
+// of the router, and returns its route, e.g. to add other matchers. The
+// server is constructed with the shared options of the router, followed by
+// the given options. The full path template of the route, including the
+// prefix of subrouters, is available to the server's request functions and
+// finalizers with httptransport.RouteFromContext.
+func Handle[REQ any, RES any](
+	r *Router,
+	method, path string,
+	e endpoint.Endpoint[REQ, RES],
+	dec httptransport.DecodeRequestFunc[REQ],
+	enc httptransport.EncodeResponseFunc[RES],
+	options ...httptransport.ServerOption[REQ, RES],
+) *mux.Route {
+	route := r.router.Methods(method).Path(path)
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		template = path
+	}
+	options = append([]httptransport.ServerOption[REQ, RES]{httptransport.ServerShared[REQ, RES](r.options...)}, options...)
+	return route.Handler(httptransport.RouteHandler(template, httptransport.NewServer(e, dec, enc, options...)))
+}
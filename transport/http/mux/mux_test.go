@@ -0,0 +1,48 @@
+package mux_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+	kitmux "github.com/a69/kit.go/transport/http/mux"
+)
+
+func TestHandle(t *testing.T) {
+	var routes []string
+	r := mux.NewRouter()
+	router := kitmux.NewRouter(r.PathPrefix("/api").Subrouter(),
+		httptransport.ServerBefore[any, any](func(ctx context.Context, _ *http.Request) context.Context {
+			route, _ := httptransport.RouteFromContext(ctx)
+			routes = append(routes, route)
+			return ctx
+		}),
+	)
+	kitmux.Handle(router, "GET", "/users/{id}",
+		func(_ context.Context, id string) (string, error) { return id, nil },
+		func(_ context.Context, r *http.Request) (string, error) { return mux.Vars(r)["id"], nil },
+		func(_ context.Context, w http.ResponseWriter, id string) error {
+			_, err := w.Write([]byte(id))
+			return err
+		},
+	)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users/42", nil))
+	if want, have := "42", rec.Body.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := []string{"/api/users/{id}"}, routes; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", "/api/users/42", nil))
+	if want, have := http.StatusMethodNotAllowed, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
@@ -130,4 +130,9 @@ const (
 	// ContextKeyResponseSize is populated in the context whenever a
 	// ServerFinalizerFunc is specified. Its value is of type int64.
 	ContextKeyResponseSize
+
+	// contextKeyResponseBody is populated in the context of the finalizers
+	// with the beginning of the response body, as captured for ServerAudit.
+	// Its value is of type *bytes.Buffer.
+	contextKeyResponseBody
 )
@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/a69/kit.go/endpoint"
 	httptransport "github.com/a69/kit.go/transport/http"
 )
 
@@ -28,3 +30,32 @@ func TestSetContentType(t *testing.T) {
 		t.Errorf("want %q, have %q", want, have)
 	}
 }
+
+func TestBudgetHeaderRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	httptransport.SetBudgetHeader(ctx, r)
+	if r.Header.Get(httptransport.BudgetHeader) == "" {
+		t.Fatal("budget header not set")
+	}
+
+	deadline, ok := endpoint.Budget(httptransport.PopulateBudget(context.Background(), r))
+	if !ok {
+		t.Fatal("no budget in server context")
+	}
+	if remaining := time.Until(deadline); remaining < 59*time.Second || remaining > time.Minute {
+		t.Errorf("unexpected remaining budget %v", remaining)
+	}
+}
+
+func TestPopulateBudgetIgnoresInvalidValues(t *testing.T) {
+	for _, value := range []string{"", "soon", "-1", "9223372036854775807", "9223372036855"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(httptransport.BudgetHeader, value)
+		if deadline, ok := endpoint.Budget(httptransport.PopulateBudget(context.Background(), r)); ok {
+			t.Errorf("%q: want no budget, have %v", value, deadline)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeKey struct{}
+
+// WithRoute returns a context carrying the template of the route a request
+// matched, e.g. "/profiles/{id}".
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext returns the route template set with WithRoute, if any.
+func RouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeKey{}).(string)
+	return route, ok
+}
+
+// RouteHandler returns a handler calling h with the route template in the
+// context of the request, and as its Pattern, so that it's seen by the
+// ServerBefore and ServerFinalizer functions of a Server, e.g. to name spans
+// or label metrics by route rather than by path. See the mux and chi
+// subpackages, which do so for the servers they register.
+func RouteHandler(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(WithRoute(r.Context(), route))
+		r.Pattern = route
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ServerShared applies options declared for servers of any request and
+// response, e.g. those shared by all the servers of a router, to a server of
+// REQ and RES. The options are applied once, to a Server[any, any], whose
+// request and response functions, error encoder and handler, headers, phases,
+// middlewares and media types are then added to every server.
+//
+//	shared := []httptransport.ServerOption[any, any]{
+//		opencensus.HTTPServerTrace[any, any](),
+//		httptransport.ServerErrorHandler[any, any](transport.NewLogErrorHandler(logger)),
+//	}
+//	httptransport.NewServer(e, dec, enc, httptransport.ServerShared[Request, Response](shared...))
+func ServerShared[REQ any, RES any](options ...ServerOption[any, any]) ServerOption[REQ, RES] {
+	var shared Server[any, any]
+	for _, option := range options {
+		option(&shared)
+	}
+	// Every field of Server set by an option must be carried over here.
+	return func(s *Server[REQ, RES]) {
+		s.prepare = append(s.prepare, shared.prepare...)
+		s.before = append(s.before, shared.before...)
+		s.after = append(s.after, shared.after...)
+		s.finalizer = append(s.finalizer, shared.finalizer...)
+		s.phases = append(s.phases, shared.phases...)
+		s.middleware = append(s.middleware, shared.middleware...)
+		s.consumes = append(s.consumes, shared.consumes...)
+		s.produces = append(s.produces, shared.produces...)
+		if shared.errorEncoder != nil {
+			s.errorEncoder = shared.errorEncoder
+		}
+		if shared.errorHandler != nil {
+			s.errorHandler = shared.errorHandler
+		}
+		if len(shared.headers) > 0 {
+			ServerHeaders[REQ, RES](shared.headers)(s)
+		}
+		if shared.recover {
+			s.recover = true
+		}
+		if shared.captureLimit > s.captureLimit {
+			s.captureLimit = shared.captureLimit
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestRouteHandler(t *testing.T) {
+	var inBefore, inFinalizer, pattern string
+	server := httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, struct{}) error { return nil },
+		httptransport.ServerBefore[struct{}, struct{}](func(ctx context.Context, r *http.Request) context.Context {
+			inBefore, _ = httptransport.RouteFromContext(ctx)
+			pattern = r.Pattern
+			return ctx
+		}),
+		httptransport.ServerFinalizer[struct{}, struct{}](func(ctx context.Context, _ int, _ *http.Request) {
+			inFinalizer, _ = httptransport.RouteFromContext(ctx)
+		}),
+	)
+
+	h := httptransport.RouteHandler("/users/{id}", server)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	for _, have := range []string{inBefore, inFinalizer, pattern} {
+		if want := "/users/{id}"; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+}
+
+func TestServerShared(t *testing.T) {
+	var before []string
+	shared := httptransport.ServerShared[struct{}, struct{}](
+		httptransport.ServerBefore[any, any](func(ctx context.Context, _ *http.Request) context.Context {
+			before = append(before, "shared")
+			return ctx
+		}),
+		httptransport.ServerHeaders[any, any](http.Header{"X-Shared": []string{"yes"}}),
+		httptransport.ServerErrorEncoder[any, any](func(_ context.Context, err error, w http.ResponseWriter) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	)
+	server := httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, errors.New("dang") },
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, struct{}) error { return nil },
+		shared,
+		httptransport.ServerBefore[struct{}, struct{}](func(ctx context.Context, _ *http.Request) context.Context {
+			before = append(before, "own")
+			return ctx
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if want, have := http.StatusTeapot, rec.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := "yes", rec.Header().Get("X-Shared"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := []string{"shared", "own"}, before; len(have) != 2 || want[0] != have[0] || want[1] != have[1] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
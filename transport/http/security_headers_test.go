@@ -0,0 +1,60 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	h := httptransport.SecurityHeaders{
+		HSTSMaxAge:     time.Hour,
+		HSTSPreload:    true,
+		NoSniff:        true,
+		ReferrerPolicy: "same-origin",
+	}.Header()
+
+	for key, want := range map[string]string{
+		"Strict-Transport-Security": "max-age=3600; preload",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "same-origin",
+		"X-Frame-Options":           "",
+		"Content-Security-Policy":   "",
+	} {
+		if have := h.Get(key); want != have {
+			t.Errorf("%s: want %q, have %q", key, want, have)
+		}
+	}
+}
+
+func TestServerSecurityHeaders(t *testing.T) {
+	for _, failure := range []error{nil, errors.New("dang")} {
+		handler := httptransport.NewServer(
+			func(context.Context, struct{}) (struct{}, error) { return struct{}{}, failure },
+			func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+			func(_ context.Context, w http.ResponseWriter, _ struct{}) error {
+				w.Header().Set("Content-Security-Policy", "default-src 'self'")
+				return nil
+			},
+			httptransport.ServerSecurityHeaders[struct{}, struct{}](httptransport.DefaultSecurityHeaders()),
+		)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if want, have := "max-age=63072000; includeSubDomains", rec.Header().Get("Strict-Transport-Security"); want != have {
+			t.Errorf("err=%v: want %q, have %q", failure, want, have)
+		}
+		csp := "default-src 'none'; frame-ancestors 'none'"
+		if failure == nil {
+			csp = "default-src 'self'" // overridden by the encoder
+		}
+		if want, have := csp, rec.Header().Get("Content-Security-Policy"); want != have {
+			t.Errorf("err=%v: want %q, have %q", failure, want, have)
+		}
+	}
+}
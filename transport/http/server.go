@@ -1,9 +1,12 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/a69/kit.go/endpoint"
 	"github.com/a69/kit.go/transport"
@@ -15,11 +18,19 @@ type Server[REQ any, RES any] struct {
 	e            endpoint.Endpoint[REQ, RES]
 	dec          DecodeRequestFunc[REQ]
 	enc          EncodeResponseFunc[RES]
+	prepare      []RequestFunc
 	before       []RequestFunc
 	after        []ServerResponseFunc
 	errorEncoder ErrorEncoder
 	finalizer    []ServerFinalizerFunc
 	errorHandler transport.ErrorHandler
+	headers      http.Header
+	phases       []transport.PhaseFunc
+	middleware   []func(http.Handler) http.Handler
+	consumes     []string
+	produces     []string
+	captureLimit int
+	recover      bool
 }
 
 // NewServer constructs a new server, which implements http.Handler and wraps
@@ -91,15 +102,50 @@ func ServerFinalizer[REQ any, RES any](f ...ServerFinalizerFunc) ServerOption[RE
 	return func(s *Server[REQ, RES]) { s.finalizer = append(s.finalizer, f...) }
 }
 
+// ServerPhase functions are called after the request is decoded, after the
+// endpoint returns, and after the response is encoded, with the time each of
+// these phases took. See the tracing packages for PhaseFuncs that annotate
+// the request's span. By default, no phase functions are registered.
+func ServerPhase[REQ any, RES any](f ...transport.PhaseFunc) ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.phases = append(s.phases, f...) }
+}
+
+// ServerRecover makes the server recover the panics raised while decoding
+// requests, running the endpoint, or encoding responses, and fail the
+// requests with a *transport.PanicError instead, passed to the error
+// handler and the error encoder. DefaultErrorEncoder answers with a generic
+// 500 Internal Server Error, which doesn't disclose the panic to clients;
+// custom error encoders should do the same. Without it, panics reach
+// net/http, which logs them and resets the connection.
+//
+// Panics with http.ErrAbortHandler are raised again, as they're meant to
+// abort the response. Panics raised by request and response functions,
+// middlewares, and error encoders aren't recovered.
+func ServerRecover[REQ any, RES any]() ServerOption[REQ, RES] {
+	return func(s *Server[REQ, RES]) { s.recover = true }
+}
+
 // ServeHTTP implements http.Handler.
 func (s Server[_, _]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	// prepare functions run before the middlewares, so that the state they
+	// set up reaches the finalizers even if a middleware rejects the request.
+	for _, f := range s.prepare {
+		ctx = f(ctx, r)
+	}
+
 	if len(s.finalizer) > 0 {
-		iw := &interceptingWriter{w, http.StatusOK, 0}
+		iw := &interceptingWriter{ResponseWriter: w, code: http.StatusOK}
+		if s.captureLimit > 0 {
+			iw.body, iw.limit = &bytes.Buffer{}, s.captureLimit
+		}
 		defer func() {
 			ctx = context.WithValue(ctx, ContextKeyResponseHeaders, iw.Header())
 			ctx = context.WithValue(ctx, ContextKeyResponseSize, iw.written)
+			if iw.body != nil {
+				ctx = context.WithValue(ctx, contextKeyResponseBody, iw.body)
+			}
 			for _, f := range s.finalizer {
 				f(ctx, iw.code, r)
 			}
@@ -107,33 +153,109 @@ func (s Server[_, _]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w = iw.reimplementInterfaces()
 	}
 
+	for k, values := range s.headers {
+		w.Header()[k] = append([]string(nil), values...)
+	}
+
+	if len(s.middleware) == 0 {
+		ctx = s.serve(ctx, w, r)
+		return
+	}
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = s.serve(r.Context(), w, r)
+	}), s.middleware...)
+	h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// serve decodes the request, invokes the endpoint and encodes the response,
+// returning the context as left by the request and response functions.
+func (s Server[_, _]) serve(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
+	// The HeaderWriter is closed before the server changes the headers
+	// itself, so that goroutines of the endpoint can't race it.
+	ctx, hw := contextWithHeaderWriter(ctx, w)
+
 	for _, f := range s.before {
 		ctx = f(ctx, r)
 	}
 
-	request, err := s.dec(ctx, r)
+	if err := checkMediaTypes(r, s.consumes, s.produces); err != nil {
+		hw.close()
+		s.errorHandler.Handle(ctx, err)
+		s.errorEncoder(ctx, err, w)
+		return ctx
+	}
+
+	start := time.Now()
+	request, err := s.decode(ctx, r)
+	s.phase(ctx, transport.PhaseDecode, start, err)
 	if err != nil {
+		hw.close()
 		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
-		return
+		return ctx
 	}
 
-	response, err := s.e(ctx, request)
+	start = time.Now()
+	response, err := s.endpoint(ctx, request)
+	hw.close()
+	s.phase(ctx, transport.PhaseEndpoint, start, err)
 	if err != nil {
 		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
-		return
+		return ctx
 	}
 
 	for _, f := range s.after {
 		ctx = f(ctx, w)
 	}
 
-	if err := s.enc(ctx, w, response); err != nil {
+	start = time.Now()
+	err = s.encode(ctx, w, response)
+	s.phase(ctx, transport.PhaseEncode, start, err)
+	if err != nil {
 		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
+	}
+	return ctx
+}
+
+// decode, endpoint and encode run the phases of serve, turning the panics
+// they raise into errors if the server recovers them.
+func (s Server[REQ, _]) decode(ctx context.Context, r *http.Request) (request REQ, err error) {
+	defer s.recoverPanic(transport.PhaseDecode, &err)
+	return s.dec(ctx, r)
+}
+
+func (s Server[REQ, RES]) endpoint(ctx context.Context, request REQ) (response RES, err error) {
+	defer s.recoverPanic(transport.PhaseEndpoint, &err)
+	return s.e(ctx, request)
+}
+
+func (s Server[_, RES]) encode(ctx context.Context, w http.ResponseWriter, response RES) (err error) {
+	defer s.recoverPanic(transport.PhaseEncode, &err)
+	return s.enc(ctx, w, response)
+}
+
+func (s Server[_, _]) recoverPanic(phase transport.Phase, err *error) {
+	if !s.recover {
 		return
 	}
+	if v := recover(); v != nil {
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+		*err = transport.NewPanicError(phase, v)
+	}
+}
+
+func (s Server[_, _]) phase(ctx context.Context, phase transport.Phase, start time.Time, err error) {
+	if len(s.phases) == 0 {
+		return
+	}
+	took := time.Since(start)
+	for _, f := range s.phases {
+		f(ctx, phase, start, took, err)
+	}
 }
 
 // ErrorEncoder is responsible for encoding an error to the ResponseWriter.
@@ -187,13 +309,32 @@ func EncodeJSONResponse[RES any](_ context.Context, w http.ResponseWriter, respo
 // the marshaling succeeds, a content type of application/json and the JSON
 // encoded form of the error will be used. If the error implements StatusCoder,
 // the provided StatusCode will be used instead of 500.
+//
+// Errors containing transport.DecodeErrors are written with a status code of
+// 400, and a JSON body listing the invalid fields, e.g.
+//
+//	{"error": "invalid request", "fields": [{"field": "name", "reason": "required"}]}
+//
+// Recovered panics, i.e. *transport.PanicError, are written with a status
+// code of 500 and a generic body, as their values may hold secrets.
 func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	var pe *transport.PanicError
+	if errors.As(err, &pe) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
 	contentType, body := "text/plain; charset=utf-8", []byte(err.Error())
 	if marshaler, ok := err.(json.Marshaler); ok {
 		if jsonBody, marshalErr := marshaler.MarshalJSON(); marshalErr == nil {
 			contentType, body = "application/json; charset=utf-8", jsonBody
 		}
 	}
+	code := http.StatusInternalServerError
+	if fields := transport.DecodeErrors(err); fields != nil {
+		contentType, body, code = "application/json; charset=utf-8", DecodeErrorBody(fields), http.StatusBadRequest
+	}
 	w.Header().Set("Content-Type", contentType)
 	if headerer, ok := err.(Headerer); ok {
 		for k, values := range headerer.Headers() {
@@ -202,7 +343,6 @@ func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 			}
 		}
 	}
-	code := http.StatusInternalServerError
 	if sc, ok := err.(StatusCoder); ok {
 		code = sc.StatusCode()
 	}
@@ -210,6 +350,16 @@ func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 	w.Write(body)
 }
 
+// DecodeErrorBody returns the JSON body DefaultErrorEncoder writes for decode
+// errors, e.g. for custom error encoders to render them alike.
+func DecodeErrorBody(fields []*transport.DecodeError) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":  "invalid request",
+		"fields": fields,
+	})
+	return body
+}
+
 // StatusCoder is checked by DefaultErrorEncoder. If an error value implements
 // StatusCoder, the StatusCode will be used when encoding the error. By default,
 // StatusInternalServerError (500) is used.
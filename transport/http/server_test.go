@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport"
 	httptransport "github.com/a69/kit.go/transport/http"
 )
 
@@ -78,6 +79,93 @@ func TestServerErrorEncoder(t *testing.T) {
 	}
 }
 
+func TestServerRecover(t *testing.T) {
+	var (
+		ok    = func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil }
+		boom  = func(context.Context, interface{}) (interface{}, error) { panic("boom") }
+		dec   = func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil }
+		enc   = func(context.Context, http.ResponseWriter, interface{}) error { return nil }
+		cause = errors.New("dang")
+	)
+	for _, tc := range []struct {
+		name  string
+		e     endpoint.Endpoint[interface{}, interface{}]
+		dec   httptransport.DecodeRequestFunc[interface{}]
+		enc   httptransport.EncodeResponseFunc[interface{}]
+		phase transport.Phase
+	}{
+		{"decode", ok, func(context.Context, *http.Request) (interface{}, error) { panic(cause) }, enc, transport.PhaseDecode},
+		{"endpoint", boom, dec, enc, transport.PhaseEndpoint},
+		{"encode", ok, dec, func(context.Context, http.ResponseWriter, interface{}) error { panic("boom") }, transport.PhaseEncode},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled error
+			handler := httptransport.NewServer(tc.e, tc.dec, tc.enc,
+				httptransport.ServerRecover[interface{}, interface{}](),
+				httptransport.ServerErrorHandler[interface{}, interface{}](transport.ErrorHandlerFunc(func(_ context.Context, err error) { handled = err })),
+			)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if want, have := http.StatusInternalServerError, rec.Code; want != have {
+				t.Errorf("want %d, have %d", want, have)
+			}
+			if want, have := http.StatusText(http.StatusInternalServerError), rec.Body.String(); want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+			var pe *transport.PanicError
+			if !errors.As(handled, &pe) {
+				t.Fatalf("want *transport.PanicError, have %v", handled)
+			}
+			if want, have := tc.phase, pe.Phase; want != have {
+				t.Errorf("want %s, have %s", want, have)
+			}
+			if len(pe.Stack) == 0 {
+				t.Error("want stack trace, have none")
+			}
+		})
+	}
+	t.Run("unwrap", func(t *testing.T) {
+		var handled error
+		handler := httptransport.NewServer(ok, func(context.Context, *http.Request) (interface{}, error) { panic(cause) }, enc,
+			httptransport.ServerRecover[interface{}, interface{}](),
+			httptransport.ServerErrorHandler[interface{}, interface{}](transport.ErrorHandlerFunc(func(_ context.Context, err error) { handled = err })),
+		)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if !errors.Is(handled, cause) {
+			t.Errorf("want %v, have %v", cause, handled)
+		}
+	})
+}
+
+func TestServerRecoverAbortHandler(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, interface{}) (interface{}, error) { panic(http.ErrAbortHandler) },
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		httptransport.ServerRecover[interface{}, interface{}](),
+	)
+	defer func() {
+		if want, have := http.ErrAbortHandler, recover(); want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestServerNoRecover(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, interface{}) (interface{}, error) { panic("boom") },
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+	)
+	defer func() {
+		if want, have := "boom", recover(); want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
 func TestServerHappyPath(t *testing.T) {
 	step, response := testServer(t)
 	step()
@@ -395,6 +483,25 @@ func TestEnhancedError(t *testing.T) {
 	}
 }
 
+func TestDecodeError(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, *http.Request) (interface{}, error) {
+			return nil, &transport.DecodeError{Field: "name", Reason: "required", Err: errors.New("internal detail")}
+		},
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if want, have := http.StatusBadRequest, rec.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := `{"error":"invalid request","fields":[{"field":"name","reason":"required"}]}`, rec.Body.String(); want != have {
+		t.Errorf("Body: want %s, have %s", want, have)
+	}
+}
+
 func TestNoOpRequestDecoder(t *testing.T) {
 	resw := httptest.NewRecorder()
 	req, err := http.NewRequest(http.MethodGet, "/", nil)
@@ -442,3 +549,42 @@ func testServer(t *testing.T) (step func(), resp <-chan *http.Response) {
 	}()
 	return func() { stepch <- true }, response
 }
+
+func TestServerPhase(t *testing.T) {
+	type observation struct {
+		phase transport.Phase
+		took  time.Duration
+		err   error
+	}
+	var (
+		observed []observation
+		failure  = errors.New("encode failed")
+		handler  = httptransport.NewServer(
+			func(context.Context, interface{}) (interface{}, error) {
+				time.Sleep(10 * time.Millisecond)
+				return struct{}{}, nil
+			},
+			func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+			func(context.Context, http.ResponseWriter, interface{}) error { return failure },
+			httptransport.ServerPhase[interface{}, interface{}](func(_ context.Context, phase transport.Phase, _ time.Time, took time.Duration, err error) {
+				observed = append(observed, observation{phase, took, err})
+			}),
+		)
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if want, have := 3, len(observed); want != have {
+		t.Fatalf("want %d, have %d", want, have)
+	}
+	for i, phase := range []transport.Phase{transport.PhaseDecode, transport.PhaseEndpoint, transport.PhaseEncode} {
+		if want, have := phase, observed[i].phase; want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+	}
+	if have := observed[1].took; have < 10*time.Millisecond {
+		t.Errorf("endpoint: want at least 10ms, have %v", have)
+	}
+	if want, have := failure, observed[2].err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// Event is a server-sent event.
+type Event struct {
+	ID    string
+	Event string // the event type, "message" if empty
+	Data  []byte
+	Retry time.Duration // the reconnection delay advised to the client, if any
+}
+
+// EncodeEventFunc encodes a response of a stream endpoint as a server-sent
+// event.
+type EncodeEventFunc[RES any] func(context.Context, RES) (Event, error)
+
+// EncodeJSONEvent is an EncodeEventFunc sending the response as a JSON
+// message event.
+func EncodeJSONEvent[RES any](_ context.Context, response RES) (Event, error) {
+	b, err := json.Marshal(response)
+	return Event{Data: b}, err
+}
+
+// EncodeErrorEventFunc encodes an error that ends an event stream after it
+// has started as the last event sent to the client.
+type EncodeErrorEventFunc func(context.Context, error) Event
+
+// DefaultErrorEventEncoder sends an "error" event with the status text of the
+// error as data: the text for its StatusCode if it implements StatusCoder, or
+// for 500 otherwise. The text of the error itself isn't sent, as it may
+// expose internal details to clients.
+func DefaultErrorEventEncoder(_ context.Context, err error) Event {
+	code := http.StatusInternalServerError
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		code = sc.StatusCode()
+	}
+	return Event{Event: "error", Data: []byte(http.StatusText(code))}
+}
+
+// SSEServer wraps a stream endpoint and implements http.Handler, sending its
+// responses to the client as server-sent events, i.e. as a text/event-stream
+// as consumed by EventSource in browsers.
+type SSEServer[REQ any, RES any] struct {
+	e            endpoint.StreamEndpoint[REQ, RES]
+	dec          DecodeRequestFunc[REQ]
+	enc          EncodeEventFunc[RES]
+	before       []RequestFunc
+	errorEncoder ErrorEncoder
+	eventEncoder EncodeErrorEventFunc
+	errorHandler transport.ErrorHandler
+	heartbeat    time.Duration
+}
+
+// NewSSEServer constructs a new SSEServer, which implements http.Handler and
+// wraps the provided stream endpoint.
+func NewSSEServer[REQ any, RES any](
+	e endpoint.StreamEndpoint[REQ, RES],
+	dec DecodeRequestFunc[REQ],
+	enc EncodeEventFunc[RES],
+	options ...SSEServerOption[REQ, RES],
+) *SSEServer[REQ, RES] {
+	s := &SSEServer[REQ, RES]{
+		e:            e,
+		dec:          dec,
+		enc:          enc,
+		errorEncoder: DefaultErrorEncoder,
+		eventEncoder: DefaultErrorEventEncoder,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// SSEServerOption sets an optional parameter for SSE servers.
+type SSEServerOption[REQ any, RES any] func(*SSEServer[REQ, RES])
+
+// SSEServerBefore functions are executed on the HTTP request object before
+// the request is decoded.
+func SSEServerBefore[REQ any, RES any](before ...RequestFunc) SSEServerOption[REQ, RES] {
+	return func(s *SSEServer[REQ, RES]) { s.before = append(s.before, before...) }
+}
+
+// SSEServerErrorEncoder is used to encode errors that occur before the event
+// stream has started, i.e. before the first event or heartbeat. Errors that
+// occur afterwards are encoded by the error event encoder instead.
+func SSEServerErrorEncoder[REQ any, RES any](ee ErrorEncoder) SSEServerOption[REQ, RES] {
+	return func(s *SSEServer[REQ, RES]) { s.errorEncoder = ee }
+}
+
+// SSEServerErrorEventEncoder is used to encode errors that occur after the
+// event stream has started as a last event. By default, errors are encoded
+// with DefaultErrorEventEncoder.
+func SSEServerErrorEventEncoder[REQ any, RES any](ee EncodeErrorEventFunc) SSEServerOption[REQ, RES] {
+	return func(s *SSEServer[REQ, RES]) { s.eventEncoder = ee }
+}
+
+// SSEServerErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored.
+func SSEServerErrorHandler[REQ any, RES any](errorHandler transport.ErrorHandler) SSEServerOption[REQ, RES] {
+	return func(s *SSEServer[REQ, RES]) { s.errorHandler = errorHandler }
+}
+
+// SSEHeartbeat sends a comment to the client whenever no event was sent for
+// the given period, so that proxies don't close idle streams. By default, no
+// heartbeats are sent.
+func SSEHeartbeat[REQ any, RES any](period time.Duration) SSEServerOption[REQ, RES] {
+	return func(s *SSEServer[REQ, RES]) { s.heartbeat = period }
+}
+
+// ServeHTTP implements http.Handler.
+func (s SSEServer[REQ, RES]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	for _, f := range s.before {
+		ctx = f(ctx, r)
+	}
+
+	request, err := s.dec(ctx, r)
+	if err != nil {
+		s.errorHandler.Handle(ctx, err)
+		s.errorEncoder(ctx, err, w)
+		return
+	}
+
+	ew := &eventWriter{w: w, rc: http.NewResponseController(w)}
+	if s.heartbeat > 0 {
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ew.heartbeats(s.heartbeat, done)
+		}()
+		defer wg.Wait()
+		defer close(done)
+	}
+
+	err = s.e(ctx, request, func(response RES) error {
+		event, err := s.enc(ctx, response)
+		if err != nil {
+			return err
+		}
+		return ew.send(event)
+	})
+	if err == nil {
+		return
+	}
+	s.errorHandler.Handle(ctx, err)
+	if !ew.started() {
+		s.errorEncoder(ctx, err, w)
+		return
+	}
+	ew.send(s.eventEncoder(ctx, err))
+}
+
+// eventWriter writes events, and heartbeats, to the event stream.
+type eventWriter struct {
+	mtx     sync.Mutex
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	start   bool
+	written time.Time
+}
+
+func (ew *eventWriter) started() bool {
+	ew.mtx.Lock()
+	defer ew.mtx.Unlock()
+	return ew.start
+}
+
+func (ew *eventWriter) send(e Event) error {
+	var b bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", strings.ReplaceAll(e.ID, "\n", ""))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", strings.ReplaceAll(e.Event, "\n", ""))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(e.Data), "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteByte('\n')
+	return ew.write(b.Bytes())
+}
+
+func (ew *eventWriter) write(b []byte) error {
+	ew.mtx.Lock()
+	defer ew.mtx.Unlock()
+	if !ew.start {
+		h := ew.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no") // disables buffering by nginx
+		ew.w.WriteHeader(http.StatusOK)
+		ew.start = true
+	}
+	if _, err := ew.w.Write(b); err != nil {
+		return err
+	}
+	ew.written = time.Now()
+	return ew.rc.Flush()
+}
+
+func (ew *eventWriter) heartbeats(period time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ew.mtx.Lock()
+			idle := time.Since(ew.written) >= period
+			ew.mtx.Unlock()
+			if idle && ew.write([]byte(":\n\n")) != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/endpoint"
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestSSEServer(t *testing.T) {
+	errTooMany := errors.New("too many")
+	server := httptransport.NewSSEServer(
+		func(ctx context.Context, n int, send func(string) error) error {
+			if n > 3 {
+				return errTooMany
+			}
+			for i := 0; i < n; i++ {
+				if err := send(strings.Repeat("x", i+1)); err != nil {
+					return err
+				}
+			}
+			if n == 3 {
+				return errors.New("line 1\nline 2")
+			}
+			return nil
+		},
+		func(_ context.Context, r *http.Request) (int, error) {
+			return len(r.URL.Query()["n"]), nil
+		},
+		httptransport.EncodeJSONEvent[string],
+		httptransport.SSEServerErrorEventEncoder[int, string](func(_ context.Context, err error) httptransport.Event {
+			return httptransport.Event{Event: "error", Data: []byte(err.Error())}
+		}),
+	)
+
+	for _, testcase := range []struct {
+		query string
+		code  int
+		body  string
+	}{
+		{"?n&n", http.StatusOK, "data: \"x\"\n\ndata: \"xx\"\n\n"},
+		{"?n&n&n", http.StatusOK, "data: \"x\"\n\ndata: \"xx\"\n\ndata: \"xxx\"\n\nevent: error\ndata: line 1\ndata: line 2\n\n"},
+		{"?n&n&n&n", http.StatusInternalServerError, "too many"},
+	} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest("GET", "/"+testcase.query, nil))
+		if want, have := testcase.code, rec.Code; want != have {
+			t.Errorf("%s: want %d, have %d", testcase.query, want, have)
+		}
+		if want, have := testcase.body, rec.Body.String(); want != have {
+			t.Errorf("%s: want %q, have %q", testcase.query, want, have)
+		}
+		if testcase.code == http.StatusOK {
+			if want, have := "text/event-stream", rec.Header().Get("Content-Type"); want != have {
+				t.Errorf("%s: want %q, have %q", testcase.query, want, have)
+			}
+		}
+	}
+}
+
+func TestSSEServerDefaultErrorEvent(t *testing.T) {
+	server := httptransport.NewSSEServer(
+		func(ctx context.Context, _ struct{}, send func(string) error) error {
+			if err := send("x"); err != nil {
+				return err
+			}
+			return errors.New("dial tcp 10.0.0.1:5432: connection refused")
+		},
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		httptransport.EncodeJSONEvent[string],
+	)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if want, have := "data: \"x\"\n\nevent: error\ndata: Internal Server Error\n\n", rec.Body.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestSSEServerHeartbeat(t *testing.T) {
+	server := httptransport.NewSSEServer(
+		endpoint.FromChannel(func(ctx context.Context, _ struct{}) (<-chan sseEvent, error) {
+			c := make(chan sseEvent)
+			go func() {
+				defer close(c)
+				time.Sleep(50 * time.Millisecond)
+				c <- sseEvent{"ready"}
+			}()
+			return c, nil
+		}),
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		func(_ context.Context, e sseEvent) (httptransport.Event, error) {
+			return httptransport.Event{ID: "1", Event: e.Name, Retry: time.Second}, nil
+		},
+		httptransport.SSEHeartbeat[struct{}, sseEvent](10*time.Millisecond),
+	)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, ":\n\n") {
+		t.Errorf("want heartbeats first, have %q", body)
+	}
+	if want := "id: 1\nevent: ready\nretry: 1000\ndata: \n\n"; !strings.HasSuffix(body, want) {
+		t.Errorf("want %q last, have %q", want, body)
+	}
+}
+
+type sseEvent struct{ Name string }
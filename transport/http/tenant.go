@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/a69/kit.go/endpoint"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by a TenantHandler, or WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// TenantFunc extracts the tenant from a request. It returns the request to be
+// served, which may have been modified, e.g. to strip a path prefix, and false
+// if the request doesn't name a tenant.
+type TenantFunc func(r *http.Request) (tenant string, rest *http.Request, ok bool)
+
+// TenantFromHost returns a TenantFunc that takes the tenant from the
+// subdomain of the Host header, e.g. "acme" from "acme.example.com:8080" with
+// the domain "example.com". Hosts outside the domain don't name a tenant.
+func TenantFromHost(domain string) TenantFunc {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+	return func(r *http.Request) (string, *http.Request, bool) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		tenant := strings.TrimSuffix(host, suffix)
+		if tenant == host || tenant == "" {
+			return "", r, false
+		}
+		return tenant, r, true
+	}
+}
+
+// TenantFromPathPrefix returns a TenantFunc that takes the tenant from the
+// first segment of the URL path, e.g. "acme" from "/acme/orders/1", and strips
+// it, so the request is served as "/orders/1".
+func TenantFromPathPrefix() TenantFunc {
+	return func(r *http.Request) (string, *http.Request, bool) {
+		tenant, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if tenant == "" {
+			return "", r, false
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + rest
+		r2.URL.RawPath = ""
+		return tenant, r2, true
+	}
+}
+
+// TenantHandler serves an endpoint for many tenants on a single listener. It
+// puts the tenant of each request into the context, where it's available to
+// RequestFuncs, decoders and endpoints via TenantFromContext, and serves the
+// request with a Server configured for that tenant. Requests that don't name
+// a tenant are answered with 404 Not Found.
+type TenantHandler[REQ any, RES any] struct {
+	tenant  TenantFunc
+	e       endpoint.Endpoint[REQ, RES]
+	dec     DecodeRequestFunc[REQ]
+	enc     EncodeResponseFunc[RES]
+	options []ServerOption[REQ, RES]
+	base    *Server[REQ, RES]
+
+	mtx     sync.RWMutex
+	servers map[string]*Server[REQ, RES]
+}
+
+// NewTenantHandler returns a TenantHandler for the endpoint. The options apply
+// to all tenants.
+func NewTenantHandler[REQ any, RES any](
+	tenant TenantFunc,
+	e endpoint.Endpoint[REQ, RES],
+	dec DecodeRequestFunc[REQ],
+	enc EncodeResponseFunc[RES],
+	options ...ServerOption[REQ, RES],
+) *TenantHandler[REQ, RES] {
+	return &TenantHandler[REQ, RES]{
+		tenant:  tenant,
+		e:       e,
+		dec:     dec,
+		enc:     enc,
+		options: options,
+		base:    NewServer(e, dec, enc, options...),
+		servers: map[string]*Server[REQ, RES]{},
+	}
+}
+
+// Tenant configures the server of a tenant with options applied after the
+// common ones, e.g. its own rate limiting middleware or authentication realm.
+// Tenants that weren't configured are served with the common options only. It
+// may be called while the handler is serving requests.
+func (h *TenantHandler[REQ, RES]) Tenant(tenant string, options ...ServerOption[REQ, RES]) {
+	all := append(append([]ServerOption[REQ, RES]{}, h.options...), options...)
+	s := NewServer(h.e, h.dec, h.enc, all...)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.servers[tenant] = s
+}
+
+// ServeHTTP implements http.Handler.
+func (h *TenantHandler[REQ, RES]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant, r, ok := h.tenant(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.mtx.RLock()
+	s, ok := h.servers[tenant]
+	h.mtx.RUnlock()
+	if !ok {
+		s = h.base
+	}
+	s.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+}
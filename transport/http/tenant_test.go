@@ -0,0 +1,83 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/a69/kit.go/transport/http"
+)
+
+func TestTenantHandler(t *testing.T) {
+	handler := httptransport.NewTenantHandler(
+		httptransport.TenantFromHost("example.com"),
+		func(ctx context.Context, path string) (string, error) {
+			tenant, _ := httptransport.TenantFromContext(ctx)
+			return tenant + " " + path, nil
+		},
+		func(_ context.Context, r *http.Request) (string, error) { return r.URL.Path, nil },
+		func(_ context.Context, w http.ResponseWriter, response string) error {
+			_, err := io.WriteString(w, response)
+			return err
+		},
+		httptransport.ServerAfter[string, string](httptransport.SetResponseHeader("X-Common", "yes")),
+	)
+	handler.Tenant("realm", httptransport.ServerAfter[string, string](httptransport.SetResponseHeader("X-Realm", "realm")))
+
+	for _, tc := range []struct {
+		host, wantBody, wantRealm string
+		wantCode                  int
+	}{
+		{host: "acme.example.com:8080", wantCode: http.StatusOK, wantBody: "acme /orders"},
+		{host: "realm.example.com", wantCode: http.StatusOK, wantBody: "realm /orders", wantRealm: "realm"},
+		{host: "example.com", wantCode: http.StatusNotFound},
+		{host: "acme.example.org", wantCode: http.StatusNotFound},
+	} {
+		r := httptest.NewRequest("GET", "/orders", nil)
+		r.Host = tc.host
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		if want, have := tc.wantCode, rec.Code; want != have {
+			t.Errorf("%s: want %d, have %d", tc.host, want, have)
+		}
+		if tc.wantCode != http.StatusOK {
+			continue
+		}
+		if want, have := tc.wantBody, rec.Body.String(); want != have {
+			t.Errorf("%s: want %q, have %q", tc.host, want, have)
+		}
+		if want, have := "yes", rec.Header().Get("X-Common"); want != have {
+			t.Errorf("%s: want %q, have %q", tc.host, want, have)
+		}
+		if want, have := tc.wantRealm, rec.Header().Get("X-Realm"); want != have {
+			t.Errorf("%s: want %q, have %q", tc.host, want, have)
+		}
+	}
+}
+
+func TestTenantFromPathPrefix(t *testing.T) {
+	handler := httptransport.NewTenantHandler(
+		httptransport.TenantFromPathPrefix(),
+		func(ctx context.Context, path string) (string, error) {
+			if tenant, _ := httptransport.TenantFromContext(ctx); tenant != "acme" {
+				return "", errors.New("wrong tenant " + tenant)
+			}
+			return path, nil
+		},
+		func(_ context.Context, r *http.Request) (string, error) { return r.URL.Path, nil },
+		func(_ context.Context, w http.ResponseWriter, response string) error {
+			_, err := io.WriteString(w, response)
+			return err
+		},
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/acme/orders/1", nil))
+	if want, have := "/orders/1", rec.Body.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,22 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/a69/kit.go/tracing"
+)
+
+// DebugToContext returns a RequestFunc that forces trace sampling in the
+// context if the message carries a tracing.DebugHeader and allow accepts it.
+// A nil allow accepts every message, and should only be used if every
+// publisher is trusted. Particularly useful for subscribers.
+func DebugToContext(allow func(*nats.Msg) bool) RequestFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		if tracing.DebugRequested(msg.Header.Get(tracing.DebugHeader)) && (allow == nil || allow(msg)) {
+			ctx = tracing.WithForceSample(ctx)
+		}
+		return ctx
+	}
+}
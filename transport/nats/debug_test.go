@@ -0,0 +1,23 @@
+package nats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/a69/kit.go/tracing"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+func TestDebugToContext(t *testing.T) {
+	ctx := context.Background()
+	msg := &nats.Msg{Header: nats.Header{}}
+	if tracing.ForceSampled(natstransport.DebugToContext(nil)(ctx, msg)) {
+		t.Error("want no force sampling without header")
+	}
+	msg.Header.Set(tracing.DebugHeader, "1")
+	if !tracing.ForceSampled(natstransport.DebugToContext(nil)(ctx, msg)) {
+		t.Error("want force sampled subscriber context")
+	}
+}
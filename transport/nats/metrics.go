@@ -0,0 +1,32 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// ObserveRequestSize returns a RequestFunc observing the size in bytes of
+// request data in h. As a SubscriberBefore, it observes the data of the
+// message received, and as a PublisherBefore, the data of the message about
+// to be sent.
+//
+// The size of the responses of subscribers can't be observed, as their
+// EncodeResponseFuncs publish them directly.
+func ObserveRequestSize(h metrics.Histogram) RequestFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		h.Observe(float64(len(msg.Data)))
+		return ctx
+	}
+}
+
+// ObserveResponseSize returns a PublisherResponseFunc observing the size in
+// bytes of response data in h.
+func ObserveResponseSize(h metrics.Histogram) PublisherResponseFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		h.Observe(float64(len(msg.Data)))
+		return ctx
+	}
+}
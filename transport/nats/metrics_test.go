@@ -0,0 +1,25 @@
+package nats_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+func TestObserveSizes(t *testing.T) {
+	var (
+		h   = metricstest.NewHistogram("size")
+		ctx = context.Background()
+	)
+	natstransport.ObserveRequestSize(h)(ctx, &nats.Msg{Data: []byte("abc")})
+	natstransport.ObserveResponseSize(h)(ctx, &nats.Msg{Data: []byte("ab")})
+
+	if want, have := "[3 2]", fmt.Sprint(h.Observations()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
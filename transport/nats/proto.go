@@ -0,0 +1,56 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodeProtoRequest is an EncodeRequestFunc that serializes the request as
+// Protobuf to the Data of the Msg. It spares high-rate internal services the
+// overhead of JSON, without the deprecated nats.EncodedConn.
+func EncodeProtoRequest[REQ proto.Message](_ context.Context, msg *nats.Msg, request REQ) error {
+	b, err := proto.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	msg.Data = b
+
+	return nil
+}
+
+// DecodeProtoRequest is a DecodeRequestFunc that deserializes the Protobuf
+// request from the Data of the Msg into a new REQ, e.g. *pb.GetUserRequest.
+func DecodeProtoRequest[REQ proto.Message](_ context.Context, msg *nats.Msg) (REQ, error) {
+	return unmarshalProto[REQ](msg.Data)
+}
+
+// EncodeProtoResponse is an EncodeResponseFunc that serializes the response
+// as Protobuf to the subscriber reply. Note that errors are still encoded by
+// the ErrorEncoder of the subscriber, as JSON by default, which
+// DecodeProtoResponse fails to decode.
+func EncodeProtoResponse[RES proto.Message](_ context.Context, reply string, nc *nats.Conn, response RES) error {
+	b, err := proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return nc.Publish(reply, b)
+}
+
+// DecodeProtoResponse is a DecodeResponseFunc that deserializes the Protobuf
+// response from the Data of the Msg into a new RES, e.g. *pb.GetUserResponse.
+func DecodeProtoResponse[RES proto.Message](_ context.Context, msg *nats.Msg) (RES, error) {
+	return unmarshalProto[RES](msg.Data)
+}
+
+func unmarshalProto[M proto.Message](b []byte) (M, error) {
+	var zero M
+	m := zero.ProtoReflect().New().Interface().(M)
+	if err := proto.Unmarshal(b, m); err != nil {
+		return zero, err
+	}
+	return m, nil
+}
@@ -0,0 +1,63 @@
+package nats_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	natstransport "github.com/a69/kit.go/transport/nats"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	s, c := newNATSConn(t)
+	defer func() { s.Shutdown(); s.WaitForShutdown() }()
+	defer c.Close()
+
+	handler := natstransport.NewSubscriber(
+		func(_ context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+			return wrapperspb.String(strings.ToUpper(request.GetValue())), nil
+		},
+		natstransport.DecodeProtoRequest[*wrapperspb.StringValue],
+		natstransport.EncodeProtoResponse[*wrapperspb.StringValue],
+	)
+	sub, err := c.QueueSubscribe("natstransport.proto", "natstransport", handler.ServeMsg(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	publisher := natstransport.NewPublisher(
+		c,
+		"natstransport.proto",
+		natstransport.EncodeProtoRequest[*wrapperspb.StringValue],
+		natstransport.DecodeProtoResponse[*wrapperspb.StringValue],
+	)
+	response, err := publisher.Endpoint()(context.Background(), wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "HELLO", response.GetValue(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestDecodeProtoRequest(t *testing.T) {
+	var msg nats.Msg
+	if err := natstransport.EncodeProtoRequest(context.Background(), &msg, wrapperspb.Int64(42)); err != nil {
+		t.Fatal(err)
+	}
+	request, err := natstransport.DecodeProtoRequest[*wrapperspb.Int64Value](context.Background(), &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int64(42), request.GetValue(); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	msg.Data = []byte{0xff}
+	if _, err := natstransport.DecodeProtoRequest[*wrapperspb.Int64Value](context.Background(), &msg); err == nil {
+		t.Error("want error decoding garbage, have none")
+	}
+}
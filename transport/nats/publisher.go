@@ -17,6 +17,7 @@ type Publisher[REQ any, RES any] struct {
 	before    []RequestFunc
 	after     []PublisherResponseFunc
 	timeout   time.Duration
+	deadline  bool
 }
 
 // NewPublisher constructs a usable Publisher for a single remote method.
@@ -61,10 +62,32 @@ func PublisherTimeout[REQ any, RES any](timeout time.Duration) PublisherOption[R
 	return func(p *Publisher[REQ, RES]) { p.timeout = timeout }
 }
 
+// PublisherContextDeadline makes the deadline of the incoming context, if
+// any, govern requests instead of the timeout set with PublisherTimeout, so
+// that e.g. the deadline of a gateway request propagates into the NATS
+// request, even if it's further away.
+func PublisherContextDeadline[REQ any, RES any]() PublisherOption[REQ, RES] {
+	return func(p *Publisher[REQ, RES]) { p.deadline = true }
+}
+
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a context overriding the timeout of the requests
+// made with it by any Publisher. As with context.WithTimeout, the deadline of
+// ctx still applies if it's sooner.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// Subject returns the subject the requests are published to.
+func (p Publisher[REQ, RES]) Subject() string {
+	return p.subject
+}
+
 // Endpoint returns a usable endpoint that invokes the remote endpoint.
 func (p Publisher[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 	return func(ctx context.Context, request REQ) (response RES, err error) {
-		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		ctx, cancel := p.withTimeout(ctx)
 		defer cancel()
 
 		msg := nats.Msg{Subject: p.subject}
@@ -77,7 +100,7 @@ func (p Publisher[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 			ctx = f(ctx, &msg)
 		}
 
-		resp, err := p.publisher.RequestWithContext(ctx, msg.Subject, msg.Data)
+		resp, err := p.publisher.RequestMsgWithContext(ctx, &msg)
 		if err != nil {
 			return
 		}
@@ -90,6 +113,16 @@ func (p Publisher[REQ, RES]) Endpoint() endpoint.Endpoint[REQ, RES] {
 	}
 }
 
+func (p Publisher[REQ, RES]) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	if _, ok := ctx.Deadline(); ok && p.deadline {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
 // EncodeJSONRequest is an EncodeRequestFunc that serializes the request as a
 // JSON object to the Data of the Msg. Many JSON-over-NATS services can use it as
 // a sensible default.
@@ -184,6 +184,106 @@ func TestPublisherTimeout(t *testing.T) {
 	}
 }
 
+func TestPublisherRequestTimeout(t *testing.T) {
+	var (
+		encode = func(context.Context, *nats.Msg, struct{}) error { return nil }
+		decode = func(_ context.Context, msg *nats.Msg) (TestResponse, error) {
+			return TestResponse{string(msg.Data), ""}, nil
+		}
+	)
+
+	s, c := newNATSConn(t)
+	defer func() { s.Shutdown(); s.WaitForShutdown() }()
+	defer c.Close()
+
+	ch := make(chan struct{})
+	defer close(ch)
+
+	sub, err := c.QueueSubscribe("natstransport.test", "natstransport", func(msg *nats.Msg) {
+		<-ch
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	publisher := natstransport.NewPublisher(
+		c,
+		"natstransport.test",
+		encode,
+		decode,
+		natstransport.PublisherTimeout[struct{}, TestResponse](time.Minute),
+	)
+
+	ctx := natstransport.WithRequestTimeout(context.Background(), 100*time.Millisecond)
+	begin := time.Now()
+	_, err = publisher.Endpoint()(ctx, struct{}{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("want %s, have %s", context.DeadlineExceeded, err)
+	}
+	if took := time.Since(begin); took > 10*time.Second {
+		t.Errorf("request timed out after %s", took)
+	}
+}
+
+func TestPublisherContextDeadline(t *testing.T) {
+	var (
+		testdata = "testdata"
+		encode   = func(context.Context, *nats.Msg, struct{}) error { return nil }
+		decode   = func(_ context.Context, msg *nats.Msg) (TestResponse, error) {
+			return TestResponse{string(msg.Data), ""}, nil
+		}
+	)
+
+	s, c := newNATSConn(t)
+	defer func() { s.Shutdown(); s.WaitForShutdown() }()
+	defer c.Close()
+
+	// The handler outlives the request timing out, so it reports its errors
+	// to the test body instead of failing t after the test returned.
+	errc := make(chan error, 2)
+	sub, err := c.QueueSubscribe("natstransport.test", "natstransport", func(msg *nats.Msg) {
+		time.Sleep(200 * time.Millisecond)
+		errc <- c.Publish(msg.Reply, []byte(testdata))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	publisher := natstransport.NewPublisher(
+		c,
+		"natstransport.test",
+		encode,
+		decode,
+		natstransport.PublisherTimeout[struct{}, TestResponse](50*time.Millisecond),
+		natstransport.PublisherContextDeadline[struct{}, TestResponse](),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := publisher.Endpoint()(ctx, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := testdata, response.String; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// Without a deadline, the timeout still applies.
+	_, err = publisher.Endpoint()(context.Background(), struct{}{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("want %s, have %s", context.DeadlineExceeded, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func TestPublisherCancellation(t *testing.T) {
 	var (
 		testdata = "testdata"
@@ -0,0 +1,269 @@
+package nats
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/a69/kit.go/endpoint"
+	"github.com/a69/kit.go/transport"
+	"github.com/go-kit/log"
+)
+
+// Headers set on messages routed to the failure subject of a WorkQueue.
+const (
+	FailureErrorHeader    = "Kit-Failure-Error"
+	FailureAttemptsHeader = "Kit-Failure-Attempts"
+	FailureStreamHeader   = "Kit-Failure-Stream"
+	FailureSequenceHeader = "Kit-Failure-Sequence"
+	FailureSubjectHeader  = "Kit-Failure-Subject"
+)
+
+// DecodeWorkFunc extracts a user-domain request object from a JetStream
+// message consumed by a WorkQueue.
+type DecodeWorkFunc[REQ any] func(context.Context, jetstream.Msg) (request REQ, err error)
+
+// Delivery describes the delivery of a JetStream message to a WorkQueue. It's
+// available to endpoints through DeliveryFromContext.
+type Delivery struct {
+	// Attempt is the number of times the message has been delivered,
+	// including this one, starting at 1.
+	Attempt int
+
+	// MaxAttempts is the number of attempts after which the message is
+	// routed to the failure subject, as configured with WorkQueueMaxDeliver.
+	MaxAttempts int
+
+	Stream         string
+	StreamSequence uint64
+	Timestamp      time.Time
+}
+
+// LastAttempt reports whether a failure of this attempt will route the
+// message to the failure subject rather than redeliver it.
+func (d Delivery) LastAttempt() bool {
+	return d.Attempt >= d.MaxAttempts
+}
+
+type deliveryKey struct{}
+
+// DeliveryFromContext returns the Delivery of the message being handled by a
+// WorkQueue.
+func DeliveryFromContext(ctx context.Context) (Delivery, bool) {
+	d, ok := ctx.Value(deliveryKey{}).(Delivery)
+	return d, ok
+}
+
+// WorkQueue wraps an endpoint and consumes messages from a JetStream consumer,
+// typically on a stream with the work-queue retention policy. Each message is
+// acknowledged when the endpoint succeeds, and negatively acknowledged when it
+// fails, so that it's redelivered. While the endpoint runs, the message is
+// periodically marked in progress, so the consumer's AckWait acts as a
+// visibility timeout that only expires if this process dies; the endpoint's
+// own processing time is bounded by WorkQueueMaxProcessing instead.
+//
+// Once a message has failed the maximum number of attempts, or if it can't be
+// decoded at all, it's published to the failure subject, if any, and
+// terminated. If publishing it fails, it's left unacknowledged, for the server
+// to redeliver it once the consumer's AckWait expires.
+type WorkQueue[REQ any, RES any] struct {
+	e              endpoint.Endpoint[REQ, RES]
+	dec            DecodeWorkFunc[REQ]
+	maxDeliver     int
+	maxProcessing  time.Duration
+	heartbeat      time.Duration
+	retryDelay     time.Duration
+	failures       jetstream.Publisher
+	failureSubject string
+	before         []WorkQueueRequestFunc
+	errorHandler   transport.ErrorHandler
+}
+
+// WorkQueueRequestFunc may take information from a JetStream message and put
+// it into the request context, prior to invoking the endpoint.
+type WorkQueueRequestFunc func(context.Context, jetstream.Msg) context.Context
+
+// NewWorkQueue constructs a new WorkQueue. Pass its HandleMsg method to
+// jetstream.Consumer's Consume.
+func NewWorkQueue[REQ any, RES any](
+	e endpoint.Endpoint[REQ, RES],
+	dec DecodeWorkFunc[REQ],
+	options ...WorkQueueOption[REQ, RES],
+) *WorkQueue[REQ, RES] {
+	w := &WorkQueue[REQ, RES]{
+		e:             e,
+		dec:           dec,
+		maxDeliver:    5,
+		maxProcessing: 30 * time.Second,
+		heartbeat:     10 * time.Second,
+		errorHandler:  transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// WorkQueueOption sets an optional parameter for work queues.
+type WorkQueueOption[REQ any, RES any] func(*WorkQueue[REQ, RES])
+
+// WorkQueueMaxDeliver sets the number of attempts after which a message is
+// given up on. It must be strictly lower than the MaxDeliver of the JetStream
+// consumer: the server stops redelivering the message once that is reached,
+// so the spare deliveries are needed to route it to the failure subject again
+// if publishing it failed. The default is 5.
+func WorkQueueMaxDeliver[REQ any, RES any](n int) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.maxDeliver = n }
+}
+
+// WorkQueueMaxProcessing bounds the time the endpoint may spend on a single
+// message; its context is canceled afterwards. The default is 30 seconds.
+func WorkQueueMaxProcessing[REQ any, RES any](d time.Duration) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.maxProcessing = d }
+}
+
+// WorkQueueHeartbeat sets how often messages are marked in progress while the
+// endpoint runs. It must be shorter than the consumer's AckWait. The default
+// is 10 seconds.
+func WorkQueueHeartbeat[REQ any, RES any](d time.Duration) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.heartbeat = d }
+}
+
+// WorkQueueRetryDelay sets the delay before a failed message is redelivered.
+// By default, the consumer's BackOff or AckWait settings apply.
+func WorkQueueRetryDelay[REQ any, RES any](d time.Duration) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.retryDelay = d }
+}
+
+// WorkQueueFailureSubject routes messages that exhausted their attempts, or
+// couldn't be decoded, to the given subject, with the Failure headers set. By
+// default, such messages are only terminated.
+func WorkQueueFailureSubject[REQ any, RES any](js jetstream.Publisher, subject string) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.failures, w.failureSubject = js, subject }
+}
+
+// WorkQueueBefore functions are executed on the JetStream message before the
+// request is decoded.
+func WorkQueueBefore[REQ any, RES any](before ...WorkQueueRequestFunc) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.before = append(w.before, before...) }
+}
+
+// WorkQueueErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored.
+func WorkQueueErrorHandler[REQ any, RES any](errorHandler transport.ErrorHandler) WorkQueueOption[REQ, RES] {
+	return func(w *WorkQueue[REQ, RES]) { w.errorHandler = errorHandler }
+}
+
+// HandleMsg implements jetstream.MessageHandler.
+func (w WorkQueue[REQ, RES]) HandleMsg(msg jetstream.Msg) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.maxProcessing)
+	defer cancel()
+
+	delivery := Delivery{Attempt: 1, MaxAttempts: w.maxDeliver}
+	if md, err := msg.Metadata(); err == nil {
+		delivery.Attempt = int(md.NumDelivered)
+		delivery.Stream = md.Stream
+		delivery.StreamSequence = md.Sequence.Stream
+		delivery.Timestamp = md.Timestamp
+	}
+	ctx = context.WithValue(ctx, deliveryKey{}, delivery)
+
+	for _, f := range w.before {
+		ctx = f(ctx, msg)
+	}
+
+	request, err := w.dec(ctx, msg)
+	if err != nil {
+		w.errorHandler.Handle(ctx, err)
+		w.fail(ctx, msg, delivery, err)
+		return
+	}
+
+	stop := w.keepAlive(msg)
+	_, err = w.e(ctx, request)
+	stop()
+
+	switch {
+	case err == nil:
+		err = msg.Ack()
+	case delivery.LastAttempt():
+		w.errorHandler.Handle(ctx, err)
+		w.fail(ctx, msg, delivery, err)
+		return
+	case w.retryDelay > 0:
+		w.errorHandler.Handle(ctx, err)
+		err = msg.NakWithDelay(w.retryDelay)
+	default:
+		w.errorHandler.Handle(ctx, err)
+		err = msg.Nak()
+	}
+	if err != nil {
+		w.errorHandler.Handle(ctx, err)
+	}
+}
+
+// keepAlive marks the message in progress every heartbeat, until the returned
+// function is called.
+func (w WorkQueue[REQ, RES]) keepAlive(msg jetstream.Msg) (stop func()) {
+	if w.heartbeat <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(w.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				msg.InProgress()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// fail routes the message to the failure subject, if any, and terminates it.
+// If publishing fails, the message is left unacknowledged, to be redelivered
+// after the consumer's AckWait rather than right away.
+func (w WorkQueue[REQ, RES]) fail(ctx context.Context, msg jetstream.Msg, delivery Delivery, cause error) {
+	if w.failures != nil {
+		failure := nats.NewMsg(w.failureSubject)
+		failure.Data = msg.Data()
+		for k, v := range msg.Headers() {
+			// Headers like Nats-Msg-Id or Nats-Expected-Last-Sequence are
+			// directives to JetStream about the original message, which
+			// could get the failure rejected or deduplicated.
+			if strings.HasPrefix(strings.ToLower(k), "nats-") {
+				continue
+			}
+			failure.Header[k] = v
+		}
+		failure.Header.Set(FailureErrorHeader, cause.Error())
+		failure.Header.Set(FailureAttemptsHeader, strconv.Itoa(delivery.Attempt))
+		failure.Header.Set(FailureStreamHeader, delivery.Stream)
+		failure.Header.Set(FailureSequenceHeader, strconv.FormatUint(delivery.StreamSequence, 10))
+		failure.Header.Set(FailureSubjectHeader, msg.Subject())
+
+		// The endpoint may have used up the context's deadline.
+		pubCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if _, err := w.failures.PublishMsg(pubCtx, failure); err != nil {
+			w.errorHandler.Handle(ctx, err)
+			return
+		}
+	}
+	if err := msg.TermWithReason(cause.Error()); err != nil {
+		w.errorHandler.Handle(ctx, err)
+	}
+}
@@ -0,0 +1,184 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/a69/kit.go/transport"
+	natstransport "github.com/a69/kit.go/transport/nats"
+)
+
+type workMsg struct {
+	jetstream.Msg // unimplemented methods panic
+
+	mtx        sync.Mutex
+	delivered  uint64
+	header     nats.Header
+	acked      bool
+	naked      bool
+	terminated bool
+	inProgress int
+}
+
+func (m *workMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.delivered, Stream: "WORK", Sequence: jetstream.SequencePair{Stream: 42}}, nil
+}
+func (m *workMsg) Data() []byte                { return []byte("payload") }
+func (m *workMsg) Headers() nats.Header        { return m.header }
+func (m *workMsg) Subject() string             { return "work.items" }
+func (m *workMsg) Ack() error                  { m.acked = true; return nil }
+func (m *workMsg) Nak() error                  { m.naked = true; return nil }
+func (m *workMsg) TermWithReason(string) error { m.terminated = true; return nil }
+func (m *workMsg) InProgress() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.inProgress++
+	return nil
+}
+
+type failurePublisher struct {
+	jetstream.Publisher
+	msgs []*nats.Msg
+	err  error
+}
+
+func (p *failurePublisher) PublishMsg(_ context.Context, msg *nats.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.msgs = append(p.msgs, msg)
+	return &jetstream.PubAck{}, nil
+}
+
+func decodeWork(_ context.Context, msg jetstream.Msg) (string, error) {
+	return string(msg.Data()), nil
+}
+
+func TestWorkQueueAck(t *testing.T) {
+	var delivery natstransport.Delivery
+	wq := natstransport.NewWorkQueue[string, struct{}](
+		func(ctx context.Context, _ string) (struct{}, error) {
+			delivery, _ = natstransport.DeliveryFromContext(ctx)
+			time.Sleep(50 * time.Millisecond)
+			return struct{}{}, nil
+		},
+		decodeWork,
+		natstransport.WorkQueueHeartbeat[string, struct{}](10*time.Millisecond),
+	)
+
+	msg := &workMsg{delivered: 2}
+	wq.HandleMsg(msg)
+
+	if !msg.acked {
+		t.Error("message wasn't acknowledged")
+	}
+	if msg.inProgress == 0 {
+		t.Error("message wasn't marked in progress")
+	}
+	if want, have := 2, delivery.Attempt; want != have {
+		t.Errorf("attempt: want %d, have %d", want, have)
+	}
+	if want, have := uint64(42), delivery.StreamSequence; want != have {
+		t.Errorf("sequence: want %d, have %d", want, have)
+	}
+}
+
+func TestWorkQueueRetryAndFailure(t *testing.T) {
+	var (
+		failure   = errors.New("boom")
+		publisher = &failurePublisher{}
+		wq        = natstransport.NewWorkQueue[string, struct{}](
+			func(context.Context, string) (struct{}, error) { return struct{}{}, failure },
+			decodeWork,
+			natstransport.WorkQueueMaxDeliver[string, struct{}](3),
+			natstransport.WorkQueueFailureSubject[string, struct{}](publisher, "work.failed"),
+		)
+	)
+
+	msg := &workMsg{delivered: 1}
+	wq.HandleMsg(msg)
+	if !msg.naked || msg.terminated || len(publisher.msgs) != 0 {
+		t.Errorf("first attempt: want nak only, have nak=%v term=%v published=%d", msg.naked, msg.terminated, len(publisher.msgs))
+	}
+
+	msg = &workMsg{delivered: 3, header: nats.Header{
+		"Nats-Msg-Id":                 {"order-1"},
+		"Nats-Expected-Last-Sequence": {"41"},
+		"Trace-Id":                    {"abc"},
+	}}
+	wq.HandleMsg(msg)
+	if msg.naked || !msg.terminated {
+		t.Errorf("last attempt: want term only, have nak=%v term=%v", msg.naked, msg.terminated)
+	}
+	if want, have := 1, len(publisher.msgs); want != have {
+		t.Fatalf("published: want %d, have %d", want, have)
+	}
+	routed := publisher.msgs[0]
+	if want, have := "work.failed", routed.Subject; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	for header, want := range map[string]string{
+		natstransport.FailureErrorHeader:    "boom",
+		natstransport.FailureAttemptsHeader: "3",
+		natstransport.FailureSequenceHeader: "42",
+		natstransport.FailureSubjectHeader:  "work.items",
+		"Trace-Id":                          "abc",
+		"Nats-Msg-Id":                       "",
+		"Nats-Expected-Last-Sequence":       "",
+	} {
+		if have := routed.Header.Get(header); want != have {
+			t.Errorf("%s: want %q, have %q", header, want, have)
+		}
+	}
+}
+
+func TestWorkQueueFailurePublishFails(t *testing.T) {
+	var (
+		errs      []error
+		publisher = &failurePublisher{err: errors.New("no responders")}
+		wq        = natstransport.NewWorkQueue[string, struct{}](
+			func(context.Context, string) (struct{}, error) { return struct{}{}, errors.New("boom") },
+			decodeWork,
+			natstransport.WorkQueueMaxDeliver[string, struct{}](3),
+			natstransport.WorkQueueFailureSubject[string, struct{}](publisher, "work.failed"),
+			natstransport.WorkQueueErrorHandler[string, struct{}](transport.ErrorHandlerFunc(func(_ context.Context, err error) {
+				errs = append(errs, err)
+			})),
+		)
+	)
+
+	// The message stays unacknowledged, for the server to redeliver it after
+	// AckWait, and to route it again on the next attempt.
+	for _, delivered := range []uint64{3, 4} {
+		msg := &workMsg{delivered: delivered}
+		wq.HandleMsg(msg)
+		if msg.acked || msg.naked || msg.terminated {
+			t.Errorf("attempt %d: want no acknowledgement, have ack=%v nak=%v term=%v", delivered, msg.acked, msg.naked, msg.terminated)
+		}
+	}
+	if len(errs) == 0 || errs[len(errs)-1] != publisher.err {
+		t.Errorf("want the publishing error handled, have %v", errs)
+	}
+}
+
+func TestWorkQueueMaxProcessing(t *testing.T) {
+	wq := natstransport.NewWorkQueue[string, struct{}](
+		func(ctx context.Context, _ string) (struct{}, error) {
+			<-ctx.Done()
+			return struct{}{}, ctx.Err()
+		},
+		decodeWork,
+		natstransport.WorkQueueMaxProcessing[string, struct{}](10*time.Millisecond),
+	)
+	msg := &workMsg{delivered: 1}
+	wq.HandleMsg(msg)
+	if !msg.naked {
+		t.Error("timed out message wasn't negatively acknowledged")
+	}
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error servers recovering panics fail requests with, in
+// place of a panic raised while decoding a request, running the endpoint,
+// or encoding a response. It's passed to the ErrorHandler and the error
+// encoder of the server like any other error, so that the panic is logged
+// with its stack, and the client gets an internal error rather than a reset
+// connection.
+type PanicError struct {
+	// Phase is the phase the panic was raised in.
+	Phase Phase
+
+	// Value is the value the panic was raised with.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// NewPanicError returns a PanicError for a panic raised with value, in the
+// phase, capturing the current stack trace. It's meant to be called by the
+// deferred function that recovered the panic.
+func NewPanicError(phase Phase, value interface{}) *PanicError {
+	return &PanicError{Phase: phase, Value: value, Stack: debug.Stack()}
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.Phase, e.Value)
+}
+
+// Unwrap returns the value of the panic if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/a69/kit.go/metrics"
+)
+
+// Phase names a step in the server-side handling of a request.
+type Phase string
+
+// Phases reported by servers, in the order they happen.
+const (
+	PhaseDecode   Phase = "decode"
+	PhaseEndpoint Phase = "endpoint"
+	PhaseEncode   Phase = "encode"
+)
+
+// PhaseFunc is called by servers after each phase of handling a request, with
+// the time the phase started, how long it took, and the error it failed with,
+// if any. The context is the one the phase ran with, so it carries the span
+// started by any tracing ServerBefore option. Phases after a failed one aren't
+// reported.
+//
+// Breaking latency down like this tells at a glance whether a slow request
+// spent its time reading and decoding the payload, in business logic, or in
+// encoding and writing the response.
+type PhaseFunc func(ctx context.Context, phase Phase, start time.Time, took time.Duration, err error)
+
+// PhaseMetrics returns a PhaseFunc that observes the duration of every phase,
+// in seconds, in the histogram, labeled with "phase".
+func PhaseMetrics(h metrics.Histogram) PhaseFunc {
+	return func(_ context.Context, phase Phase, _ time.Time, took time.Duration, _ error) {
+		h.With("phase", string(phase)).Observe(took.Seconds())
+	}
+}
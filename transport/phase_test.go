@@ -0,0 +1,24 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a69/kit.go/metrics/metricstest"
+	"github.com/a69/kit.go/transport"
+)
+
+func TestPhaseMetrics(t *testing.T) {
+	h := metricstest.NewHistogram("phase_seconds")
+	f := transport.PhaseMetrics(h)
+	f(context.Background(), transport.PhaseDecode, time.Now(), 2*time.Second, nil)
+	f(context.Background(), transport.PhaseEncode, time.Now(), time.Second, nil)
+
+	if want, have := []float64{2}, h.ObservationsWith("phase", "decode"); len(have) != 1 || have[0] != want[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := []float64{1}, h.ObservationsWith("phase", "encode"); len(have) != 1 || have[0] != want[0] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,200 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+// ErrManagerClosed is returned by the Wait method of a closed Managed.
+var ErrManagerClosed = errors.New("connection manager closed")
+
+// DialFunc establishes a connection managed by a Managed.
+type DialFunc[C comparable] func() (C, error)
+
+// Managed is the generic counterpart of Manager, owning a connection of any
+// type, e.g. a *nats.Conn or an *amqp.Connection, established by a DialFunc.
+//
+// Clients Take the current connection, or Wait for one, and Put back the
+// errors they get from it, or from its close notifications. Putting an error
+// invalidates the connection, closes it, and dials a new one, retrying
+// failures after an exponential backoff with jitter. Errors from connections
+// that were already replaced are ignored.
+type Managed[C comparable] struct {
+	dial    DialFunc[C]
+	close   func(C)
+	after   AfterFunc
+	logger  log.Logger
+	initial time.Duration
+	max     time.Duration
+
+	mtx    sync.Mutex
+	conn   C
+	ok     bool
+	ready  chan struct{} // closed once connected
+	closed bool
+	done   chan struct{}
+}
+
+// ManagedOption sets an optional parameter for managed connections.
+type ManagedOption func(*managedOptions)
+
+type managedOptions struct {
+	after   AfterFunc
+	logger  log.Logger
+	initial time.Duration
+	max     time.Duration
+}
+
+// ManagedBackoff sets the initial and maximum delays between failed dials.
+// By default, they're 1s and 1m.
+func ManagedBackoff(initial, max time.Duration) ManagedOption {
+	return func(o *managedOptions) { o.initial, o.max = initial, max }
+}
+
+// ManagedLogger sets the logger of dial failures and invalidated connections.
+// By default, nothing is logged.
+func ManagedLogger(logger log.Logger) ManagedOption {
+	return func(o *managedOptions) { o.logger = logger }
+}
+
+// ManagedAfter sets the AfterFunc timing the backoff, e.g. for tests. By
+// default, time.After is used.
+func ManagedAfter(after AfterFunc) ManagedOption {
+	return func(o *managedOptions) { o.after = after }
+}
+
+// NewManaged returns a Managed connection, dialing it in the background with
+// dial. The connections are closed with close, e.g.
+//
+//	conn.NewManaged(func() (*nats.Conn, error) { return nats.Connect(url) }, (*nats.Conn).Close)
+func NewManaged[C comparable](dial DialFunc[C], close func(C), options ...ManagedOption) *Managed[C] {
+	o := managedOptions{
+		after:   time.After,
+		logger:  log.NewNopLogger(),
+		initial: time.Second,
+		max:     time.Minute,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+	m := &Managed[C]{
+		dial:    dial,
+		close:   close,
+		after:   o.after,
+		logger:  o.logger,
+		initial: o.initial,
+		max:     o.max,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go m.connect()
+	return m
+}
+
+// CloserFunc adapts the Close method of connections returning an error, e.g.
+// net.Conn or *amqp.Connection, to NewManaged.
+func CloserFunc[C io.Closer](c C) { c.Close() }
+
+// Take returns the current connection, if any.
+func (m *Managed[C]) Take() (C, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.conn, m.ok
+}
+
+// Wait returns the current connection, waiting for one to be established if
+// needed, until ctx is done or the manager is closed.
+func (m *Managed[C]) Wait(ctx context.Context) (C, error) {
+	for {
+		m.mtx.Lock()
+		conn, ok, closed, ready := m.conn, m.ok, m.closed, m.ready
+		m.mtx.Unlock()
+		switch {
+		case ok:
+			return conn, nil
+		case closed:
+			return conn, ErrManagerClosed
+		}
+		select {
+		case <-ready:
+		case <-m.done:
+		case <-ctx.Done():
+			var zero C
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Put accepts an error that came from conn. If the error is non-nil, and conn
+// is still the current connection, it's closed, and a new one is dialed.
+func (m *Managed[C]) Put(conn C, err error) {
+	if err == nil {
+		return
+	}
+	m.mtx.Lock()
+	if !m.ok || m.closed || conn != m.conn {
+		m.mtx.Unlock()
+		return
+	}
+	var zero C
+	m.conn, m.ok = zero, false
+	m.ready = make(chan struct{})
+	m.mtx.Unlock()
+
+	m.logger.Log("err", err, "msg", "connection invalidated")
+	m.close(conn)
+	go m.connect()
+}
+
+// Close closes the current connection, and stops dialing new ones.
+func (m *Managed[C]) Close() {
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return
+	}
+	m.closed = true
+	close(m.done)
+	conn, ok := m.conn, m.ok
+	var zero C
+	m.conn, m.ok = zero, false
+	m.mtx.Unlock()
+
+	if ok {
+		m.close(conn)
+	}
+}
+
+func (m *Managed[C]) connect() {
+	backoff := m.initial
+	for {
+		conn, err := m.dial()
+		if err == nil {
+			m.mtx.Lock()
+			if m.closed {
+				m.mtx.Unlock()
+				m.close(conn)
+				return
+			}
+			m.conn, m.ok = conn, true
+			close(m.ready)
+			m.mtx.Unlock()
+			return
+		}
+
+		m.logger.Log("err", err, "msg", "dial failed", "retry_in", backoff)
+		select {
+		case <-m.after(backoff):
+		case <-m.done:
+			return
+		}
+		if backoff = Exponential(backoff); backoff > m.max {
+			backoff = m.max
+		}
+	}
+}
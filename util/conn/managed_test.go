@@ -0,0 +1,121 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type managedConn struct {
+	id     int
+	closed bool
+}
+
+type managedDialer struct {
+	mtx      sync.Mutex
+	failures int // the number of dials left to fail
+	dialed   []*managedConn
+}
+
+func (d *managedDialer) dial() (*managedConn, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.failures > 0 {
+		d.failures--
+		return nil, errors.New("connection refused")
+	}
+	c := &managedConn{id: len(d.dialed)}
+	d.dialed = append(d.dialed, c)
+	return c, nil
+}
+
+func (d *managedDialer) close(c *managedConn) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	c.closed = true
+}
+
+func TestManaged(t *testing.T) {
+	var (
+		tickc  = make(chan time.Time)
+		delays = make(chan time.Duration, 10)
+		after  = func(d time.Duration) <-chan time.Time { delays <- d; return tickc }
+		d      = &managedDialer{failures: 2}
+		m      = NewManaged(d.dial, d.close, ManagedAfter(after), ManagedBackoff(time.Second, 3*time.Second))
+	)
+	defer m.Close()
+
+	if _, ok := m.Take(); ok {
+		t.Fatal("want no connection yet")
+	}
+
+	// Two failed dials, backing off, then a connection.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		tickc <- time.Now()
+		tickc <- time.Now()
+	}()
+	c, err := m.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 0, c.id; want != have {
+		t.Errorf("want connection %d, have %d", want, have)
+	}
+	if want, have := time.Second, <-delays; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if have := <-delays; have < time.Second || have > 3*time.Second {
+		t.Errorf("want a delay within [1s, 3s], have %v", have)
+	}
+
+	// An error invalidates the connection.
+	m.Put(c, errors.New("broken pipe"))
+	c2, err := m.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, c2.id; want != have {
+		t.Errorf("want connection %d, have %d", want, have)
+	}
+	d.mtx.Lock()
+	if !c.closed {
+		t.Error("want the invalidated connection closed")
+	}
+	d.mtx.Unlock()
+
+	// Errors from replaced connections, and nil errors, are ignored.
+	m.Put(c, errors.New("late error"))
+	m.Put(c2, nil)
+	if have, ok := m.Take(); !ok || have != c2 {
+		t.Errorf("want connection %d, have %v", c2.id, have)
+	}
+
+	m.Close()
+	if want, have := ErrManagerClosed, func() error { _, err := m.Wait(ctx); return err }(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	d.mtx.Lock()
+	if !c2.closed {
+		t.Error("want the connection closed with the manager")
+	}
+	d.mtx.Unlock()
+}
+
+func TestManagedWaitTimeout(t *testing.T) {
+	var (
+		after = func(time.Duration) <-chan time.Time { return nil }
+		d     = &managedDialer{failures: 1}
+		m     = NewManaged(d.dial, d.close, ManagedAfter(after))
+	)
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if want, have := context.DeadlineExceeded, func() error { _, err := m.Wait(ctx); return err }(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}